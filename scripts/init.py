@@ -46,7 +46,31 @@ def generate_config_file(
 
     # apiserver.conf
     apiserver_file_template_str = '''
-    '''
+[apitoken]
+service_account_admins =
+[auth]
+backend = authcenter
+admins =
+[authaudit]
+denial = false
+[ratelimit]
+enable = false
+user_qps = 50
+user_burst = 100
+app_qps = 200
+app_burst = 400
+redis = false
+[redis]
+host = $redis_host
+port = $redis_port
+usr = $redis_user
+pwd = $redis_pass
+database = 0
+[trace]
+exporter = none
+file =
+sampleRatio = 1
+'''
 
     template = FileTemplate(apiserver_file_template_str)
     result = template.substitute(**context)
@@ -70,6 +94,12 @@ address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
+backend = authcenter
+admins =
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
     template = FileTemplate(auditcontroller_file_template_str)
     result = template.substitute(**context)
@@ -109,12 +139,27 @@ usr = $redis_user
 pwd = $redis_pass
 database = 0
 
+[plugin-redis]
+host = $redis_host
+port = $redis_port
+usr = $redis_user
+pwd = $redis_pass
+database = 0
+
 [redis]
 host = $redis_host
 port = $redis_port
 usr = $redis_user
 pwd = $redis_pass
 database = 0
+[trace]
+exporter = none
+file =
+sampleRatio = 1
+[netcollect]
+credentialKey =
+[plugin]
+dir =
 '''
 
     template = FileTemplate(datacollection_file_template_str)
@@ -143,6 +188,10 @@ database = 0
 port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
 
     template = FileTemplate(eventserver_file_template_str)
@@ -172,6 +221,12 @@ address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
+backend = authcenter
+admins =
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
     template = FileTemplate(host_file_template_str)
     result = template.substitute(**context)
@@ -199,6 +254,10 @@ database = 0
 port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
 
     template = FileTemplate(hostcontroller_file_template_str)
@@ -242,8 +301,15 @@ address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
+backend = authcenter
+admins =
 enableSync = false
-    '''
+    
+[trace]
+exporter = none
+file =
+sampleRatio = 1
+'''
 
     template = FileTemplate(migrate_file_template_str)
     result = template.substitute(**context)
@@ -271,6 +337,10 @@ database = 0
 port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
 
     template = FileTemplate(objectcontroller_file_template_str)
@@ -299,6 +369,13 @@ database = 0
 port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
+
+[recyclebin]
+retention_days = 30
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
 
     template = FileTemplate(coreservice_file_template_str)
@@ -315,6 +392,15 @@ usr = $redis_user
 pwd = $redis_pass
 port = $redis_port
 database = 0
+[trace]
+exporter = none
+file =
+sampleRatio = 1
+[configfile]
+backend = gse
+localDir =
+[procstate]
+interval = 60
 '''
     template = FileTemplate(proc_file_template_str)
     result = template.substitute(**context)
@@ -342,6 +428,10 @@ database = 0
 port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
 
     template = FileTemplate(proccontroller_file_template_str)
@@ -373,6 +463,10 @@ maxIDleConns = 1000
 [transaction]
 enable = false
 transactionLifetimeSecond = 60
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
 
     template = FileTemplate(txcserver_file_template_str)
@@ -400,6 +494,12 @@ address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
+backend = authcenter
+admins =
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
 
     template = FileTemplate(topo_file_template_str)
@@ -433,6 +533,10 @@ authscheme = $auth_scheme
 
 [app]
 agent_app_url = ${agent_url}/console/?app=bk_agent_setup
+[trace]
+exporter = none
+file =
+sampleRatio = 1
 '''
     template = FileTemplate(webserver_file_template_str)
     result = template.substitute(**context)
@@ -521,7 +625,7 @@ def main(argv):
     ]
     usage = '''
     usage:
-      --discovery          <discovery>            the ZooKeeper server address, eg:127.0.0.1:2181
+      --discovery          <discovery>            the ZooKeeper server address, eg:127.0.0.1:2181, or etcd://127.0.0.1:2379, file:///data/cmdb/regdiscv
       --database           <database>             the database name, default cmdb
       --redis_ip           <redis_ip>             the redis ip, eg:127.0.0.1
       --redis_port         <redis_port>           the redis port, default:6379
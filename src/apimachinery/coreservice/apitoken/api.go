@@ -0,0 +1,76 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apitoken
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/metadata"
+)
+
+func (t *apiToken) CreateAPIToken(ctx context.Context, h http.Header, input *metadata.CreateAPITokenRequest) (resp *metadata.CreateAPITokenResult, err error) {
+	resp = new(metadata.CreateAPITokenResult)
+	subPath := "/create/apitoken"
+
+	err = t.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (t *apiToken) SearchAPITokens(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.SearchAPITokensResult, err error) {
+	resp = new(metadata.SearchAPITokensResult)
+	subPath := "/read/apitoken"
+
+	err = t.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (t *apiToken) RevokeAPITokens(ctx context.Context, h http.Header, input *metadata.RevokeAPITokenRequest) (resp *metadata.UpdatedOptionResult, err error) {
+	resp = new(metadata.UpdatedOptionResult)
+	subPath := "/update/apitoken/revoke"
+
+	err = t.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (t *apiToken) ValidateAPIToken(ctx context.Context, h http.Header, input *metadata.ValidateAPITokenRequest) (resp *metadata.ValidateAPITokenResult, err error) {
+	resp = new(metadata.ValidateAPITokenResult)
+	subPath := "/read/apitoken/validate"
+
+	err = t.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
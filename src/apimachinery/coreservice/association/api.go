@@ -298,3 +298,31 @@ func (asst *association) DeleteInstAssociation(ctx context.Context, h http.Heade
 		Into(resp)
 	return
 }
+
+func (asst *association) PlanInstAssociationDelete(ctx context.Context, h http.Header, input *metadata.InstAsstDeletePlanRequest) (resp *metadata.InstAsstDeletePlanResult, err error) {
+	resp = new(metadata.InstAsstDeletePlanResult)
+	subPath := "/read/instanceassociation/deleteplan"
+
+	err = asst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (asst *association) FindInstAssociationViolations(ctx context.Context, h http.Header, input *metadata.FindAssociationViolationsRequest) (resp *metadata.AssociationViolationsResult, err error) {
+	resp = new(metadata.AssociationViolationsResult)
+	subPath := "/read/instanceassociation/violations"
+
+	err = asst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -42,6 +42,8 @@ type AssociationClientInterface interface {
 	UpdateInstAssociation(ctx context.Context, h http.Header, input *metadata.UpdateOption) (resp *metadata.UpdatedOptionResult, err error)
 	ReadInstAssociation(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.ReadInstAssociationResult, err error)
 	DeleteInstAssociation(ctx context.Context, h http.Header, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
+	PlanInstAssociationDelete(ctx context.Context, h http.Header, input *metadata.InstAsstDeletePlanRequest) (resp *metadata.InstAsstDeletePlanResult, err error)
+	FindInstAssociationViolations(ctx context.Context, h http.Header, input *metadata.FindAssociationViolationsRequest) (resp *metadata.AssociationViolationsResult, err error)
 }
 
 func NewAssociationClientInterface(client rest.ClientInterface) AssociationClientInterface {
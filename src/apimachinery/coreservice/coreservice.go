@@ -15,12 +15,15 @@ package coreservice
 import (
 	"fmt"
 
+	"configcenter/src/apimachinery/coreservice/apitoken"
 	"configcenter/src/apimachinery/coreservice/association"
 	"configcenter/src/apimachinery/coreservice/auditlog"
 	"configcenter/src/apimachinery/coreservice/host"
 	"configcenter/src/apimachinery/coreservice/instance"
+	"configcenter/src/apimachinery/coreservice/localauth"
 	"configcenter/src/apimachinery/coreservice/mainline"
 	"configcenter/src/apimachinery/coreservice/model"
+	"configcenter/src/apimachinery/coreservice/recyclebin"
 	"configcenter/src/apimachinery/coreservice/synchronize"
 	"configcenter/src/apimachinery/rest"
 	"configcenter/src/apimachinery/util"
@@ -34,6 +37,9 @@ type CoreServiceClientInterface interface {
 	Mainline() mainline.MainlineClientInterface
 	Host() host.HostClientInterface
 	Audit() auditlog.AuditClientInterface
+	RecycleBin() recyclebin.RecycleBinClientInterface
+	APIToken() apitoken.APITokenClientInterface
+	LocalAuth() localauth.LocalAuthClientInterface
 }
 
 func NewCoreServiceClient(c *util.Capability, version string) CoreServiceClientInterface {
@@ -74,3 +80,15 @@ func (c *coreService) Host() host.HostClientInterface {
 func (c *coreService) Audit() auditlog.AuditClientInterface {
 	return auditlog.NewAuditClientInterface(c.restCli)
 }
+
+func (c *coreService) RecycleBin() recyclebin.RecycleBinClientInterface {
+	return recyclebin.NewRecycleBinClientInterface(c.restCli)
+}
+
+func (c *coreService) APIToken() apitoken.APITokenClientInterface {
+	return apitoken.NewAPITokenClientInterface(c.restCli)
+}
+
+func (c *coreService) LocalAuth() localauth.LocalAuthClientInterface {
+	return localauth.NewLocalAuthClientInterface(c.restCli)
+}
@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package localauth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common/metadata"
+)
+
+func (l *localAuth) CreateAuthRole(ctx context.Context, h http.Header, input *metadata.AuthRole) (resp *metadata.CreatedOneOptionResult, err error) {
+	resp = new(metadata.CreatedOneOptionResult)
+	subPath := "/create/localauth/role"
+
+	err = l.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (l *localAuth) UpdateAuthRole(ctx context.Context, h http.Header, id int64, input *metadata.AuthRole) (resp *metadata.UpdatedOptionResult, err error) {
+	resp = new(metadata.UpdatedOptionResult)
+	subPath := "/update/localauth/role/" + strconv.FormatInt(id, 10)
+
+	err = l.client.Put().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (l *localAuth) DeleteAuthRole(ctx context.Context, h http.Header, id int64) (resp *metadata.DeletedOptionResult, err error) {
+	resp = new(metadata.DeletedOptionResult)
+	subPath := "/delete/localauth/role/" + strconv.FormatInt(id, 10)
+
+	err = l.client.Delete().
+		WithContext(ctx).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (l *localAuth) SearchAuthRoles(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.SearchAuthRolesResult, err error) {
+	resp = new(metadata.SearchAuthRolesResult)
+	subPath := "/read/localauth/role"
+
+	err = l.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (l *localAuth) CreateAuthRoleBinding(ctx context.Context, h http.Header, input *metadata.AuthRoleBinding) (resp *metadata.CreatedOneOptionResult, err error) {
+	resp = new(metadata.CreatedOneOptionResult)
+	subPath := "/create/localauth/rolebinding"
+
+	err = l.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (l *localAuth) DeleteAuthRoleBinding(ctx context.Context, h http.Header, id int64) (resp *metadata.DeletedOptionResult, err error) {
+	resp = new(metadata.DeletedOptionResult)
+	subPath := "/delete/localauth/rolebinding/" + strconv.FormatInt(id, 10)
+
+	err = l.client.Delete().
+		WithContext(ctx).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (l *localAuth) SearchAuthRoleBindings(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.SearchAuthRoleBindingsResult, err error) {
+	resp = new(metadata.SearchAuthRoleBindingsResult)
+	subPath := "/read/localauth/rolebinding"
+
+	err = l.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (l *localAuth) GetUserAuthPermissions(ctx context.Context, h http.Header, input *metadata.GetUserAuthPermissionsRequest) (resp *metadata.GetUserAuthPermissionsResult, err error) {
+	resp = new(metadata.GetUserAuthPermissionsResult)
+	subPath := "/read/localauth/permission"
+
+	err = l.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
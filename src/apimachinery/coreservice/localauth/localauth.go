@@ -0,0 +1,40 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package localauth
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/metadata"
+)
+
+type LocalAuthClientInterface interface {
+	CreateAuthRole(ctx context.Context, h http.Header, input *metadata.AuthRole) (*metadata.CreatedOneOptionResult, error)
+	UpdateAuthRole(ctx context.Context, h http.Header, id int64, input *metadata.AuthRole) (*metadata.UpdatedOptionResult, error)
+	DeleteAuthRole(ctx context.Context, h http.Header, id int64) (*metadata.DeletedOptionResult, error)
+	SearchAuthRoles(ctx context.Context, h http.Header, input *metadata.QueryCondition) (*metadata.SearchAuthRolesResult, error)
+	CreateAuthRoleBinding(ctx context.Context, h http.Header, input *metadata.AuthRoleBinding) (*metadata.CreatedOneOptionResult, error)
+	DeleteAuthRoleBinding(ctx context.Context, h http.Header, id int64) (*metadata.DeletedOptionResult, error)
+	SearchAuthRoleBindings(ctx context.Context, h http.Header, input *metadata.QueryCondition) (*metadata.SearchAuthRoleBindingsResult, error)
+	GetUserAuthPermissions(ctx context.Context, h http.Header, input *metadata.GetUserAuthPermissionsRequest) (*metadata.GetUserAuthPermissionsResult, error)
+}
+
+func NewLocalAuthClientInterface(client rest.ClientInterface) LocalAuthClientInterface {
+	return &localAuth{client: client}
+}
+
+type localAuth struct {
+	client rest.ClientInterface
+}
@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recyclebin
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/metadata"
+)
+
+func (r *recycleBin) SnapshotRecycleRecords(ctx context.Context, h http.Header, objID string, input *metadata.CreateRecycleRecords) (resp *metadata.SnapshotRecycleRecordsResp, err error) {
+	resp = new(metadata.SnapshotRecycleRecordsResp)
+	subPath := "/read/recyclebin/snapshot/" + objID
+
+	err = r.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (r *recycleBin) CreateRecycleRecords(ctx context.Context, h http.Header, objID string, input *metadata.SaveRecycleRecords) (resp *metadata.CreatedManyOptionResult, err error) {
+	resp = new(metadata.CreatedManyOptionResult)
+	subPath := "/create/recyclebin/" + objID
+
+	err = r.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (r *recycleBin) SearchRecycleRecords(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.SearchRecycleRecordsResult, err error) {
+	resp = new(metadata.SearchRecycleRecordsResult)
+	subPath := "/read/recyclebin"
+
+	err = r.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (r *recycleBin) RestoreRecycleRecords(ctx context.Context, h http.Header, input *metadata.RestoreRecycleRecords) (resp *metadata.RestoreRecycleRecordsResp, err error) {
+	resp = new(metadata.RestoreRecycleRecordsResp)
+	subPath := "/update/recyclebin/restore"
+
+	err = r.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (r *recycleBin) PurgeRecycleRecords(ctx context.Context, h http.Header, input *metadata.PurgeRecycleRecords) (resp *metadata.DeletedOptionResult, err error) {
+	resp = new(metadata.DeletedOptionResult)
+	subPath := "/delete/recyclebin"
+
+	err = r.client.Delete().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -0,0 +1,37 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recyclebin
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/metadata"
+)
+
+type RecycleBinClientInterface interface {
+	SnapshotRecycleRecords(ctx context.Context, h http.Header, objID string, input *metadata.CreateRecycleRecords) (*metadata.SnapshotRecycleRecordsResp, error)
+	CreateRecycleRecords(ctx context.Context, h http.Header, objID string, input *metadata.SaveRecycleRecords) (*metadata.CreatedManyOptionResult, error)
+	SearchRecycleRecords(ctx context.Context, h http.Header, input *metadata.QueryCondition) (*metadata.SearchRecycleRecordsResult, error)
+	RestoreRecycleRecords(ctx context.Context, h http.Header, input *metadata.RestoreRecycleRecords) (*metadata.RestoreRecycleRecordsResp, error)
+	PurgeRecycleRecords(ctx context.Context, h http.Header, input *metadata.PurgeRecycleRecords) (*metadata.DeletedOptionResult, error)
+}
+
+func NewRecycleBinClientInterface(client rest.ClientInterface) RecycleBinClientInterface {
+	return &recycleBin{client: client}
+}
+
+type recycleBin struct {
+	client rest.ClientInterface
+}
@@ -16,7 +16,6 @@ import (
 	"fmt"
 
 	"configcenter/src/common"
-	"configcenter/src/common/backbone/service_mange/zk"
 	"configcenter/src/common/registerdiscover"
 	"configcenter/src/common/types"
 )
@@ -48,9 +47,7 @@ type Interface interface {
 }
 
 // NewServiceDiscovery new a simple discovery module which can be used to get alive server address
-func NewServiceDiscovery(client *zk.ZkClient) (DiscoveryInterface, error) {
-	disc := registerdiscover.NewRegDiscoverEx(client)
-
+func NewServiceDiscovery(disc *registerdiscover.RegDiscover) (DiscoveryInterface, error) {
 	d := &discover{
 		servers: make(map[string]*server),
 	}
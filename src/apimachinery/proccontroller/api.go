@@ -208,6 +208,51 @@ func (p *procctrl) DeleteProcInstanceDetail(ctx context.Context, h http.Header,
 	return
 }
 
+func (p *procctrl) SetProcInstanceState(ctx context.Context, h http.Header, dat []metadata.ProcInstanceState) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/instance/state"
+
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) SearchProcInstanceState(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcInstanceStateResult, err error) {
+	resp = new(metadata.ProcInstanceStateResult)
+	subPath := "/instance/state/search"
+
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) DeleteProcInstanceState(ctx context.Context, h http.Header, dat map[string]interface{}) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/instance/state"
+
+	err = p.client.Delete().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
 func (p *procctrl) AddOperateTaskInfo(ctx context.Context, h http.Header, dat []*metadata.ProcessOperateTask) (resp *metadata.Response, err error) {
 	resp = new(metadata.Response)
 	subPath := "/operate/task"
@@ -251,3 +296,157 @@ func (p *procctrl) SearchOperateTaskInfo(ctx context.Context, h http.Header, dat
 
 	return
 }
+
+func (p *procctrl) AddOperateBatchTask(ctx context.Context, h http.Header, dat *metadata.ProcOpBatchTask) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/operate/batch/task"
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) UpdateOperateBatchTask(ctx context.Context, h http.Header, dat *metadata.ProcOpBatchTask) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/operate/batch/task"
+	err = p.client.Put().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) SearchOperateBatchTask(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcOpBatchTaskResult, err error) {
+	resp = new(metadata.ProcOpBatchTaskResult)
+	subPath := "/operate/batch/task/search"
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) CreateConfigFile(ctx context.Context, h http.Header, dat []metadata.ProcConfigFile) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/config/file"
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) UpdateConfigFile(ctx context.Context, h http.Header, dat *metadata.UpdateParams) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/config/file"
+	err = p.client.Put().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) SearchConfigFile(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcConfigFileResult, err error) {
+	resp = new(metadata.ProcConfigFileResult)
+	subPath := "/config/file/search"
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) DeleteConfigFile(ctx context.Context, h http.Header, dat map[string]interface{}) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/config/file"
+	err = p.client.Delete().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) CreateTemplateVariable(ctx context.Context, h http.Header, dat *metadata.ProcTemplateVariable) (resp *metadata.ProcTemplateVariableCreateResult, err error) {
+	resp = new(metadata.ProcTemplateVariableCreateResult)
+	subPath := "/template/variable"
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) UpdateTemplateVariable(ctx context.Context, h http.Header, dat *metadata.ProcTemplateVariable) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/template/variable"
+	err = p.client.Put().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) SearchTemplateVariable(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcTemplateVariableResult, err error) {
+	resp = new(metadata.ProcTemplateVariableResult)
+	subPath := "/template/variable/search"
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+func (p *procctrl) DeleteTemplateVariable(ctx context.Context, h http.Header, dat map[string]interface{}) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/template/variable"
+	err = p.client.Delete().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
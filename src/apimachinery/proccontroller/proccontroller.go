@@ -36,9 +36,23 @@ type ProcCtrlClientInterface interface {
 	ModifyProcInstanceDetail(ctx context.Context, h http.Header, dat *metadata.ModifyProcInstanceDetail) (resp *metadata.Response, err error)
 	GetProcInstanceDetail(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcInstanceDetailResult, err error)
 	DeleteProcInstanceDetail(ctx context.Context, h http.Header, dat map[string]interface{}) (resp *metadata.Response, err error)
+	SetProcInstanceState(ctx context.Context, h http.Header, dat []metadata.ProcInstanceState) (resp *metadata.Response, err error)
+	SearchProcInstanceState(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcInstanceStateResult, err error)
+	DeleteProcInstanceState(ctx context.Context, h http.Header, dat map[string]interface{}) (resp *metadata.Response, err error)
 	AddOperateTaskInfo(ctx context.Context, h http.Header, dat []*metadata.ProcessOperateTask) (resp *metadata.Response, err error)
 	UpdateOperateTaskInfo(ctx context.Context, h http.Header, dat *metadata.UpdateParams) (resp *metadata.Response, err error)
 	SearchOperateTaskInfo(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcessOperateTaskResult, err error)
+	AddOperateBatchTask(ctx context.Context, h http.Header, dat *metadata.ProcOpBatchTask) (resp *metadata.Response, err error)
+	UpdateOperateBatchTask(ctx context.Context, h http.Header, dat *metadata.ProcOpBatchTask) (resp *metadata.Response, err error)
+	SearchOperateBatchTask(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcOpBatchTaskResult, err error)
+	CreateConfigFile(ctx context.Context, h http.Header, dat []metadata.ProcConfigFile) (resp *metadata.Response, err error)
+	UpdateConfigFile(ctx context.Context, h http.Header, dat *metadata.UpdateParams) (resp *metadata.Response, err error)
+	SearchConfigFile(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcConfigFileResult, err error)
+	DeleteConfigFile(ctx context.Context, h http.Header, dat map[string]interface{}) (resp *metadata.Response, err error)
+	CreateTemplateVariable(ctx context.Context, h http.Header, dat *metadata.ProcTemplateVariable) (resp *metadata.ProcTemplateVariableCreateResult, err error)
+	UpdateTemplateVariable(ctx context.Context, h http.Header, dat *metadata.ProcTemplateVariable) (resp *metadata.Response, err error)
+	SearchTemplateVariable(ctx context.Context, h http.Header, dat *metadata.QueryInput) (resp *metadata.ProcTemplateVariableResult, err error)
+	DeleteTemplateVariable(ctx context.Context, h http.Header, dat map[string]interface{}) (resp *metadata.Response, err error)
 }
 
 func NewProcCtrlClientInterface(c *util.Capability, version string) ProcCtrlClientInterface {
@@ -36,6 +36,8 @@ type ProcessClientInterface interface {
 	UpdateConfigTemp(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.Response, err error)
 	DeleteConfigTemp(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.Response, err error)
 	QueryConfigTemp(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.Response, err error)
+	CheckHostPortConflict(ctx context.Context, ownerID string, businessID string, h http.Header, dat *metadata.ProcPortCheckParam) (resp *metadata.ProcPortConflictResult, err error)
+	GetBizPortConflicts(ctx context.Context, ownerID string, businessID string, h http.Header) (resp *metadata.ProcPortConflictResult, err error)
 }
 
 func NewProcessClientInterface(client rest.ClientInterface) ProcessClientInterface {
@@ -229,3 +229,31 @@ func (p *process) QueryConfigTemp(ctx context.Context, h http.Header, dat interf
         Into(resp)
     return
 }
+
+func (p *process) CheckHostPortConflict(ctx context.Context, ownerID string, businessID string, h http.Header, dat *metadata.ProcPortCheckParam) (resp *metadata.ProcPortConflictResult, err error) {
+	resp = new(metadata.ProcPortConflictResult)
+	subPath := fmt.Sprintf("/port/conflict/check/%s/%s", ownerID, businessID)
+
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (p *process) GetBizPortConflicts(ctx context.Context, ownerID string, businessID string, h http.Header) (resp *metadata.ProcPortConflictResult, err error) {
+	resp = new(metadata.ProcPortConflictResult)
+	subPath := fmt.Sprintf("/port/conflict/%s/%s", ownerID, businessID)
+
+	err = p.client.Get().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
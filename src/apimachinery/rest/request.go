@@ -32,6 +32,7 @@ import (
 
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/trace"
 	commonUtil "configcenter/src/common/util"
 )
 
@@ -200,6 +201,35 @@ func (r *Request) WrapURL() *url.URL {
 }
 
 func (r *Request) Do() *Result {
+	span := r.startSpan()
+	result := r.do(span)
+	if result.StatusCode != 0 {
+		span.SetAttribute("http.status_code", result.StatusCode)
+	}
+	if result.Err == nil && result.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(trace.StatusError, result.Status)
+	}
+	span.Finish(result.Err)
+	return result
+}
+
+// startSpan start a client span of the request, whose parent is the span in the context, or the
+// span in the traceparent header forwarded from the incoming request.
+func (r *Request) startSpan() *trace.Span {
+	ctx := r.ctx
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx = trace.ContextWithHeader(ctx, r.headers)
+	}
+	_, span := trace.StartSpan(ctx, "HTTP "+string(r.verb), trace.KindClient)
+	span.SetAttribute("http.method", string(r.verb))
+	span.SetAttribute("http.target", "/"+r.subPath)
+	return span
+}
+
+func (r *Request) do(span *trace.Span) *Result {
 	rid := commonUtil.ExtractRequestIDFromContext(r.ctx)
 	if rid == "" {
 		rid = commonUtil.GetHTTPCCRequestID(r.headers)
@@ -226,6 +256,14 @@ func (r *Request) Do() *Result {
 		return result
 	}
 
+	// the header is copied, as the traceparent of the request should not change the header of
+	// the caller, which may be used for the other requests.
+	header := make(http.Header)
+	for key, values := range r.headers {
+		header[key] = values
+	}
+	header.Set(trace.TraceparentHeader, trace.FormatTraceparent(span.SpanContext()))
+
 	maxRetryCycle := 3
 	var retries int
 	for try := 0; try < maxRetryCycle; try++ {
@@ -242,10 +280,7 @@ func (r *Request) Do() *Result {
 				req.WithContext(r.ctx)
 			}
 
-			req.Header = r.headers
-			if len(req.Header) == 0 {
-				req.Header = make(http.Header)
-			}
+			req.Header = header
 			req.Header.Set("Content-Type", "application/json")
 			req.Header.Set("Accept", "application/json")
 
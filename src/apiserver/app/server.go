@@ -21,16 +21,18 @@ import (
 
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/apiserver/app/options"
+	"configcenter/src/apiserver/ratelimit"
 	"configcenter/src/apiserver/service"
-	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/types"
 	"configcenter/src/common/version"
+	dalredis "configcenter/src/storage/dal/redis"
 
 	"github.com/emicklei/go-restful"
+	redis "gopkg.in/redis.v5"
 )
 
 // Run main loop function
@@ -69,18 +71,28 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		return err
 	}
 
-	authorize, err := auth.NewAuthorize(nil, authConf)
+	authorize, err := engine.NewAuthorize(nil, authConf)
 	if err != nil {
 		return fmt.Errorf("new authorize failed, err: %v", err)
 	}
 
-	blog.Infof("enable authcenter: %v", authConf.Enable)
+	rateLimitConf := ratelimit.ParseConfigFromKV("ratelimit", apiSvr.Config)
+	var cache *redis.Client
+	if rateLimitConf.Enable && rateLimitConf.Redis {
+		cache, err = dalredis.NewFromConfig(dalredis.ParseConfigFromKV("redis", apiSvr.Config))
+		if err != nil {
+			return fmt.Errorf("new redis client for rate limiting failed, err: %v", err)
+		}
+	}
+	blog.Infof("enable rate limiting: %v, share with redis: %v", rateLimitConf.Enable, rateLimitConf.Redis)
 
-	svc.SetConfig(authConf.Enable, engine, client, engine.Discovery(), authorize)
+	svc.SetConfig(authConf.Enable, engine, client, engine.Discovery(), authorize, service.ParseAPITokenConfig(apiSvr.Config),
+		service.ParseAuthAuditConfig(apiSvr.Config), ratelimit.New(rateLimitConf, cache))
 
 	ctnr := restful.NewContainer()
 	ctnr.Router(restful.CurlyRouter{})
 	ctnr.Router(restful.CurlyRouter{})
+	ctnr.Filter(svc.RateLimitFilter)
 	for _, item := range svc.WebServices(authConf) {
 		ctnr.Add(item)
 	}
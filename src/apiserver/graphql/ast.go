@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+// Document is a parsed graphql request document, only the query operations are supported.
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query operation of the document
+type Operation struct {
+	Type         string
+	Name         string
+	Variables    []*VariableDefinition
+	SelectionSet []Selection
+}
+
+// VariableDefinition is a variable declared by an operation
+type VariableDefinition struct {
+	Name         string
+	Type         string
+	NonNull      bool
+	DefaultValue interface{}
+}
+
+// Fragment is a named fragment which can be spread in the selection sets
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	SelectionSet  []Selection
+}
+
+// Selection is one of *Field, *FragmentSpread and *InlineFragment
+type Selection interface {
+	directives() []*Directive
+}
+
+// Field is a field selected in a selection set
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    map[string]interface{}
+	Directives   []*Directive
+	SelectionSet []Selection
+}
+
+// ResponseKey the key of the field in the response
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+func (f *Field) directives() []*Directive {
+	return f.Directives
+}
+
+// FragmentSpread spreads a named fragment
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+}
+
+func (f *FragmentSpread) directives() []*Directive {
+	return f.Directives
+}
+
+// InlineFragment is an anonymous fragment, the type condition is optional
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+func (f *InlineFragment) directives() []*Directive {
+	return f.Directives
+}
+
+// Directive is a directive of a selection, only @skip and @include are supported
+type Directive struct {
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// Variable is a reference to a variable in an argument value
+type Variable struct {
+	Name string
+}
+
+// EnumValue is an enum literal in an argument value
+type EnumValue string
@@ -0,0 +1,732 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+const (
+	queryTypeName = "Query"
+	typeNameField = "__typename"
+
+	// MaxDepth the max depth of the nested relation fields
+	MaxDepth = 10
+	// MaxLimit the max number of instances a root field can return
+	MaxLimit = 1000
+	// MaxRelatedInstances the max number of instances a relation field can find on one level
+	MaxRelatedInstances = 10000
+)
+
+// conditionOperators the operators can be used in the condition arguments
+var conditionOperators = []string{common.BKDBIN, common.BKDBNIN, common.BKDBEQ, common.BKDBNE, common.BKDBLT,
+	common.BKDBLTE, common.BKDBGT, common.BKDBGTE, common.BKDBLIKE, common.BKDBExists}
+
+// Resolver reads the instances and their relations, the executor calls it once for a field on
+// each level of the query, no matter how many instances the field is resolved for.
+type Resolver interface {
+	// FindInstances find the instances of the model, the instances the user is not authorized
+	// to find should be filtered out.
+	FindInstances(ctx context.Context, objID string, query *metadata.QueryCondition) ([]mapstr.MapStr, error)
+	// FindAssociatedInstanceIDs find the ids of the instances associated with the instances by the
+	// association, the result maps each of the instance ids to the ids associated with it. the
+	// instances are the destination of the association when reverse is true.
+	FindAssociatedInstanceIDs(ctx context.Context, asstID string, reverse bool, instIDs []int64) (map[int64][]int64, error)
+	// FindHostModuleRelations find the relations of either the hosts or the modules
+	FindHostModuleRelations(ctx context.Context, hostIDs, moduleIDs []int64) ([]metadata.ModuleHost, error)
+}
+
+// Request is the body of a graphql request
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Error is an error occurred when parsing or executing the request
+type Error struct {
+	Message string   `json:"message"`
+	Path    []string `json:"path,omitempty"`
+}
+
+// Response is the result of a graphql request
+type Response struct {
+	Data   *OrderedMap `json:"data"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// OrderedMap is a json object which keeps the order of the fields as they are selected
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewOrderedMap create an empty ordered map
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{keys: make([]string, 0), values: make(map[string]interface{})}
+}
+
+// Set set the value of the key, a new key is appended to the end
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if _, exist := m.values[key]; !exist {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get get the value of the key
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	value, exist := m.values[key]
+	return value, exist
+}
+
+// Keys get the keys in order
+func (m *OrderedMap) Keys() []string {
+	return m.keys
+}
+
+// MarshalJSON encode the map with the keys in order
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type executor struct {
+	ctx       context.Context
+	schema    *Schema
+	resolver  Resolver
+	doc       *Document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+// Execute execute the query of the request on the schema, the errors of the fields are returned
+// in the response along with the data of the other fields.
+func Execute(ctx context.Context, schema *Schema, resolver Resolver, request *Request) *Response {
+	doc, err := Parse(request.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	var operation *Operation
+	for _, op := range doc.Operations {
+		if request.OperationName == "" || op.Name == request.OperationName {
+			if operation != nil {
+				return &Response{Errors: []*Error{{Message: "operationName is required when the document has several operations"}}}
+			}
+			operation = op
+		}
+	}
+	if operation == nil {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("operation %s not found", request.OperationName)}}}
+	}
+	if operation.Type != "query" {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("%s operation is not supported", operation.Type)}}}
+	}
+
+	e := &executor{
+		ctx:       ctx,
+		schema:    schema,
+		resolver:  resolver,
+		doc:       doc,
+		variables: make(map[string]interface{}),
+	}
+	for _, def := range operation.Variables {
+		value, exist := request.Variables[def.Name]
+		if !exist {
+			value = def.DefaultValue
+		}
+		if value == nil && def.NonNull {
+			return &Response{Errors: []*Error{{Message: fmt.Sprintf("variable $%s of type %s! is required", def.Name, def.Type)}}}
+		}
+		e.variables[def.Name] = value
+	}
+
+	fields, err := e.collectFields(queryTypeName, operation.SelectionSet, make(map[string]bool))
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	data := NewOrderedMap()
+	for _, field := range fields {
+		data.Set(field.ResponseKey(), e.executeRootField(field))
+	}
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func (e *executor) addError(path []string, format string, args ...interface{}) {
+	e.errors = append(e.errors, &Error{Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+func (e *executor) executeRootField(field *Field) interface{} {
+	path := []string{field.ResponseKey()}
+	switch field.Name {
+	case typeNameField:
+		return queryTypeName
+	case "__schema":
+		return e.project(introspectSchema(e.schema), field, path)
+	case "__type":
+		args, err := e.arguments(field.Arguments)
+		if err != nil {
+			e.addError(path, "%v", err)
+			return nil
+		}
+		name, _ := args["name"].(string)
+		typ := introspectType(e.schema, name)
+		if typ == nil {
+			return nil
+		}
+		return e.project(typ, field, path)
+	}
+
+	typ := e.schema.Type(field.Name)
+	if typ == nil {
+		e.addError(path, "cannot query field %s on type %s", field.Name, queryTypeName)
+		return nil
+	}
+	if len(field.SelectionSet) == 0 {
+		e.addError(path, "field %s of type [%s] must have a selection of subfields", field.Name, typ.Name)
+		return nil
+	}
+
+	args, err := e.arguments(field.Arguments)
+	if err != nil {
+		e.addError(path, "%v", err)
+		return nil
+	}
+	query := &metadata.QueryCondition{
+		Limit: metadata.SearchLimit{Limit: common.BKDefaultLimit},
+	}
+	for name, value := range args {
+		switch name {
+		case "condition":
+			if query.Condition, err = e.condition(typ, value); err != nil {
+				e.addError(path, "%v", err)
+				return nil
+			}
+		case "start":
+			start, err := util.GetInt64ByInterface(value)
+			if err != nil || start < 0 {
+				e.addError(path, "invalid argument start: %v", value)
+				return nil
+			}
+			query.Limit.Offset = start
+		case "limit":
+			limit, err := util.GetInt64ByInterface(value)
+			if err != nil || limit <= 0 || limit > MaxLimit {
+				e.addError(path, "invalid argument limit: %v, it should be in (0, %d]", value, MaxLimit)
+				return nil
+			}
+			query.Limit.Limit = limit
+		case "sort":
+			sort, ok := value.(string)
+			if !ok {
+				e.addError(path, "invalid argument sort: %v", value)
+				return nil
+			}
+			query.SortArr = metadata.NewSearchSortParse().String(sort).ToSearchSortArr()
+		default:
+			e.addError(path, "unknown argument %s on field %s", name, field.Name)
+			return nil
+		}
+	}
+	if query.Condition == nil {
+		query.Condition = mapstr.New()
+	}
+
+	fields, err := e.collectFields(typ.Name, field.SelectionSet, make(map[string]bool))
+	if err != nil {
+		e.addError(path, "%v", err)
+		return nil
+	}
+	query.Fields = queryFields(typ, fields)
+	instances, err := e.resolver.FindInstances(e.ctx, typ.ObjectID, query)
+	if err != nil {
+		e.addError(path, "%v", err)
+		return nil
+	}
+
+	objects := e.resolveObjects(typ, instances, fields, path, 1)
+	result := make([]interface{}, len(objects))
+	for i := range objects {
+		result[i] = objects[i]
+	}
+	return result
+}
+
+// resolveObjects resolve the fields of the instances of a type, each relation field is resolved
+// for all the instances at once.
+func (e *executor) resolveObjects(typ *ObjectType, instances []mapstr.MapStr, fields []*Field, path []string, depth int) []*OrderedMap {
+	objects := make([]*OrderedMap, len(instances))
+	for i := range objects {
+		objects[i] = NewOrderedMap()
+	}
+
+	for _, field := range fields {
+		key := field.ResponseKey()
+		fieldPath := appendPath(path, key)
+		if field.Name == typeNameField {
+			for _, object := range objects {
+				object.Set(key, typ.Name)
+			}
+			continue
+		}
+
+		def := typ.Field(field.Name)
+		if def == nil {
+			e.addError(fieldPath, "cannot query field %s on type %s", field.Name, typ.Name)
+			for _, object := range objects {
+				object.Set(key, nil)
+			}
+			continue
+		}
+
+		if def.Relation == nil {
+			if len(field.SelectionSet) > 0 {
+				e.addError(fieldPath, "field %s of type %s must not have a selection", field.Name, def.Type)
+			}
+			for i, object := range objects {
+				object.Set(key, instances[i][def.PropertyID])
+			}
+			continue
+		}
+
+		values := e.resolveRelation(typ, def, instances, field, fieldPath, depth+1)
+		for i, object := range objects {
+			object.Set(key, values[i])
+		}
+	}
+	return objects
+}
+
+// resolveRelation resolve a relation field of the instances, the values are returned in the
+// order of the instances.
+func (e *executor) resolveRelation(typ *ObjectType, def *ObjectField, instances []mapstr.MapStr, field *Field, path []string, depth int) []interface{} {
+	values := make([]interface{}, len(instances))
+	if len(instances) == 0 {
+		return values
+	}
+	if depth > MaxDepth {
+		e.addError(path, "the query exceeds the max depth %d", MaxDepth)
+		return values
+	}
+	target := e.schema.TypeOfObject(def.Relation.ObjectID)
+	if len(field.SelectionSet) == 0 {
+		e.addError(path, "field %s of type [%s] must have a selection of subfields", field.Name, target.Name)
+		return values
+	}
+	args, err := e.arguments(field.Arguments)
+	if err != nil {
+		e.addError(path, "%v", err)
+		return values
+	}
+	cond := mapstr.New()
+	for name, value := range args {
+		if name != "condition" {
+			e.addError(path, "unknown argument %s on field %s", name, field.Name)
+			return values
+		}
+		if cond, err = e.condition(target, value); err != nil {
+			e.addError(path, "%v", err)
+			return values
+		}
+	}
+	fields, err := e.collectFields(target.Name, field.SelectionSet, make(map[string]bool))
+	if err != nil {
+		e.addError(path, "%v", err)
+		return values
+	}
+
+	ids := instanceIDs(typ, instances)
+	// links maps the id of each instance to the ids of the related instances
+	var links map[int64][]int64
+	var related []mapstr.MapStr
+	switch {
+	case def.Relation.Kind == RelationAssociation:
+		links, err = e.resolver.FindAssociatedInstanceIDs(e.ctx, def.Relation.AssociationID, def.Relation.Reverse, ids)
+		if err == nil {
+			related, err = e.findInstancesByID(target, cond, fields, links)
+		}
+
+	case typ.ObjectID == common.BKInnerObjIDHost || target.ObjectID == common.BKInnerObjIDHost:
+		links, err = e.findHostModuleLinks(typ, ids)
+		if err == nil {
+			related, err = e.findInstancesByID(target, cond, fields, links)
+		}
+
+	case def.Relation.Kind == RelationMainlineParent:
+		links = make(map[int64][]int64)
+		for i, inst := range instances {
+			if parentID, err := util.GetInt64ByInterface(inst[common.BKInstParentStr]); err == nil {
+				links[ids[i]] = []int64{parentID}
+			}
+		}
+		related, err = e.findInstancesByID(target, cond, fields, links)
+
+	default:
+		if _, exist := cond[common.BKInstParentStr]; exist {
+			err = fmt.Errorf("condition on %s is not allowed", common.BKInstParentStr)
+			break
+		}
+		cond[common.BKInstParentStr] = mapstr.MapStr{common.BKDBIN: ids}
+		related, err = e.findInstances(target, cond, fields)
+		links = make(map[int64][]int64)
+		for _, inst := range related {
+			parentID, parentErr := util.GetInt64ByInterface(inst[common.BKInstParentStr])
+			childID, childErr := util.GetInt64ByInterface(inst[target.IDField])
+			if parentErr == nil && childErr == nil {
+				links[parentID] = append(links[parentID], childID)
+			}
+		}
+	}
+	if err != nil {
+		e.addError(path, "%v", err)
+		return values
+	}
+
+	objects := e.resolveObjects(target, related, fields, path, depth)
+	objectMap := make(map[int64]*OrderedMap)
+	for i, inst := range related {
+		if id, err := util.GetInt64ByInterface(inst[target.IDField]); err == nil {
+			objectMap[id] = objects[i]
+		}
+	}
+	for i, id := range ids {
+		list := make([]interface{}, 0)
+		for _, relatedID := range links[id] {
+			// the related instances which are filtered out by the condition or the authorization are ignored
+			if object, exist := objectMap[relatedID]; exist {
+				list = append(list, object)
+			}
+		}
+		if def.List {
+			values[i] = list
+		} else if len(list) > 0 {
+			values[i] = list[0]
+		}
+	}
+	return values
+}
+
+func (e *executor) findHostModuleLinks(typ *ObjectType, ids []int64) (map[int64][]int64, error) {
+	links := make(map[int64][]int64)
+	if len(ids) == 0 {
+		return links, nil
+	}
+	var relations []metadata.ModuleHost
+	var err error
+	if typ.ObjectID == common.BKInnerObjIDHost {
+		relations, err = e.resolver.FindHostModuleRelations(e.ctx, ids, nil)
+	} else {
+		relations, err = e.resolver.FindHostModuleRelations(e.ctx, nil, ids)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		if typ.ObjectID == common.BKInnerObjIDHost {
+			links[relation.HostID] = append(links[relation.HostID], relation.ModuleID)
+		} else {
+			links[relation.ModuleID] = append(links[relation.ModuleID], relation.HostID)
+		}
+	}
+	return links, nil
+}
+
+// findInstancesByID find the instances the links point to
+func (e *executor) findInstancesByID(typ *ObjectType, cond mapstr.MapStr, fields []*Field, links map[int64][]int64) ([]mapstr.MapStr, error) {
+	ids := make([]int64, 0)
+	for _, linked := range links {
+		for _, id := range linked {
+			if !util.ContainsInt64(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return make([]mapstr.MapStr, 0), nil
+	}
+	if _, exist := cond[typ.IDField]; exist {
+		return nil, fmt.Errorf("condition on %s is not allowed", typ.IDField)
+	}
+	cond[typ.IDField] = mapstr.MapStr{common.BKDBIN: ids}
+	return e.findInstances(typ, cond, fields)
+}
+
+func (e *executor) findInstances(typ *ObjectType, cond mapstr.MapStr, fields []*Field) ([]mapstr.MapStr, error) {
+	query := &metadata.QueryCondition{
+		Fields:    queryFields(typ, fields),
+		Condition: cond,
+		Limit:     metadata.SearchLimit{Limit: MaxRelatedInstances + 1},
+	}
+	instances, err := e.resolver.FindInstances(e.ctx, typ.ObjectID, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) > MaxRelatedInstances {
+		return nil, fmt.Errorf("too many %s instances, at most %d instances can be found on one level", typ.Name, MaxRelatedInstances)
+	}
+	return instances, nil
+}
+
+// collectFields collect the fields of the selection set on the type, the fragments are expanded
+// and the fields of the same response key are merged.
+func (e *executor) collectFields(typeName string, selections []Selection, visited map[string]bool) ([]*Field, error) {
+	fields := make([]*Field, 0)
+	fieldMap := make(map[string]*Field)
+	var collect func(selections []Selection) error
+	collect = func(selections []Selection) error {
+		for _, selection := range selections {
+			include, err := e.shouldInclude(selection.directives())
+			if err != nil {
+				return err
+			}
+			if !include {
+				continue
+			}
+
+			switch s := selection.(type) {
+			case *Field:
+				key := s.ResponseKey()
+				exist, ok := fieldMap[key]
+				if !ok {
+					merged := *s
+					fieldMap[key] = &merged
+					fields = append(fields, &merged)
+					continue
+				}
+				if exist.Name != s.Name {
+					return fmt.Errorf("fields %s and %s conflict because they have the same response key %s", exist.Name, s.Name, key)
+				}
+				exist.SelectionSet = append(append([]Selection{}, exist.SelectionSet...), s.SelectionSet...)
+
+			case *FragmentSpread:
+				if visited[s.Name] {
+					return fmt.Errorf("fragment %s is spread in a cycle", s.Name)
+				}
+				fragment, exist := e.doc.Fragments[s.Name]
+				if !exist {
+					return fmt.Errorf("unknown fragment %s", s.Name)
+				}
+				if typeName != "" && fragment.TypeCondition != typeName {
+					continue
+				}
+				visited[s.Name] = true
+				err := collect(fragment.SelectionSet)
+				delete(visited, s.Name)
+				if err != nil {
+					return err
+				}
+
+			case *InlineFragment:
+				if typeName != "" && s.TypeCondition != "" && s.TypeCondition != typeName {
+					continue
+				}
+				if err := collect(s.SelectionSet); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := collect(selections); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (e *executor) shouldInclude(directives []*Directive) (bool, error) {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			return false, fmt.Errorf("unknown directive @%s", directive.Name)
+		}
+		value, err := e.value(directive.Arguments["if"])
+		if err != nil {
+			return false, err
+		}
+		condition, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("argument if of directive @%s must be a boolean", directive.Name)
+		}
+		if (directive.Name == "skip") == condition {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (e *executor) arguments(arguments map[string]interface{}) (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	for name, arg := range arguments {
+		value, err := e.value(arg)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			args[name] = value
+		}
+	}
+	return args, nil
+}
+
+// value replace the variables in the argument value with their values
+func (e *executor) value(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case *Variable:
+		variable, exist := e.variables[v.Name]
+		if !exist {
+			return nil, fmt.Errorf("variable $%s is not defined", v.Name)
+		}
+		return variable, nil
+	case EnumValue:
+		return string(v), nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i := range v {
+			item, err := e.value(v[i])
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	case map[string]interface{}:
+		object := make(map[string]interface{})
+		for key := range v {
+			item, err := e.value(v[key])
+			if err != nil {
+				return nil, err
+			}
+			object[key] = item
+		}
+		return object, nil
+	}
+	return value, nil
+}
+
+// condition convert the condition argument to the query condition, only the attributes of the
+// type can be used as the keys, the values are the same as the condition of the instance search.
+func (e *executor) condition(typ *ObjectType, value interface{}) (mapstr.MapStr, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("argument condition must be an object")
+	}
+	cond := mapstr.New()
+	for key, item := range object {
+		field := typ.Field(key)
+		if field == nil || field.Relation != nil {
+			return nil, fmt.Errorf("invalid condition key %s, it's not an attribute of %s", key, typ.Name)
+		}
+		operators, ok := item.(map[string]interface{})
+		if !ok {
+			cond[field.PropertyID] = item
+			continue
+		}
+		// the names of graphql can't start with $, so the operators can be written without it.
+		ops := mapstr.New()
+		for op, operand := range operators {
+			if !strings.HasPrefix(op, "$") {
+				op = "$" + op
+			}
+			if !util.InStrArr(conditionOperators, op) {
+				return nil, fmt.Errorf("unsupported operator %s of condition key %s", op, key)
+			}
+			ops[op] = operand
+		}
+		cond[field.PropertyID] = ops
+	}
+	return cond, nil
+}
+
+// project select the fields from the plain data, it's used by the introspection.
+func (e *executor) project(value interface{}, field *Field, path []string) interface{} {
+	if len(field.SelectionSet) == 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i := range v {
+			list[i] = e.project(v[i], field, path)
+		}
+		return list
+	case map[string]interface{}:
+		fields, err := e.collectFields("", field.SelectionSet, make(map[string]bool))
+		if err != nil {
+			e.addError(path, "%v", err)
+			return nil
+		}
+		object := NewOrderedMap()
+		for _, f := range fields {
+			item, exist := v[f.Name]
+			if !exist {
+				e.addError(appendPath(path, f.ResponseKey()), "cannot query field %s on type %v", f.Name, v[typeNameField])
+			}
+			object.Set(f.ResponseKey(), e.project(item, f, appendPath(path, f.ResponseKey())))
+		}
+		return object
+	}
+	return value
+}
+
+// queryFields the attributes to read for the selected fields, the id, the parent and the business
+// of the instances are always read for resolving the relations and authorizing.
+func queryFields(typ *ObjectType, fields []*Field) []string {
+	properties := []string{typ.IDField, common.BKInstParentStr, common.BKAppIDField}
+	for _, field := range fields {
+		def := typ.Field(field.Name)
+		if def == nil || def.Relation != nil || util.InStrArr(properties, def.PropertyID) {
+			continue
+		}
+		properties = append(properties, def.PropertyID)
+	}
+	return properties
+}
+
+func instanceIDs(typ *ObjectType, instances []mapstr.MapStr) []int64 {
+	ids := make([]int64, len(instances))
+	for i, inst := range instances {
+		// an invalid id is left as 0, which is never a valid instance id
+		ids[i], _ = util.GetInt64ByInterface(inst[typ.IDField])
+	}
+	return ids
+}
+
+func appendPath(path []string, key string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), key)
+}
@@ -0,0 +1,262 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+type fakeResolver struct {
+	instances map[string][]mapstr.MapStr
+	asst      []metadata.InstAsst
+	relations []metadata.ModuleHost
+	calls     int
+}
+
+func (r *fakeResolver) FindInstances(ctx context.Context, objID string, query *metadata.QueryCondition) ([]mapstr.MapStr, error) {
+	r.calls++
+	result := make([]mapstr.MapStr, 0)
+	for _, inst := range r.instances[objID] {
+		matched := true
+		for key, value := range query.Condition {
+			if in, ok := value.(mapstr.MapStr); ok {
+				id, _ := util.GetInt64ByInterface(inst[key])
+				matched = matched && util.ContainsInt64(in[common.BKDBIN].([]int64), id)
+				continue
+			}
+			matched = matched && inst[key] == value
+		}
+		if matched {
+			result = append(result, inst)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeResolver) FindAssociatedInstanceIDs(ctx context.Context, asstID string, reverse bool, instIDs []int64) (map[int64][]int64, error) {
+	r.calls++
+	links := make(map[int64][]int64)
+	for _, asst := range r.asst {
+		if asst.ObjectAsstID != asstID {
+			continue
+		}
+		if reverse && util.ContainsInt64(instIDs, asst.AsstInstID) {
+			links[asst.AsstInstID] = append(links[asst.AsstInstID], asst.InstID)
+		}
+		if !reverse && util.ContainsInt64(instIDs, asst.InstID) {
+			links[asst.InstID] = append(links[asst.InstID], asst.AsstInstID)
+		}
+	}
+	return links, nil
+}
+
+func (r *fakeResolver) FindHostModuleRelations(ctx context.Context, hostIDs, moduleIDs []int64) ([]metadata.ModuleHost, error) {
+	r.calls++
+	relations := make([]metadata.ModuleHost, 0)
+	for _, relation := range r.relations {
+		if util.ContainsInt64(hostIDs, relation.HostID) || util.ContainsInt64(moduleIDs, relation.ModuleID) {
+			relations = append(relations, relation)
+		}
+	}
+	return relations, nil
+}
+
+func newTestSchema() *Schema {
+	model := func(objID string, attrs ...metadata.Attribute) metadata.SearchModelInfo {
+		return metadata.SearchModelInfo{Spec: metadata.Object{ObjectID: objID, ObjectName: objID}, Attributes: attrs}
+	}
+	attr := func(id, typ string) metadata.Attribute {
+		return metadata.Attribute{PropertyID: id, PropertyType: typ}
+	}
+	models := []metadata.SearchModelInfo{
+		model(common.BKInnerObjIDApp, attr(common.BKAppNameField, common.FieldTypeSingleChar)),
+		model(common.BKInnerObjIDSet, attr(common.BKSetNameField, common.FieldTypeSingleChar)),
+		model(common.BKInnerObjIDModule, attr(common.BKModuleNameField, common.FieldTypeSingleChar)),
+		model(common.BKInnerObjIDHost, attr(common.BKHostInnerIPField, common.FieldTypeSingleChar), attr("bk_cpu", common.FieldTypeInt)),
+		model("bk_switch", attr(common.BKInstNameField, common.FieldTypeSingleChar)),
+	}
+	mainline := func(child, parent string) metadata.Association {
+		return metadata.Association{ObjectID: child, AsstObjID: parent, AsstKindID: common.AssociationKindMainline}
+	}
+	associations := []metadata.Association{
+		mainline(common.BKInnerObjIDSet, common.BKInnerObjIDApp),
+		mainline(common.BKInnerObjIDModule, common.BKInnerObjIDSet),
+		mainline(common.BKInnerObjIDHost, common.BKInnerObjIDModule),
+		{AssociationName: "bk_switch_connect_host", ObjectID: "bk_switch", AsstObjID: common.BKInnerObjIDHost, AsstKindID: "connect"},
+	}
+	return NewSchema(models, associations)
+}
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		# the topology of a business
+		query topo($name: String = "demo", $ids: [Int!]) {
+			biz: biz(condition: {bk_biz_name: $name}, limit: 10) { ...bizFields }
+			host(condition: {bk_host_id: {in: $ids}}) { bk_host_innerip @include(if: true) ... on host { bk_cpu } }
+		}
+		fragment bizFields on biz { bk_biz_id bk_biz_name }
+	`)
+	if err != nil {
+		t.Fatalf("parse failed, err: %v", err)
+	}
+	if len(doc.Operations) != 1 || doc.Operations[0].Name != "topo" || len(doc.Operations[0].Variables) != 2 {
+		t.Fatalf("unexpected operations: %+v", doc.Operations)
+	}
+	if doc.Operations[0].Variables[0].DefaultValue != "demo" || doc.Operations[0].Variables[1].Type != "[Int!]" {
+		t.Errorf("unexpected variables: %+v, %+v", doc.Operations[0].Variables[0], doc.Operations[0].Variables[1])
+	}
+	biz := doc.Operations[0].SelectionSet[0].(*Field)
+	if biz.Alias != "biz" || biz.Arguments["limit"] != int64(10) {
+		t.Errorf("unexpected field: %+v", biz)
+	}
+	if _, ok := doc.Fragments["bizFields"]; !ok {
+		t.Errorf("fragment bizFields is not parsed")
+	}
+
+	for _, query := range []string{`{ biz { bk_biz_id }`, `query { }`, `{ biz(limit: ) { bk_biz_id } }`, `{ biz { name: "x" } }`} {
+		if _, err := Parse(query); err == nil {
+			t.Errorf("parse %s should fail", query)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema := newTestSchema()
+	host := schema.TypeOfObject(common.BKInnerObjIDHost)
+	if host == nil {
+		t.Fatalf("host type is not generated")
+	}
+	if field := host.Field("bk_cpu"); field == nil || field.Type != ScalarInt {
+		t.Errorf("unexpected field bk_cpu: %+v", field)
+	}
+	if field := host.Field(common.BKInnerObjIDModule); field == nil || !field.List || field.Relation.Kind != RelationMainlineParent {
+		t.Errorf("unexpected field module: %+v", field)
+	}
+	if field := host.Field("bk_switch_connect_host"); field == nil || !field.Relation.Reverse || field.Type != "bk_switch" {
+		t.Errorf("unexpected field bk_switch_connect_host: %+v", field)
+	}
+	set := schema.TypeOfObject(common.BKInnerObjIDSet)
+	if field := set.Field(common.BKInnerObjIDApp); field == nil || field.List {
+		t.Errorf("unexpected field biz: %+v", field)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	resolver := &fakeResolver{
+		instances: map[string][]mapstr.MapStr{
+			common.BKInnerObjIDApp: {
+				{common.BKAppIDField: int64(1), common.BKAppNameField: "demo"},
+			},
+			common.BKInnerObjIDSet: {
+				{common.BKSetIDField: int64(10), common.BKSetNameField: "set1", common.BKInstParentStr: int64(1)},
+				{common.BKSetIDField: int64(11), common.BKSetNameField: "set2", common.BKInstParentStr: int64(1)},
+			},
+			common.BKInnerObjIDModule: {
+				{common.BKModuleIDField: int64(100), common.BKModuleNameField: "m1", common.BKInstParentStr: int64(10)},
+				{common.BKModuleIDField: int64(101), common.BKModuleNameField: "m2", common.BKInstParentStr: int64(11)},
+			},
+			common.BKInnerObjIDHost: {
+				{common.BKHostIDField: int64(1000), common.BKHostInnerIPField: "10.0.0.1"},
+				{common.BKHostIDField: int64(1001), common.BKHostInnerIPField: "10.0.0.2"},
+			},
+			"bk_switch": {
+				{common.BKInstIDField: int64(5), common.BKInstNameField: "sw1"},
+			},
+		},
+		asst: []metadata.InstAsst{
+			{ObjectAsstID: "bk_switch_connect_host", InstID: 5, AsstInstID: 1000},
+			{ObjectAsstID: "bk_switch_connect_host", InstID: 5, AsstInstID: 1001},
+		},
+		relations: []metadata.ModuleHost{
+			{ModuleID: 100, HostID: 1000},
+			{ModuleID: 101, HostID: 1001},
+		},
+	}
+
+	request := &Request{
+		Query: `query topo($name: String!) {
+			biz(condition: {bk_biz_name: $name}) {
+				__typename
+				bk_biz_name
+				set { bk_set_name module { bk_module_name host { ...hostFields } } }
+			}
+		}
+		fragment hostFields on host { ip: bk_host_innerip switch: bk_switch_connect_host { bk_inst_name } }`,
+		Variables: map[string]interface{}{"name": "demo"},
+	}
+	response := Execute(context.Background(), newTestSchema(), resolver, request)
+	if len(response.Errors) > 0 {
+		t.Fatalf("execute failed, errors: %+v", response.Errors[0])
+	}
+	out, err := json.Marshal(response.Data)
+	if err != nil {
+		t.Fatalf("marshal response failed, err: %v", err)
+	}
+	expected := `{"biz":[{"__typename":"biz","bk_biz_name":"demo","set":[` +
+		`{"bk_set_name":"set1","module":[{"bk_module_name":"m1","host":[{"ip":"10.0.0.1","switch":[{"bk_inst_name":"sw1"}]}]}]},` +
+		`{"bk_set_name":"set2","module":[{"bk_module_name":"m2","host":[{"ip":"10.0.0.2","switch":[{"bk_inst_name":"sw1"}]}]}]}]}]}`
+	if string(out) != expected {
+		t.Errorf("unexpected result:\n%s\nexpected:\n%s", out, expected)
+	}
+	// biz, set, module, host relations and hosts, switch associations and switches
+	if resolver.calls != 7 {
+		t.Errorf("the relations should be resolved in batch, but the resolver is called %d times", resolver.calls)
+	}
+
+	response = Execute(context.Background(), newTestSchema(), resolver, &Request{Query: `{ host { bk_cpu unknown } biz(limit: 0) { bk_biz_id } }`})
+	if len(response.Errors) != 2 {
+		t.Errorf("expected 2 errors, but got: %+v", response.Errors)
+	}
+	response = Execute(context.Background(), newTestSchema(), resolver, &Request{Query: `mutation { host { bk_cpu } }`})
+	if len(response.Errors) != 1 || response.Data != nil {
+		t.Errorf("mutation should be rejected, but got: %+v", response)
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	request := &Request{Query: `{ __schema { queryType { name } types { name kind fields { name type { kind name ofType { name } } } } } }`}
+	response := Execute(context.Background(), newTestSchema(), &fakeResolver{}, request)
+	if len(response.Errors) > 0 {
+		t.Fatalf("introspection failed, errors: %+v", response.Errors[0])
+	}
+	out, err := json.Marshal(response.Data)
+	if err != nil {
+		t.Fatalf("marshal response failed, err: %v", err)
+	}
+	result := struct {
+		Schema struct {
+			Types []struct {
+				Name string `json:"name"`
+			} `json:"types"`
+		} `json:"__schema"`
+	}{}
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatalf("unmarshal introspection failed, err: %v", err)
+	}
+	names := make([]string, 0)
+	for _, typ := range result.Schema.Types {
+		names = append(names, typ.Name)
+	}
+	for _, name := range []string{queryTypeName, ScalarJSON, "host", "bk_switch"} {
+		if !util.InStrArr(names, name) {
+			t.Errorf("type %s is not in the introspection: %v", name, names)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+// the introspection is built as plain data, and the selections are projected on it, so that the
+// graphql tools can read the schema.
+
+var scalarTypes = []string{ScalarInt, ScalarFloat, ScalarBoolean, ScalarString, ScalarJSON}
+
+func introspectSchema(schema *Schema) map[string]interface{} {
+	types := []map[string]interface{}{introspectQueryType(schema)}
+	for _, name := range scalarTypes {
+		types = append(types, introspectScalar(name))
+	}
+	for _, typ := range schema.Types {
+		types = append(types, introspectObject(typ))
+	}
+	return map[string]interface{}{
+		typeNameField:      "__Schema",
+		"description":      nil,
+		"queryType":        typeRef("OBJECT", queryTypeName),
+		"mutationType":     nil,
+		"subscriptionType": nil,
+		"types":            types,
+		"directives": []map[string]interface{}{
+			introspectDirective("skip", "Skip the selection when the argument is true."),
+			introspectDirective("include", "Include the selection only when the argument is true."),
+		},
+	}
+}
+
+func introspectType(schema *Schema, name string) map[string]interface{} {
+	if name == queryTypeName {
+		return introspectQueryType(schema)
+	}
+	for _, scalar := range scalarTypes {
+		if scalar == name {
+			return introspectScalar(name)
+		}
+	}
+	if typ := schema.Type(name); typ != nil {
+		return introspectObject(typ)
+	}
+	return nil
+}
+
+func introspectQueryType(schema *Schema) map[string]interface{} {
+	fields := make([]map[string]interface{}, 0)
+	for _, typ := range schema.Types {
+		fields = append(fields, introspectField(typ.Name, typ.Description, listRef(typ.Name), []map[string]interface{}{
+			introspectArgument("condition", "the condition of the instances, which is the same as the instance search.", typeRef("SCALAR", ScalarJSON)),
+			introspectArgument("start", "the offset of the instances.", typeRef("SCALAR", ScalarInt)),
+			introspectArgument("limit", "the max number of the instances.", typeRef("SCALAR", ScalarInt)),
+			introspectArgument("sort", "the sort fields, separated by comma, and prefixed by - for descending.", typeRef("SCALAR", ScalarString)),
+		}))
+	}
+	return objectType(queryTypeName, "", fields)
+}
+
+func introspectObject(typ *ObjectType) map[string]interface{} {
+	fields := make([]map[string]interface{}, 0)
+	for _, field := range typ.Fields {
+		kind := "SCALAR"
+		args := make([]map[string]interface{}, 0)
+		if field.Relation != nil {
+			kind = "OBJECT"
+			args = append(args, introspectArgument("condition", "the condition of the related instances.", typeRef("SCALAR", ScalarJSON)))
+		}
+		ref := typeRef(kind, field.Type)
+		if field.List {
+			ref = listRef(field.Type)
+		}
+		fields = append(fields, introspectField(field.Name, field.Description, ref, args))
+	}
+	return objectType(typ.Name, typ.Description, fields)
+}
+
+func objectType(name, description string, fields []map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		typeNameField:   "__Type",
+		"kind":          "OBJECT",
+		"name":          name,
+		"description":   description,
+		"fields":        fields,
+		"interfaces":    []map[string]interface{}{},
+		"possibleTypes": nil,
+		"enumValues":    nil,
+		"inputFields":   nil,
+		"ofType":        nil,
+	}
+}
+
+func introspectScalar(name string) map[string]interface{} {
+	return map[string]interface{}{
+		typeNameField:   "__Type",
+		"kind":          "SCALAR",
+		"name":          name,
+		"description":   nil,
+		"fields":        nil,
+		"interfaces":    nil,
+		"possibleTypes": nil,
+		"enumValues":    nil,
+		"inputFields":   nil,
+		"ofType":        nil,
+	}
+}
+
+func introspectField(name, description string, ref map[string]interface{}, args []map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		typeNameField:       "__Field",
+		"name":              name,
+		"description":       description,
+		"args":              args,
+		"type":              ref,
+		"isDeprecated":      false,
+		"deprecationReason": nil,
+	}
+}
+
+func introspectArgument(name, description string, ref map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		typeNameField:  "__InputValue",
+		"name":         name,
+		"description":  description,
+		"type":         ref,
+		"defaultValue": nil,
+	}
+}
+
+func introspectDirective(name, description string) map[string]interface{} {
+	return map[string]interface{}{
+		typeNameField: "__Directive",
+		"name":        name,
+		"description": description,
+		"locations":   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		"args": []map[string]interface{}{
+			introspectArgument("if", "", nonNullRef(typeRef("SCALAR", ScalarBoolean))),
+		},
+	}
+}
+
+func typeRef(kind, name string) map[string]interface{} {
+	return map[string]interface{}{typeNameField: "__Type", "kind": kind, "name": name, "ofType": nil}
+}
+
+func listRef(name string) map[string]interface{} {
+	return map[string]interface{}{typeNameField: "__Type", "kind": "LIST", "name": nil, "ofType": typeRef("OBJECT", name)}
+}
+
+func nonNullRef(ofType map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{typeNameField: "__Type", "kind": "NON_NULL", "name": nil, "ofType": ofType}
+}
@@ -0,0 +1,277 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+// the scalar types of the fields
+const (
+	ScalarInt     = "Int"
+	ScalarFloat   = "Float"
+	ScalarBoolean = "Boolean"
+	ScalarString  = "String"
+	// ScalarJSON is used by the attributes whose value is not a plain value, such as the lists.
+	ScalarJSON = "JSON"
+)
+
+// RelationKind how the instances of a relation field are found
+type RelationKind int
+
+const (
+	// RelationAssociation the instances are associated by an association of the models
+	RelationAssociation RelationKind = iota
+	// RelationMainlineParent the parent instance in the mainline topology
+	RelationMainlineParent
+	// RelationMainlineChildren the children instances in the mainline topology
+	RelationMainlineChildren
+)
+
+// Relation describe the instances a relation field resolves to
+type Relation struct {
+	Kind RelationKind
+	// ObjectID the model of the related instances
+	ObjectID string
+	// AssociationID the bk_obj_asst_id of the association, only used by RelationAssociation
+	AssociationID string
+	// Reverse is true when the type of the field is the destination of the association,
+	// the related instances are the source instances then.
+	Reverse bool
+}
+
+// ObjectField is a field of an object type, it's either an attribute of the model or a relation.
+type ObjectField struct {
+	Name        string
+	Description string
+	// Type is the scalar type of an attribute field, or the object type of a relation field
+	Type string
+	List bool
+	// PropertyID the attribute of the instance, empty for the relation fields
+	PropertyID string
+	Relation   *Relation
+}
+
+// ObjectType is the graphql type generated for a model
+type ObjectType struct {
+	Name        string
+	Description string
+	ObjectID    string
+	// IDField the field which is the id of the instances
+	IDField string
+	Fields  []*ObjectField
+
+	fieldMap map[string]*ObjectField
+}
+
+// Field get the field of the type by the name
+func (t *ObjectType) Field(name string) *ObjectField {
+	return t.fieldMap[name]
+}
+
+func (t *ObjectType) addField(field *ObjectField) bool {
+	if _, exist := t.fieldMap[field.Name]; exist {
+		return false
+	}
+	t.Fields = append(t.Fields, field)
+	t.fieldMap[field.Name] = field
+	return true
+}
+
+// Schema is the graphql schema generated from the models, each model is an object type,
+// and the query type has a field for each model to search its instances.
+type Schema struct {
+	// Types the object types sorted by name
+	Types []*ObjectType
+
+	types   map[string]*ObjectType
+	objects map[string]*ObjectType
+}
+
+// Type get the object type by the graphql type name
+func (s *Schema) Type(name string) *ObjectType {
+	return s.types[name]
+}
+
+// TypeOfObject get the object type of the model
+func (s *Schema) TypeOfObject(objID string) *ObjectType {
+	return s.objects[objID]
+}
+
+// NewSchema generate the schema from the models with their attributes and the association
+// definitions of the models. the mainline associations are turned into the parent and
+// children fields, and the others into fields on both side of the association.
+func NewSchema(models []metadata.SearchModelInfo, associations []metadata.Association) *Schema {
+	schema := &Schema{
+		Types:   make([]*ObjectType, 0),
+		types:   make(map[string]*ObjectType),
+		objects: make(map[string]*ObjectType),
+	}
+
+	for _, model := range models {
+		name := TypeName(model.Spec.ObjectID)
+		if _, exist := schema.types[name]; exist || isReservedName(name) {
+			continue
+		}
+		typ := &ObjectType{
+			Name:        name,
+			Description: model.Spec.ObjectName,
+			ObjectID:    model.Spec.ObjectID,
+			IDField:     common.GetInstIDField(model.Spec.ObjectID),
+			Fields:      make([]*ObjectField, 0),
+			fieldMap:    make(map[string]*ObjectField),
+		}
+		typ.addField(&ObjectField{Name: typ.IDField, Type: ScalarInt, PropertyID: typ.IDField})
+
+		attributes := model.Attributes
+		sort.Slice(attributes, func(i, j int) bool {
+			return attributes[i].PropertyIndex < attributes[j].PropertyIndex
+		})
+		for _, attr := range attributes {
+			if !isValidName(attr.PropertyID) {
+				continue
+			}
+			typ.addField(&ObjectField{
+				Name:        attr.PropertyID,
+				Description: attr.PropertyName,
+				Type:        scalarType(attr.PropertyType),
+				PropertyID:  attr.PropertyID,
+			})
+		}
+
+		schema.Types = append(schema.Types, typ)
+		schema.types[name] = typ
+		schema.objects[model.Spec.ObjectID] = typ
+	}
+	sort.Slice(schema.Types, func(i, j int) bool {
+		return schema.Types[i].Name < schema.Types[j].Name
+	})
+
+	for _, asst := range associations {
+		src, dest := schema.objects[asst.ObjectID], schema.objects[asst.AsstObjID]
+		if src == nil || dest == nil {
+			continue
+		}
+
+		if asst.AsstKindID == common.AssociationKindMainline {
+			// the source of a mainline association is the child, and a host can be in several modules.
+			src.addField(&ObjectField{
+				Name:        dest.Name,
+				Description: dest.Description,
+				Type:        dest.Name,
+				List:        src.ObjectID == common.BKInnerObjIDHost,
+				Relation:    &Relation{Kind: RelationMainlineParent, ObjectID: dest.ObjectID},
+			})
+			dest.addField(&ObjectField{
+				Name:        src.Name,
+				Description: src.Description,
+				Type:        src.Name,
+				List:        true,
+				Relation:    &Relation{Kind: RelationMainlineChildren, ObjectID: src.ObjectID},
+			})
+			continue
+		}
+
+		name := TypeName(asst.AssociationName)
+		src.addField(&ObjectField{
+			Name:        name,
+			Description: asst.AssociationAliasName,
+			Type:        dest.Name,
+			List:        true,
+			Relation:    &Relation{Kind: RelationAssociation, ObjectID: dest.ObjectID, AssociationID: asst.AssociationName},
+		})
+		reverse := &ObjectField{
+			Name:        name,
+			Description: asst.AssociationAliasName,
+			Type:        src.Name,
+			List:        true,
+			Relation:    &Relation{Kind: RelationAssociation, ObjectID: src.ObjectID, AssociationID: asst.AssociationName, Reverse: true},
+		}
+		// the two sides of an association between the same model can't share the name
+		if !dest.addField(reverse) {
+			reverse.Name = name + "_reverse"
+			dest.addField(reverse)
+		}
+	}
+	return schema
+}
+
+// TypeName convert a model or association id to a valid graphql name
+func TypeName(id string) string {
+	name := []byte(id)
+	for i, c := range name {
+		if c != '_' && !isLetter(c) && !isDigit(c) {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || isDigit(name[0]) {
+		return "_" + string(name)
+	}
+	return string(name)
+}
+
+func isValidName(name string) bool {
+	return name != "" && TypeName(name) == name && !strings.HasPrefix(name, "__")
+}
+
+// isReservedName check whether the name conflicts with the built-in types
+func isReservedName(name string) bool {
+	switch name {
+	case queryTypeName, ScalarInt, ScalarFloat, ScalarBoolean, ScalarString, ScalarJSON:
+		return true
+	}
+	return strings.HasPrefix(name, "__")
+}
+
+func scalarType(propertyType string) string {
+	switch propertyType {
+	case common.FieldTypeInt:
+		return ScalarInt
+	case common.FieldTypeFloat:
+		return ScalarFloat
+	case common.FieldTypeBool:
+		return ScalarBoolean
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeEnum, common.FieldTypeDate,
+		common.FieldTypeTime, common.FieldTypeTimeZone, common.FieldTypeUser:
+		return ScalarString
+	}
+	return ScalarJSON
+}
+
+// String print the schema in the graphql schema language
+func (s *Schema) String() string {
+	var sb strings.Builder
+	sb.WriteString("scalar JSON\n\n")
+	fmt.Fprintf(&sb, "type %s {\n", queryTypeName)
+	for _, typ := range s.Types {
+		fmt.Fprintf(&sb, "  %s(condition: JSON, start: Int, limit: Int, sort: String): [%s]\n", typ.Name, typ.Name)
+	}
+	sb.WriteString("}\n")
+	for _, typ := range s.Types {
+		fmt.Fprintf(&sb, "\ntype %s {\n", typ.Name)
+		for _, field := range typ.Fields {
+			if field.List {
+				fmt.Fprintf(&sb, "  %s: [%s]\n", field.Name, field.Type)
+			} else {
+				fmt.Fprintf(&sb, "  %s: %s\n", field.Name, field.Type)
+			}
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
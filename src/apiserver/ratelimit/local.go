@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// the buckets which are not used for idleTimeout are dropped, and they are checked every cleanInterval.
+	idleTimeout   = 5 * time.Minute
+	cleanInterval = time.Minute
+)
+
+// localBucket a token bucket which is refilled with qps tokens per second up to burst tokens.
+type localBucket struct {
+	tokens   float64
+	limit    Limit
+	lastUsed time.Time
+}
+
+// refill add the tokens since the bucket is used last time
+func (b *localBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.lastUsed).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*float64(b.limit.QPS))
+	}
+	b.lastUsed = now
+}
+
+// localLimiter keep the token buckets in the memory, so each apiserver replica limits the requests separately.
+type localLimiter struct {
+	lock      sync.Mutex
+	buckets   map[string]*localBucket
+	lastClean time.Time
+}
+
+func newLocalLimiter() *localLimiter {
+	return &localLimiter{
+		buckets:   make(map[string]*localBucket),
+		lastClean: time.Now(),
+	}
+}
+
+func (l *localLimiter) Take(buckets []Bucket) (bool, int, time.Duration) {
+	now := time.Now()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.lastClean) > cleanInterval {
+		for k, bucket := range l.buckets {
+			if now.Sub(bucket.lastUsed) > idleTimeout {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastClean = now
+	}
+
+	local := make([]*localBucket, len(buckets))
+	for i, item := range buckets {
+		bucket, exist := l.buckets[item.Key]
+		if !exist || bucket.limit != item.Limit {
+			bucket = &localBucket{tokens: float64(item.Limit.Burst), limit: item.Limit, lastUsed: now}
+			l.buckets[item.Key] = bucket
+		}
+		bucket.refill(now)
+		local[i] = bucket
+	}
+
+	for i, bucket := range local {
+		if bucket.tokens < 1 {
+			wait := (1 - bucket.tokens) / float64(bucket.limit.QPS)
+			return false, i, time.Duration(math.Ceil(wait * float64(time.Second)))
+		}
+	}
+	for _, bucket := range local {
+		bucket.tokens--
+	}
+	return true, 0, 0
+}
@@ -0,0 +1,165 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common/blog"
+
+	redis "gopkg.in/redis.v5"
+)
+
+// Limit the qps and the burst of a rate limit, the requests are not limited if qps is 0.
+type Limit struct {
+	QPS   int64
+	Burst int64
+}
+
+// Config the config of the rate limiting, which is read from the [ratelimit] section of the config file:
+//
+//	enable=true
+//	user_qps=50        # the limit of each user on each route class
+//	user_burst=100
+//	app_qps=200        # the limit of each app code on each route class
+//	app_burst=400
+//	host_user_qps=10   # overrides the limit of the route class, such as topo, host, graphql, auth
+//	redis=true         # share the counters across the apiserver replicas with the [redis] section
+type Config struct {
+	Enable bool
+	// UserLimits the limits of each user by the route class, "" is the default one.
+	UserLimits map[string]Limit
+	// AppLimits the limits of each app code by the route class, "" is the default one.
+	AppLimits map[string]Limit
+	Redis     bool
+}
+
+// ParseConfigFromKV parse the rate limiting config from the config of apiserver
+func ParseConfigFromKV(prefix string, configmap map[string]string) Config {
+	conf := Config{
+		UserLimits: make(map[string]Limit),
+		AppLimits:  make(map[string]Limit),
+	}
+	conf.Enable, _ = strconv.ParseBool(configmap[prefix+".enable"])
+	conf.Redis, _ = strconv.ParseBool(configmap[prefix+".redis"])
+
+	for key := range configmap {
+		if !strings.HasPrefix(key, prefix+".") {
+			continue
+		}
+		name := strings.TrimPrefix(key, prefix+".")
+		switch {
+		case strings.HasSuffix(name, "user_qps"):
+			class := strings.TrimSuffix(strings.TrimSuffix(name, "user_qps"), "_")
+			conf.UserLimits[class] = parseLimit(configmap, prefix+"."+strings.TrimSuffix(name, "qps"))
+		case strings.HasSuffix(name, "app_qps"):
+			class := strings.TrimSuffix(strings.TrimSuffix(name, "app_qps"), "_")
+			conf.AppLimits[class] = parseLimit(configmap, prefix+"."+strings.TrimSuffix(name, "qps"))
+		}
+	}
+	return conf
+}
+
+// parseLimit parse the <key>qps and <key>burst config, the burst is the same as the qps if it's not set.
+func parseLimit(configmap map[string]string, key string) Limit {
+	limit := Limit{}
+	qps, err := strconv.ParseInt(strings.TrimSpace(configmap[key+"qps"]), 10, 64)
+	if err != nil || qps < 0 {
+		blog.Errorf("invalid rate limit config %sqps: %s, the requests are not limited", key, configmap[key+"qps"])
+		return limit
+	}
+	limit.QPS, limit.Burst = qps, qps
+	if burst, exist := configmap[key+"burst"]; exist {
+		limit.Burst, err = strconv.ParseInt(strings.TrimSpace(burst), 10, 64)
+		if err != nil || limit.Burst < qps {
+			blog.Errorf("invalid rate limit config %sburst: %s, use the qps as the burst", key, burst)
+			limit.Burst = qps
+		}
+	}
+	return limit
+}
+
+// Bucket a token bucket identified by the key with the limit
+type Bucket struct {
+	Key   string
+	Limit Limit
+}
+
+// Limiter take a token from each of the buckets at once, no token is taken if any of the buckets
+// is empty, in which case it returns the index of the empty bucket and how long to wait before
+// the next token is available.
+type Limiter interface {
+	Take(buckets []Bucket) (allowed bool, rejected int, retryAfter time.Duration)
+}
+
+// RateLimiter limit the requests of each user and each app code on each route class.
+type RateLimiter struct {
+	conf    Config
+	limiter Limiter
+}
+
+// New create a rate limiter, the counters are shared with redis if cache is not nil,
+// or else they are kept in the memory of this process.
+func New(conf Config, cache *redis.Client) *RateLimiter {
+	var limiter Limiter = newLocalLimiter()
+	if cache != nil {
+		limiter = newRedisLimiter(cache, limiter)
+	}
+	return &RateLimiter{conf: conf, limiter: limiter}
+}
+
+// Enabled check whether the rate limiting is enabled
+func (r *RateLimiter) Enabled() bool {
+	return r.conf.Enable
+}
+
+// Take take a token for the request of the user with the app code on the route class, it returns
+// the rejected key and how long to wait before retry if the request exceeds the limit. the tokens
+// are only taken when both the user and the app code are within their limits, so a rejected request
+// does not use up the quota of the other one.
+func (r *RateLimiter) Take(user, appCode, class string) (allowed bool, key string, retryAfter time.Duration) {
+	if !r.conf.Enable {
+		return true, "", 0
+	}
+	buckets := make([]Bucket, 0, 2)
+	if user != "" {
+		buckets = appendBucket(buckets, "user:"+user+":"+class, r.conf.UserLimits, class)
+	}
+	if appCode != "" {
+		buckets = appendBucket(buckets, "app:"+appCode+":"+class, r.conf.AppLimits, class)
+	}
+	if len(buckets) == 0 {
+		return true, "", 0
+	}
+
+	allowed, rejected, retryAfter := r.limiter.Take(buckets)
+	if allowed {
+		return true, "", 0
+	}
+	return false, buckets[rejected].Key, retryAfter
+}
+
+// appendBucket append the bucket of the key with the limit of the route class, the default limit
+// is used if the route class has none, and the bucket is not appended if it's unlimited.
+func appendBucket(buckets []Bucket, key string, limits map[string]Limit, class string) []Bucket {
+	limit, exist := limits[class]
+	if !exist {
+		limit = limits[""]
+	}
+	if limit.QPS <= 0 {
+		return buckets
+	}
+	return append(buckets, Bucket{Key: key, Limit: limit})
+}
@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"testing"
+)
+
+func TestParseConfigFromKV(t *testing.T) {
+	conf := ParseConfigFromKV("ratelimit", map[string]string{
+		"ratelimit.enable":          "true",
+		"ratelimit.user_qps":        "10",
+		"ratelimit.user_burst":      "20",
+		"ratelimit.app_qps":         "100",
+		"ratelimit.host_user_qps":   "2",
+		"ratelimit.host_user_burst": "1",
+	})
+	if !conf.Enable || conf.Redis {
+		t.Fatalf("parse enable and redis failed, got: %+v", conf)
+	}
+	if conf.UserLimits[""] != (Limit{QPS: 10, Burst: 20}) {
+		t.Errorf("parse default user limit failed, got: %+v", conf.UserLimits[""])
+	}
+	if conf.AppLimits[""] != (Limit{QPS: 100, Burst: 100}) {
+		t.Errorf("parse default app limit failed, got: %+v", conf.AppLimits[""])
+	}
+	// the burst less than the qps is invalid
+	if conf.UserLimits["host"] != (Limit{QPS: 2, Burst: 2}) {
+		t.Errorf("parse host user limit failed, got: %+v", conf.UserLimits["host"])
+	}
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	limiter := New(Config{
+		Enable:     true,
+		UserLimits: map[string]Limit{"": {QPS: 1, Burst: 2}, "topo": {QPS: 0}},
+		AppLimits:  map[string]Limit{},
+	}, nil)
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := limiter.Take("admin", "", "host"); !allowed {
+			t.Fatalf("request %d in the burst is rejected", i)
+		}
+	}
+	allowed, key, retryAfter := limiter.Take("admin", "", "host")
+	if allowed || key != "user:admin:host" || retryAfter <= 0 {
+		t.Errorf("request exceeds the burst is not rejected, allowed: %v, key: %s, retry after: %v", allowed, key, retryAfter)
+	}
+	if allowed, _, _ := limiter.Take("other", "", "host"); !allowed {
+		t.Errorf("request of another user is rejected")
+	}
+	for i := 0; i < 10; i++ {
+		if allowed, _, _ := limiter.Take("admin", "", "topo"); !allowed {
+			t.Fatalf("request of the unlimited route class is rejected")
+		}
+	}
+}
+
+func TestRateLimiterTakeBothBuckets(t *testing.T) {
+	limiter := New(Config{
+		Enable:     true,
+		UserLimits: map[string]Limit{"": {QPS: 1, Burst: 3}},
+		AppLimits:  map[string]Limit{"": {QPS: 1, Burst: 1}},
+	}, nil)
+
+	if allowed, _, _ := limiter.Take("admin", "app", "host"); !allowed {
+		t.Fatalf("the first request is rejected")
+	}
+	// the app bucket is empty, the user bucket must not be taken by the rejected requests.
+	for i := 0; i < 3; i++ {
+		allowed, key, _ := limiter.Take("admin", "app", "host")
+		if allowed || key != "app:app:host" {
+			t.Fatalf("request %d exceeds the app limit is not rejected by the app, allowed: %v, key: %s", i, allowed, key)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := limiter.Take("admin", "", "host"); !allowed {
+			t.Fatalf("request %d of the user is rejected, the tokens are taken by the rejected requests", i)
+		}
+	}
+	if allowed, key, _ := limiter.Take("admin", "", "host"); allowed || key != "user:admin:host" {
+		t.Errorf("request exceeds the user limit is not rejected, allowed: %v, key: %s", allowed, key)
+	}
+}
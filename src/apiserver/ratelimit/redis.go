@@ -0,0 +1,127 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+
+	redis "gopkg.in/redis.v5"
+)
+
+const redisKeyPrefix = "cc:apiserver:ratelimit:"
+
+// takeTokenScript the token buckets stored in redis hashes, the arguments are the current
+// milliseconds and the qps and burst of each key. a token is taken from every bucket only if
+// none of them is empty, it returns 0 in this case, or else the 1-based index of the empty
+// bucket with the milliseconds to wait for its next token.
+var takeTokenScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local tokens = {}
+for i = 1, #KEYS do
+	local qps = tonumber(ARGV[2 * i])
+	local burst = tonumber(ARGV[2 * i + 1])
+	local state = redis.call("HMGET", KEYS[i], "tokens", "ts")
+	local current = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if current == nil or ts == nil then
+		current = burst
+		ts = now
+	end
+	if now > ts then
+		current = math.min(burst, current + (now - ts) * qps / 1000)
+	end
+	tokens[i] = current
+end
+local rejected = 0
+local wait = 0
+for i = 1, #KEYS do
+	if tokens[i] < 1 then
+		rejected = i
+		wait = math.ceil((1 - tokens[i]) * 1000 / tonumber(ARGV[2 * i]))
+		break
+	end
+end
+for i = 1, #KEYS do
+	local qps = tonumber(ARGV[2 * i])
+	local burst = tonumber(ARGV[2 * i + 1])
+	if rejected == 0 then
+		tokens[i] = tokens[i] - 1
+	end
+	redis.call("HMSET", KEYS[i], "tokens", tostring(tokens[i]), "ts", now)
+	redis.call("PEXPIRE", KEYS[i], math.ceil(burst * 1000 / qps) + 1000)
+end
+return {rejected, wait}
+`)
+
+// redisLimiter share the token buckets across the apiserver replicas with redis, it falls back
+// to the local limiter when redis is not available, so that the requests are never rejected
+// because of a redis failure.
+type redisLimiter struct {
+	cache    *redis.Client
+	fallback Limiter
+
+	// unavailable whether the last request to redis failed, the failure and the recovery are
+	// only logged when it changes, so that a redis outage does not flood the log.
+	lock        sync.Mutex
+	unavailable bool
+}
+
+func newRedisLimiter(cache *redis.Client, fallback Limiter) *redisLimiter {
+	return &redisLimiter{cache: cache, fallback: fallback}
+}
+
+func (r *redisLimiter) Take(buckets []Bucket) (bool, int, time.Duration) {
+	keys := make([]string, len(buckets))
+	args := make([]interface{}, 0, 2*len(buckets)+1)
+	args = append(args, time.Now().UnixNano()/int64(time.Millisecond))
+	for i, bucket := range buckets {
+		keys[i] = redisKeyPrefix + bucket.Key
+		args = append(args, bucket.Limit.QPS, bucket.Limit.Burst)
+	}
+
+	result, err := takeTokenScript.Run(r.cache, keys, args...).Result()
+	if err != nil {
+		r.setAvailable(false, err)
+		return r.fallback.Take(buckets)
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		blog.Errorf("take rate limit tokens of %v from redis, but got invalid result: %v, use the local limiter", keys, result)
+		return r.fallback.Take(buckets)
+	}
+	r.setAvailable(true, nil)
+
+	rejected, _ := values[0].(int64)
+	wait, _ := values[1].(int64)
+	if rejected == 0 {
+		return true, 0, 0
+	}
+	return false, int(rejected) - 1, time.Duration(wait) * time.Millisecond
+}
+
+func (r *redisLimiter) setAvailable(available bool, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.unavailable == !available {
+		return
+	}
+	r.unavailable = !available
+	if available {
+		blog.Infof("redis is available again, share the rate limit tokens with redis")
+		return
+	}
+	blog.Errorf("take rate limit tokens from redis failed, use the local limiter until redis is available, err: %v", err)
+}
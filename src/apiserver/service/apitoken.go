@@ -0,0 +1,319 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/auth/parser"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+const (
+	// the request attributes set by the api token filter
+	apiTokenAttribute       = "api_token"
+	apiTokenAccessAttribute = "api_token_access"
+
+	bearerPrefix = "Bearer "
+)
+
+// APITokenConfig the config of the api tokens, which is read from the [apitoken] section of the config file.
+type APITokenConfig struct {
+	// ServiceAccountAdmins the users who can create the service account tokens, no one can
+	// create them if it's empty, because a service account token can act as any user name.
+	ServiceAccountAdmins []string
+}
+
+// ParseAPITokenConfig parse the api token config from the config of apiserver
+func ParseAPITokenConfig(config map[string]string) APITokenConfig {
+	conf := APITokenConfig{ServiceAccountAdmins: make([]string, 0)}
+	for _, user := range strings.Split(config["apitoken.service_account_admins"], ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			conf.ServiceAccountAdmins = append(conf.ServiceAccountAdmins, user)
+		}
+	}
+	return conf
+}
+
+// apiTokenFilter authenticate the request with the api token in the authorization header, the user
+// and supplier account headers are replaced with the ones of the token, so that the following filters
+// and the backend servers see the request as it's sent by the token user. the requests without an api
+// token are not changed.
+func (s *service) apiTokenFilter(errFunc func() ccErr.CCErrorIf) func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+		plainToken := getBearerToken(req.Request.Header)
+		if plainToken == "" {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		rid := util.GetHTTPCCRequestID(req.Request.Header)
+		defErr := errFunc().CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+		ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+		input := &metadata.ValidateAPITokenRequest{Token: plainToken}
+		result, err := s.engine.CoreAPI.CoreService().APIToken().ValidateAPIToken(ctx, req.Request.Header, input)
+		if err != nil {
+			blog.Errorf("apiTokenFilter failed, validate api token failed, err: %v, rid: %s", err, rid)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+			return
+		}
+		if !result.Result {
+			blog.Warnf("apiTokenFilter failed, caller: %s, invalid api token, err: %s, rid: %s", req.Request.RemoteAddr, result.ErrMsg, rid)
+			resp.WriteError(http.StatusUnauthorized, &metadata.RespError{Msg: defErr.Error(common.CCErrAPITokenInvalid), ErrCode: common.CCErrAPITokenInvalid})
+			return
+		}
+		token := result.Data
+
+		req.Request.Header.Del("Authorization")
+		req.Request.Header.Set(common.BKHTTPHeaderUser, token.User)
+		req.Request.Header.Set(common.BKHTTPOwnerID, token.OwnerID)
+		req.Request.Header.Set(common.BKHTTPOwner, token.OwnerID)
+		req.SetAttribute(apiTokenAttribute, &token)
+		req.SetAttribute(apiTokenAccessAttribute, s.parseRequestAccess(req))
+		blog.V(5).Infof("apiTokenFilter, request %s %s with api token %d of user %s, rid: %s", req.Request.Method, req.Request.URL.Path, token.ID, token.User, rid)
+
+		fchain.ProcessFilter(req, resp)
+	}
+}
+
+// checkAPITokenScope check whether the api token of the request, if there is one, has the scope
+// to access the backend server.
+func (s *service) checkAPITokenScope(req *restful.Request, kind RequestType) error {
+	token, ok := req.Attribute(apiTokenAttribute).(*metadata.APIToken)
+	if !ok {
+		return nil
+	}
+	access, _ := req.Attribute(apiTokenAccessAttribute).(string)
+	if token.Allow(string(kind), access) {
+		return nil
+	}
+
+	return s.apiTokenScopeError(req, token, string(kind)+":"+access)
+}
+
+// localAPITokenScopeFilter check the scope of the api token on the apis served by the api server
+// itself, which are not proxied by the url filter. the graphql queries read the instances and the
+// hosts, and the local authorization apis can only be used by a login user, like the api tokens.
+func (s *service) localAPITokenScopeFilter(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+	token, ok := req.Attribute(apiTokenAttribute).(*metadata.APIToken)
+	if !ok {
+		fchain.ProcessFilter(req, resp)
+		return
+	}
+	kinds, local := localAPITokenScopes(req.Request.URL.Path)
+	if !local {
+		fchain.ProcessFilter(req, resp)
+		return
+	}
+
+	var err error
+	if len(kinds) == 0 {
+		err = s.apiTokenScopeError(req, token, req.Request.URL.Path)
+	}
+	for _, kind := range kinds {
+		if !token.Allow(string(kind), metadata.APITokenAccessRead) {
+			err = s.apiTokenScopeError(req, token, string(kind)+":"+metadata.APITokenAccessRead)
+			break
+		}
+	}
+	if err != nil {
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{
+			Msg:     err,
+			ErrCode: common.CCErrAPITokenScopeNotAllowed,
+		})
+		return
+	}
+	fchain.ProcessFilter(req, resp)
+}
+
+// localAPITokenScopes get the request types an api token needs to read to access the local api of
+// the path, no api token can access it if there is none. local is false if the api is not served
+// locally, or the api checks the api token by itself.
+func localAPITokenScopes(path string) (kinds []RequestType, local bool) {
+	switch {
+	case path == rootPath+"/graphql":
+		return []RequestType{TopoType, HostType}, true
+	case path == rootPath+"/auth/explain",
+		strings.HasPrefix(path, rootPath+"/auth/role"),
+		strings.HasPrefix(path, rootPath+"/auth/permission"):
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+func (s *service) apiTokenScopeError(req *restful.Request, token *metadata.APIToken, scope string) error {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	blog.Warnf("api token %d of user %s has no scope %s, scopes: %v, rid: %s", token.ID, token.User, scope, token.Scopes, rid)
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	return defErr.Errorf(common.CCErrAPITokenScopeNotAllowed, scope)
+}
+
+// parseRequestAccess decide whether the request only reads resources with the auth attribute of
+// the request, the requests which can not be parsed are taken as writing.
+func (s *service) parseRequestAccess(req *restful.Request) string {
+	if req.Request.Method == http.MethodGet {
+		return metadata.APITokenAccessRead
+	}
+	attribute, err := parser.ParseAttribute(req, s.engine)
+	if err != nil {
+		blog.V(5).Infof("parse auth attribute of %s %s failed, take it as writing, err: %v", req.Request.Method, req.Request.URL.Path, err)
+		return metadata.APITokenAccessWrite
+	}
+	for _, resource := range attribute.Resources {
+		switch resource.Action {
+		case meta.Find, meta.FindMany, meta.SkipAction:
+		default:
+			return metadata.APITokenAccessWrite
+		}
+	}
+	return metadata.APITokenAccessRead
+}
+
+func getBearerToken(header http.Header) string {
+	authorization := header.Get("Authorization")
+	if !strings.HasPrefix(authorization, bearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len(bearerPrefix):])
+}
+
+// CreateAPIToken create a personal or service account api token for current user
+func (s *service) CreateAPIToken(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkAPITokenManager(req, resp) {
+		return
+	}
+
+	input := new(metadata.CreateAPITokenRequest)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("create api token, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if input.Type == metadata.APITokenTypeService && !util.InStrArr(s.tokenConf.ServiceAccountAdmins, util.GetUser(pheader)) {
+		blog.Errorf("create api token, but user %s can not create service account token, rid: %s", util.GetUser(pheader), rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: defErr.Error(common.CCErrCommAuthNotHavePermission), ErrCode: common.CCErrCommAuthNotHavePermission})
+		return
+	}
+
+	ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+	result, err := s.engine.CoreAPI.CoreService().APIToken().CreateAPIToken(ctx, pheader, input)
+	if err != nil {
+		blog.Errorf("create api token failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: errors.New(result.ErrMsg), ErrCode: result.Code})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result.Data))
+}
+
+// SearchAPITokens search the api tokens created by or acting as current user
+func (s *service) SearchAPITokens(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkAPITokenManager(req, resp) {
+		return
+	}
+
+	input := new(metadata.QueryCondition)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("search api tokens, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	user := util.GetUser(pheader)
+	userCond := mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{metadata.APITokenFieldCreator: user},
+			{metadata.APITokenFieldUser: user},
+		},
+	}
+	if len(input.Condition) == 0 {
+		input.Condition = userCond
+	} else {
+		input.Condition = mapstr.MapStr{common.BKDBAND: []mapstr.MapStr{input.Condition, userCond}}
+	}
+
+	ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+	result, err := s.engine.CoreAPI.CoreService().APIToken().SearchAPITokens(ctx, pheader, input)
+	if err != nil {
+		blog.Errorf("search api tokens failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: errors.New(result.ErrMsg), ErrCode: result.Code})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result.Data))
+}
+
+// RevokeAPITokens revoke the api tokens created by or acting as current user
+func (s *service) RevokeAPITokens(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkAPITokenManager(req, resp) {
+		return
+	}
+
+	input := new(metadata.RevokeAPITokenRequest)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("revoke api tokens, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+	result, err := s.engine.CoreAPI.CoreService().APIToken().RevokeAPITokens(ctx, pheader, input)
+	if err != nil {
+		blog.Errorf("revoke api tokens failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: errors.New(result.ErrMsg), ErrCode: result.Code})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result.Data))
+}
+
+// checkAPITokenManager the api tokens can only be managed by a login user, so that a leaked
+// token can not be used to create more tokens or to keep itself from being revoked.
+func (s *service) checkAPITokenManager(req *restful.Request, resp *restful.Response) bool {
+	if _, ok := req.Attribute(apiTokenAttribute).(*metadata.APIToken); !ok {
+		return true
+	}
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: defErr.Error(common.CCErrAPITokenManagedByToken), ErrCode: common.CCErrAPITokenManagedByToken})
+	return false
+}
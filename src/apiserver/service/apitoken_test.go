@@ -0,0 +1,73 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+
+	"github.com/emicklei/go-restful"
+)
+
+func TestLocalAPITokenScopeFilter(t *testing.T) {
+	s := &service{engine: &backbone.Engine{CCErr: errors.NewFromCtx(errors.EmptyErrorsSetting)}}
+	narrow := &metadata.APIToken{ID: 1, User: "admin", Scopes: []string{"topo:read"}}
+	wide := &metadata.APIToken{ID: 2, User: "admin", Scopes: []string{"topo:read", "host:read"}}
+	all := &metadata.APIToken{ID: 3, User: "admin", Scopes: []string{"*:*"}}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    *metadata.APIToken
+		wantPass bool
+	}{
+		{"graphql without the host scope", http.MethodPost, "/api/v3/graphql", narrow, false},
+		{"graphql with the read scopes", http.MethodPost, "/api/v3/graphql", wide, true},
+		{"create role", http.MethodPost, "/api/v3/auth/role", all, false},
+		{"update role", http.MethodPut, "/api/v3/auth/role/1", narrow, false},
+		{"search roles", http.MethodPost, "/api/v3/auth/role/search", all, false},
+		{"create role binding", http.MethodPost, "/api/v3/auth/rolebinding", all, false},
+		{"delete role binding", http.MethodDelete, "/api/v3/auth/rolebinding/1", narrow, false},
+		{"search permissions", http.MethodPost, "/api/v3/auth/permission/search", all, false},
+		{"explain", http.MethodPost, "/api/v3/auth/explain", all, false},
+		{"proxied apis are checked by the url filter", http.MethodPost, "/api/v3/hosts/search", narrow, true},
+		{"login user", http.MethodPost, "/api/v3/auth/role", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := restful.NewRequest(httptest.NewRequest(tt.method, tt.path, nil))
+			if tt.token != nil {
+				req.SetAttribute(apiTokenAttribute, tt.token)
+			}
+			recorder := httptest.NewRecorder()
+			resp := restful.NewResponse(recorder)
+			passed := false
+			chain := &restful.FilterChain{Target: func(*restful.Request, *restful.Response) { passed = true }}
+
+			s.localAPITokenScopeFilter(req, resp, chain)
+			if passed != tt.wantPass {
+				t.Fatalf("localAPITokenScopeFilter() passed = %v, want %v", passed, tt.wantPass)
+			}
+			if !tt.wantPass && recorder.Code != http.StatusForbidden {
+				t.Errorf("localAPITokenScopeFilter() status = %d, want %d", recorder.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"configcenter/src/auth"
+	"configcenter/src/auth/meta"
+	"configcenter/src/auth/parser"
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// authDenialQueueSize the max number of the denial audit logs waiting to be saved, the logs are dropped
+// when the queue is full, so that the denied requests never pile up goroutines.
+const authDenialQueueSize = 1024
+
+// authDenialAudit a denial audit log waiting to be saved with the header of the denied request
+type authDenialAudit struct {
+	rid    string
+	header http.Header
+	log    metadata.SaveAuditLogParams
+}
+
+// AuthAuditConfig the config of the authorization audit, which is read from the [authaudit] section of the config file.
+type AuthAuditConfig struct {
+	// AuditDenial write an audit log for each request denied by the authorization
+	AuditDenial bool
+}
+
+// ParseAuthAuditConfig parse the authorization audit config from the config of apiserver
+func ParseAuthAuditConfig(config map[string]string) AuthAuditConfig {
+	conf := AuthAuditConfig{}
+	if denial, exist := config["authaudit.denial"]; exist {
+		auditDenial, err := strconv.ParseBool(denial)
+		if err != nil {
+			blog.Errorf("invalid authaudit.denial config %s, denials are not audited, err: %v", denial, err)
+		}
+		conf.AuditDenial = auditDenial
+	}
+	return conf
+}
+
+// ExplainAuth explain why a request is authorized or denied, it returns the resources the request
+// is parsed to by the auth parser and the decision on each of them. a user can explain their own
+// requests, and the users who can enter the admin entrance can explain the requests of anyone.
+func (s *service) ExplainAuth(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+
+	if s.authorizer.Enabled() == false {
+		blog.Errorf("inappropriate calling, auth is disabled, rid: %s", rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommInappropriateVisitToIAM)})
+		return
+	}
+
+	input := new(metadata.AuthExplainRequest)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("explain auth, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if input.Method == "" {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "method"), ErrCode: common.CCErrCommParamsNeedSet})
+		return
+	}
+	path, err := url.Parse(input.Path)
+	if err != nil || path.Path == "" {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "path"), ErrCode: common.CCErrCommParamsIsInvalid})
+		return
+	}
+
+	ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+	user := meta.UserInfo{
+		UserName:        util.GetUser(pheader),
+		SupplierAccount: util.GetOwnerID(pheader),
+	}
+	if input.User != "" && input.User != user.UserName {
+		systems, err := s.authorizer.AdminEntrance(ctx, user)
+		if err != nil {
+			blog.Errorf("explain auth, but check admin entrance of user %s failed, err: %v, rid: %s", user.UserName, err, rid)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrAPIGetUserResourceAuthStatusFailed)})
+			return
+		}
+		if len(systems) == 0 {
+			blog.Errorf("explain auth, but user %s can not explain the requests of user %s, rid: %s", user.UserName, input.User, rid)
+			resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: defErr.Error(common.CCErrCommAuthNotHavePermission), ErrCode: common.CCErrCommAuthNotHavePermission})
+			return
+		}
+		user.UserName = input.User
+	}
+
+	// rebuild the request as it's sent by the user, and parse it the same way as the auth filter.
+	explainReq, err := http.NewRequest(strings.ToUpper(input.Method), path.RequestURI(), bytes.NewReader(input.Body))
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "method"), ErrCode: common.CCErrCommParamsIsInvalid})
+		return
+	}
+	for key, values := range pheader {
+		explainReq.Header[key] = values
+	}
+	explainReq.Header.Set(common.BKHTTPHeaderUser, user.UserName)
+
+	result := metadata.AuthExplainResult{
+		User:            user.UserName,
+		SupplierAccount: user.SupplierAccount,
+		Method:          explainReq.Method,
+		Path:            path.Path,
+		Resources:       make([]metadata.AuthExplainResource, 0),
+	}
+	attribute, err := parser.ParseAttribute(restful.NewRequest(explainReq), s.engine)
+	if err != nil {
+		result.ParseError = err.Error()
+		resp.WriteEntity(metadata.NewSuccessResp(result))
+		return
+	}
+
+	result.Resources, result.Passed, err = explainAuthDecisions(ctx, s.authorizer, attribute.User, attribute.Resources)
+	if err != nil {
+		blog.Errorf("explain auth, but authorize batch failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrAPIGetUserResourceAuthStatusFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// auditAuthDenial write an audit log for the request denied by the authorization, it's queued and
+// saved in background so that the response is not delayed.
+func (s *service) auditAuthDenial(req *restful.Request, attribute *meta.AuthAttribute, decision meta.Decision) {
+	if !s.authAuditConf.AuditDenial || s.authDenials == nil {
+		return
+	}
+
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	header := make(http.Header)
+	header.Set(common.BKHTTPHeaderUser, attribute.User.UserName)
+	header.Set(common.BKHTTPOwnerID, attribute.User.SupplierAccount)
+	header.Set(common.BKHTTPCCRequestID, rid)
+	denial := authDenialAudit{rid: rid, header: header, log: newAuthDenialAuditLog(req.Request, attribute, decision)}
+
+	select {
+	case s.authDenials <- denial:
+	default:
+		if dropped := atomic.AddUint64(&s.droppedAuthDenials, 1); dropped%1000 == 1 {
+			blog.Warnf("the auth denial audit queue is full, %d denial audit logs are dropped, rid: %s", dropped, rid)
+		}
+	}
+}
+
+// runAuthDenialAudit save the queued denial audit logs one by one.
+func (s *service) runAuthDenialAudit() {
+	for denial := range s.authDenials {
+		ctx := context.WithValue(context.Background(), common.ContextRequestIDField, denial.rid)
+		result, err := s.engine.CoreAPI.CoreService().Audit().SaveAuditLog(ctx, denial.header, denial.log)
+		if err != nil {
+			blog.Errorf("save auth denial audit log failed, err: %v, rid: %s", err, denial.rid)
+			continue
+		}
+		if !result.Result {
+			blog.Errorf("save auth denial audit log failed, err: %s, rid: %s", result.ErrMsg, denial.rid)
+		}
+	}
+}
+
+// explainAuthDecisions authorize the resources the same way as the auth filter, the resources
+// skipped by the api server pass without being authorized.
+func explainAuthDecisions(ctx context.Context, authorizer auth.Authorizer, user meta.UserInfo, resources []meta.ResourceAttribute) ([]metadata.AuthExplainResource, bool, error) {
+	explained := convertAuthExplainResources(resources)
+	checkIndexes := make([]int, 0)
+	checkResources := make([]meta.ResourceAttribute, 0)
+	for i, resource := range resources {
+		if resource.Action == meta.SkipAction {
+			explained[i].Skipped = true
+			explained[i].Passed = true
+			continue
+		}
+		checkIndexes = append(checkIndexes, i)
+		checkResources = append(checkResources, resource)
+	}
+	if len(checkResources) == 0 {
+		return explained, true, nil
+	}
+
+	decisions, err := authorizer.AuthorizeBatch(ctx, user, checkResources...)
+	if err != nil {
+		return nil, false, err
+	}
+	passed := true
+	for i, decision := range decisions {
+		explained[checkIndexes[i]].Passed = decision.Authorized
+		explained[checkIndexes[i]].Reason = decision.Reason
+		if !decision.Authorized {
+			passed = false
+		}
+	}
+	return explained, passed, nil
+}
+
+// newAuthDenialAuditLog build the audit log of the request denied by the authorization
+func newAuthDenialAuditLog(req *http.Request, attribute *meta.AuthAttribute, decision meta.Decision) metadata.SaveAuditLogParams {
+	denial := metadata.SaveAuditLogParams{
+		Model:  metadata.AuthDenialAuditTarget,
+		OpType: auditoplog.AuditOpTypeAuthDenied,
+		OpDesc: "request denied by authorization",
+		Content: metadata.AuthDenial{
+			Method:    req.Method,
+			Path:      req.URL.Path,
+			Reason:    decision.Reason,
+			Resources: convertAuthExplainResources(attribute.Resources),
+		},
+	}
+	if len(attribute.Resources) > 0 {
+		denial.BizID = attribute.Resources[0].BusinessID
+	}
+	return denial
+}
+
+func convertAuthExplainResources(resources []meta.ResourceAttribute) []metadata.AuthExplainResource {
+	explained := make([]metadata.AuthExplainResource, len(resources))
+	for i, resource := range resources {
+		explained[i] = metadata.AuthExplainResource{
+			BizID:        resource.BusinessID,
+			ResourceType: string(resource.Type),
+			Action:       string(resource.Action),
+			Name:         resource.Name,
+			ResourceID:   resource.InstanceID,
+			ResourceIDEx: resource.InstanceIDEx,
+			ParentLayers: make([]metadata.AuthExplainLayer, 0),
+		}
+		for _, layer := range resource.Layers {
+			explained[i].ParentLayers = append(explained[i].ParentLayers, metadata.AuthExplainLayer{
+				ResourceType: string(layer.Type),
+				ResourceID:   layer.InstanceID,
+			})
+		}
+	}
+	return explained
+}
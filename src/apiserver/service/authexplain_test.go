@@ -0,0 +1,184 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"configcenter/src/auth"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/metadata"
+
+	"github.com/emicklei/go-restful"
+)
+
+// fakeAuthorizer denies the resources of the denied types, and records the resources it's asked.
+type fakeAuthorizer struct {
+	auth.Authorizer
+	denied    map[meta.ResourceType]bool
+	err       error
+	requested []meta.ResourceAttribute
+}
+
+func (a *fakeAuthorizer) Enabled() bool {
+	return true
+}
+
+func (a *fakeAuthorizer) AuthorizeBatch(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Decision, error) {
+	a.requested = append(a.requested, resources...)
+	if a.err != nil {
+		return nil, a.err
+	}
+	decisions := make([]meta.Decision, len(resources))
+	for i, resource := range resources {
+		if a.denied[resource.Type] {
+			decisions[i] = meta.Decision{Reason: "no permission"}
+			continue
+		}
+		decisions[i] = meta.Decision{Authorized: true}
+	}
+	return decisions, nil
+}
+
+func TestExplainAuthDecisions(t *testing.T) {
+	host := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update}, BusinessID: 2}
+	set := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.ModelSet, Action: meta.Find}, BusinessID: 2}
+	skipped := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.ModelModule, Action: meta.SkipAction}}
+
+	tests := []struct {
+		name          string
+		resources     []meta.ResourceAttribute
+		denied        map[meta.ResourceType]bool
+		wantPassed    bool
+		wantResPassed []bool
+		wantRequested int
+	}{
+		{"all authorized", []meta.ResourceAttribute{host, set}, nil, true, []bool{true, true}, 2},
+		{"one denied", []meta.ResourceAttribute{host, set}, map[meta.ResourceType]bool{meta.ModelSet: true}, false, []bool{true, false}, 2},
+		{"skipped resources are not authorized", []meta.ResourceAttribute{skipped, host}, map[meta.ResourceType]bool{meta.ModelModule: true}, true, []bool{true, true}, 1},
+		{"only skipped resources", []meta.ResourceAttribute{skipped}, nil, true, []bool{true}, 0},
+		{"no resources", []meta.ResourceAttribute{}, nil, true, []bool{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizer := &fakeAuthorizer{denied: tt.denied}
+			explained, passed, err := explainAuthDecisions(context.Background(), authorizer, meta.UserInfo{UserName: "admin"}, tt.resources)
+			if err != nil {
+				t.Fatalf("explainAuthDecisions() unexpected error: %v", err)
+			}
+			if passed != tt.wantPassed {
+				t.Errorf("explainAuthDecisions() passed = %v, want %v", passed, tt.wantPassed)
+			}
+			resPassed := make([]bool, len(explained))
+			for i, resource := range explained {
+				resPassed[i] = resource.Passed
+				if resource.Skipped != (tt.resources[i].Action == meta.SkipAction) {
+					t.Errorf("explainAuthDecisions() resource %d skipped = %v", i, resource.Skipped)
+				}
+				if !resource.Passed && resource.Reason == "" {
+					t.Errorf("explainAuthDecisions() denied resource %d has no reason", i)
+				}
+			}
+			if !reflect.DeepEqual(resPassed, tt.wantResPassed) {
+				t.Errorf("explainAuthDecisions() resources passed = %v, want %v", resPassed, tt.wantResPassed)
+			}
+			if len(authorizer.requested) != tt.wantRequested {
+				t.Errorf("explainAuthDecisions() authorized %d resources, want %d", len(authorizer.requested), tt.wantRequested)
+			}
+		})
+	}
+}
+
+func TestExplainAuthDecisionsError(t *testing.T) {
+	authorizer := &fakeAuthorizer{err: errors.New("iam unavailable")}
+	resources := []meta.ResourceAttribute{{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update}}}
+	if _, passed, err := explainAuthDecisions(context.Background(), authorizer, meta.UserInfo{}, resources); err == nil || passed {
+		t.Errorf("explainAuthDecisions() want error and not passed, got err: %v, passed: %v", err, passed)
+	}
+}
+
+func TestParseAuthAuditConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]string
+		want   bool
+	}{
+		{"not configured", map[string]string{}, false},
+		{"enabled", map[string]string{"authaudit.denial": "true"}, true},
+		{"disabled", map[string]string{"authaudit.denial": "false"}, false},
+		{"invalid", map[string]string{"authaudit.denial": "yes please"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAuthAuditConfig(tt.config).AuditDenial; got != tt.want {
+				t.Errorf("ParseAuthAuditConfig() AuditDenial = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAuthDenialAuditLog(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPut, "/api/v3/hosts/batch?x=1", nil)
+	attribute := &meta.AuthAttribute{
+		User: meta.UserInfo{UserName: "admin", SupplierAccount: "0"},
+		Resources: []meta.ResourceAttribute{{
+			Basic:      meta.Basic{Type: meta.HostInstance, Action: meta.Update, InstanceID: 5},
+			BusinessID: 3,
+			Layers:     []meta.Item{{Type: meta.Business, InstanceID: 3}},
+		}},
+	}
+
+	denial := newAuthDenialAuditLog(req, attribute, meta.Decision{Reason: "no permission"})
+	if denial.Model != metadata.AuthDenialAuditTarget || denial.OpType != auditoplog.AuditOpTypeAuthDenied || denial.BizID != 3 {
+		t.Errorf("newAuthDenialAuditLog() model: %s, op type: %v, biz: %d", denial.Model, denial.OpType, denial.BizID)
+	}
+	content, ok := denial.Content.(metadata.AuthDenial)
+	if !ok {
+		t.Fatalf("newAuthDenialAuditLog() content is %T", denial.Content)
+	}
+	if content.Method != http.MethodPut || content.Path != "/api/v3/hosts/batch" || content.Reason != "no permission" {
+		t.Errorf("newAuthDenialAuditLog() content: %+v", content)
+	}
+	if len(content.Resources) != 1 || content.Resources[0].ResourceID != 5 || len(content.Resources[0].ParentLayers) != 1 {
+		t.Errorf("newAuthDenialAuditLog() resources: %+v", content.Resources)
+	}
+
+	if denial := newAuthDenialAuditLog(req, &meta.AuthAttribute{}, meta.Decision{}); denial.BizID != 0 {
+		t.Errorf("newAuthDenialAuditLog() without resources biz = %d, want 0", denial.BizID)
+	}
+}
+
+func TestAuditAuthDenialDisabled(t *testing.T) {
+	// the engine is not set, the audit log must not be saved when the denials are not audited.
+	s := &service{authAuditConf: AuthAuditConfig{AuditDenial: false}}
+	req, _ := http.NewRequest(http.MethodGet, "/api/v3/biz/search", nil)
+	s.auditAuthDenial(restful.NewRequest(req), &meta.AuthAttribute{}, meta.Decision{})
+}
+
+func TestAuditAuthDenialQueueFull(t *testing.T) {
+	// no worker drains the queue, the denials beyond its size are dropped instead of blocking the request.
+	s := &service{authAuditConf: AuthAuditConfig{AuditDenial: true}, authDenials: make(chan authDenialAudit, 2)}
+	req, _ := http.NewRequest(http.MethodGet, "/api/v3/biz/search", nil)
+	for i := 0; i < 5; i++ {
+		s.auditAuthDenial(restful.NewRequest(req), &meta.AuthAttribute{}, meta.Decision{})
+	}
+	if len(s.authDenials) != 2 || s.droppedAuthDenials != 3 {
+		t.Errorf("auditAuthDenial() queued %d, dropped %d, want 2 queued and 3 dropped", len(s.authDenials), s.droppedAuthDenials)
+	}
+}
@@ -13,8 +13,12 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"configcenter/src/common"
@@ -55,6 +59,14 @@ func (s *service) URLFilterChan(req *restful.Request, resp *restful.Response, ch
 		return
 	}
 
+	if err := s.checkAPITokenScope(req, kind); err != nil {
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{
+			Msg:     err,
+			ErrCode: common.CCErrAPITokenScopeNotAllowed,
+		})
+		return
+	}
+
 	defer func() {
 		if err != nil {
 			blog.Errorf("proxy request url[%s] failed, err: %v, rid: %s", req.Request.RequestURI, err, rid)
@@ -70,25 +82,7 @@ func (s *service) URLFilterChan(req *restful.Request, resp *restful.Response, ch
 		}
 	}()
 
-	servers := make([]string, 0)
-	switch kind {
-	case TopoType:
-		servers, err = s.discovery.TopoServer().GetServers()
-
-	case ProcType:
-		servers, err = s.discovery.ProcServer().GetServers()
-
-	case EventType:
-		servers, err = s.discovery.EventServer().GetServers()
-
-	case HostType:
-		servers, err = s.discovery.HostServer().GetServers()
-
-	case DataCollectType:
-		servers, err = s.discovery.DataCollect().GetServers()
-
-	}
-
+	servers, err := s.getBackendServers(kind)
 	if err != nil {
 		return
 	}
@@ -103,3 +97,74 @@ func (s *service) URLFilterChan(req *restful.Request, resp *restful.Response, ch
 
 	chain.ProcessFilter(req, resp)
 }
+
+// RateLimitFilter limit the requests of each user and each app code on each route class, the requests
+// exceed the limit are rejected with 429, and the client should retry after the Retry-After seconds.
+// it's a container filter, so that the apis served by the api server itself are limited as well as
+// the ones proxied to the backend servers.
+func (s *service) RateLimitFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	if s.rateLimiter == nil || !s.rateLimiter.Enabled() {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+	class, limited := rateLimitClass(req.Request)
+	if !limited {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+
+	header := req.Request.Header
+	allowed, key, retryAfter := s.rateLimiter.Take(rateLimitUser(header), header.Get(common.BKHTTPRequestAppCode), class)
+	if allowed {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+
+	blog.Warnf("request %s %s exceeds the rate limit of %s, rid: %s", req.Request.Method, req.Request.URL.Path, key, util.GetHTTPCCRequestID(header))
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	resp.AddHeader("Retry-After", strconv.FormatInt(seconds, 10))
+	resp.WriteError(http.StatusTooManyRequests, &metadata.RespError{
+		Msg:     defErr.Error(common.CCErrAPIRequestRateLimited),
+		ErrCode: common.CCErrAPIRequestRateLimited,
+	})
+}
+
+// rateLimitClass decide the route class of a request to the v3 apis. the requests proxied to the
+// backend servers are classified by the backend, and the ones served by the api server itself are
+// classified by the api.
+func rateLimitClass(req *http.Request) (string, bool) {
+	path := req.URL.Path
+	if !strings.HasPrefix(path, rootPath+"/") {
+		return "", false
+	}
+	switch {
+	case path == rootPath+"/graphql":
+		return "graphql", true
+	case path == rootPath+"/openapi.json":
+		return "openapi", true
+	case strings.HasPrefix(path, rootPath+"/auth/"):
+		return "auth", true
+	}
+
+	// the url is rewritten when it matches a backend, so classify with a copy of the request.
+	copied := *req
+	copiedURL := *req.URL
+	copied.URL = &copiedURL
+	kind, _ := URLPath(req.RequestURI).FilterChain(restful.NewRequest(&copied))
+	return string(kind), true
+}
+
+// rateLimitUser the user the request is limited as. the filter runs before the api token is validated,
+// so the requests with an api token are limited by the token instead of the user header.
+func rateLimitUser(header http.Header) string {
+	token := getBearerToken(header)
+	if token == "" {
+		return util.GetUser(header)
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:8])
+}
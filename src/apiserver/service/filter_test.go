@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"configcenter/src/common"
+)
+
+func TestRateLimitClass(t *testing.T) {
+	tests := []struct {
+		method      string
+		path        string
+		wantClass   string
+		wantLimited bool
+	}{
+		{http.MethodPost, "/api/v3/graphql", "graphql", true},
+		{http.MethodGet, "/api/v3/openapi.json", "openapi", true},
+		{http.MethodPost, "/api/v3/auth/explain", "auth", true},
+		{http.MethodPost, "/api/v3/auth/token/revoke", "auth", true},
+		{http.MethodPut, "/api/v3/auth/role/1", "auth", true},
+		{http.MethodPost, "/api/v3/biz/search/0", "topo", true},
+		{http.MethodPost, "/api/v3/hosts/search", "host", true},
+		{http.MethodGet, "/healthz", "", false},
+		{http.MethodGet, "/api/v2/app/getapplist", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.RequestURI = tt.path
+			class, limited := rateLimitClass(req)
+			if class != tt.wantClass || limited != tt.wantLimited {
+				t.Errorf("rateLimitClass() = %s, %v, want %s, %v", class, limited, tt.wantClass, tt.wantLimited)
+			}
+			if req.URL.Path != tt.path || req.RequestURI != tt.path {
+				t.Errorf("rateLimitClass() rewrites the request to %s", req.URL.Path)
+			}
+		})
+	}
+}
+
+func TestRateLimitUser(t *testing.T) {
+	header := make(http.Header)
+	header.Set(common.BKHTTPHeaderUser, "admin")
+	if user := rateLimitUser(header); user != "admin" {
+		t.Errorf("rateLimitUser() = %s, want admin", user)
+	}
+
+	// the user header can be anything before the api token is validated
+	header.Set("Authorization", bearerPrefix+"cc_token")
+	user := rateLimitUser(header)
+	header.Set(common.BKHTTPHeaderUser, "other")
+	if user == "admin" || rateLimitUser(header) != user {
+		t.Errorf("rateLimitUser() of the api token = %s, should not depend on the user header", user)
+	}
+}
@@ -0,0 +1,293 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"configcenter/src/apiserver/graphql"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// graphQLSchemaTTL how long the schema generated from the models is cached, the changes of the
+// models and associations are seen by the graphql queries after this.
+const graphQLSchemaTTL = time.Minute
+
+type cachedGraphQLSchema struct {
+	schema   *graphql.Schema
+	expireAt time.Time
+}
+
+// GraphQL execute a graphql query on the models, instances and associations. the result is in the
+// format of graphql rather than the common response, so that the graphql clients can be used. the
+// instances the user is not authorized to find are filtered out of the result.
+func (s *service) GraphQL(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+
+	input := new(graphql.Request)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("graphql query, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if input.Query == "" {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "query"), ErrCode: common.CCErrCommParamsNeedSet})
+		return
+	}
+
+	ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+	schema, err := s.getGraphQLSchema(ctx, pheader)
+	if err != nil {
+		blog.Errorf("graphql query, but generate the schema failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrTopoObjectSelectFailed)})
+		return
+	}
+
+	resolver := &graphQLResolver{
+		s:      s,
+		header: pheader,
+		rid:    rid,
+		user: meta.UserInfo{
+			UserName:        util.GetUser(pheader),
+			SupplierAccount: util.GetOwnerID(pheader),
+		},
+		decisions: make(map[string]bool),
+	}
+	response := graphql.Execute(ctx, schema, resolver, input)
+	for _, e := range response.Errors {
+		blog.Warnf("graphql query error, path: %v, err: %s, rid: %s", e.Path, e.Message, rid)
+	}
+	resp.WriteAsJson(response)
+}
+
+// getGraphQLSchema get the schema of the supplier account, it's generated from the models and
+// the association definitions read from core service.
+func (s *service) getGraphQLSchema(ctx context.Context, header http.Header) (*graphql.Schema, error) {
+	ownerID := util.GetOwnerID(header)
+	s.graphQLLock.Lock()
+	defer s.graphQLLock.Unlock()
+	if s.graphQLSchemas == nil {
+		s.graphQLSchemas = make(map[string]*cachedGraphQLSchema)
+	}
+	if cached, exist := s.graphQLSchemas[ownerID]; exist && time.Now().Before(cached.expireAt) {
+		return cached.schema, nil
+	}
+
+	models, err := s.engine.CoreAPI.CoreService().Model().ReadModel(ctx, header, &metadata.QueryCondition{})
+	if err != nil {
+		return nil, err
+	}
+	if !models.Result {
+		return nil, errors.New(models.ErrMsg)
+	}
+	associations, err := s.engine.CoreAPI.CoreService().Association().ReadModelAssociation(ctx, header, &metadata.QueryCondition{})
+	if err != nil {
+		return nil, err
+	}
+	if !associations.Result {
+		return nil, errors.New(associations.ErrMsg)
+	}
+
+	schema := graphql.NewSchema(models.Data.Info, associations.Data.Info)
+	s.graphQLSchemas[ownerID] = &cachedGraphQLSchema{schema: schema, expireAt: time.Now().Add(graphQLSchemaTTL)}
+	return schema, nil
+}
+
+// graphQLResolver resolve the graphql queries with core service, the instances are authorized
+// with the same resources as the instance search apis.
+type graphQLResolver struct {
+	s      *service
+	header http.Header
+	rid    string
+	user   meta.UserInfo
+
+	// decisions the authorization decisions made in the request, by the resource and business
+	decisions map[string]bool
+}
+
+func (r *graphQLResolver) FindInstances(ctx context.Context, objID string, query *metadata.QueryCondition) ([]mapstr.MapStr, error) {
+	result, err := r.s.engine.CoreAPI.CoreService().Instance().ReadInstance(ctx, r.header, objID, query)
+	if err != nil {
+		blog.Errorf("graphql find instances of %s failed, err: %v, rid: %s", objID, err, r.rid)
+		return nil, err
+	}
+	if !result.Result {
+		blog.Errorf("graphql find instances of %s failed, err: %s, rid: %s", objID, result.ErrMsg, r.rid)
+		return nil, errors.New(result.ErrMsg)
+	}
+	instances := result.Data.Info
+	if r.s.authorizer.Enabled() && len(instances) > 0 {
+		instances, err = r.filterAuthorized(ctx, objID, instances)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// the protected attributes are redacted in the same way as the instance search apis do
+	if err := r.s.authManager.RedactUnauthorizedInstanceAttributes(ctx, r.header, objID, instances...); err != nil {
+		blog.Errorf("graphql redact the instance attributes of %s failed, err: %v, rid: %s", objID, err, r.rid)
+		return nil, err
+	}
+	return instances, nil
+}
+
+func (r *graphQLResolver) FindAssociatedInstanceIDs(ctx context.Context, asstID string, reverse bool, instIDs []int64) (map[int64][]int64, error) {
+	instField := common.BKInstIDField
+	if reverse {
+		instField = common.BKAsstInstIDField
+	}
+	input := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{
+			common.AssociationObjAsstIDField: asstID,
+			instField:                        mapstr.MapStr{common.BKDBIN: instIDs},
+		},
+		Limit: metadata.SearchLimit{Limit: common.BKNoLimit},
+	}
+	result, err := r.s.engine.CoreAPI.CoreService().Association().ReadInstAssociation(ctx, r.header, input)
+	if err != nil {
+		blog.Errorf("graphql find instance associations of %s failed, err: %v, rid: %s", asstID, err, r.rid)
+		return nil, err
+	}
+	if !result.Result {
+		blog.Errorf("graphql find instance associations of %s failed, err: %s, rid: %s", asstID, result.ErrMsg, r.rid)
+		return nil, errors.New(result.ErrMsg)
+	}
+
+	links := make(map[int64][]int64)
+	for _, asst := range result.Data.Info {
+		if reverse {
+			links[asst.AsstInstID] = append(links[asst.AsstInstID], asst.InstID)
+		} else {
+			links[asst.InstID] = append(links[asst.InstID], asst.AsstInstID)
+		}
+	}
+	return links, nil
+}
+
+func (r *graphQLResolver) FindHostModuleRelations(ctx context.Context, hostIDs, moduleIDs []int64) ([]metadata.ModuleHost, error) {
+	input := &metadata.HostModuleRelationRequest{HostIDArr: hostIDs, ModuleIDArr: moduleIDs}
+	result, err := r.s.engine.CoreAPI.CoreService().Host().GetHostModuleRelation(ctx, r.header, input)
+	if err != nil {
+		blog.Errorf("graphql find host module relations failed, err: %v, rid: %s", err, r.rid)
+		return nil, err
+	}
+	if !result.Result {
+		blog.Errorf("graphql find host module relations failed, err: %s, rid: %s", result.ErrMsg, r.rid)
+		return nil, errors.New(result.ErrMsg)
+	}
+	return result.Data, nil
+}
+
+// filterAuthorized filter out the instances the user is not authorized to find, the instances are
+// authorized by the businesses they belong to, and a host belongs to the businesses of its modules.
+func (r *graphQLResolver) filterAuthorized(ctx context.Context, objID string, instances []mapstr.MapStr) ([]mapstr.MapStr, error) {
+	instBizIDs := make([][]int64, len(instances))
+	if objID == common.BKInnerObjIDHost {
+		hostIDs := make([]int64, 0)
+		for _, inst := range instances {
+			hostID, err := util.GetInt64ByInterface(inst[common.BKHostIDField])
+			if err != nil {
+				return nil, fmt.Errorf("invalid host id %v", inst[common.BKHostIDField])
+			}
+			hostIDs = append(hostIDs, hostID)
+		}
+		relations, err := r.FindHostModuleRelations(ctx, hostIDs, nil)
+		if err != nil {
+			return nil, err
+		}
+		hostBizIDs := make(map[int64][]int64)
+		for _, relation := range relations {
+			hostBizIDs[relation.HostID] = append(hostBizIDs[relation.HostID], relation.AppID)
+		}
+		for i, hostID := range hostIDs {
+			instBizIDs[i] = hostBizIDs[hostID]
+		}
+	} else {
+		for i, inst := range instances {
+			// the instances which are not in a business are authorized as the global resources
+			bizID, _ := util.GetInt64ByInterface(inst[common.BKAppIDField])
+			instBizIDs[i] = []int64{bizID}
+		}
+	}
+
+	// authorize the businesses which are not authorized in the request yet in a batch
+	resources := make([]meta.ResourceAttribute, 0)
+	keys := make([]string, 0)
+	for _, bizIDs := range instBizIDs {
+		for _, bizID := range bizIDs {
+			key := fmt.Sprintf("%s:%d", objID, bizID)
+			if _, exist := r.decisions[key]; exist || util.InStrArr(keys, key) {
+				continue
+			}
+			keys = append(keys, key)
+			resources = append(resources, graphQLResource(r.user.SupplierAccount, objID, bizID))
+		}
+	}
+	if len(resources) > 0 {
+		decisions, err := r.s.authorizer.AuthorizeBatch(ctx, r.user, resources...)
+		if err != nil {
+			blog.Errorf("graphql authorize the instances of %s failed, err: %v, rid: %s", objID, err, r.rid)
+			return nil, err
+		}
+		for i, decision := range decisions {
+			r.decisions[keys[i]] = decision.Authorized
+		}
+	}
+
+	authorized := make([]mapstr.MapStr, 0)
+	for i, inst := range instances {
+		for _, bizID := range instBizIDs[i] {
+			if r.decisions[fmt.Sprintf("%s:%d", objID, bizID)] {
+				authorized = append(authorized, inst)
+				break
+			}
+		}
+	}
+	return authorized, nil
+}
+
+// graphQLResource the resource to authorize for finding the instances of the model in the business
+func graphQLResource(ownerID, objID string, bizID int64) meta.ResourceAttribute {
+	resource := meta.ResourceAttribute{
+		SupplierAccount: ownerID,
+		BusinessID:      bizID,
+		Basic:           meta.Basic{Action: meta.FindMany},
+	}
+	switch objID {
+	case common.BKInnerObjIDApp:
+		resource.Type = meta.Business
+	case common.BKInnerObjIDSet:
+		resource.Type = meta.ModelSet
+	case common.BKInnerObjIDModule:
+		resource.Type = meta.ModelModule
+	case common.BKInnerObjIDHost:
+		resource.Type = meta.HostInstance
+	default:
+		resource.Type = meta.ModelInstance
+		resource.Layers = []meta.Item{{Type: meta.Model, Name: objID}}
+	}
+	return resource
+}
@@ -0,0 +1,160 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/apimachinery/coreservice/instance"
+	"configcenter/src/apimachinery/coreservice/model"
+	"configcenter/src/auth"
+	"configcenter/src/auth/extensions"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// fakeAuthorize the auth manager needs an auth.Authorize, only the authorizer of it is used.
+type fakeAuthorize struct {
+	auth.ResourceHandler
+	*fakeAuthorizer
+}
+
+type fakeClientSet struct {
+	apimachinery.ClientSetInterface
+	core *fakeCoreService
+}
+
+func (c *fakeClientSet) CoreService() coreservice.CoreServiceClientInterface {
+	return c.core
+}
+
+// fakeCoreService serves the instances and the auth protected attributes of a model.
+type fakeCoreService struct {
+	coreservice.CoreServiceClientInterface
+	object     metadata.Object
+	attributes []metadata.Attribute
+	instances  []mapstr.MapStr
+}
+
+func (c *fakeCoreService) Instance() instance.InstanceClientInterface {
+	return &fakeInstanceClient{core: c}
+}
+
+func (c *fakeCoreService) Model() model.ModelClientInterface {
+	return &fakeModelClient{core: c}
+}
+
+type fakeInstanceClient struct {
+	instance.InstanceClientInterface
+	core *fakeCoreService
+}
+
+func (c *fakeInstanceClient) ReadInstance(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (*metadata.QueryConditionResult, error) {
+	result := &metadata.QueryConditionResult{BaseResp: metadata.SuccessBaseResp}
+	for _, inst := range c.core.instances {
+		result.Data.Info = append(result.Data.Info, inst.Clone())
+	}
+	result.Data.Count = len(result.Data.Info)
+	return result, nil
+}
+
+type fakeModelClient struct {
+	model.ModelClientInterface
+	core *fakeCoreService
+}
+
+func (c *fakeModelClient) ReadModel(ctx context.Context, h http.Header, input *metadata.QueryCondition) (*metadata.ReadModelResult, error) {
+	result := &metadata.ReadModelResult{BaseResp: metadata.SuccessBaseResp}
+	result.Data.Info = []metadata.SearchModelInfo{{Spec: c.core.object}}
+	return result, nil
+}
+
+func (c *fakeModelClient) ReadModelAttr(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (*metadata.ReadModelAttrResult, error) {
+	result := &metadata.ReadModelAttrResult{BaseResp: metadata.SuccessBaseResp}
+	result.Data.Info = c.core.attributes
+	return result, nil
+}
+
+func TestGraphQLFindInstancesRedactAttributes(t *testing.T) {
+	core := &fakeCoreService{
+		object: metadata.Object{ID: 20, ObjectID: "switch"},
+		attributes: []metadata.Attribute{
+			{ID: 100, ObjectID: "switch", PropertyID: "admin_password", IsAuthProtected: true},
+		},
+		instances: []mapstr.MapStr{
+			{common.BKInstIDField: int64(1), common.BKInstNameField: "sw-1", "admin_password": "secret"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		denied map[meta.ResourceType]bool
+		want   []mapstr.MapStr
+	}{
+		{
+			name: "protected attribute authorized",
+			want: []mapstr.MapStr{
+				{common.BKInstIDField: int64(1), common.BKInstNameField: "sw-1", "admin_password": "secret"},
+			},
+		},
+		{
+			name:   "protected attribute not authorized",
+			denied: map[meta.ResourceType]bool{meta.ModelInstanceAttribute: true},
+			want: []mapstr.MapStr{
+				{common.BKInstIDField: int64(1), common.BKInstNameField: "sw-1"},
+			},
+		},
+		{
+			name:   "instance not authorized",
+			denied: map[meta.ResourceType]bool{meta.ModelInstance: true},
+			want:   []mapstr.MapStr{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientSet := &fakeClientSet{core: core}
+			authorizer := &fakeAuthorize{fakeAuthorizer: &fakeAuthorizer{denied: tt.denied}}
+			s := &service{
+				engine:      &backbone.Engine{CoreAPI: clientSet},
+				authorizer:  authorizer,
+				authManager: extensions.NewAuthManager(clientSet, authorizer),
+			}
+			header := http.Header{}
+			header.Set(common.BKHTTPHeaderUser, "user1")
+			header.Set(common.BKHTTPOwnerID, common.BKDefaultOwnerID)
+			resolver := &graphQLResolver{
+				s:         s,
+				header:    header,
+				user:      meta.UserInfo{UserName: "user1", SupplierAccount: common.BKDefaultOwnerID},
+				decisions: make(map[string]bool),
+			}
+
+			got, err := resolver.FindInstances(context.Background(), "switch", &metadata.QueryCondition{})
+			if err != nil {
+				t.Fatalf("FindInstances() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindInstances() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/auth/localauth"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// CreateAuthRole create a role of the local authorization
+func (s *service) CreateAuthRole(req *restful.Request, resp *restful.Response) {
+	authorizer, ok := s.checkLocalAuthAdmin(req, resp)
+	if !ok {
+		return
+	}
+	input := new(metadata.AuthRole)
+	if !s.decodeLocalAuthBody(req, resp, input) {
+		return
+	}
+
+	ctx, pheader := s.localAuthContext(req)
+	result, err := s.engine.CoreAPI.CoreService().LocalAuth().CreateAuthRole(ctx, pheader, input)
+	if err != nil {
+		s.writeLocalAuthError(req, resp, "create auth role", err)
+		return
+	}
+	authorizer.Flush()
+	s.writeLocalAuthResult(resp, result.BaseResp, result.Data)
+}
+
+// UpdateAuthRole update a role of the local authorization
+func (s *service) UpdateAuthRole(req *restful.Request, resp *restful.Response) {
+	authorizer, ok := s.checkLocalAuthAdmin(req, resp)
+	if !ok {
+		return
+	}
+	id, ok := s.parseLocalAuthID(req, resp)
+	if !ok {
+		return
+	}
+	input := new(metadata.AuthRole)
+	if !s.decodeLocalAuthBody(req, resp, input) {
+		return
+	}
+
+	ctx, pheader := s.localAuthContext(req)
+	result, err := s.engine.CoreAPI.CoreService().LocalAuth().UpdateAuthRole(ctx, pheader, id, input)
+	if err != nil {
+		s.writeLocalAuthError(req, resp, "update auth role", err)
+		return
+	}
+	authorizer.Flush()
+	s.writeLocalAuthResult(resp, result.BaseResp, result.Data)
+}
+
+// DeleteAuthRole delete a role of the local authorization with all its bindings
+func (s *service) DeleteAuthRole(req *restful.Request, resp *restful.Response) {
+	authorizer, ok := s.checkLocalAuthAdmin(req, resp)
+	if !ok {
+		return
+	}
+	id, ok := s.parseLocalAuthID(req, resp)
+	if !ok {
+		return
+	}
+
+	ctx, pheader := s.localAuthContext(req)
+	result, err := s.engine.CoreAPI.CoreService().LocalAuth().DeleteAuthRole(ctx, pheader, id)
+	if err != nil {
+		s.writeLocalAuthError(req, resp, "delete auth role", err)
+		return
+	}
+	authorizer.Flush()
+	s.writeLocalAuthResult(resp, result.BaseResp, result.Data)
+}
+
+// SearchAuthRoles search the roles of the local authorization
+func (s *service) SearchAuthRoles(req *restful.Request, resp *restful.Response) {
+	if _, ok := s.checkLocalAuthAdmin(req, resp); !ok {
+		return
+	}
+	input := new(metadata.QueryCondition)
+	if !s.decodeLocalAuthBody(req, resp, input) {
+		return
+	}
+
+	ctx, pheader := s.localAuthContext(req)
+	result, err := s.engine.CoreAPI.CoreService().LocalAuth().SearchAuthRoles(ctx, pheader, input)
+	if err != nil {
+		s.writeLocalAuthError(req, resp, "search auth roles", err)
+		return
+	}
+	s.writeLocalAuthResult(resp, result.BaseResp, result.Data)
+}
+
+// CreateAuthRoleBinding bind a role of the local authorization to the users
+func (s *service) CreateAuthRoleBinding(req *restful.Request, resp *restful.Response) {
+	authorizer, ok := s.checkLocalAuthAdmin(req, resp)
+	if !ok {
+		return
+	}
+	input := new(metadata.AuthRoleBinding)
+	if !s.decodeLocalAuthBody(req, resp, input) {
+		return
+	}
+
+	ctx, pheader := s.localAuthContext(req)
+	result, err := s.engine.CoreAPI.CoreService().LocalAuth().CreateAuthRoleBinding(ctx, pheader, input)
+	if err != nil {
+		s.writeLocalAuthError(req, resp, "create auth role binding", err)
+		return
+	}
+	authorizer.Flush()
+	s.writeLocalAuthResult(resp, result.BaseResp, result.Data)
+}
+
+// DeleteAuthRoleBinding delete a role binding of the local authorization
+func (s *service) DeleteAuthRoleBinding(req *restful.Request, resp *restful.Response) {
+	authorizer, ok := s.checkLocalAuthAdmin(req, resp)
+	if !ok {
+		return
+	}
+	id, ok := s.parseLocalAuthID(req, resp)
+	if !ok {
+		return
+	}
+
+	ctx, pheader := s.localAuthContext(req)
+	result, err := s.engine.CoreAPI.CoreService().LocalAuth().DeleteAuthRoleBinding(ctx, pheader, id)
+	if err != nil {
+		s.writeLocalAuthError(req, resp, "delete auth role binding", err)
+		return
+	}
+	authorizer.Flush()
+	s.writeLocalAuthResult(resp, result.BaseResp, result.Data)
+}
+
+// SearchAuthRoleBindings search the role bindings of the local authorization
+func (s *service) SearchAuthRoleBindings(req *restful.Request, resp *restful.Response) {
+	if _, ok := s.checkLocalAuthAdmin(req, resp); !ok {
+		return
+	}
+	input := new(metadata.QueryCondition)
+	if !s.decodeLocalAuthBody(req, resp, input) {
+		return
+	}
+
+	ctx, pheader := s.localAuthContext(req)
+	result, err := s.engine.CoreAPI.CoreService().LocalAuth().SearchAuthRoleBindings(ctx, pheader, input)
+	if err != nil {
+		s.writeLocalAuthError(req, resp, "search auth role bindings", err)
+		return
+	}
+	s.writeLocalAuthResult(resp, result.BaseResp, result.Data)
+}
+
+// SearchUserAuthPermissions get the permissions of a user, a user can always get their own
+// permissions, and only the admins can get the ones of the other users.
+func (s *service) SearchUserAuthPermissions(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	authorizer, ok := s.getLocalAuthorizer(req, resp)
+	if !ok {
+		return
+	}
+	input := new(metadata.GetUserAuthPermissionsRequest)
+	if !s.decodeLocalAuthBody(req, resp, input) {
+		return
+	}
+	user := util.GetUser(pheader)
+	if input.User == "" {
+		input.User = user
+	}
+	if input.User != user && !authorizer.IsAdmin(user) {
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: defErr.Error(common.CCErrCommAuthNotHavePermission), ErrCode: common.CCErrCommAuthNotHavePermission})
+		return
+	}
+
+	ctx, pheader := s.localAuthContext(req)
+	result, err := s.engine.CoreAPI.CoreService().LocalAuth().GetUserAuthPermissions(ctx, pheader, input)
+	if err != nil {
+		s.writeLocalAuthError(req, resp, "search user auth permissions", err)
+		return
+	}
+	s.writeLocalAuthResult(resp, result.BaseResp, result.Data)
+}
+
+// getLocalAuthorizer get the local authorizer, the management apis are not available
+// when the apiserver authorizes with the auth center.
+func (s *service) getLocalAuthorizer(req *restful.Request, resp *restful.Response) (*localauth.Authorizer, bool) {
+	authorizer, ok := s.authorizer.(*localauth.Authorizer)
+	if ok {
+		return authorizer, true
+	}
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrAPILocalAuthNotEnabled), ErrCode: common.CCErrAPILocalAuthNotEnabled})
+	return nil, false
+}
+
+// checkLocalAuthAdmin only the admins of the local authorization can manage the roles and the bindings.
+func (s *service) checkLocalAuthAdmin(req *restful.Request, resp *restful.Response) (*localauth.Authorizer, bool) {
+	authorizer, ok := s.getLocalAuthorizer(req, resp)
+	if !ok {
+		return nil, false
+	}
+	user := util.GetUser(req.Request.Header)
+	if authorizer.IsAdmin(user) {
+		return authorizer, true
+	}
+	blog.Errorf("user %s is not an admin of the local authorization, rid: %s", user, util.GetHTTPCCRequestID(req.Request.Header))
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: defErr.Error(common.CCErrCommAuthNotHavePermission), ErrCode: common.CCErrCommAuthNotHavePermission})
+	return nil, false
+}
+
+func (s *service) parseLocalAuthID(req *restful.Request, resp *restful.Response) (int64, bool) {
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err == nil {
+		return id, true
+	}
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, "id"), ErrCode: common.CCErrCommParamsNeedInt})
+	return 0, false
+}
+
+func (s *service) decodeLocalAuthBody(req *restful.Request, resp *restful.Response, input interface{}) bool {
+	err := json.NewDecoder(req.Request.Body).Decode(input)
+	if err == nil {
+		return true
+	}
+	blog.Errorf("decode local auth request body failed, url: %s, err: %v, rid: %s", req.Request.URL.Path, err, util.GetHTTPCCRequestID(req.Request.Header))
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+	return false
+}
+
+func (s *service) localAuthContext(req *restful.Request) (context.Context, http.Header) {
+	pheader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(pheader)
+	return context.WithValue(context.Background(), common.ContextRequestIDField, rid), pheader
+}
+
+func (s *service) writeLocalAuthError(req *restful.Request, resp *restful.Response, operation string, err error) {
+	blog.Errorf("%s failed, err: %v, rid: %s", operation, err, util.GetHTTPCCRequestID(req.Request.Header))
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+}
+
+func (s *service) writeLocalAuthResult(resp *restful.Response, result metadata.BaseResp, data interface{}) {
+	if !result.Result {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: errors.New(result.ErrMsg), ErrCode: result.Code})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(data))
+}
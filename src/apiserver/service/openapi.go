@@ -0,0 +1,176 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/openapi"
+	"configcenter/src/common/util"
+	"configcenter/src/common/version"
+
+	"github.com/emicklei/go-restful"
+)
+
+// openAPICacheTTL how long the aggregated document is cached, the backend servers change their
+// routes only when they are upgraded.
+const openAPICacheTTL = 5 * time.Minute
+
+// openAPIRewrite a backend path prefix and the public path prefix it's proxied from
+type openAPIRewrite struct {
+	backend string
+	public  string
+}
+
+// openAPIBackends the backend servers whose routes are proxied by the api server, the rewrites are
+// the reverse of the ones in url.go, each candidate public path is checked with the url filter chain,
+// so the routes which are not proxied are dropped.
+var openAPIBackends = []struct {
+	kind     RequestType
+	rewrites []openAPIRewrite
+}{
+	{kind: TopoType, rewrites: []openAPIRewrite{
+		{backend: "/topo/v3/app", public: rootPath + "/biz"},
+		{backend: "/topo/v3/objectattr", public: rootPath + "/object/attr"},
+		{backend: "/topo/v3", public: rootPath},
+	}},
+	{kind: HostType, rewrites: []openAPIRewrite{{backend: "/host/v3", public: rootPath}}},
+	{kind: ProcType, rewrites: []openAPIRewrite{{backend: "/process/v3", public: rootPath + "/proc"}}},
+	{kind: EventType, rewrites: []openAPIRewrite{{backend: "/event/v3", public: rootPath + "/event"}}},
+	{kind: DataCollectType, rewrites: []openAPIRewrite{{backend: "/collector/v3", public: rootPath + "/collector"}}},
+}
+
+// OpenAPISpec serve the OpenAPI document of the apis, which is aggregated from the routes of the
+// api server itself and the ones of the backend servers it proxies requests to.
+func (s *service) OpenAPISpec(req *restful.Request, resp *restful.Response) {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+
+	s.openAPILock.RLock()
+	cached, expire := s.openAPIDoc, s.openAPIExpire
+	s.openAPILock.RUnlock()
+	if cached != nil && time.Now().Before(expire) {
+		resp.WriteAsJson(cached)
+		return
+	}
+
+	// the backend servers are requested without holding the lock, so that a slow backend does
+	// not block the requests which can be served from the cache.
+	doc := openapi.NewDocument("bk-cmdb", version.CCVersion)
+	doc.AddWebService(s.apiWebService, "apiserver")
+	complete := true
+	for _, backend := range openAPIBackends {
+		backendDoc, err := s.getBackendOpenAPIDocument(backend.kind)
+		if err != nil {
+			blog.Errorf("get openapi document of %s server failed, err: %v, rid: %s", backend.kind, err, rid)
+			complete = false
+			continue
+		}
+		for _, item := range backendDoc.Paths {
+			for _, operation := range item {
+				operation.Tags = []string{string(backend.kind)}
+			}
+		}
+		rewrites := backend.rewrites
+		kind := backend.kind
+		doc.Merge(backendDoc, func(routePath string) (string, bool) {
+			return publicAPIPath(kind, rewrites, routePath)
+		})
+	}
+
+	// the document without the apis of some backend servers is not cached, so that they are
+	// included once the servers are available.
+	if complete {
+		s.openAPILock.Lock()
+		s.openAPIDoc = doc
+		s.openAPIExpire = time.Now().Add(openAPICacheTTL)
+		s.openAPILock.Unlock()
+	}
+	resp.WriteAsJson(doc)
+}
+
+func (s *service) getBackendOpenAPIDocument(kind RequestType) (*openapi.Document, error) {
+	servers, err := s.getBackendServers(kind)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("no server is available")
+	}
+
+	httpReq, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(servers[0], "/")+openapi.SpecPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", httpResp.StatusCode)
+	}
+
+	doc := new(openapi.Document)
+	if err := json.NewDecoder(httpResp.Body).Decode(doc); err != nil {
+		return nil, err
+	}
+	if doc.Components.Schemas == nil {
+		doc.Components.Schemas = make(map[string]*openapi.Schema)
+	}
+	return doc, nil
+}
+
+// publicAPIPath get the public path a backend route is proxied from, it returns false if the
+// route is not proxied by the api server.
+func publicAPIPath(kind RequestType, rewrites []openAPIRewrite, backendPath string) (string, bool) {
+	for _, rewrite := range rewrites {
+		if backendPath != rewrite.backend && !strings.HasPrefix(backendPath, rewrite.backend+"/") {
+			continue
+		}
+		publicPath := rewrite.public + strings.TrimPrefix(backendPath, rewrite.backend)
+		httpReq, err := http.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+		if err != nil {
+			return "", false
+		}
+		httpReq.URL.Path = publicPath
+		httpReq.RequestURI = publicPath
+		proxied, err := URLPath(publicPath).FilterChain(restful.NewRequest(httpReq))
+		if err == nil && proxied == kind && httpReq.URL.Path == backendPath {
+			return publicPath, true
+		}
+	}
+	return "", false
+}
+
+// getBackendServers get the servers of the backend which the requests of the kind are proxied to
+func (s *service) getBackendServers(kind RequestType) ([]string, error) {
+	switch kind {
+	case TopoType:
+		return s.discovery.TopoServer().GetServers()
+	case ProcType:
+		return s.discovery.ProcServer().GetServers()
+	case EventType:
+		return s.discovery.EventServer().GetServers()
+	case HostType:
+		return s.discovery.HostServer().GetServers()
+	case DataCollectType:
+		return s.discovery.DataCollect().GetServers()
+	}
+	return nil, fmt.Errorf("unknown request type %s", kind)
+}
@@ -13,17 +13,25 @@
 package service
 
 import (
+	"strings"
+	"sync"
+	"time"
+
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/apiserver/core"
 	compatiblev2 "configcenter/src/apiserver/core/compatiblev2/service"
+	"configcenter/src/apiserver/graphql"
+	"configcenter/src/apiserver/ratelimit"
 	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/extensions"
 	"configcenter/src/auth/parser"
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/util"
 
@@ -33,7 +41,8 @@ import (
 // Service service methods
 type Service interface {
 	WebServices(auth authcenter.AuthConfig) []*restful.WebService
-	SetConfig(enableAuth bool, engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize)
+	SetConfig(enableAuth bool, engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize, tokenConf APITokenConfig, authAuditConf AuthAuditConfig, rateLimiter *ratelimit.RateLimiter)
+	RateLimitFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain)
 }
 
 // NewService create a new service instance
@@ -50,15 +59,43 @@ type service struct {
 	core       core.Core
 	discovery  discovery.DiscoveryInterface
 	authorizer auth.Authorizer
+	tokenConf  APITokenConfig
+	// authManager works to redact the protected instance attributes of the graphql results
+	authManager *extensions.AuthManager
+
+	authAuditConf AuthAuditConfig
+	rateLimiter   *ratelimit.RateLimiter
+
+	// the denial audit logs waiting to be saved, and the count of the ones dropped when it's full
+	authDenials        chan authDenialAudit
+	droppedAuthDenials uint64
+
+	// the web service of the api server, and the cached openapi document of all the apis
+	apiWebService *restful.WebService
+	openAPILock   sync.RWMutex
+	openAPIDoc    *openapi.Document
+	openAPIExpire time.Time
+
+	// the graphql schemas generated from the models, by the supplier account
+	graphQLLock    sync.Mutex
+	graphQLSchemas map[string]*cachedGraphQLSchema
 }
 
-func (s *service) SetConfig(enableAuth bool, engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize) {
+func (s *service) SetConfig(enableAuth bool, engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize, tokenConf APITokenConfig, authAuditConf AuthAuditConfig, rateLimiter *ratelimit.RateLimiter) {
 	s.enableAuth = enableAuth
 	s.engine = engine
 	s.client = httpClient
 	s.discovery = discovery
 	s.core.CompatibleV2Operation().SetConfig(engine)
 	s.authorizer = authorize
+	s.authManager = extensions.NewAuthManager(engine.CoreAPI, authorize)
+	s.tokenConf = tokenConf
+	s.authAuditConf = authAuditConf
+	s.rateLimiter = rateLimiter
+	if authAuditConf.AuditDenial && s.authDenials == nil {
+		s.authDenials = make(chan authDenialAudit, authDenialQueueSize)
+		go s.runAuthDenialAudit()
+	}
 }
 
 func (s *service) WebServices(auth authcenter.AuthConfig) []*restful.WebService {
@@ -68,17 +105,35 @@ func (s *service) WebServices(auth authcenter.AuthConfig) []*restful.WebService
 
 	ws := &restful.WebService{}
 	ws.Path(rootPath).Filter(rdapi.AllGlobalFilter(getErrFun)).Produces(restful.MIME_JSON)
+	ws.Filter(s.apiTokenFilter(getErrFun))
+	ws.Filter(s.localAPITokenScopeFilter)
 	if s.authorizer.Enabled() == true {
 		ws.Filter(s.authFilter(getErrFun))
 	}
 	ws.Route(ws.POST("/auth/verify").To(s.AuthVerify))
 	ws.Route(ws.GET("/auth/business-list").To(s.GetAnyAuthorizedAppList))
 	ws.Route(ws.GET("/auth/admin-entrance").To(s.GetAdminEntrance))
+	ws.Route(ws.POST("/auth/explain").To(s.ExplainAuth).Reads(metadata.AuthExplainRequest{}))
+	ws.Route(ws.GET("/openapi.json").To(s.OpenAPISpec))
+	ws.Route(ws.POST("/graphql").To(s.GraphQL).Reads(graphql.Request{}))
+	ws.Route(ws.POST("/auth/token").To(s.CreateAPIToken).Reads(metadata.CreateAPITokenRequest{}).Writes(metadata.CreateAPITokenResult{}))
+	ws.Route(ws.POST("/auth/token/search").To(s.SearchAPITokens).Reads(metadata.QueryCondition{}).Writes(metadata.SearchAPITokensResult{}))
+	ws.Route(ws.POST("/auth/token/revoke").To(s.RevokeAPITokens).Reads(metadata.RevokeAPITokenRequest{}))
+	ws.Route(ws.POST("/auth/role").To(s.CreateAuthRole).Reads(metadata.AuthRole{}).Writes(metadata.CreatedOneOptionResult{}))
+	ws.Route(ws.PUT("/auth/role/{id}").To(s.UpdateAuthRole).Reads(metadata.AuthRole{}).Writes(metadata.UpdatedOptionResult{}))
+	ws.Route(ws.DELETE("/auth/role/{id}").To(s.DeleteAuthRole).Writes(metadata.DeletedOptionResult{}))
+	ws.Route(ws.POST("/auth/role/search").To(s.SearchAuthRoles).Reads(metadata.QueryCondition{}).Writes(metadata.SearchAuthRolesResult{}))
+	ws.Route(ws.POST("/auth/rolebinding").To(s.CreateAuthRoleBinding).Reads(metadata.AuthRoleBinding{}).Writes(metadata.CreatedOneOptionResult{}))
+	ws.Route(ws.DELETE("/auth/rolebinding/{id}").To(s.DeleteAuthRoleBinding).Writes(metadata.DeletedOptionResult{}))
+	ws.Route(ws.POST("/auth/rolebinding/search").To(s.SearchAuthRoleBindings).Reads(metadata.QueryCondition{}).Writes(metadata.SearchAuthRoleBindingsResult{}))
+	ws.Route(ws.POST("/auth/permission/search").To(s.SearchUserAuthPermissions).Reads(metadata.GetUserAuthPermissionsRequest{}).Writes(metadata.GetUserAuthPermissionsResult{}))
 	ws.Route(ws.GET("{.*}").Filter(s.URLFilterChan).To(s.Get))
 	ws.Route(ws.POST("{.*}").Filter(s.URLFilterChan).To(s.Post))
 	ws.Route(ws.PUT("{.*}").Filter(s.URLFilterChan).To(s.Put))
 	ws.Route(ws.DELETE("{.*}").Filter(s.URLFilterChan).To(s.Delete))
 
+	s.apiWebService = ws
+
 	allWebServices := make([]*restful.WebService, 0)
 	allWebServices = append(allWebServices, ws, s.core.CompatibleV2Operation().WebService())
 	allWebServices = append(allWebServices, s.V3Healthz())
@@ -110,6 +165,29 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 			fchain.ProcessFilter(req, resp)
 			return
 		}
+		if path == "/api/v3/openapi.json" {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+		// the graphql queries authorize the instances they find by themselves.
+		if path == "/api/v3/graphql" {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+		if path == "/api/v3/auth/explain" {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+		// a user can always manage their own api tokens.
+		if strings.HasPrefix(path, "/api/v3/auth/token") {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+		// the local authorization apis check the admins by themselves.
+		if strings.HasPrefix(path, "/api/v3/auth/role") || strings.HasPrefix(path, "/api/v3/auth/permission") {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
 
 		// if common.BKSuperOwnerID == util.GetOwnerID(req.Request.Header) {
 		// 	blog.Errorf("authFilter failed, can not use super supplier account, rid: %s", rid)
@@ -148,7 +226,7 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 			return
 		}
 
-		blog.V(7).Infof("auth filter parse attribute result: %s, rid: %s", attribute, rid)
+		blog.V(7).Infof("auth filter parse attribute result: %+v, rid: %s", attribute, rid)
 		decision, err := s.authorizer.Authorize(req.Request.Context(), attribute)
 		if err != nil {
 			blog.Errorf("authFilter failed, authorized request failed, url: %s, err: %v, rid: %s", path, err, rid)
@@ -173,6 +251,7 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 				return
 			}
 			blog.Warnf("authFilter failed, url: %s, reason: %s, rid: %s", path, decision.Reason, rid)
+			s.auditAuthDenial(req, attribute, decision)
 			rsp := metadata.BaseResp{
 				Code:        9900403,
 				ErrMsg:      errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommAuthNotHavePermission).Error(),
@@ -63,6 +63,9 @@ func (u *URLPath) WithTopo(req *restful.Request) (isHit bool) {
 	case strings.HasPrefix(string(*u), rootPath+"/module/"):
 		from, to, isHit = rootPath, topoRoot, true
 
+	case strings.HasPrefix(string(*u), rootPath+"/recyclebin/"):
+		from, to, isHit = rootPath, topoRoot, true
+
 		// Attention:
 		// do not change the check sequences.
 	case string(*u) == rootPath+"/object":
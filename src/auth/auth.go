@@ -16,8 +16,10 @@ import (
 	"context"
 	"errors"
 
+	"configcenter/src/apimachinery/coreservice"
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/localauth"
 	"configcenter/src/auth/meta"
 )
 
@@ -68,3 +70,9 @@ type ResourceHandler interface {
 func NewAuthorize(tls *util.TLSClientConfig, authConfig authcenter.AuthConfig) (Authorize, error) {
 	return authcenter.NewAuthCenter(tls, authConfig)
 }
+
+// NewLocalAuthorize is used to initialized a Authorize instance interface which authorize
+// the requests with the roles stored in cmdb, it's used when the auth center is not available.
+func NewLocalAuthorize(client coreservice.CoreServiceClientInterface, authConfig authcenter.AuthConfig) Authorize {
+	return localauth.NewAuthorizer(client, authConfig.LocalAdmins)
+}
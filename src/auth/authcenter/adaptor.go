@@ -67,6 +67,13 @@ func convertResourceType(resourceType meta.ResourceType, businessID int64) (*Res
 	case meta.MainlineModel, meta.ModelTopology:
 		iamResourceType = SysSystemBase
 
+	case meta.ModelInstanceAttribute:
+		if businessID > 0 {
+			iamResourceType = BizInstanceAttribute
+		} else {
+			iamResourceType = SysInstanceAttribute
+		}
+
 	case meta.ModelClassification:
 		if businessID > 0 {
 			iamResourceType = BizModelGroup
@@ -143,6 +150,8 @@ const (
 	SysInstance         ResourceTypeID = "sys_instance"
 	SysAssociationType  ResourceTypeID = "sys_association_type"
 	SysAuditLog         ResourceTypeID = "sys_audit_log"
+	// the protected attribute of a model's instance
+	SysInstanceAttribute ResourceTypeID = "sys_instance_attribute"
 )
 
 // Business Resource
@@ -156,6 +165,8 @@ const (
 	BizModel           ResourceTypeID = "biz_model"
 	BizInstance        ResourceTypeID = "biz_instance"
 	BizAuditLog        ResourceTypeID = "biz_audit_log"
+	// the protected attribute of a business model's instance
+	BizInstanceAttribute ResourceTypeID = "biz_instance_attribute"
 )
 
 const (
@@ -163,25 +174,27 @@ const (
 )
 
 var ResourceTypeIDMap = map[ResourceTypeID]string{
-	SysSystemBase:       "系统基础",
-	SysBusinessInstance: "业务",
-	SysHostInstance:     "主机",
-	SysEventPushing:     "事件推送",
-	SysModelGroup:       "模型分级",
-	SysModel:            "模型",
-	SysInstance:         "实例",
-	SysAssociationType:  "关联类型",
-	SysAuditLog:         "操作审计",
-	BizCustomQuery:      "动态分组",
-	BizHostInstance:     "业务主机",
-	BizProcessInstance:  "进程",
+	SysSystemBase:        "系统基础",
+	SysBusinessInstance:  "业务",
+	SysHostInstance:      "主机",
+	SysEventPushing:      "事件推送",
+	SysModelGroup:        "模型分级",
+	SysModel:             "模型",
+	SysInstance:          "实例",
+	SysAssociationType:   "关联类型",
+	SysAuditLog:          "操作审计",
+	SysInstanceAttribute: "实例字段",
+	BizCustomQuery:       "动态分组",
+	BizHostInstance:      "业务主机",
+	BizProcessInstance:   "进程",
 	// TODO: delete this when upgrade to v3.5.x
-	BizTopology:   "拓扑",
-	BizModelGroup: "模型分组",
-	BizModel:      "模型",
-	BizInstance:   "实例",
-	BizAuditLog:   "操作审计",
-	UserCustom:    "",
+	BizTopology:          "拓扑",
+	BizModelGroup:        "模型分组",
+	BizModel:             "模型",
+	BizInstance:          "实例",
+	BizAuditLog:          "操作审计",
+	BizInstanceAttribute: "实例字段",
+	UserCustom:           "",
 }
 
 type ActionID string
@@ -37,10 +37,32 @@ const (
 	cmdbUserID             string = "system"
 )
 
+const (
+	// BackendAuthCenter authorize the requests with blueking's auth center
+	BackendAuthCenter = "authcenter"
+	// BackendLocal authorize the requests with the roles stored in cmdb, the auth center
+	// is disabled with this backend, so the resources are not registered to it.
+	BackendLocal = "local"
+)
+
 // ParseConfigFromKV returns a new config
 func ParseConfigFromKV(prefix string, configmap map[string]string) (AuthConfig, error) {
 	var err error
 	var cfg AuthConfig
+
+	if configmap[prefix+".backend"] == BackendLocal {
+		cfg.Backend = BackendLocal
+		cfg.LocalAdmins = make([]string, 0)
+		for _, admin := range strings.Split(configmap[prefix+".admins"], ",") {
+			if admin = strings.TrimSpace(admin); admin != "" {
+				cfg.LocalAdmins = append(cfg.LocalAdmins, admin)
+			}
+		}
+		if len(cfg.LocalAdmins) == 0 {
+			return cfg, errors.New(`missing "admins" configuration for local authorization`)
+		}
+		return cfg, nil
+	}
 	enable, exist := configmap[prefix+".enable"]
 	if !exist {
 		return AuthConfig{}, nil
@@ -53,6 +53,8 @@ func GenerateResourceID(resourceType ResourceTypeID, attribute *meta.ResourceAtt
 		return modelAttributeGroupResourceID(resourceType, attribute)
 	case meta.ModelAttribute:
 		return modelAttributeResourceID(resourceType, attribute)
+	case meta.ModelInstanceAttribute:
+		return modelInstanceAttributeResourceID(resourceType, attribute)
 	case meta.ModelUnique:
 		return modelUniqueResourceID(resourceType, attribute)
 	case meta.UserCustom:
@@ -257,6 +259,33 @@ func modelAttributeResourceID(resourceType ResourceTypeID, attribute *meta.Resou
 	return []RscTypeAndID{id}, nil
 }
 
+// generate protected instance attribute resource id, the last layer is the model
+// that this attribute belongs to.
+func modelInstanceAttributeResourceID(resourceType ResourceTypeID, attribute *meta.ResourceAttribute) ([]RscTypeAndID, error) {
+	if len(attribute.Layers) < 1 {
+		return nil, NotEnoughLayer
+	}
+
+	modelType := SysModel
+	if attribute.BusinessID > 0 {
+		modelType = BizModel
+	}
+
+	ids := []RscTypeAndID{
+		{
+			ResourceType: modelType,
+			ResourceID:   strconv.FormatInt(attribute.Layers[len(attribute.Layers)-1].InstanceID, 10),
+		},
+	}
+	if attribute.InstanceID > 0 {
+		ids = append(ids, RscTypeAndID{
+			ResourceType: resourceType,
+			ResourceID:   strconv.FormatInt(attribute.InstanceID, 10),
+		})
+	}
+	return ids, nil
+}
+
 func modelUniqueResourceID(resourceType ResourceTypeID, attribute *meta.ResourceAttribute) ([]RscTypeAndID, error) {
 	if len(attribute.Layers) < 1 {
 		return nil, NotEnoughLayer
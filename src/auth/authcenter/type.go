@@ -34,6 +34,10 @@ type AuthConfig struct {
 	Enable bool
 	// enable sync auth data to iam
 	EnableSync bool
+	// the backend to authorize the requests, it's BackendAuthCenter by default.
+	Backend string
+	// the users who have all the permissions when the backend is BackendLocal.
+	LocalAdmins []string
 }
 
 type RegisterInfo struct {
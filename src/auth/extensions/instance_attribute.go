@@ -0,0 +1,310 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extensions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/meta"
+	"configcenter/src/auth/parser"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+/*
+ * instance attribute represent the value of a protected attribute on model's instances,
+ * which works for field level read/write authorization.
+ */
+
+// collectAuthProtectedAttributes get all the attributes of a model which instance's value is auth protected.
+func (am *AuthManager) collectAuthProtectedAttributes(ctx context.Context, header http.Header, objectID string) ([]metadata.Attribute, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	cond := condition.CreateCondition()
+	cond.Field(common.BKObjIDField).Eq(objectID)
+	cond.Field(metadata.AttributeFieldIsAuthProtected).Eq(true)
+	queryCond := &metadata.QueryCondition{
+		Condition: cond.ToMapStr(),
+		Limit:     metadata.SearchLimit{Limit: common.BKNoLimit},
+	}
+	resp, err := am.clientSet.CoreService().Model().ReadModelAttr(ctx, header, objectID, queryCond)
+	if err != nil {
+		blog.Errorf("get auth protected attributes of model %s failed, err: %+v, rid: %s", objectID, err, rid)
+		return nil, fmt.Errorf("get auth protected attributes of model %s failed, err: %+v", objectID, err)
+	}
+	if resp.Result == false {
+		blog.Errorf("get auth protected attributes of model %s failed, err: %s, rid: %s", objectID, resp.ErrMsg, rid)
+		return nil, fmt.Errorf("get auth protected attributes of model %s failed, err: %s", objectID, resp.ErrMsg)
+	}
+
+	return resp.Data.Info, nil
+}
+
+// filterWrittenAttributes returns the attributes whose value is set in any of the datas.
+func filterWrittenAttributes(attributes []metadata.Attribute, datas ...mapstr.MapStr) []metadata.Attribute {
+	written := make([]metadata.Attribute, 0)
+	for _, attribute := range attributes {
+		for _, data := range datas {
+			if data.Exists(attribute.PropertyID) {
+				written = append(written, attribute)
+				break
+			}
+		}
+	}
+	return written
+}
+
+func (am *AuthManager) makeResourcesByInstanceAttributes(ctx context.Context, header http.Header, action meta.Action, objectID string, attributes ...metadata.Attribute) ([]meta.ResourceAttribute, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	businessID, err := am.ExtractBusinessIDFromModelAttributes(attributes...)
+	if err != nil {
+		return nil, fmt.Errorf("extract business id from model attribute failed, err: %+v", err)
+	}
+
+	objects, err := am.collectObjectsByObjectIDs(ctx, header, businessID, objectID)
+	if err != nil {
+		blog.Errorf("make instance attribute resources failed, get model %s failed, err: %+v, rid: %s", objectID, err, rid)
+		return nil, fmt.Errorf("get model %s failed, err: %+v", objectID, err)
+	}
+	object := objects[0]
+
+	resources := make([]meta.ResourceAttribute, 0)
+	for _, attribute := range attributes {
+		resource := meta.ResourceAttribute{
+			Basic: meta.Basic{
+				Action:     action,
+				Type:       meta.ModelInstanceAttribute,
+				Name:       attribute.PropertyID,
+				InstanceID: attribute.ID,
+			},
+			SupplierAccount: util.GetOwnerID(header),
+			BusinessID:      businessID,
+			Layers: meta.Layers{
+				{
+					Type:       meta.Model,
+					Name:       object.ObjectID,
+					InstanceID: object.ID,
+				},
+			},
+		}
+		resources = append(resources, resource)
+	}
+
+	blog.V(9).Infof("makeResourcesByInstanceAttributes output: %+v, rid: %s", resources, rid)
+	return resources, nil
+}
+
+// AuthorizeInstanceAttributeUpdate check if the user has the authority to write all the protected attribute
+// which is contained in the instance data to be created or updated.
+func (am *AuthManager) AuthorizeInstanceAttributeUpdate(ctx context.Context, header http.Header, objectID string, datas ...mapstr.MapStr) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	if am.Enabled() == false {
+		return nil
+	}
+
+	attributes, err := am.collectAuthProtectedAttributes(ctx, header, objectID)
+	if err != nil {
+		return err
+	}
+
+	// only the protected attributes which are written in this time should be authorized.
+	written := filterWrittenAttributes(attributes, datas...)
+	if len(written) == 0 {
+		return nil
+	}
+
+	resources, err := am.makeResourcesByInstanceAttributes(ctx, header, meta.Update, objectID, written...)
+	if err != nil {
+		blog.Errorf("AuthorizeInstanceAttributeUpdate failed, make resources failed, model: %s, err: %+v, rid: %s", objectID, err, rid)
+		return fmt.Errorf("make instance attribute resources failed, err: %+v", err)
+	}
+
+	return am.batchAuthorize(ctx, header, resources...)
+}
+
+// RedactUnauthorizedInstanceAttributes remove the value of the protected attributes which the user
+// has no authority to read from the instances, the instances is modified in place.
+func (am *AuthManager) RedactUnauthorizedInstanceAttributes(ctx context.Context, header http.Header, objectID string, instances ...mapstr.MapStr) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	if am.Enabled() == false {
+		return nil
+	}
+
+	if len(instances) == 0 {
+		return nil
+	}
+
+	attributes, err := am.collectAuthProtectedAttributes(ctx, header, objectID)
+	if err != nil {
+		return err
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	resources, err := am.makeResourcesByInstanceAttributes(ctx, header, meta.Find, objectID, attributes...)
+	if err != nil {
+		blog.Errorf("RedactUnauthorizedInstanceAttributes failed, make resources failed, model: %s, err: %+v, rid: %s", objectID, err, rid)
+		return fmt.Errorf("make instance attribute resources failed, err: %+v", err)
+	}
+
+	commonInfo, err := parser.ParseCommonInfo(&header)
+	if err != nil {
+		return fmt.Errorf("authentication failed, parse user info from header failed, err: %+v", err)
+	}
+	decisions, err := am.Authorize.AuthorizeBatch(ctx, commonInfo.User, resources...)
+	if err != nil {
+		return fmt.Errorf("authorize failed, err: %+v", err)
+	}
+	if len(decisions) != len(resources) {
+		return fmt.Errorf("authorize failed, get %d decisions with %d resources", len(decisions), len(resources))
+	}
+
+	redactInstanceAttributes(attributes, decisions, instances...)
+	return nil
+}
+
+// redactInstanceAttributes remove the value of the attributes which is not authorized from the instances,
+// the decisions is one to one with the attributes.
+func redactInstanceAttributes(attributes []metadata.Attribute, decisions []meta.Decision, instances ...mapstr.MapStr) {
+	for idx, decision := range decisions {
+		if decision.Authorized {
+			continue
+		}
+		for _, instance := range instances {
+			instance.Remove(attributes[idx].PropertyID)
+		}
+	}
+}
+
+// GenInstanceAttributeNoPermissionResp generate the no permission response for writing protected attributes.
+func (am *AuthManager) GenInstanceAttributeNoPermissionResp(ctx context.Context, header http.Header, objectID string, datas ...mapstr.MapStr) (*metadata.BaseResp, error) {
+	attributes, err := am.collectAuthProtectedAttributes(ctx, header, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	written := filterWrittenAttributes(attributes, datas...)
+
+	resources, err := am.makeResourcesByInstanceAttributes(ctx, header, meta.Update, objectID, written...)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := authcenter.AdoptPermissions(resources)
+	if err != nil {
+		return nil, err
+	}
+	resp := metadata.NewNoPermissionResp(permissions)
+	return &resp, nil
+}
+
+// RegisterInstanceAttributes register the auth protected attributes as a resource, so that
+// the field level authority can be granted in auth center.
+func (am *AuthManager) RegisterInstanceAttributes(ctx context.Context, header http.Header, attributes ...metadata.Attribute) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	protectedObjects := make(map[string][]metadata.Attribute)
+	for _, attribute := range attributes {
+		if attribute.IsAuthProtected {
+			protectedObjects[attribute.ObjectID] = append(protectedObjects[attribute.ObjectID], attribute)
+		}
+	}
+
+	for objectID, protected := range protectedObjects {
+		resources, err := am.makeResourcesByInstanceAttributes(ctx, header, meta.EmptyAction, objectID, protected...)
+		if err != nil {
+			return fmt.Errorf("register instance attribute failed, err: %+v", err)
+		}
+		if err := am.Authorize.RegisterResource(ctx, resources...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeregisterInstanceAttributes deregister the auth protected attributes from auth center.
+func (am *AuthManager) DeregisterInstanceAttributes(ctx context.Context, header http.Header, attributes ...metadata.Attribute) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	protectedObjects := make(map[string][]metadata.Attribute)
+	for _, attribute := range attributes {
+		if attribute.IsAuthProtected {
+			protectedObjects[attribute.ObjectID] = append(protectedObjects[attribute.ObjectID], attribute)
+		}
+	}
+
+	for objectID, protected := range protectedObjects {
+		resources, err := am.makeResourcesByInstanceAttributes(ctx, header, meta.EmptyAction, objectID, protected...)
+		if err != nil {
+			return fmt.Errorf("deregister instance attribute failed, err: %+v", err)
+		}
+		if err := am.Authorize.DeregisterResource(ctx, resources...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterInstanceAttributesByID register the attributes by attribute id, only the auth protected
+// ones will be registered.
+func (am *AuthManager) RegisterInstanceAttributesByID(ctx context.Context, header http.Header, attributeIDs ...int64) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	if len(attributeIDs) == 0 {
+		return nil
+	}
+
+	attributes, err := am.collectAttributesByAttributeIDs(ctx, header, attributeIDs...)
+	if err != nil {
+		return fmt.Errorf("register instance attribute failed, get attribute by id failed, err: %+v", err)
+	}
+	return am.RegisterInstanceAttributes(ctx, header, attributes...)
+}
+
+// DeregisterInstanceAttributesByID deregister the auth protected attributes by attribute id.
+func (am *AuthManager) DeregisterInstanceAttributesByID(ctx context.Context, header http.Header, attributeIDs ...int64) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	if len(attributeIDs) == 0 {
+		return nil
+	}
+
+	attributes, err := am.collectAttributesByAttributeIDs(ctx, header, attributeIDs...)
+	if err != nil {
+		return fmt.Errorf("deregister instance attribute failed, get attribute by id failed, err: %+v", err)
+	}
+	return am.DeregisterInstanceAttributes(ctx, header, attributes...)
+}
@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extensions
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestRedactInstanceAttributes(t *testing.T) {
+	attributes := []metadata.Attribute{
+		{PropertyID: "bk_password", IsAuthProtected: true},
+		{PropertyID: "bk_secret", IsAuthProtected: true},
+	}
+
+	tests := []struct {
+		name      string
+		decisions []meta.Decision
+		want      []mapstr.MapStr
+	}{
+		{
+			name:      "all authorized",
+			decisions: []meta.Decision{{Authorized: true}, {Authorized: true}},
+			want: []mapstr.MapStr{
+				{"bk_inst_name": "a", "bk_password": "p1", "bk_secret": "s1"},
+				{"bk_inst_name": "b", "bk_password": "p2"},
+			},
+		},
+		{
+			name:      "part authorized",
+			decisions: []meta.Decision{{Authorized: false}, {Authorized: true}},
+			want: []mapstr.MapStr{
+				{"bk_inst_name": "a", "bk_secret": "s1"},
+				{"bk_inst_name": "b"},
+			},
+		},
+		{
+			name:      "none authorized",
+			decisions: []meta.Decision{{Authorized: false}, {Authorized: false}},
+			want: []mapstr.MapStr{
+				{"bk_inst_name": "a"},
+				{"bk_inst_name": "b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances := []mapstr.MapStr{
+				{"bk_inst_name": "a", "bk_password": "p1", "bk_secret": "s1"},
+				{"bk_inst_name": "b", "bk_password": "p2"},
+			}
+			redactInstanceAttributes(attributes, tt.decisions, instances...)
+			if !reflect.DeepEqual(instances, tt.want) {
+				t.Errorf("redactInstanceAttributes() = %v, want %v", instances, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterWrittenAttributes(t *testing.T) {
+	attributes := []metadata.Attribute{
+		{PropertyID: "bk_password", IsAuthProtected: true},
+		{PropertyID: "bk_secret", IsAuthProtected: true},
+	}
+
+	tests := []struct {
+		name  string
+		datas []mapstr.MapStr
+		want  []string
+	}{
+		{
+			name:  "no protected attribute written",
+			datas: []mapstr.MapStr{{"bk_inst_name": "a"}},
+			want:  []string{},
+		},
+		{
+			name:  "written in one of the datas",
+			datas: []mapstr.MapStr{{"bk_inst_name": "a"}, {"bk_secret": "s"}},
+			want:  []string{"bk_secret"},
+		},
+		{
+			name:  "written in all the datas",
+			datas: []mapstr.MapStr{{"bk_password": "p", "bk_secret": "s"}, {"bk_password": "p"}},
+			want:  []string{"bk_password", "bk_secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, attribute := range filterWrittenAttributes(attributes, tt.datas...) {
+				got = append(got, attribute.PropertyID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterWrittenAttributes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -242,6 +242,25 @@ func (am *AuthManager) AuthorizeResourceCreate(ctx context.Context, header http.
 	return am.authorize(ctx, header, businessID, resource)
 }
 
+// AuthorizeResourceDelete check the delete authority of the resource type, which works for the
+// resources that are not registered any more, such as the instances in recycle bin.
+func (am *AuthManager) AuthorizeResourceDelete(ctx context.Context, header http.Header, businessID int64, resourceType meta.ResourceType) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	resource := meta.ResourceAttribute{
+		Basic: meta.Basic{
+			Type:   resourceType,
+			Action: meta.Delete,
+		},
+		SupplierAccount: util.GetOwnerID(header),
+		BusinessID:      businessID,
+	}
+
+	return am.authorize(ctx, header, businessID, resource)
+}
+
 func (am *AuthManager) RegisterObject(ctx context.Context, header http.Header, objects ...metadata.Object) error {
 	if am.Enabled() == false {
 		return nil
@@ -0,0 +1,406 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package localauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// permissionTTL how long the permissions of a user are cached, the changes of the roles
+// take effect on the other apiserver instances after this.
+const permissionTTL = 30 * time.Second
+
+// permissionCacheSize the max number of users whose permissions are cached, so that the
+// cache does not grow with every user who ever sent a request.
+const permissionCacheSize = 1024
+
+// pageSize the number of the businesses or the models read from core service at a time.
+const pageSize = 500
+
+type cachedPermissions struct {
+	permissions []metadata.AuthPermission
+	expireAt    time.Time
+}
+
+// Authorizer authorize the requests with the role bindings stored in cmdb, it's used
+// when blueking's auth center is not available. the resources are not registered to
+// anywhere, so the resource handler methods do nothing.
+type Authorizer struct {
+	client coreservice.CoreServiceClientInterface
+	admins []string
+
+	lock  sync.RWMutex
+	cache map[string]*cachedPermissions
+}
+
+// NewAuthorizer create a local authorizer, the admins have all the permissions.
+func NewAuthorizer(client coreservice.CoreServiceClientInterface, admins []string) *Authorizer {
+	return &Authorizer{
+		client: client,
+		admins: admins,
+		cache:  make(map[string]*cachedPermissions),
+	}
+}
+
+// IsAdmin check whether the user is an admin of the local authorization
+func (a *Authorizer) IsAdmin(user string) bool {
+	return util.InStrArr(a.admins, user)
+}
+
+// Flush drop the cached permissions, so that the changes of the roles take effect at once.
+func (a *Authorizer) Flush() {
+	a.lock.Lock()
+	a.cache = make(map[string]*cachedPermissions)
+	a.lock.Unlock()
+}
+
+func (a *Authorizer) Enabled() bool {
+	return true
+}
+
+func (a *Authorizer) Authorize(ctx context.Context, attribute *meta.AuthAttribute) (decision meta.Decision, err error) {
+	// filter out SkipAction, which set by api server to skip authorization
+	noSkipResources := make([]meta.ResourceAttribute, 0)
+	for _, resource := range attribute.Resources {
+		if resource.Action == meta.SkipAction {
+			continue
+		}
+		noSkipResources = append(noSkipResources, resource)
+	}
+	attribute.Resources = noSkipResources
+	if len(noSkipResources) == 0 {
+		return meta.Decision{Authorized: true}, nil
+	}
+
+	decisions, err := a.AuthorizeBatch(ctx, attribute.User, attribute.Resources...)
+	if err != nil {
+		return meta.Decision{}, err
+	}
+	noAuth := make([]string, 0)
+	for i, item := range decisions {
+		if !item.Authorized {
+			noAuth = append(noAuth, fmt.Sprintf("resource [%v] permission deny by reason: %s", attribute.Resources[i].Type, item.Reason))
+		}
+	}
+	if len(noAuth) > 0 {
+		return meta.Decision{Authorized: false, Reason: fmt.Sprintf("%v", noAuth)}, nil
+	}
+	return meta.Decision{Authorized: true}, nil
+}
+
+func (a *Authorizer) AuthorizeBatch(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) (decisions []meta.Decision, err error) {
+	decisions = make([]meta.Decision, len(resources))
+	if a.IsAdmin(user.UserName) {
+		for i := range decisions {
+			decisions[i].Authorized = true
+		}
+		return decisions, nil
+	}
+
+	permissions, err := a.getPermissions(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	for i, resource := range resources {
+		if resource.Action == meta.SkipAction {
+			decisions[i].Authorized = true
+			continue
+		}
+		for _, permission := range permissions {
+			if matchPermission(permission, resource) {
+				decisions[i].Authorized = true
+				break
+			}
+		}
+		if !decisions[i].Authorized {
+			decisions[i].Reason = fmt.Sprintf("no role of user %s grants %s on %s in business %d", user.UserName, resource.Action, resource.Type, resource.BusinessID)
+		}
+	}
+	return decisions, nil
+}
+
+func (a *Authorizer) GetAnyAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	return a.getAuthorizedBusinessList(ctx, user, false)
+}
+
+func (a *Authorizer) GetExactAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	return a.getAuthorizedBusinessList(ctx, user, true)
+}
+
+// getAuthorizedBusinessList get the businesses the user has permissions in, only the bindings of the
+// whole business are taken into account when exact is true.
+func (a *Authorizer) getAuthorizedBusinessList(ctx context.Context, user meta.UserInfo, exact bool) ([]int64, error) {
+	if a.IsAdmin(user.UserName) {
+		return a.getAllBusinessList(ctx, user)
+	}
+
+	permissions, err := a.getPermissions(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	bizIDs := make([]int64, 0)
+	for _, permission := range permissions {
+		if exact && len(permission.Binding.InstIDs) > 0 {
+			continue
+		}
+		if permission.Binding.BizID == 0 {
+			return a.getAllBusinessList(ctx, user)
+		}
+		if !util.ContainsInt64(bizIDs, permission.Binding.BizID) {
+			bizIDs = append(bizIDs, permission.Binding.BizID)
+		}
+	}
+	return bizIDs, nil
+}
+
+func (a *Authorizer) AdminEntrance(ctx context.Context, user meta.UserInfo) ([]string, error) {
+	if a.IsAdmin(user.UserName) {
+		return []string{authcenter.SystemIDCMDB}, nil
+	}
+	permissions, err := a.getPermissions(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range permissions {
+		if permission.Binding.BizID == 0 {
+			return []string{authcenter.SystemIDCMDB}, nil
+		}
+	}
+	return make([]string, 0), nil
+}
+
+// GetAuthorizedAuditList get the models whose audit logs in the business the user can read, the
+// business 0 means the audit logs of any business. the role bindings grant the audit logs of all
+// the models at once, because the rules do not tell the models apart.
+func (a *Authorizer) GetAuthorizedAuditList(ctx context.Context, user meta.UserInfo, businessID int64) ([]authcenter.AuthorizedResource, error) {
+	resource := meta.ResourceAttribute{
+		Basic:           meta.Basic{Type: meta.AuditLog, Action: meta.Find},
+		SupplierAccount: user.SupplierAccount,
+		BusinessID:      businessID,
+	}
+	decisions, err := a.AuthorizeBatch(ctx, user, resource)
+	if err != nil {
+		return nil, err
+	}
+	if !decisions[0].Authorized {
+		return make([]authcenter.AuthorizedResource, 0), nil
+	}
+
+	resourceType := authcenter.SysAuditLog
+	if businessID > 0 {
+		resourceType = authcenter.BizAuditLog
+	}
+	objIDs, err := a.getAllModelList(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	audit := authcenter.AuthorizedResource{
+		ActionID:     authcenter.Get,
+		ResourceType: resourceType,
+		ResourceIDs:  make([][]authcenter.RscTypeAndID, 0, len(objIDs)),
+	}
+	for _, objID := range objIDs {
+		audit.ResourceIDs = append(audit.ResourceIDs, []authcenter.RscTypeAndID{{ResourceType: resourceType, ResourceID: objID}})
+	}
+	return []authcenter.AuthorizedResource{audit}, nil
+}
+
+func (a *Authorizer) RegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	return nil
+}
+
+func (a *Authorizer) DryRunRegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) (*authcenter.RegisterInfo, error) {
+	return new(authcenter.RegisterInfo), nil
+}
+
+func (a *Authorizer) DeregisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	return nil
+}
+
+func (a *Authorizer) RawDeregisterResource(ctx context.Context, scope authcenter.ScopeInfo, rs ...meta.BackendResource) error {
+	return nil
+}
+
+func (a *Authorizer) UpdateResource(ctx context.Context, rs *meta.ResourceAttribute) error {
+	return nil
+}
+
+func (a *Authorizer) Get(ctx context.Context) error {
+	return nil
+}
+
+func (a *Authorizer) ListResources(ctx context.Context, r *meta.ResourceAttribute) ([]meta.BackendResource, error) {
+	return make([]meta.BackendResource, 0), nil
+}
+
+func (a *Authorizer) Init(ctx context.Context, config meta.InitConfig) error {
+	return nil
+}
+
+// getPermissions get the permissions of the user from core service, the result is cached.
+func (a *Authorizer) getPermissions(ctx context.Context, user meta.UserInfo) ([]metadata.AuthPermission, error) {
+	key := user.SupplierAccount + ":" + user.UserName
+	a.lock.RLock()
+	cached, exists := a.cache[key]
+	a.lock.RUnlock()
+	if exists && time.Now().Before(cached.expireAt) {
+		return cached.permissions, nil
+	}
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	input := &metadata.GetUserAuthPermissionsRequest{User: user.UserName}
+	result, err := a.client.LocalAuth().GetUserAuthPermissions(ctx, newHeader(user, rid), input)
+	if err != nil {
+		blog.Errorf("get local auth permissions of user %s failed, err: %v, rid: %s", user.UserName, err, rid)
+		return nil, err
+	}
+	if !result.Result {
+		blog.Errorf("get local auth permissions of user %s failed, err: %s, rid: %s", user.UserName, result.ErrMsg, rid)
+		return nil, errors.New(result.ErrMsg)
+	}
+
+	a.setCache(key, result.Data, time.Now())
+	return result.Data, nil
+}
+
+// setCache cache the permissions of a user, the expired entries are evicted when the cache is
+// full, and an arbitrary entry is evicted if none of them is expired.
+func (a *Authorizer) setCache(key string, permissions []metadata.AuthPermission, now time.Time) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if _, exists := a.cache[key]; !exists && len(a.cache) >= permissionCacheSize {
+		for cachedKey, cached := range a.cache {
+			if !now.Before(cached.expireAt) {
+				delete(a.cache, cachedKey)
+			}
+		}
+		for cachedKey := range a.cache {
+			if len(a.cache) < permissionCacheSize {
+				break
+			}
+			delete(a.cache, cachedKey)
+		}
+	}
+	a.cache[key] = &cachedPermissions{permissions: permissions, expireAt: now.Add(permissionTTL)}
+}
+
+func (a *Authorizer) getAllBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	bizIDs := make([]int64, 0)
+	input := &metadata.QueryCondition{
+		Fields:  []string{common.BKAppIDField},
+		Limit:   metadata.SearchLimit{Limit: pageSize},
+		SortArr: []metadata.SearchSort{{Field: common.BKAppIDField}},
+	}
+	for {
+		result, err := a.client.Instance().ReadInstance(ctx, newHeader(user, rid), common.BKInnerObjIDApp, input)
+		if err != nil {
+			blog.Errorf("get all business list failed, err: %v, rid: %s", err, rid)
+			return nil, err
+		}
+		if !result.Result {
+			blog.Errorf("get all business list failed, err: %s, rid: %s", result.ErrMsg, rid)
+			return nil, errors.New(result.ErrMsg)
+		}
+
+		for _, biz := range result.Data.Info {
+			bizID, err := biz.Int64(common.BKAppIDField)
+			if err != nil {
+				blog.Errorf("get all business list, but parse business id failed, err: %v, business: %v, rid: %s", err, biz, rid)
+				return nil, err
+			}
+			bizIDs = append(bizIDs, bizID)
+		}
+		if len(result.Data.Info) < pageSize {
+			return bizIDs, nil
+		}
+		input.Limit.Offset += pageSize
+	}
+}
+
+func (a *Authorizer) getAllModelList(ctx context.Context, user meta.UserInfo) ([]string, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	objIDs := make([]string, 0)
+	input := &metadata.QueryCondition{
+		Fields:  []string{common.BKObjIDField},
+		Limit:   metadata.SearchLimit{Limit: pageSize},
+		SortArr: []metadata.SearchSort{{Field: common.BKFieldID}},
+	}
+	for {
+		result, err := a.client.Model().ReadModel(ctx, newHeader(user, rid), input)
+		if err != nil {
+			blog.Errorf("get all model list failed, err: %v, rid: %s", err, rid)
+			return nil, err
+		}
+		if !result.Result {
+			blog.Errorf("get all model list failed, err: %s, rid: %s", result.ErrMsg, rid)
+			return nil, errors.New(result.ErrMsg)
+		}
+
+		for _, model := range result.Data.Info {
+			objIDs = append(objIDs, model.Spec.ObjectID)
+		}
+		if len(result.Data.Info) < pageSize {
+			return objIDs, nil
+		}
+		input.Limit.Offset += pageSize
+	}
+}
+
+// matchPermission check whether the resource is in the scope of the binding, and is granted by one
+// of the rules. the resources which are not in a business are only in the scope of the global bindings.
+func matchPermission(permission metadata.AuthPermission, resource meta.ResourceAttribute) bool {
+	if permission.Binding.BizID > 0 && permission.Binding.BizID != resource.BusinessID {
+		return false
+	}
+	if len(permission.Binding.InstIDs) > 0 && !util.ContainsInt64(permission.Binding.InstIDs, resource.InstanceID) {
+		return false
+	}
+	for _, rule := range permission.Rules {
+		if matchAny(rule.ResourceTypes, string(resource.Type)) && matchAny(rule.Actions, string(resource.Action)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == metadata.AuthRuleMatchAll || pattern == value {
+			return true
+		}
+	}
+	return false
+}
+
+func newHeader(user meta.UserInfo, rid string) http.Header {
+	header := make(http.Header)
+	header.Set(common.BKHTTPHeaderUser, user.UserName)
+	header.Set(common.BKHTTPOwnerID, user.SupplierAccount)
+	header.Set(common.BKHTTPCCRequestID, rid)
+	return header
+}
@@ -0,0 +1,306 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package localauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/apimachinery/coreservice/instance"
+	apilocalauth "configcenter/src/apimachinery/coreservice/localauth"
+	"configcenter/src/apimachinery/coreservice/model"
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+type fakeCoreService struct {
+	coreservice.CoreServiceClientInterface
+	bizIDs      []int64
+	objIDs      []string
+	permissions []metadata.AuthPermission
+	permCalls   int
+}
+
+func (c *fakeCoreService) Instance() instance.InstanceClientInterface {
+	return &fakeInstanceClient{bizIDs: c.bizIDs}
+}
+
+func (c *fakeCoreService) Model() model.ModelClientInterface {
+	return &fakeModelClient{objIDs: c.objIDs}
+}
+
+func (c *fakeCoreService) LocalAuth() apilocalauth.LocalAuthClientInterface {
+	return &fakeLocalAuthClient{core: c}
+}
+
+type fakeInstanceClient struct {
+	instance.InstanceClientInterface
+	bizIDs []int64
+}
+
+func (c *fakeInstanceClient) ReadInstance(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (*metadata.QueryConditionResult, error) {
+	result := &metadata.QueryConditionResult{BaseResp: metadata.SuccessBaseResp}
+	start, end := page(len(c.bizIDs), input.Limit)
+	for _, bizID := range c.bizIDs[start:end] {
+		result.Data.Info = append(result.Data.Info, mapstr.MapStr{common.BKAppIDField: bizID})
+	}
+	return result, nil
+}
+
+type fakeModelClient struct {
+	model.ModelClientInterface
+	objIDs []string
+}
+
+func (c *fakeModelClient) ReadModel(ctx context.Context, h http.Header, input *metadata.QueryCondition) (*metadata.ReadModelResult, error) {
+	result := &metadata.ReadModelResult{BaseResp: metadata.SuccessBaseResp}
+	start, end := page(len(c.objIDs), input.Limit)
+	for _, objID := range c.objIDs[start:end] {
+		result.Data.Info = append(result.Data.Info, metadata.SearchModelInfo{Spec: metadata.Object{ObjectID: objID}})
+	}
+	return result, nil
+}
+
+// page get the range of the page in the items, the limit must be set, like core service requires.
+func page(total int, limit metadata.SearchLimit) (start, end int) {
+	start, end = int(limit.Offset), int(limit.Offset+limit.Limit)
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+type fakeLocalAuthClient struct {
+	apilocalauth.LocalAuthClientInterface
+	core *fakeCoreService
+}
+
+func (c *fakeLocalAuthClient) GetUserAuthPermissions(ctx context.Context, h http.Header, input *metadata.GetUserAuthPermissionsRequest) (*metadata.GetUserAuthPermissionsResult, error) {
+	c.core.permCalls++
+	return &metadata.GetUserAuthPermissionsResult{BaseResp: metadata.SuccessBaseResp, Data: c.core.permissions}, nil
+}
+
+func TestMatchPermission(t *testing.T) {
+	hostRule := metadata.AuthRoleRule{ResourceTypes: []string{string(meta.HostInstance)}, Actions: []string{string(meta.Update), string(meta.Find)}}
+	allRule := metadata.AuthRoleRule{ResourceTypes: []string{metadata.AuthRuleMatchAll}, Actions: []string{metadata.AuthRuleMatchAll}}
+	updateHost := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update, InstanceID: 7}, BusinessID: 2}
+
+	tests := []struct {
+		name       string
+		permission metadata.AuthPermission
+		resource   meta.ResourceAttribute
+		want       bool
+	}{
+		{"global binding matches any business", metadata.AuthPermission{Rules: []metadata.AuthRoleRule{hostRule}}, updateHost, true},
+		{"business binding matches the same business", metadata.AuthPermission{Binding: metadata.AuthRoleBinding{BizID: 2}, Rules: []metadata.AuthRoleRule{hostRule}}, updateHost, true},
+		{"business binding does not match other business", metadata.AuthPermission{Binding: metadata.AuthRoleBinding{BizID: 3}, Rules: []metadata.AuthRoleRule{allRule}}, updateHost, false},
+		{"business binding does not match resource out of business", metadata.AuthPermission{Binding: metadata.AuthRoleBinding{BizID: 2}, Rules: []metadata.AuthRoleRule{allRule}},
+			meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.Update}}, false},
+		{"instance binding matches the instance", metadata.AuthPermission{Binding: metadata.AuthRoleBinding{BizID: 2, InstIDs: []int64{7}}, Rules: []metadata.AuthRoleRule{hostRule}}, updateHost, true},
+		{"instance binding does not match other instance", metadata.AuthPermission{Binding: metadata.AuthRoleBinding{BizID: 2, InstIDs: []int64{8}}, Rules: []metadata.AuthRoleRule{hostRule}}, updateHost, false},
+		{"rule does not grant the action", metadata.AuthPermission{Rules: []metadata.AuthRoleRule{hostRule}},
+			meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Delete}, BusinessID: 2}, false},
+		{"rule does not grant the resource type", metadata.AuthPermission{Rules: []metadata.AuthRoleRule{hostRule}},
+			meta.ResourceAttribute{Basic: meta.Basic{Type: meta.ModelSet, Action: meta.Update}, BusinessID: 2}, false},
+		{"match all rule", metadata.AuthPermission{Rules: []metadata.AuthRoleRule{allRule}}, updateHost, true},
+		{"no rules", metadata.AuthPermission{}, updateHost, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPermission(tt.permission, tt.resource); got != tt.want {
+				t.Errorf("matchPermission() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAuthorizedBusinessList(t *testing.T) {
+	allBizIDs := []int64{1, 2, 3, 4}
+	bizBinding := func(bizID int64, instIDs ...int64) metadata.AuthPermission {
+		return metadata.AuthPermission{Binding: metadata.AuthRoleBinding{BizID: bizID, InstIDs: instIDs}}
+	}
+
+	tests := []struct {
+		name        string
+		user        string
+		permissions []metadata.AuthPermission
+		exact       bool
+		want        []int64
+	}{
+		{"admin gets all businesses", "admin", nil, true, allBizIDs},
+		{"no bindings", "user", nil, false, []int64{}},
+		{"business bindings without duplicates", "user", []metadata.AuthPermission{bizBinding(2), bizBinding(3), bizBinding(2)}, false, []int64{2, 3}},
+		{"instance bindings count for any", "user", []metadata.AuthPermission{bizBinding(2, 7), bizBinding(3)}, false, []int64{2, 3}},
+		{"instance bindings do not count for exact", "user", []metadata.AuthPermission{bizBinding(2, 7), bizBinding(3)}, true, []int64{3}},
+		{"global binding gets all businesses", "user", []metadata.AuthPermission{bizBinding(2), bizBinding(0)}, true, allBizIDs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeCoreService{bizIDs: allBizIDs, permissions: tt.permissions}
+			authorizer := NewAuthorizer(client, []string{"admin"})
+			got, err := authorizer.getAuthorizedBusinessList(context.Background(), meta.UserInfo{UserName: tt.user, SupplierAccount: "0"}, tt.exact)
+			if err != nil {
+				t.Fatalf("getAuthorizedBusinessList() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getAuthorizedBusinessList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllBusinessListByPage(t *testing.T) {
+	allBizIDs := make([]int64, 0)
+	for i := int64(1); i <= 2*pageSize+1; i++ {
+		allBizIDs = append(allBizIDs, i)
+	}
+	authorizer := NewAuthorizer(&fakeCoreService{bizIDs: allBizIDs}, []string{"admin"})
+	got, err := authorizer.getAllBusinessList(context.Background(), meta.UserInfo{UserName: "admin", SupplierAccount: "0"})
+	if err != nil {
+		t.Fatalf("getAllBusinessList() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, allBizIDs) {
+		t.Errorf("getAllBusinessList() got %d businesses, want %d", len(got), len(allBizIDs))
+	}
+}
+
+func TestGetAuthorizedAuditList(t *testing.T) {
+	objIDs := []string{"biz", "host", "switch"}
+	auditRule := metadata.AuthRoleRule{ResourceTypes: []string{string(meta.AuditLog)}, Actions: []string{string(meta.Find)}}
+	hostRule := metadata.AuthRoleRule{ResourceTypes: []string{string(meta.HostInstance)}, Actions: []string{metadata.AuthRuleMatchAll}}
+	auditBinding := func(bizID int64, instIDs ...int64) metadata.AuthPermission {
+		return metadata.AuthPermission{Binding: metadata.AuthRoleBinding{BizID: bizID, InstIDs: instIDs}, Rules: []metadata.AuthRoleRule{auditRule}}
+	}
+
+	tests := []struct {
+		name        string
+		user        string
+		permissions []metadata.AuthPermission
+		businessID  int64
+		want        bool
+	}{
+		{"admin reads the audit logs of any business", "admin", nil, 0, true},
+		{"no bindings", "user", nil, 2, false},
+		{"rule does not grant the audit logs", "user", []metadata.AuthPermission{{Rules: []metadata.AuthRoleRule{hostRule}}}, 2, false},
+		{"business binding grants the business", "user", []metadata.AuthPermission{auditBinding(2)}, 2, true},
+		{"business binding does not grant other business", "user", []metadata.AuthPermission{auditBinding(3)}, 2, false},
+		{"business binding does not grant any business", "user", []metadata.AuthPermission{auditBinding(2)}, 0, false},
+		{"instance binding does not grant the audit logs", "user", []metadata.AuthPermission{auditBinding(2, 7)}, 2, false},
+		{"global binding grants any business", "user", []metadata.AuthPermission{auditBinding(0)}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeCoreService{objIDs: objIDs, permissions: tt.permissions}
+			authorizer := NewAuthorizer(client, []string{"admin"})
+			got, err := authorizer.GetAuthorizedAuditList(context.Background(), meta.UserInfo{UserName: tt.user, SupplierAccount: "0"}, tt.businessID)
+			if err != nil {
+				t.Fatalf("GetAuthorizedAuditList() unexpected error: %v", err)
+			}
+			if !tt.want {
+				if len(got) != 0 {
+					t.Errorf("GetAuthorizedAuditList() = %v, want none", got)
+				}
+				return
+			}
+
+			wantType := authcenter.SysAuditLog
+			if tt.businessID > 0 {
+				wantType = authcenter.BizAuditLog
+			}
+			if len(got) != 1 || got[0].ResourceType != wantType || len(got[0].ResourceIDs) != len(objIDs) {
+				t.Fatalf("GetAuthorizedAuditList() = %v, want the %s of all the models", got, wantType)
+			}
+			for i, resourceID := range got[0].ResourceIDs {
+				if resourceID[len(resourceID)-1].ResourceID != objIDs[i] {
+					t.Errorf("GetAuthorizedAuditList() model %d = %v, want %s", i, resourceID, objIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetPermissionsCached(t *testing.T) {
+	client := &fakeCoreService{}
+	authorizer := NewAuthorizer(client, nil)
+	user := meta.UserInfo{UserName: "user", SupplierAccount: "0"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := authorizer.getPermissions(context.Background(), user); err != nil {
+			t.Fatalf("getPermissions() unexpected error: %v", err)
+		}
+	}
+	if client.permCalls != 1 {
+		t.Errorf("getPermissions() requested core service %d times, want 1", client.permCalls)
+	}
+
+	authorizer.Flush()
+	if _, err := authorizer.getPermissions(context.Background(), user); err != nil {
+		t.Fatalf("getPermissions() unexpected error: %v", err)
+	}
+	if client.permCalls != 2 {
+		t.Errorf("getPermissions() after flush requested core service %d times, want 2", client.permCalls)
+	}
+}
+
+func TestSetCacheSizeLimit(t *testing.T) {
+	authorizer := NewAuthorizer(&fakeCoreService{}, nil)
+	now := time.Now()
+
+	// half of the entries are expired when the cache is full.
+	for i := 0; i < permissionCacheSize; i++ {
+		cachedAt := now
+		if i%2 == 0 {
+			cachedAt = now.Add(-2 * permissionTTL)
+		}
+		authorizer.setCache(fmt.Sprintf("0:user%d", i), nil, cachedAt)
+	}
+	if len(authorizer.cache) != permissionCacheSize {
+		t.Fatalf("setCache() cached %d users, want %d", len(authorizer.cache), permissionCacheSize)
+	}
+
+	authorizer.setCache("0:new", nil, now)
+	if len(authorizer.cache) != permissionCacheSize/2+1 {
+		t.Errorf("setCache() should evict the expired entries, %d users are cached", len(authorizer.cache))
+	}
+	for key, cached := range authorizer.cache {
+		if !now.Before(cached.expireAt) {
+			t.Errorf("setCache() expired entry %s is not evicted", key)
+		}
+	}
+
+	// none is expired, an arbitrary entry is evicted.
+	for i := 0; len(authorizer.cache) < permissionCacheSize; i++ {
+		authorizer.setCache(fmt.Sprintf("0:more%d", i), nil, now)
+	}
+	authorizer.setCache("0:last", nil, now)
+	if len(authorizer.cache) != permissionCacheSize {
+		t.Errorf("setCache() cached %d users, want at most %d", len(authorizer.cache), permissionCacheSize)
+	}
+	if _, exists := authorizer.cache["0:last"]; !exists {
+		t.Errorf("setCache() the new entry is not cached")
+	}
+}
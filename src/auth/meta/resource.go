@@ -40,6 +40,7 @@ const (
 	ModelClassification      ResourceType = "modelClassification"
 	ModelAttributeGroup      ResourceType = "modelAttributeGroup"
 	ModelAttribute           ResourceType = "modelAttribute"
+	ModelInstanceAttribute   ResourceType = "modelInstanceAttribute" // 实例字段
 	ModelUnique              ResourceType = "modelUnique"
 	HostFavorite             ResourceType = "hostFavorite"
 	Process                  ResourceType = "process"
@@ -59,12 +60,16 @@ const (
 	ProcessConfigTemplate        = "processConfigTemplate"
 	ProcessConfigTemplateVersion = "processConfigTemplateVersion"
 	ProcessBoundConfig           = "processBoundConfig"
+	ProcessTemplateVariable      = "processTemplateVariable"
 	SystemFunctionality          = "systemFunctionality"
 
-	NetCollector = "netCollector"
-	NetDevice    = "netDevice"
-	NetProperty  = "netProperty"
-	NetReport    = "netReport"
+	NetCollector  = "netCollector"
+	NetDevice     = "netDevice"
+	NetProperty   = "netProperty"
+	NetReport     = "netReport"
+	NetCredential = "netCredential"
+
+	DiscoverPolicy = "discoverPolicy"
 )
 
 type ResourceDescribe struct {
@@ -172,6 +177,11 @@ var (
 		Actions: []Action{Find, Create, Update, Delete},
 	}
 
+	ModelInstanceAttributeDescribe = ResourceDescribe{
+		Type:    ModelInstanceAttribute,
+		Actions: []Action{Find, Update},
+	}
+
 	ModelUniqueDescribe = ResourceDescribe{
 		Type:    ModelUnique,
 		Actions: []Action{FindMany, Create, Update, Delete},
@@ -15,7 +15,9 @@ func (ps *parseStream) netCollectorRelated() *parseStream {
 	ps.netCollector().
 		netDevice().
 		netProperty().
-		netReport()
+		netReport().
+		netCredential().
+		discover()
 
 	return ps
 }
@@ -329,3 +331,133 @@ func (ps *parseStream) netReport() *parseStream {
 
 	return ps
 }
+
+const (
+	createNetCredentialPattern = "/api/v3/collector/netcollect/credential/action/create"
+	findNetCredentialsPattern  = "/api/v3/collector/netcollect/credential/action/search"
+)
+
+var (
+	updateNetCredentialRegexp = regexp.MustCompile(`^/api/v3/collector/netcollect/credential/[0-9]+/action/update$`)
+	deleteNetCredentialRegexp = regexp.MustCompile(`^/api/v3/collector/netcollect/credential/[0-9]+/action/delete$`)
+)
+
+func (ps *parseStream) netCredential() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// create a net collect credential
+	if ps.hitPattern(createNetCredentialPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetCredential,
+					Action: meta.Create,
+				},
+			},
+		}
+		return ps
+	}
+
+	// update a net collect credential
+	if ps.hitRegexp(updateNetCredentialRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetCredential,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find net collect credentials
+	if ps.hitPattern(findNetCredentialsPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetCredential,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// delete a net collect credential
+	if ps.hitRegexp(deleteNetCredentialRegexp, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetCredential,
+					Action: meta.Delete,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+const (
+	updateDiscoverPolicyPattern        = "/api/v3/collector/discover/policy/action/update"
+	findDiscoverPoliciesPattern        = "/api/v3/collector/discover/policy/action/search"
+	findDiscoverReportSummariesPattern = "/api/v3/collector/discover/summary/action/search"
+)
+
+func (ps *parseStream) discover() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// create or update the discover policy of a model
+	if ps.hitPattern(updateDiscoverPolicyPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.DiscoverPolicy,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the discover policies
+	if ps.hitPattern(findDiscoverPoliciesPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.DiscoverPolicy,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the summaries of the pending discover reports
+	if ps.hitPattern(findDiscoverReportSummariesPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetReport,
+					Action: meta.Find,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
@@ -28,7 +28,8 @@ func (ps *parseStream) processRelated() *parseStream {
 
 	ps.process().
 		processTemplate().
-		processTemplateBound()
+		processTemplateBound().
+		processTemplateVariable()
 
 	return ps
 }
@@ -46,6 +47,12 @@ var (
 	findboundModuleToProcessRegexp = regexp.MustCompile(`^/api/v3/proc/module/[^\s/]+/[0-9]+/[0-9]+/?$`)
 	findProcessInstanceRegexp      = regexp.MustCompile(`^/api/v3/proc/inst/[^\s/]+/[0-9]+/?$`)
 	freshProcHostInstPattern       = "/api/v3/proc/process/refresh/hostinstnum"
+	findProcessRunSummaryRegexp    = regexp.MustCompile(`^/api/v3/proc/state/summary/[^\s/]+/[0-9]+/?$`)
+	findProcessInstanceStateRegexp = regexp.MustCompile(`^/api/v3/proc/state/search/[^\s/]+/[0-9]+/?$`)
+	findProcessPortConflictRegexp  = regexp.MustCompile(`^/api/v3/proc/port/conflict/[^\s/]+/[0-9]+/?$`)
+	checkProcessPortConflictRegexp = regexp.MustCompile(`^/api/v3/proc/port/conflict/check/[^\s/]+/[0-9]+/?$`)
+	findProcessOpBatchTaskRegexp   = regexp.MustCompile(`^/api/v3/proc/operate/process/batch/[^\s/]+/[0-9]+/[^\s/]+/?$`)
+	changeProcessOpBatchTaskRegexp = regexp.MustCompile(`^/api/v3/proc/operate/process/batch/(pause|resume|abort)/[^\s/]+/[0-9]+/[^\s/]+/?$`)
 )
 
 func (ps *parseStream) process() *parseStream {
@@ -329,6 +336,111 @@ func (ps *parseStream) process() *parseStream {
 		return ps
 	}
 
+	// find the expected and running process instances per module, or the state of every instance
+	if ps.hitRegexp(findProcessRunSummaryRegexp, http.MethodGet) || ps.hitRegexp(findProcessInstanceStateRegexp, http.MethodPost) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[6], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("find process instance state, but got invalid business id: %s", ps.RequestCtx.Elements[6])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.Process,
+					Action: meta.FindMany,
+					Name:   string(meta.Process),
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// find the process port conflicts on the hosts of a business
+	if ps.hitRegexp(findProcessPortConflictRegexp, http.MethodGet) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[6], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("find process port conflict, but got invalid business id: %s", ps.RequestCtx.Elements[6])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.Process,
+					Action: meta.FindMany,
+					Name:   string(meta.Process),
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// check whether transferring hosts to the modules cause process port conflicts
+	if ps.hitRegexp(checkProcessPortConflictRegexp, http.MethodPost) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("check process port conflict, but got invalid business id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.Process,
+					Action: meta.FindMany,
+					Name:   string(meta.Process),
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// find a rolling process operate task of a business
+	if ps.hitRegexp(findProcessOpBatchTaskRegexp, http.MethodGet) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("find process operate batch task, but got invalid business id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.Process,
+					Action: meta.FindMany,
+					Name:   string(meta.Process),
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// pause, resume or abort a rolling process operate task of a business
+	if ps.hitRegexp(changeProcessOpBatchTaskRegexp, http.MethodPost) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("change process operate batch task, but got invalid business id: %s", ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.Process,
+					Action: meta.UpdateMany,
+					Name:   string(meta.Process),
+				},
+			},
+		}
+
+		return ps
+	}
+
 	if ps.hitPattern(freshProcHostInstPattern, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
 			meta.ResourceAttribute{
@@ -354,6 +466,8 @@ var (
 	createProcessTemplateVersionRegexp = regexp.MustCompile(`^/api/v3/template/version/[^\s/]+/[0-9]+/[0-9]+/?$`)
 	updateProcessTemplateVersionRegexp = regexp.MustCompile(`^/api/v3/template/version/[^\s/]+/[0-9]+/[0-9]+/[0-9]+/?$`)
 	previewProcessConfigRegexp         = regexp.MustCompile(`^/api/v3/proc/template/[^\s/]+/[0-9]+/[0-9]+/?$`)
+	reviewProcessTemplateVersionRegexp = regexp.MustCompile(`^/api/v3/proc/template/version/(submit|approve|reject)/[^\s/]+/[0-9]+/[0-9]+/[0-9]+/?$`)
+	rollbackProcessTemplateRegexp      = regexp.MustCompile(`^/api/v3/proc/template/version/rollback/[^\s/]+/[0-9]+/[0-9]+/?$`)
 )
 
 func (ps *parseStream) processTemplate() *parseStream {
@@ -535,6 +649,62 @@ func (ps *parseStream) processTemplate() *parseStream {
 		return ps
 	}
 
+	// submit, approve or reject a process template version
+	if ps.hitRegexp(reviewProcessTemplateVersionRegexp, http.MethodPost) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("review process config template version, but got invalid business id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+
+		versionID, err := strconv.ParseInt(ps.RequestCtx.Elements[9], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("review process config template version, but got invalid version id: %s", ps.RequestCtx.Elements[9])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:       meta.Process,
+					Action:     meta.Update,
+					Name:       meta.ProcessConfigTemplateVersion,
+					InstanceID: versionID,
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// roll back a process config template to a released version
+	if ps.hitRegexp(rollbackProcessTemplateRegexp, http.MethodPost) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("roll back process config template, but got invalid business id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+
+		templateID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("roll back process config template, but got invalid template id: %s", ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:       meta.Process,
+					Action:     meta.Update,
+					Name:       meta.ProcessConfigTemplate,
+					InstanceID: templateID,
+				},
+			},
+		}
+
+		return ps
+	}
+
 	// preview process config
 	if ps.hitRegexp(previewProcessConfigRegexp, http.MethodGet) {
 		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[5], 10, 64)
@@ -670,3 +840,196 @@ func (ps *parseStream) processTemplateBound() *parseStream {
 
 	return ps
 }
+
+var (
+	createProcTemplateVariableRegexp      = regexp.MustCompile(`^/api/v3/proc/template/variable/[^\s/]+/[0-9]+/?$`)
+	updateProcTemplateVariableRegexp      = regexp.MustCompile(`^/api/v3/proc/template/variable/[^\s/]+/[0-9]+/[0-9]+/?$`)
+	deleteProcTemplateVariableRegexp      = regexp.MustCompile(`^/api/v3/proc/template/variable/[^\s/]+/[0-9]+/[0-9]+/?$`)
+	findProcTemplateVariablesRegexp       = regexp.MustCompile(`^/api/v3/proc/template/variable/search/[^\s/]+/[0-9]+/?$`)
+	setProcTemplateVariableValueRegexp    = regexp.MustCompile(`^/api/v3/proc/template/variable/value/[^\s/]+/[0-9]+/[0-9]+/?$`)
+	deleteProcTemplateVariableValueRegexp = regexp.MustCompile(`^/api/v3/proc/template/variable/value/[^\s/]+/[0-9]+/[0-9]+/[^\s/]+/[0-9]+/?$`)
+	findProcEffectiveVariablesRegexp      = regexp.MustCompile(`^/api/v3/proc/template/variable/effective/[^\s/]+/[0-9]+/?$`)
+)
+
+func (ps *parseStream) processTemplateVariable() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// create a variable used by the process config templates.
+	if ps.hitRegexp(createProcTemplateVariableRegexp, http.MethodPost) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[6], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("create process template variable, but got invalid business id: %s", ps.RequestCtx.Elements[6])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.Process,
+					Action: meta.Create,
+					Name:   meta.ProcessTemplateVariable,
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// update a process template variable.
+	if ps.hitRegexp(updateProcTemplateVariableRegexp, http.MethodPut) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[6], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("update process template variable, but got invalid business id: %s", ps.RequestCtx.Elements[6])
+			return ps
+		}
+
+		variableID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("update process template variable, but got invalid variable id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:       meta.Process,
+					Action:     meta.Update,
+					Name:       meta.ProcessTemplateVariable,
+					InstanceID: variableID,
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// delete a process template variable.
+	if ps.hitRegexp(deleteProcTemplateVariableRegexp, http.MethodDelete) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[6], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("delete process template variable, but got invalid business id: %s", ps.RequestCtx.Elements[6])
+			return ps
+		}
+
+		variableID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("delete process template variable, but got invalid variable id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:       meta.Process,
+					Action:     meta.Delete,
+					Name:       meta.ProcessTemplateVariable,
+					InstanceID: variableID,
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// find process template variables with condition.
+	if ps.hitRegexp(findProcTemplateVariablesRegexp, http.MethodPost) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("find process template variables, but got invalid business id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.Process,
+					Action: meta.FindMany,
+					Name:   meta.ProcessTemplateVariable,
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// override the value of a process template variable on a set, module or host.
+	if ps.hitRegexp(setProcTemplateVariableValueRegexp, http.MethodPut) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("set process template variable value, but got invalid business id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+
+		variableID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("set process template variable value, but got invalid variable id: %s", ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:       meta.Process,
+					Action:     meta.Update,
+					Name:       meta.ProcessTemplateVariable,
+					InstanceID: variableID,
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// delete the value of a process template variable overridden on a set, module or host.
+	if ps.hitRegexp(deleteProcTemplateVariableValueRegexp, http.MethodDelete) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("delete process template variable value, but got invalid business id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+
+		variableID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("delete process template variable value, but got invalid variable id: %s", ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:       meta.Process,
+					Action:     meta.Update,
+					Name:       meta.ProcessTemplateVariable,
+					InstanceID: variableID,
+				},
+			},
+		}
+
+		return ps
+	}
+
+	// find the effective variables of a process instance.
+	if ps.hitRegexp(findProcEffectiveVariablesRegexp, http.MethodPost) {
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("find process effective variables, but got invalid business id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.Process,
+					Action: meta.FindMany,
+					Name:   meta.ProcessTemplateVariable,
+				},
+			},
+		}
+
+		return ps
+	}
+
+	return ps
+}
@@ -46,6 +46,8 @@ func (ps *parseStream) topology() *parseStream {
 		objectUnique().
 		audit().
 		instanceAudit().
+		recycleBin().
+		history().
 		privilege()
 
 	return ps
@@ -444,6 +446,7 @@ const (
 	findObjectAssociationPattern                    = "/api/v3/object/association/action/search"
 	createObjectAssociationPattern                  = "/api/v3/object/association/action/create"
 	findObjectAssociationWithAssociationKindPattern = "/api/v3/topo/association/type/action/search/batch"
+	findObjectAssociationViolationsPattern          = "/api/v3/object/association/action/violations"
 )
 
 var (
@@ -532,6 +535,19 @@ func (ps *parseStream) objectAssociation() *parseStream {
 		return ps
 	}
 
+	// find the instances which violate the object association constraints.
+	if ps.RequestCtx.URI == findObjectAssociationViolationsPattern && ps.RequestCtx.Method == http.MethodPost {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ModelAssociation,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
 	// find object association with a association kind list.
 	if ps.RequestCtx.URI == findObjectAssociationWithAssociationKindPattern && ps.RequestCtx.Method == http.MethodPost {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
@@ -1918,6 +1934,63 @@ func (ps *parseStream) instanceAudit() *parseStream {
 	return ps
 }
 
+const (
+	searchRecycleBin  = `/api/v3/recyclebin/search`
+	restoreRecycleBin = `/api/v3/recyclebin/restore`
+	purgeRecycleBin   = `/api/v3/recyclebin/purge`
+)
+
+func (ps *parseStream) recycleBin() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(searchRecycleBin, http.MethodPost) ||
+		ps.hitPattern(restoreRecycleBin, http.MethodPost) ||
+		ps.hitPattern(purgeRecycleBin, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					// recycle bin authorization in topo scene layer
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+var (
+	findInstanceAsOfRegexp     = regexp.MustCompile(`^/api/v3/inst/asof/[^\s/]+/[0-9]+/?$`)
+	findBusinessTopoAsOfRegexp = regexp.MustCompile(`^/api/v3/topo/inst/asof/[0-9]+/?$`)
+	findHostModulesAsOfRegexp  = regexp.MustCompile(`^/api/v3/topo/hostmodule/asof/[0-9]+/?$`)
+)
+
+func (ps *parseStream) history() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitRegexp(findInstanceAsOfRegexp, http.MethodPost) ||
+		ps.hitRegexp(findBusinessTopoAsOfRegexp, http.MethodPost) ||
+		ps.hitRegexp(findHostModulesAsOfRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type: meta.AuditLog,
+					// history is replayed from audit logs, authorization in topo scene layer
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
 var (
 	findPrivilege = regexp.MustCompile(`^/api/v3/topo/privilege/.*$`)
 	postPrivilege = regexp.MustCompile(`^/api/v3/topo/privilege/.*$`)
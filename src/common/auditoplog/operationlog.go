@@ -24,6 +24,8 @@ const (
 	AuditOpTypeDel AuditOpType = 3
 	// AuditOpTypeHostModule host  change module
 	AuditOpTypeHostModule AuditOpType = 100
+	// AuditOpTypeAuthDenied a request denied by the authorization
+	AuditOpTypeAuthDenied AuditOpType = 200
 )
 
 // 操作类型代码分两部分， 前2位表示大类入，后1位表示操作类型，1增加，2.修改，3，删除， 列入100
@@ -0,0 +1,33 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backbone
+
+import (
+	"configcenter/src/apimachinery/util"
+	"configcenter/src/auth"
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/common/blog"
+)
+
+// NewAuthorize create the authorizer of the backend chosen by the auth config, so that all the
+// servers authorize the requests the same way. the local authorizer reads the roles with the
+// core service client of the engine.
+func (e *Engine) NewAuthorize(tls *util.TLSClientConfig, authConf authcenter.AuthConfig) (auth.Authorize, error) {
+	if authConf.Backend == authcenter.BackendLocal {
+		blog.Infof("enable local authorization, admins: %v", authConf.LocalAdmins)
+		return auth.NewLocalAuthorize(e.CoreAPI.CoreService(), authConf), nil
+	}
+
+	blog.Infof("enable authcenter: %v", authConf.Enable)
+	return auth.NewAuthorize(tls, authConf)
+}
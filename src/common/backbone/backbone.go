@@ -17,7 +17,6 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
-	"time"
 
 	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/discovery"
@@ -28,7 +27,13 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
+	"configcenter/src/common/metric"
+	"configcenter/src/common/openapi"
+	"configcenter/src/common/registerdiscover"
+	"configcenter/src/common/trace"
 	"configcenter/src/common/types"
+
+	"github.com/emicklei/go-restful"
 )
 
 // BackboneParameter Used to constrain different services to ensure
@@ -45,19 +50,6 @@ type BackboneParameter struct {
 	SrvInfo *types.ServerInfo
 }
 
-func newSvcManagerClient(ctx context.Context, svcManagerAddr string) (*zk.ZkClient, error) {
-	client := zk.NewZkClient(svcManagerAddr, 5*time.Second)
-	if err := client.Start(); err != nil {
-		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", svcManagerAddr, err)
-	}
-
-	if err := client.Ping(); err != nil {
-		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", svcManagerAddr, err)
-	}
-
-	return client, nil
-}
-
 func newConfig(ctx context.Context, srvInfo *types.ServerInfo, discovery discovery.DiscoveryInterface, apiMachineryConfig *util.APIMachineryConfig) (*Config, error) {
 
 	machinery, err := apimachinery.NewApiMachinery(apiMachineryConfig, discovery)
@@ -99,15 +91,15 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	}
 
 	common.SetServerInfo(input.SrvInfo)
-	client, err := newSvcManagerClient(ctx, input.Regdiscv)
+	backend, err := NewRegDiscvBackend(ctx, input.Regdiscv)
 	if err != nil {
 		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", input.Regdiscv, err)
 	}
-	serviceDiscovery, err := discovery.NewServiceDiscovery(client)
+	serviceDiscovery, err := discovery.NewServiceDiscovery(registerdiscover.NewRegDiscoverWithServer(backend.RegDiscv()))
 	if err != nil {
 		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", input.Regdiscv, err)
 	}
-	disc, err := NewServiceRegister(client)
+	disc, err := NewServiceRegister(backend)
 	if err != nil {
 		return nil, fmt.Errorf("new service discover failed, err:%v", err)
 	}
@@ -125,19 +117,19 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	if err != nil {
 		return nil, fmt.Errorf("new engine failed, err: %v", err)
 	}
-	engine.client = client
+	engine.backend = backend
 	engine.apiMachineryConfig = apiMachineryConfig
 	engine.discovery = serviceDiscovery
 	engine.ServiceManageInterface = serviceDiscovery
 	engine.srvInfo = input.SrvInfo
 
 	handler := &cc.CCHandler{
-		OnProcessUpdate:  input.ConfigUpdate,
+		OnProcessUpdate:  onProcessUpdate(input.ConfigUpdate),
 		OnLanguageUpdate: engine.onLanguageUpdate,
 		OnErrorUpdate:    engine.onErrorUpdate,
 	}
 
-	err = cc.NewConfigCenter(ctx, client, common.GetIdentification(), input.ConfigPath, handler)
+	err = cc.New(ctx, common.GetIdentification(), input.ConfigPath, backend.ConfRegDiscv(), handler)
 	if err != nil {
 		return nil, fmt.Errorf("new config center failed, err: %v", err)
 	}
@@ -146,6 +138,21 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 }
 
 func StartServer(ctx context.Context, e *Engine, HTTPHandler http.Handler) error {
+	// serve the openapi document of the routes, which is aggregated by the api server, and the
+	// metrics of the requests in the prometheus format.
+	metricLabels := map[string]string{"module": common.GetIdentification()}
+	if container, ok := HTTPHandler.(*restful.Container); ok {
+		container.Add(openapi.NewWebService(container, common.GetIdentification(), e.srvInfo.Version))
+		container.Add(metric.NewPrometheusWebService(metricLabels))
+		container.Filter(metric.RestfulFilter)
+		container.Filter(trace.RestfulFilter)
+	} else {
+		mux := http.NewServeMux()
+		mux.Handle(metric.PrometheusPath, metric.PrometheusHandler(metricLabels))
+		mux.Handle("/", metric.InstrumentHandler(trace.Handler(HTTPHandler)))
+		HTTPHandler = mux
+	}
+
 	e.server = Server{
 		ListenAddr: e.srvInfo.IP,
 		ListenPort: e.srvInfo.Port,
@@ -159,6 +166,17 @@ func StartServer(ctx context.Context, e *Engine, HTTPHandler http.Handler) error
 	return nil
 }
 
+// onProcessUpdate init the tracing with the process config before it's handled by the service,
+// so that all the services are traced the same way.
+func onProcessUpdate(handler cc.ProcHandlerFunc) cc.ProcHandlerFunc {
+	return func(previous, current cc.ProcessConfig) {
+		if err := trace.InitFromConfig(common.GetIdentification(), current.ConfigMap); err != nil {
+			blog.Errorf("init trace failed, err: %v", err)
+		}
+		handler(previous, current)
+	}
+}
+
 func New(c *Config, disc ServiceRegisterInterface) (*Engine, error) {
 	if err := disc.Register(c.RegisterPath, c.RegisterInfo); err != nil {
 		return nil, err
@@ -178,7 +196,7 @@ type Engine struct {
 	CoreAPI            apimachinery.ClientSetInterface
 	apiMachineryConfig *util.APIMachineryConfig
 
-	client                 *zk.ZkClient
+	backend                RegDiscvBackend
 	ServiceManageInterface discovery.ServiceManageInterface
 	SvcDisc                ServiceRegisterInterface
 	discovery              discovery.DiscoveryInterface
@@ -202,8 +220,17 @@ func (e *Engine) ApiMachineryConfig() *util.APIMachineryConfig {
 	return e.apiMachineryConfig
 }
 
+// RegDiscvBackend the backend of the service register and discover and the config center
+func (e *Engine) RegDiscvBackend() RegDiscvBackend {
+	return e.backend
+}
+
+// ServiceManageClient return the zookeeper client, it's nil if the backend is not zookeeper
 func (e *Engine) ServiceManageClient() *zk.ZkClient {
-	return e.client
+	if b, ok := e.backend.(*zkBackend); ok {
+		return b.client
+	}
+	return nil
 }
 
 func (e *Engine) onLanguageUpdate(previous, current map[string]language.LanguageMap) {
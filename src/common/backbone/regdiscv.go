@@ -0,0 +1,193 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backbone
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"configcenter/src/common/backbone/service_mange/etcd"
+	"configcenter/src/common/backbone/service_mange/zk"
+	"configcenter/src/common/confregdiscover"
+	"configcenter/src/common/registerdiscover"
+)
+
+// the schemes of the regdiscv address, which select the backend of register and discover.
+// the address without scheme is the zookeeper hosts, such as 127.0.0.1:2181,127.0.0.2:2181
+const (
+	RegDiscvSchemeZk    = "zk"
+	RegDiscvSchemeEtcd  = "etcd"
+	RegDiscvSchemeEtcds = "etcds"
+	RegDiscvSchemeFile  = "file"
+)
+
+// fileReloadInterval the interval to reload the services and configs of the file backend
+const fileReloadInterval = 5 * time.Second
+
+// RegDiscvBackend is the backend of the service register and discover and the config center,
+// all the services share one backend, which is selected by the scheme of the regdiscv address:
+//   - 127.0.0.1:2181 or zk://127.0.0.1:2181: zookeeper
+//   - etcd://127.0.0.1:2379,127.0.0.2:2379?user=cc&password=xx&prefix=/v3: etcd, etcds uses https
+//   - file:///data/cmdb/regdiscv: the static services file and config files in the directory
+type RegDiscvBackend interface {
+	// Ping to ping server
+	Ping() error
+	// Stop the backend, the registered services are deregistered
+	Stop() error
+	// RegDiscv the service register and discover of the backend
+	RegDiscv() registerdiscover.RegDiscvServer
+	// ConfRegDiscv the config register and discover of the backend
+	ConfRegDiscv() confregdiscover.ConfRegDiscvIf
+}
+
+// NewRegDiscvBackend create the backend by the regdiscv address and connect to it
+func NewRegDiscvBackend(ctx context.Context, address string) (RegDiscvBackend, error) {
+	scheme, hosts, query, err := parseRegDiscvAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var backend RegDiscvBackend
+	switch scheme {
+	case RegDiscvSchemeZk:
+		client := zk.NewZkClient(hosts, 5*time.Second)
+		if err := client.Start(); err != nil {
+			return nil, err
+		}
+		backend = &zkBackend{client: client}
+	case RegDiscvSchemeEtcd, RegDiscvSchemeEtcds:
+		opt := etcd.Options{
+			Endpoints: strings.Split(hosts, ","),
+			TLS:       scheme == RegDiscvSchemeEtcds,
+			APIPrefix: query.Get("prefix"),
+			User:      query.Get("user"),
+			Password:  query.Get("password"),
+		}
+		client, err := etcd.NewEtcdClient(opt, 10*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.Start(); err != nil {
+			return nil, err
+		}
+		backend = &etcdBackend{client: client}
+	case RegDiscvSchemeFile:
+		if hosts == "" {
+			return nil, fmt.Errorf("the directory of the file regdiscv is not set")
+		}
+		fileCtx, cancel := context.WithCancel(ctx)
+		backend = &fileBackend{
+			regDiscv:     registerdiscover.NewFileRegDiscv(fileCtx, hosts, fileReloadInterval),
+			confRegDiscv: confregdiscover.NewFileRegDiscover(fileCtx, hosts, fileReloadInterval),
+			cancel:       cancel,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported regdiscv scheme %s", scheme)
+	}
+
+	if err := backend.Ping(); err != nil {
+		backend.Stop()
+		return nil, err
+	}
+	return backend, nil
+}
+
+// parseRegDiscvAddress split the regdiscv address to the scheme, the hosts (or the directory of the
+// file backend) and the query options
+func parseRegDiscvAddress(address string) (string, string, url.Values, error) {
+	address = strings.TrimSpace(address)
+	scheme := RegDiscvSchemeZk
+	if idx := strings.Index(address, "://"); idx >= 0 {
+		scheme = strings.ToLower(address[:idx])
+		address = address[idx+len("://"):]
+	}
+
+	query := url.Values{}
+	if idx := strings.Index(address, "?"); idx >= 0 {
+		var err error
+		if query, err = url.ParseQuery(address[idx+1:]); err != nil {
+			return "", "", nil, fmt.Errorf("invalid regdiscv options %s, err: %v", address[idx+1:], err)
+		}
+		address = address[:idx]
+	}
+	if scheme != RegDiscvSchemeFile {
+		address = strings.TrimSuffix(address, "/")
+	}
+	return scheme, address, query, nil
+}
+
+type zkBackend struct {
+	client *zk.ZkClient
+}
+
+func (b *zkBackend) Ping() error {
+	return b.client.Ping()
+}
+
+func (b *zkBackend) Stop() error {
+	return b.client.Stop()
+}
+
+func (b *zkBackend) RegDiscv() registerdiscover.RegDiscvServer {
+	return registerdiscover.NewZkRegDiscv(b.client)
+}
+
+func (b *zkBackend) ConfRegDiscv() confregdiscover.ConfRegDiscvIf {
+	return confregdiscover.NewZkRegDiscover(b.client)
+}
+
+type etcdBackend struct {
+	client *etcd.EtcdClient
+}
+
+func (b *etcdBackend) Ping() error {
+	return b.client.Ping()
+}
+
+func (b *etcdBackend) Stop() error {
+	return b.client.Stop()
+}
+
+func (b *etcdBackend) RegDiscv() registerdiscover.RegDiscvServer {
+	return registerdiscover.NewEtcdRegDiscv(b.client)
+}
+
+func (b *etcdBackend) ConfRegDiscv() confregdiscover.ConfRegDiscvIf {
+	return confregdiscover.NewEtcdRegDiscover(b.client)
+}
+
+type fileBackend struct {
+	regDiscv     *registerdiscover.FileRegDiscv
+	confRegDiscv *confregdiscover.FileRegDiscover
+	cancel       context.CancelFunc
+}
+
+func (b *fileBackend) Ping() error {
+	return b.confRegDiscv.Ping()
+}
+
+func (b *fileBackend) Stop() error {
+	b.cancel()
+	return nil
+}
+
+func (b *fileBackend) RegDiscv() registerdiscover.RegDiscvServer {
+	return b.regDiscv
+}
+
+func (b *fileBackend) ConfRegDiscv() confregdiscover.ConfRegDiscvIf {
+	return b.confRegDiscv
+}
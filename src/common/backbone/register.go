@@ -15,7 +15,6 @@ package backbone
 import (
 	"github.com/gin-gonic/gin/json"
 
-	"configcenter/src/common/backbone/service_mange/zk"
 	"configcenter/src/common/registerdiscover"
 	"configcenter/src/common/types"
 	"configcenter/src/framework/core/errors"
@@ -28,9 +27,9 @@ type ServiceRegisterInterface interface {
 	Register(path string, c types.ServerInfo) error
 }
 
-func NewServiceRegister(client *zk.ZkClient) (ServiceRegisterInterface, error) {
+func NewServiceRegister(backend RegDiscvBackend) (ServiceRegisterInterface, error) {
 	s := new(serviceRegister)
-	s.client = registerdiscover.NewRegDiscoverEx(client)
+	s.client = registerdiscover.NewRegDiscoverWithServer(backend.RegDiscv())
 	return s, nil
 }
 
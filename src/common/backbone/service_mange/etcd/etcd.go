@@ -0,0 +1,471 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package etcd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAPIPrefix the path prefix of the grpc gateway of etcd v3.4 and later, it's /v3beta for v3.3.
+	DefaultAPIPrefix = "/v3"
+	requestTimeout   = 10 * time.Second
+)
+
+// ErrCompacted the revision to watch from is compacted, the caller should read again
+var ErrCompacted = errors.New("etcd revision is compacted")
+
+// Options the options of the etcd client
+type Options struct {
+	// Endpoints the addresses of the etcd members, such as 127.0.0.1:2379
+	Endpoints []string
+	// TLS use https to connect the members, the certificates are verified with the system roots
+	TLS bool
+	// APIPrefix the path prefix of the grpc gateway, the default is /v3
+	APIPrefix string
+	// User and Password the user to authenticate with if the auth of etcd is enabled
+	User     string
+	Password string
+}
+
+// KeyValue is a key and its value in etcd
+type KeyValue struct {
+	Key            string
+	Value          []byte
+	CreateRevision int64
+	ModRevision    int64
+	Lease          int64
+}
+
+// EtcdClient do service register and discover and config watch by etcd, it talks to the grpc
+// gateway of etcd v3 with json, so that no grpc client is needed.
+type EtcdClient struct {
+	opt            Options
+	endpoints      []string
+	client         *http.Client
+	watchClient    *http.Client
+	rootCxt        context.Context
+	cancel         context.CancelFunc
+	sessionTimeOut time.Duration
+
+	lock    sync.Mutex
+	current int
+	token   string
+}
+
+// NewEtcdClient create a object of EtcdClient, the session timeout is the ttl of the registered nodes.
+func NewEtcdClient(opt Options, sessionTimeOut time.Duration) (*EtcdClient, error) {
+	if opt.APIPrefix == "" {
+		opt.APIPrefix = DefaultAPIPrefix
+	}
+	scheme := "http://"
+	if opt.TLS {
+		scheme = "https://"
+	}
+	endpoints := make([]string, 0, len(opt.Endpoints))
+	for _, ep := range opt.Endpoints {
+		if ep = strings.TrimSpace(ep); ep != "" {
+			endpoints = append(endpoints, scheme+ep+"/"+strings.Trim(opt.APIPrefix, "/"))
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, errors.New("no etcd endpoint is set")
+	}
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &EtcdClient{
+		opt:            opt,
+		endpoints:      endpoints,
+		client:         &http.Client{Transport: transport, Timeout: requestTimeout},
+		watchClient:    &http.Client{Transport: transport},
+		sessionTimeOut: sessionTimeOut,
+	}, nil
+}
+
+// Start used to run register and discover server
+func (c *EtcdClient) Start() error {
+	c.rootCxt, c.cancel = context.WithCancel(context.Background())
+	if c.opt.User != "" {
+		if err := c.authenticate(c.rootCxt); err != nil {
+			return fmt.Errorf("fail to authenticate with etcd, err: %v", err)
+		}
+	}
+	return nil
+}
+
+// Stop used to stop register and discover server, the watches and lease keepalives are stopped
+func (c *EtcdClient) Stop() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return nil
+}
+
+// WithCancel context with cancel
+func (c *EtcdClient) WithCancel() (context.Context, context.CancelFunc) {
+	return context.WithCancel(c.rootCxt)
+}
+
+// SessionTimeOut the ttl of the leases of the registered nodes
+func (c *EtcdClient) SessionTimeOut() time.Duration {
+	return c.sessionTimeOut
+}
+
+// Ping to ping server
+func (c *EtcdClient) Ping() error {
+	return c.call(context.Background(), "/maintenance/status", struct{}{}, nil)
+}
+
+// Get get the value of the key, it's nil if the key not exist. the revision of the store is also
+// returned, so that the changes after the read can be watched.
+func (c *EtcdClient) Get(ctx context.Context, key string) (*KeyValue, int64, error) {
+	kvs, revision, err := c.rangeKeys(ctx, key, "")
+	if err != nil || len(kvs) == 0 {
+		return nil, revision, err
+	}
+	return &kvs[0], revision, nil
+}
+
+// List get the keys with the prefix, they are sorted by the key.
+func (c *EtcdClient) List(ctx context.Context, prefix string) ([]KeyValue, int64, error) {
+	return c.rangeKeys(ctx, prefix, prefixEnd(prefix))
+}
+
+// Put set the value of the key, the key is deleted with the lease if the lease is not 0.
+func (c *EtcdClient) Put(ctx context.Context, key string, value []byte, lease int64) error {
+	req := map[string]string{
+		"key":   encode(key),
+		"value": base64.StdEncoding.EncodeToString(value),
+	}
+	if lease != 0 {
+		req["lease"] = strconv.FormatInt(lease, 10)
+	}
+	return c.call(ctx, "/kv/put", req, nil)
+}
+
+// Delete delete the key
+func (c *EtcdClient) Delete(ctx context.Context, key string) error {
+	return c.call(ctx, "/kv/deleterange", map[string]string{"key": encode(key)}, nil)
+}
+
+// Grant create a lease with the ttl
+func (c *EtcdClient) Grant(ctx context.Context, ttl time.Duration) (int64, error) {
+	resp := new(leaseResponse)
+	req := map[string]string{"TTL": strconv.FormatInt(int64(ttl/time.Second), 10)}
+	if err := c.call(ctx, "/lease/grant", req, resp); err != nil {
+		return 0, err
+	}
+	if resp.Error != "" {
+		return 0, errors.New(resp.Error)
+	}
+	return int64(resp.ID), nil
+}
+
+// KeepAliveOnce refresh the ttl of the lease, the lease is expired if the returned ttl is not positive.
+func (c *EtcdClient) KeepAliveOnce(ctx context.Context, lease int64) (int64, error) {
+	resp := new(struct {
+		Result leaseResponse `json:"result"`
+	})
+	req := map[string]string{"ID": strconv.FormatInt(lease, 10)}
+	if err := c.call(ctx, "/lease/keepalive", req, resp); err != nil {
+		return 0, err
+	}
+	return int64(resp.Result.TTL), nil
+}
+
+// Revoke revoke the lease, the keys with the lease are deleted
+func (c *EtcdClient) Revoke(ctx context.Context, lease int64) error {
+	return c.call(ctx, "/lease/revoke", map[string]string{"ID": strconv.FormatInt(lease, 10)}, nil)
+}
+
+// WaitChange block until the key, or the keys with the prefix if prefix is true, changed after the
+// revision, or the ctx is done. ErrCompacted is returned if the revision is compacted.
+func (c *EtcdClient) WaitChange(ctx context.Context, key string, prefix bool, revision int64) error {
+	create := map[string]string{
+		"key":            encode(key),
+		"start_revision": strconv.FormatInt(revision+1, 10),
+	}
+	if prefix {
+		create["range_end"] = encode(prefixEnd(key))
+	}
+	body, err := json.Marshal(map[string]interface{}{"create_request": create})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, c.watchClient, "/watch", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// the watch responses are streamed as json objects, the first one confirms the creation
+	decoder := json.NewDecoder(bufio.NewReader(resp.Body))
+	for {
+		event := new(struct {
+			Result struct {
+				Created         bool         `json:"created"`
+				Canceled        bool         `json:"canceled"`
+				CompactRevision int64Value   `json:"compact_revision"`
+				CancelReason    string       `json:"cancel_reason"`
+				Events          []watchEvent `json:"events"`
+			} `json:"result"`
+			Error *gatewayError `json:"error"`
+		})
+		if err := decoder.Decode(event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("watch %s failed, err: %v", key, err)
+		}
+		if event.Error != nil {
+			return event.Error
+		}
+		if event.Result.CompactRevision > 0 {
+			return ErrCompacted
+		}
+		if event.Result.Canceled {
+			return fmt.Errorf("watch %s is canceled, reason: %s", key, event.Result.CancelReason)
+		}
+		if len(event.Result.Events) > 0 {
+			return nil
+		}
+	}
+}
+
+func (c *EtcdClient) rangeKeys(ctx context.Context, key, rangeEnd string) ([]KeyValue, int64, error) {
+	req := map[string]string{"key": encode(key)}
+	if rangeEnd != "" {
+		req["range_end"] = encode(rangeEnd)
+	}
+	resp := new(struct {
+		Header struct {
+			Revision int64Value `json:"revision"`
+		} `json:"header"`
+		Kvs []struct {
+			Key            string     `json:"key"`
+			Value          string     `json:"value"`
+			CreateRevision int64Value `json:"create_revision"`
+			ModRevision    int64Value `json:"mod_revision"`
+			Lease          int64Value `json:"lease"`
+		} `json:"kvs"`
+	})
+	if err := c.call(ctx, "/kv/range", req, resp); err != nil {
+		return nil, 0, err
+	}
+
+	kvs := make([]KeyValue, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		k, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid key %s, err: %v", kv.Key, err)
+		}
+		v, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid value of key %s, err: %v", k, err)
+		}
+		kvs = append(kvs, KeyValue{
+			Key:            string(k),
+			Value:          v,
+			CreateRevision: int64(kv.CreateRevision),
+			ModRevision:    int64(kv.ModRevision),
+			Lease:          int64(kv.Lease),
+		})
+	}
+	return kvs, int64(resp.Header.Revision), nil
+}
+
+func (c *EtcdClient) authenticate(ctx context.Context) error {
+	body, err := json.Marshal(map[string]string{"name": c.opt.User, "password": c.opt.Password})
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.token = ""
+	c.lock.Unlock()
+
+	resp, err := c.do(ctx, c.client, "/auth/authenticate", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	result := new(struct {
+		Token string `json:"token"`
+	})
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.token = result.Token
+	c.lock.Unlock()
+	return nil
+}
+
+// call do a unary request, the token is refreshed once if it's expired.
+func (c *EtcdClient) call(ctx context.Context, path string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	for retry := 0; ; retry++ {
+		httpResp, err := c.do(ctx, c.client, path, body)
+		if err != nil {
+			if gwErr, ok := err.(*gatewayError); ok && gwErr.isInvalidToken() && c.opt.User != "" && retry == 0 {
+				if err := c.authenticate(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		defer httpResp.Body.Close()
+		if resp == nil {
+			return nil
+		}
+		// the keepalive response is streamed, only the first one is read
+		return json.NewDecoder(httpResp.Body).Decode(resp)
+	}
+}
+
+// do send the request to the members in turn until one of them responses
+func (c *EtcdClient) do(ctx context.Context, client *http.Client, path string, body []byte) (*http.Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c.lock.Lock()
+	start, token := c.current, c.token
+	c.lock.Unlock()
+
+	var lastErr error
+	for i := 0; i < len(c.endpoints); i++ {
+		idx := (start + i) % len(c.endpoints)
+		req, err := http.NewRequest(http.MethodPost, c.endpoints[idx]+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		if idx != start {
+			c.lock.Lock()
+			c.current = idx
+			c.lock.Unlock()
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+			gwErr := new(gatewayError)
+			if err := json.Unmarshal(data, gwErr); err != nil || gwErr.message() == "" {
+				return nil, fmt.Errorf("etcd %s failed, status: %s, body: %s", path, resp.Status, data)
+			}
+			return nil, gwErr
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("no etcd endpoint is available, err: %v", lastErr)
+}
+
+type leaseResponse struct {
+	ID    int64Value `json:"ID"`
+	TTL   int64Value `json:"TTL"`
+	Error string     `json:"error"`
+}
+
+// watchEvent is an event of a watch, only the existence of the events matters
+type watchEvent struct {
+	Type string `json:"type"`
+}
+
+// gatewayError the error returned by the grpc gateway
+type gatewayError struct {
+	Err     string `json:"error"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+func (e *gatewayError) message() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Err
+}
+
+func (e *gatewayError) Error() string {
+	return fmt.Sprintf("etcd error, code: %d, message: %s", e.Code, e.message())
+}
+
+func (e *gatewayError) isInvalidToken() bool {
+	return strings.Contains(e.message(), "invalid auth token")
+}
+
+// int64Value the int64 of the gateway, which is encoded as a json string
+type int64Value int64
+
+func (v *int64Value) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*v = 0
+		return nil
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*v = int64Value(i)
+	return nil
+}
+
+func encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// prefixEnd the range end of the keys with the prefix
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	// the prefix is all 0xff, the range end is the end of the keys
+	return "\x00"
+}
@@ -0,0 +1,157 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package etcd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGateway is a in memory etcd grpc gateway with the apis used by the client
+type fakeGateway struct {
+	sync.Mutex
+	revision int64
+	kvs      map[string]map[string]string
+	changed  chan struct{}
+}
+
+func newFakeGateway() *httptest.Server {
+	g := &fakeGateway{kvs: make(map[string]map[string]string), changed: make(chan struct{})}
+	return httptest.NewServer(g)
+}
+
+func (g *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := make(map[string]interface{})
+	json.NewDecoder(r.Body).Decode(&req)
+	decode := func(v interface{}) string {
+		s, _ := v.(string)
+		data, _ := base64.StdEncoding.DecodeString(s)
+		return string(data)
+	}
+
+	g.Lock()
+	switch r.URL.Path {
+	case "/v3/kv/put":
+		g.revision++
+		g.kvs[decode(req["key"])] = map[string]string{
+			"key":             req["key"].(string),
+			"value":           req["value"].(string),
+			"create_revision": fmt.Sprint(g.revision),
+		}
+		close(g.changed)
+		g.changed = make(chan struct{})
+		json.NewEncoder(w).Encode(map[string]interface{}{})
+	case "/v3/kv/range":
+		key, end := decode(req["key"]), decode(req["range_end"])
+		kvs := make([]map[string]string, 0)
+		for k, kv := range g.kvs {
+			if k == key || (end != "" && k >= key && k < end) {
+				kvs = append(kvs, kv)
+			}
+		}
+		sort.Slice(kvs, func(i, j int) bool { return kvs[i]["key"] < kvs[j]["key"] })
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"header": map[string]string{"revision": fmt.Sprint(g.revision)},
+			"kvs":    kvs,
+		})
+	case "/v3/lease/grant":
+		json.NewEncoder(w).Encode(map[string]string{"ID": "7587841234567890", "TTL": req["TTL"].(string)})
+	case "/v3/watch":
+		changed := g.changed
+		g.Unlock()
+		w.Write([]byte(`{"result":{"created":true}}`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-changed:
+			w.Write([]byte(`{"result":{"events":[{"kv":{}}]}}`))
+		case <-r.Context().Done():
+		}
+		return
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": "Not Found", "message": "Not Found", "code": 5})
+	}
+	g.Unlock()
+}
+
+func TestClient(t *testing.T) {
+	server := newFakeGateway()
+	defer server.Close()
+
+	client, err := NewEtcdClient(Options{Endpoints: []string{"127.0.0.1:1", strings.TrimPrefix(server.URL, "http://")}}, 10*time.Second)
+	if err != nil {
+		t.Fatalf("new client failed, err: %v", err)
+	}
+	if err := client.Start(); err != nil {
+		t.Fatalf("start client failed, err: %v", err)
+	}
+	defer client.Stop()
+	ctx := context.Background()
+
+	lease, err := client.Grant(ctx, 10*time.Second)
+	if err != nil || lease != 7587841234567890 {
+		t.Fatalf("grant lease failed, lease: %d, err: %v", lease, err)
+	}
+
+	if err := client.Put(ctx, "/cc/a/1", []byte("1"), lease); err != nil {
+		t.Fatalf("put failed, err: %v", err)
+	}
+	client.Put(ctx, "/cc/a/2", []byte("2"), 0)
+	client.Put(ctx, "/cc/b", []byte("3"), 0)
+
+	kv, revision, err := client.Get(ctx, "/cc/a/1")
+	if err != nil || kv == nil || string(kv.Value) != "1" || kv.CreateRevision != 1 || revision != 3 {
+		t.Fatalf("get failed, kv: %+v, revision: %d, err: %v", kv, revision, err)
+	}
+	kvs, _, err := client.List(ctx, "/cc/a/")
+	if err != nil || len(kvs) != 2 || kvs[1].Key != "/cc/a/2" {
+		t.Fatalf("list failed, kvs: %+v, err: %v", kvs, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.WaitChange(ctx, "/cc/a/", true, revision)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	client.Put(ctx, "/cc/a/3", []byte("3"), 0)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("wait change failed, err: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("the change is not watched")
+	}
+
+	if err := client.Delete(ctx, "/cc/a/1"); err == nil || !strings.Contains(err.Error(), "Not Found") {
+		t.Errorf("the gateway error is not returned, err: %v", err)
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	if end := prefixEnd("/cc/a/"); end != "/cc/a0" {
+		t.Errorf("unexpected range end %s", end)
+	}
+	if end := prefixEnd("a\xff"); end != "b" {
+		t.Errorf("unexpected range end %q", end)
+	}
+}
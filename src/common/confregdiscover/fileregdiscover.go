@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package confregdiscover
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+// FileRegDiscover config register and discover by the files in a directory, the config of a key is
+// saved in the file of the same path under the directory, which is for the single node and test
+// deployments.
+type FileRegDiscover struct {
+	dir      string
+	interval time.Duration
+	cancel   context.CancelFunc
+	rootCtx  context.Context
+}
+
+// NewFileRegDiscover create a object of FileRegDiscover, the files are reloaded by the interval
+func NewFileRegDiscover(ctx context.Context, dir string, interval time.Duration) *FileRegDiscover {
+	rootCtx, cancel := context.WithCancel(ctx)
+	return &FileRegDiscover{
+		dir:      dir,
+		interval: interval,
+		cancel:   cancel,
+		rootCtx:  rootCtx,
+	}
+}
+
+// Ping check the directory exist
+func (fileRD *FileRegDiscover) Ping() error {
+	_, err := os.Stat(fileRD.dir)
+	return err
+}
+
+// Write save the config data into the file, it's replaced atomically so that the readers never
+// get a partial config.
+func (fileRD *FileRegDiscover) Write(key string, data []byte) error {
+	file := fileRD.file(key)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Read the config data from the file
+func (fileRD *FileRegDiscover) Read(key string) (string, error) {
+	data, err := ioutil.ReadFile(fileRD.file(key))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Discover reload the file periodically, the event is sent when the config changed
+func (fileRD *FileRegDiscover) Discover(key string) (<-chan *DiscoverEvent, error) {
+	env := make(chan *DiscoverEvent, 1)
+
+	go func() {
+		ticker := time.NewTicker(fileRD.interval)
+		defer ticker.Stop()
+
+		var previous []byte
+		first := true
+		for {
+			data, err := ioutil.ReadFile(fileRD.file(key))
+			if err != nil {
+				blog.Warnf("fail to read config(%s) from file, err: %v", key, err)
+			} else if first || !bytes.Equal(data, previous) {
+				first = false
+				previous = data
+				select {
+				case env <- &DiscoverEvent{Key: key, Data: data}:
+				case <-fileRD.rootCtx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-fileRD.rootCtx.Done():
+				blog.Infof("discover path(%s) done", key)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return env, nil
+}
+
+func (fileRD *FileRegDiscover) file(key string) string {
+	return filepath.Join(fileRD.dir, filepath.FromSlash(key))
+}
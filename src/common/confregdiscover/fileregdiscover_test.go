@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package confregdiscover
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFileRegDiscover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confregdiscv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rd := NewFileRegDiscover(ctx, dir, 10*time.Millisecond)
+	key := "/cc/services/config/host"
+	if err := rd.Write(key, []byte("a")); err != nil {
+		t.Fatalf("write failed, err: %v", err)
+	}
+	if data, err := rd.Read(key); err != nil || data != "a" {
+		t.Fatalf("read failed, data: %s, err: %v", data, err)
+	}
+
+	env, err := rd.Discover(key)
+	if err != nil {
+		t.Fatalf("discover failed, err: %v", err)
+	}
+	if event := <-env; string(event.Data) != "a" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	rd.Write(key, []byte("b"))
+	select {
+	case event := <-env:
+		if string(event.Data) != "b" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("the change of the config is not discovered")
+	}
+}
@@ -230,6 +230,12 @@ const (
 	// BKHostOuterIPField the host outerip field
 	BKHostOuterIPField = "bk_host_outerip"
 
+	// BKHostMacField the host inner mac field
+	BKHostMacField = "bk_mac"
+
+	// BKHostOuterMacField the host outer mac field
+	BKHostOuterMacField = "bk_outer_mac"
+
 	// TimeTransferModel the time transferModel field
 	TimeTransferModel = "2006-01-02 15:04:05"
 
@@ -358,6 +364,12 @@ const (
 	// BKFileNameField the file name field
 	BKFileNameField = "file_name"
 
+	// BKFilePathField the directory of the config file on the host
+	BKFilePathField = "path"
+
+	// BKFilePermissionField the permission of the config file, such as 0644
+	BKFilePermissionField = "permission"
+
 	// BKPropertyIDField the propety id field
 	BKPropertyIDField = "bk_property_id"
 
@@ -835,6 +847,8 @@ const (
 	BKHTTPOtherRequestID  = "X-Bkapi-Request-Id"
 	BKHTTPCCRequestTime   = "Cc_Request_Time"
 	BKHTTPCCTransactionID = "Cc_Txn_Id"
+	// BKHTTPRequestAppCode the app code of the blueking app which sends the request
+	BKHTTPRequestAppCode = "Bk-App-Code"
 )
 
 type CCContextKey string
@@ -911,6 +925,8 @@ const (
 
 const (
 	BKDefaultLoginUserPluginVersion = "self"
+	BKOIDCLoginUserPluginVersion    = "oidc"
+	BKLDAPLoginUserPluginVersion    = "ldap"
 	HTTPCookieBKToken               = "bk_token"
 
 	WEBSessionUinKey           = "username"
@@ -941,12 +957,27 @@ const (
 const TemplateStatusField = "status"
 const BKStatusField = "status"
 
+// the status of the template version, a draft is submitted for reviewing, and it's released after
+// it's approved, the released version is deprecated when another version is released.
 const (
-	TemplateStatusDraft   = "draft"
+	TemplateStatusDraft      = "draft"
+	TemplateStatusReviewing  = "reviewing"
+	TemplateStatusReleased   = "released"
+	TemplateStatusDeprecated = "deprecated"
+
+	// TemplateStatusOnline and TemplateStatusHistory the status of the versions before the review
+	// is introduced, they are migrated to TemplateStatusReleased and TemplateStatusDeprecated.
 	TemplateStatusOnline  = "online"
 	TemplateStatusHistory = "history"
 )
 
+// the fields of the template version review
+const (
+	BKTemplateSubmitterField   = "submitter"
+	BKTemplateReviewerField    = "reviewer"
+	BKTemplateReleaseTimeField = "release_time"
+)
+
 const (
 	BKProcInstanceOpUser             = "proc instance user"
 	BKSynchronizeDataTaskDefaultUser = "synchronize task user"
@@ -964,13 +995,18 @@ const (
 	GSEProcOPKill           = 9
 )
 const (
-	RedisProcSrvHostInstanceRefreshModuleKey  = BKCacheKeyV3Prefix + "prochostinstancerefresh:set"
-	RedisProcSrvHostInstanceAllRefreshLockKey = BKCacheKeyV3Prefix + "lock:prochostinstancerefresh"
-	RedisProcSrvQueryProcOPResultKey          = BKCacheKeyV3Prefix + "procsrv:query:opresult:set"
-	RedisCloudSyncInstancePendingStart        = BKCacheKeyV3Prefix + "cloudsyncinstancependingstart:list"
-	RedisCloudSyncInstanceStarted             = BKCacheKeyV3Prefix + "cloudsyncinstancestarted:list"
-	RedisCloudSyncInstancePendingStop         = BKCacheKeyV3Prefix + "cloudsyncinstancependingstop:list"
-	RedisCloudSyncStartLockKey                = BKCacheKeyV3Prefix + "lock:cloudsyncstart"
+	RedisProcSrvHostInstanceRefreshModuleKey   = BKCacheKeyV3Prefix + "prochostinstancerefresh:set"
+	RedisProcSrvHostInstanceAllRefreshLockKey  = BKCacheKeyV3Prefix + "lock:prochostinstancerefresh"
+	RedisProcSrvQueryProcOPResultKey           = BKCacheKeyV3Prefix + "procsrv:query:opresult:set"
+	RedisProcSrvOpBatchTaskLockKeyPrefix       = BKCacheKeyV3Prefix + "lock:procopbatch:"
+	RedisProcSrvProcStateReconcileLockKey      = BKCacheKeyV3Prefix + "lock:procstatereconcile"
+	RedisCloudSyncInstancePendingStart         = BKCacheKeyV3Prefix + "cloudsyncinstancependingstart:list"
+	RedisCloudSyncInstanceStarted              = BKCacheKeyV3Prefix + "cloudsyncinstancestarted:list"
+	RedisCloudSyncInstancePendingStop          = BKCacheKeyV3Prefix + "cloudsyncinstancependingstop:list"
+	RedisCloudSyncStartLockKey                 = BKCacheKeyV3Prefix + "lock:cloudsyncstart"
+	RedisCoreSrvAsstInstLimitLockKeyPrefix     = BKCacheKeyV3Prefix + "lock:asstinstlimit:"
+	RedisCoreSrvRecycleBinPurgeLockKey         = BKCacheKeyV3Prefix + "lock:recyclebinpurge"
+	RedisCoreSrvRecycleBinRestoreLockKeyPrefix = BKCacheKeyV3Prefix + "lock:recyclebinrestore:"
 )
 
 // association fields
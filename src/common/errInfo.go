@@ -173,6 +173,17 @@ const (
 	CCErrAPIGetUserResourceAuthStatusFailed    = 1100002
 	CCErrAPINoObjectInstancesIsFound           = 1100003
 
+	// CCErrAPITokenInvalid the api token is invalid, expired or revoked
+	CCErrAPITokenInvalid = 1100004
+	// CCErrAPITokenScopeNotAllowed the api token has no scope for the request
+	CCErrAPITokenScopeNotAllowed = 1100005
+	// CCErrAPITokenManagedByToken api tokens can not be managed with an api token
+	CCErrAPITokenManagedByToken = 1100006
+	// CCErrAPILocalAuthNotEnabled the local authorization is not enabled
+	CCErrAPILocalAuthNotEnabled = 1100007
+	// CCErrAPIRequestRateLimited the request exceeds the rate limit
+	CCErrAPIRequestRateLimited = 1100008
+
 	// toposerver 1101XXX
 	// CCErrTopoInstCreateFailed unable to create the instance
 	CCErrTopoInstCreateFailed = 1101000
@@ -386,6 +397,10 @@ const (
 	CCErrorTopoMainlineObjectCanNotBeChanged   = 1101085
 	CCErrorTopoGetAuthorizedBusinessListFailed = 1101086
 	CCErrTopoArchiveBusinessHasHost            = 1101087
+	// CCErrorTopoAssociationOnDeleteInvalid the on delete action of the association is invalid
+	CCErrorTopoAssociationOnDeleteInvalid = 1101088
+	// CCErrorTopoAssociationInstanceCountExceedLimit the instance has been associated with as many instances as the association allows
+	CCErrorTopoAssociationInstanceCountExceedLimit = 1101089
 
 	// objectcontroller 1102XXX
 
@@ -499,6 +514,22 @@ const (
 	CCErrProcQueryTaskWaitOPFail        = 1108021
 	CCErrProcQueryTaskOPErrFail         = 1108022
 	CCErrProcCreateTemplateFail         = 1108023
+	CCErrProcConfigTemplateNotFound     = 1108024
+	CCErrProcTemplateVersionNotFound    = 1108025
+	CCErrProcRenderConfigFileFail       = 1108026
+	CCErrProcGenerateConfigFileFail     = 1108027
+	CCErrProcDistributeConfigFileFail   = 1108028
+	CCErrProcTemplateVersionStatusWrong = 1108029
+	CCErrProcTemplateVersionSelfReview  = 1108030
+	CCErrProcTemplateVersionNoRollback  = 1108031
+	CCErrProcOpBatchTaskNotFound        = 1108032
+	CCErrProcOpBatchTaskStatusWrong     = 1108033
+	CCErrProcOpBatchStrategyInvalid     = 1108034
+	CCErrProcPortInvalid                = 1108035
+	CCErrProcPortConflict               = 1108036
+	CCErrProcTemplateVariableInvalid    = 1108037
+	CCErrProcTemplateVariableNotFound   = 1108038
+	CCErrProcTemplateVariableDuplicate  = 1108039
 
 	// auditlog 1109XXX
 	CCErrAuditSaveLogFaile      = 1109001
@@ -611,6 +642,14 @@ const (
 	CCErrCollectNetHistorySearchFail           = 1112016
 	CCErrCollectNetDeviceUpdateFail            = 1112017
 	CCErrCollectNetPropertyUpdateFail          = 1112018
+	// CCErrCollectNetCredentialInvalid the net collect credential is invalid: %s
+	CCErrCollectNetCredentialInvalid = 1112019
+	// CCErrCollectNetCredentialNotExist the net collect credential does not exist
+	CCErrCollectNetCredentialNotExist = 1112020
+	// CCErrCollectNetCredentialInUse the net collect credential is used by the collectors or the devices
+	CCErrCollectNetCredentialInUse = 1112021
+	// CCErrCollectNetCredentialKeyNotSet the key to encrypt the credentials is not configured
+	CCErrCollectNetCredentialKeyNotSet = 1112022
 
 	// coreservice 1113xxx
 
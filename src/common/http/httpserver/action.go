@@ -23,6 +23,10 @@ type Action struct {
 	Params        []*restful.Parameter // List of parameters associated with the action.
 	Handler       restful.RouteFunction
 	FilterHandler []restful.FilterFunction
+	// ReadSample and WriteSample the samples of the request and the response bodies, which
+	// describe the action in the openapi document, they can be nil if they are not known.
+	ReadSample  interface{}
+	WriteSample interface{}
 }
 
 func NewAction(verb, path string, params []*restful.Parameter, handler restful.RouteFunction, filters []restful.FilterFunction) *Action {
@@ -0,0 +1,180 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APITokenType the type of the api token
+type APITokenType string
+
+const (
+	// APITokenTypePersonal the token acts as the user who created it
+	APITokenTypePersonal APITokenType = "personal"
+	// APITokenTypeService the token acts as a service account, which is used by automation
+	APITokenTypeService APITokenType = "service"
+)
+
+const (
+	// APITokenPrefix the prefix of the plain api token, which makes the token easy to recognize
+	APITokenPrefix = "cc_"
+
+	// APITokenFieldTokenHash the sha256 hex digest of the plain token
+	APITokenFieldTokenHash = "token_hash"
+	// APITokenFieldRevoked whether the token is revoked
+	APITokenFieldRevoked = "revoked"
+	// APITokenFieldRevokeTime the time when the token is revoked
+	APITokenFieldRevokeTime = "revoke_time"
+	// APITokenFieldExpireTime the time after which the token is not valid
+	APITokenFieldExpireTime = "expire_time"
+	// APITokenFieldLastUsedTime the time when the token is used last time
+	APITokenFieldLastUsedTime = "last_used_time"
+	// APITokenFieldUser the user name the requests with the token act as
+	APITokenFieldUser = "bk_user"
+	// APITokenFieldCreator the user who created the token
+	APITokenFieldCreator = "creator"
+
+	// APITokenScopeAll matches any backend or any access
+	APITokenScopeAll = "*"
+	// APITokenAccessRead allows the requests which only read resources
+	APITokenAccessRead = "read"
+	// APITokenAccessWrite allows any requests, including the read ones
+	APITokenAccessWrite = "write"
+)
+
+// APITokenScopeResources the backends which can be granted to an api token, they
+// are the same as the request types the apiserver proxies requests to.
+var APITokenScopeResources = []string{"topo", "host", "proc", "event", "collect"}
+
+// APIToken a personal or service account api token, only the hash of the token is
+// stored, the plain token is returned once when it is created.
+type APIToken struct {
+	ID      int64        `field:"id" json:"id" bson:"id"`
+	OwnerID string       `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Name    string       `field:"name" json:"name" bson:"name"`
+	Type    APITokenType `field:"type" json:"type" bson:"type"`
+	// User the user name the requests with this token act as, it is the creator for a
+	// personal token, and the service account name for a service token.
+	User    string `field:"bk_user" json:"bk_user" bson:"bk_user"`
+	Creator string `field:"creator" json:"creator" bson:"creator"`
+	// TokenHash is never returned by the api
+	TokenHash string `field:"token_hash" json:"-" bson:"token_hash"`
+	// TokenPrefix the first characters of the plain token, used to recognize the token
+	TokenPrefix  string     `field:"token_prefix" json:"token_prefix" bson:"token_prefix"`
+	Scopes       []string   `field:"scopes" json:"scopes" bson:"scopes"`
+	ExpireTime   *time.Time `field:"expire_time" json:"expire_time" bson:"expire_time"`
+	Revoked      bool       `field:"revoked" json:"revoked" bson:"revoked"`
+	RevokeTime   *time.Time `field:"revoke_time" json:"revoke_time" bson:"revoke_time"`
+	LastUsedTime *time.Time `field:"last_used_time" json:"last_used_time" bson:"last_used_time"`
+	CreateTime   time.Time  `field:"create_time" json:"create_time" bson:"create_time"`
+}
+
+// Expired check whether the token is expired at the time.
+func (t *APIToken) Expired(now time.Time) bool {
+	return t.ExpireTime != nil && !now.Before(*t.ExpireTime)
+}
+
+// Allow check whether the token scopes allow the access to the backend resource.
+// a scope is in the form of <resource>:<access>, both of which can be *, and the
+// write access implies the read access.
+func (t *APIToken) Allow(resource, access string) bool {
+	for _, scope := range t.Scopes {
+		res, acc := splitAPITokenScope(scope)
+		if res != APITokenScopeAll && res != resource {
+			continue
+		}
+		if acc == APITokenScopeAll || acc == APITokenAccessWrite || acc == access {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAPITokenScope validate the scope is in the form of <resource>:<access>.
+func ValidateAPITokenScope(scope string) error {
+	res, acc := splitAPITokenScope(scope)
+	if acc != APITokenScopeAll && acc != APITokenAccessRead && acc != APITokenAccessWrite {
+		return fmt.Errorf("invalid access %s of scope %s", acc, scope)
+	}
+	if res == APITokenScopeAll {
+		return nil
+	}
+	for _, item := range APITokenScopeResources {
+		if res == item {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid resource %s of scope %s", res, scope)
+}
+
+func splitAPITokenScope(scope string) (resource, access string) {
+	idx := strings.Index(scope, ":")
+	if idx < 0 {
+		return scope, ""
+	}
+	return scope[:idx], scope[idx+1:]
+}
+
+// CreateAPITokenRequest the api token to be created, the token never expires if
+// ExpireTime is not set.
+type CreateAPITokenRequest struct {
+	Name       string       `json:"name"`
+	Type       APITokenType `json:"type"`
+	User       string       `json:"bk_user"`
+	Scopes     []string     `json:"scopes"`
+	ExpireTime *time.Time   `json:"expire_time"`
+}
+
+// CreatedAPIToken the created api token with the plain token, which can not be
+// got from the api any more.
+type CreatedAPIToken struct {
+	APIToken `json:",inline" bson:",inline"`
+	Token    string `json:"token"`
+}
+
+// RevokeAPITokenRequest the api tokens to be revoked
+type RevokeAPITokenRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// ValidateAPITokenRequest the plain token to be validated
+type ValidateAPITokenRequest struct {
+	Token string `json:"token"`
+}
+
+// QueryAPITokensResult query api tokens result
+type QueryAPITokensResult struct {
+	Count uint64     `json:"count"`
+	Info  []APIToken `json:"info"`
+}
+
+// CreateAPITokenResult create api token api http response return result struct
+type CreateAPITokenResult struct {
+	BaseResp `json:",inline"`
+	Data     CreatedAPIToken `json:"data"`
+}
+
+// SearchAPITokensResult search api tokens api http response return result struct
+type SearchAPITokensResult struct {
+	BaseResp `json:",inline"`
+	Data     QueryAPITokensResult `json:"data"`
+}
+
+// ValidateAPITokenResult validate api token api http response return result struct
+type ValidateAPITokenResult struct {
+	BaseResp `json:",inline"`
+	Data     APIToken `json:"data"`
+}
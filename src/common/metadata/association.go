@@ -34,6 +34,12 @@ const (
 	// AssociationFieldAssociationId auto incr id
 	AssociationFieldAssociationId   = "id"
 	AssociationFieldAssociationKind = "bk_asst_id"
+	// AssociationFieldOnDelete the action when the associated instance is deleted
+	AssociationFieldOnDelete = "on_delete"
+	// AssociationFieldMaxDestCount the max number of destination instances of a source instance
+	AssociationFieldMaxDestCount = "max_dest_count"
+	// AssociationFieldMaxSrcCount the max number of source instances of a destination instance
+	AssociationFieldMaxSrcCount = "max_src_count"
 )
 
 type SearchAssociationTypeRequest struct {
@@ -204,12 +210,15 @@ type AssociationOnDeleteAction string
 type AssociationMapping string
 
 const (
-	// this is a default action, which is do nothing when a association between object is deleted.
+	// this is a default action, which restrict the destination instance to be deleted while it is still
+	// associated by a source instance, and only unlink the association when the source instance is deleted.
 	NoAction AssociationOnDeleteAction = "none"
-	// delete related source object instances when the association is deleted.
+	// delete related source object instances when the destination instance is deleted.
 	DeleteSource AssociationOnDeleteAction = "delete_src"
-	// delete related destination object instances when the association is deleted.
+	// delete related destination object instances when the source instance is deleted.
 	DeleteDestinatioin AssociationOnDeleteAction = "delete_dest"
+	// only unlink the association when either the source or the destination instance is deleted.
+	Unlink AssociationOnDeleteAction = "unlink"
 
 	// the source object can be related with only one destination object
 	OneToOneMapping AssociationMapping = "1:1"
@@ -219,6 +228,23 @@ const (
 	ManyToManyMapping AssociationMapping = "n:n"
 )
 
+const (
+	// AssociationSideSource the source side of an association
+	AssociationSideSource = "src"
+	// AssociationSideDestination the destination side of an association
+	AssociationSideDestination = "dest"
+)
+
+// IsValid check whether the on delete action is supported, empty means the default action.
+func (a AssociationOnDeleteAction) IsValid() bool {
+	switch a {
+	case "", NoAction, DeleteSource, DeleteDestinatioin, Unlink:
+		return true
+	default:
+		return false
+	}
+}
+
 // Association defines the association between two objects.
 type Association struct {
 	ID      int64  `field:"id" json:"id" bson:"id"`
@@ -241,6 +267,12 @@ type Association struct {
 	Mapping AssociationMapping `field:"mapping" json:"mapping" bson:"mapping"`
 	// describe the action when this association is deleted.
 	OnDelete AssociationOnDeleteAction `field:"on_delete" json:"on_delete" bson:"on_delete"`
+	// the max number of destination instances which a source instance can be associated with,
+	// 0 means there is no more limit than the mapping.
+	MaxDestCount int64 `field:"max_dest_count" json:"max_dest_count" bson:"max_dest_count"`
+	// the max number of source instances which a destination instance can be associated by,
+	// 0 means there is no more limit than the mapping.
+	MaxSrcCount int64 `field:"max_src_count" json:"max_src_count" bson:"max_src_count"`
 	// describe whether this association is a pre-defined association or not,
 	// if true, it means this association is used by cmdb itself.
 	IsPre *bool `field:"ispre" json:"ispre" bson:"ispre"`
@@ -287,6 +319,20 @@ func (a *Association) CanUpdate() (field string, can bool) {
 	return "", true
 }
 
+// InstanceLimits return the max number of destination instances of a source instance, and the max number of
+// source instances of a destination instance, which combine the mapping and the max count constraints.
+// 0 means no limit.
+func (a *Association) InstanceLimits() (maxDestPerSrc, maxSrcPerDest int64) {
+	maxDestPerSrc, maxSrcPerDest = a.MaxDestCount, a.MaxSrcCount
+	switch a.Mapping {
+	case OneToOneMapping:
+		maxDestPerSrc, maxSrcPerDest = 1, 1
+	case OneToManyMapping:
+		maxSrcPerDest = 1
+	}
+	return maxDestPerSrc, maxSrcPerDest
+}
+
 // Parse load the data from mapstr attribute into attribute instance
 func (cli *Association) Parse(data mapstr.MapStr) (*Association, error) {
 	//TODO support parse metadata params
@@ -337,6 +383,51 @@ func (asst InstAsst) GetInstID(objID string) (instID int64, ok bool) {
 	}
 }
 
+// InstAsstDeletePlanRequest the instances which are going to be deleted
+type InstAsstDeletePlanRequest struct {
+	ObjectID string  `json:"bk_obj_id"`
+	InstIDs  []int64 `json:"bk_inst_ids"`
+}
+
+// InstAsstDeleteRef an instance which will be deleted
+type InstAsstDeleteRef struct {
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+	// ObjectAsstID the association which cascade delete this instance,
+	// it's empty for the instances which are requested to be deleted.
+	ObjectAsstID string `json:"bk_obj_asst_id"`
+}
+
+// InstAsstDeletePlan describe what will happen to the associations when some instances are deleted,
+// according to the on delete action of the object association.
+type InstAsstDeletePlan struct {
+	// Instances all the instances to be deleted, including the ones deleted by cascade.
+	Instances []InstAsstDeleteRef `json:"instances"`
+	// Restricted the associations which prevent the instances from being deleted.
+	Restricted []InstAsst `json:"restricted"`
+	// Unlinked the associations which will be removed with the instances.
+	Unlinked []InstAsst `json:"unlinked"`
+}
+
+// FindAssociationViolationsRequest the object associations to be checked, all the object associations
+// are checked if it's empty.
+type FindAssociationViolationsRequest struct {
+	ObjectAsstIDs []string `json:"bk_obj_asst_ids"`
+}
+
+// AssociationViolation an instance which is associated with more instances than the object association allows.
+type AssociationViolation struct {
+	ObjectAsstID string             `json:"bk_obj_asst_id"`
+	Mapping      AssociationMapping `json:"mapping"`
+	// Side the side of the instance in the association, src or dest.
+	Side     string `json:"side"`
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+	// Count the number of instances associated on the other side.
+	Count int64 `json:"count"`
+	Limit int64 `json:"limit"`
+}
+
 type InstNameAsst struct {
 	ID         string `json:"id"`
 	ObjID      string `json:"bk_obj_id"`
@@ -408,6 +499,12 @@ type TopoInstRst struct {
 	Child    []*TopoInstRst `json:"child"`
 }
 
+// SearchTopoInstResult search business topo api http response return result struct
+type SearchTopoInstResult struct {
+	BaseResp `json:",inline"`
+	Data     []*TopoInstRst `json:"data"`
+}
+
 // ConditionItem subcondition
 type ConditionItem struct {
 	Field    string      `json:"field,omitempty"`
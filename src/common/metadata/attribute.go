@@ -33,6 +33,7 @@ const (
 	AttributeFieldIsOnly          = "isonly"
 	AttributeFieldIsSystem        = "bk_issystem"
 	AttributeFieldIsAPI           = "bk_isapi"
+	AttributeFieldIsAuthProtected = "bk_isauthprotected"
 	AttributeFieldPropertyType    = "bk_property_type"
 	AttributeFieldOption          = "option"
 	AttributeFieldDescription     = "description"
@@ -61,6 +62,7 @@ type Attribute struct {
 	IsOnly            bool        `field:"isonly" json:"isonly" bson:"isonly"`
 	IsSystem          bool        `field:"bk_issystem" json:"bk_issystem" bson:"bk_issystem"`
 	IsAPI             bool        `field:"bk_isapi" json:"bk_isapi" bson:"bk_isapi"`
+	IsAuthProtected   bool        `field:"bk_isauthprotected" json:"bk_isauthprotected" bson:"bk_isauthprotected"`
 	PropertyType      string      `field:"bk_property_type" json:"bk_property_type" bson:"bk_property_type"`
 	Option            interface{} `field:"option" json:"option" bson:"option"`
 	Description       string      `field:"description" json:"description" bson:"description"`
@@ -12,6 +12,10 @@
 
 package metadata
 
+import (
+	"encoding/json"
+)
+
 type AuthBathVerifyRequest struct {
 	Resources []AuthResource `json:"resources"`
 }
@@ -34,3 +38,55 @@ type AuthBathVerifyResult struct {
 	// the detailed reason for this authorize.
 	Reason string `json:"reason"`
 }
+
+// AuthDenialAuditTarget the op_target of the audit logs of the requests denied by the authorization
+const AuthDenialAuditTarget = "auth"
+
+// AuthExplainRequest the request to be explained, the user is the current user if it's not set.
+type AuthExplainRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body"`
+	User   string          `json:"bk_user"`
+}
+
+// AuthExplainResource a resource the request is parsed to, with the authorize decision on it.
+type AuthExplainResource struct {
+	BizID        int64              `json:"bk_biz_id"`
+	ResourceType string             `json:"resource_type"`
+	Action       string             `json:"action"`
+	Name         string             `json:"name"`
+	ResourceID   int64              `json:"resource_id"`
+	ResourceIDEx string             `json:"resource_id_ex"`
+	ParentLayers []AuthExplainLayer `json:"parent_layers"`
+	// Skipped the resource is not authorized, because the api server skips it.
+	Skipped bool   `json:"skipped"`
+	Passed  bool   `json:"is_pass"`
+	Reason  string `json:"reason"`
+}
+
+// AuthExplainLayer a parent layer of the explained resource
+type AuthExplainLayer struct {
+	ResourceType string `json:"resource_type"`
+	ResourceID   int64  `json:"resource_id"`
+}
+
+// AuthExplainResult how a request is parsed by the auth parser, and the authorize decision on it.
+type AuthExplainResult struct {
+	User            string `json:"bk_user"`
+	SupplierAccount string `json:"bk_supplier_account"`
+	Method          string `json:"method"`
+	Path            string `json:"path"`
+	// ParseError the request can not be parsed, it's denied by the api server in this case.
+	ParseError string                `json:"parse_error"`
+	Passed     bool                  `json:"is_pass"`
+	Resources  []AuthExplainResource `json:"resources"`
+}
+
+// AuthDenial the content of the audit log of a request denied by the authorization
+type AuthDenial struct {
+	Method    string                `json:"method"`
+	Path      string                `json:"path"`
+	Reason    string                `json:"reason"`
+	Resources []AuthExplainResource `json:"resources"`
+}
@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common/util"
+)
+
+// ReportSourceDiscover marks the reports of the discovered middlewares and the collect plugins,
+// they are confirmed by the same pipeline as the net collect reports
+const ReportSourceDiscover = "discover"
+
+// DiscoverPolicy the policy to apply the discovered instances of a model
+type DiscoverPolicy struct {
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	// AutoCreate creates the discovered new instances without confirmation
+	AutoCreate bool `json:"auto_create" bson:"auto_create"`
+	// ConfirmFields the changes of these fields are applied after confirmed, the others are applied directly
+	ConfirmFields []string `json:"confirm_fields" bson:"confirm_fields"`
+	OwnerID       string   `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	LastTime      Time     `json:"last_time" bson:"last_time"`
+}
+
+// DefaultDiscoverPolicy the policy of the models without one, the discovered data is applied directly
+func DefaultDiscoverPolicy(objID string) DiscoverPolicy {
+	return DiscoverPolicy{ObjectID: objID, AutoCreate: true}
+}
+
+// NeedConfirm returns whether the change of the field should be confirmed
+func (p DiscoverPolicy) NeedConfirm(field string) bool {
+	return util.InStrArr(p.ConfirmFields, field)
+}
+
+type ParamSearchDiscoverPolicy struct {
+	ObjectID string `json:"bk_obj_id"`
+}
+
+type RspDiscoverPolicy struct {
+	Count uint64           `json:"count"`
+	Info  []DiscoverPolicy `json:"info"`
+}
+
+// DiscoverPolicyResult search discover policies api http response return result struct
+type DiscoverPolicyResult struct {
+	BaseResp `json:",inline"`
+	Data     RspDiscoverPolicy `json:"data"`
+}
+
+// DiscoverReportSummary the pending discover reports of a model
+type DiscoverReportSummary struct {
+	ObjectID   string         `json:"bk_obj_id"`
+	ObjectName string         `json:"bk_obj_name"`
+	LastTime   Time           `json:"last_time"`
+	Statistics map[string]int `json:"statistics"`
+}
+
+// DiscoverReportSummaryResult search discover report summary api http response return result struct
+type DiscoverReportSummaryResult struct {
+	BaseResp `json:",inline"`
+	Data     []DiscoverReportSummary `json:"data"`
+}
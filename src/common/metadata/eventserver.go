@@ -39,6 +39,12 @@ type RspSubscriptionSearch struct {
 	Info  []Subscription `json:"info"`
 }
 
+// SubscriptionSearchResult search subscriptions api http response return result struct
+type SubscriptionSearchResult struct {
+	BaseResp `json:",inline"`
+	Data     RspSubscriptionSearch `json:"data"`
+}
+
 type ParamSubscriptionTelnet struct {
 	CallbackUrl string `json:"callback_url"`
 }
@@ -52,6 +58,12 @@ type RspSubscriptionTestCallback struct {
 	ResponseBody string `json:"response_body"`
 }
 
+// SubscriptionTestCallbackResult test subscription callback api http response return result struct
+type SubscriptionTestCallbackResult struct {
+	BaseResp `json:",inline"`
+	Data     RspSubscriptionTestCallback `json:"data"`
+}
+
 // Subscription define
 type Subscription struct {
 	SubscriptionID   int64       `bson:"subscription_id" json:"subscription_id"`
@@ -168,6 +180,8 @@ const (
 const (
 	EventObjTypeProcModule     = "processmodule"
 	EventObjTypeModuleTransfer = "moduletransfer"
+	// EventObjTypeProcState the running state of a process instance is changed
+	EventObjTypeProcState = "processstate"
 )
 
 // ConfirmMode define
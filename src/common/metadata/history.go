@@ -0,0 +1,76 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+	"time"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+
+	"github.com/coccyx/timeparser"
+)
+
+// HistoryQueryOption the option of a point-in-time query, which reconstruct the data
+// as it was at the given time from the audit logs.
+type HistoryQueryOption struct {
+	// AsOf an unix timestamp in seconds, or a time string such as "2019-05-10 12:00:00" in UTC.
+	AsOf interface{} `json:"as_of"`
+}
+
+// Time parse the as of time
+func (h HistoryQueryOption) Time() (time.Time, error) {
+	switch val := h.AsOf.(type) {
+	case nil:
+		return time.Time{}, errors.New("as_of is not set")
+	case string:
+		return timeparser.TimeParserInLocation(val, time.UTC)
+	default:
+		ts, err := util.GetInt64ByInterface(val)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(ts, 0).UTC(), nil
+	}
+}
+
+// InstanceAsOf the instance data as it was at a given time
+type InstanceAsOf struct {
+	ObjectID string    `json:"bk_obj_id"`
+	InstID   int64     `json:"bk_inst_id"`
+	AsOf     time.Time `json:"as_of"`
+	// Exists is false when the instance is not created yet or is already deleted at that time.
+	Exists bool          `json:"exists"`
+	Data   mapstr.MapStr `json:"data"`
+}
+
+// HostModulesAsOf the module membership of a host as it was at a given time
+type HostModulesAsOf struct {
+	HostID  int64     `json:"bk_host_id"`
+	AsOf    time.Time `json:"as_of"`
+	BizID   int64     `json:"bk_biz_id"`
+	Modules []Ref     `json:"modules"`
+}
+
+// InstanceAsOfResult find instance as of api http response return result struct
+type InstanceAsOfResult struct {
+	BaseResp `json:",inline"`
+	Data     InstanceAsOf `json:"data"`
+}
+
+// HostModulesAsOfResult find host modules as of api http response return result struct
+type HostModulesAsOfResult struct {
+	BaseResp `json:",inline"`
+	Data     HostModulesAsOf `json:"data"`
+}
@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+const (
+	// AuthRoleFieldName the name of the role
+	AuthRoleFieldName = "name"
+	// AuthRoleFieldDescription the description of the role
+	AuthRoleFieldDescription = "description"
+	// AuthRoleFieldRules the rules of the role
+	AuthRoleFieldRules = "rules"
+	// AuthRoleBindingFieldRoleID the role id of the role binding
+	AuthRoleBindingFieldRoleID = "role_id"
+	// AuthRoleBindingFieldUsers the users of the role binding
+	AuthRoleBindingFieldUsers = "users"
+
+	// AuthRuleMatchAll matches any resource type or any action in a role rule
+	AuthRuleMatchAll = "*"
+)
+
+// AuthRole a role of the local authorization, which grants the actions on the resource types.
+type AuthRole struct {
+	ID          int64          `field:"id" json:"id" bson:"id"`
+	OwnerID     string         `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Name        string         `field:"name" json:"name" bson:"name"`
+	Description string         `field:"description" json:"description" bson:"description"`
+	Rules       []AuthRoleRule `field:"rules" json:"rules" bson:"rules"`
+	Creator     string         `field:"creator" json:"creator" bson:"creator"`
+	CreateTime  time.Time      `field:"create_time" json:"create_time" bson:"create_time"`
+	LastTime    time.Time      `field:"last_time" json:"last_time" bson:"last_time"`
+}
+
+// AuthRoleRule grants the actions on the resource types, the resource types are the ones of
+// the auth meta, such as modelInstance, and the actions are such as create, findMany.
+type AuthRoleRule struct {
+	ResourceTypes []string `field:"resource_types" json:"resource_types" bson:"resource_types"`
+	Actions       []string `field:"actions" json:"actions" bson:"actions"`
+}
+
+// AuthRoleBinding bind a role to the users within a scope. the role applies to the resources
+// of all the businesses if BizID is 0, or else only to the resources of the business, and it
+// applies to all the instances if InstIDs is empty, or else only to the listed instances.
+type AuthRoleBinding struct {
+	ID         int64     `field:"id" json:"id" bson:"id"`
+	OwnerID    string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+	RoleID     int64     `field:"role_id" json:"role_id" bson:"role_id"`
+	Users      []string  `field:"users" json:"users" bson:"users"`
+	BizID      int64     `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
+	InstIDs    []int64   `field:"inst_ids" json:"inst_ids" bson:"inst_ids"`
+	Creator    string    `field:"creator" json:"creator" bson:"creator"`
+	CreateTime time.Time `field:"create_time" json:"create_time" bson:"create_time"`
+}
+
+// AuthPermission an effective permission of a user, which is the rules of a role within
+// the scope of a binding.
+type AuthPermission struct {
+	Binding AuthRoleBinding `json:"binding"`
+	Rules   []AuthRoleRule  `json:"rules"`
+}
+
+// GetUserAuthPermissionsRequest the user whose permissions are got
+type GetUserAuthPermissionsRequest struct {
+	User string `json:"bk_user"`
+}
+
+// QueryAuthRolesResult query auth roles result
+type QueryAuthRolesResult struct {
+	Count uint64     `json:"count"`
+	Info  []AuthRole `json:"info"`
+}
+
+// QueryAuthRoleBindingsResult query auth role bindings result
+type QueryAuthRoleBindingsResult struct {
+	Count uint64            `json:"count"`
+	Info  []AuthRoleBinding `json:"info"`
+}
+
+// SearchAuthRolesResult search auth roles api http response return result struct
+type SearchAuthRolesResult struct {
+	BaseResp `json:",inline"`
+	Data     QueryAuthRolesResult `json:"data"`
+}
+
+// SearchAuthRoleBindingsResult search auth role bindings api http response return result struct
+type SearchAuthRoleBindingsResult struct {
+	BaseResp `json:",inline"`
+	Data     QueryAuthRoleBindingsResult `json:"data"`
+}
+
+// GetUserAuthPermissionsResult get user auth permissions api http response return result struct
+type GetUserAuthPermissionsResult struct {
+	BaseResp `json:",inline"`
+	Data     []AuthPermission `json:"data"`
+}
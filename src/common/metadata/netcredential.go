@@ -0,0 +1,80 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// snmp versions supported by the net collect credential
+const (
+	SnmpVersion2c = "v2c"
+	SnmpVersion3  = "v3"
+)
+
+// security levels of the snmp v3 credential
+const (
+	SnmpSecurityLevelNoAuthNoPriv = "noAuthNoPriv"
+	SnmpSecurityLevelAuthNoPriv   = "authNoPriv"
+	SnmpSecurityLevelAuthPriv     = "authPriv"
+)
+
+// authentication protocols of the snmp v3 credential
+const (
+	SnmpAuthProtocolMD5 = "MD5"
+	SnmpAuthProtocolSHA = "SHA"
+)
+
+// privacy protocols of the snmp v3 credential
+const (
+	SnmpPrivProtocolDES = "DES"
+	SnmpPrivProtocolAES = "AES"
+)
+
+// NetcollectCredentialMask replace the secrets of the credentials in the responses,
+// a secret equal to the mask in the update request means the secret is not changed
+const NetcollectCredentialMask = "******"
+
+// NetcollectCredential a named set of snmp credentials referenced by the collectors and the devices,
+// the community, the auth key and the priv key are stored encrypted and never returned in plaintext
+type NetcollectCredential struct {
+	CredentialID  uint64     `json:"credential_id" bson:"credential_id"`
+	Name          string     `json:"name" bson:"name"`
+	Version       string     `json:"version" bson:"version"`
+	Community     string     `json:"community,omitempty" bson:"community"`
+	SecurityName  string     `json:"security_name,omitempty" bson:"security_name"`
+	SecurityLevel string     `json:"security_level,omitempty" bson:"security_level"`
+	AuthProtocol  string     `json:"auth_protocol,omitempty" bson:"auth_protocol"`
+	AuthKey       string     `json:"auth_key,omitempty" bson:"auth_key"`
+	PrivProtocol  string     `json:"priv_protocol,omitempty" bson:"priv_protocol"`
+	PrivKey       string     `json:"priv_key,omitempty" bson:"priv_key"`
+	Description   string     `json:"description" bson:"description"`
+	OwnerID       string     `json:"-" bson:"bk_supplier_account"`
+	CreateTime    *time.Time `json:"create_time,omitempty" bson:"create_time"`
+	LastTime      *time.Time `json:"last_time,omitempty" bson:"last_time"`
+}
+
+type AddNetCredentialResult struct {
+	CredentialID uint64 `json:"credential_id"`
+}
+
+type SearchNetCredential struct {
+	Count uint64                 `json:"count"`
+	Info  []NetcollectCredential `json:"info"`
+}
+
+// SearchNetCredentialResult search net credentials api http response return result struct
+type SearchNetCredentialResult struct {
+	BaseResp `json:",inline"`
+	Data     SearchNetCredential `json:"data"`
+}
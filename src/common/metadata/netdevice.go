@@ -17,15 +17,17 @@ import (
 )
 
 type NetcollectDevice struct {
-	DeviceID    uint64     `json:"device_id,omitempty" bson:"device_id,omitempty"`
-	DeviceName  string     `json:"device_name,omitempty" bson:"device_name,omitempty"`
-	DeviceModel string     `json:"device_model,omitempty" bson:"device_model,omitempty"`
-	ObjectID    string     `json:"bk_obj_id" bson:"bk_obj_id,omitempty"`
-	ObjectName  string     `json:"bk_obj_name,omitempty" bson:"-"`
-	BkVendor    string     `json:"bk_vendor,omitempty" bson:"bk_vendor,omitempty"`
-	OwnerID     string     `json:"-" bson:"bk_supplier_account,omitempty"`
-	CreateTime  *time.Time `field:"create_time,omitempty" json:"create_time,omitempty" bson:"create_time,omitempty"`
-	LastTime    *time.Time `field:"last_time" json:"last_time,omitempty" bson:"last_time,omitempty"`
+	DeviceID    uint64 `json:"device_id,omitempty" bson:"device_id,omitempty"`
+	DeviceName  string `json:"device_name,omitempty" bson:"device_name,omitempty"`
+	DeviceModel string `json:"device_model,omitempty" bson:"device_model,omitempty"`
+	ObjectID    string `json:"bk_obj_id" bson:"bk_obj_id,omitempty"`
+	ObjectName  string `json:"bk_obj_name,omitempty" bson:"-"`
+	BkVendor    string `json:"bk_vendor,omitempty" bson:"bk_vendor,omitempty"`
+	// CredentialID the credential used to collect the device, the credential of the collector is used if it's not set
+	CredentialID uint64     `json:"credential_id,omitempty" bson:"credential_id,omitempty"`
+	OwnerID      string     `json:"-" bson:"bk_supplier_account,omitempty"`
+	CreateTime   *time.Time `field:"create_time,omitempty" json:"create_time,omitempty" bson:"create_time,omitempty"`
+	LastTime     *time.Time `field:"last_time" json:"last_time,omitempty" bson:"last_time,omitempty"`
 }
 
 type NetcollectProperty struct {
@@ -55,6 +57,12 @@ type RspNetcollectorSearch struct {
 	Info  []Netcollector `json:"info"`
 }
 
+// NetcollectorSearchResult search net collectors api http response return result struct
+type NetcollectorSearchResult struct {
+	BaseResp `json:",inline"`
+	Data     RspNetcollectorSearch `json:"data"`
+}
+
 type Netcollector struct {
 	CloudID       int64              `json:"bk_cloud_id" bson:"bk_cloud_id"`
 	CloudName     string             `json:"bk_cloud_name" bson:"-"`
@@ -95,7 +103,9 @@ const (
 type NetcollectConfig struct {
 	ScanRange []string `json:"scan_range" bson:"scan_range"`
 	Period    string   `json:"period" bson:"period"`
-	Community string   `json:"community" bson:"community"`
+	// Community the snmp v2c community encrypted with the credential key, use the credential instead
+	Community    string `json:"community" bson:"community"`
+	CredentialID uint64 `json:"credential_id" bson:"credential_id"`
 }
 
 type ParamSearchNetcollectReport struct {
@@ -106,6 +116,7 @@ type ParamSearchNetcollectReport struct {
 	CloudID   int64    `json:"bk_cloud_id"`
 	InnerIP   string   `json:"bk_host_innerip"`
 	LastTime  []Time   `json:"last_time"`
+	Source    string   `json:"source"`
 	Page      BasePage `json:"page"`
 }
 
@@ -116,15 +127,33 @@ type NetcollectReportSummary struct {
 	Statistics map[string]int `json:"statistics"`
 }
 
+// NetcollectReportSummaryResult search net collect report summary api http response return result struct
+type NetcollectReportSummaryResult struct {
+	BaseResp `json:",inline"`
+	Data     []NetcollectReportSummary `json:"data"`
+}
+
 type RspNetcollectReport struct {
 	Count uint64             `json:"count"`
 	Info  []NetcollectReport `json:"info"`
 }
+
+// NetcollectReportResult search net collect reports api http response return result struct
+type NetcollectReportResult struct {
+	BaseResp `json:",inline"`
+	Data     RspNetcollectReport `json:"data"`
+}
 type RspNetcollectHistory struct {
 	Count uint64              `json:"count"`
 	Info  []NetcollectHistory `json:"info"`
 }
 
+// NetcollectHistoryResult search net collect histories api http response return result struct
+type NetcollectHistoryResult struct {
+	BaseResp `json:",inline"`
+	Data     RspNetcollectHistory `json:"data"`
+}
+
 type ParamNetcollectComfirm struct {
 	Reports []NetcollectReport `json:"reports"`
 }
@@ -144,6 +173,16 @@ type NetcollectReport struct {
 	LastTime     Time                          `json:"last_time" bson:"last_time"`
 	Attributes   []NetcollectReportAttribute   `json:"attributes" bson:"attributes"`
 	Associations []NetcollectReportAssociation `json:"associations" bson:"associations"`
+
+	// Source is where the report comes from, empty for the net collect reports
+	Source string `json:"source,omitempty" bson:"source,omitempty"`
+	// KeyField is the property of the instance the InstKey matches, the instance name by default
+	KeyField string `json:"key_field,omitempty" bson:"key_field,omitempty"`
+
+	// ChassisID is the lldp/cdp chassis id the device advertises to its neighbors
+	ChassisID  string                      `json:"chassis_id,omitempty" bson:"chassis_id,omitempty"`
+	Neighbors  []NetcollectReportNeighbor  `json:"neighbors,omitempty" bson:"neighbors,omitempty"`
+	Interfaces []NetcollectReportInterface `json:"interfaces,omitempty" bson:"interfaces,omitempty"`
 }
 
 type NetcollectHistory struct {
@@ -163,6 +202,17 @@ type NetcollectReportAttribute struct {
 	Error   string `json:"error,omitempty" bson:"-"`
 }
 
+const (
+	// NetcollectSwitchConnectHost the association of the switch and the hosts connected to its access ports
+	NetcollectSwitchConnectHost = "bk_switch_connect_host"
+	// NetcollectSwitchConnectSwitch the association of the switches discovered by lldp/cdp
+	NetcollectSwitchConnectSwitch = "bk_switch_connect_bk_switch"
+)
+
+// NetcollectTopoObjectAsstIDs the associations built from the switch topology, the ones no longer
+// observed by the collector are deleted when the report is confirmed
+var NetcollectTopoObjectAsstIDs = []string{NetcollectSwitchConnectHost, NetcollectSwitchConnectSwitch}
+
 type NetcollectReportAssociation struct {
 	Action       string `json:"action" bson:"-"`
 	AsstInstName string `json:"bk_asst_inst_name" bson:"bk_asst_inst_name"`
@@ -174,6 +224,22 @@ type NetcollectReportAssociation struct {
 	Configuration string `json:"configuration" bson:"configuration"`
 }
 
+// NetcollectReportNeighbor is a neighbor of the device discovered by lldp or cdp
+type NetcollectReportNeighbor struct {
+	Protocol        string `json:"protocol" bson:"protocol"`
+	LocalPort       string `json:"local_port" bson:"local_port"`
+	RemoteChassisID string `json:"remote_chassis_id" bson:"remote_chassis_id"`
+	RemoteSysName   string `json:"remote_sys_name" bson:"remote_sys_name"`
+	RemotePort      string `json:"remote_port" bson:"remote_port"`
+	RemoteAddress   string `json:"remote_address" bson:"remote_address"`
+}
+
+// NetcollectReportInterface is an interface of the device with the mac addresses learned on it
+type NetcollectReportInterface struct {
+	Port string   `json:"port" bson:"port"`
+	MACs []string `json:"macs" bson:"macs"`
+}
+
 type NetcollectReportAsstCond struct {
 	PropertyID   string      `json:"bk_property_id" bson:"bk_property_id"`
 	PropertyName string      `json:"bk_property_name" bson:"bk_property_name"`
@@ -188,6 +254,17 @@ type RspNetcollectConfirm struct {
 	Errors                    []string `json:"errors"`
 }
 
+// NetcollectConfirmResult confirm net collect reports api http response return result struct
+type NetcollectConfirmResult struct {
+	BaseResp `json:",inline"`
+	Data     RspNetcollectConfirm `json:"data"`
+}
+
+const (
+	NetcollectNeighborProtocolLLDP = "lldp"
+	NetcollectNeighborProtocolCDP  = "cdp"
+)
+
 const (
 	ReporctActionCreate = "create"
 	ReporctActionUpdate = "update"
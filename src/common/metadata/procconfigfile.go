@@ -0,0 +1,143 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// ProcConfigFileStatus the status of a generated config file
+type ProcConfigFileStatus string
+
+const (
+	// ProcConfigFileStatusGenerated the file is generated but not pushed to the host
+	ProcConfigFileStatusGenerated ProcConfigFileStatus = "generated"
+	// ProcConfigFileStatusPushed the file is pushed to the host
+	ProcConfigFileStatusPushed ProcConfigFileStatus = "pushed"
+	// ProcConfigFileStatusPushFailed the file is failed to push to the host
+	ProcConfigFileStatusPushFailed ProcConfigFileStatus = "push_failed"
+)
+
+// the fields of the config file which are updated after it's generated
+const (
+	ProcConfigFileFieldPath     = "path"
+	ProcConfigFileFieldStatus   = "status"
+	ProcConfigFileFieldMessage  = "message"
+	ProcConfigFileFieldPushTime = "push_time"
+	ProcConfigFileFieldMd5      = "md5"
+)
+
+// ProcConfigFile is the config file rendered from a template version for a process instance
+type ProcConfigFile struct {
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	AppID      int64  `json:"bk_biz_id" bson:"bk_biz_id"`
+	TemplateID int64  `json:"template_id" bson:"template_id"`
+	VersionID  int64  `json:"version_id" bson:"version_id"`
+	SetID      int64  `json:"bk_set_id" bson:"bk_set_id"`
+	ModuleID   int64  `json:"bk_module_id" bson:"bk_module_id"`
+	ProcID     int64  `json:"bk_process_id" bson:"bk_process_id"`
+	FuncID     int64  `json:"bk_func_id" bson:"bk_func_id"`
+	HostID     int64  `json:"bk_host_id" bson:"bk_host_id"`
+	InnerIP    string `json:"bk_host_innerip" bson:"bk_host_innerip"`
+	CloudID    int64  `json:"bk_cloud_id" bson:"bk_cloud_id"`
+	// HostInstID the instance id of the process on the host, ProcInstID the id in the module
+	HostInstID uint64 `json:"bk_host_instance_id" bson:"bk_host_instance_id"`
+	ProcInstID uint64 `json:"proc_instance_id" bson:"proc_instance_id"`
+
+	// Path the absolute path of the file on the host
+	Path       string               `json:"path" bson:"path"`
+	User       string               `json:"user" bson:"user"`
+	Permission string               `json:"permission" bson:"permission"`
+	Content    string               `json:"content" bson:"content"`
+	Md5        string               `json:"md5" bson:"md5"`
+	Status     ProcConfigFileStatus `json:"status" bson:"status"`
+	Message    string               `json:"message" bson:"message"`
+	CreateTime time.Time            `json:"create_time" bson:"create_time"`
+	PushTime   *time.Time           `json:"push_time,omitempty" bson:"push_time,omitempty"`
+}
+
+// ProcConfigFileResult the result of searching the config files
+type ProcConfigFileResult struct {
+	BaseResp `json:",inline"`
+	Data     struct {
+		Count int              `json:"count"`
+		Info  []ProcConfigFile `json:"info"`
+	} `json:"data"`
+}
+
+// CreateConfigFileParams the parameters to generate the config files of a template
+type CreateConfigFileParams struct {
+	// VersionID the version to render, the released version is used if it's 0
+	VersionID int64 `json:"version_id"`
+	// ProcessIDs render the files of these processes only, all the processes bound to the template
+	// are rendered if it's empty
+	ProcessIDs []int64 `json:"bk_process_ids"`
+}
+
+// ConfigFileHostParams select the config files of a template by the hosts
+type ConfigFileHostParams struct {
+	// HostIDs the hosts of the files, all the files of the template are selected if it's empty
+	HostIDs []int64 `json:"bk_host_ids"`
+}
+
+// ConfigFileResult the result of an operation on a config file
+type ConfigFileResult struct {
+	HostID     int64  `json:"bk_host_id"`
+	InnerIP    string `json:"bk_host_innerip"`
+	CloudID    int64  `json:"bk_cloud_id"`
+	ModuleID   int64  `json:"bk_module_id"`
+	ProcID     int64  `json:"bk_process_id"`
+	ProcInstID uint64 `json:"proc_instance_id"`
+	Path       string `json:"path"`
+	Success    bool   `json:"success"`
+	Message    string `json:"message,omitempty"`
+	// Content the content of the file on the host
+	Content string `json:"content,omitempty"`
+	// Diff the unified diff from the file on the host to the generated file
+	Diff string `json:"diff,omitempty"`
+}
+
+// GseConfigFile the config file pushed by gse
+type GseConfigFile struct {
+	Path       string `json:"path"`
+	Content    string `json:"content"`
+	Md5        string `json:"md5"`
+	User       string `json:"user,omitempty"`
+	Permission string `json:"permission,omitempty"`
+}
+
+// GsePushConfigFileRequest push the files to the hosts by gse
+type GsePushConfigFileRequest struct {
+	Hosts []GseHost       `json:"hosts"`
+	Files []GseConfigFile `json:"files"`
+}
+
+// GseGetConfigFileRequest get the content of the file on the hosts by gse
+type GseGetConfigFileRequest struct {
+	Hosts []GseHost `json:"hosts"`
+	Path  string    `json:"path"`
+}
+
+// GseConfigFileContent the content of the file on a host
+type GseConfigFileContent struct {
+	Errcode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	Content string `json:"content"`
+}
+
+// GseConfigFileResult the result of the config file operation by gse, the key of the data is
+// cloud id:ip of the host
+type GseConfigFileResult struct {
+	EsbBaseResponse `json:",inline"`
+	Data            map[string]GseConfigFileContent `json:"data"`
+}
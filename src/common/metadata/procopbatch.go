@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"net/http"
+	"time"
+)
+
+// ProcOpBatchStrategy the strategy to operate the process instances batch by batch
+type ProcOpBatchStrategy struct {
+	// Size the count of the process instances in a batch, it takes precedence over Percent
+	Size int `json:"size" bson:"size"`
+	// Percent the percentage of all the matched process instances in a batch
+	Percent int `json:"percent" bson:"percent"`
+	// Interval the seconds to wait after a batch is finished before the next batch starts
+	Interval int64 `json:"interval" bson:"interval"`
+	// MaxFailures the task is paused once the failed instances since it is started or
+	// resumed are more than it
+	MaxFailures int `json:"max_failures" bson:"max_failures"`
+}
+
+// ProcOpBatchTaskStatus the status of a rolling process operate task
+type ProcOpBatchTaskStatus string
+
+const (
+	ProcOpBatchTaskStatusRunning  ProcOpBatchTaskStatus = "running"
+	ProcOpBatchTaskStatusPaused   ProcOpBatchTaskStatus = "paused"
+	ProcOpBatchTaskStatusAborted  ProcOpBatchTaskStatus = "aborted"
+	ProcOpBatchTaskStatusFinished ProcOpBatchTaskStatus = "finished"
+)
+
+// ProcOpBatchStatus the status of a batch in the rolling process operate task
+type ProcOpBatchStatus string
+
+const (
+	ProcOpBatchStatusWaiting   ProcOpBatchStatus = "waiting"
+	ProcOpBatchStatusExecuting ProcOpBatchStatus = "executing"
+	ProcOpBatchStatusSucceeded ProcOpBatchStatus = "succeeded"
+	ProcOpBatchStatusFailed    ProcOpBatchStatus = "failed"
+)
+
+// the fields of the rolling process operate task
+const (
+	ProcOpBatchTaskFieldStatus       = "status"
+	ProcOpBatchTaskFieldCurrentBatch = "current_batch"
+	ProcOpBatchTaskFieldFailures     = "failures"
+	ProcOpBatchTaskFieldNextTime     = "next_time"
+	ProcOpBatchTaskFieldBatches      = "batches"
+	ProcOpBatchTaskFieldMessage      = "message"
+	ProcOpBatchTaskFieldLastTime     = "last_time"
+)
+
+// ProcOpBatch is a batch of the process instances operated at the same time
+type ProcOpBatch struct {
+	Index  int               `json:"index" bson:"index"`
+	Status ProcOpBatchStatus `json:"status" bson:"status"`
+	// TaskID the task id of the process operate task records of this batch
+	TaskID    string              `json:"task_id" bson:"task_id"`
+	Instances []ProcInstanceModel `json:"instances" bson:"instances"`
+	Failures  int                 `json:"failures" bson:"failures"`
+	// Errors the error message of the failed instances, the key is the gse result key
+	Errors    map[string]string `json:"errors,omitempty" bson:"errors,omitempty"`
+	StartTime *time.Time        `json:"start_time,omitempty" bson:"start_time,omitempty"`
+	EndTime   *time.Time        `json:"end_time,omitempty" bson:"end_time,omitempty"`
+}
+
+// ProcOpBatchTask is a process operation which is executed batch by batch
+type ProcOpBatchTask struct {
+	TaskID      string                `json:"task_id" bson:"task_id"`
+	OwnerID     string                `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	AppID       int64                 `json:"bk_biz_id" bson:"bk_biz_id"`
+	User        string                `json:"user" bson:"user"`
+	OperateInfo *ProcessOperate       `json:"operate_info" bson:"operate_info"`
+	Status      ProcOpBatchTaskStatus `json:"status" bson:"status"`
+	// CurrentBatch the index of the batch which is executing or will be executed next
+	CurrentBatch int `json:"current_batch" bson:"current_batch"`
+	// Failures the failed instances since the task is started or resumed
+	Failures int `json:"failures" bson:"failures"`
+	// NextTime the time after which the current batch can be executed
+	NextTime   time.Time     `json:"next_time" bson:"next_time"`
+	Batches    []ProcOpBatch `json:"batches" bson:"batches"`
+	Message    string        `json:"message" bson:"message"`
+	HTTPHeader http.Header   `json:"http_header" bson:"http_header"`
+	CreateTime time.Time     `json:"create_time" bson:"create_time"`
+	LastTime   time.Time     `json:"last_time" bson:"last_time"`
+}
+
+// ProcOpBatchTaskResult the result of searching the rolling process operate tasks
+type ProcOpBatchTaskResult struct {
+	BaseResp `json:",inline"`
+	Data     struct {
+		Count int               `json:"count"`
+		Info  []ProcOpBatchTask `json:"info"`
+	} `json:"data"`
+}
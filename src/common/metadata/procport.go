@@ -0,0 +1,79 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the value of the bind_ip enum attribute of the process
+const (
+	ProcBindIPLocalhost = "1"
+	ProcBindIPAll       = "2"
+	ProcBindIPInnerIP   = "3"
+	ProcBindIPOuterIP   = "4"
+)
+
+// the value of the protocol enum attribute of the process
+const (
+	ProcProtocolTCP = "1"
+	ProcProtocolUDP = "2"
+)
+
+// ProcPortBinding the address a process listens on
+type ProcPortBinding struct {
+	ProcID   int64  `json:"bk_process_id"`
+	ProcName string `json:"bk_process_name"`
+	BindIP   string `json:"bind_ip"`
+	Protocol string `json:"protocol"`
+	Port     string `json:"port"`
+}
+
+// ProcPortConflict two processes deployed on the same host listen on the same ip and port
+type ProcPortConflict struct {
+	HostID   int64  `json:"bk_host_id"`
+	InnerIP  string `json:"bk_host_innerip"`
+	BindIP   string `json:"bind_ip"`
+	Protocol string `json:"protocol"`
+	// Port the overlapped ports, eg: 8000-8010,8080
+	Port      string            `json:"port"`
+	Processes []ProcPortBinding `json:"processes"`
+}
+
+// Desc describe the conflict in the error message
+func (c ProcPortConflict) Desc() string {
+	names := make([]string, 0, len(c.Processes))
+	for _, proc := range c.Processes {
+		names = append(names, fmt.Sprintf("%s(%d)", proc.ProcName, proc.ProcID))
+	}
+	protocol := "TCP"
+	if ProcProtocolUDP == c.Protocol {
+		protocol = "UDP"
+	}
+	return fmt.Sprintf("host %s %s %s:%s used by %s", c.InnerIP, protocol, c.BindIP, c.Port, strings.Join(names, ", "))
+}
+
+// ProcPortCheckParam check whether hosts transferred to the modules cause process port conflicts
+type ProcPortCheckParam struct {
+	HostID   []int64 `json:"bk_host_id"`
+	ModuleID []int64 `json:"bk_module_id"`
+	// IsIncrement keep the modules the hosts currently belong to in the business
+	IsIncrement bool `json:"is_increment"`
+}
+
+// ProcPortConflictResult the process port conflicts found
+type ProcPortConflictResult struct {
+	BaseResp `json:",inline"`
+	Data     []ProcPortConflict `json:"data"`
+}
@@ -48,6 +48,8 @@ type MatchProcInstParam struct {
 type ProcessOperate struct {
 	MatchProcInstParam `json:",inline"`
 	OpType             int `json:"bk_proc_optype"`
+	// Batch operate the matched instances batch by batch if it's not nil
+	Batch *ProcOpBatchStrategy `json:"batch,omitempty" bson:"batch,omitempty"`
 }
 
 type ProcModuleResult struct {
@@ -253,7 +255,27 @@ type ProcessModule struct {
 }
 
 type TemplateVersion struct {
-	Content     string `json:"content" field:"content"`
+	Content string `json:"content" field:"content"`
+	// Status is not set by the request any more, the versions are created as drafts and released
+	// by the review, the online and history versions are migrated to released and deprecated.
 	Status      string `json:"status" field:"status"`
 	Description string `json:"description" field:"description"`
 }
+
+// TemplateVersionReview the comment of the template version review
+type TemplateVersionReview struct {
+	Comment string `json:"comment"`
+}
+
+// TemplateVersionRollback roll back the template to a deprecated version, the version released
+// before the current one is used if VersionID is 0
+type TemplateVersionRollback struct {
+	VersionID int64 `json:"version_id"`
+}
+
+// TemplateVersionRollbackResult the version released by the rollback and the push result of the
+// config files rendered from it
+type TemplateVersionRollbackResult struct {
+	VersionID int64              `json:"version_id"`
+	Files     []ConfigFileResult `json:"files"`
+}
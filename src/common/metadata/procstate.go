@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// ProcRunState the actual state of a process instance reported by gse
+type ProcRunState string
+
+const (
+	ProcRunStateRunning ProcRunState = "running"
+	ProcRunStateStopped ProcRunState = "stopped"
+	ProcRunStateUnknown ProcRunState = "unknown"
+)
+
+// the fields of the process instance state
+const (
+	ProcInstanceStateFieldState      = "state"
+	ProcInstanceStateFieldCheckRound = "check_round"
+)
+
+// ProcInstanceState the actual running state of the process instances of a process on a host
+type ProcInstanceState struct {
+	OwnerID  string       `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	AppID    int64        `json:"bk_biz_id" bson:"bk_biz_id"`
+	ModuleID int64        `json:"bk_module_id" bson:"bk_module_id"`
+	ProcID   int64        `json:"bk_process_id" bson:"bk_process_id"`
+	HostID   int64        `json:"bk_host_id" bson:"bk_host_id"`
+	InnerIP  string       `json:"bk_host_innerip" bson:"bk_host_innerip"`
+	CloudID  int64        `json:"bk_cloud_id" bson:"bk_cloud_id"`
+	State    ProcRunState `json:"state" bson:"state"`
+	Pid      int          `json:"pid" bson:"pid"`
+	Message  string       `json:"message" bson:"message"`
+	// LastSeen the last time the process is found running
+	LastSeen *time.Time `json:"last_seen,omitempty" bson:"last_seen,omitempty"`
+	// CheckTime the last time the state is checked, CheckRound is the unix time the check round starts
+	CheckTime  time.Time `json:"check_time" bson:"check_time"`
+	CheckRound int64     `json:"check_round" bson:"check_round"`
+	// ChangeTime the time the state changes to the current state
+	ChangeTime time.Time `json:"change_time" bson:"change_time"`
+}
+
+// ProcInstanceStateResult the result of searching the process instance states
+type ProcInstanceStateResult struct {
+	BaseResp `json:",inline"`
+	Data     ProcInstanceStateData `json:"data"`
+}
+
+type ProcInstanceStateData struct {
+	Count int                 `json:"count"`
+	Info  []ProcInstanceState `json:"info"`
+}
+
+// ProcModuleRunSummary the expected and the actually running process instances of a process in a module
+type ProcModuleRunSummary struct {
+	ModuleID int64 `json:"bk_module_id"`
+	ProcID   int64 `json:"bk_process_id"`
+	Expected int   `json:"expected"`
+	Running  int   `json:"running"`
+	Stopped  int   `json:"stopped"`
+	Unknown  int   `json:"unknown"`
+}
+
+// ProcModuleRunSummaryResult get process run summary api http response return result struct
+type ProcModuleRunSummaryResult struct {
+	BaseResp `json:",inline"`
+	Data     []ProcModuleRunSummary `json:"data"`
+}
+
+// the status of the process in gse
+const (
+	GseProcStatusRunning = 1
+	GseProcStatusStopped = 2
+)
+
+// GseProcStatus the status of a process on a host in gse
+type GseProcStatus struct {
+	Meta   GseProcMeta `json:"meta"`
+	Host   GseHost     `json:"host"`
+	Status int         `json:"status"`
+	IsAuto bool        `json:"isauto"`
+	Pid    int         `json:"pid"`
+}
+
+// GseProcStatusResult the result of querying the process status from gse
+type GseProcStatusResult struct {
+	EsbBaseResponse `json:",inline"`
+	Data            struct {
+		ProcInfos []GseProcStatus `json:"proc_infos"`
+	} `json:"data"`
+}
@@ -0,0 +1,112 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// ProcVariableType the type of the value of the template variable
+type ProcVariableType string
+
+const (
+	ProcVariableTypeString ProcVariableType = "string"
+	ProcVariableTypeInt    ProcVariableType = "int"
+	ProcVariableTypeBool   ProcVariableType = "bool"
+	ProcVariableTypeEnum   ProcVariableType = "enum"
+	ProcVariableTypeIP     ProcVariableType = "ip"
+	ProcVariableTypePort   ProcVariableType = "port"
+)
+
+// the fields of the template variable
+const (
+	ProcTemplateVariableFieldID   = "id"
+	ProcTemplateVariableFieldName = "name"
+)
+
+// ProcTemplateVariable the user defined variable used to render the config templates of a business,
+// the default value is used unless it's overridden on the set, module or host the process instance belongs to
+type ProcTemplateVariable struct {
+	ID      int64            `json:"id" bson:"id"`
+	OwnerID string           `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	AppID   int64            `json:"bk_biz_id" bson:"bk_biz_id"`
+	Name    string           `json:"name" bson:"name"`
+	Type    ProcVariableType `json:"type" bson:"type"`
+	// Options the values allowed of the enum variable
+	Options     []string               `json:"options" bson:"options"`
+	Default     interface{}            `json:"default" bson:"default"`
+	Description string                 `json:"description" bson:"description"`
+	Overrides   []ProcVariableOverride `json:"overrides" bson:"overrides"`
+	Creator     string                 `json:"creator" bson:"creator"`
+	Modifier    string                 `json:"modifier" bson:"modifier"`
+	CreateTime  time.Time              `json:"create_time" bson:"create_time"`
+	LastTime    time.Time              `json:"last_time" bson:"last_time"`
+}
+
+// ProcVariableOverride the value of the variable on a set, module or host
+type ProcVariableOverride struct {
+	ObjID  string      `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID int64       `json:"bk_inst_id" bson:"bk_inst_id"`
+	Value  interface{} `json:"value" bson:"value"`
+}
+
+// ProcTemplateVariableCreateResult the result of creating the template variable
+type ProcTemplateVariableCreateResult struct {
+	BaseResp `json:",inline"`
+	Data     ProcTemplateVariable `json:"data"`
+}
+
+// ProcTemplateVariableResult the result of searching the template variables
+type ProcTemplateVariableResult struct {
+	BaseResp `json:",inline"`
+	Data     ProcTemplateVariableData `json:"data"`
+}
+
+type ProcTemplateVariableData struct {
+	Count int                    `json:"count"`
+	Info  []ProcTemplateVariable `json:"info"`
+}
+
+// ProcEffectiveVariableParam select the process instance to get the effective variables
+type ProcEffectiveVariableParam struct {
+	ModuleID int64 `json:"bk_module_id"`
+	HostID   int64 `json:"bk_host_id"`
+	ProcID   int64 `json:"bk_process_id"`
+	// ProcInstanceID the first instance of the process on the host is used if it's not set
+	ProcInstanceID uint64 `json:"proc_instance_id"`
+}
+
+// ProcEffectiveVariable the value of the template variable used by the process instance
+type ProcEffectiveVariable struct {
+	Name  string           `json:"name"`
+	Type  ProcVariableType `json:"type"`
+	Value interface{}      `json:"value"`
+	// Source the level the value comes from, biz means the default value is used
+	Source       string `json:"source"`
+	SourceInstID int64  `json:"source_inst_id"`
+}
+
+// ProcEffectiveVariables the variables used to render the config templates for the process instance
+type ProcEffectiveVariables struct {
+	Instance ProcInstanceModel       `json:"instance"`
+	Custom   []ProcEffectiveVariable `json:"custom"`
+	// Variables all the variables in the render context, including the fields of the
+	// business, set, module, host and process and the user defined variables
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// ProcEffectiveVariablesResult get effective template variables api http response return result struct
+type ProcEffectiveVariablesResult struct {
+	BaseResp `json:",inline"`
+	Data     ProcEffectiveVariables `json:"data"`
+}
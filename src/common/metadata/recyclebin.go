@@ -0,0 +1,117 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common/mapstr"
+)
+
+const (
+	// RecycleRecordFieldDeleteTime the time when the instance is moved into recycle bin
+	RecycleRecordFieldDeleteTime = "delete_time"
+	// RecycleRecordFieldExpireTime the time after which the record will be purged
+	RecycleRecordFieldExpireTime = "expire_time"
+
+	// RecycleBinDefaultRetentionDays the default days that a deleted instance is kept in recycle bin
+	RecycleBinDefaultRetentionDays = 30
+)
+
+// RecycleRecord a deleted instance kept in recycle bin, which contains everything
+// needed to restore the instance.
+type RecycleRecord struct {
+	ID       int64  `field:"id" json:"id" bson:"id"`
+	OwnerID  string `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+	ObjectID string `field:"bk_obj_id" json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID   int64  `field:"bk_inst_id" json:"bk_inst_id" bson:"bk_inst_id"`
+	BizID    int64  `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
+	// the origin document of the instance
+	Data mapstr.MapStr `field:"data" json:"data" bson:"data"`
+	// the origin instance association documents
+	Associations []mapstr.MapStr `field:"associations" json:"associations" bson:"associations"`
+	// the origin host module relation documents, only for host
+	ModuleHosts []mapstr.MapStr `field:"module_hosts" json:"module_hosts" bson:"module_hosts"`
+	Operator    string          `field:"operator" json:"operator" bson:"operator"`
+	DeleteTime  time.Time       `field:"delete_time" json:"delete_time" bson:"delete_time"`
+	ExpireTime  time.Time       `field:"expire_time" json:"expire_time" bson:"expire_time"`
+}
+
+// CreateRecycleRecords the instances which will be moved into recycle bin
+type CreateRecycleRecords struct {
+	InstIDs []int64 `json:"inst_ids"`
+}
+
+// SaveRecycleRecords the snapshots of the deleted instances to be saved into recycle bin
+type SaveRecycleRecords struct {
+	Records []RecycleRecord `json:"records"`
+}
+
+// RestoreRecycleRecords the recycle records to be restored
+type RestoreRecycleRecords struct {
+	IDs []int64 `json:"ids"`
+}
+
+// PurgeRecycleRecords the recycle records to be purged, all the expired
+// records will be purged when Expired is true.
+type PurgeRecycleRecords struct {
+	IDs     []int64 `json:"ids"`
+	Expired bool    `json:"expired"`
+}
+
+// RestoredRecycleRecord the restore result of a recycle record
+type RestoredRecycleRecord struct {
+	ID       int64  `json:"id"`
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+	// the associations which can not be restored, because the associated
+	// instance or the model association does not exist any more, or they
+	// conflict with the mapping or max count constraints.
+	DroppedAssociations []mapstr.MapStr `json:"dropped_associations"`
+}
+
+// RestoreRecycleRecordsResult restore recycle records result
+type RestoreRecycleRecordsResult struct {
+	Restored   []RestoredRecycleRecord `json:"restored"`
+	Exceptions []ExceptionResult       `json:"exception"`
+}
+
+// QueryRecycleRecordsResult query recycle records result
+type QueryRecycleRecordsResult struct {
+	Count uint64          `json:"count"`
+	Info  []RecycleRecord `json:"info"`
+}
+
+// SnapshotRecycleRecordsResult the snapshots of the instances which will be moved into recycle bin
+type SnapshotRecycleRecordsResult struct {
+	Info       []RecycleRecord   `json:"info"`
+	Exceptions []ExceptionResult `json:"exceptions"`
+}
+
+// SnapshotRecycleRecordsResp snapshot recycle records api http response return result struct
+type SnapshotRecycleRecordsResp struct {
+	BaseResp `json:",inline"`
+	Data     SnapshotRecycleRecordsResult `json:"data"`
+}
+
+// SearchRecycleRecordsResult search recycle records api http response return result struct
+type SearchRecycleRecordsResult struct {
+	BaseResp `json:",inline"`
+	Data     QueryRecycleRecordsResult `json:"data"`
+}
+
+// RestoreRecycleRecordsResp restore recycle records api http response return result struct
+type RestoreRecycleRecordsResp struct {
+	BaseResp `json:",inline"`
+	Data     RestoreRecycleRecordsResult `json:"data"`
+}
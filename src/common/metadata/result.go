@@ -207,6 +207,18 @@ type ReadInstAssociationResult struct {
 	}
 }
 
+// InstAsstDeletePlanResult the instances and associations affected by deleting instances
+type InstAsstDeletePlanResult struct {
+	BaseResp `json:",inline"`
+	Data     InstAsstDeletePlan `json:"data"`
+}
+
+// AssociationViolationsResult the instances which violate the object association constraints
+type AssociationViolationsResult struct {
+	BaseResp `json:",inline"`
+	Data     []AssociationViolation `json:"data"`
+}
+
 // OperaterException  result
 type OperaterException struct {
 	BaseResp `json:",inline"`
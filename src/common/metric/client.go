@@ -42,7 +42,18 @@ func newMetricController(conf Config, healthFunc HealthFunc, collectors ...*Coll
 		metricController.Collectors[c.Name] = c.Collector
 	}
 
+	// the json format is kept for the existing consumers, prometheus gets the text format by the Accept header.
+	constLabels := map[string]string{"module": conf.ModuleName}
+	for key, value := range conf.Labels {
+		constLabels[key] = value
+	}
+	prometheusHandler := newPrometheusHandler(constLabels, collectors)
+
 	metricHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if wantsPrometheus(req) {
+			prometheusHandler.ServeHTTP(w, req)
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
 		metric, err := metricController.PackMetrics()
 		if nil != err {
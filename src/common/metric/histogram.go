@@ -0,0 +1,231 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metric
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets the default buckets of the histograms, which fit the latency of the http requests in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefObjectives the default quantiles of the summaries
+var DefObjectives = []float64{0.5, 0.9, 0.99}
+
+const (
+	// summaryMaxAge the summaries only calculate the quantiles of the observations in this window
+	summaryMaxAge = 10 * time.Minute
+	// summaryMaxSamples the max number of observations kept by a summary series
+	summaryMaxSamples = 1024
+)
+
+// vec holds the series of a metric by the label values
+type vec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	lock   sync.Mutex
+	keys   []string
+	series map[string]interface{}
+}
+
+func (v *vec) Name() string {
+	return v.name
+}
+
+// getSeries get the series of the label values, the series is created by newFunc if it not exist.
+func (v *vec) getSeries(labelValues []string, newFunc func() interface{}) interface{} {
+	if len(labelValues) != len(v.labelNames) {
+		panic("metric " + v.name + ": inconsistent label cardinality")
+	}
+	key := strings.Join(labelValues, "\xff")
+	series, exist := v.series[key]
+	if !exist {
+		series = newFunc()
+		v.series[key] = series
+		v.keys = append(v.keys, key)
+	}
+	return series
+}
+
+func (v *vec) labels(key string, extra ...Label) []Label {
+	values := strings.Split(key, "\xff")
+	labels := make([]Label, 0, len(v.labelNames)+len(extra))
+	for i, name := range v.labelNames {
+		labels = append(labels, Label{Name: name, Value: values[i]})
+	}
+	return append(labels, extra...)
+}
+
+// HistogramVec is a histogram partitioned by the labels, the observations are counted in
+// configurable buckets.
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+type histogramSeries struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec create a histogram, the default buckets are used if the buckets is empty.
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+	return &HistogramVec{
+		vec:     vec{name: name, help: help, labelNames: labelNames, series: make(map[string]interface{})},
+		buckets: sorted,
+	}
+}
+
+// Observe add an observation to the series of the label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	s := h.getSeries(labelValues, func() interface{} {
+		return &histogramSeries{counts: make([]uint64, len(h.buckets))}
+	}).(*histogramSeries)
+	for i, upper := range h.buckets {
+		if value <= upper {
+			s.counts[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+// CollectFamily collect the buckets, sum and count of the series
+func (h *HistogramVec) CollectFamily() *Family {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	family := &Family{Name: h.name, Help: h.help, Type: TypeHistogram, Samples: make([]Sample, 0)}
+	for _, key := range h.keys {
+		s := h.series[key].(*histogramSeries)
+		for i, upper := range h.buckets {
+			family.Samples = append(family.Samples, Sample{
+				Name:   h.name + "_bucket",
+				Labels: h.labels(key, Label{Name: "le", Value: formatFloat(upper)}),
+				Value:  float64(s.counts[i]),
+			})
+		}
+		family.Samples = append(family.Samples,
+			Sample{Name: h.name + "_bucket", Labels: h.labels(key, Label{Name: "le", Value: "+Inf"}), Value: float64(s.count)},
+			Sample{Name: h.name + "_sum", Labels: h.labels(key), Value: s.sum},
+			Sample{Name: h.name + "_count", Labels: h.labels(key), Value: float64(s.count)},
+		)
+	}
+	return family
+}
+
+// SummaryVec is a summary partitioned by the labels, the quantiles are calculated from the recent
+// observations, and the sum and count are of all the observations.
+type SummaryVec struct {
+	vec
+	objectives []float64
+}
+
+type observation struct {
+	value float64
+	at    time.Time
+}
+
+type summarySeries struct {
+	samples []observation
+	sum     float64
+	count   uint64
+}
+
+// NewSummaryVec create a summary, the default objectives are used if the objectives is empty.
+func NewSummaryVec(name, help string, objectives []float64, labelNames ...string) *SummaryVec {
+	if len(objectives) == 0 {
+		objectives = DefObjectives
+	}
+	return &SummaryVec{
+		vec:        vec{name: name, help: help, labelNames: labelNames, series: make(map[string]interface{})},
+		objectives: objectives,
+	}
+}
+
+// Observe add an observation to the series of the label values
+func (s *SummaryVec) Observe(value float64, labelValues ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	series := s.getSeries(labelValues, func() interface{} {
+		return &summarySeries{samples: make([]observation, 0)}
+	}).(*summarySeries)
+	series.samples = append(series.samples, observation{value: value, at: time.Now()})
+	if len(series.samples) > summaryMaxSamples {
+		series.samples = series.samples[len(series.samples)-summaryMaxSamples:]
+	}
+	series.sum += value
+	series.count++
+}
+
+// CollectFamily collect the quantiles, sum and count of the series
+func (s *SummaryVec) CollectFamily() *Family {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	family := &Family{Name: s.name, Help: s.help, Type: TypeSummary, Samples: make([]Sample, 0)}
+	expired := time.Now().Add(-summaryMaxAge)
+	for _, key := range s.keys {
+		series := s.series[key].(*summarySeries)
+		values := make([]float64, 0, len(series.samples))
+		recent := series.samples[:0]
+		for _, o := range series.samples {
+			if o.at.After(expired) {
+				recent = append(recent, o)
+				values = append(values, o.value)
+			}
+		}
+		series.samples = recent
+		sort.Float64s(values)
+
+		for _, q := range s.objectives {
+			family.Samples = append(family.Samples, Sample{
+				Name:   s.name,
+				Labels: s.labels(key, Label{Name: "quantile", Value: formatFloat(q)}),
+				Value:  quantile(values, q),
+			})
+		}
+		family.Samples = append(family.Samples,
+			Sample{Name: s.name + "_sum", Labels: s.labels(key), Value: series.sum},
+			Sample{Name: s.name + "_count", Labels: s.labels(key), Value: float64(series.count)},
+		)
+	}
+	return family
+}
+
+// quantile get the q-quantile of the sorted values, it's NaN when there is no value.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
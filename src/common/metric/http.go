@@ -0,0 +1,175 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metric
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+
+	"github.com/emicklei/go-restful"
+)
+
+// PrometheusPath the path the metrics are exposed at in the prometheus format
+const PrometheusPath = "/metrics"
+
+const (
+	// routeUnmatched the route label of the requests which match no route
+	routeUnmatched = "unmatched"
+	// routeOther the route label once the number of the routes of a plain handler exceeds maxRoutes
+	routeOther = "other"
+	// maxRoutes the max number of the route labels of a plain handler
+	maxRoutes = 500
+)
+
+// wildcardRoutes the route labels of the requests to the wildcard routes
+var wildcardRoutes = &routeLimiter{routes: make(map[string]bool)}
+
+// HTTPRequestDuration the latency of the http requests served by the service, by the route and status code
+var HTTPRequestDuration = NewHistogramVec("cmdb_http_request_duration_seconds",
+	"The latency of the http requests in seconds.", DefBuckets, "method", "route", "code")
+
+func init() {
+	DefaultRegistry.MustRegister(HTTPRequestDuration)
+}
+
+// RestfulFilter record the latency of the requests of a restful container, it should be installed as
+// a container filter. the requests are labeled by the route pattern, such as /api/v3/biz/{bk_supplier_account},
+// except the ones to the wildcard routes, such as /api/v3/{.*}, which are labeled by the normalized paths.
+func RestfulFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	start := time.Now()
+	// the path is read before the filters run, as the filters of the proxies rewrite it.
+	path := req.Request.URL.Path
+	chain.ProcessFilter(req, resp)
+
+	route := req.SelectedRoutePath()
+	switch {
+	case route == "":
+		route = routeUnmatched
+	case isWildcardRoute(route):
+		route = wildcardRoutes.get(normalizePath(path))
+	}
+	HTTPRequestDuration.Observe(time.Since(start).Seconds(), req.Request.Method, route, strconv.Itoa(resp.StatusCode()))
+}
+
+// InstrumentHandler record the latency of the requests of a plain handler, the requests are labeled
+// by the path with the numeric segments replaced, and the number of the routes is limited.
+func InstrumentHandler(handler http.Handler) http.Handler {
+	routes := &routeLimiter{routes: make(map[string]bool)}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		handler.ServeHTTP(sw, req)
+		route := routes.get(normalizePath(req.URL.Path))
+		HTTPRequestDuration.Observe(time.Since(start).Seconds(), req.Method, route, strconv.Itoa(sw.code))
+	})
+}
+
+// PrometheusHandler serve the metrics of the default registry and the collectors in the prometheus
+// text format, the const labels are added to all the samples.
+func PrometheusHandler(constLabels map[string]string, collectors ...*Collector) http.Handler {
+	return newPrometheusHandler(constLabels, append(collectors, newGoMetricCollector()))
+}
+
+func newPrometheusHandler(constLabels map[string]string, collectors []*Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		families := append(DefaultRegistry.Gather(), collectorFamilies(collectors)...)
+		w.Header().Set("Content-Type", PrometheusContentType)
+		if err := WriteText(w, families, constLabels); err != nil {
+			blog.Errorf("write prometheus metrics failed, err: %v", err)
+		}
+	})
+}
+
+// NewPrometheusWebService create the web service which serves the metrics at /metrics
+func NewPrometheusWebService(constLabels map[string]string, collectors ...*Collector) *restful.WebService {
+	handler := PrometheusHandler(constLabels, collectors...)
+	ws := new(restful.WebService)
+	// the prometheus server accepts the text format, which is not a mime type known by restful
+	ws.Path(PrometheusPath).Produces("*/*")
+	ws.Route(ws.GET("").To(func(req *restful.Request, resp *restful.Response) {
+		handler.ServeHTTP(resp.ResponseWriter, req.Request)
+	}))
+	return ws
+}
+
+// wantsPrometheus check whether the metrics are requested in the prometheus format, the format is
+// negotiated by the Accept header which is sent by the prometheus server, or the format query.
+func wantsPrometheus(req *http.Request) bool {
+	switch req.URL.Query().Get("format") {
+	case "prometheus":
+		return true
+	case "json":
+		return false
+	}
+	accept := req.Header.Get("Accept")
+	return strings.Contains(accept, "text/plain") || strings.Contains(accept, "application/openmetrics-text")
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps the streaming responses working
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+type routeLimiter struct {
+	lock   sync.Mutex
+	routes map[string]bool
+}
+
+func (l *routeLimiter) get(route string) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.routes[route] {
+		return route
+	}
+	if len(l.routes) >= maxRoutes {
+		return routeOther
+	}
+	l.routes[route] = true
+	return route
+}
+
+// isWildcardRoute check whether the route matches any path under it, such as /api/v3/{.*} or /static/{subpath:*}
+func isWildcardRoute(route string) bool {
+	return strings.Contains(route, "{.*}") || strings.Contains(route, ":*}")
+}
+
+// normalizePath replace the numeric segments of the path, which are mostly ids, with {id}
+func normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		if _, err := strconv.ParseInt(segment, 10, 64); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
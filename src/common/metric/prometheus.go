@@ -0,0 +1,251 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metric
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+// PrometheusContentType the content type of the prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricType the type of a metric family in the prometheus exposition
+type MetricType string
+
+const (
+	TypeCounter   MetricType = "counter"
+	TypeGauge     MetricType = "gauge"
+	TypeHistogram MetricType = "histogram"
+	TypeSummary   MetricType = "summary"
+	TypeUntyped   MetricType = "untyped"
+)
+
+// Label is a label of a sample
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a sample of a metric family, the name includes the suffix such as _bucket and _sum.
+type Sample struct {
+	Name   string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a metric family in the prometheus exposition
+type Family struct {
+	Name    string
+	Help    string
+	Type    MetricType
+	Samples []Sample
+}
+
+// FamilyCollector collects the metric families, it's implemented by the histograms and summaries.
+type FamilyCollector interface {
+	Name() string
+	CollectFamily() *Family
+}
+
+// Registry holds the metrics which are exposed in the prometheus format
+type Registry struct {
+	lock    sync.RWMutex
+	metrics []FamilyCollector
+}
+
+// DefaultRegistry the registry the metrics of the http requests are registered to, it's exposed
+// by the /metrics of every service.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry create an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make([]FamilyCollector, 0)}
+}
+
+// Register register the metrics, the names of the metrics in a registry must be unique.
+func (r *Registry) Register(metrics ...FamilyCollector) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, m := range metrics {
+		if !isValidMetricName(m.Name()) {
+			return fmt.Errorf("invalid metric name %s", m.Name())
+		}
+		for _, exist := range r.metrics {
+			if exist.Name() == m.Name() {
+				return fmt.Errorf("metric %s is already registered", m.Name())
+			}
+		}
+		r.metrics = append(r.metrics, m)
+	}
+	return nil
+}
+
+// MustRegister register the metrics, and panic if it fails, it's used when the metrics are
+// defined as the package variables.
+func (r *Registry) MustRegister(metrics ...FamilyCollector) {
+	if err := r.Register(metrics...); err != nil {
+		panic(err)
+	}
+}
+
+// Gather collect the families of the metrics in the registry
+func (r *Registry) Gather() []*Family {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	families := make([]*Family, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		families = append(families, m.CollectFamily())
+	}
+	return families
+}
+
+// collectorFamilies convert the metrics of the collectors to the untyped families, the metrics
+// whose value is a string are not exposed, as prometheus only accepts numbers.
+func collectorFamilies(collectors []*Collector) []*Family {
+	families := make([]*Family, 0)
+	for _, c := range collectors {
+		done := make(chan []MetricInterf, 1)
+		go func(c CollectInter) {
+			done <- c.Collect()
+		}(c.Collector)
+
+		var metrics []MetricInterf
+		select {
+		case <-time.After(10 * time.Second):
+			blog.Errorf("collect metrics of %s timeout, skip it.", c.Name)
+			continue
+		case metrics = <-done:
+		}
+
+		for _, mi := range metrics {
+			m, err := newMetric(mi)
+			if err != nil {
+				blog.Errorf("new metric failed. err: %v", err)
+				continue
+			}
+			if m.Value.Type != Float || !isValidMetricName(m.Name) {
+				continue
+			}
+			families = append(families, &Family{
+				Name:    m.Name,
+				Help:    m.Help,
+				Type:    TypeUntyped,
+				Samples: []Sample{{Name: m.Name, Value: m.Value.Float}},
+			})
+		}
+	}
+	return families
+}
+
+// WriteText write the families in the prometheus text exposition format, the const labels are
+// added to all the samples. the families of the same name are merged.
+func WriteText(w io.Writer, families []*Family, constLabels map[string]string) error {
+	labels := make([]Label, 0, len(constLabels))
+	for name, value := range constLabels {
+		labels = append(labels, Label{Name: name, Value: value})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+	merged := make(map[string]*Family)
+	names := make([]string, 0)
+	for _, f := range families {
+		if exist, ok := merged[f.Name]; ok {
+			exist.Samples = append(exist.Samples, f.Samples...)
+			continue
+		}
+		copied := *f
+		merged[f.Name] = &copied
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := merged[name]
+		if f.Help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.Name, f.Type)
+		for _, s := range f.Samples {
+			bw.WriteString(s.Name)
+			writeLabels(bw, labels, s.Labels)
+			bw.WriteByte(' ')
+			bw.WriteString(formatFloat(s.Value))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+func writeLabels(w *bufio.Writer, constLabels, labels []Label) {
+	if len(constLabels)+len(labels) == 0 {
+		return
+	}
+	w.WriteByte('{')
+	for i, l := range append(append([]Label{}, constLabels...), labels...) {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(l.Name)
+		w.WriteString(`="`)
+		w.WriteString(escapeLabelValue(l.Value))
+		w.WriteByte('"')
+	}
+	w.WriteByte('}')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(strings.TrimSpace(s))
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func isValidMetricName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metric
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+)
+
+func TestHistogramText(t *testing.T) {
+	h := NewHistogramVec("test_latency_seconds", "The latency.\nin seconds", []float64{1, 0.1}, "route")
+	h.Observe(0.05, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(5, `/b"`)
+
+	buf := new(bytes.Buffer)
+	if err := WriteText(buf, []*Family{h.CollectFamily()}, map[string]string{"module": "test"}); err != nil {
+		t.Fatalf("write text failed, err: %v", err)
+	}
+	expected := `# HELP test_latency_seconds The latency.\nin seconds
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{module="test",route="/a",le="0.1"} 1
+test_latency_seconds_bucket{module="test",route="/a",le="1"} 2
+test_latency_seconds_bucket{module="test",route="/a",le="+Inf"} 2
+test_latency_seconds_sum{module="test",route="/a"} 0.55
+test_latency_seconds_count{module="test",route="/a"} 2
+test_latency_seconds_bucket{module="test",route="/b\"",le="0.1"} 0
+test_latency_seconds_bucket{module="test",route="/b\"",le="1"} 0
+test_latency_seconds_bucket{module="test",route="/b\"",le="+Inf"} 1
+test_latency_seconds_sum{module="test",route="/b\""} 5
+test_latency_seconds_count{module="test",route="/b\""} 1
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected exposition:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	s := NewSummaryVec("test_size", "The size.", []float64{0.5, 0.9})
+	for i := 1; i <= 10; i++ {
+		s.Observe(float64(i))
+	}
+	family := s.CollectFamily()
+	values := make(map[string]float64)
+	for _, sample := range family.Samples {
+		key := sample.Name
+		for _, l := range sample.Labels {
+			key += "," + l.Value
+		}
+		values[key] = sample.Value
+	}
+	if values["test_size,0.5"] != 5 || values["test_size,0.9"] != 9 || values["test_size_sum"] != 55 || values["test_size_count"] != 10 {
+		t.Errorf("unexpected summary samples: %v", values)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(NewHistogramVec("test_a", "a", nil)); err != nil {
+		t.Fatalf("register failed, err: %v", err)
+	}
+	if err := r.Register(NewSummaryVec("test_a", "a", nil)); err == nil {
+		t.Errorf("duplicated metric should not be registered")
+	}
+	if err := r.Register(NewSummaryVec("0test", "a", nil)); err == nil {
+		t.Errorf("invalid metric name should not be registered")
+	}
+}
+
+func TestRestfulFilter(t *testing.T) {
+	container := restful.NewContainer()
+	container.Router(restful.CurlyRouter{})
+	ws := new(restful.WebService)
+	ws.Path("/test/v3")
+	ws.Route(ws.GET("/inst/{id}").To(func(req *restful.Request, resp *restful.Response) {
+		resp.WriteHeader(http.StatusNotFound)
+	}))
+	container.Add(ws)
+	// the proxy rewrites the path of the requests, which is not the path of the route label
+	proxy := new(restful.WebService)
+	proxy.Path("/proxy/v3")
+	proxy.Route(proxy.GET("/{.*}").To(func(req *restful.Request, resp *restful.Response) {
+		req.Request.URL.Path = "/backend/v3/inst/2"
+		resp.WriteHeader(http.StatusOK)
+	}))
+	container.Add(proxy)
+	container.Add(NewPrometheusWebService(map[string]string{"module": "test"}))
+	container.Filter(RestfulFilter)
+
+	container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/v3/inst/1", nil))
+	container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/proxy/v3/inst/1", nil))
+	req := httptest.NewRequest(http.MethodGet, PrometheusPath, nil)
+	req.Header.Set("Accept", "text/plain;version=0.0.4;q=1,*/*;q=0.1")
+	recorder := httptest.NewRecorder()
+	container.ServeHTTP(recorder, req)
+
+	out := recorder.Body.String()
+	sample := `cmdb_http_request_duration_seconds_count{module="test",method="GET",route="/test/v3/inst/{id}",code="404"} 1`
+	if !strings.Contains(out, sample) {
+		t.Errorf("the request is not instrumented, metrics:\n%s", out)
+	}
+	sample = `cmdb_http_request_duration_seconds_count{module="test",method="GET",route="/proxy/v3/inst/{id}",code="200"} 1`
+	if !strings.Contains(out, sample) {
+		t.Errorf("the request to the wildcard route is not labeled by the path, metrics:\n%s", out)
+	}
+	if !strings.Contains(out, "# TYPE go_goroutines untyped") {
+		t.Errorf("the runtime metrics are not exposed, metrics:\n%s", out)
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	if path := normalizePath("/api/v3/object/12/attr/abc"); path != "/api/v3/object/{id}/attr/abc" {
+		t.Errorf("unexpected path %s", path)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if wantsPrometheus(req) {
+		t.Errorf("the json format should be the default")
+	}
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1,text/plain;version=0.0.4;q=0.5")
+	if !wantsPrometheus(req) {
+		t.Errorf("prometheus should get the text format")
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+)
+
+type testBase struct {
+	Result bool `json:"result"`
+}
+
+type testNode struct {
+	testBase `json:",inline"`
+	Name     string            `json:"name"`
+	Secret   string            `json:"-"`
+	Children []*testNode       `json:"children"`
+	Labels   map[string]string `json:"labels"`
+	Created  time.Time         `json:"created"`
+	Data     interface{}       `json:"data"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	doc := NewDocument("test", "1.0")
+	schema := doc.SchemaOf(testNode{})
+	if schema.Ref != schemaRefPrefix+"openapi.testNode" {
+		t.Fatalf("named struct is not referenced, got: %+v", schema)
+	}
+
+	node := doc.Components.Schemas["openapi.testNode"]
+	if node == nil {
+		t.Fatalf("named struct is not added to the components")
+	}
+	for _, name := range []string{"result", "name", "children", "labels", "created", "data"} {
+		if _, exist := node.Properties[name]; !exist {
+			t.Errorf("property %s is missing", name)
+		}
+	}
+	if _, exist := node.Properties["Secret"]; exist {
+		t.Errorf("ignored field is generated")
+	}
+	if node.Properties["children"].Items.Ref != schema.Ref {
+		t.Errorf("recursive struct is not referenced, got: %+v", node.Properties["children"])
+	}
+	if node.Properties["created"].Format != "date-time" {
+		t.Errorf("time is not a date-time string, got: %+v", node.Properties["created"])
+	}
+	if node.Properties["labels"].AdditionalProperties.Type != "string" {
+		t.Errorf("map value schema is wrong, got: %+v", node.Properties["labels"])
+	}
+}
+
+func TestWebService(t *testing.T) {
+	container := restful.NewContainer()
+	ws := new(restful.WebService)
+	ws.Path("/test/v3/").Produces(restful.MIME_JSON)
+	handler := func(req *restful.Request, resp *restful.Response) {}
+	ws.Route(ws.POST("nodes/{bk_biz_id}/{id:[0-9]+}").To(handler).Reads(testNode{}).Writes(testNode{}))
+	ws.Route(ws.GET("{.*}").To(handler))
+	container.Add(ws)
+	container.Add(NewWebService(container, "test", "1.0"))
+
+	recorder := httptest.NewRecorder()
+	container.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, SpecPath, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("get document failed, status: %d", recorder.Code)
+	}
+	doc := new(Document)
+	if err := json.Unmarshal(recorder.Body.Bytes(), doc); err != nil {
+		t.Fatalf("decode document failed, err: %v", err)
+	}
+
+	if len(doc.Paths) != 1 {
+		t.Fatalf("only the post route should be documented, got: %+v", doc.Paths)
+	}
+	operation := doc.Paths["/test/v3/nodes/{bk_biz_id}/{id}"]["post"]
+	if operation == nil {
+		t.Fatalf("post route is missing, got: %+v", doc.Paths)
+	}
+	if len(operation.Parameters) != 2 || operation.Parameters[1].Name != "id" {
+		t.Errorf("path parameters are wrong, got: %+v", operation.Parameters)
+	}
+	if operation.RequestBody == nil || operation.RequestBody.Content[restful.MIME_JSON].Schema.Ref == "" {
+		t.Errorf("request body is wrong, got: %+v", operation.RequestBody)
+	}
+	if operation.OperationID != "post_test_v3_nodes_bk_biz_id_id" {
+		t.Errorf("operation id is wrong, got: %s", operation.OperationID)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"configcenter/src/common/blog"
+
+	"github.com/emicklei/go-restful"
+)
+
+// pathParamRegexp matches the path parameters of a route, such as {bk_biz_id} or {id:[0-9]+}
+var pathParamRegexp = regexp.MustCompile(`{([^}:]+)(:[^}]*)?}`)
+
+// AddWebService add the routes of the web service to the document. the wildcard routes which
+// proxy the requests are skipped, the path parameters are parsed from the route paths, and the
+// request and response schemas are generated from the samples set with Reads and Writes.
+func (d *Document) AddWebService(ws *restful.WebService, tag string) {
+	for _, route := range ws.Routes() {
+		if strings.Contains(route.Path, "{.*}") {
+			continue
+		}
+		d.AddOperation(route.Method, route.Path, tag, route.Doc, route.ReadSample, route.WriteSample)
+	}
+}
+
+// AddOperation add an operation to the document, request and response are the samples of the
+// bodies, which can be nil if they are not known.
+func (d *Document) AddOperation(method, routePath, tag, summary string, request, response interface{}) {
+	routePath = cleanPath(routePath)
+	operation := &Operation{
+		Summary:    summary,
+		Parameters: make([]Parameter, 0),
+		Responses:  make(map[string]Response),
+	}
+	if tag != "" {
+		operation.Tags = []string{tag}
+	}
+	for _, match := range pathParamRegexp.FindAllStringSubmatch(routePath, -1) {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	routePath = pathParamRegexp.ReplaceAllString(routePath, "{$1}")
+
+	if request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{restful.MIME_JSON: {Schema: d.SchemaOf(request)}},
+		}
+	} else if method == http.MethodPost || method == http.MethodPut {
+		operation.RequestBody = &RequestBody{
+			Content: map[string]MediaType{restful.MIME_JSON: {Schema: &Schema{Type: "object"}}},
+		}
+	}
+
+	responseSchema := d.defaultResponseSchema()
+	if response != nil {
+		responseSchema = d.SchemaOf(response)
+	}
+	operation.Responses["200"] = Response{
+		Description: "the result, bk_error_code and bk_error_msg tell whether the request succeeds",
+		Content:     map[string]MediaType{restful.MIME_JSON: {Schema: responseSchema}},
+	}
+
+	d.addOperation(strings.ToLower(method), routePath, operation)
+}
+
+func (d *Document) addOperation(method, routePath string, operation *Operation) {
+	item, exist := d.Paths[routePath]
+	if !exist {
+		item = make(PathItem)
+		d.Paths[routePath] = item
+	}
+	if _, exist := item[method]; exist {
+		blog.V(5).Infof("openapi operation %s %s is registered more than once, keep the first one", method, routePath)
+		return
+	}
+	operation.OperationID = operationID(method, routePath)
+	item[method] = operation
+}
+
+// defaultResponseSchema the response of the operations whose response sample is not known,
+// all the apis respond in this form.
+func (d *Document) defaultResponseSchema() *Schema {
+	name := "BaseResponse"
+	if _, exist := d.Components.Schemas[name]; !exist {
+		d.Components.Schemas[name] = &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"result":        {Type: "boolean"},
+				"bk_error_code": {Type: "integer", Format: "int64"},
+				"bk_error_msg":  {Type: "string"},
+				"data":          {},
+			},
+		}
+	}
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+// Merge add the operations and the schemas of another document, the paths are rewritten with
+// rewrite, and the operations are dropped if it returns false.
+func (d *Document) Merge(other *Document, rewrite func(routePath string) (string, bool)) {
+	for name, schema := range other.Components.Schemas {
+		if _, exist := d.Components.Schemas[name]; !exist {
+			d.Components.Schemas[name] = schema
+		}
+	}
+	for routePath, item := range other.Paths {
+		newPath, ok := rewrite(routePath)
+		if !ok {
+			continue
+		}
+		for method, operation := range item {
+			d.addOperation(method, newPath, operation)
+		}
+	}
+}
+
+// NewWebService create a web service serving the document of the routes registered to the container
+// at SpecPath, the document is generated when it's requested, so all the routes are included.
+func NewWebService(container *restful.Container, title, version string) *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path(SpecPath).Produces(restful.MIME_JSON)
+	ws.Route(ws.GET("").To(func(req *restful.Request, resp *restful.Response) {
+		doc := NewDocument(title, version)
+		for _, item := range container.RegisteredWebServices() {
+			if item == ws {
+				continue
+			}
+			doc.AddWebService(item, title)
+		}
+		resp.WriteAsJson(doc)
+	}))
+	return ws
+}
+
+func cleanPath(routePath string) string {
+	for strings.Contains(routePath, "//") {
+		routePath = strings.Replace(routePath, "//", "/", -1)
+	}
+	if len(routePath) > 1 {
+		routePath = strings.TrimSuffix(routePath, "/")
+	}
+	if !strings.HasPrefix(routePath, "/") {
+		routePath = "/" + routePath
+	}
+	return routePath
+}
+
+// operationID generate a unique operation id from the method and the path, such as post_api_v3_hosts_search
+func operationID(method, routePath string) string {
+	id := strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_", ".", "_").Replace(routePath)
+	return strings.ToLower(method) + strings.TrimRight(id, "_")
+}
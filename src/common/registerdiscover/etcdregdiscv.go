@@ -0,0 +1,216 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"configcenter/src/common/backbone/service_mange/etcd"
+	"configcenter/src/common/blog"
+)
+
+// EtcdRegDiscv do register and discover by etcd, the registered node is a key with a lease,
+// which is deleted by etcd when the process is gone and the lease is not kept alive.
+type EtcdRegDiscv struct {
+	client  *etcd.EtcdClient
+	cancel  context.CancelFunc
+	rootCxt context.Context
+	ttl     time.Duration
+}
+
+// NewEtcdRegDiscv create a object of EtcdRegDiscv
+func NewEtcdRegDiscv(client *etcd.EtcdClient) *EtcdRegDiscv {
+	ctx, ctxCancel := client.WithCancel()
+	ttl := client.SessionTimeOut()
+	if ttl < 5*time.Second {
+		ttl = 5 * time.Second
+	}
+	return &EtcdRegDiscv{
+		client:  client,
+		cancel:  ctxCancel,
+		rootCxt: ctx,
+		ttl:     ttl,
+	}
+}
+
+// RegisterAndWatch put the node of the service with a lease and keep it alive. if the lease is
+// expired, register again
+func (etcdRD *EtcdRegDiscv) RegisterAndWatch(path string, data []byte) error {
+	blog.Infof("register server and keep it alive. path(%s), data(%s)", path, string(data))
+	go func() {
+		var registerKey string
+		var lease int64
+
+		ticker := time.NewTicker(etcdRD.ttl / 3)
+		defer ticker.Stop()
+		for {
+			if registerKey == "" {
+				var err error
+				registerKey, lease, err = etcdRD.register(path, data)
+				if err != nil {
+					blog.Errorf("fail to register server node(%s). err: %v", path, err)
+				}
+			} else {
+				ttl, err := etcdRD.client.KeepAliveOnce(etcdRD.rootCxt, lease)
+				if err != nil || ttl <= 0 {
+					blog.Errorf("fail to keep register node(%s) alive, ttl: %d, err: %v, register again", registerKey, ttl, err)
+					etcdRD.client.Delete(etcdRD.rootCxt, registerKey)
+					registerKey = ""
+					continue
+				}
+			}
+
+			select {
+			case <-etcdRD.rootCxt.Done():
+				blog.Infof("watch register node(%s) done, now exist service register.", path)
+				if lease != 0 {
+					// the register node is deleted with the lease
+					etcdRD.client.Revoke(context.Background(), lease)
+				}
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	blog.Infof("finish register server node(%s) and keep it alive", path)
+	return nil
+}
+
+func (etcdRD *EtcdRegDiscv) register(path string, data []byte) (string, int64, error) {
+	lease, err := etcdRD.client.Grant(etcdRD.rootCxt, etcdRD.ttl)
+	if err != nil {
+		return "", 0, fmt.Errorf("grant lease failed, err: %v", err)
+	}
+
+	// the lease id is unique, it makes the nodes of the same ip not overwrite each other, like the
+	// sequence of the ephemeral node of zookeeper.
+	key := fmt.Sprintf("%s_%016x", path, lease)
+	if err := etcdRD.client.Put(etcdRD.rootCxt, key, data, lease); err != nil {
+		etcdRD.client.Revoke(etcdRD.rootCxt, lease)
+		return "", 0, err
+	}
+	return key, lease, nil
+}
+
+// GetServNodes get server nodes by path
+func (etcdRD *EtcdRegDiscv) GetServNodes(path string) ([]string, error) {
+	kvs, _, err := etcdRD.listChildren(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]string, 0, len(kvs))
+	for _, kv := range kvs {
+		nodes = append(nodes, strings.TrimPrefix(kv.Key, path+"/"))
+	}
+	return nodes, nil
+}
+
+// Ping to ping server
+func (etcdRD *EtcdRegDiscv) Ping() error {
+	return etcdRD.client.Ping()
+}
+
+// Discover watch the children
+func (etcdRD *EtcdRegDiscv) Discover(path string) (<-chan *DiscoverEvent, error) {
+	blog.Infof("begin to discover by watch children of path(%s)", path)
+	env := make(chan *DiscoverEvent, 1)
+
+	go etcdRD.loopDiscover(etcdRD.rootCxt, path, env)
+
+	return env, nil
+}
+
+func (etcdRD *EtcdRegDiscv) loopDiscover(discvCtx context.Context, path string, env chan *DiscoverEvent) {
+	for {
+		kvs, revision, err := etcdRD.listChildren(path)
+		if err != nil {
+			blog.Errorf("fail to get children of path(%s), err: %v, will watch after 5s", path, err)
+			if !sleepWithContext(discvCtx, 5*time.Second) {
+				return
+			}
+			continue
+		}
+
+		discvEnv := &DiscoverEvent{
+			Err: nil,
+			Key: path,
+		}
+		for _, kv := range kvs {
+			discvEnv.Nodes = append(discvEnv.Nodes, strings.TrimPrefix(kv.Key, path+"/"))
+			discvEnv.Server = append(discvEnv.Server, string(kv.Value))
+		}
+
+		// write into discoverEvent channel
+		select {
+		case env <- discvEnv:
+		case <-discvCtx.Done():
+			blog.Infof("discover path(%s) done", path)
+			return
+		}
+
+		err = etcdRD.client.WaitChange(discvCtx, path+"/", true, revision)
+		switch {
+		case discvCtx.Err() != nil:
+			blog.Infof("discover path(%s) done", path)
+			return
+		case err == nil:
+			blog.V(4).Infof("watch found the children of path(%s) change", path)
+		case err == etcd.ErrCompacted:
+			blog.Warnf("watch the children of path(%s) from revision %d, but it's compacted", path, revision)
+		default:
+			blog.Errorf("fail to watch children of path(%s), err: %v, will watch after 5s", path, err)
+			if !sleepWithContext(discvCtx, 5*time.Second) {
+				return
+			}
+		}
+	}
+}
+
+// listChildren get the direct children of the path, which are sorted by the creation, so that the
+// first one is the master as it's with zookeeper.
+func (etcdRD *EtcdRegDiscv) listChildren(path string) ([]etcd.KeyValue, int64, error) {
+	kvs, revision, err := etcdRD.client.List(etcdRD.rootCxt, path+"/")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	children := make([]etcd.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		if strings.Contains(strings.TrimPrefix(kv.Key, path+"/"), "/") {
+			continue
+		}
+		children = append(children, kv)
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].CreateRevision < children[j].CreateRevision
+	})
+	return children, revision, nil
+}
+
+// sleepWithContext sleep for the duration, false is returned if the ctx is done before that
+func sleepWithContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
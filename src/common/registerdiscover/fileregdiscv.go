@@ -0,0 +1,193 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/types"
+)
+
+// ServicesFileName the file of the static services in the directory of FileRegDiscv
+const ServicesFileName = "services.json"
+
+// FileRegDiscv discover the services from a static file, which is for the single node and test
+// deployments. the file is a json object, the key is the module name and the value is the addresses
+// of the module, such as {"host": ["127.0.0.1:60001", "https://127.0.0.2:60001"]}, the first address
+// is the master. the file is reloaded periodically, so that it can be changed without restart.
+type FileRegDiscv struct {
+	file     string
+	interval time.Duration
+	cancel   context.CancelFunc
+	rootCxt  context.Context
+}
+
+// NewFileRegDiscv create a object of FileRegDiscv with the directory of the services file
+func NewFileRegDiscv(ctx context.Context, dir string, interval time.Duration) *FileRegDiscv {
+	rootCxt, cancel := context.WithCancel(ctx)
+	return &FileRegDiscv{
+		file:     filepath.Join(dir, ServicesFileName),
+		interval: interval,
+		cancel:   cancel,
+		rootCxt:  rootCxt,
+	}
+}
+
+// Ping check the services file is readable
+func (fileRD *FileRegDiscv) Ping() error {
+	_, err := fileRD.load()
+	return err
+}
+
+// RegisterAndWatch the services are static, only check the service is in the file
+func (fileRD *FileRegDiscv) RegisterAndWatch(path string, data []byte) error {
+	info := new(types.ServerInfo)
+	if err := json.Unmarshal(data, info); err != nil {
+		return fmt.Errorf("invalid server info %s, err: %v", data, err)
+	}
+
+	nodes, err := fileRD.GetServNodes(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	node := net.JoinHostPort(info.IP, strconv.FormatUint(uint64(info.Port), 10))
+	for _, n := range nodes {
+		if n == node {
+			return nil
+		}
+	}
+	blog.Warnf("server %s is not in the services file %s of path(%s), it can not be discovered", node, fileRD.file, path)
+	return nil
+}
+
+// GetServNodes get server nodes by path
+func (fileRD *FileRegDiscv) GetServNodes(path string) ([]string, error) {
+	servers, err := fileRD.getServers(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]string, 0, len(servers))
+	for _, server := range servers {
+		nodes = append(nodes, net.JoinHostPort(server.IP, strconv.FormatUint(uint64(server.Port), 10)))
+	}
+	return nodes, nil
+}
+
+// Discover reload the services file periodically, the event is sent when the servers changed
+func (fileRD *FileRegDiscv) Discover(path string) (<-chan *DiscoverEvent, error) {
+	blog.Infof("begin to discover path(%s) from services file %s", path, fileRD.file)
+	env := make(chan *DiscoverEvent, 1)
+
+	go func() {
+		ticker := time.NewTicker(fileRD.interval)
+		defer ticker.Stop()
+
+		var previous []types.ServerInfo
+		first := true
+		for {
+			servers, err := fileRD.getServers(path)
+			if err != nil {
+				blog.Errorf("fail to discover path(%s) from services file, err: %v", path, err)
+			} else if first || !reflect.DeepEqual(servers, previous) {
+				first = false
+				previous = servers
+				discvEnv := &DiscoverEvent{Key: path}
+				for _, server := range servers {
+					js, _ := json.Marshal(server)
+					discvEnv.Server = append(discvEnv.Server, string(js))
+					discvEnv.Nodes = append(discvEnv.Nodes, net.JoinHostPort(server.IP, strconv.FormatUint(uint64(server.Port), 10)))
+				}
+				select {
+				case env <- discvEnv:
+				case <-fileRD.rootCxt.Done():
+					return
+				}
+			}
+
+			select {
+			case <-fileRD.rootCxt.Done():
+				blog.Infof("discover path(%s) done", path)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return env, nil
+}
+
+// getServers get the servers of the module of the path
+func (fileRD *FileRegDiscv) getServers(path string) ([]types.ServerInfo, error) {
+	services, err := fileRD.load()
+	if err != nil {
+		return nil, err
+	}
+
+	module := strings.TrimPrefix(path, types.CC_SERV_BASEPATH+"/")
+	servers := make([]types.ServerInfo, 0)
+	for _, addr := range services[module] {
+		server, err := ParseServerAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s of %s in services file %s, err: %v", addr, module, fileRD.file, err)
+		}
+		servers = append(servers, *server)
+	}
+	return servers, nil
+}
+
+func (fileRD *FileRegDiscv) load() (map[string][]string, error) {
+	data, err := ioutil.ReadFile(fileRD.file)
+	if err != nil {
+		return nil, err
+	}
+	services := make(map[string][]string)
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("invalid services file %s, err: %v", fileRD.file, err)
+	}
+	return services, nil
+}
+
+// ParseServerAddress parse the address like 127.0.0.1:80 or https://127.0.0.1:443 to server info
+func ParseServerAddress(addr string) (*types.ServerInfo, error) {
+	server := &types.ServerInfo{Scheme: "http"}
+	if idx := strings.Index(addr, "://"); idx >= 0 {
+		server.Scheme = addr[:idx]
+		addr = addr[idx+len("://"):]
+	}
+	if server.Scheme != "http" && server.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %s", server.Scheme)
+	}
+
+	host, port, err := net.SplitHostPort(strings.TrimSuffix(addr, "/"))
+	if err != nil {
+		return nil, err
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || p == 0 {
+		return nil, fmt.Errorf("invalid port %s", port)
+	}
+	server.IP = host
+	server.Port = uint(p)
+	return server, nil
+}
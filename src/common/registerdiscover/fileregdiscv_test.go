@@ -0,0 +1,79 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"configcenter/src/common/types"
+)
+
+func TestParseServerAddress(t *testing.T) {
+	server, err := ParseServerAddress("https://127.0.0.1:443/")
+	if err != nil || server.Scheme != "https" || server.IP != "127.0.0.1" || server.Port != 443 {
+		t.Errorf("parse address failed, server: %+v, err: %v", server, err)
+	}
+	server, err = ParseServerAddress("[::1]:80")
+	if err != nil || server.Scheme != "http" || server.IP != "::1" || server.Port != 80 {
+		t.Errorf("parse address failed, server: %+v, err: %v", server, err)
+	}
+	for _, addr := range []string{"127.0.0.1", "ftp://127.0.0.1:21", "127.0.0.1:0"} {
+		if _, err := ParseServerAddress(addr); err == nil {
+			t.Errorf("invalid address %s is parsed", addr)
+		}
+	}
+}
+
+func TestFileRegDiscv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "regdiscv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, ServicesFileName)
+	ioutil.WriteFile(file, []byte(`{"host": ["127.0.0.1:60001", "https://127.0.0.2:60001"]}`), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rd := NewFileRegDiscv(ctx, dir, 10*time.Millisecond)
+	path := types.CC_SERV_BASEPATH + "/" + types.CC_MODULE_HOST
+
+	env, err := rd.Discover(path)
+	if err != nil {
+		t.Fatalf("discover failed, err: %v", err)
+	}
+	event := <-env
+	if len(event.Server) != 2 || event.Nodes[1] != "127.0.0.2:60001" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	server := new(types.ServerInfo)
+	if err := json.Unmarshal([]byte(event.Server[1]), server); err != nil || server.Address() != "https://127.0.0.2:60001" {
+		t.Errorf("unexpected server %s, err: %v", event.Server[1], err)
+	}
+
+	ioutil.WriteFile(file, []byte(`{"host": ["127.0.0.3:60001"]}`), 0644)
+	select {
+	case event = <-env:
+		if len(event.Nodes) != 1 || event.Nodes[0] != "127.0.0.3:60001" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("the change of the services file is not discovered")
+	}
+}
@@ -53,6 +53,14 @@ func NewRegDiscoverEx(client *zk.ZkClient) *RegDiscover {
 	return regDiscv
 }
 
+// NewRegDiscoverWithServer used to create a object of RegDiscover with the register and discover
+// server of any backend, such as zookeeper, etcd or a static file
+func NewRegDiscoverWithServer(server RegDiscvServer) *RegDiscover {
+	return &RegDiscover{
+		rdServer: server,
+	}
+}
+
 // RegisterAndWatchService register service info into register-discover platform
 // and then watch the service info, if not exist, then register again
 // key is the index of registered service
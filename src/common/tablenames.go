@@ -29,6 +29,18 @@ const (
 	// BKTableNameProcOperateTask  the table name of the process instance operater task info
 	BKTableNameProcOperateTask = "cc_ProcOpTask"
 
+	// BKTableNameProcConfigFile the table name of the config files generated for the process instances
+	BKTableNameProcConfigFile = "cc_ProcConfigFile"
+
+	// BKTableNameProcOpBatchTask the table name of the rolling process operate task
+	BKTableNameProcOpBatchTask = "cc_ProcOpBatchTask"
+
+	// BKTableNameProcInstanceState the table name of the running state of the process instances
+	BKTableNameProcInstanceState = "cc_ProcInstanceState"
+
+	// BKTableNameProcTemplateVariable the table name of the user defined variables of the config templates
+	BKTableNameProcTemplateVariable = "cc_ProcTemplateVariable"
+
 	// BKTableNamePrivilege the table name of the privilege module
 	BKTableNamePrivilege = "cc_Privilege"
 
@@ -83,9 +95,11 @@ const (
 	BKTableNameNetcollectDevice   = "cc_NetcollectDevice"
 	BKTableNameNetcollectProperty = "cc_NetcollectProperty"
 
-	BKTableNameNetcollectConfig  = "cc_NetcollectConfig"
-	BKTableNameNetcollectReport  = "cc_NetcollectReport"
-	BKTableNameNetcollectHistory = "cc_NetcollectHistory"
+	BKTableNameNetcollectConfig     = "cc_NetcollectConfig"
+	BKTableNameNetcollectReport     = "cc_NetcollectReport"
+	BKTableNameNetcollectHistory    = "cc_NetcollectHistory"
+	BKTableNameNetcollectCredential = "cc_NetcollectCredential"
+	BKTableNameDiscoverPolicy       = "cc_DiscoverPolicy"
 
 	BKTableNameHostLock = "cc_HostLock"
 
@@ -94,6 +108,17 @@ const (
 	BKTableNameCloudSyncHistory       = "cc_CloudSyncHistory"
 	BKTableNameCloudResourceConfirm   = "cc_CloudResourceConfirm"
 	BKTableNameResourceConfirmHistory = "cc_ResourceConfirmHistory"
+
+	// BKTableNameRecycleBin the table name of the deleted instances which can be restored
+	BKTableNameRecycleBin = "cc_RecycleBin"
+
+	// BKTableNameAPIToken the table name of the personal and service account api tokens
+	BKTableNameAPIToken = "cc_APIToken"
+
+	// BKTableNameAuthRole the table name of the roles of the local authorization
+	BKTableNameAuthRole = "cc_AuthRole"
+	// BKTableNameAuthRoleBinding the table name of the role bindings of the local authorization
+	BKTableNameAuthRoleBinding = "cc_AuthRoleBinding"
 )
 
 // AllTables alltables
@@ -103,6 +128,10 @@ var AllTables = []string{
 	BKTableNameProcInstanceModel,
 	BKTableNameProcInstaceDetail,
 	BKTableNameProcOperateTask,
+	BKTableNameProcConfigFile,
+	BKTableNameProcOpBatchTask,
+	BKTableNameProcInstanceState,
+	BKTableNameProcTemplateVariable,
 	BKTableNamePrivilege,
 	BKTableNameUserGroup,
 	BKTableNameUserGroupPrivilege,
@@ -133,6 +162,8 @@ var AllTables = []string{
 	BKTableNameNetcollectProperty,
 	BKTableNameNetcollectReport,
 	BKTableNameNetcollectHistory,
+	BKTableNameNetcollectCredential,
+	BKTableNameDiscoverPolicy,
 	BKTableNameTransaction,
 	BKTableNameIDgenerator,
 	BKTableNameHostLock,
@@ -142,6 +173,10 @@ var AllTables = []string{
 	BKTableNameResourceConfirmHistory,
 	BKTableNameObjUnique,
 	BKTableNameAsstDes,
+	BKTableNameRecycleBin,
+	BKTableNameAPIToken,
+	BKTableNameAuthRole,
+	BKTableNameAuthRoleBinding,
 }
 
 // GetInstTableName returns inst data table name
@@ -0,0 +1,134 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"configcenter/src/common/blog"
+)
+
+// exportQueueSize the max number of the spans waiting to be written, the spans are dropped
+// when the queue is full, so that the requests are never blocked by the exporter.
+const exportQueueSize = 4096
+
+// Exporter exports the ended spans, ExportSpan should not block.
+type Exporter interface {
+	ExportSpan(span *SpanData)
+	// Close flush the spans which are not exported yet and release the resources
+	Close() error
+}
+
+// NewExporter create a built in exporter by the name, it's nil for the none exporter.
+func NewExporter(name, file string) (Exporter, error) {
+	switch name {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return NewWriterExporter(os.Stdout), nil
+	case ExporterFile:
+		return NewFileExporter(file)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %s", name)
+	}
+}
+
+// NewWriterExporter create an exporter which writes the spans to the writer as json lines, which
+// can be collected by the log agents. the writer is closed with the exporter if it's an io.Closer.
+func NewWriterExporter(w io.Writer) Exporter {
+	e := &writerExporter{
+		writer: w,
+		queue:  make(chan *SpanData, exportQueueSize),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// NewFileExporter create an exporter which appends the spans to the file as json lines
+func NewFileExporter(path string) (Exporter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("the file of the trace file exporter is not set")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open trace file %s failed, err: %v", path, err)
+	}
+	return NewWriterExporter(f), nil
+}
+
+type writerExporter struct {
+	writer io.Writer
+	queue  chan *SpanData
+	done   chan struct{}
+
+	lock    sync.RWMutex
+	closed  bool
+	dropped uint64
+}
+
+func (e *writerExporter) ExportSpan(span *SpanData) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	if e.closed {
+		return
+	}
+	select {
+	case e.queue <- span:
+	default:
+		if dropped := atomic.AddUint64(&e.dropped, 1); dropped%1000 == 1 {
+			blog.Warnf("the trace export queue is full, %d spans are dropped", dropped)
+		}
+	}
+}
+
+func (e *writerExporter) run() {
+	defer close(e.done)
+	bw := bufio.NewWriter(e.writer)
+	encoder := json.NewEncoder(bw)
+	for span := range e.queue {
+		if err := encoder.Encode(span); err != nil {
+			blog.Errorf("export span %s of trace %s failed, err: %v", span.SpanID, span.TraceID, err)
+		}
+		// flush when the queue is drained, so that the spans are written in batches under load
+		if len(e.queue) == 0 {
+			if err := bw.Flush(); err != nil {
+				blog.Errorf("flush the trace spans failed, err: %v", err)
+			}
+		}
+	}
+	bw.Flush()
+}
+
+func (e *writerExporter) Close() error {
+	e.lock.Lock()
+	if e.closed {
+		e.lock.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.queue)
+	e.lock.Unlock()
+
+	<-e.done
+	if closer, ok := e.writer.(io.Closer); ok && e.writer != os.Stdout {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"net/http"
+
+	"configcenter/src/common"
+
+	"github.com/emicklei/go-restful"
+)
+
+// RestfulFilter start a server span for each request of a restful container, it should be installed
+// as a container filter. the traceparent header of the request is replaced with the server span, so
+// that the handlers which forward the header to the other services, or build the database context
+// from the header, continue the trace with the server span as the parent.
+func RestfulFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	var span *Span
+	req.Request, span = startServerSpan(req.Request)
+	chain.ProcessFilter(req, resp)
+
+	route := req.SelectedRoutePath()
+	if route == "" {
+		route = req.Request.URL.Path
+	} else {
+		span.SetAttribute("http.route", route)
+	}
+	span.SetName(req.Request.Method + " " + route)
+	endServerSpan(span, resp.StatusCode())
+}
+
+// Handler start a server span for each request of a plain handler
+func Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req, span := startServerSpan(req)
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		handler.ServeHTTP(sw, req)
+		endServerSpan(span, sw.code)
+	})
+}
+
+func startServerSpan(req *http.Request) (*http.Request, *Span) {
+	ctx := ContextWithHeader(req.Context(), req.Header)
+	ctx, span := StartSpan(ctx, req.Method+" "+req.URL.Path, KindServer)
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.target", req.URL.Path)
+	if rid := req.Header.Get(common.BKHTTPCCRequestID); rid != "" {
+		span.SetAttribute("cc.request_id", rid)
+	}
+	Inject(ctx, req.Header)
+	return req.WithContext(ctx), span
+}
+
+func endServerSpan(span *Span, code int) {
+	span.SetAttribute("http.status_code", code)
+	if code >= http.StatusInternalServerError {
+		span.SetStatus(StatusError, http.StatusText(code))
+	}
+	span.End()
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps the streaming responses working
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
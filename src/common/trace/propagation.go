@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader the header of the w3c trace context, see https://www.w3.org/TR/trace-context/
+const TraceparentHeader = "traceparent"
+
+const (
+	traceparentVersion = "00"
+	flagSampled        = 0x01
+)
+
+// ParseTraceparent parse the value of the traceparent header, which is in the format of
+// version-traceid-spanid-flags, such as 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func ParseTraceparent(value string) (SpanContext, error) {
+	sc := SpanContext{}
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return sc, fmt.Errorf("invalid traceparent %s", value)
+	}
+	version := parts[0]
+	if len(version) != 2 || !isLowerHex(version) || version == "ff" {
+		return sc, fmt.Errorf("invalid traceparent version %s", version)
+	}
+	// the future versions may append fields, while the version 00 has exactly 4 fields
+	if version == traceparentVersion && len(parts) != 4 {
+		return sc, fmt.Errorf("invalid traceparent %s", value)
+	}
+	if len(parts[1]) != 32 || !isLowerHex(parts[1]) || len(parts[2]) != 16 || !isLowerHex(parts[2]) ||
+		len(parts[3]) != 2 || !isLowerHex(parts[3]) {
+		return sc, fmt.Errorf("invalid traceparent %s", value)
+	}
+
+	hex.Decode(sc.TraceID[:], []byte(parts[1]))
+	hex.Decode(sc.SpanID[:], []byte(parts[2]))
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %s, the ids can not be all zero", value)
+	}
+	flags := make([]byte, 1)
+	hex.Decode(flags, []byte(parts[3]))
+	sc.Sampled = flags[0]&flagSampled != 0
+	return sc, nil
+}
+
+// FormatTraceparent format the span context as the value of the traceparent header
+func FormatTraceparent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return traceparentVersion + "-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// Extract get the span context of the caller from the header, it's invalid if there is not.
+func Extract(header http.Header) SpanContext {
+	if header == nil {
+		return SpanContext{}
+	}
+	value := header.Get(TraceparentHeader)
+	if value == "" {
+		return SpanContext{}
+	}
+	sc, err := ParseTraceparent(value)
+	if err != nil {
+		return SpanContext{}
+	}
+	return sc
+}
+
+// Inject set the traceparent header with the span context in the ctx, the header is not changed
+// if there is no span context in the ctx.
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() || header == nil {
+		return
+	}
+	header.Set(TraceparentHeader, FormatTraceparent(sc))
+}
+
+// ContextWithHeader return a context whose parent span is the one in the traceparent header, it's
+// used where the context is built from the header of the request, such as the database calls.
+func ContextWithHeader(ctx context.Context, header http.Header) context.Context {
+	return ContextWithRemoteParent(ctx, Extract(header))
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace, it's shared by all the spans of a request
+type TraceID [16]byte
+
+// SpanID identifies a span in a trace
+type SpanID [8]byte
+
+// IsValid check whether the trace id is not all zero
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsValid check whether the span id is not all zero
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext is the part of a span which is propagated to the downstream services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// Sampled whether the trace is recorded, the downstream services follow the decision of the caller.
+	Sampled bool
+}
+
+// IsValid check whether both the trace id and span id are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind the role of a span, the same as the span kinds of opentelemetry
+type SpanKind string
+
+const (
+	// KindServer a span of handling a http request
+	KindServer SpanKind = "server"
+	// KindClient a span of calling a remote service, including the databases
+	KindClient SpanKind = "client"
+	// KindInternal a span of an operation in the process
+	KindInternal SpanKind = "internal"
+)
+
+// StatusCode the status of a span, the same as the status codes of opentelemetry
+type StatusCode string
+
+const (
+	StatusUnset StatusCode = "unset"
+	StatusOK    StatusCode = "ok"
+	StatusError StatusCode = "error"
+)
+
+// Span is an operation in a trace, it's created by StartSpan and should always be ended.
+// a nil span is valid and does nothing, so that the callers need not check it.
+type Span struct {
+	lock       sync.Mutex
+	name       string
+	kind       SpanKind
+	ctx        SpanContext
+	parent     SpanID
+	start      time.Time
+	end        time.Time
+	attributes map[string]interface{}
+	status     StatusCode
+	message    string
+	ended      bool
+}
+
+// SpanContext get the span context which is propagated to the downstream services
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.ctx
+}
+
+// SetName update the name of the span, such as the route of a request which is known after routing.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.name = name
+	s.lock.Unlock()
+}
+
+// SetAttribute set an attribute of the span, the keys follow the semantic conventions of
+// opentelemetry where possible, such as http.method and db.system.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+	s.lock.Unlock()
+}
+
+// SetStatus set the status of the span, the message is only kept for the error status.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.status = code
+	if code == StatusError {
+		s.message = message
+	}
+	s.lock.Unlock()
+}
+
+// End end the span and export it if the trace is sampled, the span can only be ended once.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.lock.Unlock()
+
+	if s.ctx.Sampled {
+		export(s.data())
+	}
+}
+
+// Finish set the error status if err is not nil, and end the span.
+func (s *Span) Finish(err error) {
+	if err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+	s.End()
+}
+
+func (s *Span) data() *SpanData {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data := &SpanData{
+		TraceID:        s.ctx.TraceID.String(),
+		SpanID:         s.ctx.SpanID.String(),
+		Name:           s.name,
+		Kind:           s.kind,
+		StartTime:      s.start,
+		EndTime:        s.end,
+		DurationMicros: s.end.Sub(s.start).Nanoseconds() / int64(time.Microsecond),
+		Attributes:     s.attributes,
+		Status:         s.status,
+		StatusMessage:  s.message,
+	}
+	if s.parent.IsValid() {
+		data.ParentSpanID = s.parent.String()
+	}
+	return data
+}
+
+// SpanData is the exported form of an ended span
+type SpanData struct {
+	TraceID        string                 `json:"traceId"`
+	SpanID         string                 `json:"spanId"`
+	ParentSpanID   string                 `json:"parentSpanId,omitempty"`
+	Name           string                 `json:"name"`
+	Kind           SpanKind               `json:"kind"`
+	Service        string                 `json:"service"`
+	StartTime      time.Time              `json:"startTime"`
+	EndTime        time.Time              `json:"endTime"`
+	DurationMicros int64                  `json:"durationMicros"`
+	Attributes     map[string]interface{} `json:"attributes,omitempty"`
+	Status         StatusCode             `json:"status"`
+	StatusMessage  string                 `json:"statusMessage,omitempty"`
+}
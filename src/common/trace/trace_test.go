@@ -0,0 +1,164 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+)
+
+type memoryExporter struct {
+	lock  sync.Mutex
+	spans []*SpanData
+}
+
+func (e *memoryExporter) ExportSpan(span *SpanData) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func (e *memoryExporter) Close() error {
+	return nil
+}
+
+func TestTraceparent(t *testing.T) {
+	value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(value)
+	if err != nil {
+		t.Fatalf("parse traceparent failed, err: %v", err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Errorf("unexpected span context %+v", sc)
+	}
+	if FormatTraceparent(sc) != value {
+		t.Errorf("unexpected traceparent %s", FormatTraceparent(sc))
+	}
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+	for _, v := range invalid {
+		if _, err := ParseTraceparent(v); err == nil {
+			t.Errorf("traceparent %s should be invalid", v)
+		}
+	}
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Errorf("the fields appended by the future versions should be ignored, err: %v", err)
+	}
+}
+
+func TestStartSpan(t *testing.T) {
+	exporter := new(memoryExporter)
+	Init("test", exporter, 1)
+	defer Init("", nil, 1)
+
+	parent := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}, Sampled: true}
+	ctx := ContextWithRemoteParent(context.Background(), parent)
+	ctx, server := StartSpan(ctx, "server", KindServer)
+	_, client := StartSpan(ctx, "client", KindClient)
+	client.SetAttribute("http.method", "GET")
+	client.Finish(context.DeadlineExceeded)
+	server.End()
+	server.End()
+
+	if len(exporter.spans) != 2 {
+		t.Fatalf("expect 2 spans exported, but got %d", len(exporter.spans))
+	}
+	c, s := exporter.spans[0], exporter.spans[1]
+	if c.TraceID != parent.TraceID.String() || s.TraceID != parent.TraceID.String() {
+		t.Errorf("the spans should be in the trace of the remote parent")
+	}
+	if s.ParentSpanID != parent.SpanID.String() || c.ParentSpanID != s.SpanID {
+		t.Errorf("unexpected parents, server: %s, client: %s", s.ParentSpanID, c.ParentSpanID)
+	}
+	if c.Status != StatusError || c.Service != "test" || c.Attributes["http.method"] != "GET" {
+		t.Errorf("unexpected client span %+v", c)
+	}
+
+	// the decision of the caller is followed
+	ctx = ContextWithRemoteParent(context.Background(), SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}})
+	_, span := StartSpan(ctx, "unsampled", KindServer)
+	span.End()
+	if len(exporter.spans) != 2 {
+		t.Errorf("the span of the unsampled trace should not be exported")
+	}
+}
+
+func TestRestfulFilter(t *testing.T) {
+	exporter := new(memoryExporter)
+	Init("test", exporter, 1)
+	defer Init("", nil, 1)
+
+	var forwarded SpanContext
+	container := restful.NewContainer()
+	ws := new(restful.WebService)
+	ws.Path("/test/v3")
+	ws.Route(ws.GET("/inst/{id}").To(func(req *restful.Request, resp *restful.Response) {
+		forwarded = Extract(req.Request.Header)
+		StartDBSpan(ContextWithHeader(context.Background(), req.Request.Header), "mongodb", "find", "cc_test").End()
+		resp.WriteHeader(http.StatusInternalServerError)
+	}))
+	container.Add(ws)
+	container.Filter(RestfulFilter)
+
+	req := httptest.NewRequest(http.MethodGet, "/test/v3/inst/1", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	container.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(exporter.spans) != 2 {
+		t.Fatalf("expect 2 spans exported, but got %d", len(exporter.spans))
+	}
+	db, server := exporter.spans[0], exporter.spans[1]
+	if server.Name != "GET /test/v3/inst/{id}" || server.Kind != KindServer || server.Status != StatusError {
+		t.Errorf("unexpected server span %+v", server)
+	}
+	if server.ParentSpanID != "00f067aa0ba902b7" || forwarded.SpanID.String() != server.SpanID {
+		t.Errorf("the header should be forwarded with the server span")
+	}
+	if db.ParentSpanID != server.SpanID || db.Name != "mongodb find cc_test" {
+		t.Errorf("unexpected db span %+v", db)
+	}
+}
+
+func TestWriterExporter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	exporter := NewWriterExporter(buf)
+	exporter.ExportSpan(&SpanData{TraceID: "a", SpanID: "b", Name: "one"})
+	exporter.ExportSpan(&SpanData{TraceID: "a", SpanID: "c", Name: "two"})
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("close exporter failed, err: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expect 2 lines, but got: %s", buf.String())
+	}
+	span := new(SpanData)
+	if err := json.Unmarshal([]byte(lines[1]), span); err != nil || span.Name != "two" {
+		t.Errorf("unexpected span %s, err: %v", lines[1], err)
+	}
+}
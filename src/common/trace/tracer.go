@@ -0,0 +1,238 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+const (
+	// ExporterNone no span is exported, the trace context is still propagated
+	ExporterNone = "none"
+	// ExporterStdout the spans are written to the stdout as json lines
+	ExporterStdout = "stdout"
+	// ExporterFile the spans are appended to a file as json lines
+	ExporterFile = "file"
+)
+
+type contextKey int
+
+const (
+	spanKey contextKey = iota
+	remoteKey
+)
+
+// tracer the process wide tracing settings
+var tracer = struct {
+	lock        sync.RWMutex
+	service     string
+	exporter    Exporter
+	sampleRatio float64
+	// config the config the tracing is inited with by InitFromConfig
+	config string
+}{sampleRatio: 1}
+
+var random = struct {
+	lock sync.Mutex
+	rand *rand.Rand
+}{rand: newRand()}
+
+func newRand() *rand.Rand {
+	var seed int64
+	if err := binary.Read(cryptorand.Reader, binary.LittleEndian, &seed); err != nil {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// Init set the exporter the spans of the service are exported with, and the ratio of the
+// traces started by the service which are sampled. the previous exporter is closed, and a nil
+// exporter disables the exporting.
+func Init(service string, exporter Exporter, sampleRatio float64) {
+	tracer.lock.Lock()
+	previous := tracer.exporter
+	tracer.service = service
+	tracer.exporter = exporter
+	tracer.sampleRatio = sampleRatio
+	tracer.config = ""
+	tracer.lock.Unlock()
+
+	if previous != nil && previous != exporter {
+		if err := previous.Close(); err != nil {
+			blog.Errorf("close the previous trace exporter failed, err: %v", err)
+		}
+	}
+}
+
+// InitFromConfig init the tracing with the process config, the keys are:
+// trace.exporter: none, stdout or file, the default is none.
+// trace.file: the file the spans are appended to with the file exporter.
+// trace.sampleRatio: the ratio of the traces started by the service which are sampled, the default is 1.
+func InitFromConfig(service string, configMap map[string]string) error {
+	// the process config is reloaded when any item changes, the exporter is kept if the tracing
+	// config is not changed.
+	config := strings.Join([]string{service, configMap["trace.exporter"], configMap["trace.file"],
+		configMap["trace.sampleRatio"]}, "|")
+	tracer.lock.RLock()
+	unchanged := tracer.config == config
+	tracer.lock.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	sampleRatio := 1.0
+	if ratio := configMap["trace.sampleRatio"]; ratio != "" {
+		var err error
+		sampleRatio, err = strconv.ParseFloat(ratio, 64)
+		if err != nil || sampleRatio < 0 || sampleRatio > 1 {
+			return fmt.Errorf("invalid trace.sampleRatio %s, it should be in [0, 1]", ratio)
+		}
+	}
+
+	exporter, err := NewExporter(configMap["trace.exporter"], configMap["trace.file"])
+	if err != nil {
+		return err
+	}
+	Init(service, exporter, sampleRatio)
+	tracer.lock.Lock()
+	tracer.config = config
+	tracer.lock.Unlock()
+	return nil
+}
+
+// Enabled check whether the spans are exported, the callers can skip the costly attributes if not.
+func Enabled() bool {
+	tracer.lock.RLock()
+	defer tracer.lock.RUnlock()
+	return tracer.exporter != nil
+}
+
+func export(data *SpanData) {
+	tracer.lock.RLock()
+	defer tracer.lock.RUnlock()
+	if tracer.exporter == nil {
+		return
+	}
+	data.Service = tracer.service
+	tracer.exporter.ExportSpan(data)
+}
+
+// sample decide whether a trace started by this service is recorded
+func sample() bool {
+	tracer.lock.RLock()
+	enabled, ratio := tracer.exporter != nil, tracer.sampleRatio
+	tracer.lock.RUnlock()
+	if !enabled || ratio <= 0 {
+		return false
+	}
+	if ratio >= 1 {
+		return true
+	}
+	random.lock.Lock()
+	defer random.lock.Unlock()
+	return random.rand.Float64() < ratio
+}
+
+func newIDs(traceID *TraceID, spanID *SpanID) {
+	random.lock.Lock()
+	defer random.lock.Unlock()
+	if traceID != nil {
+		for !traceID.IsValid() {
+			random.rand.Read(traceID[:])
+		}
+	}
+	for !spanID.IsValid() {
+		random.rand.Read(spanID[:])
+	}
+}
+
+// StartSpan start a span as the child of the span in the ctx, or the remote parent in the ctx,
+// otherwise a new trace is started. the returned context holds the new span.
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	span := &Span{name: name, kind: kind, start: time.Now(), status: StatusUnset}
+
+	parent := SpanContextFromContext(ctx)
+	if parent.IsValid() {
+		span.ctx.TraceID = parent.TraceID
+		span.ctx.Sampled = parent.Sampled
+		span.parent = parent.SpanID
+		newIDs(nil, &span.ctx.SpanID)
+	} else {
+		span.ctx.Sampled = sample()
+		newIDs(&span.ctx.TraceID, &span.ctx.SpanID)
+	}
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// StartDBSpan start a client span of a database operation, such as a mongodb find or a redis command.
+func StartDBSpan(ctx context.Context, system, operation, table string) *Span {
+	name := system + " " + operation
+	if table != "" {
+		name += " " + table
+	}
+	_, span := StartSpan(ctx, name, KindClient)
+	span.SetAttribute("db.system", system)
+	span.SetAttribute("db.operation", operation)
+	if table != "" {
+		span.SetAttribute("db.collection", table)
+	}
+	return span
+}
+
+// SpanFromContext get the span in the ctx, it's nil if there is not.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey).(*Span)
+	return span
+}
+
+// ContextWithSpan return a context holds the span, the spans started with it are the children of the span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey, span)
+}
+
+// ContextWithRemoteParent return a context holds the span context of the remote caller, the spans
+// started with it are the children of the remote span.
+func ContextWithRemoteParent(ctx context.Context, parent SpanContext) context.Context {
+	if !parent.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey, parent)
+}
+
+// SpanContextFromContext get the span context of the span in the ctx, or the remote parent in the ctx.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	remote, _ := ctx.Value(remoteKey).(SpanContext)
+	return remote
+}
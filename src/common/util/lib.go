@@ -22,6 +22,7 @@ import (
 	"sync/atomic"
 
 	"configcenter/src/common"
+	"configcenter/src/common/trace"
 	"configcenter/src/storage/dal"
 	"github.com/emicklei/go-restful"
 	"github.com/rs/xid"
@@ -105,7 +106,8 @@ func GetHTTPCCTransaction(header http.Header) string {
 	return rid
 }
 
-// GetDBContext returns a new context that contains JoinOption
+// GetDBContext returns a new context that contains JoinOption, and the span of the request
+// as the parent of the spans of the database operations.
 func GetDBContext(parent context.Context, header http.Header) context.Context {
 	rid := header.Get(common.BKHTTPCCRequestID)
 	user := GetUser(header)
@@ -115,6 +117,9 @@ func GetDBContext(parent context.Context, header http.Header) context.Context {
 	})
 	ctx = context.WithValue(ctx, common.ContextRequestIDField, rid)
 	ctx = context.WithValue(ctx, common.ContextRequestUserField, user)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = trace.ContextWithHeader(ctx, header)
+	}
 	return ctx
 }
 
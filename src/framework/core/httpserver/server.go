@@ -67,6 +67,11 @@ func (s *HttpServer) RegisterActions(as ...Action) {
 	s.server.RegisterActions(s.rootWS, httpactions)
 }
 
+// AddFilter add a filter to all the requests of the server
+func (s *HttpServer) AddFilter(filter restful.FilterFunction) {
+	s.server.GetWebContainer().Filter(filter)
+}
+
 func (s *HttpServer) ListenAndServe() error {
 	return s.server.ListenAndServe()
 }
@@ -19,6 +19,7 @@ import (
 type Server interface {
 	ListenAndServe() error
 	RegisterActions(as ...Action)
+	AddFilter(filter restful.FilterFunction)
 }
 
 type Action struct {
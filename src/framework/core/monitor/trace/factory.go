@@ -1,14 +1,28 @@
 /*
  * Tencent is pleased to support the open source community by making 蓝鲸 available.
  * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
- * Licensed under the MIT License (the "License"); you may not use this file except 
+ * Licensed under the MIT License (the "License"); you may not use this file except
  * in compliance with the License. You may obtain a copy of the License at
  * http://opensource.org/licenses/MIT
  * Unless required by applicable law or agreed to in writing, software distributed under
  * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
- * either express or implied. See the License for the specific language governing permissions and 
+ * either express or implied. See the License for the specific language governing permissions and
  * limitations under the License.
  */
- 
 package trace
 
+import (
+	"configcenter/src/common/trace"
+	"configcenter/src/framework/core/option"
+)
+
+// NewManager init the tracing with the options, the spans are exported by the exporter set by
+// the --trace-exporter flag, and the trace context is propagated even if no exporter is set.
+func NewManager(opt *option.Options) (Trace, error) {
+	exporter, err := trace.NewExporter(opt.TraceExporter, opt.TraceFile)
+	if err != nil {
+		return nil, err
+	}
+	trace.Init(opt.AppName, exporter, opt.TraceSampleRatio)
+	return &Manager{}, nil
+}
@@ -1,14 +1,33 @@
 /*
  * Tencent is pleased to support the open source community by making 蓝鲸 available.
  * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
- * Licensed under the MIT License (the "License"); you may not use this file except 
+ * Licensed under the MIT License (the "License"); you may not use this file except
  * in compliance with the License. You may obtain a copy of the License at
  * http://opensource.org/licenses/MIT
  * Unless required by applicable law or agreed to in writing, software distributed under
  * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
- * either express or implied. See the License for the specific language governing permissions and 
+ * either express or implied. See the License for the specific language governing permissions and
  * limitations under the License.
  */
- 
 package trace
 
+import (
+	"configcenter/src/common/trace"
+
+	"github.com/emicklei/go-restful"
+)
+
+// Manager the tracing implemented with the common trace package
+type Manager struct{}
+
+var _ Trace = &Manager{}
+
+// Filter returns the restful filter which starts a server span for each request
+func (m *Manager) Filter() restful.FilterFunction {
+	return trace.RestfulFilter
+}
+
+// Close flush the spans which are not exported yet
+func (m *Manager) Close() {
+	trace.Init("", nil, 0)
+}
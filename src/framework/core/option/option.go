@@ -21,4 +21,7 @@ func (cli *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&cli.Addrport, "addrport", "127.0.0.1:8086", "which addrport should this server listen on, e.g: 127.0.0.1:8086")
 	fs.StringVar(&cli.Config, "config", "", "The config path. e.g conf/api.conf")
 	fs.StringVar(&cli.Regdiscv, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181")
+	fs.StringVar(&cli.TraceExporter, "trace-exporter", "none", "the exporter of the trace spans, one of none, stdout and file")
+	fs.StringVar(&cli.TraceFile, "trace-file", "", "the file the trace spans are appended to with the file exporter")
+	fs.Float64Var(&cli.TraceSampleRatio, "trace-sample-ratio", 1, "the ratio of the traces started by this application which are sampled")
 }
@@ -20,4 +20,8 @@ type Options struct {
 	Config   string
 	Regdiscv string
 	Addrport string
+
+	TraceExporter    string
+	TraceFile        string
+	TraceSampleRatio float64
 }
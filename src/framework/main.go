@@ -31,6 +31,7 @@ import (
 	"configcenter/src/framework/core/httpserver"
 	"configcenter/src/framework/core/log"
 	"configcenter/src/framework/core/monitor/metric"
+	"configcenter/src/framework/core/monitor/trace"
 	"configcenter/src/framework/core/option"
 	"configcenter/src/framework/core/output/module/client"
 	_ "configcenter/src/framework/plugins"
@@ -121,6 +122,14 @@ func main() {
 
 	metricManager := metric.NewManager(opt)
 
+	traceManager, err := trace.NewManager(opt)
+	if err != nil {
+		log.Errorf("init trace error: %v", err)
+		return
+	}
+	defer traceManager.Close()
+	server.AddFilter(traceManager.Filter())
+
 	server.RegisterActions(api.Actions()...)
 	server.RegisterActions(metricManager.Actions()...)
 
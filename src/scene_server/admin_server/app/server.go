@@ -64,7 +64,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	service.Config = *process.Config
 	process.Core = engine
 	process.Service = service
-	process.ConfigCenter = configures.NewConfCenter(ctx, engine.ServiceManageClient())
+	process.ConfigCenter = configures.NewConfCenter(ctx, engine.RegDiscvBackend().ConfRegDiscv())
 	for {
 		if process.Config == nil {
 			time.Sleep(time.Second * 2)
@@ -43,4 +43,15 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.04.16.01"
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.04.16.02"
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.04.16.03"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.10.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.16.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.17.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.20.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.20.02"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.20.03"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.20.04"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.20.05"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.20.06"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.20.07"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.20.08"
 )
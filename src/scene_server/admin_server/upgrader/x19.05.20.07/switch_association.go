@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_05_20_07
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// addSwitchConnectSwitchAssociation adds the association between the switches discovered by lldp/cdp,
+// the association is only inserted if it's missing, so that the one changed by the users is kept
+func addSwitchConnectSwitchAssociation(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	cond := mapstr.MapStr{
+		common.AssociationObjAsstIDField: "bk_switch_connect_bk_switch",
+		common.BKOwnerIDField:            conf.OwnerID,
+	}
+	count, err := db.Table(common.BKTableNameObjAsst).Find(cond).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	id, err := db.NextSequence(ctx, common.BKTableNameObjAsst)
+	if err != nil {
+		return err
+	}
+	falseVar := false
+	switchAsst := metadata.Association{
+		ID:              int64(id),
+		OwnerID:         conf.OwnerID,
+		AsstKindID:      "connect",
+		ObjectID:        common.BKInnerObjIDSwitch,
+		AsstObjID:       common.BKInnerObjIDSwitch,
+		AssociationName: "bk_switch_connect_bk_switch",
+		Mapping:         metadata.ManyToManyMapping,
+		OnDelete:        metadata.NoAction,
+		IsPre:           &falseVar,
+	}
+	return db.Table(common.BKTableNameObjAsst).Insert(ctx, switchAsst)
+}
@@ -36,7 +36,7 @@ func NewServerOption() *ServerOption {
 func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.AddrPort, "addrport", "127.0.0.1:50006", "The ip address and port for the serve on")
 
-	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181")
+	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181, etcd://127.0.0.1:2379 or file:///data/cmdb/regdiscv")
 	fs.StringVar(&s.ServConf.ExConfig, "config", "", "The config path. e.g conf/api.conf")
 }
 
@@ -46,7 +46,12 @@ type Config struct {
 	SnapRedis       SnapRedis
 	DiscoverRedis   SnapRedis
 	NetcollectRedis SnapRedis
+	PluginRedis     SnapRedis
 	Esb             esbutil.EsbConfig
+	// CredentialKey the key to encrypt the secrets of the net collect credentials
+	CredentialKey string
+	// PluginDir the directory of the collect plugin declarations
+	PluginDir string
 }
 
 type SnapRedis struct {
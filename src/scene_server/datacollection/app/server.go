@@ -78,7 +78,10 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 			return fmt.Errorf("new esb client failed, err: %s", err.Error())
 		}
 
-		process.Service.Logics = logics.NewLogics(ctx, service.Engine, instance, esb)
+		process.Service.Logics = logics.NewLogics(ctx, service.Engine, instance, esb, process.Config.CredentialKey)
+		if err := process.Service.Logics.EncryptCollectorCommunities(); err != nil {
+			blog.Errorf("encrypt the communities of the collectors failed, err: %v", err)
+		}
 
 		err = datacollection.NewDataCollection(ctx, process.Config, process.Core).Run()
 		if err != nil {
@@ -138,10 +141,18 @@ func (h *DCServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		h.Config.NetcollectRedis.Config = netcollectRedisConf
 		h.Config.SnapRedis.Enable = current.ConfigMap[netcollectPrefix+".enable"]
 
+		pluginPrefix := "plugin-redis"
+		pluginRedisConf := redis.ParseConfigFromKV(pluginPrefix, current.ConfigMap)
+		h.Config.PluginRedis.Config = pluginRedisConf
+		h.Config.PluginRedis.Enable = current.ConfigMap[pluginPrefix+".enable"]
+
 		esbPrefix := "esb"
 		h.Config.Esb.Addrs = current.ConfigMap[esbPrefix+".addr"]
 		h.Config.Esb.AppCode = current.ConfigMap[esbPrefix+".appCode"]
 		h.Config.Esb.AppSecret = current.ConfigMap[esbPrefix+".appSecret"]
+
+		h.Config.CredentialKey = current.ConfigMap["netcollect.credentialKey"]
+		h.Config.PluginDir = current.ConfigMap["plugin.dir"]
 	}
 }
 
@@ -26,6 +26,7 @@ import (
 	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
 	"configcenter/src/scene_server/datacollection/datacollection/middleware"
 	"configcenter/src/scene_server/datacollection/datacollection/netcollect"
+	"configcenter/src/scene_server/datacollection/datacollection/plugin"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/redis"
@@ -95,7 +96,7 @@ func (d *DataCollection) Run() error {
 		}
 		blog.Infof("[datacollect][RUN]connected to discover-redis %+v", d.Config.DiscoverRedis.Config)
 		discoverChanName := d.getDiscoverChanName(defaultAppID)
-		middlewareCollector := middleware.NewDiscover(d.ctx, rediscli, d.Engine)
+		middlewareCollector := middleware.NewDiscover(d.ctx, rediscli, d.Engine, db)
 		middlewarePorter := BuildChanPorter("middleware", middlewareCollector, rediscli, discli, discoverChanName, middleware.MockMessage)
 		man.AddPorter(middlewarePorter)
 	}
@@ -114,6 +115,29 @@ func (d *DataCollection) Run() error {
 		man.AddPorter(netcollectPorter)
 	}
 
+	if d.Config.PluginRedis.Enable != "false" && d.Config.PluginDir != "" {
+		plugins, err := plugin.LoadConfigs(d.Config.PluginDir)
+		if err != nil {
+			blog.Errorf("[datacollection][RUN] load plugins from %s failed: %v", d.Config.PluginDir, err)
+			return err
+		}
+		if len(plugins) > 0 {
+			blog.Infof("[datacollect][RUN]connecting to plugin-redis %+v", d.Config.PluginRedis.Config)
+			plugincli, err := redis.NewFromConfig(d.Config.PluginRedis.Config)
+			if nil != err {
+				blog.Errorf("[datacollection][RUN] connect plugin-redis failed: %v", err)
+				return err
+			}
+			blog.Infof("[datacollect][RUN]connected to plugin-redis %+v", d.Config.PluginRedis.Config)
+			pluginDiscover := middleware.NewDiscover(d.ctx, rediscli, d.Engine, db)
+			for _, config := range plugins {
+				pluginCollector := plugin.NewPlugin(d.ctx, config, pluginDiscover)
+				pluginPorter := BuildChanPorter(pluginCollector.Name(), pluginCollector, rediscli, plugincli, pluginCollector.Channels(), "")
+				man.AddPorter(pluginPorter)
+			}
+		}
+	}
+
 	blog.Infof("datacollection started")
 	return nil
 }
@@ -19,6 +19,7 @@ import (
 
 	bkc "configcenter/src/common"
 	"configcenter/src/common/backbone"
+	"configcenter/src/storage/dal"
 
 	"gopkg.in/redis.v5"
 )
@@ -28,18 +29,20 @@ type Discover struct {
 	pheader http.Header
 
 	redisCli *redis.Client
+	db       dal.RDB
 	*backbone.Engine
 }
 
 var msgHandlerCnt = int64(0)
 
-func NewDiscover(ctx context.Context, redisCli *redis.Client, backbone *backbone.Engine) *Discover {
+func NewDiscover(ctx context.Context, redisCli *redis.Client, backbone *backbone.Engine, db dal.RDB) *Discover {
 	pheader := http.Header{}
 	pheader.Add(bkc.BKHTTPOwnerID, bkc.BKDefaultOwnerID)
 	pheader.Add(bkc.BKHTTPHeaderUser, bkc.CCSystemCollectorUserName)
 
 	discover := &Discover{
 		redisCli: redisCli,
+		db:       db,
 		ctx:      ctx,
 		pheader:  pheader,
 	}
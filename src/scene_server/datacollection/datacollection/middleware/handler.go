@@ -585,8 +585,19 @@ func (d *Discover) UpdateOrCreateInst(msg string) error {
 
 	blog.Infof("get inst result: %v", inst)
 
+	policy, err := d.GetPolicy(ownerID, objID)
+	if nil != err {
+		return fmt.Errorf("get discover policy error: %s", err)
+	}
+
 	if len(inst) <= 0 {
 		data, err := mapstr.NewFromInterface(gjson.Get(msg, "data.data").Value())
+		if !policy.AutoCreate {
+			if err != nil {
+				return fmt.Errorf("parse data error: %s", err)
+			}
+			return d.SaveReport(ownerID, objID, instKeyStr, nil, data)
+		}
 		resp, err := d.CoreAPI.CoreService().Instance().CreateInstance(d.ctx, d.pheader, objID, &metadata.CreateModelInstance{Data: data})
 		if err != nil {
 			blog.Errorf("search model failed %s", err.Error())
@@ -606,6 +617,7 @@ func (d *Discover) UpdateOrCreateInst(msg string) error {
 	}
 
 	hasDiff := false
+	pendingAttrs := make([]string, 0)
 	for attrId, attrValue := range bodyData {
 
 		if attrId == defaultRelateAttr {
@@ -633,6 +645,10 @@ func (d *Discover) UpdateOrCreateInst(msg string) error {
 		}
 
 		if inst[attrId] != attrValue {
+			if policy.NeedConfirm(attrId) {
+				pendingAttrs = append(pendingAttrs, attrId)
+				continue
+			}
 			inst[attrId] = attrValue
 			blog.Debug("[changed]  %s: %v ---> %v", attrId, attrValue, inst[attrId])
 			hasDiff = true
@@ -640,6 +656,12 @@ func (d *Discover) UpdateOrCreateInst(msg string) error {
 
 	}
 
+	if len(policy.ConfirmFields) > 0 {
+		if err := d.reportPending(ownerID, objID, instKeyStr, mapstr.MapStr(bodyData), pendingAttrs); err != nil {
+			return fmt.Errorf("save report error: %s", err)
+		}
+	}
+
 	if !hasDiff {
 		blog.Infof("no need to update inst")
 		return nil
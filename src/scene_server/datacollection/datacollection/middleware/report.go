@@ -0,0 +1,139 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"fmt"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// GetPolicy returns the discover policy of the model, the default one if not set
+func (d *Discover) GetPolicy(ownerID, objID string) (metadata.DiscoverPolicy, error) {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+	cond.Field(common.BKObjIDField).Eq(objID)
+
+	policies := []metadata.DiscoverPolicy{}
+	if err := d.db.Table(common.BKTableNameDiscoverPolicy).Find(cond.ToMapStr()).All(d.ctx, &policies); err != nil {
+		return metadata.DiscoverPolicy{}, err
+	}
+	if len(policies) <= 0 {
+		return metadata.DefaultDiscoverPolicy(objID), nil
+	}
+	return policies[0], nil
+}
+
+// FindInst finds the instance of the supplier account by the bk_inst_key from db, nil if not exists
+func (d *Discover) FindInst(ownerID, objID, instKey string) (mapstr.MapStr, error) {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+	cond.Field(common.BKObjIDField).Eq(objID)
+	cond.Field(common.BKInstKeyField).Eq(instKey)
+
+	insts := []mapstr.MapStr{}
+	if err := d.db.Table(common.GetInstTableName(objID)).Find(cond.ToMapStr()).All(d.ctx, &insts); err != nil {
+		return nil, err
+	}
+	if len(insts) <= 0 {
+		return nil, nil
+	}
+	return insts[0], nil
+}
+
+// SaveReport saves the changes of the instance to be confirmed by ConfirmReport,
+// the pending report of the instance is removed if there is no changes
+func (d *Discover) SaveReport(ownerID, objID, instKey string, inst mapstr.MapStr, data mapstr.MapStr) error {
+	report := metadata.NetcollectReport{
+		Action:       metadata.ReporctActionCreate,
+		ObjectID:     objID,
+		OwnerID:      ownerID,
+		InstKey:      instKey,
+		KeyField:     common.BKInstKeyField,
+		Source:       metadata.ReportSourceDiscover,
+		LastTime:     metadata.Now(),
+		Attributes:   DiffAttributes(inst, data),
+		Associations: []metadata.NetcollectReportAssociation{},
+	}
+	if inst != nil {
+		report.Action = metadata.ReporctActionUpdate
+	}
+
+	existCond := condition.CreateCondition()
+	existCond.Field(common.BKOwnerIDField).Eq(report.OwnerID)
+	existCond.Field(common.BKCloudIDField).Eq(report.CloudID)
+	existCond.Field(common.BKObjIDField).Eq(report.ObjectID)
+	existCond.Field(common.BKInstKeyField).Eq(report.InstKey)
+	if len(report.Attributes) <= 0 {
+		return d.db.Table(common.BKTableNameNetcollectReport).Delete(d.ctx, existCond.ToMapStr())
+	}
+
+	count, err := d.db.Table(common.BKTableNameNetcollectReport).Find(existCond.ToMapStr()).Count(d.ctx)
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return d.db.Table(common.BKTableNameNetcollectReport).Insert(d.ctx, report)
+	}
+	return d.db.Table(common.BKTableNameNetcollectReport).Update(d.ctx, existCond.ToMapStr(), report)
+}
+
+// reportPending saves the changes of the fields which need confirmation, they are compared with
+// the instance in db, for the cached one may be out of date after confirmed
+func (d *Discover) reportPending(ownerID, objID, instKey string, data mapstr.MapStr, fields []string) error {
+	pending := mapstr.MapStr{}
+	for _, field := range fields {
+		pending[field] = data[field]
+	}
+	if len(pending) <= 0 {
+		// clear the out of date report
+		return d.SaveReport(ownerID, objID, instKey, mapstr.MapStr{}, pending)
+	}
+
+	inst, err := d.FindInst(ownerID, objID, instKey)
+	if err != nil {
+		return fmt.Errorf("find inst error: %v", err)
+	}
+	if inst == nil {
+		// the instance is removed, it is handled as a new one next time
+		d.TryUnsetRedis(instKey)
+		return nil
+	}
+	return d.SaveReport(ownerID, objID, instKey, inst, pending)
+}
+
+// DiffAttributes returns the attributes of the data which are different from the instance,
+// all of them are returned if the instance not exists
+func DiffAttributes(inst mapstr.MapStr, data mapstr.MapStr) []metadata.NetcollectReportAttribute {
+	propertyIDs := make([]string, 0, len(data))
+	for propertyID := range data {
+		propertyIDs = append(propertyIDs, propertyID)
+	}
+	sort.Strings(propertyIDs)
+
+	attributes := make([]metadata.NetcollectReportAttribute, 0)
+	for _, propertyID := range propertyIDs {
+		if inst != nil && inst.Exists(propertyID) && fmt.Sprint(inst[propertyID]) == fmt.Sprint(data[propertyID]) {
+			continue
+		}
+		attributes = append(attributes, metadata.NetcollectReportAttribute{
+			PropertyID: propertyID,
+			CurValue:   data[propertyID],
+		})
+	}
+	return attributes
+}
@@ -0,0 +1,32 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"testing"
+
+	"configcenter/src/common/mapstr"
+)
+
+func TestDiffAttributes(t *testing.T) {
+	data := mapstr.MapStr{"bk_inst_key": "acme#sn01", "bk_capacity": float64(1024), "bk_vendor": "acme"}
+	if attrs := DiffAttributes(nil, data); len(attrs) != 3 {
+		t.Errorf("all attributes should be reported for new instance, got %+v", attrs)
+	}
+
+	inst := mapstr.MapStr{"bk_inst_key": "acme#sn01", "bk_capacity": int64(512), "bk_vendor": "acme"}
+	attrs := DiffAttributes(inst, data)
+	if len(attrs) != 1 || attrs[0].PropertyID != "bk_capacity" {
+		t.Errorf("only bk_capacity changed, got %+v", attrs)
+	}
+}
@@ -58,6 +58,11 @@ func (h *Netcollect) Analyze(raw string) error {
 }
 
 func (h *Netcollect) handleReport(report *metadata.NetcollectReport) (err error) {
+	// the attributes are still reported when the neighbors could not be resolved
+	if err = h.buildTopoAssociations(report); err != nil {
+		blog.Errorf("[datacollect][netcollect] build topo associations for %s error: %v", report.InstKey, err)
+	}
+
 	// TODO compare 若有变化才插入
 	if err = h.upsertReport(report); err != nil {
 		blog.Errorf("[datacollect][netcollect] upsert association error: %v", err)
@@ -133,7 +138,24 @@ const MockMessage = `{
                     "bk_asst_obj_name": "主机",
                     "bk_asst_property_id": "bk_host_id"
 				}
-			]
+			],
+            "chassis_id": "56-79-9a-00-00-01",
+            "neighbors": [
+                {
+                    "protocol": "lldp",
+                    "local_port": "GE0/0/24",
+                    "remote_chassis_id": "56-79-9a-00-00-02",
+                    "remote_sys_name": "huawei 5789#56-79-9a-jj",
+                    "remote_port": "GE0/0/1",
+                    "remote_address": "192.168.1.2"
+                }
+            ],
+            "interfaces": [
+                {
+                    "port": "GE0/0/3",
+                    "macs": ["5254-0012-3456"]
+                }
+            ]
         }
     ]
 }
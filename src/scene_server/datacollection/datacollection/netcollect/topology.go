@@ -0,0 +1,216 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// buildTopoAssociations converts the lldp/cdp neighbors and the interface macs of the switch report
+// into associations, so that the physical connections are confirmed with ConfirmReport like the others.
+// only the connections of the switches to the switches and the hosts are built.
+func (h *Netcollect) buildTopoAssociations(report *metadata.NetcollectReport) error {
+	if report.ObjectID != common.BKInnerObjIDSwitch {
+		return nil
+	}
+
+	// the macs learned on a port with a neighbor device come from the hosts behind that device,
+	// so only the access ports are used to match the hosts
+	uplinks := map[string]bool{}
+	for _, neighbor := range report.Neighbors {
+		uplinks[neighbor.LocalPort] = true
+
+		remote, err := h.findNeighborReport(report.CloudID, neighbor)
+		if err != nil {
+			return fmt.Errorf("find neighbor %+v failed: %v", neighbor, err)
+		}
+		if remote == nil {
+			blog.V(3).Infof("[datacollect][netcollect] neighbor %+v of %s not collected yet, skip it", neighbor, report.InstKey)
+			continue
+		}
+		if remote.ObjectID == report.ObjectID && remote.InstKey == report.InstKey {
+			continue
+		}
+		objAsstID := topoObjectAsstID(report.ObjectID, remote.ObjectID)
+		if objAsstID == "" {
+			blog.V(3).Infof("[datacollect][netcollect] neighbor %s of %s is a %s, skip it", remote.InstKey, report.InstKey, remote.ObjectID)
+			continue
+		}
+		report.Associations = mergeTopoAssociation(report.Associations, metadata.NetcollectReportAssociation{
+			AsstInstName:  remote.InstKey,
+			AsstObjectID:  remote.ObjectID,
+			ObjectAsstID:  objAsstID,
+			Configuration: neighborConfiguration(neighbor),
+		})
+	}
+
+	portMACs := map[string][]string{}
+	macs := []string{}
+	for _, inter := range report.Interfaces {
+		if uplinks[inter.Port] {
+			continue
+		}
+		for _, mac := range inter.MACs {
+			if mac = normalizeMAC(mac); mac != "" {
+				portMACs[mac] = append(portMACs[mac], inter.Port)
+				macs = append(macs, mac, strings.ToUpper(mac))
+			}
+		}
+	}
+	if len(macs) <= 0 {
+		return nil
+	}
+
+	hosts, err := h.findHostsByMAC(report.CloudID, macs)
+	if err != nil {
+		return fmt.Errorf("find hosts by macs %v failed: %v", macs, err)
+	}
+	for _, host := range hosts {
+		innerIP, err := host.String(common.BKHostInnerIPField)
+		if err != nil || innerIP == "" {
+			continue
+		}
+		for _, field := range []string{common.BKHostMacField, common.BKHostOuterMacField} {
+			mac, _ := host.String(field)
+			for _, port := range portMACs[normalizeMAC(mac)] {
+				report.Associations = mergeTopoAssociation(report.Associations, metadata.NetcollectReportAssociation{
+					AsstInstName:  innerIP,
+					AsstObjectID:  common.BKInnerObjIDHost,
+					ObjectAsstID:  topoObjectAsstID(report.ObjectID, common.BKInnerObjIDHost),
+					Configuration: port,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// findNeighborReport finds the report of the neighbor device by its chassis id or management address,
+// the confirmed reports are looked up in the history
+func (h *Netcollect) findNeighborReport(cloudID int64, neighbor metadata.NetcollectReportNeighbor) (*metadata.NetcollectReport, error) {
+	conds := []condition.Condition{}
+	if neighbor.RemoteChassisID != "" {
+		cond := condition.CreateCondition()
+		cond.Field(common.BKCloudIDField).Eq(cloudID)
+		cond.Field("chassis_id").Eq(neighbor.RemoteChassisID)
+		conds = append(conds, cond)
+	}
+	if neighbor.RemoteAddress != "" {
+		cond := condition.CreateCondition()
+		cond.Field(common.BKCloudIDField).Eq(cloudID)
+		cond.Field(common.BKHostInnerIPField).Eq(neighbor.RemoteAddress)
+		conds = append(conds, cond)
+	}
+
+	for _, tableName := range []string{common.BKTableNameNetcollectReport, common.BKTableNameNetcollectHistory} {
+		for _, cond := range conds {
+			reports := []metadata.NetcollectReport{}
+			err := h.db.Table(tableName).Find(cond.ToMapStr()).Sort("-"+common.LastTimeField).Limit(1).All(h.ctx, &reports)
+			if err != nil {
+				return nil, err
+			}
+			if len(reports) > 0 {
+				return &reports[0], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (h *Netcollect) findHostsByMAC(cloudID int64, macs []string) ([]mapstr.MapStr, error) {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKCloudIDField).Eq(cloudID)
+	cond.Field(common.BKDBOR).Eq([]map[string]interface{}{
+		{common.BKHostMacField: map[string]interface{}{common.BKDBIN: macs}},
+		{common.BKHostOuterMacField: map[string]interface{}{common.BKDBIN: macs}},
+	})
+
+	hosts := []mapstr.MapStr{}
+	err := h.db.Table(common.BKTableNameBaseHost).Find(cond.ToMapStr()).
+		Fields(common.BKHostInnerIPField, common.BKHostMacField, common.BKHostOuterMacField).All(h.ctx, &hosts)
+	return hosts, err
+}
+
+// topoObjectAsstID returns the association of the physical connection between the objects,
+// empty if the connection is not one of the switch topology associations
+func topoObjectAsstID(objID, asstObjID string) string {
+	if objID != common.BKInnerObjIDSwitch {
+		return ""
+	}
+	switch asstObjID {
+	case common.BKInnerObjIDHost:
+		return metadata.NetcollectSwitchConnectHost
+	case common.BKInnerObjIDSwitch:
+		return metadata.NetcollectSwitchConnectSwitch
+	}
+	return ""
+}
+
+func neighborConfiguration(neighbor metadata.NetcollectReportNeighbor) string {
+	return fmt.Sprintf("%s %s-%s", neighbor.Protocol, neighbor.LocalPort, neighbor.RemotePort)
+}
+
+// mergeTopoAssociation appends the association, the ports of an association already exists are joined into its configuration
+func mergeTopoAssociation(assts []metadata.NetcollectReportAssociation, asst metadata.NetcollectReportAssociation) []metadata.NetcollectReportAssociation {
+	for index := range assts {
+		if assts[index].AsstObjectID != asst.AsstObjectID || assts[index].AsstInstName != asst.AsstInstName {
+			continue
+		}
+		if asst.Configuration == "" {
+			return assts
+		}
+		for _, conf := range strings.Split(assts[index].Configuration, ",") {
+			if conf == asst.Configuration {
+				return assts
+			}
+		}
+		if assts[index].Configuration != "" {
+			assts[index].Configuration += ","
+		}
+		assts[index].Configuration += asst.Configuration
+		return assts
+	}
+	return append(assts, asst)
+}
+
+// normalizeMAC formats the mac address like aa:bb:cc:dd:ee:ff, whatever the separators used by the device,
+// returns empty string if it is not a valid mac
+func normalizeMAC(mac string) string {
+	hex := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'f':
+			return r
+		case r >= 'A' && r <= 'F':
+			return r - 'A' + 'a'
+		case r == ':', r == '-', r == '.', r == ' ':
+			return -1
+		}
+		return 'x'
+	}, mac)
+	if len(hex) != 12 || strings.Contains(hex, "x") {
+		return ""
+	}
+
+	parts := make([]string, 0, 6)
+	for i := 0; i < len(hex); i += 2 {
+		parts = append(parts, hex[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
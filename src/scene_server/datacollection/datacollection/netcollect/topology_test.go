@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestNormalizeMAC(t *testing.T) {
+	cases := map[string]string{
+		"52:54:00:12:34:56": "52:54:00:12:34:56",
+		"5254-0012-3456":    "52:54:00:12:34:56",
+		"5254.0012.3456":    "52:54:00:12:34:56",
+		"52-54-00-AB-CD-EF": "52:54:00:ab:cd:ef",
+		"52:54:00:12:34":    "",
+		"52:54:00:12:34:zz": "",
+		"":                  "",
+	}
+	for mac, expect := range cases {
+		if got := normalizeMAC(mac); got != expect {
+			t.Errorf("normalize %s, expect %s, got %s", mac, expect, got)
+		}
+	}
+}
+
+func TestMergeTopoAssociation(t *testing.T) {
+	assts := []metadata.NetcollectReportAssociation{}
+	assts = mergeTopoAssociation(assts, metadata.NetcollectReportAssociation{AsstObjectID: "host", AsstInstName: "10.0.0.1", Configuration: "GE0/0/1"})
+	assts = mergeTopoAssociation(assts, metadata.NetcollectReportAssociation{AsstObjectID: "host", AsstInstName: "10.0.0.1", Configuration: "GE0/0/2"})
+	assts = mergeTopoAssociation(assts, metadata.NetcollectReportAssociation{AsstObjectID: "host", AsstInstName: "10.0.0.1", Configuration: "GE0/0/1"})
+	assts = mergeTopoAssociation(assts, metadata.NetcollectReportAssociation{AsstObjectID: "host", AsstInstName: "10.0.0.2", Configuration: "GE0/0/3"})
+	if len(assts) != 2 {
+		t.Fatalf("expect 2 associations, got %d", len(assts))
+	}
+	if assts[0].Configuration != "GE0/0/1,GE0/0/2" {
+		t.Errorf("expect ports GE0/0/1,GE0/0/2, got %s", assts[0].Configuration)
+	}
+}
+
+func TestTopoObjectAsstID(t *testing.T) {
+	cases := []struct {
+		objID     string
+		asstObjID string
+		expect    string
+	}{
+		{common.BKInnerObjIDSwitch, common.BKInnerObjIDHost, metadata.NetcollectSwitchConnectHost},
+		{common.BKInnerObjIDSwitch, common.BKInnerObjIDSwitch, metadata.NetcollectSwitchConnectSwitch},
+		{common.BKInnerObjIDSwitch, common.BKInnerObjIDRouter, ""},
+		{common.BKInnerObjIDSwitch, common.BKInnerObjIDFirewall, ""},
+		{common.BKInnerObjIDRouter, common.BKInnerObjIDSwitch, ""},
+		{common.BKInnerObjIDRouter, common.BKInnerObjIDHost, ""},
+	}
+	for _, c := range cases {
+		if got := topoObjectAsstID(c.objID, c.asstObjID); got != c.expect {
+			t.Errorf("association of %s and %s, expect %q, got %q", c.objID, c.asstObjID, c.expect, got)
+		}
+	}
+}
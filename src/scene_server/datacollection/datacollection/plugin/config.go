@@ -0,0 +1,215 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// UpdatePolicyAuto updates the instances with the collected data directly
+	UpdatePolicyAuto = "auto"
+	// UpdatePolicyConfirm saves the changes as reports, which are applied after confirmed
+	UpdatePolicyConfirm = "confirm"
+)
+
+// instKeySeparator joins the values of the key properties into the bk_inst_key of the instance
+const instKeySeparator = "#"
+
+// Config declares a collect plugin
+type Config struct {
+	// Name the name of the plugin, which should be unique
+	Name string `json:"name"`
+	// Channels the redis channels the collector publishes the messages to
+	Channels []string `json:"channels"`
+	OwnerID  string   `json:"bk_supplier_account"`
+	Model    Model    `json:"model"`
+	// Records the json path of the records in a message, the whole message is a record if empty
+	Records string `json:"records"`
+	// Fields maps the properties of the model to the json paths in a record
+	Fields map[string]Field `json:"fields"`
+	// Keys the properties to match the instance of a record
+	Keys         []string `json:"keys"`
+	UpdatePolicy string   `json:"update_policy"`
+}
+
+// Model the model the records are collected to, it is created if not exists
+type Model struct {
+	ClassificationID string `json:"bk_classification_id"`
+	ObjectID         string `json:"bk_obj_id"`
+	ObjectName       string `json:"bk_obj_name"`
+}
+
+// Field maps a property to the json path in a record, the property is created if not exists
+type Field struct {
+	Path         string `json:"path"`
+	PropertyName string `json:"bk_property_name"`
+	PropertyType string `json:"bk_property_type"`
+}
+
+// LoadConfigs loads the plugins declared by the json files in the directory
+func LoadConfigs(dir string) ([]Config, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]Config, 0, len(files))
+	names := map[string]bool{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read plugin %s failed: %v", file, err)
+		}
+		config := Config{}
+		if err := json.Unmarshal(content, &config); err != nil {
+			return nil, fmt.Errorf("unmarshal plugin %s failed: %v", file, err)
+		}
+		if err := config.validate(); err != nil {
+			return nil, fmt.Errorf("plugin %s invalid: %v", file, err)
+		}
+		if names[config.Name] {
+			return nil, fmt.Errorf("plugin %s invalid: name %s duplicated", file, config.Name)
+		}
+		names[config.Name] = true
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// validate checks the declaration and fills the defaults
+func (c *Config) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name not set")
+	}
+	if len(c.Channels) <= 0 {
+		return fmt.Errorf("channels not set")
+	}
+	if c.Model.ObjectID == "" || c.Model.ClassificationID == "" {
+		return fmt.Errorf("model bk_obj_id or bk_classification_id not set")
+	}
+	if c.Model.ObjectName == "" {
+		c.Model.ObjectName = c.Model.ObjectID
+	}
+	if c.OwnerID == "" {
+		c.OwnerID = common.BKDefaultOwnerID
+	}
+
+	if len(c.Fields) <= 0 {
+		return fmt.Errorf("fields not set")
+	}
+	if _, ok := c.Fields[common.BKInstKeyField]; ok {
+		return fmt.Errorf("field %s is reserved, it is joined by the keys", common.BKInstKeyField)
+	}
+	for propertyID, field := range c.Fields {
+		if field.Path == "" {
+			return fmt.Errorf("path of field %s not set", propertyID)
+		}
+		if field.PropertyName == "" {
+			field.PropertyName = propertyID
+		}
+		if field.PropertyType == "" {
+			field.PropertyType = common.FieldTypeLongChar
+		}
+		c.Fields[propertyID] = field
+	}
+
+	if len(c.Keys) <= 0 {
+		return fmt.Errorf("keys not set")
+	}
+	for _, key := range c.Keys {
+		if _, ok := c.Fields[key]; !ok {
+			return fmt.Errorf("key %s not in fields", key)
+		}
+	}
+
+	switch c.UpdatePolicy {
+	case "":
+		c.UpdatePolicy = UpdatePolicyAuto
+	case UpdatePolicyAuto, UpdatePolicyConfirm:
+	default:
+		return fmt.Errorf("update_policy %s not supported", c.UpdatePolicy)
+	}
+	return nil
+}
+
+// mapRecord maps a record to the instance data, the bk_inst_key is joined by the values of the keys
+func (c *Config) mapRecord(record gjson.Result) (mapstr.MapStr, error) {
+	data := mapstr.MapStr{}
+	for propertyID, field := range c.Fields {
+		if value := record.Get(field.Path); value.Exists() {
+			data[propertyID] = value.Value()
+		}
+	}
+
+	keys := make([]string, 0, len(c.Keys))
+	for _, key := range c.Keys {
+		value := record.Get(c.Fields[key].Path).String()
+		if value == "" {
+			return nil, fmt.Errorf("key %s is empty", key)
+		}
+		keys = append(keys, value)
+	}
+	instKey := strings.Join(keys, instKeySeparator)
+	data[common.BKInstKeyField] = instKey
+	if _, ok := data[common.BKInstNameField]; !ok {
+		data[common.BKInstNameField] = instKey
+	}
+	return data, nil
+}
+
+// discoverMessage builds the message of the middleware discover, so that the models, the properties
+// and the instances are created and updated the same way as the discovered middlewares
+func (c *Config) discoverMessage(data mapstr.MapStr) (string, error) {
+	fields := map[string]interface{}{
+		common.BKInstKeyField: map[string]interface{}{
+			common.BKPropertyNameField: "采集标识",
+			common.BKPropertyTypeField: common.FieldTypeSingleChar,
+		},
+	}
+	for propertyID, field := range c.Fields {
+		fields[propertyID] = map[string]interface{}{
+			common.BKPropertyNameField: field.PropertyName,
+			common.BKPropertyTypeField: field.PropertyType,
+		}
+	}
+
+	msg := map[string]interface{}{
+		"data": map[string]interface{}{
+			"host": map[string]interface{}{
+				common.BKOwnerIDField: c.OwnerID,
+			},
+			"meta": map[string]interface{}{
+				"model": map[string]interface{}{
+					common.BKClassificationIDField: c.Model.ClassificationID,
+					common.BKObjIDField:            c.Model.ObjectID,
+					common.BKObjNameField:          c.Model.ObjectName,
+					"bk_obj_keys":                  common.BKInstKeyField,
+				},
+				"fields": fields,
+			},
+			"data": data,
+		},
+	}
+	out, err := json.Marshal(msg)
+	return string(out), err
+}
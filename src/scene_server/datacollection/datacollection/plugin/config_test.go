@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/tidwall/gjson"
+)
+
+func newTestConfig() Config {
+	return Config{
+		Name:     "storage",
+		Channels: []string{"storage"},
+		Model:    Model{ClassificationID: "bk_storage", ObjectID: "bk_storage_array"},
+		Records:  "arrays",
+		Fields: map[string]Field{
+			"bk_sn":       {Path: "serial"},
+			"bk_vendor":   {Path: "vendor.name", PropertyName: "vendor"},
+			"bk_capacity": {Path: "capacity", PropertyType: common.FieldTypeInt},
+		},
+		Keys: []string{"bk_vendor", "bk_sn"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	config := newTestConfig()
+	if err := config.validate(); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if config.UpdatePolicy != UpdatePolicyAuto || config.OwnerID != common.BKDefaultOwnerID {
+		t.Errorf("defaults not filled, policy: %s, owner: %s", config.UpdatePolicy, config.OwnerID)
+	}
+	if field := config.Fields["bk_sn"]; field.PropertyName != "bk_sn" || field.PropertyType != common.FieldTypeLongChar {
+		t.Errorf("field defaults not filled: %+v", field)
+	}
+
+	config = newTestConfig()
+	config.Keys = []string{"bk_model"}
+	if err := config.validate(); err == nil {
+		t.Errorf("key not in fields should be invalid")
+	}
+
+	config = newTestConfig()
+	config.UpdatePolicy = "manual"
+	if err := config.validate(); err == nil {
+		t.Errorf("unknown update policy should be invalid")
+	}
+}
+
+func TestMapRecord(t *testing.T) {
+	config := newTestConfig()
+	if err := config.validate(); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	records := gjson.Get(`{"arrays": [{"serial": "sn01", "vendor": {"name": "acme"}, "capacity": 1024}, {"vendor": {"name": "acme"}}]}`, config.Records).Array()
+	data, err := config.mapRecord(records[0])
+	if err != nil {
+		t.Fatalf("map record failed: %v", err)
+	}
+	if data[common.BKInstKeyField] != "acme#sn01" || data[common.BKInstNameField] != "acme#sn01" {
+		t.Errorf("unexpected inst key or name: %v", data)
+	}
+	if data["bk_capacity"] != float64(1024) {
+		t.Errorf("unexpected capacity: %v", data["bk_capacity"])
+	}
+
+	if _, err := config.mapRecord(records[1]); err == nil {
+		t.Errorf("record without key should fail")
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/scene_server/datacollection/datacollection/middleware"
+
+	"github.com/tidwall/gjson"
+)
+
+// Plugin analyzes the messages of a collect plugin into the instances of its model
+type Plugin struct {
+	ctx      context.Context
+	config   Config
+	discover *middleware.Discover
+}
+
+// NewPlugin returns a new collect plugin
+func NewPlugin(ctx context.Context, config Config, discover *middleware.Discover) *Plugin {
+	return &Plugin{
+		ctx:      ctx,
+		config:   config,
+		discover: discover,
+	}
+}
+
+// Name returns the porter name of the plugin
+func (p *Plugin) Name() string {
+	return "plugin:" + p.config.Name
+}
+
+// Channels returns the redis channels of the plugin
+func (p *Plugin) Channels() []string {
+	return p.config.Channels
+}
+
+// Analyze implements the Analyzer interface
+func (p *Plugin) Analyze(raw string) error {
+	blog.V(4).Infof("[datacollect][%s] received message: %s", p.Name(), raw)
+	if !gjson.Valid(raw) {
+		return fmt.Errorf("invalid json message: %s", raw)
+	}
+
+	records := []gjson.Result{gjson.Parse(raw)}
+	if p.config.Records != "" {
+		records = gjson.Get(raw, p.config.Records).Array()
+	}
+	for _, record := range records {
+		data, err := p.config.mapRecord(record)
+		if err != nil {
+			blog.Errorf("[datacollect][%s] map record failed: %v, record: %s", p.Name(), err, record.Raw)
+			continue
+		}
+		if err = p.handleRecord(data); err != nil {
+			blog.Errorf("[datacollect][%s] handle record failed: %v, record: %s", p.Name(), err, record.Raw)
+		}
+	}
+	return nil
+}
+
+func (p *Plugin) handleRecord(data mapstr.MapStr) error {
+	msg, err := p.config.discoverMessage(data)
+	if err != nil {
+		return fmt.Errorf("build message error: %v", err)
+	}
+	if err = p.discover.TryCreateModel(msg); err != nil {
+		return fmt.Errorf("create model err: %v", err)
+	}
+	if err = p.discover.UpdateOrAppendAttrs(msg); err != nil {
+		return fmt.Errorf("create property err: %v", err)
+	}
+
+	if p.config.UpdatePolicy == UpdatePolicyAuto {
+		if err = p.discover.UpdateOrCreateInst(msg); err != nil {
+			return fmt.Errorf("create inst err: %v", err)
+		}
+		return nil
+	}
+	return p.reportChanges(data)
+}
+
+// reportChanges saves the changes of the instance as a report, which is applied by ConfirmReport
+func (p *Plugin) reportChanges(data mapstr.MapStr) error {
+	instKey, err := data.String(common.BKInstKeyField)
+	if err != nil {
+		return err
+	}
+
+	inst, err := p.discover.FindInst(p.config.OwnerID, p.config.Model.ObjectID, instKey)
+	if err != nil {
+		return fmt.Errorf("find inst error: %v", err)
+	}
+	return p.discover.SaveReport(p.config.OwnerID, p.config.Model.ObjectID, instKey, inst, data)
+}
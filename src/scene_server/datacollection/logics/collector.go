@@ -124,6 +124,9 @@ func (lgc *Logics) SearchCollector(header http.Header, cond metadata.ParamNetcol
 			blog.Warnf("[NetDevice][SearchCollector] get collector config for %s failed", key)
 		}
 		collector.Config = existsOne.Config
+		if "" != collector.Config.Community {
+			collector.Config.Community = metadata.NetcollectCredentialMask
+		}
 		collector.ReportTotal = existsOne.ReportTotal
 		collector.TaskID = existsOne.TaskID
 		collector.DeployTime = existsOne.DeployTime
@@ -198,16 +201,38 @@ func (lgc *Logics) findCollectorMap(cond interface{}) (map[string]metadata.Netco
 }
 
 func (lgc *Logics) UpdateCollector(header http.Header, config metadata.Netcollector) error {
+	if err := lgc.checkNetCredentialExist(header, config.Config.CredentialID); err != nil {
+		return err
+	}
+
 	cond := condition.CreateCondition()
 	cond.Field(common.BKCloudIDField).Eq(config.CloudID)
 	cond.Field(common.BKHostInnerIPField).Eq(config.InnerIP)
 
-	count, err := lgc.Instance.Table(common.BKTableNameNetcollectConfig).Find(cond.ToMapStr()).Count(lgc.ctx)
+	existing := make([]metadata.Netcollector, 0)
+	err := lgc.Instance.Table(common.BKTableNameNetcollectConfig).Find(cond.ToMapStr()).All(lgc.ctx, &existing)
 	if err != nil {
-		blog.Errorf("[UpdateCollector] count by %+v error: %v", cond.ToMapStr(), err)
+		blog.Errorf("[UpdateCollector] find by %+v error: %v", cond.ToMapStr(), err)
 		return err
 	}
-	if count > 0 {
+	// the community is masked by SearchCollector, keep it unless a new one is set
+	if config.Config.Community == metadata.NetcollectCredentialMask {
+		config.Config.Community = ""
+		if len(existing) > 0 {
+			config.Config.Community = existing[0].Config.Community
+		}
+	} else if "" != config.Config.Community {
+		if "" == lgc.credentialKey {
+			blog.Errorf("[UpdateCollector] update collector %s fail, the credential key to encrypt the community is not configured", config.InnerIP)
+			return lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header)).Error(common.CCErrCollectNetCredentialKeyNotSet)
+		}
+		config.Config.Community, err = encryptCredentialSecret(lgc.credentialKey, config.Config.Community)
+		if err != nil {
+			blog.Errorf("[UpdateCollector] encrypt the community of collector %s error: %v", config.InnerIP, err)
+			return err
+		}
+	}
+	if len(existing) > 0 {
 		err = lgc.Instance.Table(common.BKTableNameNetcollectConfig).Update(lgc.ctx, cond, config)
 		if err != nil {
 			blog.Errorf("[UpdateCollector] UpdateByCondition by %+v to %+v error: %v", cond.ToMapStr(), config, err)
@@ -357,6 +382,61 @@ func (lgc *Logics) buildNetdevicebeatConfigFile(collector *metadata.Netcollector
 		blog.Errorf("[NetDevice][buildNetdevicebeatConfigFile] findCustom for %+v failed: %v", collector, err)
 		return []byte(""), err
 	}
+	devices, err := lgc.findCredentialDevices()
+	if err != nil {
+		blog.Errorf("[NetDevice][buildNetdevicebeatConfigFile] findCredentialDevices for %+v failed: %v", collector, err)
+		return []byte(""), err
+	}
+
+	credentialIDs := []uint64{}
+	if 0 != collector.Config.CredentialID {
+		credentialIDs = append(credentialIDs, collector.Config.CredentialID)
+	}
+	for _, device := range devices {
+		credentialIDs = append(credentialIDs, device.CredentialID)
+	}
+	credentials, err := lgc.findNetCredentialMap(credentialIDs)
+	if err != nil {
+		blog.Errorf("[NetDevice][buildNetdevicebeatConfigFile] findNetCredentialMap for %+v failed: %v", collector, err)
+		return []byte(""), err
+	}
+
+	community, err := lgc.decryptCollectorCommunity(collector.Config.Community)
+	if err != nil {
+		blog.Errorf("[NetDevice][buildNetdevicebeatConfigFile] decrypt the community of collector %s failed: %v", collector.InnerIP, err)
+		return []byte(""), err
+	}
+	snmp := SnmpConfig{
+		Port:      161,
+		Community: community,
+		Version:   Version2c,
+		Timeout:   10,
+		Retries:   3,
+		MaxOids:   10,
+	}
+	if 0 != collector.Config.CredentialID {
+		credential, ok := credentials[collector.Config.CredentialID]
+		if !ok {
+			blog.Errorf("[NetDevice][buildNetdevicebeatConfigFile] credential %d of %+v not found", collector.Config.CredentialID, collector)
+			return []byte(""), fmt.Errorf("credential %d not found", collector.Config.CredentialID)
+		}
+		snmp = snmpConfigWithCredential(snmp, credential)
+	}
+
+	deviceSnmps := []DeviceSnmp{}
+	for _, device := range devices {
+		credential, ok := credentials[device.CredentialID]
+		if !ok {
+			blog.Warnf("[NetDevice][buildNetdevicebeatConfigFile] credential %d of device %s not found", device.CredentialID, device.DeviceName)
+			continue
+		}
+		deviceSnmps = append(deviceSnmps, DeviceSnmp{
+			DeviceModel: device.DeviceModel,
+			ObjectID:    device.ObjectID,
+			BkVendor:    device.BkVendor,
+			Snmp:        snmpConfigWithCredential(snmp, credential),
+		})
+	}
 
 	config := NetDeviceConfig{
 		DataID:      1014,
@@ -366,15 +446,9 @@ func (lgc *Logics) buildNetdevicebeatConfigFile(collector *metadata.Netcollector
 		PingRetry:   3,
 		Worker:      10,
 		Period:      collector.Config.Period,
-		Snmp: SnmpConfig{
-			Port:      161,
-			Community: collector.Config.Community,
-			Version:   Version2c,
-			Timeout:   10,
-			Retries:   3,
-			MaxOids:   10,
-		},
-		Customs: customs,
+		Snmp:        snmp,
+		Devices:     deviceSnmps,
+		Customs:     customs,
 		Report: Report{
 			Debug: true,
 		},
@@ -396,6 +470,89 @@ func (lgc *Logics) buildNetdevicebeatConfigFile(collector *metadata.Netcollector
 	return yaml.Marshal(&configContent)
 }
 
+// decryptCollectorCommunity decrypt the community of the collector, the community is kept in
+// plaintext only if the credential key is not configured, see EncryptCollectorCommunities.
+func (lgc *Logics) decryptCollectorCommunity(community string) (string, error) {
+	if "" == lgc.credentialKey {
+		return community, nil
+	}
+	return decryptCredentialSecret(lgc.credentialKey, community)
+}
+
+// EncryptCollectorCommunities encrypt the plaintext communities of the collectors, which were
+// saved before the communities are encrypted, it's called when the server starts.
+func (lgc *Logics) EncryptCollectorCommunities() error {
+	cond := mapstr.MapStr{"config.community": mapstr.MapStr{common.BKDBNE: ""}}
+	collectors := make([]metadata.Netcollector, 0)
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectConfig).Find(cond).All(lgc.ctx, &collectors); err != nil {
+		blog.Errorf("[NetDevice][EncryptCollectorCommunities] find collectors by %+v failed: %v", cond, err)
+		return err
+	}
+	if 0 == len(collectors) {
+		return nil
+	}
+	if "" == lgc.credentialKey {
+		blog.Warnf("[NetDevice][EncryptCollectorCommunities] the credential key is not configured, the communities of %d collectors are kept in plaintext", len(collectors))
+		return nil
+	}
+
+	for _, collector := range collectors {
+		// the community which can be decrypted is encrypted already
+		if _, err := decryptCredentialSecret(lgc.credentialKey, collector.Config.Community); nil == err {
+			continue
+		}
+		encrypted, err := encryptCredentialSecret(lgc.credentialKey, collector.Config.Community)
+		if err != nil {
+			blog.Errorf("[NetDevice][EncryptCollectorCommunities] encrypt the community of collector %s failed: %v", collector.InnerIP, err)
+			return err
+		}
+		updateCond := mapstr.MapStr{
+			common.BKCloudIDField:     collector.CloudID,
+			common.BKHostInnerIPField: collector.InnerIP,
+			"config.community":        collector.Config.Community,
+		}
+		doc := mapstr.MapStr{"config.community": encrypted}
+		if err := lgc.Instance.Table(common.BKTableNameNetcollectConfig).Update(lgc.ctx, updateCond, doc); err != nil {
+			blog.Errorf("[NetDevice][EncryptCollectorCommunities] update the community of collector %s failed: %v", collector.InnerIP, err)
+			return err
+		}
+	}
+	blog.Infof("[NetDevice][EncryptCollectorCommunities] the communities of the collectors are encrypted")
+	return nil
+}
+
+// findCredentialDevices get the devices which are collected with their own credentials
+func (lgc *Logics) findCredentialDevices() ([]metadata.NetcollectDevice, error) {
+	devices := []metadata.NetcollectDevice{}
+	cond := mapstr.MapStr{netCredentialIDField: mapstr.MapStr{common.BKDBGT: 0}}
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectDevice).Find(cond).All(lgc.ctx, &devices); err != nil {
+		blog.Errorf("[NetDevice] failed to query the devices with credential, error info %v", err)
+		return nil, err
+	}
+	return devices, nil
+}
+
+// snmpConfigWithCredential use the version and the secrets of the credential in the snmp config
+func snmpConfigWithCredential(snmp SnmpConfig, credential metadata.NetcollectCredential) SnmpConfig {
+	if metadata.SnmpVersion3 == credential.Version {
+		snmp.Version = Version3
+		snmp.Community = ""
+		snmp.SecurityName = credential.SecurityName
+		snmp.SecurityLevel = credential.SecurityLevel
+		snmp.AuthProtocol = credential.AuthProtocol
+		snmp.AuthPassphrase = credential.AuthKey
+		snmp.PrivProtocol = credential.PrivProtocol
+		snmp.PrivPassphrase = credential.PrivKey
+		return snmp
+	}
+	snmp.Version = Version2c
+	snmp.Community = credential.Community
+	snmp.SecurityName, snmp.SecurityLevel = "", ""
+	snmp.AuthProtocol, snmp.AuthPassphrase = "", ""
+	snmp.PrivProtocol, snmp.PrivPassphrase = "", ""
+	return snmp
+}
+
 func (lgc *Logics) findCustom() ([]Custom, error) {
 	customs := []Custom{}
 	propertys := []metadata.NetcollectProperty{}
@@ -441,8 +598,18 @@ type NetDeviceConfig struct {
 	PingRetry   int        `yaml:"ping_retry,omitempty"`
 	Worker      int        `yaml:"worker,omitempty"`
 	Period      string     `yaml:"period,omitempty"`
-	Customs     []Custom   `yaml:"customs,omitempty"`
-	Report      Report     `yaml:"report,omitempty"`
+	// Devices the snmp configs of the devices collected with their own credentials
+	Devices []DeviceSnmp `yaml:"devices,omitempty"`
+	Customs []Custom     `yaml:"customs,omitempty"`
+	Report  Report       `yaml:"report,omitempty"`
+}
+
+// DeviceSnmp the snmp config used to collect the devices of the model
+type DeviceSnmp struct {
+	DeviceModel string     `yaml:"device_model,omitempty"`
+	ObjectID    string     `yaml:"bk_obj_id,omitempty"`
+	BkVendor    string     `yaml:"bk_vendor,omitempty"`
+	Snmp        SnmpConfig `yaml:"snmp,omitempty"`
 }
 
 type Report struct {
@@ -470,6 +637,18 @@ type SnmpConfig struct {
 	Community string `yaml:"community,omitempty"`
 	// Version is an SNMP Version
 	Version Version `yaml:"version,omitempty"`
+	// SecurityName is the SNMPv3 user name
+	SecurityName string `yaml:"security_name,omitempty"`
+	// SecurityLevel is the SNMPv3 security level: noAuthNoPriv, authNoPriv or authPriv
+	SecurityLevel string `yaml:"security_level,omitempty"`
+	// AuthProtocol is the SNMPv3 authentication protocol: MD5 or SHA
+	AuthProtocol string `yaml:"auth_protocol,omitempty"`
+	// AuthPassphrase is the SNMPv3 authentication passphrase
+	AuthPassphrase string `yaml:"auth_passphrase,omitempty"`
+	// PrivProtocol is the SNMPv3 privacy protocol: DES or AES
+	PrivProtocol string `yaml:"priv_protocol,omitempty"`
+	// PrivPassphrase is the SNMPv3 privacy passphrase
+	PrivPassphrase string `yaml:"priv_passphrase,omitempty"`
 	// Timeout is the timeout for the SNMP Query
 	Timeout int `yaml:"timeout,omitempty"`
 	// Set the number of retries to attempt within timeout.
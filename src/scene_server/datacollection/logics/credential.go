@@ -0,0 +1,399 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+const (
+	netCredentialIDField   = "credential_id"
+	netCredentialNameField = "name"
+	// the shortest snmp v3 auth and priv passphrase allowed by the snmp agents
+	snmpMinKeyLength = 8
+)
+
+func newCredentialCipher(key string) (cipher.AEAD, error) {
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if nil != err {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptCredentialSecret encrypt the secret with aes-gcm, the nonce is prepended to the sealed data
+func encryptCredentialSecret(key, secret string) (string, error) {
+	if "" == secret {
+		return "", nil
+	}
+	aead, err := newCredentialCipher(key)
+	if nil != err {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); nil != err {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func decryptCredentialSecret(key, secret string) (string, error) {
+	if "" == secret {
+		return "", nil
+	}
+	data, err := base64.StdEncoding.DecodeString(secret)
+	if nil != err {
+		return "", err
+	}
+	aead, err := newCredentialCipher(key)
+	if nil != err {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("the encrypted secret is too short")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if nil != err {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func credentialSecrets(credential *meta.NetcollectCredential) []*string {
+	return []*string{&credential.Community, &credential.AuthKey, &credential.PrivKey}
+}
+
+func encryptNetCredential(key string, credential *meta.NetcollectCredential) error {
+	for _, secret := range credentialSecrets(credential) {
+		encrypted, err := encryptCredentialSecret(key, *secret)
+		if nil != err {
+			return err
+		}
+		*secret = encrypted
+	}
+	return nil
+}
+
+func decryptNetCredential(key string, credential *meta.NetcollectCredential) error {
+	for _, secret := range credentialSecrets(credential) {
+		plain, err := decryptCredentialSecret(key, *secret)
+		if nil != err {
+			return fmt.Errorf("decrypt credential %d failed, %v", credential.CredentialID, err)
+		}
+		*secret = plain
+	}
+	return nil
+}
+
+// maskNetCredential hide the secrets of the credential before it's returned
+func maskNetCredential(credential *meta.NetcollectCredential) {
+	for _, secret := range credentialSecrets(credential) {
+		if "" != *secret {
+			*secret = meta.NetcollectCredentialMask
+		}
+	}
+}
+
+// restoreNetCredential keep the secrets which are still masked in the update request
+func restoreNetCredential(credential, existing *meta.NetcollectCredential) {
+	secrets, existingSecrets := credentialSecrets(credential), credentialSecrets(existing)
+	for idx, secret := range secrets {
+		if meta.NetcollectCredentialMask == *secret {
+			*secret = *existingSecrets[idx]
+		}
+	}
+}
+
+// checkNetCredential check the credential by the snmp version and the security level,
+// the fields not used by the security level are cleared
+func checkNetCredential(credential *meta.NetcollectCredential) error {
+	credential.Name = strings.TrimSpace(credential.Name)
+	if "" == credential.Name {
+		return errors.New("name is not set")
+	}
+
+	switch credential.Version {
+	case meta.SnmpVersion2c:
+		if "" == credential.Community {
+			return errors.New("community is not set")
+		}
+		credential.SecurityName, credential.SecurityLevel = "", ""
+		credential.AuthProtocol, credential.AuthKey = "", ""
+		credential.PrivProtocol, credential.PrivKey = "", ""
+		return nil
+	case meta.SnmpVersion3:
+		credential.Community = ""
+	default:
+		return fmt.Errorf("snmp version %s is not supported", credential.Version)
+	}
+
+	if "" == credential.SecurityName {
+		return errors.New("security name is not set")
+	}
+	switch credential.SecurityLevel {
+	case meta.SnmpSecurityLevelNoAuthNoPriv:
+		credential.AuthProtocol, credential.AuthKey = "", ""
+		credential.PrivProtocol, credential.PrivKey = "", ""
+		return nil
+	case meta.SnmpSecurityLevelAuthNoPriv, meta.SnmpSecurityLevelAuthPriv:
+	default:
+		return fmt.Errorf("security level %s is not supported", credential.SecurityLevel)
+	}
+
+	if meta.SnmpAuthProtocolMD5 != credential.AuthProtocol && meta.SnmpAuthProtocolSHA != credential.AuthProtocol {
+		return fmt.Errorf("auth protocol %s is not supported", credential.AuthProtocol)
+	}
+	if len(credential.AuthKey) < snmpMinKeyLength {
+		return fmt.Errorf("auth key should be at least %d characters", snmpMinKeyLength)
+	}
+	if meta.SnmpSecurityLevelAuthNoPriv == credential.SecurityLevel {
+		credential.PrivProtocol, credential.PrivKey = "", ""
+		return nil
+	}
+
+	if meta.SnmpPrivProtocolDES != credential.PrivProtocol && meta.SnmpPrivProtocolAES != credential.PrivProtocol {
+		return fmt.Errorf("priv protocol %s is not supported", credential.PrivProtocol)
+	}
+	if len(credential.PrivKey) < snmpMinKeyLength {
+		return fmt.Errorf("priv key should be at least %d characters", snmpMinKeyLength)
+	}
+	return nil
+}
+
+// AddCredential create a named set of snmp credentials, the secrets are stored encrypted
+func (lgc *Logics) AddCredential(pheader http.Header, credential meta.NetcollectCredential) (meta.AddNetCredentialResult, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	if "" == lgc.credentialKey {
+		blog.Errorf("[NetCredential] add credential fail, the credential key is not configured")
+		return meta.AddNetCredentialResult{}, defErr.Error(common.CCErrCollectNetCredentialKeyNotSet)
+	}
+	if err := checkNetCredential(&credential); nil != err {
+		blog.Errorf("[NetCredential] add credential fail, credential [%s] is invalid: %v", credential.Name, err)
+		return meta.AddNetCredentialResult{}, defErr.Errorf(common.CCErrCollectNetCredentialInvalid, err.Error())
+	}
+	if err := lgc.checkNetCredentialName(pheader, credential.Name, 0); nil != err {
+		return meta.AddNetCredentialResult{}, err
+	}
+	if err := encryptNetCredential(lgc.credentialKey, &credential); nil != err {
+		blog.Errorf("[NetCredential] add credential fail, encrypt credential [%s] error: %v", credential.Name, err)
+		return meta.AddNetCredentialResult{}, defErr.Error(common.CCErrCommDBInsertFailed)
+	}
+
+	now := util.GetCurrentTimePtr()
+	credential.CreateTime = now
+	credential.LastTime = now
+	credential.OwnerID = ownerID
+
+	credentialID, err := lgc.Instance.NextSequence(lgc.ctx, common.BKTableNameNetcollectCredential)
+	if nil != err {
+		blog.Errorf("[NetCredential] add credential fail, get id error: %v", err)
+		return meta.AddNetCredentialResult{}, defErr.Error(common.CCErrCommDBInsertFailed)
+	}
+	credential.CredentialID = credentialID
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectCredential).Insert(lgc.ctx, credential); nil != err {
+		blog.Errorf("[NetCredential] add credential [%s] fail, error: %v", credential.Name, err)
+		return meta.AddNetCredentialResult{}, defErr.Error(common.CCErrCommDBInsertFailed)
+	}
+
+	return meta.AddNetCredentialResult{CredentialID: credentialID}, nil
+}
+
+// UpdateCredential update the credential, the secrets equal to the mask are not changed
+func (lgc *Logics) UpdateCredential(pheader http.Header, credentialID uint64, credential meta.NetcollectCredential) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	if "" == lgc.credentialKey {
+		blog.Errorf("[NetCredential] update credential fail, the credential key is not configured")
+		return defErr.Error(common.CCErrCollectNetCredentialKeyNotSet)
+	}
+	existing, err := lgc.getNetCredential(pheader, credentialID)
+	if nil != err {
+		return err
+	}
+	restoreNetCredential(&credential, existing)
+	if err := checkNetCredential(&credential); nil != err {
+		blog.Errorf("[NetCredential] update credential fail, credential [%d] is invalid: %v", credentialID, err)
+		return defErr.Errorf(common.CCErrCollectNetCredentialInvalid, err.Error())
+	}
+	if err := lgc.checkNetCredentialName(pheader, credential.Name, credentialID); nil != err {
+		return err
+	}
+	if err := encryptNetCredential(lgc.credentialKey, &credential); nil != err {
+		blog.Errorf("[NetCredential] update credential fail, encrypt credential [%d] error: %v", credentialID, err)
+		return defErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+
+	credential.CredentialID = credentialID
+	credential.OwnerID = ownerID
+	credential.CreateTime = existing.CreateTime
+	credential.LastTime = util.GetCurrentTimePtr()
+	cond := mapstr.MapStr{netCredentialIDField: credentialID, common.BKOwnerIDField: ownerID}
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectCredential).Update(lgc.ctx, cond, credential); nil != err {
+		blog.Errorf("[NetCredential] update credential [%d] fail, error: %v", credentialID, err)
+		return defErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+
+	return nil
+}
+
+// SearchCredential get the credentials, the secrets are masked
+func (lgc *Logics) SearchCredential(pheader http.Header) (meta.SearchNetCredential, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := mapstr.MapStr{common.BKOwnerIDField: util.GetOwnerID(pheader)}
+	credentials := make([]meta.NetcollectCredential, 0)
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectCredential).Find(cond).Sort(netCredentialIDField).All(lgc.ctx, &credentials); nil != err {
+		blog.Errorf("[NetCredential] search credential fail, error: %v, condition: %#v", err, cond)
+		return meta.SearchNetCredential{}, defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	for idx := range credentials {
+		maskNetCredential(&credentials[idx])
+	}
+
+	return meta.SearchNetCredential{Count: uint64(len(credentials)), Info: credentials}, nil
+}
+
+// DeleteCredential delete the credential which is not used by any collector or device
+func (lgc *Logics) DeleteCredential(pheader http.Header, credentialID uint64) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	if err := lgc.checkNetCredentialExist(pheader, credentialID); nil != err {
+		return err
+	}
+
+	usages := map[string]mapstr.MapStr{
+		common.BKTableNameNetcollectConfig: mapstr.MapStr{"config." + netCredentialIDField: credentialID},
+		common.BKTableNameNetcollectDevice: mapstr.MapStr{netCredentialIDField: credentialID, common.BKOwnerIDField: ownerID},
+	}
+	for table, cond := range usages {
+		count, err := lgc.Instance.Table(table).Find(cond).Count(lgc.ctx)
+		if nil != err {
+			blog.Errorf("[NetCredential] delete credential fail, count %s by condition %#v error: %v", table, cond, err)
+			return defErr.Error(common.CCErrCommDBSelectFailed)
+		}
+		if 0 != count {
+			blog.Errorf("[NetCredential] delete credential fail, credential [%d] is used by %d items of %s", credentialID, count, table)
+			return defErr.Error(common.CCErrCollectNetCredentialInUse)
+		}
+	}
+
+	cond := mapstr.MapStr{netCredentialIDField: credentialID, common.BKOwnerIDField: ownerID}
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectCredential).Delete(lgc.ctx, cond); nil != err {
+		blog.Errorf("[NetCredential] delete credential [%d] fail, error: %v", credentialID, err)
+		return defErr.Error(common.CCErrCommDBDeleteFailed)
+	}
+
+	return nil
+}
+
+func (lgc *Logics) checkNetCredentialName(pheader http.Header, name string, credentialID uint64) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := mapstr.MapStr{netCredentialNameField: name, common.BKOwnerIDField: util.GetOwnerID(pheader)}
+	if 0 != credentialID {
+		cond[netCredentialIDField] = mapstr.MapStr{common.BKDBNE: credentialID}
+	}
+	count, err := lgc.Instance.Table(common.BKTableNameNetcollectCredential).Find(cond).Count(lgc.ctx)
+	if nil != err {
+		blog.Errorf("[NetCredential] check credential name fail, error: %v, condition: %#v", err, cond)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if 0 != count {
+		blog.Errorf("[NetCredential] check credential name fail, duplicate credential name [%s]", name)
+		return defErr.Errorf(common.CCErrCommDuplicateItem, "credential")
+	}
+	return nil
+}
+
+// checkNetCredentialExist check the credential referenced by a collector or a device exists, 0 means no credential
+func (lgc *Logics) checkNetCredentialExist(pheader http.Header, credentialID uint64) error {
+	if 0 == credentialID {
+		return nil
+	}
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := mapstr.MapStr{netCredentialIDField: credentialID, common.BKOwnerIDField: util.GetOwnerID(pheader)}
+	count, err := lgc.Instance.Table(common.BKTableNameNetcollectCredential).Find(cond).Count(lgc.ctx)
+	if nil != err {
+		blog.Errorf("[NetCredential] check credential exist fail, error: %v, condition: %#v", err, cond)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if 0 == count {
+		blog.Errorf("[NetCredential] credential [%d] does not exist", credentialID)
+		return defErr.Error(common.CCErrCollectNetCredentialNotExist)
+	}
+	return nil
+}
+
+// getNetCredential get the credential with the secrets decrypted
+func (lgc *Logics) getNetCredential(pheader http.Header, credentialID uint64) (*meta.NetcollectCredential, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := mapstr.MapStr{netCredentialIDField: credentialID, common.BKOwnerIDField: util.GetOwnerID(pheader)}
+	credential := new(meta.NetcollectCredential)
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectCredential).Find(cond).One(lgc.ctx, credential); nil != err {
+		blog.Errorf("[NetCredential] get credential fail, error: %v, condition: %#v", err, cond)
+		if lgc.Instance.IsNotFoundError(err) {
+			return nil, defErr.Error(common.CCErrCollectNetCredentialNotExist)
+		}
+		return nil, defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if err := decryptNetCredential(lgc.credentialKey, credential); nil != err {
+		blog.Errorf("[NetCredential] get credential fail, %v", err)
+		return nil, defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	return credential, nil
+}
+
+// findNetCredentialMap get the credentials with the secrets decrypted to build the collector config
+func (lgc *Logics) findNetCredentialMap(credentialIDs []uint64) (map[uint64]meta.NetcollectCredential, error) {
+	credentialMap := map[uint64]meta.NetcollectCredential{}
+	if 0 == len(credentialIDs) {
+		return credentialMap, nil
+	}
+
+	cond := mapstr.MapStr{netCredentialIDField: mapstr.MapStr{common.BKDBIN: credentialIDs}}
+	credentials := make([]meta.NetcollectCredential, 0)
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectCredential).Find(cond).All(lgc.ctx, &credentials); nil != err {
+		blog.Errorf("[NetCredential] find credentials fail, error: %v, condition: %#v", err, cond)
+		return nil, err
+	}
+	for idx := range credentials {
+		if err := decryptNetCredential(lgc.credentialKey, &credentials[idx]); nil != err {
+			blog.Errorf("[NetCredential] find credentials fail, %v", err)
+			return nil, err
+		}
+		credentialMap[credentials[idx].CredentialID] = credentials[idx]
+	}
+	return credentialMap, nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"testing"
+
+	meta "configcenter/src/common/metadata"
+)
+
+func TestCredentialSecret(t *testing.T) {
+	encrypted, err := encryptCredentialSecret("key", "public")
+	if err != nil {
+		t.Fatalf("encrypt secret failed, err: %v", err)
+	}
+	if "public" == encrypted {
+		t.Fatalf("the secret is not encrypted")
+	}
+	plain, err := decryptCredentialSecret("key", encrypted)
+	if err != nil || "public" != plain {
+		t.Errorf("expect public, got %s, err: %v", plain, err)
+	}
+	if _, err := decryptCredentialSecret("other", encrypted); err == nil {
+		t.Errorf("decrypt with a wrong key should fail")
+	}
+	if encrypted, err := encryptCredentialSecret("key", ""); err != nil || "" != encrypted {
+		t.Errorf("empty secret should be kept empty, got %s, err: %v", encrypted, err)
+	}
+}
+
+func TestCheckNetCredential(t *testing.T) {
+	credential := &meta.NetcollectCredential{
+		Name:          "core switch",
+		Version:       meta.SnmpVersion3,
+		Community:     "public",
+		SecurityName:  "cmdb",
+		SecurityLevel: meta.SnmpSecurityLevelAuthNoPriv,
+		AuthProtocol:  meta.SnmpAuthProtocolSHA,
+		AuthKey:       "authpassword",
+		PrivProtocol:  meta.SnmpPrivProtocolAES,
+		PrivKey:       "privpassword",
+	}
+	if err := checkNetCredential(credential); err != nil {
+		t.Fatalf("check credential failed, err: %v", err)
+	}
+	if "" != credential.Community || "" != credential.PrivKey || "" != credential.PrivProtocol {
+		t.Errorf("the fields not used should be cleared, got %+v", credential)
+	}
+
+	invalids := []meta.NetcollectCredential{
+		{Name: "", Version: meta.SnmpVersion2c, Community: "public"},
+		{Name: "a", Version: "v1", Community: "public"},
+		{Name: "a", Version: meta.SnmpVersion2c},
+		{Name: "a", Version: meta.SnmpVersion3, SecurityLevel: meta.SnmpSecurityLevelNoAuthNoPriv},
+		{Name: "a", Version: meta.SnmpVersion3, SecurityName: "u", SecurityLevel: "auth"},
+		{Name: "a", Version: meta.SnmpVersion3, SecurityName: "u", SecurityLevel: meta.SnmpSecurityLevelAuthNoPriv, AuthProtocol: "SHA256", AuthKey: "authpassword"},
+		{Name: "a", Version: meta.SnmpVersion3, SecurityName: "u", SecurityLevel: meta.SnmpSecurityLevelAuthNoPriv, AuthProtocol: meta.SnmpAuthProtocolMD5, AuthKey: "short"},
+		{Name: "a", Version: meta.SnmpVersion3, SecurityName: "u", SecurityLevel: meta.SnmpSecurityLevelAuthPriv, AuthProtocol: meta.SnmpAuthProtocolMD5, AuthKey: "authpassword", PrivProtocol: "3DES", PrivKey: "privpassword"},
+		{Name: "a", Version: meta.SnmpVersion3, SecurityName: "u", SecurityLevel: meta.SnmpSecurityLevelAuthPriv, AuthProtocol: meta.SnmpAuthProtocolMD5, AuthKey: "authpassword", PrivProtocol: meta.SnmpPrivProtocolDES},
+	}
+	for idx := range invalids {
+		if err := checkNetCredential(&invalids[idx]); err == nil {
+			t.Errorf("credential %+v should be invalid", invalids[idx])
+		}
+	}
+}
+
+func TestMaskNetCredential(t *testing.T) {
+	existing := meta.NetcollectCredential{AuthKey: "authpassword", PrivKey: "privpassword"}
+	credential := existing
+	maskNetCredential(&credential)
+	if meta.NetcollectCredentialMask != credential.AuthKey || meta.NetcollectCredentialMask != credential.PrivKey || "" != credential.Community {
+		t.Fatalf("the secrets should be masked, got %+v", credential)
+	}
+	credential.PrivKey = "newpassword"
+	restoreNetCredential(&credential, &existing)
+	if "authpassword" != credential.AuthKey || "newpassword" != credential.PrivKey {
+		t.Errorf("the masked secrets should be restored, got %+v", credential)
+	}
+}
+
+func TestSnmpConfigWithCredential(t *testing.T) {
+	base := SnmpConfig{Port: 161, Community: "public", Version: Version2c}
+	snmp := snmpConfigWithCredential(base, meta.NetcollectCredential{
+		Version:       meta.SnmpVersion3,
+		SecurityName:  "cmdb",
+		SecurityLevel: meta.SnmpSecurityLevelAuthPriv,
+		AuthProtocol:  meta.SnmpAuthProtocolSHA,
+		AuthKey:       "authpassword",
+		PrivProtocol:  meta.SnmpPrivProtocolAES,
+		PrivKey:       "privpassword",
+	})
+	if Version3 != snmp.Version || "" != snmp.Community || "cmdb" != snmp.SecurityName || "privpassword" != snmp.PrivPassphrase || 161 != snmp.Port {
+		t.Errorf("unexpected v3 snmp config %+v", snmp)
+	}
+	snmp = snmpConfigWithCredential(snmp, meta.NetcollectCredential{Version: meta.SnmpVersion2c, Community: "private"})
+	if Version2c != snmp.Version || "private" != snmp.Community || "" != snmp.SecurityName || "" != snmp.AuthPassphrase {
+		t.Errorf("unexpected v2c snmp config %+v", snmp)
+	}
+}
+
+func TestDecryptCollectorCommunity(t *testing.T) {
+	encrypted, err := encryptCredentialSecret("secret", "public")
+	if nil != err {
+		t.Fatalf("encrypt community failed: %v", err)
+	}
+	lgc := &Logics{credentialKey: "secret"}
+	if community, err := lgc.decryptCollectorCommunity(encrypted); nil != err || "public" != community {
+		t.Errorf("the encrypted community should be decrypted, got %s, %v", community, err)
+	}
+	if _, err := lgc.decryptCollectorCommunity("public"); nil == err {
+		t.Errorf("the plaintext community should not be decrypted")
+	}
+	lgc = &Logics{}
+	if community, err := lgc.decryptCollectorCommunity("public"); nil != err || "public" != community {
+		t.Errorf("the community should be kept in plaintext without the key, got %s, %v", community, err)
+	}
+}
@@ -311,6 +311,12 @@ func (lgc *Logics) checkNetDevice(
 		return false, err
 	}
 
+	// check if the credential used to collect the device exists
+	if err := lgc.checkNetCredentialExist(pheader, deviceInfo.CredentialID); nil != err {
+		blog.Errorf("[NetDevice] check net device fail, credential [%d] is invalid: %v", deviceInfo.CredentialID, err)
+		return false, err
+	}
+
 	// check if device_name exist
 	isExist, err = lgc.checkIfNetDeviceNameExist(deviceInfo.DeviceName, ownerID)
 	if nil != err {
@@ -0,0 +1,136 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// UpdateDiscoverPolicy creates or updates the discover policy of the model
+func (lgc *Logics) UpdateDiscoverPolicy(pheader http.Header, policy metadata.DiscoverPolicy) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	if policy.ObjectID == "" {
+		blog.Errorf("[DiscoverPolicy] update policy fail, bk_obj_id not set")
+		return defErr.Errorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)
+	}
+	objects, err := lgc.findObjectIn(pheader, policy.ObjectID)
+	if err != nil {
+		blog.Errorf("[DiscoverPolicy] update policy fail, find object %s error: %v", policy.ObjectID, err)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(objects) <= 0 {
+		blog.Errorf("[DiscoverPolicy] update policy fail, object %s not exist", policy.ObjectID)
+		return defErr.Errorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+	if policy.ConfirmFields == nil {
+		policy.ConfirmFields = []string{}
+	}
+
+	policy.OwnerID = util.GetOwnerID(pheader)
+	policy.LastTime = metadata.Now()
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(policy.OwnerID)
+	cond.Field(common.BKObjIDField).Eq(policy.ObjectID)
+
+	count, err := lgc.Instance.Table(common.BKTableNameDiscoverPolicy).Find(cond.ToMapStr()).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[DiscoverPolicy] update policy fail, count by %+v error: %v", cond.ToMapStr(), err)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if count <= 0 {
+		if err := lgc.Instance.Table(common.BKTableNameDiscoverPolicy).Insert(lgc.ctx, policy); err != nil {
+			blog.Errorf("[DiscoverPolicy] insert policy %+v fail, error: %v", policy, err)
+			return defErr.Error(common.CCErrCommDBInsertFailed)
+		}
+		return nil
+	}
+	if err := lgc.Instance.Table(common.BKTableNameDiscoverPolicy).Update(lgc.ctx, cond.ToMapStr(), policy); err != nil {
+		blog.Errorf("[DiscoverPolicy] update policy %+v fail, error: %v", policy, err)
+		return defErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// SearchDiscoverPolicy search the discover policies, the models without policy use the default one
+func (lgc *Logics) SearchDiscoverPolicy(pheader http.Header, param metadata.ParamSearchDiscoverPolicy) (metadata.RspDiscoverPolicy, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(util.GetOwnerID(pheader))
+	if param.ObjectID != "" {
+		cond.Field(common.BKObjIDField).Eq(param.ObjectID)
+	}
+	policies := make([]metadata.DiscoverPolicy, 0)
+	if err := lgc.Instance.Table(common.BKTableNameDiscoverPolicy).Find(cond.ToMapStr()).Sort(common.BKObjIDField).All(lgc.ctx, &policies); err != nil {
+		blog.Errorf("[DiscoverPolicy] search policy fail, error: %v, condition: %#v", err, cond.ToMapStr())
+		return metadata.RspDiscoverPolicy{}, defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	return metadata.RspDiscoverPolicy{Count: uint64(len(policies)), Info: policies}, nil
+}
+
+// SearchDiscoverReportSummary summarizes the pending discover reports by model
+func (lgc *Logics) SearchDiscoverReportSummary(pheader http.Header) ([]*metadata.DiscoverReportSummary, error) {
+	cond := condition.CreateCondition()
+	cond.Field("source").Eq(metadata.ReportSourceDiscover)
+	reports := make([]metadata.NetcollectReport, 0)
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectReport).Find(cond.ToMapStr()).All(lgc.ctx, &reports); err != nil {
+		blog.Errorf("[DiscoverReport][SearchDiscoverReportSummary] search reports by %+v failed: %v", cond.ToMapStr(), err)
+		return nil, err
+	}
+
+	objIDs := []string{}
+	summarym := map[string]*metadata.DiscoverReportSummary{}
+	for _, report := range reports {
+		summary, ok := summarym[report.ObjectID]
+		if !ok {
+			summary = &metadata.DiscoverReportSummary{
+				ObjectID:   report.ObjectID,
+				Statistics: map[string]int{},
+			}
+			summarym[report.ObjectID] = summary
+			objIDs = append(objIDs, report.ObjectID)
+		}
+
+		summary.Statistics[report.Action]++
+		summary.Statistics["attributes"] += len(report.Attributes)
+
+		if report.LastTime.Time.Sub(summary.LastTime.Time) > 0 {
+			summary.LastTime = report.LastTime
+		}
+	}
+
+	summarys := []*metadata.DiscoverReportSummary{}
+	if len(objIDs) <= 0 {
+		return summarys, nil
+	}
+	objMap, err := lgc.findObjectMap(pheader, objIDs...)
+	if err != nil {
+		blog.Errorf("[DiscoverReport][SearchDiscoverReportSummary] findObjectMap by %+v failed: %v", objIDs, err)
+		return nil, err
+	}
+	for _, objID := range objIDs {
+		if object, ok := objMap[objID]; ok {
+			summarym[objID].ObjectName = object.ObjectName
+		}
+		summarys = append(summarys, summarym[objID])
+	}
+	return summarys, nil
+}
@@ -26,8 +26,10 @@ type Logics struct {
 	Instance dal.RDB
 	ESB      esbserver.EsbClientInterface
 	ctx      context.Context
+	// credentialKey the key to encrypt the secrets of the net collect credentials
+	credentialKey string
 }
 
-func NewLogics(ctx context.Context, engine *backbone.Engine, instance dal.RDB, esb esbserver.EsbClientInterface) *Logics {
-	return &Logics{ctx: ctx, Instance: instance, Engine: engine, ESB: esb}
+func NewLogics(ctx context.Context, engine *backbone.Engine, instance dal.RDB, esb esbserver.EsbClientInterface, credentialKey string) *Logics {
+	return &Logics{ctx: ctx, Instance: instance, Engine: engine, ESB: esb, credentialKey: credentialKey}
 }
@@ -14,6 +14,7 @@ package logics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -95,6 +96,9 @@ func (lgc *Logics) buildSearchCond(header http.Header, param metadata.ParamSearc
 	if param.ObjectID != "" {
 		cond.Field(common.BKObjIDField).Eq(param.ObjectID)
 	}
+	if param.Source != "" {
+		cond.Field("source").Eq(param.Source)
+	}
 	if param.InnerIP != "" {
 		cond.Field(common.BKHostInnerIPField).Like(param.InnerIP)
 	}
@@ -182,12 +186,7 @@ func (lgc *Logics) SearchReport(header http.Header, param metadata.ParamSearchNe
 			reports[index].CloudName = cloudname
 		}
 
-		cond := condition.CreateCondition()
-		cond.Field(common.BKInstNameField).Eq(reports[index].InstKey)
-		objType := common.GetObjByType(reports[index].ObjectID)
-		if objType == common.BKInnerObjIDObject {
-			cond.Field(common.BKObjIDField).Eq(reports[index].ObjectID)
-		}
+		cond := reportInstCond(&reports[index])
 		insts, err := lgc.findInst(header, reports[index].ObjectID, &metadata.QueryCondition{Condition: cond.ToMapStr()})
 		if err != nil {
 			blog.Errorf("[NetDevice][SearchReport] find inst by %+v for %v failed %v", cond.ToMapStr(), reports[index].ObjectID, err)
@@ -371,6 +370,16 @@ func (lgc *Logics) ConfirmReport(header http.Header, reports []metadata.Netcolle
 			}
 			lgc.saveHistory(report, true)
 		}
+		deleteCount, errs := lgc.deleteStaleTopoAssociations(header, report)
+		result.ChangeAssociationsFailure += len(errs)
+		result.ChangeAssociationsSuccess += deleteCount
+		if len(errs) > 0 {
+			for _, err := range errs {
+				result.Errors = append(result.Errors, err.Error())
+			}
+			continue
+		}
+
 		cond := condition.CreateCondition()
 		cond.Field(common.BKObjIDField).Eq(report.ObjectID)
 		cond.Field(common.BKInstKeyField).Eq(report.InstKey)
@@ -400,16 +409,7 @@ func (lgc *Logics) confirmAttributes(header http.Header, report *metadata.Netcol
 
 	objType := common.GetObjByType(report.ObjectID)
 
-	cond := condition.CreateCondition()
-	if objType == common.BKInnerObjIDObject {
-		cond.Field(common.GetInstNameField(report.ObjectID)).Eq(report.InstKey)
-		cond.Field(common.BKObjIDField).Eq(report.ObjectID)
-	}
-
-	if objType == common.BKInnerObjIDHost {
-		cond.Field(common.BKCloudIDField).Eq(report.CloudID)
-		cond.Field(common.BKHostInnerIPField).Eq(report.InstKey)
-	}
+	cond := reportInstCond(report)
 	insts, err := lgc.findInst(header, report.ObjectID, &metadata.QueryCondition{Condition: cond.ToMapStr()})
 	if err != nil {
 		blog.Errorf("[NetDevice][ConfirmReport] find inst failed %v", err)
@@ -479,17 +479,7 @@ func (lgc *Logics) confirmAttributes(header http.Header, report *metadata.Netcol
 }
 
 func (lgc *Logics) confirmAssociations(header http.Header, report *metadata.NetcollectReport) (successCount int, errs []error) {
-	objType := common.GetObjByType(report.ObjectID)
-	cond := condition.CreateCondition()
-	if objType == common.BKInnerObjIDObject {
-		cond.Field(common.GetInstNameField(report.ObjectID)).Eq(report.InstKey)
-		cond.Field(common.BKObjIDField).Eq(report.ObjectID)
-	}
-	if objType == common.BKInnerObjIDHost {
-		cond.Field(common.BKCloudIDField).Eq(report.CloudID)
-		cond.Field(common.BKHostInnerIPField).Eq(report.InstKey)
-	}
-
+	cond := reportInstCond(report)
 	insts, err := lgc.findInst(header, report.ObjectID, &metadata.QueryCondition{Condition: cond.ToMapStr()})
 	if err != nil {
 		blog.Errorf("[NetDevice][ConfirmReport] find inst %+v failed %v", cond.ToMapStr(), err)
@@ -512,31 +502,12 @@ func (lgc *Logics) confirmAssociations(header http.Header, report *metadata.Netc
 	}
 
 	for _, asst := range report.Associations {
-		asstObjType := common.GetObjByType(asst.AsstObjectID)
-		asstCond := condition.CreateCondition()
-		if asstObjType == common.BKInnerObjIDObject {
-			asstCond.Field(common.GetInstNameField(asst.AsstObjectID)).Eq(asst.AsstInstName)
-			asstCond.Field(common.BKObjIDField).Eq(asst.AsstObjectID)
-		}
-		if asstObjType == common.BKInnerObjIDHost {
-			asstCond.Field(common.BKCloudIDField).Eq(report.CloudID)
-			asstCond.Field(common.BKHostInnerIPField).Eq(asst.AsstInstName)
-		}
-		asstInsts, err := lgc.findInst(header, asst.AsstObjectID, &metadata.QueryCondition{Condition: asstCond.ToMapStr()})
+		asstInstID, err := lgc.findAsstInstID(header, report, asst)
 		if err != nil {
-			blog.Errorf("[NetDevice][ConfirmReport] find inst by %+v failed %v", asstCond.ToMapStr(), err)
 			errs = append(errs, err)
 			continue
 		}
-		blog.V(4).Infof("[NetDevice][ConfirmReport] find inst result: %#v, condition: %#v", asstInsts, asstCond.ToMapStr())
-		if len(asstInsts) > 0 {
-			asstInstID, err := asstInsts[0].Int64(common.GetInstIDField(asst.AsstObjectID))
-			if err != nil {
-				blog.Errorf("[NetDevice][ConfirmReport] propertyID %s not exist in %#v ", common.GetInstIDField(asst.AsstObjectID), asstInsts[0])
-				errs = append(errs, err)
-				continue
-			}
-
+		if asstInstID > 0 {
 			if !isAssociationExists(instassts, report.ObjectID, instID, asst.AsstObjectID, asstInstID) {
 				req := metadata.CreateAssociationInstRequest{
 					ObjectAsstID: asst.ObjectAsstID,
@@ -561,6 +532,143 @@ func (lgc *Logics) confirmAssociations(header http.Header, report *metadata.Netc
 	return successCount, errs
 }
 
+// findAsstInstID returns the id of the instance the association of the report points to, 0 if it's not found
+func (lgc *Logics) findAsstInstID(header http.Header, report *metadata.NetcollectReport, asst metadata.NetcollectReportAssociation) (int64, error) {
+	asstObjType := common.GetObjByType(asst.AsstObjectID)
+	asstCond := condition.CreateCondition()
+	if asstObjType == common.BKInnerObjIDObject {
+		asstCond.Field(common.GetInstNameField(asst.AsstObjectID)).Eq(asst.AsstInstName)
+		asstCond.Field(common.BKObjIDField).Eq(asst.AsstObjectID)
+	}
+	if asstObjType == common.BKInnerObjIDHost {
+		asstCond.Field(common.BKCloudIDField).Eq(report.CloudID)
+		asstCond.Field(common.BKHostInnerIPField).Eq(asst.AsstInstName)
+	}
+	asstInsts, err := lgc.findInst(header, asst.AsstObjectID, &metadata.QueryCondition{Condition: asstCond.ToMapStr()})
+	if err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] find inst by %+v failed %v", asstCond.ToMapStr(), err)
+		return 0, err
+	}
+	blog.V(4).Infof("[NetDevice][ConfirmReport] find inst result: %#v, condition: %#v", asstInsts, asstCond.ToMapStr())
+	if len(asstInsts) <= 0 {
+		return 0, nil
+	}
+	asstInstID, err := asstInsts[0].Int64(common.GetInstIDField(asst.AsstObjectID))
+	if err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] propertyID %s not exist in %#v ", common.GetInstIDField(asst.AsstObjectID), asstInsts[0])
+		return 0, err
+	}
+	return asstInstID, nil
+}
+
+// deleteStaleTopoAssociations delete the switch topology associations of the switch which are no longer
+// observed by the collector, the stored report is used as the observed connections, as only some of the
+// associations may be confirmed in the request
+func (lgc *Logics) deleteStaleTopoAssociations(header http.Header, report *metadata.NetcollectReport) (successCount int, errs []error) {
+	if report.ObjectID != common.BKInnerObjIDSwitch {
+		return 0, nil
+	}
+
+	cond := condition.CreateCondition()
+	cond.Field(common.BKCloudIDField).Eq(report.CloudID)
+	cond.Field(common.BKObjIDField).Eq(report.ObjectID)
+	cond.Field(common.BKInstKeyField).Eq(report.InstKey)
+	stored := make([]metadata.NetcollectReport, 0)
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectReport).Find(cond.ToMapStr()).All(lgc.ctx, &stored); err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] find report by %+v failed %v", cond.ToMapStr(), err)
+		return 0, append(errs, err)
+	}
+	// the topology is not collected, nothing is known about the connections
+	if len(stored) <= 0 || (len(stored[0].Neighbors) <= 0 && len(stored[0].Interfaces) <= 0) {
+		return 0, nil
+	}
+
+	instCond := reportInstCond(&stored[0])
+	insts, err := lgc.findInst(header, report.ObjectID, &metadata.QueryCondition{Condition: instCond.ToMapStr()})
+	if err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] find inst %+v failed %v", instCond.ToMapStr(), err)
+		return 0, append(errs, err)
+	}
+	if len(insts) <= 0 {
+		return 0, nil
+	}
+	instID, err := insts[0].Int64(common.GetInstIDField(report.ObjectID))
+	if err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] find inst failed, instID not found from %+v", insts[0])
+		return 0, append(errs, err)
+	}
+
+	observed := map[string]bool{}
+	for _, asst := range stored[0].Associations {
+		asstInstID, err := lgc.findAsstInstID(header, &stored[0], asst)
+		if err != nil {
+			// the connection may be still observed, do not delete anything
+			return 0, append(errs, err)
+		}
+		observed[topoAssociationKey(asst.ObjectAsstID, asstInstID)] = true
+	}
+
+	instassts, err := lgc.findInstAssociation(header, report.ObjectID, instID)
+	if err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] find inst association of %s %d failed: %v", report.ObjectID, instID, err)
+		return 0, append(errs, err)
+	}
+	for _, asst := range instassts {
+		if asst.ObjectID != report.ObjectID || asst.InstID != instID || !isTopoObjectAsstID(asst.ObjectAsstID) {
+			continue
+		}
+		if observed[topoAssociationKey(asst.ObjectAsstID, asst.AsstInstID)] {
+			continue
+		}
+		resp, err := lgc.CoreAPI.TopoServer().Association().DeleteInst(context.Background(), header, asst.ID)
+		if err != nil {
+			blog.Errorf("[NetDevice][ConfirmReport] delete stale inst association %+v error: %v", asst, err)
+			errs = append(errs, err)
+			continue
+		}
+		if !resp.Result {
+			blog.Errorf("[NetDevice][ConfirmReport] delete stale inst association %+v error: %v", asst, resp.ErrMsg)
+			errs = append(errs, errors.New(resp.ErrMsg))
+			continue
+		}
+		blog.V(3).Infof("[NetDevice][ConfirmReport] the stale inst association %+v is deleted", asst)
+		successCount++
+	}
+	return successCount, errs
+}
+
+func topoAssociationKey(objAsstID string, asstInstID int64) string {
+	return fmt.Sprintf("%s:%d", objAsstID, asstInstID)
+}
+
+func isTopoObjectAsstID(objAsstID string) bool {
+	for _, id := range metadata.NetcollectTopoObjectAsstIDs {
+		if id == objAsstID {
+			return true
+		}
+	}
+	return false
+}
+
+// reportInstCond returns the condition to find the instance of the report
+func reportInstCond(report *metadata.NetcollectReport) condition.Condition {
+	cond := condition.CreateCondition()
+	objType := common.GetObjByType(report.ObjectID)
+	if objType == common.BKInnerObjIDObject {
+		keyField := report.KeyField
+		if keyField == "" {
+			keyField = common.GetInstNameField(report.ObjectID)
+		}
+		cond.Field(keyField).Eq(report.InstKey)
+		cond.Field(common.BKObjIDField).Eq(report.ObjectID)
+	}
+	if objType == common.BKInnerObjIDHost {
+		cond.Field(common.BKCloudIDField).Eq(report.CloudID)
+		cond.Field(common.BKHostInnerIPField).Eq(report.InstKey)
+	}
+	return cond
+}
+
 func isAssociationExists(assts []*metadata.InstAsst, objectID string, instID int64, asstObjectID string, asstInstID int64) bool {
 	for _, asst := range assts {
 		if asst.ObjectID == objectID &&
@@ -60,7 +60,7 @@ func (s *Service) UpdateCollector(req *restful.Request, resp *restful.Response)
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
-	blog.Infof("[NetDevice][UpdateCollector] update by %+v", cond)
+	blog.Infof("[NetDevice][UpdateCollector] update collector %s of cloud %d", cond.InnerIP, cond.CloudID)
 
 	if cond.BizID <= 0 {
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsLostField, common.BKAppIDField)})
@@ -73,6 +73,11 @@ func (s *Service) UpdateCollector(req *restful.Request, resp *restful.Response)
 
 	err := s.Logics.UpdateCollector(pheader, cond)
 	if err != nil {
+		if err.Error() == defErr.Error(common.CCErrCollectNetCredentialNotExist).Error() ||
+			err.Error() == defErr.Error(common.CCErrCollectNetCredentialKeyNotSet).Error() {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+			return
+		}
 		resp.WriteError(http.StatusInternalServerError,
 			&metadata.RespError{Msg: defErr.Error(common.CCErrCollectNetCollectorUpdateFail)})
 		return
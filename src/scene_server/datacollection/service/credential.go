@@ -0,0 +1,133 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	restful "github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateCredential create a named set of snmp credentials
+func (s *Service) CreateCredential(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	credential := meta.NetcollectCredential{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&credential); nil != err {
+		blog.Errorf("[NetCredential] add credential failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.AddCredential(pheader, credential)
+	if nil != err {
+		resp.WriteError(credentialErrorStatus(defErr, err), &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// UpdateCredential update the credential, the masked secrets are kept
+func (s *Service) UpdateCredential(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	credentialID, err := checkCredentialIDPathParam(defErr, req.PathParameter("credential_id"))
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	credential := meta.NetcollectCredential{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&credential); nil != err {
+		blog.Errorf("[NetCredential] update credential failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err = s.Logics.UpdateCredential(pheader, credentialID, credential); nil != err {
+		resp.WriteError(credentialErrorStatus(defErr, err), &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchCredential search the credentials, the secrets are never returned
+func (s *Service) SearchCredential(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+
+	credentials, err := s.Logics.SearchCredential(pheader)
+	if nil != err {
+		blog.Errorf("[NetCredential] search credential failed, err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(credentials))
+}
+
+// DeleteCredential delete the credential not used by the collectors and the devices
+func (s *Service) DeleteCredential(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	credentialID, err := checkCredentialIDPathParam(defErr, req.PathParameter("credential_id"))
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	if err = s.Logics.DeleteCredential(pheader, credentialID); nil != err {
+		blog.Errorf("[NetCredential] delete credential failed, with credential_id [%d], err: %v", credentialID, err)
+		resp.WriteError(credentialErrorStatus(defErr, err), &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// credentialErrorStatus the errors caused by the database are server errors, the others are bad requests
+func credentialErrorStatus(defErr errors.DefaultCCErrorIf, err error) int {
+	for _, code := range []int{common.CCErrCommDBSelectFailed, common.CCErrCommDBInsertFailed,
+		common.CCErrCommDBUpdateFailed, common.CCErrCommDBDeleteFailed} {
+		if err.Error() == defErr.Error(code).Error() {
+			return http.StatusInternalServerError
+		}
+	}
+	return http.StatusBadRequest
+}
+
+func checkCredentialIDPathParam(defErr errors.DefaultCCErrorIf, ID string) (uint64, error) {
+	credentialID, err := strconv.ParseUint(ID, 10, 64)
+	if nil != err {
+		blog.Errorf("[NetCredential] parse the credential id [%s] error: %v", ID, err)
+		return 0, defErr.Errorf(common.CCErrCommParamsNeedInt, "credential_id")
+	}
+	if 0 == credentialID {
+		blog.Errorf("[NetCredential] the credential id should not be 0")
+		return 0, defErr.Error(common.CCErrCommHTTPInputInvalid)
+	}
+
+	return credentialID, nil
+}
@@ -0,0 +1,82 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// UpdateDiscoverPolicy creates or updates the policy to apply the discovered instances of a model
+func (s *Service) UpdateDiscoverPolicy(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	policy := metadata.DiscoverPolicy{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&policy); err != nil {
+		blog.Errorf("[DiscoverPolicy] update policy failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := s.Logics.UpdateDiscoverPolicy(pheader, policy); err != nil {
+		resp.WriteError(credentialErrorStatus(defErr, err), &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// SearchDiscoverPolicy search the discover policies
+func (s *Service) SearchDiscoverPolicy(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	param := metadata.ParamSearchDiscoverPolicy{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&param); err != nil {
+		blog.Errorf("[DiscoverPolicy] search policy failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.SearchDiscoverPolicy(pheader, param)
+	if err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// SearchDiscoverReportSummary summarizes the pending discover reports by model, the reports
+// are searched, confirmed and recorded by the net collect report apis with source discover
+func (s *Service) SearchDiscoverReportSummary(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	result, err := s.Logics.SearchDiscoverReportSummary(pheader)
+	if err != nil {
+		blog.Errorf("[DiscoverReport][SearchDiscoverReportSummary] SearchDiscoverReportSummary failed, err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCollectNetReportSearchFail)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
@@ -53,26 +53,35 @@ func (s *Service) WebService() *restful.Container {
 
 	api.Path("/collector/v3").Filter(rdapi.AllGlobalFilter(getErrFunc)).Produces(restful.MIME_JSON)
 
-	api.Route(api.POST("/netcollect/device/action/create").To(s.CreateDevice))
-	api.Route(api.POST("/netcollect/device/{device_id}/action/update").To(s.UpdateDevice))
-	api.Route(api.POST("/netcollect/device/action/batch").To(s.BatchCreateDevice))
-	api.Route(api.POST("/netcollect/device/action/search").To(s.SearchDevice))
-	api.Route(api.DELETE("/netcollect/device/action/delete").To(s.DeleteDevice))
-
-	api.Route(api.POST("/netcollect/property/action/create").To(s.CreateProperty))
-	api.Route(api.POST("/netcollect/property/{netcollect_property_id}/action/update").To(s.UpdateProperty))
-	api.Route(api.POST("/netcollect/property/action/batch").To(s.BatchCreateProperty))
-	api.Route(api.POST("/netcollect/property/action/search").To(s.SearchProperty))
-	api.Route(api.DELETE("/netcollect/property/action/delete").To(s.DeleteProperty))
-
-	api.Route(api.POST("/netcollect/summary/action/search").To(s.SearchReportSummary))
-	api.Route(api.POST("/netcollect/report/action/search").To(s.SearchReport))
-	api.Route(api.POST("/netcollect/report/action/confirm").To(s.ConfirmReport))
-	api.Route(api.POST("/netcollect/history/action/search").To(s.SearchHistory))
-
-	api.Route(api.POST("/netcollect/collector/action/search").To(s.SearchCollector))
-	api.Route(api.POST("/netcollect/collector/action/update").To(s.UpdateCollector))
-	api.Route(api.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice))
+	api.Route(api.POST("/netcollect/device/action/create").To(s.CreateDevice).Reads(metadata.NetcollectDevice{}))
+	api.Route(api.POST("/netcollect/device/{device_id}/action/update").To(s.UpdateDevice).Reads(metadata.NetcollectDevice{}))
+	api.Route(api.POST("/netcollect/device/action/batch").To(s.BatchCreateDevice).Reads(metadata.BatchAddDevice{}))
+	api.Route(api.POST("/netcollect/device/action/search").To(s.SearchDevice).Reads(metadata.NetCollSearchParams{}).Writes(metadata.SearchNetDeviceResult{}))
+	api.Route(api.DELETE("/netcollect/device/action/delete").To(s.DeleteDevice).Reads(metadata.DeleteNetDeviceBatchOpt{}))
+
+	api.Route(api.POST("/netcollect/property/action/create").To(s.CreateProperty).Reads(metadata.NetcollectProperty{}))
+	api.Route(api.POST("/netcollect/property/{netcollect_property_id}/action/update").To(s.UpdateProperty).Reads(metadata.NetcollectProperty{}))
+	api.Route(api.POST("/netcollect/property/action/batch").To(s.BatchCreateProperty).Reads(metadata.BatchAddNetProperty{}))
+	api.Route(api.POST("/netcollect/property/action/search").To(s.SearchProperty).Reads(metadata.NetCollSearchParams{}).Writes(metadata.SearchNetPropertyResult{}))
+	api.Route(api.DELETE("/netcollect/property/action/delete").To(s.DeleteProperty).Reads(metadata.DeleteNetPropertyBatchOpt{}))
+
+	api.Route(api.POST("/netcollect/summary/action/search").To(s.SearchReportSummary).Reads(metadata.ParamSearchNetcollectReport{}).Writes(metadata.NetcollectReportSummaryResult{}))
+	api.Route(api.POST("/netcollect/report/action/search").To(s.SearchReport).Reads(metadata.ParamSearchNetcollectReport{}).Writes(metadata.NetcollectReportResult{}))
+	api.Route(api.POST("/netcollect/report/action/confirm").To(s.ConfirmReport).Reads(metadata.ParamNetcollectComfirm{}).Writes(metadata.NetcollectConfirmResult{}))
+	api.Route(api.POST("/netcollect/history/action/search").To(s.SearchHistory).Reads(metadata.ParamSearchNetcollectReport{}).Writes(metadata.NetcollectHistoryResult{}))
+
+	api.Route(api.POST("/netcollect/collector/action/search").To(s.SearchCollector).Reads(metadata.ParamNetcollectorSearch{}).Writes(metadata.NetcollectorSearchResult{}))
+	api.Route(api.POST("/netcollect/collector/action/update").To(s.UpdateCollector).Reads(metadata.Netcollector{}))
+	api.Route(api.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice).Reads(metadata.ParamNetcollectDiscover{}))
+
+	api.Route(api.POST("/netcollect/credential/action/create").To(s.CreateCredential).Reads(metadata.NetcollectCredential{}))
+	api.Route(api.POST("/netcollect/credential/{credential_id}/action/update").To(s.UpdateCredential).Reads(metadata.NetcollectCredential{}))
+	api.Route(api.POST("/netcollect/credential/action/search").To(s.SearchCredential).Writes(metadata.SearchNetCredentialResult{}))
+	api.Route(api.DELETE("/netcollect/credential/{credential_id}/action/delete").To(s.DeleteCredential))
+
+	api.Route(api.POST("/discover/policy/action/update").To(s.UpdateDiscoverPolicy).Reads(metadata.DiscoverPolicy{}))
+	api.Route(api.POST("/discover/policy/action/search").To(s.SearchDiscoverPolicy).Reads(metadata.ParamSearchDiscoverPolicy{}).Writes(metadata.DiscoverPolicyResult{}))
+	api.Route(api.POST("/discover/summary/action/search").To(s.SearchDiscoverReportSummary).Writes(metadata.DiscoverReportSummaryResult{}))
 
 	container.Add(api)
 
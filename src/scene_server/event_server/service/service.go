@@ -65,12 +65,12 @@ func (s *Service) WebService() *restful.Container {
 	}
 	api.Path("/event/v3").Filter(rdapi.AllGlobalFilter(getErrFunc)).Produces(restful.MIME_JSON)
 
-	api.Route(api.POST("/subscribe/search/{ownerID}/{appID}").To(s.Query))
-	api.Route(api.POST("/subscribe/ping").To(s.Ping))
-	api.Route(api.POST("/subscribe/telnet").To(s.Telnet))
-	api.Route(api.POST("/subscribe/{ownerID}/{appID}").To(s.Subscribe))
+	api.Route(api.POST("/subscribe/search/{ownerID}/{appID}").To(s.Query).Reads(metadata.ParamSubscriptionSearch{}).Writes(metadata.SubscriptionSearchResult{}))
+	api.Route(api.POST("/subscribe/ping").To(s.Ping).Reads(metadata.ParamSubscriptionTestCallback{}).Writes(metadata.SubscriptionTestCallbackResult{}))
+	api.Route(api.POST("/subscribe/telnet").To(s.Telnet).Reads(metadata.ParamSubscriptionTelnet{}))
+	api.Route(api.POST("/subscribe/{ownerID}/{appID}").To(s.Subscribe).Reads(metadata.Subscription{}).Writes(metadata.RspSubscriptionCreate{}))
 	api.Route(api.DELETE("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.UnSubscribe))
-	api.Route(api.PUT("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.Rebook))
+	api.Route(api.PUT("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.Rebook).Reads(metadata.Subscription{}))
 
 	container.Add(api)
 
@@ -21,7 +21,6 @@ import (
 
 	"github.com/emicklei/go-restful"
 
-	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/auth/extensions"
 	"configcenter/src/common"
@@ -77,7 +76,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	}
 
 	blog.Info("host server auth config is: %+v", hostSrv.Config.Auth)
-	authorizer, err := auth.NewAuthorize(nil, hostSrv.Config.Auth)
+	authorizer, err := engine.NewAuthorize(nil, hostSrv.Config.Auth)
 	if err != nil {
 		blog.Errorf("new host authorizer failed, err: %+v", err)
 		return fmt.Errorf("new host authorizer failed, err: %+v", err)
@@ -329,6 +329,10 @@ func (lgc *Logics) TransferHostAcrossBusiness(ctx context.Context, srcBizID, dst
 		blog.Errorf("check host authorization failed, hosts: %+v, err: %v", hostID, err)
 		return lgc.ccErr.Errorf(common.CCErrCommAuthorizeFailed)
 	}
+	if _, err := lgc.CheckHostTransferPortConflict(ctx, dstAppID, []int64{hostID}, moduleID, false); err != nil {
+		blog.Errorf("TransferHostAcrossBusiness check process port conflict failed, err:%s,hostID:%d,appID:%d,moduleID:%#v,rid:%s", err.Error(), hostID, dstAppID, moduleID, lgc.rid)
+		return err
+	}
 	// auth: deregister
 	if err := lgc.AuthManager.DeregisterHostsByID(ctx, lgc.header, hostID); err != nil {
 		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v", hostID, err)
@@ -13,6 +13,7 @@
 package logics
 
 import (
+	"context"
 	"net/http"
 
 	"gopkg.in/redis.v5"
@@ -22,7 +23,9 @@ import (
 	"configcenter/src/common/backbone"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
+	"configcenter/src/common/trace"
 	"configcenter/src/common/util"
+	dalredis "configcenter/src/storage/dal/redis"
 )
 
 type Logics struct {
@@ -53,7 +56,7 @@ func (lgc *Logics) NewFromHeader(header http.Header) *Logics {
 		header:  header,
 		Engine:  lgc.Engine,
 		rid:     rid,
-		cache:   lgc.cache,
+		cache:   dalredis.Trace(trace.ContextWithHeader(context.Background(), header), lgc.cache),
 		user:    util.GetUser(header),
 		ownerID: util.GetOwnerID(header),
 	}
@@ -79,7 +82,7 @@ func NewLogics(b *backbone.Engine, header http.Header, cache *redis.Client, auth
 		ccLang:  b.Language.CreateDefaultCCLanguageIf(lang),
 		user:    util.GetUser(header),
 		ownerID: util.GetOwnerID(header),
-		cache:   cache,
+		cache:   dalredis.Trace(trace.ContextWithHeader(context.Background(), header), cache),
 		AuthManager: authManager,
 	}
 }
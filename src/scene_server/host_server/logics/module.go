@@ -15,6 +15,7 @@ package logics
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
@@ -138,6 +139,40 @@ func (lgc *Logics) GetModuleMapByCond(ctx context.Context, fields []string, cond
 	return moduleMap, nil
 }
 
+// CheckHostTransferPortConflict check whether transferring the hosts to the modules of the business cause process port conflicts.
+// the resource pool, idle and fault modules have no processes, so they are not checked. the transfer is blocked if the
+// conflicts can not be checked, since the processes of the hosts may be bound to the conflicting ports otherwise.
+func (lgc *Logics) CheckHostTransferPortConflict(ctx context.Context, appID int64, hostIDs, moduleIDs []int64, isIncrement bool) ([]metadata.ProcPortConflict, errors.CCError) {
+	moduleCond := []metadata.ConditionItem{
+		{Field: common.BKModuleIDField, Operator: common.BKDBIN, Value: moduleIDs},
+		{Field: common.BKDefaultField, Operator: common.BKDBEQ, Value: 0},
+	}
+	normalModuleIDs, err := lgc.GetModuleIDByCond(ctx, moduleCond)
+	if err != nil {
+		blog.Errorf("CheckHostTransferPortConflict get the normal modules failed, err:%s,appID:%d,moduleIDs:%v,rid:%s", err.Error(), appID, moduleIDs, lgc.rid)
+		return nil, err
+	}
+	if 0 == len(normalModuleIDs) {
+		return nil, nil
+	}
+
+	param := &metadata.ProcPortCheckParam{HostID: hostIDs, ModuleID: normalModuleIDs, IsIncrement: isIncrement}
+	result, err := lgc.CoreAPI.ProcServer().Process().CheckHostPortConflict(ctx, lgc.ownerID, strconv.FormatInt(appID, 10), lgc.header, param)
+	if err != nil {
+		blog.Errorf("CheckHostTransferPortConflict http do error, err:%s,appID:%d,input:%+v,rid:%s", err.Error(), appID, param, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("CheckHostTransferPortConflict http response error, err code:%d, err msg:%s,appID:%d,input:%+v,rid:%s", result.Code, result.ErrMsg, appID, param, lgc.rid)
+		return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	if 0 != len(result.Data) {
+		blog.Errorf("CheckHostTransferPortConflict process port conflict:%+v,appID:%d,input:%+v,rid:%s", result.Data, appID, param, lgc.rid)
+		return result.Data, lgc.ccErr.Errorf(common.CCErrProcPortConflict, result.Data[0].Desc())
+	}
+	return nil, nil
+}
+
 func (lgc *Logics) MoveHostToResourcePool(ctx context.Context, conf *metadata.DefaultModuleHostConfigParams) ([]metadata.ExceptionResult, error) {
 
 	ownerAppID, err := lgc.GetDefaultAppID(ctx)
@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/apimachinery/coreservice/instance"
+	"configcenter/src/apimachinery/procserver"
+	"configcenter/src/apimachinery/procserver/process"
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// fakeClientSet serves the default flags of the modules or the error of core service, and the port conflicts
+// or the error of proc server.
+type fakeClientSet struct {
+	apimachinery.ClientSetInterface
+	coreservice.CoreServiceClientInterface
+	instance.InstanceClientInterface
+	procserver.ProcServerClientInterface
+	process.ProcessClientInterface
+
+	moduleDefaults map[int64]int
+	moduleErr      error
+	conflicts      []metadata.ProcPortConflict
+	procErr        error
+	checked        *metadata.ProcPortCheckParam
+}
+
+func (c *fakeClientSet) CoreService() coreservice.CoreServiceClientInterface {
+	return c
+}
+
+func (c *fakeClientSet) Instance() instance.InstanceClientInterface {
+	return c
+}
+
+func (c *fakeClientSet) ProcServer() procserver.ProcServerClientInterface {
+	return c
+}
+
+func (c *fakeClientSet) Process() process.ProcessClientInterface {
+	return c
+}
+
+func (c *fakeClientSet) ReadInstance(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (*metadata.QueryConditionResult, error) {
+	if c.moduleErr != nil {
+		return nil, c.moduleErr
+	}
+	result := &metadata.QueryConditionResult{BaseResp: metadata.SuccessBaseResp}
+	moduleIDs := input.Condition[common.BKModuleIDField].(map[string]interface{})[common.BKDBIN].([]int64)
+	for _, moduleID := range moduleIDs {
+		if flag, exists := c.moduleDefaults[moduleID]; exists && flag == input.Condition[common.BKDefaultField] {
+			result.Data.Info = append(result.Data.Info, mapstr.MapStr{common.BKModuleIDField: moduleID})
+		}
+	}
+	return result, nil
+}
+
+func (c *fakeClientSet) CheckHostPortConflict(ctx context.Context, ownerID string, businessID string, h http.Header, dat *metadata.ProcPortCheckParam) (*metadata.ProcPortConflictResult, error) {
+	c.checked = dat
+	if c.procErr != nil {
+		return nil, c.procErr
+	}
+	return &metadata.ProcPortConflictResult{BaseResp: metadata.SuccessBaseResp, Data: c.conflicts}, nil
+}
+
+func TestCheckHostTransferPortConflict(t *testing.T) {
+	moduleDefaults := map[int64]int{1: common.DefaultResModuleFlag, 2: common.DefaultFaultModuleFlag, 3: 0, 4: 0}
+	conflict := metadata.ProcPortConflict{HostID: 10, InnerIP: "127.0.0.1", Protocol: "tcp", Port: "8080"}
+
+	tests := []struct {
+		name          string
+		moduleIDs     []int64
+		moduleErr     error
+		conflicts     []metadata.ProcPortConflict
+		procErr       error
+		wantChecked   []int64
+		wantConflicts []metadata.ProcPortConflict
+		wantCode      int
+	}{
+		{"idle module is not checked", []int64{1}, nil, []metadata.ProcPortConflict{conflict}, nil, nil, nil, 0},
+		{"fault module is not checked", []int64{2}, nil, []metadata.ProcPortConflict{conflict}, nil, nil, nil, 0},
+		{"no conflicts", []int64{3, 4}, nil, nil, nil, []int64{3, 4}, nil, 0},
+		{"conflicts block the transfer", []int64{3}, nil, []metadata.ProcPortConflict{conflict}, nil, []int64{3}, []metadata.ProcPortConflict{conflict}, common.CCErrProcPortConflict},
+		{"only the normal modules are checked", []int64{1, 4}, nil, []metadata.ProcPortConflict{conflict}, nil, []int64{4}, []metadata.ProcPortConflict{conflict}, common.CCErrProcPortConflict},
+		{"core service unavailable blocks the transfer", []int64{3}, errors.New("core service is down"), nil, nil, nil, nil, common.CCErrCommHTTPDoRequestFailed},
+		{"proc server unavailable blocks the transfer", []int64{3}, nil, nil, errors.New("proc server is down"), []int64{3}, nil, common.CCErrCommHTTPDoRequestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClientSet{moduleDefaults: moduleDefaults, moduleErr: tt.moduleErr, conflicts: tt.conflicts, procErr: tt.procErr}
+			lgc := &Logics{
+				Engine:  &backbone.Engine{CoreAPI: client},
+				header:  http.Header{},
+				ownerID: common.BKDefaultOwnerID,
+				ccErr:   ccErr.NewFromCtx(ccErr.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+			}
+
+			conflicts, err := lgc.CheckHostTransferPortConflict(context.Background(), 2, []int64{10}, tt.moduleIDs, false)
+			if (err != nil) != (tt.wantCode != 0) {
+				t.Fatalf("CheckHostTransferPortConflict() err = %v, want the code %d", err, tt.wantCode)
+			}
+			if coder, ok := err.(ccErr.CCErrorCoder); tt.wantCode != 0 && (!ok || coder.GetCode() != tt.wantCode) {
+				t.Errorf("CheckHostTransferPortConflict() err = %v, want the code %d", err, tt.wantCode)
+			}
+			if !reflect.DeepEqual(conflicts, tt.wantConflicts) {
+				t.Errorf("CheckHostTransferPortConflict() conflicts = %v, want %v", conflicts, tt.wantConflicts)
+			}
+			if tt.wantChecked == nil {
+				if client.checked != nil {
+					t.Errorf("CheckHostTransferPortConflict() checked the default modules %v", client.checked.ModuleID)
+				}
+				return
+			}
+			if client.checked == nil || !reflect.DeepEqual(client.checked.ModuleID, tt.wantChecked) {
+				t.Errorf("CheckHostTransferPortConflict() checked %+v, want the modules %v", client.checked, tt.wantChecked)
+			}
+		})
+	}
+}
@@ -112,6 +112,27 @@ func (s *Service) DeleteHostBatchFromResourcePool(req *restful.Request, resp *re
 		logConentsMap[hostID] = logger.AuditLog(srvData.ctx, hostID)
 	}
 
+	// take a copy of the hosts and their module relations, which is kept in recycle bin after
+	// the hosts is deleted, so that they can be restored. the copy is saved before the hosts
+	// is deleted, and purged for the hosts which failed to be deleted.
+	recycleInput := &meta.CreateRecycleRecords{InstIDs: iHostIDArr}
+	snapshotResult, err := s.CoreAPI.CoreService().RecycleBin().SnapshotRecycleRecords(srvData.ctx, srvData.header, common.BKInnerObjIDHost, recycleInput)
+	if err != nil {
+		blog.Errorf("DeleteHostBatch SnapshotRecycleRecords http do error. err:%s, input:%+v, rid:%s", err.Error(), recycleInput, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !snapshotResult.Result {
+		blog.Errorf("DeleteHostBatch SnapshotRecycleRecords http reply error. result: %#v, input:%+v, rid:%s", snapshotResult, recycleInput, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.New(snapshotResult.Code, snapshotResult.ErrMsg)})
+		return
+	}
+	recordIDs, err := s.saveHostRecycleRecords(srvData, snapshotResult.Data.Info)
+	if err != nil {
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
 	input := &meta.DeleteHostRequest{
 		ApplicationID: appID,
 		HostIDArr:     iHostIDArr,
@@ -119,13 +140,23 @@ func (s *Service) DeleteHostBatchFromResourcePool(req *restful.Request, resp *re
 	delResult, err := s.CoreAPI.CoreService().Host().DeleteHost(srvData.ctx, srvData.header, input)
 	if err != nil {
 		blog.Error("DeleteHostBatch DeleteHost http do error. err:%s, input:%s, rid:%s", err.Error(), input, srvData.rid)
+		s.rollbackHostRecycleRecords(srvData, recordIDs, nil)
 		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
 		return
 	}
 
 	// ensure delete host add log
+	notDeletedHostIDs := make(map[int64]bool)
 	for _, ex := range delResult.Data {
 		delete(logConentsMap, ex.OriginIndex)
+		notDeletedHostIDs[ex.OriginIndex] = true
+	}
+	// only the hosts deleted successfully are kept in recycle bin, none of the hosts is deleted
+	// when the request failed without any exception of the hosts.
+	if delResult.Result || len(delResult.Data) > 0 {
+		s.rollbackHostRecycleRecords(srvData, recordIDs, notDeletedHostIDs)
+	} else {
+		s.rollbackHostRecycleRecords(srvData, recordIDs, nil)
 	}
 	var logConents []meta.SaveAuditLogParams
 	for _, item := range logConentsMap {
@@ -152,6 +183,55 @@ func (s *Service) DeleteHostBatchFromResourcePool(req *restful.Request, resp *re
 	resp.WriteEntity(meta.NewSuccessResp(nil))
 }
 
+// saveHostRecycleRecords save the snapshots of the hosts into recycle bin before they are deleted,
+// it returns the recycle record id of each host.
+func (s *Service) saveHostRecycleRecords(srvData *srvComm, records []meta.RecycleRecord) (map[int64]int64, error) {
+	recordIDs := make(map[int64]int64)
+	if len(records) == 0 {
+		return recordIDs, nil
+	}
+
+	saveInput := &meta.SaveRecycleRecords{Records: records}
+	saveResult, err := s.CoreAPI.CoreService().RecycleBin().CreateRecycleRecords(srvData.ctx, srvData.header, common.BKInnerObjIDHost, saveInput)
+	if err != nil {
+		blog.Errorf("DeleteHostBatch CreateRecycleRecords http do error. err: %v, rid:%s", err, srvData.rid)
+		return nil, srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !saveResult.Result {
+		blog.Errorf("DeleteHostBatch CreateRecycleRecords http reply error. result: %#v, rid:%s", saveResult, srvData.rid)
+		return nil, srvData.ccErr.New(saveResult.Code, saveResult.ErrMsg)
+	}
+
+	for _, created := range saveResult.Data.Created {
+		recordIDs[records[created.OriginIndex].InstID] = int64(created.ID)
+	}
+	if len(saveResult.Data.Exceptions) > 0 {
+		blog.Errorf("DeleteHostBatch CreateRecycleRecords failed, exceptions: %#v, rid:%s", saveResult.Data.Exceptions, srvData.rid)
+		s.rollbackHostRecycleRecords(srvData, recordIDs, nil)
+		return nil, srvData.ccErr.New(int(saveResult.Data.Exceptions[0].Code), saveResult.Data.Exceptions[0].Message)
+	}
+	return recordIDs, nil
+}
+
+// rollbackHostRecycleRecords purge the recycle records of the hosts which failed to be deleted,
+// all the records are purged when failedHostIDs is nil.
+func (s *Service) rollbackHostRecycleRecords(srvData *srvComm, recordIDs map[int64]int64, failedHostIDs map[int64]bool) {
+	purgeInput := &meta.PurgeRecycleRecords{IDs: make([]int64, 0)}
+	for hostID, recordID := range recordIDs {
+		if failedHostIDs == nil || failedHostIDs[hostID] {
+			purgeInput.IDs = append(purgeInput.IDs, recordID)
+		}
+	}
+	if len(purgeInput.IDs) == 0 {
+		return
+	}
+
+	purgeResult, err := s.CoreAPI.CoreService().RecycleBin().PurgeRecycleRecords(srvData.ctx, srvData.header, purgeInput)
+	if err != nil || (err == nil && !purgeResult.Result) {
+		blog.Errorf("DeleteHostBatch rollback recycle records %v failed, err: %v, result: %#v, rid:%s", purgeInput.IDs, err, purgeResult, srvData.rid)
+	}
+}
+
 func (s *Service) GetHostInstanceProperties(req *restful.Request, resp *restful.Response) {
 	srvData := s.newSrvComm(req.Request.Header)
 
@@ -183,6 +263,13 @@ func (s *Service) GetHostInstanceProperties(req *restful.Request, resp *restful.
 		return
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(srvData.ctx, srvData.header, common.BKInnerObjIDHost, details); err != nil {
+		blog.Errorf("redact host protected attributes failed, hosts: %+v, err: %v, rid: %s", hostIDInt64, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
 	attribute, err := srvData.lgc.GetHostAttributes(srvData.ctx, srvData.ownerID, nil)
 	if err != nil {
 		blog.Errorf("get host attribute fields failed, err: %v,rid:%s", err, srvData.rid)
@@ -283,6 +370,16 @@ func (s *Service) AddHost(req *restful.Request, resp *restful.Response) {
 		}
 	}
 
+	// auth: check authorization on protected attributes
+	hostDatas := make([]mapstr.MapStr, 0)
+	for _, host := range hostList.HostInfo {
+		hostDatas = append(hostDatas, host)
+	}
+	if err := s.AuthManager.AuthorizeInstanceAttributeUpdate(srvData.ctx, srvData.header, common.BKInnerObjIDHost, hostDatas...); err != nil {
+		s.writeInstanceAttributeNoPermissionResp(srvData, resp, err, hostDatas...)
+		return
+	}
+
 	cond := hutil.NewOperation().WithModuleName(common.DefaultResModuleName).WithAppID(appID).MapStr()
 	cond.Set(common.BKDefaultField, common.DefaultResModuleFlag)
 	moduleID, err := srvData.lgc.GetResoulePoolModuleID(srvData.ctx, cond)
@@ -417,6 +514,13 @@ func (s *Service) SearchHost(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactSearchHostAttributes(srvData, host); err != nil {
+		blog.Errorf("redact host protected attributes failed, hosts: %+v, err: %v, rid: %s", hostIDArray, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
 	resp.WriteEntity(meta.SearchHostResult{
 		BaseResp: meta.SuccessBaseResp,
 		Data:     *host,
@@ -448,6 +552,13 @@ func (s *Service) SearchHostWithAsstDetail(req *restful.Request, resp *restful.R
 		return
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactSearchHostAttributes(srvData, host); err != nil {
+		blog.Errorf("redact host protected attributes failed, hosts: %+v, err: %v, rid: %s", hostIDArray, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
 	resp.WriteEntity(meta.SearchHostResult{
 		BaseResp: meta.SuccessBaseResp,
 		Data:     *host,
@@ -501,6 +612,10 @@ func (s *Service) UpdateHostBatch(req *restful.Request, resp *restful.Response)
 		resp.WriteEntity(s.AuthManager.GenDeleteHostBatchNoPermissionResp(hostIDArr))
 		return
 	}
+	if err := s.AuthManager.AuthorizeInstanceAttributeUpdate(srvData.ctx, srvData.header, common.BKInnerObjIDHost, data); err != nil {
+		s.writeInstanceAttributeNoPermissionResp(srvData, resp, err, data)
+		return
+	}
 
 	logPreConents := make(map[int64]meta.SaveAuditLogParams, 0)
 	hostIDs := make([]int64, 0)
@@ -690,6 +805,15 @@ func (s *Service) NewHostSyncAppTopo(req *restful.Request, resp *restful.Respons
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	// auth: check authorization on protected attributes
+	hostDatas := make([]mapstr.MapStr, 0)
+	for _, host := range hostList.HostInfo {
+		hostDatas = append(hostDatas, host)
+	}
+	if err := s.AuthManager.AuthorizeInstanceAttributeUpdate(srvData.ctx, srvData.header, common.BKInnerObjIDHost, hostDatas...); err != nil {
+		s.writeInstanceAttributeNoPermissionResp(srvData, resp, err, hostDatas...)
+		return
+	}
 
 	hostIDs, succ, updateErrRow, errRow, err := srvData.lgc.AddHost(srvData.ctx, hostList.ApplicationID, hostList.ModuleID, srvData.ownerID, hostList.HostInfo, common.InputTypeApiNewHostSync)
 	if err != nil {
@@ -993,6 +1117,23 @@ func (s *Service) CloneHostProperty(req *restful.Request, resp *restful.Response
 		resp.WriteEntity(s.AuthManager.GenEditBizHostNoPermissionResp([]int64{dstHostID}))
 		return
 	}
+	// step3. verify has permission to write the protected attributes copied from src host
+	hostMap, _, err := srvData.lgc.NewPHPAPI().GetHostMapByCond(srvData.ctx, common.KvMap{common.BKHostIDField: srcHostID})
+	if err != nil {
+		blog.Errorf("get src host failed, host: %d, input:%+v, err: %v, rid:%s", srcHostID, input, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	cloneData := mapstr.New()
+	for key, val := range hostMap[srcHostID] {
+		if nil != val {
+			cloneData[key] = val
+		}
+	}
+	if err := s.AuthManager.AuthorizeInstanceAttributeUpdate(srvData.ctx, srvData.header, common.BKInnerObjIDHost, cloneData); err != nil {
+		s.writeInstanceAttributeNoPermissionResp(srvData, resp, err, cloneData)
+		return
+	}
 
 	res, err := srvData.lgc.CloneHostProperty(srvData.ctx, input, input.AppID, input.CloudID)
 	if nil != err {
@@ -1006,3 +1147,33 @@ func (s *Service) CloneHostProperty(req *restful.Request, resp *restful.Response
 		Data:     res,
 	})
 }
+
+// redactSearchHostAttributes remove the host's auth protected attributes which the user has no authority to read.
+func (s *Service) redactSearchHostAttributes(srvData *srvComm, host *meta.SearchHost) error {
+	hosts := make([]mapstr.MapStr, 0)
+	for _, item := range host.Info {
+		data, ok := item[common.BKInnerObjIDHost].(mapstr.MapStr)
+		if !ok {
+			continue
+		}
+		hosts = append(hosts, data)
+	}
+	return s.AuthManager.RedactUnauthorizedInstanceAttributes(srvData.ctx, srvData.header, common.BKInnerObjIDHost, hosts...)
+}
+
+// writeInstanceAttributeNoPermissionResp write the response when authorize host's protected attributes failed.
+func (s *Service) writeInstanceAttributeNoPermissionResp(srvData *srvComm, resp *restful.Response, err error, datas ...mapstr.MapStr) {
+	if err != auth.NoAuthorizeError {
+		blog.Errorf("check host protected attributes authorization failed, err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	noPermResp, err := s.AuthManager.GenInstanceAttributeNoPermissionResp(srvData.ctx, srvData.header, common.BKInnerObjIDHost, datas...)
+	if err != nil {
+		blog.Errorf("generate host protected attributes no permission response failed, err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+	resp.WriteEntity(noPermResp)
+}
@@ -71,6 +71,12 @@ func (s *Service) HostModuleRelation(req *restful.Request, resp *restful.Respons
 		resp.WriteEntity(s.AuthManager.GenEditBizHostNoPermissionResp(config.HostID))
 		return
 	}
+
+	if conflicts, err := srvData.lgc.CheckHostTransferPortConflict(srvData.ctx, config.ApplicationID, config.HostID, config.ModuleID, config.IsIncrement); err != nil {
+		blog.Errorf("add host and module relation, but check process port conflict failed, err: %v,param:%+v,rid:%s", err, config, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err, Data: conflicts})
+		return
+	}
 	// auth: deregister hosts
 	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, config.HostID...); err != nil {
 		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v", config.HostID, err)
@@ -464,6 +470,7 @@ func (s *Service) moveHostToModuleByName(req *restful.Request, resp *restful.Res
 		resp.WriteEntity(s.AuthManager.GenEditBizHostNoPermissionResp(conf.HostID))
 		return
 	}
+
 	// auth: deregister hosts
 	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, conf.HostID...); err != nil {
 		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v", conf.HostID, err)
@@ -68,6 +68,13 @@ func (s *Service) UpdateHost(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	// auth: check authorization on host's protected attributes
+	if updateData, ok := input["data"].(map[string]interface{}); ok {
+		if err := s.AuthManager.AuthorizeInstanceAttributeUpdate(srvData.ctx, srvData.header, common.BKInnerObjIDHost, updateData); err != nil {
+			s.writeInstanceAttributeNoPermissionResp(srvData, resp, err, updateData)
+			return
+		}
+	}
 
 	data, httpCode, errMsg := srvData.lgc.UpdateHost(srvData.ctx, input, appID)
 
@@ -113,6 +120,18 @@ func (s *Service) UpdateHostByAppID(req *restful.Request, resp *restful.Response
 		return
 	}
 
+	// auth: check authorization on the protected attributes of the proxy hosts
+	proxyDatas := make([]mapstr.MapStr, 0)
+	for _, proxy := range input.ProxyList {
+		if proxyData, ok := proxy.(map[string]interface{}); ok {
+			proxyDatas = append(proxyDatas, proxyData)
+		}
+	}
+	if err := s.AuthManager.AuthorizeInstanceAttributeUpdate(srvData.ctx, srvData.header, common.BKInnerObjIDHost, proxyDatas...); err != nil {
+		s.writeInstanceAttributeNoPermissionResp(srvData, resp, err, proxyDatas...)
+		return
+	}
+
 	blog.V(5).Infof("updateHostByAppID http body data: %v,srvData.rid", input, srvData.rid)
 	result, httpCode, errMsg := srvData.lgc.UpdateHostByAppID(srvData.ctx, input, appID)
 	if nil != errMsg {
@@ -198,6 +217,12 @@ func (s *Service) HostSearchByIP(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	// auth: remove the host's protected attributes which the user has no authority to read
+	if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(srvData.ctx, srvData.header, common.BKInnerObjIDHost, hostData...); err != nil {
+		blog.Errorf("redact host protected attributes failed, hosts: %+v, err: %v, rid: %s", hostIDArr, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
 
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
@@ -246,6 +271,12 @@ func (s *Service) HostSearchByConds(req *restful.Request, resp *restful.Response
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	// auth: remove the host's protected attributes which the user has no authority to read
+	if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(srvData.ctx, srvData.header, common.BKInnerObjIDHost, hostData...); err != nil {
+		blog.Errorf("redact host protected attributes failed, hosts: %+v, err: %v, rid: %s", hostIDArr, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
 
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
@@ -310,6 +341,12 @@ func (s *Service) HostSearchByModuleID(req *restful.Request, resp *restful.Respo
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	// auth: remove the host's protected attributes which the user has no authority to read
+	if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(srvData.ctx, srvData.header, common.BKInnerObjIDHost, hostData...); err != nil {
+		blog.Errorf("redact host protected attributes failed, hosts: %+v, err: %v, rid: %s", hostIDArr, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
 
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
@@ -371,6 +408,12 @@ func (s *Service) HostSearchBySetID(req *restful.Request, resp *restful.Response
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	// auth: remove the host's protected attributes which the user has no authority to read
+	if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(srvData.ctx, srvData.header, common.BKInnerObjIDHost, hostData...); err != nil {
+		blog.Errorf("redact host protected attributes failed, hosts: %+v, err: %v, rid: %s", hostIDArr, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
 
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
@@ -429,6 +472,12 @@ func (s *Service) HostSearchByAppID(req *restful.Request, resp *restful.Response
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	// auth: remove the host's protected attributes which the user has no authority to read
+	if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(srvData.ctx, srvData.header, common.BKInnerObjIDHost, hostData...); err != nil {
+		blog.Errorf("redact host protected attributes failed, hosts: %+v, err: %v, rid: %s", hostIDArr, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
 
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
@@ -528,6 +577,12 @@ func (s *Service) HostSearchByProperty(req *restful.Request, resp *restful.Respo
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	// auth: remove the host's protected attributes which the user has no authority to read
+	if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(srvData.ctx, srvData.header, common.BKInnerObjIDHost, hostData...); err != nil {
+		blog.Errorf("redact host protected attributes failed, hosts: %+v, err: %v, rid: %s", hostIDArr, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
 
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
@@ -655,6 +710,12 @@ func (s *Service) UpdateCustomProperty(req *restful.Request, resp *restful.Respo
 		return
 	}
 
+	// auth: check authorization on host's protected attributes
+	if err := s.AuthManager.AuthorizeInstanceAttributeUpdate(srvData.ctx, srvData.header, common.BKInnerObjIDHost, propertyMap); err != nil {
+		s.writeInstanceAttributeNoPermissionResp(srvData, resp, err, propertyMap)
+		return
+	}
+
 	res, err := srvData.lgc.UpdateCustomProperty(srvData.ctx, hostID, appID, propertyMap)
 	if nil != err {
 		blog.Errorf("UpdateCustomPropertyinput not found property, input:%+v,rid:%s", input, srvData.rid)
@@ -88,7 +88,7 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.DELETE("/hosts/batch").To(s.DeleteHostBatchFromResourcePool))
 	api.Route(api.GET("/hosts/{bk_supplier_account}/{bk_host_id}").To(s.GetHostInstanceProperties))
 	api.Route(api.GET("/hosts/snapshot/{bk_host_id}").To(s.HostSnapInfo))
-	api.Route(api.POST("/hosts/add").To(s.AddHost))
+	api.Route(api.POST("/hosts/add").To(s.AddHost).Reads(metadata.HostList{}))
 	// api.Route(api.POST("/host/add/agent").To(s.AddHostFromAgent))
 	api.Route(api.POST("/hosts/sync/new/host").To(s.NewHostSyncAppTopo))
 	api.Route(api.POST("hosts/favorites/search").To(s.GetHostFavourites))
@@ -105,7 +105,7 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/usercustom").To(s.SaveUserCustom))
 	api.Route(api.POST("/usercustom/user/search").To(s.GetUserCustom))
 	api.Route(api.POST("/usercustom/default/search").To(s.GetDefaultCustom))
-	api.Route(api.POST("/hosts/search").To(s.SearchHost))
+	api.Route(api.POST("/hosts/search").To(s.SearchHost).Reads(metadata.HostCommonSearch{}).Writes(metadata.SearchHostResult{}))
 	api.Route(api.POST("/hosts/search/asstdetail").To(s.SearchHostWithAsstDetail))
 	api.Route(api.PUT("/hosts/batch").To(s.UpdateHostBatch))
 	api.Route(api.PUT("/hosts/property/clone").To(s.CloneHostProperty))
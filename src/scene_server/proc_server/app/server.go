@@ -18,7 +18,6 @@ import (
 	"os"
 	"time"
 
-	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/auth/extensions"
 	"configcenter/src/common"
@@ -72,7 +71,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		return err
 	}
 
-	authorize, err := auth.NewAuthorize(nil, authConf)
+	authorize, err := engine.NewAuthorize(nil, authConf)
 	if err != nil {
 		return fmt.Errorf("new authorize failed, err: %v", err)
 	}
@@ -39,6 +39,7 @@ func (lgc *Logics) reshReshInitChan(ctx context.Context, config *ProcHostInstCon
 	}
 	handEventDataChan = make(chan chanItem, maxEventDataChan)
 	refreshHostInstModuleIDChan = make(chan *refreshHostInstModuleID, maxRefreshModuleData)
+	gseOPProcTaskChan = make(chan *opProcTask, maxRefreshModuleData)
 	// get appID,moduleID from redis
 	go lgc.getEventRefreshModuleItemFromRedis(config.GetModuleIDInterval)
 	go lgc.backgroudHandleOpGseProcTaskResult(ctx, config.FetchGseOPProcResultInterval)
@@ -74,6 +75,7 @@ func (lgc *Logics) getEventRefreshModuleItemFromRedis(interval time.Duration) {
 func (lgc *Logics) backgroudHandleOpGseProcTaskResult(ctx context.Context, interval time.Duration) {
 	go lgc.getGseOPProcTaskIDFromRedis(interval)
 	go lgc.timedTriggerTaskInfoToRedis(ctx)
+	go lgc.timedTriggerProcOpBatchTask(ctx)
 	for {
 		select {
 		case taskInfo := <-gseOPProcTaskChan:
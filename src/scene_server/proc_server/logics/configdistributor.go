@@ -0,0 +1,244 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/thirdpartyclient/esbserver"
+)
+
+// the backends of the config file distribution
+const (
+	ConfigFileBackendGse   = "gse"
+	ConfigFileBackendLocal = "local"
+)
+
+// ConfigFileDistributor distribute the config files to the hosts of the process instances and fetch
+// the files on the hosts back. the results are in the order of the files.
+type ConfigFileDistributor interface {
+	Push(ctx context.Context, header http.Header, files []metadata.ProcConfigFile) []error
+	Fetch(ctx context.Context, header http.Header, files []metadata.ProcConfigFile) ([]string, []error)
+}
+
+// NewConfigFileDistributor create the distributor by the config of proc server:
+// configfile.backend is gse (default) or local, configfile.localDir is the root directory of the
+// local backend, which is for testing.
+func NewConfigFileDistributor(configMap map[string]string, esbServ esbserver.EsbClientInterface) (ConfigFileDistributor, error) {
+	switch backend := configMap["configfile.backend"]; backend {
+	case "", ConfigFileBackendGse:
+		if esbServ == nil {
+			return nil, fmt.Errorf("esb is not initialized")
+		}
+		return &gseDistributor{esbServ: esbServ}, nil
+	case ConfigFileBackendLocal:
+		dir := configMap["configfile.localDir"]
+		if dir == "" {
+			return nil, fmt.Errorf("configfile.localDir is not set for the local backend")
+		}
+		return NewLocalDistributor(dir), nil
+	default:
+		return nil, fmt.Errorf("unknown config file backend %s", backend)
+	}
+}
+
+// gseDistributor distribute the files by the gse agents on the hosts
+type gseDistributor struct {
+	esbServ esbserver.EsbClientInterface
+}
+
+func gseHostKey(cloudID int64, ip string) string {
+	return fmt.Sprintf("%d:%s", cloudID, ip)
+}
+
+// Push push the files of a host in one request
+func (d *gseDistributor) Push(ctx context.Context, header http.Header, files []metadata.ProcConfigFile) []error {
+	errs := make([]error, len(files))
+	hostFiles := make(map[string][]int)
+	for idx, file := range files {
+		key := gseHostKey(file.CloudID, file.InnerIP)
+		hostFiles[key] = append(hostFiles[key], idx)
+	}
+
+	for key, idxs := range hostFiles {
+		first := files[idxs[0]]
+		req := &metadata.GsePushConfigFileRequest{
+			Hosts: []metadata.GseHost{{HostID: first.HostID, Ip: first.InnerIP, BkCloudId: first.CloudID}},
+		}
+		for _, idx := range idxs {
+			req.Files = append(req.Files, metadata.GseConfigFile{
+				Path:       files[idx].Path,
+				Content:    files[idx].Content,
+				Md5:        files[idx].Md5,
+				User:       files[idx].User,
+				Permission: files[idx].Permission,
+			})
+		}
+
+		resp, err := d.esbServ.GseSrv().PushConfigFile(ctx, header, req)
+		err = gseResultError(key, resp, err)
+		for _, idx := range idxs {
+			errs[idx] = err
+		}
+	}
+	return errs
+}
+
+// Fetch get the files of the same path on the hosts in one request
+func (d *gseDistributor) Fetch(ctx context.Context, header http.Header, files []metadata.ProcConfigFile) ([]string, []error) {
+	contents := make([]string, len(files))
+	errs := make([]error, len(files))
+	pathFiles := make(map[string][]int)
+	for idx, file := range files {
+		pathFiles[file.Path] = append(pathFiles[file.Path], idx)
+	}
+
+	for path, idxs := range pathFiles {
+		req := &metadata.GseGetConfigFileRequest{Path: path}
+		for _, idx := range idxs {
+			req.Hosts = append(req.Hosts, metadata.GseHost{HostID: files[idx].HostID, Ip: files[idx].InnerIP, BkCloudId: files[idx].CloudID})
+		}
+
+		resp, err := d.esbServ.GseSrv().GetConfigFileContent(ctx, header, req)
+		for _, idx := range idxs {
+			key := gseHostKey(files[idx].CloudID, files[idx].InnerIP)
+			if errs[idx] = gseResultError(key, resp, err); errs[idx] == nil {
+				contents[idx] = resp.Data[key].Content
+			}
+		}
+	}
+	return contents, errs
+}
+
+// gseResultError get the error of the host from the result of gse
+func gseResultError(key string, resp *metadata.GseConfigFileResult, err error) error {
+	if err != nil {
+		return err
+	}
+	if !resp.Result {
+		return fmt.Errorf("gse error, code: %d, message: %s", resp.Code, resp.Message)
+	}
+	result, ok := resp.Data[key]
+	if !ok {
+		return fmt.Errorf("gse returns no result of host %s", key)
+	}
+	if result.Errcode != 0 {
+		return fmt.Errorf("gse error of host %s, code: %d, message: %s", key, result.Errcode, result.ErrMsg)
+	}
+	return nil
+}
+
+// LocalDistributor distribute the files to a local directory, the file of a host is saved in
+// <dir>/<cloud id>/<ip>/<path>. the user of the files is not changed.
+type LocalDistributor struct {
+	dir string
+}
+
+// NewLocalDistributor create a distributor which distribute the files to the directory
+func NewLocalDistributor(dir string) *LocalDistributor {
+	return &LocalDistributor{dir: dir}
+}
+
+// file get the local path of the file, the ip must be an ip, and the path must be in the directory
+// of the host, so that the file of a host can not be written out of it.
+func (d *LocalDistributor) file(file *metadata.ProcConfigFile) (string, error) {
+	if net.ParseIP(file.InnerIP) == nil {
+		return "", fmt.Errorf("invalid host ip %s", file.InnerIP)
+	}
+	hostDir := filepath.Join(d.dir, strconv.FormatInt(file.CloudID, 10), file.InnerIP)
+	name := filepath.Join(hostDir, filepath.FromSlash(file.Path))
+	if !strings.HasPrefix(name, hostDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path %s", file.Path)
+	}
+	return name, nil
+}
+
+// Push write the files
+func (d *LocalDistributor) Push(ctx context.Context, header http.Header, files []metadata.ProcConfigFile) []error {
+	errs := make([]error, len(files))
+	for idx := range files {
+		errs[idx] = d.push(&files[idx])
+	}
+	return errs
+}
+
+func (d *LocalDistributor) push(file *metadata.ProcConfigFile) error {
+	mode := os.FileMode(0644)
+	if file.Permission != "" {
+		perm, err := strconv.ParseUint(file.Permission, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid permission %s", file.Permission)
+		}
+		mode = os.FileMode(perm).Perm()
+	}
+
+	name, err := d.file(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(name, []byte(file.Content), mode); err != nil {
+		return err
+	}
+	return os.Chmod(name, mode)
+}
+
+// Fetch read the files
+func (d *LocalDistributor) Fetch(ctx context.Context, header http.Header, files []metadata.ProcConfigFile) ([]string, []error) {
+	contents := make([]string, len(files))
+	errs := make([]error, len(files))
+	for idx := range files {
+		name, err := d.file(&files[idx])
+		if err != nil {
+			errs[idx] = err
+			continue
+		}
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			errs[idx] = err
+			continue
+		}
+		contents[idx] = string(data)
+	}
+	return contents, errs
+}
+
+// resolveConfigFilePath get the absolute path of the config file on the host, the file name is used
+// if it's absolute, otherwise it's in the directory of the template or the work path of the process.
+func resolveConfigFilePath(fileName, dir, workPath string) (string, error) {
+	fileName = strings.TrimSpace(fileName)
+	if fileName == "" {
+		return "", fmt.Errorf("the file name of the template is empty")
+	}
+	if strings.HasPrefix(fileName, "/") {
+		return filepath.ToSlash(filepath.Clean(fileName)), nil
+	}
+	if dir == "" {
+		dir = workPath
+	}
+	if !strings.HasPrefix(dir, "/") {
+		return "", fmt.Errorf("the path of file %s is not absolute, the directory is %s", fileName, dir)
+	}
+	return filepath.ToSlash(filepath.Join(dir, fileName)), nil
+}
@@ -0,0 +1,521 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/flosch/pongo2"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// GetConfigTemplate get the config template of the business
+func (lgc *Logics) GetConfigTemplate(ctx context.Context, appID, templateID int64) (mapstr.MapStr, error) {
+	input := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{
+			common.BKAppIDField:  appID,
+			common.BKInstIDField: templateID,
+		},
+	}
+	ret, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, common.BKInnerObjIDConfigTemp, input)
+	if err != nil {
+		blog.Errorf("GetConfigTemplate ReadInstance http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("GetConfigTemplate ReadInstance http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	if len(ret.Data.Info) == 0 {
+		blog.Errorf("GetConfigTemplate template %d of business %d not found,rid:%s", templateID, appID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrProcConfigTemplateNotFound)
+	}
+	return ret.Data.Info[0], nil
+}
+
+// GetTemplateVersion get the version of the template, the released version is returned if versionID is 0
+func (lgc *Logics) GetTemplateVersion(ctx context.Context, appID, templateID, versionID int64) (mapstr.MapStr, error) {
+	cond := mapstr.MapStr{
+		common.BKAppIDField:     appID,
+		common.BKTemlateIDField: templateID,
+	}
+	if versionID != 0 {
+		cond[common.BKInstIDField] = versionID
+	} else {
+		cond[common.BKStatusField] = common.TemplateStatusReleased
+	}
+	input := &metadata.QueryCondition{Condition: cond}
+	ret, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, common.BKInnerObjIDTempVersion, input)
+	if err != nil {
+		blog.Errorf("GetTemplateVersion ReadInstance http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("GetTemplateVersion ReadInstance http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	if len(ret.Data.Info) == 0 {
+		blog.Errorf("GetTemplateVersion version %d of template %d not found,rid:%s", versionID, templateID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrProcTemplateVersionNotFound)
+	}
+	return ret.Data.Info[0], nil
+}
+
+// GenerateConfigFiles render the version of the template for the instances of the processes bound to
+// the template, and replace the files generated before.
+func (lgc *Logics) GenerateConfigFiles(ctx context.Context, appID, templateID int64, params *metadata.CreateConfigFileParams) ([]metadata.ProcConfigFile, error) {
+	template, err := lgc.GetConfigTemplate(ctx, appID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	version, err := lgc.GetTemplateVersion(ctx, appID, templateID, params.VersionID)
+	if err != nil {
+		return nil, err
+	}
+	versionID, err := version.Int64(common.BKInstIDField)
+	if err != nil {
+		blog.Errorf("GenerateConfigFiles version id of %+v is invalid, err:%v,rid:%s", version, err, lgc.rid)
+		return nil, lgc.ccErr.Errorf(common.CCErrCommInstFieldConvFail, common.BKInnerObjIDTempVersion, common.BKInstIDField, "int", err.Error())
+	}
+	content, _ := version.String(common.BKContentField)
+	tpl, err := pongo2.FromString(content)
+	if err != nil {
+		blog.Errorf("GenerateConfigFiles parse the content of version %d failed, err:%v,rid:%s", versionID, err, lgc.rid)
+		return nil, lgc.ccErr.Errorf(common.CCErrProcRenderConfigFileFail, err.Error())
+	}
+
+	procIDs, err := lgc.getTemplateBindProcIDs(ctx, appID, templateID, params.ProcessIDs)
+	if err != nil {
+		return nil, err
+	}
+	deleteCond := mapstr.MapStr{
+		common.BKAppIDField:     appID,
+		common.BKTemlateIDField: templateID,
+	}
+	if len(params.ProcessIDs) != 0 {
+		deleteCond[common.BKProcessIDField] = mapstr.MapStr{common.BKDBIN: params.ProcessIDs}
+	}
+	if 0 == len(procIDs) {
+		return []metadata.ProcConfigFile{}, lgc.deleteConfigFiles(ctx, deleteCond)
+	}
+
+	vars, err := lgc.newConfigFileVariables(ctx, appID, procIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName, _ := template.String(common.BKFileNameField)
+	dir, _ := template.String(common.BKFilePathField)
+	user, _ := template.String(common.BKUser)
+	permission, _ := template.String(common.BKFilePermissionField)
+	now := time.Now().UTC()
+	files := make([]metadata.ProcConfigFile, 0, len(vars.insts))
+	for _, inst := range vars.insts {
+		proc := vars.procs[inst.ProcID]
+		host, ok := vars.hosts[inst.HostID]
+		if !ok {
+			blog.Errorf("GenerateConfigFiles host %d of process instance %+v not found,rid:%s", inst.HostID, inst, lgc.rid)
+			return nil, lgc.ccErr.Errorf(common.CCErrProcGenerateConfigFileFail, fmt.Sprintf("host %d not found", inst.HostID))
+		}
+
+		workPath, _ := proc.String(common.BKProcWorkPath)
+		path, err := resolveConfigFilePath(fileName, dir, workPath)
+		if err != nil {
+			blog.Errorf("GenerateConfigFiles get the path of process %d failed, err:%v,rid:%s", inst.ProcID, err, lgc.rid)
+			return nil, lgc.ccErr.Errorf(common.CCErrProcGenerateConfigFileFail, err.Error())
+		}
+		out, err := tpl.Execute(vars.context(inst))
+		if err != nil {
+			blog.Errorf("GenerateConfigFiles render version %d for process instance %+v failed, err:%v,rid:%s", versionID, inst, err, lgc.rid)
+			return nil, lgc.ccErr.Errorf(common.CCErrProcRenderConfigFileFail, err.Error())
+		}
+
+		file := metadata.ProcConfigFile{
+			OwnerID:    lgc.ownerID,
+			AppID:      appID,
+			TemplateID: templateID,
+			VersionID:  versionID,
+			SetID:      inst.SetID,
+			ModuleID:   inst.ModuleID,
+			ProcID:     inst.ProcID,
+			FuncID:     inst.FuncID,
+			HostID:     inst.HostID,
+			HostInstID: inst.HostInstanID,
+			ProcInstID: inst.ProcInstanceID,
+			Path:       path,
+			User:       user,
+			Permission: permission,
+			Content:    out,
+			Md5:        fmt.Sprintf("%x", md5.Sum([]byte(out))),
+			Status:     metadata.ProcConfigFileStatusGenerated,
+			CreateTime: now,
+		}
+		file.InnerIP, _ = host.String(common.BKHostInnerIPField)
+		file.CloudID, _ = host.Int64(common.BKCloudIDField)
+		if file.User == "" {
+			file.User, _ = proc.String(common.BKUser)
+		}
+		files = append(files, file)
+	}
+
+	if err := lgc.deleteConfigFiles(ctx, deleteCond); err != nil {
+		return nil, err
+	}
+	ret, err := lgc.CoreAPI.ProcController().CreateConfigFile(ctx, lgc.header, files)
+	if err != nil {
+		blog.Errorf("GenerateConfigFiles CreateConfigFile http do error,err:%s,template:%d,rid:%s", err.Error(), templateID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("GenerateConfigFiles CreateConfigFile http response error,err code:%d,err msg:%s,template:%d,rid:%s", ret.Code, ret.ErrMsg, templateID, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return files, nil
+}
+
+// SearchConfigFiles get the files generated from the template, hostIDs filter the files by the hosts
+func (lgc *Logics) SearchConfigFiles(ctx context.Context, appID, templateID int64, hostIDs []int64) ([]metadata.ProcConfigFile, error) {
+	cond := mapstr.MapStr{
+		common.BKAppIDField:     appID,
+		common.BKTemlateIDField: templateID,
+	}
+	if len(hostIDs) != 0 {
+		cond[common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: hostIDs}
+	}
+	input := &metadata.QueryInput{
+		Condition: cond,
+		Limit:     common.BKNoLimit,
+		Sort:      common.BKHostIDField,
+	}
+	ret, err := lgc.CoreAPI.ProcController().SearchConfigFile(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("SearchConfigFiles http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("SearchConfigFiles http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return ret.Data.Info, nil
+}
+
+// PushConfigFiles push the generated files to the hosts, and save the result of each file
+func (lgc *Logics) PushConfigFiles(ctx context.Context, distributor ConfigFileDistributor, appID, templateID int64, hostIDs []int64) ([]metadata.ConfigFileResult, error) {
+	files, err := lgc.SearchConfigFiles(ctx, appID, templateID, hostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := distributor.Push(ctx, lgc.header, files)
+	now := time.Now().UTC()
+	results := make([]metadata.ConfigFileResult, 0, len(files))
+	for idx, file := range files {
+		data := mapstr.MapStr{
+			metadata.ProcConfigFileFieldStatus:   metadata.ProcConfigFileStatusPushed,
+			metadata.ProcConfigFileFieldMessage:  "",
+			metadata.ProcConfigFileFieldPushTime: now,
+		}
+		if errs[idx] != nil {
+			blog.Errorf("PushConfigFiles push file %s to host %s failed, err:%v,rid:%s", file.Path, file.InnerIP, errs[idx], lgc.rid)
+			data[metadata.ProcConfigFileFieldStatus] = metadata.ProcConfigFileStatusPushFailed
+			data[metadata.ProcConfigFileFieldMessage] = errs[idx].Error()
+		}
+		input := &metadata.UpdateParams{Condition: configFileKey(&file), Data: data}
+		ret, err := lgc.CoreAPI.ProcController().UpdateConfigFile(ctx, lgc.header, input)
+		if err != nil {
+			blog.Errorf("PushConfigFiles UpdateConfigFile http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+			return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !ret.Result {
+			blog.Errorf("PushConfigFiles UpdateConfigFile http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+			return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+		}
+		results = append(results, newConfigFileResult(&file, errs[idx]))
+	}
+	return results, nil
+}
+
+// FetchRemoteConfigFiles get the content of the generated files on the hosts
+func (lgc *Logics) FetchRemoteConfigFiles(ctx context.Context, distributor ConfigFileDistributor, appID, templateID int64, hostIDs []int64) ([]metadata.ConfigFileResult, error) {
+	files, err := lgc.SearchConfigFiles(ctx, appID, templateID, hostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, errs := distributor.Fetch(ctx, lgc.header, files)
+	results := make([]metadata.ConfigFileResult, 0, len(files))
+	for idx := range files {
+		if errs[idx] != nil {
+			blog.Errorf("FetchRemoteConfigFiles get file %s of host %s failed, err:%v,rid:%s", files[idx].Path, files[idx].InnerIP, errs[idx], lgc.rid)
+		}
+		result := newConfigFileResult(&files[idx], errs[idx])
+		result.Content = contents[idx]
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// DiffConfigFiles compare the files on the hosts with the generated files, the diff is empty if
+// the files are the same.
+func (lgc *Logics) DiffConfigFiles(ctx context.Context, distributor ConfigFileDistributor, appID, templateID int64, hostIDs []int64) ([]metadata.ConfigFileResult, error) {
+	files, err := lgc.SearchConfigFiles(ctx, appID, templateID, hostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, errs := distributor.Fetch(ctx, lgc.header, files)
+	results := make([]metadata.ConfigFileResult, 0, len(files))
+	for idx := range files {
+		result := newConfigFileResult(&files[idx], errs[idx])
+		if errs[idx] != nil {
+			blog.Errorf("DiffConfigFiles get file %s of host %s failed, err:%v,rid:%s", files[idx].Path, files[idx].InnerIP, errs[idx], lgc.rid)
+		} else if result.Diff, err = diffConfigFile(files[idx].Path, contents[idx], files[idx].Content); err != nil {
+			result.Success = false
+			result.Message = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (lgc *Logics) deleteConfigFiles(ctx context.Context, cond mapstr.MapStr) error {
+	ret, err := lgc.CoreAPI.ProcController().DeleteConfigFile(ctx, lgc.header, cond)
+	if err != nil {
+		blog.Errorf("deleteConfigFiles http do error,err:%s,input:%+v,rid:%s", err.Error(), cond, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("deleteConfigFiles http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, cond, lgc.rid)
+		return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return nil
+}
+
+// getTemplateBindProcIDs get the processes bound to the template, filterProcIDs limit the processes
+// if it's not empty.
+func (lgc *Logics) getTemplateBindProcIDs(ctx context.Context, appID, templateID int64, filterProcIDs []int64) ([]int64, error) {
+	cond := mapstr.MapStr{
+		common.BKAppIDField:     appID,
+		common.BKTemlateIDField: templateID,
+	}
+	if len(filterProcIDs) != 0 {
+		cond[common.BKProcessIDField] = mapstr.MapStr{common.BKDBIN: filterProcIDs}
+	}
+	ret, err := lgc.CoreAPI.ProcController().SearchProc2Template(ctx, lgc.header, cond)
+	if err != nil {
+		blog.Errorf("getTemplateBindProcIDs SearchProc2Template http do error,err:%s,input:%+v,rid:%s", err.Error(), cond, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("getTemplateBindProcIDs SearchProc2Template http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, cond, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+
+	procIDs := make([]int64, 0, len(ret.Data))
+	for _, item := range ret.Data {
+		procID, err := item.Int64(common.BKProcessIDField)
+		if err != nil {
+			blog.Warnf("getTemplateBindProcIDs process id of %+v is invalid, err:%v,rid:%s", item, err, lgc.rid)
+			continue
+		}
+		procIDs = append(procIDs, procID)
+	}
+	return util.IntArrayUnique(procIDs), nil
+}
+
+// configFileVariables the data to render the config files of the process instances
+type configFileVariables struct {
+	app     mapstr.MapStr
+	sets    map[int64]mapstr.MapStr
+	modules map[int64]mapstr.MapStr
+	hosts   map[int64]mapstr.MapStr
+	procs   map[int64]mapstr.MapStr
+	insts   []metadata.ProcInstanceModel
+	custom  []metadata.ProcTemplateVariable
+}
+
+func (lgc *Logics) newConfigFileVariables(ctx context.Context, appID int64, procIDs []int64) (*configFileVariables, error) {
+	vars := new(configFileVariables)
+	input := &metadata.QueryInput{
+		Condition: mapstr.MapStr{
+			common.BKAppIDField:     appID,
+			common.BKProcessIDField: mapstr.MapStr{common.BKDBIN: procIDs},
+		},
+		Limit: common.BKNoLimit,
+	}
+	instRet, err := lgc.CoreAPI.ProcController().GetProcInstanceModel(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("newConfigFileVariables GetProcInstanceModel http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !instRet.Result {
+		blog.Errorf("newConfigFileVariables GetProcInstanceModel http response error,err code:%d,err msg:%s,input:%+v,rid:%s", instRet.Code, instRet.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(instRet.Code, instRet.ErrMsg)
+	}
+	vars.insts = instRet.Data.Info
+
+	var setIDs, moduleIDs, hostIDs []int64
+	for _, inst := range vars.insts {
+		setIDs = append(setIDs, inst.SetID)
+		moduleIDs = append(moduleIDs, inst.ModuleID)
+		hostIDs = append(hostIDs, inst.HostID)
+	}
+
+	apps, err := lgc.getInstByIDs(ctx, common.BKInnerObjIDApp, []int64{appID})
+	if err != nil {
+		return nil, err
+	}
+	vars.app = apps[appID]
+	if vars.sets, err = lgc.getInstByIDs(ctx, common.BKInnerObjIDSet, util.IntArrayUnique(setIDs)); err != nil {
+		return nil, err
+	}
+	if vars.modules, err = lgc.getInstByIDs(ctx, common.BKInnerObjIDModule, util.IntArrayUnique(moduleIDs)); err != nil {
+		return nil, err
+	}
+	if vars.procs, err = lgc.getInstByIDs(ctx, common.BKInnerObjIDProc, procIDs); err != nil {
+		return nil, err
+	}
+	if vars.hosts, err = lgc.getHostByIDs(ctx, util.IntArrayUnique(hostIDs)); err != nil {
+		return nil, err
+	}
+	if vars.custom, err = lgc.getProcTemplateVariables(ctx, appID, nil); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// context get the variables of the process instance, the fields of the business, set, module, host
+// and process are merged, the more specific one is used if a field exists in more than one of them.
+// the user defined variables take precedence over the fields as they're defined for the templates.
+func (v *configFileVariables) context(inst metadata.ProcInstanceModel) pongo2.Context {
+	ctx := pongo2.Context{}
+	for _, data := range []mapstr.MapStr{v.app, v.sets[inst.SetID], v.modules[inst.ModuleID], v.hosts[inst.HostID], v.procs[inst.ProcID]} {
+		for key, val := range data {
+			ctx[key] = val
+		}
+	}
+	for _, variable := range effectiveProcVariables(v.custom, inst) {
+		ctx[variable.Name] = variable.Value
+	}
+	ctx[common.BKFuncIDField] = inst.FuncID
+	ctx["proc_instance_id"] = inst.ProcInstanceID
+	ctx["bk_host_instance_id"] = inst.HostInstanID
+	ctx["host_proc_id"] = inst.HostProcID
+	return ctx
+}
+
+func (lgc *Logics) getInstByIDs(ctx context.Context, objID string, ids []int64) (map[int64]mapstr.MapStr, error) {
+	idField := common.GetInstIDField(objID)
+	input := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: ids}},
+		Limit:     metadata.SearchLimit{Limit: common.BKNoLimit},
+	}
+	ret, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, objID, input)
+	if err != nil {
+		blog.Errorf("getInstByIDs ReadInstance %s http do error,err:%s,input:%+v,rid:%s", objID, err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("getInstByIDs ReadInstance %s http response error,err code:%d,err msg:%s,input:%+v,rid:%s", objID, ret.Code, ret.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+
+	insts := make(map[int64]mapstr.MapStr, len(ret.Data.Info))
+	for _, inst := range ret.Data.Info {
+		id, err := inst.Int64(idField)
+		if err != nil {
+			blog.Warnf("getInstByIDs %s of %s instance %+v is invalid, err:%v,rid:%s", idField, objID, inst, err, lgc.rid)
+			continue
+		}
+		insts[id] = inst
+	}
+	return insts, nil
+}
+
+func (lgc *Logics) getHostByIDs(ctx context.Context, hostIDs []int64) (map[int64]mapstr.MapStr, error) {
+	input := &metadata.QueryInput{
+		Condition: mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}},
+		Limit:     common.BKNoLimit,
+	}
+	ret, err := lgc.CoreAPI.HostController().Host().GetHosts(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("getHostByIDs GetHosts http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("getHostByIDs GetHosts http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+
+	hosts := make(map[int64]mapstr.MapStr, len(ret.Data.Info))
+	for _, host := range ret.Data.Info {
+		hostID, err := host.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Warnf("getHostByIDs host id of %+v is invalid, err:%v,rid:%s", host, err, lgc.rid)
+			continue
+		}
+		hosts[hostID] = host
+	}
+	return hosts, nil
+}
+
+// configFileKey the condition to select a generated file
+func configFileKey(file *metadata.ProcConfigFile) map[string]interface{} {
+	return map[string]interface{}{
+		common.BKAppIDField:     file.AppID,
+		common.BKTemlateIDField: file.TemplateID,
+		common.BKModuleIDField:  file.ModuleID,
+		common.BKProcessIDField: file.ProcID,
+		"proc_instance_id":      file.ProcInstID,
+	}
+}
+
+func newConfigFileResult(file *metadata.ProcConfigFile, err error) metadata.ConfigFileResult {
+	result := metadata.ConfigFileResult{
+		HostID:     file.HostID,
+		InnerIP:    file.InnerIP,
+		CloudID:    file.CloudID,
+		ModuleID:   file.ModuleID,
+		ProcID:     file.ProcID,
+		ProcInstID: file.ProcInstID,
+		Path:       file.Path,
+		Success:    err == nil,
+	}
+	if err != nil {
+		result.Message = err.Error()
+	}
+	return result
+}
+
+// diffConfigFile get the unified diff from the file on the host to the generated file
+func diffConfigFile(path, remote, generated string) (string, error) {
+	if remote == generated {
+		return "", nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(remote),
+		B:        difflib.SplitLines(generated),
+		FromFile: "remote:" + path,
+		ToFile:   path,
+		Context:  3,
+	})
+}
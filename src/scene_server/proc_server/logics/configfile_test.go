@@ -0,0 +1,134 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestResolveConfigFilePath(t *testing.T) {
+	type testData struct {
+		fileName string
+		dir      string
+		workPath string
+		path     string
+		isErr    bool
+	}
+
+	td := []testData{
+		{fileName: "/etc/nginx/nginx.conf", dir: "/data", path: "/etc/nginx/nginx.conf"},
+		{fileName: "conf/app.conf", dir: "/data/app", workPath: "/opt", path: "/data/app/conf/app.conf"},
+		{fileName: "app.conf", workPath: "/opt/app/", path: "/opt/app/app.conf"},
+		{fileName: "app.conf", isErr: true},
+		{fileName: "app.conf", dir: "data", isErr: true},
+		{fileName: " ", dir: "/data", isErr: true},
+	}
+
+	for _, item := range td {
+		path, err := resolveConfigFilePath(item.fileName, item.dir, item.workPath)
+		if item.isErr != (err != nil) {
+			t.Errorf("resolve %+v, unexpected err: %v", item, err)
+			continue
+		}
+		if path != item.path {
+			t.Errorf("resolve %+v, got path %s", item, path)
+		}
+	}
+}
+
+func TestLocalDistributor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	distributor := NewLocalDistributor(dir)
+	files := []metadata.ProcConfigFile{
+		{CloudID: 0, InnerIP: "127.0.0.1", Path: "/data/app.conf", Content: "port = 80\n", Permission: "0600"},
+		{CloudID: 1, InnerIP: "127.0.0.1", Path: "/data/app.conf", Content: "port = 8080\n"},
+		{CloudID: 1, InnerIP: "127.0.0.2", Path: "/data/app.conf", Content: "", Permission: "rw"},
+	}
+	errs := distributor.Push(context.Background(), nil, files)
+	if errs[0] != nil || errs[1] != nil || errs[2] == nil {
+		t.Fatalf("unexpected push result: %v", errs)
+	}
+	info, err := os.Stat(filepath.Join(dir, "0", "127.0.0.1", "data", "app.conf"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected file %v, err: %v", info, err)
+	}
+
+	contents, errs := distributor.Fetch(context.Background(), nil, files)
+	if contents[0] != files[0].Content || contents[1] != files[1].Content || errs[0] != nil || errs[2] == nil {
+		t.Errorf("unexpected fetch result: %q, %v", contents, errs)
+	}
+}
+
+func TestLocalDistributorOutOfHostDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	distributor := NewLocalDistributor(filepath.Join(dir, "files"))
+	files := []metadata.ProcConfigFile{
+		{CloudID: 0, InnerIP: "../..", Path: "/app.conf", Content: "escaped"},
+		{CloudID: 0, InnerIP: "../../../tmp", Path: "/app.conf", Content: "escaped"},
+		{CloudID: 0, InnerIP: "127.0.0.1/../..", Path: "/app.conf", Content: "escaped"},
+		{CloudID: 0, InnerIP: `..\..`, Path: "/app.conf", Content: "escaped"},
+		{CloudID: 0, InnerIP: "", Path: "/app.conf", Content: "escaped"},
+		{CloudID: 0, InnerIP: "127.0.0.1", Path: "/", Content: "escaped"},
+		{CloudID: 0, InnerIP: "127.0.0.1", Path: "../../../app.conf", Content: "escaped"},
+	}
+	for idx, err := range distributor.Push(context.Background(), nil, files) {
+		if err == nil {
+			t.Errorf("push %+v should be rejected", files[idx])
+		}
+	}
+	if _, errs := distributor.Fetch(context.Background(), nil, files); errs[0] == nil || errs[6] == nil {
+		t.Errorf("fetch out of the host directory should be rejected, got %v", errs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.conf")); !os.IsNotExist(err) {
+		t.Errorf("the file is written out of the directory, err: %v", err)
+	}
+
+	ipv6 := metadata.ProcConfigFile{CloudID: 0, InnerIP: "::1", Path: "/data/app.conf", Content: "port = 80\n"}
+	if errs := distributor.Push(context.Background(), nil, []metadata.ProcConfigFile{ipv6}); errs[0] != nil {
+		t.Errorf("push to an ipv6 host failed, err: %v", errs[0])
+	}
+}
+
+func TestDiffConfigFile(t *testing.T) {
+	diff, err := diffConfigFile("/data/app.conf", "a\nb\n", "a\nb\n")
+	if err != nil || diff != "" {
+		t.Errorf("the same files should have no diff, got %q, err: %v", diff, err)
+	}
+
+	diff, err = diffConfigFile("/data/app.conf", "a\nport = 80\n", "a\nport = 8080%d\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"--- remote:/data/app.conf", "+++ /data/app.conf", "-port = 80\n", "+port = 8080%d\n"} {
+		if !strings.Contains(diff, line) {
+			t.Errorf("diff %q should contain %q", diff, line)
+		}
+	}
+}
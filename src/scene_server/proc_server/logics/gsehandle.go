@@ -198,13 +198,13 @@ func (lgc *Logics) getOperateProcInstanceData(ctx context.Context, procOp *metad
 		if !ok {
 			continue
 		}
-		hostInfoArr := make([]*metadata.GseHost, 0)
+		hostInfoArr := make([]metadata.GseHost, 0)
 		for _, hostID := range opGseProcInfo.HostIDArr {
 			hostInfo, ok := hostInfoMap[hostID]
 			if !ok {
 				continue
 			}
-			hostInfoArr = append(hostInfoArr, hostInfo)
+			hostInfoArr = append(hostInfoArr, *hostInfo)
 		}
 		procName, err := procInfo.String(common.BKProcessNameField)
 		if nil != err {
@@ -215,6 +215,10 @@ func (lgc *Logics) getOperateProcInstanceData(ctx context.Context, procOp *metad
 		gseprocReq.Meta.Name = procName
 		gseprocReq.Meta.Namespace = getGseProcNameSpace(procOp.ApplicationID, opGseProcInfo.ModuleID)
 		gseprocReq.OpType = procOp.OpType
+		gseprocReq.AppID = procOp.ApplicationID
+		gseprocReq.ModuleID = opGseProcInfo.ModuleID
+		gseprocReq.ProcID = opGseProcInfo.ProcID
+		gseprocReq.Hosts = hostInfoArr
 		gseReqArr = append(gseReqArr, gseprocReq)
 	}
 
@@ -222,13 +226,21 @@ func (lgc *Logics) getOperateProcInstanceData(ctx context.Context, procOp *metad
 }
 
 func (lgc *Logics) OperateProcInstanceByGse(ctx context.Context, procOp *metadata.ProcessOperate, instModels map[string]*metadata.ProcInstanceModel) (string, error) {
-
 	ccTaskID := getTaskID()
+	if err := lgc.operateProcInstanceByGse(ctx, procOp, instModels, ccTaskID); nil != err {
+		return "", err
+	}
+	return ccTaskID, nil
+}
+
+// operateProcInstanceByGse send the operation of the process instances to gse, the result of
+// every gse task is recorded with the ccTaskID
+func (lgc *Logics) operateProcInstanceByGse(ctx context.Context, procOp *metadata.ProcessOperate, instModels map[string]*metadata.ProcInstanceModel, ccTaskID string) error {
 	opProcInsts := make([]*metadata.ProcessOperateTask, 0)
 
 	gseReqArr, err := lgc.getOperateProcInstanceData(ctx, procOp, instModels)
 	if nil != err {
-		return "", err
+		return err
 	}
 
 	mustNeedHeader := getMustNeedHeader(lgc.header)
@@ -255,8 +267,11 @@ func (lgc *Logics) OperateProcInstanceByGse(ctx context.Context, procOp *metadat
 			}
 		}
 
-		taskID, ok := gseRsp.Data[common.BKGseTaskIDField].(string)
-		if !ok {
+		taskID := ""
+		if nil == err {
+			taskID, _ = gseRsp.Data[common.BKGseTaskIDField].(string)
+		}
+		if nil == err && gseRsp.Result && "" == taskID {
 			blog.Warnf("OperateProcInstanceByGse convert gse process operate taskid to string failed. value: %v,rid:%s", gseRsp.Data, lgc.rid)
 			status = metadata.ProcOpTaskStatusNotTaskIDErr
 			detail["not_foud_gse_task_id"] = metadata.ProcessOperateTaskDetail{
@@ -274,6 +289,7 @@ func (lgc *Logics) OperateProcInstanceByGse(ctx context.Context, procOp *metadat
 			Host:        gseReq.Hosts,
 			ProcName:    gseReq.Meta.Name,
 			Detail:      detail,
+			HTTPHeader:  mustNeedHeader,
 		})
 		cacheTaskInfo.GseTaskIDArr = append(cacheTaskInfo.GseTaskIDArr, taskID)
 	}
@@ -282,25 +298,25 @@ func (lgc *Logics) OperateProcInstanceByGse(ctx context.Context, procOp *metadat
 		ret, err := lgc.CoreAPI.ProcController().AddOperateTaskInfo(ctx, lgc.header, opProcInsts)
 		if nil != err {
 			blog.Errorf("OperateProcInstanceByGse AddOperateTaskInfo http do  error:%s, input:%+v,rid:%s", err.Error(), procOp, lgc.rid)
-			return "", lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
 		}
 		if !ret.Result {
 			blog.Errorf("OperateProcInstanceByGse AddOperateTaskInfo  error:%s, input:%+v,rid:%s", ret.Result, procOp, lgc.rid)
-			return "", lgc.ccErr.New(ret.Code, ret.ErrMsg)
+			return lgc.ccErr.New(ret.Code, ret.ErrMsg)
 		}
 		cacheInfoStr, err := json.Marshal(cacheTaskInfo)
 		if nil != err {
 			blog.Errorf("OperateProcInstanceByGse cache OperateTaskInfo json marshal error:%s, logID:%s", err.Error(), lgc.rid)
-			return "", err
+			return err
 		}
 		_, err = lgc.cache.SAdd(common.RedisProcSrvQueryProcOPResultKey, string(cacheInfoStr)).Result()
 		if nil != err {
 			blog.Errorf("OperateProcInstanceByGse cache TaskIDInfo  error:%s, logID:%s", err.Error(), lgc.rid)
-			return "", lgc.ccErr.Errorf(common.CCErrCommUtilHandleFail, "redis sadd", err.Error())
+			return lgc.ccErr.Errorf(common.CCErrCommUtilHandleFail, "redis sadd", err.Error())
 		}
 	}
 
-	return ccTaskID, nil
+	return nil
 }
 
 func (lgc *Logics) QueryProcessOperateResult(ctx context.Context, taskID string) (succ, waitExec []string, exceErrMap map[string]string, err error) {
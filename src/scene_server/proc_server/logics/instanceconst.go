@@ -55,4 +55,9 @@ var (
 	timedTriggerLockExpire      time.Duration = time.Minute * 30
 	GETTASKIDSPOPINTERVAL       time.Duration = time.Second * 5
 	timedTriggerTaskTime        time.Duration = time.Minute * 20
+	procOpBatchTaskInterval     time.Duration = time.Second * 5
+	procOpBatchTimeout          time.Duration = time.Minute * 30
+	procOpBatchLockExpire       time.Duration = time.Minute * 5
+	procStateReconcileInterval  time.Duration = time.Minute
+	procStatePageSize           int           = 500
 )
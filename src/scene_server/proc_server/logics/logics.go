@@ -97,6 +97,8 @@ func (lgc *Logics) InitFunc(ctx context.Context) {
 	chnOpLock.Do(func() { lgc.bgHandle(ctx) })
 	// timed tigger refresh  host
 	go lgc.timedTriggerRefreshHostInstance(ctx)
+	// timed check the running state of the process instances
+	go lgc.timedTriggerReconcileProcState(ctx)
 
 }
 
@@ -111,4 +113,6 @@ type ProcHostInstConfig struct {
 	MaxRefreshModuleCount        int
 	GetModuleIDInterval          time.Duration
 	FetchGseOPProcResultInterval time.Duration
+	// ProcStateInterval the interval to check the running state of the process instances
+	ProcStateInterval time.Duration
 }
@@ -0,0 +1,436 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"gopkg.in/redis.v5"
+)
+
+// unlockProcOpBatchTaskScript delete the task lock only if it's still held by the value, so that
+// a lock expired and taken by others is never released by the previous holder
+var unlockProcOpBatchTaskScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// checkProcOpBatchStrategy check the batch strategy, return the reason if it's invalid
+func checkProcOpBatchStrategy(strategy *metadata.ProcOpBatchStrategy) string {
+	switch {
+	case strategy.Size < 0:
+		return "size can not be negative"
+	case strategy.Percent < 0 || strategy.Percent > 100:
+		return "percent must be between 0 and 100"
+	case strategy.Interval < 0:
+		return "interval can not be negative"
+	case strategy.MaxFailures < 0:
+		return "max_failures can not be negative"
+	}
+	return ""
+}
+
+// splitProcOpBatches split the process instances into batches by the strategy, all the instances
+// are in one batch if neither the size nor the percent is set
+func splitProcOpBatches(insts []metadata.ProcInstanceModel, strategy *metadata.ProcOpBatchStrategy) []metadata.ProcOpBatch {
+	sort.Slice(insts, func(i, j int) bool {
+		if insts[i].HostID != insts[j].HostID {
+			return insts[i].HostID < insts[j].HostID
+		}
+		if insts[i].ModuleID != insts[j].ModuleID {
+			return insts[i].ModuleID < insts[j].ModuleID
+		}
+		if insts[i].ProcID != insts[j].ProcID {
+			return insts[i].ProcID < insts[j].ProcID
+		}
+		return insts[i].ProcInstanceID < insts[j].ProcInstanceID
+	})
+
+	size := len(insts)
+	if strategy.Size > 0 {
+		size = strategy.Size
+	} else if strategy.Percent > 0 {
+		size = (len(insts)*strategy.Percent + 99) / 100
+	}
+	if size <= 0 {
+		size = 1
+	}
+
+	batches := make([]metadata.ProcOpBatch, 0)
+	for start := 0; start < len(insts); start += size {
+		end := start + size
+		if end > len(insts) {
+			end = len(insts)
+		}
+		batches = append(batches, metadata.ProcOpBatch{
+			Index:     len(batches),
+			Status:    metadata.ProcOpBatchStatusWaiting,
+			Instances: insts[start:end],
+		})
+	}
+	return batches
+}
+
+// CreateProcOpBatchTask create a rolling task to operate the process instances batch by batch,
+// the first batch is executed at once, and the others are executed in the background
+func (lgc *Logics) CreateProcOpBatchTask(ctx context.Context, procOp *metadata.ProcessOperate, instModels map[string]*metadata.ProcInstanceModel) (string, error) {
+	if reason := checkProcOpBatchStrategy(procOp.Batch); "" != reason {
+		blog.Errorf("CreateProcOpBatchTask invalid batch strategy %+v, %s,rid:%s", procOp.Batch, reason, lgc.rid)
+		return "", lgc.ccErr.Errorf(common.CCErrProcOpBatchStrategyInvalid, reason)
+	}
+
+	insts := make([]metadata.ProcInstanceModel, 0, len(instModels))
+	for _, inst := range instModels {
+		insts = append(insts, *inst)
+	}
+
+	task := &metadata.ProcOpBatchTask{
+		TaskID:      getTaskID(),
+		AppID:       procOp.ApplicationID,
+		OperateInfo: procOp,
+		Status:      metadata.ProcOpBatchTaskStatusRunning,
+		NextTime:    time.Now().UTC(),
+		Batches:     splitProcOpBatches(insts, procOp.Batch),
+		HTTPHeader:  getMustNeedHeader(lgc.header),
+	}
+	if 0 == len(task.Batches) {
+		task.Status = metadata.ProcOpBatchTaskStatusFinished
+	}
+	ret, err := lgc.CoreAPI.ProcController().AddOperateBatchTask(ctx, lgc.header, task)
+	if nil != err {
+		blog.Errorf("CreateProcOpBatchTask AddOperateBatchTask http do error:%s, input:%+v,rid:%s", err.Error(), procOp, lgc.rid)
+		return "", lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("CreateProcOpBatchTask AddOperateBatchTask http reply error:%s, input:%+v,rid:%s", ret.ErrMsg, procOp, lgc.rid)
+		return "", lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+
+	if metadata.ProcOpBatchTaskStatusRunning == task.Status {
+		// the background loop will retry if the first batch is not started here
+		if err := lgc.runProcOpBatchTask(ctx, task.AppID, task.TaskID, lgc.advanceProcOpBatchTask); nil != err {
+			blog.Warnf("CreateProcOpBatchTask start task %s failed, err:%s,rid:%s", task.TaskID, err.Error(), lgc.rid)
+		}
+	}
+	return task.TaskID, nil
+}
+
+// GetProcOpBatchTask get the rolling process operate task of the business, the tasks of the other
+// businesses are taken as not found.
+func (lgc *Logics) GetProcOpBatchTask(ctx context.Context, bizID int64, taskID string) (*metadata.ProcOpBatchTask, error) {
+	dat := &metadata.QueryInput{
+		Condition: mapstr.MapStr{common.BKTaskIDField: taskID},
+		Limit:     1,
+	}
+	ret, err := lgc.CoreAPI.ProcController().SearchOperateBatchTask(ctx, lgc.header, dat)
+	if nil != err {
+		blog.Errorf("GetProcOpBatchTask SearchOperateBatchTask http do error:%s, taskID:%s,rid:%s", err.Error(), taskID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("GetProcOpBatchTask SearchOperateBatchTask http reply error:%s, taskID:%s,rid:%s", ret.ErrMsg, taskID, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	if 0 == len(ret.Data.Info) {
+		blog.Errorf("GetProcOpBatchTask task %s not found,rid:%s", taskID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrProcOpBatchTaskNotFound)
+	}
+	if bizID != ret.Data.Info[0].AppID {
+		blog.Errorf("GetProcOpBatchTask task %s is in business %d, not %d,rid:%s", taskID, ret.Data.Info[0].AppID, bizID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrProcOpBatchTaskNotFound)
+	}
+	return &ret.Data.Info[0], nil
+}
+
+// PauseProcOpBatchTask stop starting new batches, the executing batch is not affected
+func (lgc *Logics) PauseProcOpBatchTask(ctx context.Context, bizID int64, taskID string) error {
+	return lgc.runProcOpBatchTask(ctx, bizID, taskID, func(ctx context.Context, task *metadata.ProcOpBatchTask) error {
+		if metadata.ProcOpBatchTaskStatusRunning != task.Status {
+			return lgc.ccErr.Errorf(common.CCErrProcOpBatchTaskStatusWrong, task.Status, "pause")
+		}
+		task.Status = metadata.ProcOpBatchTaskStatusPaused
+		task.Message = fmt.Sprintf("paused by %s", lgc.user)
+		return lgc.saveProcOpBatchTask(ctx, task)
+	})
+}
+
+// ResumeProcOpBatchTask continue the paused task, the failures are counted from zero again
+func (lgc *Logics) ResumeProcOpBatchTask(ctx context.Context, bizID int64, taskID string) error {
+	return lgc.runProcOpBatchTask(ctx, bizID, taskID, func(ctx context.Context, task *metadata.ProcOpBatchTask) error {
+		if metadata.ProcOpBatchTaskStatusPaused != task.Status {
+			return lgc.ccErr.Errorf(common.CCErrProcOpBatchTaskStatusWrong, task.Status, "resume")
+		}
+		task.Status = metadata.ProcOpBatchTaskStatusRunning
+		task.Failures = 0
+		task.NextTime = time.Now().UTC()
+		task.Message = fmt.Sprintf("resumed by %s", lgc.user)
+		return lgc.saveProcOpBatchTask(ctx, task)
+	})
+}
+
+// AbortProcOpBatchTask stop the task, the batches not started will never be executed
+func (lgc *Logics) AbortProcOpBatchTask(ctx context.Context, bizID int64, taskID string) error {
+	return lgc.runProcOpBatchTask(ctx, bizID, taskID, func(ctx context.Context, task *metadata.ProcOpBatchTask) error {
+		if metadata.ProcOpBatchTaskStatusRunning != task.Status && metadata.ProcOpBatchTaskStatusPaused != task.Status {
+			return lgc.ccErr.Errorf(common.CCErrProcOpBatchTaskStatusWrong, task.Status, "abort")
+		}
+		task.Status = metadata.ProcOpBatchTaskStatusAborted
+		task.Message = fmt.Sprintf("aborted by %s", lgc.user)
+		return lgc.saveProcOpBatchTask(ctx, task)
+	})
+}
+
+// runProcOpBatchTask load the task of the business and call the handle with the task locked, so
+// that the background loop and the api never change the same task at the same time
+func (lgc *Logics) runProcOpBatchTask(ctx context.Context, bizID int64, taskID string, handle func(ctx context.Context, task *metadata.ProcOpBatchTask) error) error {
+	lockKey := common.RedisProcSrvOpBatchTaskLockKeyPrefix + taskID
+	lockValue := lgc.rid
+	if lockValue == "" {
+		lockValue = util.GenerateRID()
+	}
+	locked := false
+	for retry := 0; retry < 10 && !locked; retry++ {
+		if 0 < retry {
+			time.Sleep(time.Millisecond * 200)
+		}
+		var err error
+		locked, err = lgc.cache.SetNX(lockKey, lockValue, procOpBatchLockExpire).Result()
+		if nil != err {
+			blog.Errorf("runProcOpBatchTask lock task %s error:%s,rid:%s", taskID, err.Error(), lgc.rid)
+			return lgc.ccErr.Errorf(common.CCErrCommUtilHandleFail, "redis setnx", err.Error())
+		}
+	}
+	if !locked {
+		blog.Errorf("runProcOpBatchTask task %s is locked by others,rid:%s", taskID, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommUtilHandleFail, "lock task", taskID)
+	}
+	defer func() {
+		if err := unlockProcOpBatchTaskScript.Run(lgc.cache, []string{lockKey}, lockValue).Err(); nil != err {
+			blog.Warnf("runProcOpBatchTask unlock task %s error:%s,rid:%s", taskID, err.Error(), lgc.rid)
+		}
+	}()
+
+	task, err := lgc.GetProcOpBatchTask(ctx, bizID, taskID)
+	if nil != err {
+		return err
+	}
+	return handle(ctx, task)
+}
+
+func (lgc *Logics) saveProcOpBatchTask(ctx context.Context, task *metadata.ProcOpBatchTask) error {
+	ret, err := lgc.CoreAPI.ProcController().UpdateOperateBatchTask(ctx, lgc.header, task)
+	if nil != err {
+		blog.Errorf("saveProcOpBatchTask UpdateOperateBatchTask http do error:%s, taskID:%s,rid:%s", err.Error(), task.TaskID, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("saveProcOpBatchTask UpdateOperateBatchTask http reply error:%s, taskID:%s,rid:%s", ret.ErrMsg, task.TaskID, lgc.rid)
+		return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return nil
+}
+
+// advanceProcOpBatchTask start the current batch if it's waiting, or collect its result if
+// it's executing. the task is paused once the failures are more than the max failures.
+func (lgc *Logics) advanceProcOpBatchTask(ctx context.Context, task *metadata.ProcOpBatchTask) error {
+	now := time.Now().UTC()
+	if metadata.ProcOpBatchTaskStatusRunning != task.Status || now.Before(task.NextTime) ||
+		task.CurrentBatch >= len(task.Batches) {
+		return nil
+	}
+
+	batch := &task.Batches[task.CurrentBatch]
+	switch batch.Status {
+	case metadata.ProcOpBatchStatusWaiting:
+		lgc.startProcOpBatch(ctx, task, batch)
+		if metadata.ProcOpBatchStatusExecuting == batch.Status {
+			return lgc.saveProcOpBatchTask(ctx, task)
+		}
+	case metadata.ProcOpBatchStatusExecuting:
+		done, err := lgc.collectProcOpBatchResult(ctx, batch)
+		if nil != err {
+			return err
+		}
+		if !done {
+			return nil
+		}
+	}
+
+	// the current batch is finished
+	task.Failures += batch.Failures
+	if task.CurrentBatch == len(task.Batches)-1 {
+		task.Status = metadata.ProcOpBatchTaskStatusFinished
+		return lgc.saveProcOpBatchTask(ctx, task)
+	}
+	task.CurrentBatch++
+	task.NextTime = now.Add(time.Duration(task.OperateInfo.Batch.Interval) * time.Second)
+	if task.Failures > task.OperateInfo.Batch.MaxFailures {
+		task.Status = metadata.ProcOpBatchTaskStatusPaused
+		task.Message = fmt.Sprintf("paused automatically, %d instances failed", task.Failures)
+	}
+	return lgc.saveProcOpBatchTask(ctx, task)
+}
+
+// startProcOpBatch send the operation of the instances in the batch to gse, all the instances
+// are failed if the operation can not be sent
+func (lgc *Logics) startProcOpBatch(ctx context.Context, task *metadata.ProcOpBatchTask, batch *metadata.ProcOpBatch) {
+	now := time.Now().UTC()
+	batch.StartTime = &now
+	batch.TaskID = fmt.Sprintf("%s:%d", task.TaskID, batch.Index)
+	instModels := make(map[string]*metadata.ProcInstanceModel, len(batch.Instances))
+	for idx := range batch.Instances {
+		inst := &batch.Instances[idx]
+		instModels[fmt.Sprintf("%d-%d-%d", inst.ModuleID, inst.ProcID, inst.ProcInstanceID)] = inst
+	}
+
+	if err := lgc.operateProcInstanceByGse(ctx, task.OperateInfo, instModels, batch.TaskID); nil != err {
+		blog.Errorf("startProcOpBatch operate batch %d of task %s failed, err:%s,rid:%s", batch.Index, task.TaskID, err.Error(), lgc.rid)
+		batch.Status = metadata.ProcOpBatchStatusFailed
+		batch.Failures = len(batch.Instances)
+		batch.Errors = map[string]string{"operate": err.Error()}
+		batch.EndTime = &now
+		return
+	}
+	batch.Status = metadata.ProcOpBatchStatusExecuting
+}
+
+// collectProcOpBatchResult query the operate result of the batch from gse, return true if all
+// the gse tasks of the batch are finished or the batch is timeout
+func (lgc *Logics) collectProcOpBatchResult(ctx context.Context, batch *metadata.ProcOpBatch) (bool, error) {
+	waitExecArr, _, requestErr := lgc.handleOPProcTask(ctx, batch.TaskID)
+	timeout := nil != batch.StartTime && time.Since(*batch.StartTime) > procOpBatchTimeout
+	if !timeout && (nil != requestErr || 0 < len(waitExecArr)) {
+		return false, nil
+	}
+
+	dat := &metadata.QueryInput{
+		Condition: mapstr.MapStr{common.BKTaskIDField: batch.TaskID},
+		Limit:     common.BKNoLimit,
+	}
+	ret, err := lgc.CoreAPI.ProcController().SearchOperateTaskInfo(ctx, lgc.header, dat)
+	if nil != err {
+		blog.Errorf("collectProcOpBatchResult SearchOperateTaskInfo http do error:%s, taskID:%s,rid:%s", err.Error(), batch.TaskID, lgc.rid)
+		return false, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("collectProcOpBatchResult SearchOperateTaskInfo http reply error:%s, taskID:%s,rid:%s", ret.ErrMsg, batch.TaskID, lgc.rid)
+		return false, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+
+	errs := make(map[string]string)
+	for _, record := range ret.Data.Info {
+		pending := metadata.ProcOpTaskStatusWaitOP == record.Status || metadata.ProcOpTaskStatusExecuteing == record.Status
+		if pending && !timeout {
+			return false, nil
+		}
+		for key, msg := range procOpTaskFailures(&record) {
+			errs[key] = msg
+		}
+	}
+
+	now := time.Now().UTC()
+	batch.EndTime = &now
+	batch.Failures = len(errs)
+	batch.Errors = errs
+	batch.Status = metadata.ProcOpBatchStatusSucceeded
+	if 0 < batch.Failures {
+		batch.Status = metadata.ProcOpBatchStatusFailed
+	}
+	return true, nil
+}
+
+// procOpTaskFailures get the failed instances of a gse operate task record, the key is the key in
+// the gse result, or the host ip if the operation is not accepted by gse at all
+func procOpTaskFailures(record *metadata.ProcessOperateTask) map[string]string {
+	failures := make(map[string]string)
+	if metadata.ProcOpTaskStatusSucc == record.Status {
+		return failures
+	}
+
+	if "" != record.GseTaskID && metadata.ProcOpTaskStatusErr == record.Status {
+		for key, detail := range record.Detail {
+			if 0 != detail.Errcode && int(metadata.ProcOpTaskStatusExecuteing) != detail.Errcode {
+				failures[key] = detail.ErrMsg
+			}
+		}
+		if 0 < len(failures) {
+			return failures
+		}
+	}
+
+	msg := fmt.Sprintf("operate task status %d", record.Status)
+	for _, detail := range record.Detail {
+		if 0 != detail.Errcode {
+			msg = detail.ErrMsg
+		}
+	}
+	for _, host := range record.Host {
+		failures[fmt.Sprintf("%d:%s:%s:%s", host.BkCloudId, host.Ip, record.Namespace, record.ProcName)] = msg
+	}
+	if 0 == len(failures) {
+		failures[fmt.Sprintf("%s:%s", record.Namespace, record.ProcName)] = msg
+	}
+	return failures
+}
+
+// timedTriggerProcOpBatchTask advance all the running rolling process operate tasks
+func (lgc *Logics) timedTriggerProcOpBatchTask(ctx context.Context) {
+	ticker := time.NewTicker(procOpBatchTaskInterval)
+	for range ticker.C {
+		header := make(http.Header, 0)
+		header.Set(common.BKHTTPOwnerID, common.BKSuperOwnerID)
+		header.Set(common.BKHTTPHeaderUser, common.BKProcInstanceOpUser)
+		newLgc := lgc.NewFromHeader(header)
+
+		dat := &metadata.QueryInput{
+			Condition: mapstr.MapStr{common.BKStatusField: metadata.ProcOpBatchTaskStatusRunning},
+			Fields:    fmt.Sprintf("%s,%s,%s,%s", common.BKTaskIDField, common.BKAppIDField, metadata.ProcOpBatchTaskFieldNextTime, "http_header"),
+			Limit:     common.BKNoLimit,
+		}
+		rsp, err := newLgc.CoreAPI.ProcController().SearchOperateBatchTask(ctx, newLgc.header, dat)
+		if nil != err {
+			blog.Warnf("timedTriggerProcOpBatchTask http do error:%s,rid:%s", err.Error(), newLgc.rid)
+			continue
+		}
+		if !rsp.Result {
+			blog.Warnf("timedTriggerProcOpBatchTask http reply error:%s,rid:%s", rsp.ErrMsg, newLgc.rid)
+			continue
+		}
+
+		now := time.Now().UTC()
+		for _, task := range rsp.Data.Info {
+			if now.Before(task.NextTime) {
+				continue
+			}
+			if nil == task.HTTPHeader {
+				task.HTTPHeader = header
+			}
+			taskLgc := lgc.NewFromHeader(task.HTTPHeader)
+			if err := taskLgc.runProcOpBatchTask(ctx, task.AppID, task.TaskID, taskLgc.advanceProcOpBatchTask); nil != err {
+				blog.Warnf("timedTriggerProcOpBatchTask advance task %s error:%s,rid:%s", task.TaskID, err.Error(), taskLgc.rid)
+			}
+		}
+	}
+}
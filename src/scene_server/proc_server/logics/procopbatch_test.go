@@ -0,0 +1,160 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/proccontroller"
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestSplitProcOpBatches(t *testing.T) {
+	type testData struct {
+		count    int
+		strategy metadata.ProcOpBatchStrategy
+		sizes    []int
+	}
+
+	td := []testData{
+		{count: 10, strategy: metadata.ProcOpBatchStrategy{Size: 3}, sizes: []int{3, 3, 3, 1}},
+		{count: 10, strategy: metadata.ProcOpBatchStrategy{Size: 20}, sizes: []int{10}},
+		{count: 10, strategy: metadata.ProcOpBatchStrategy{Percent: 25}, sizes: []int{3, 3, 3, 1}},
+		{count: 400, strategy: metadata.ProcOpBatchStrategy{Percent: 10}, sizes: []int{40, 40, 40, 40, 40, 40, 40, 40, 40, 40}},
+		{count: 3, strategy: metadata.ProcOpBatchStrategy{Percent: 1}, sizes: []int{1, 1, 1}},
+		{count: 10, strategy: metadata.ProcOpBatchStrategy{Size: 5, Percent: 50}, sizes: []int{5, 5}},
+		{count: 4, strategy: metadata.ProcOpBatchStrategy{}, sizes: []int{4}},
+		{count: 0, strategy: metadata.ProcOpBatchStrategy{Size: 2}, sizes: []int{}},
+	}
+
+	for _, item := range td {
+		insts := make([]metadata.ProcInstanceModel, 0)
+		for i := item.count; i > 0; i-- {
+			insts = append(insts, metadata.ProcInstanceModel{HostID: int64(i), ProcInstanceID: uint64(i)})
+		}
+		batches := splitProcOpBatches(insts, &item.strategy)
+		if len(batches) != len(item.sizes) {
+			t.Errorf("split %d instances by %+v, expect %d batches, got %d", item.count, item.strategy, len(item.sizes), len(batches))
+			continue
+		}
+		hostID := int64(0)
+		for idx, batch := range batches {
+			if batch.Index != idx || batch.Status != metadata.ProcOpBatchStatusWaiting || len(batch.Instances) != item.sizes[idx] {
+				t.Errorf("split %d instances by %+v, batch %d is wrong: %+v", item.count, item.strategy, idx, batch)
+			}
+			for _, inst := range batch.Instances {
+				if inst.HostID <= hostID {
+					t.Errorf("split %d instances by %+v, instances are not sorted by host", item.count, item.strategy)
+				}
+				hostID = inst.HostID
+			}
+		}
+	}
+}
+
+func TestProcOpTaskFailures(t *testing.T) {
+	hosts := []metadata.GseHost{{Ip: "127.0.0.1"}, {Ip: "127.0.0.2"}}
+
+	record := &metadata.ProcessOperateTask{Status: metadata.ProcOpTaskStatusSucc, Host: hosts}
+	if failures := procOpTaskFailures(record); 0 != len(failures) {
+		t.Errorf("succeeded task should not have failures, got %v", failures)
+	}
+
+	record = &metadata.ProcessOperateTask{
+		Status:    metadata.ProcOpTaskStatusErr,
+		GseTaskID: "gse-task",
+		Host:      hosts,
+		Detail: map[string]metadata.ProcessOperateTaskDetail{
+			"0:127.0.0.1:1.1:nginx": {Errcode: 0},
+			"0:127.0.0.2:1.1:nginx": {Errcode: 2, ErrMsg: "start failed"},
+		},
+	}
+	failures := procOpTaskFailures(record)
+	if 1 != len(failures) || "start failed" != failures["0:127.0.0.2:1.1:nginx"] {
+		t.Errorf("only the failed instance should be returned, got %v", failures)
+	}
+
+	record = &metadata.ProcessOperateTask{
+		Status:    metadata.ProcOpTaskStatusHTTPErr,
+		Namespace: "1.1",
+		ProcName:  "nginx",
+		Host:      hosts,
+		Detail: map[string]metadata.ProcessOperateTaskDetail{
+			"http_request_error": {Errcode: 1, ErrMsg: "timeout"},
+		},
+	}
+	failures = procOpTaskFailures(record)
+	if 2 != len(failures) || "timeout" != failures["0:127.0.0.1:1.1:nginx"] {
+		t.Errorf("all the hosts should be failed if gse does not accept the operation, got %v", failures)
+	}
+}
+
+type fakeProcCtrlClientSet struct {
+	apimachinery.ClientSetInterface
+	tasks []metadata.ProcOpBatchTask
+}
+
+func (c *fakeProcCtrlClientSet) ProcController() proccontroller.ProcCtrlClientInterface {
+	return &fakeProcCtrlClient{tasks: c.tasks}
+}
+
+type fakeProcCtrlClient struct {
+	proccontroller.ProcCtrlClientInterface
+	tasks []metadata.ProcOpBatchTask
+}
+
+func (c *fakeProcCtrlClient) SearchOperateBatchTask(ctx context.Context, h http.Header, dat *metadata.QueryInput) (*metadata.ProcOpBatchTaskResult, error) {
+	result := &metadata.ProcOpBatchTaskResult{BaseResp: metadata.SuccessBaseResp}
+	for _, task := range c.tasks {
+		if task.TaskID == dat.Condition.(mapstr.MapStr)[common.BKTaskIDField] {
+			result.Data.Info = append(result.Data.Info, task)
+		}
+	}
+	return result, nil
+}
+
+func TestGetProcOpBatchTask(t *testing.T) {
+	lgc := &Logics{
+		Engine: &backbone.Engine{CoreAPI: &fakeProcCtrlClientSet{tasks: []metadata.ProcOpBatchTask{{TaskID: "task", AppID: 2}}}},
+		header: http.Header{},
+		ccErr:  errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+	}
+
+	tests := []struct {
+		name   string
+		bizID  int64
+		taskID string
+		code   int
+	}{
+		{"task of the business", 2, "task", 0},
+		{"task of another business", 3, "task", common.CCErrProcOpBatchTaskNotFound},
+		{"task not found", 2, "other", common.CCErrProcOpBatchTaskNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := lgc.GetProcOpBatchTask(context.Background(), tt.bizID, tt.taskID)
+			assertErrorCode(t, err, tt.code)
+			if tt.code == 0 && task.TaskID != tt.taskID {
+				t.Errorf("GetProcOpBatchTask() = %+v, want task %s", task, tt.taskID)
+			}
+		})
+	}
+}
@@ -0,0 +1,538 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+const procBindIPAllAddr = "0.0.0.0"
+
+type procPortRange struct {
+	start int
+	end   int
+}
+
+// parseProcPortRanges parse the port attribute of the process, eg: 8080, 8000-8010, 8000-8010,8080
+func parseProcPortRanges(port string) ([]procPortRange, error) {
+	port = strings.TrimSpace(port)
+	if "" == port {
+		return nil, nil
+	}
+	ranges := make([]procPortRange, 0)
+	for _, item := range strings.Split(port, ",") {
+		item = strings.TrimSpace(item)
+		parts := strings.SplitN(item, "-", 2)
+		start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if nil != err {
+			return nil, fmt.Errorf("port %s is not a number", item)
+		}
+		end := start
+		if 2 == len(parts) {
+			end, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+			if nil != err {
+				return nil, fmt.Errorf("port %s is not a number", item)
+			}
+		}
+		if start < 1 || end > 65535 || start > end {
+			return nil, fmt.Errorf("port %s out of range", item)
+		}
+		ranges = append(ranges, procPortRange{start: start, end: end})
+	}
+
+	// merge the overlapped ranges
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	merged := []procPortRange{ranges[0]}
+	for _, item := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if item.start <= last.end+1 {
+			if item.end > last.end {
+				last.end = item.end
+			}
+			continue
+		}
+		merged = append(merged, item)
+	}
+	return merged, nil
+}
+
+// ValidateProcPort check whether the port attribute of the process is valid
+func ValidateProcPort(port string) error {
+	_, err := parseProcPortRanges(port)
+	return err
+}
+
+func overlapProcPortRanges(a, b []procPortRange) []procPortRange {
+	overlap := make([]procPortRange, 0)
+	for _, x := range a {
+		for _, y := range b {
+			start, end := x.start, x.end
+			if y.start > start {
+				start = y.start
+			}
+			if y.end < end {
+				end = y.end
+			}
+			if start <= end {
+				overlap = append(overlap, procPortRange{start: start, end: end})
+			}
+		}
+	}
+	return overlap
+}
+
+func formatProcPortRanges(ranges []procPortRange) string {
+	items := make([]string, 0, len(ranges))
+	for _, item := range ranges {
+		if item.start == item.end {
+			items = append(items, strconv.Itoa(item.start))
+		} else {
+			items = append(items, fmt.Sprintf("%d-%d", item.start, item.end))
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// normalizeProcProtocol the process listens on tcp when the protocol is not set
+func normalizeProcProtocol(protocol string) string {
+	switch strings.ToUpper(strings.TrimSpace(protocol)) {
+	case "", metadata.ProcProtocolTCP, "TCP":
+		return metadata.ProcProtocolTCP
+	case metadata.ProcProtocolUDP, "UDP":
+		return metadata.ProcProtocolUDP
+	}
+	return protocol
+}
+
+func firstIP(ips string) string {
+	return strings.TrimSpace(strings.Split(ips, ",")[0])
+}
+
+// resolveProcBindIP get the address the process listens on the host,
+// the process listens on all addresses when the bind ip is not set
+func resolveProcBindIP(bindIP, innerIP, outerIP string) string {
+	switch strings.TrimSpace(bindIP) {
+	case "", metadata.ProcBindIPAll, procBindIPAllAddr:
+		return procBindIPAllAddr
+	case metadata.ProcBindIPLocalhost:
+		return "127.0.0.1"
+	case metadata.ProcBindIPInnerIP, "第一内网IP":
+		if ip := firstIP(innerIP); "" != ip {
+			return ip
+		}
+		return metadata.ProcBindIPInnerIP
+	case metadata.ProcBindIPOuterIP, "第一外网IP", "第一公网IP":
+		if ip := firstIP(outerIP); "" != ip {
+			return ip
+		}
+		return metadata.ProcBindIPOuterIP
+	}
+	return strings.TrimSpace(bindIP)
+}
+
+type procPortHost struct {
+	hostID  int64
+	innerIP string
+	outerIP string
+}
+
+// findHostProcPortConflicts find the processes listen on the same address of the host
+func findHostProcPortConflicts(host procPortHost, bindings []metadata.ProcPortBinding) []metadata.ProcPortConflict {
+	type listen struct {
+		binding  metadata.ProcPortBinding
+		ip       string
+		protocol string
+		ports    []procPortRange
+	}
+	listens := make([]listen, 0, len(bindings))
+	for _, binding := range bindings {
+		ports, err := parseProcPortRanges(binding.Port)
+		if nil != err || 0 == len(ports) {
+			continue
+		}
+		listens = append(listens, listen{
+			binding:  binding,
+			ip:       resolveProcBindIP(binding.BindIP, host.innerIP, host.outerIP),
+			protocol: normalizeProcProtocol(binding.Protocol),
+			ports:    ports,
+		})
+	}
+	sort.Slice(listens, func(i, j int) bool { return listens[i].binding.ProcID < listens[j].binding.ProcID })
+
+	conflicts := make([]metadata.ProcPortConflict, 0)
+	for i := 0; i < len(listens); i++ {
+		for j := i + 1; j < len(listens); j++ {
+			a, b := listens[i], listens[j]
+			if a.protocol != b.protocol {
+				continue
+			}
+			if a.ip != b.ip && procBindIPAllAddr != a.ip && procBindIPAllAddr != b.ip {
+				continue
+			}
+			overlap := overlapProcPortRanges(a.ports, b.ports)
+			if 0 == len(overlap) {
+				continue
+			}
+			ip := a.ip
+			if procBindIPAllAddr == ip {
+				ip = b.ip
+			}
+			conflicts = append(conflicts, metadata.ProcPortConflict{
+				HostID:    host.hostID,
+				InnerIP:   host.innerIP,
+				BindIP:    ip,
+				Protocol:  a.protocol,
+				Port:      formatProcPortRanges(overlap),
+				Processes: []metadata.ProcPortBinding{a.binding, b.binding},
+			})
+		}
+	}
+	return conflicts
+}
+
+func procPortConflictKey(conflict metadata.ProcPortConflict) string {
+	procIDs := make([]string, 0, len(conflict.Processes))
+	for _, proc := range conflict.Processes {
+		procIDs = append(procIDs, strconv.FormatInt(proc.ProcID, 10))
+	}
+	return fmt.Sprintf("%d|%s|%s|%s|%s", conflict.HostID, conflict.BindIP, conflict.Protocol, conflict.Port, strings.Join(procIDs, ","))
+}
+
+// newProcPortConflicts the conflicts exist after the change but not before, the
+// conflicts already exist are reported by the business port conflict report
+func newProcPortConflicts(before, after []metadata.ProcPortConflict) []metadata.ProcPortConflict {
+	exists := make(map[string]bool, len(before))
+	for _, conflict := range before {
+		exists[procPortConflictKey(conflict)] = true
+	}
+	conflicts := make([]metadata.ProcPortConflict, 0)
+	for _, conflict := range after {
+		if !exists[procPortConflictKey(conflict)] {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
+
+// procPortTopo the processes deployed on the hosts of a business
+type procPortTopo struct {
+	hosts       map[int64]procPortHost
+	hostModules map[int64][]int64
+	moduleNames map[int64]string
+	// moduleProcs the processes bound to the module name
+	moduleProcs map[string][]int64
+	procs       map[int64]metadata.ProcPortBinding
+}
+
+func (t *procPortTopo) clone() *procPortTopo {
+	c := &procPortTopo{
+		hosts:       t.hosts,
+		moduleNames: t.moduleNames,
+		hostModules: make(map[int64][]int64, len(t.hostModules)),
+		moduleProcs: make(map[string][]int64, len(t.moduleProcs)),
+		procs:       make(map[int64]metadata.ProcPortBinding, len(t.procs)),
+	}
+	for key, val := range t.hostModules {
+		c.hostModules[key] = val
+	}
+	for key, val := range t.moduleProcs {
+		c.moduleProcs[key] = val
+	}
+	for key, val := range t.procs {
+		c.procs[key] = val
+	}
+	return c
+}
+
+func (t *procPortTopo) hostProcIDs(hostID int64) []int64 {
+	procIDs := make([]int64, 0)
+	for _, moduleID := range t.hostModules[hostID] {
+		for _, procID := range t.moduleProcs[t.moduleNames[moduleID]] {
+			if !util.ContainsInt64(procIDs, procID) {
+				procIDs = append(procIDs, procID)
+			}
+		}
+	}
+	return procIDs
+}
+
+func (t *procPortTopo) conflicts(hostIDs []int64) []metadata.ProcPortConflict {
+	conflicts := make([]metadata.ProcPortConflict, 0)
+	for _, hostID := range hostIDs {
+		bindings := make([]metadata.ProcPortBinding, 0)
+		for _, procID := range t.hostProcIDs(hostID) {
+			if binding, ok := t.procs[procID]; ok {
+				bindings = append(bindings, binding)
+			}
+		}
+		host, ok := t.hosts[hostID]
+		if !ok {
+			host = procPortHost{hostID: hostID}
+		}
+		conflicts = append(conflicts, findHostProcPortConflicts(host, bindings)...)
+	}
+	return conflicts
+}
+
+// hostsWithProc the hosts the processes deployed on
+func (t *procPortTopo) hostsWithProc(procIDs []int64) []int64 {
+	hostIDs := make([]int64, 0)
+	for hostID := range t.hostModules {
+		for _, procID := range t.hostProcIDs(hostID) {
+			if util.ContainsInt64(procIDs, procID) {
+				hostIDs = append(hostIDs, hostID)
+				break
+			}
+		}
+	}
+	sort.Slice(hostIDs, func(i, j int) bool { return hostIDs[i] < hostIDs[j] })
+	return hostIDs
+}
+
+func (t *procPortTopo) allHosts() []int64 {
+	hostIDs := make([]int64, 0, len(t.hostModules))
+	for hostID := range t.hostModules {
+		hostIDs = append(hostIDs, hostID)
+	}
+	sort.Slice(hostIDs, func(i, j int) bool { return hostIDs[i] < hostIDs[j] })
+	return hostIDs
+}
+
+// getProcPortTopo get the processes deployed on the hosts of the business,
+// extraHostIDs are the hosts not in the business yet
+func (lgc *Logics) getProcPortTopo(ctx context.Context, appID int64, extraHostIDs []int64) (*procPortTopo, error) {
+	defErr := lgc.ccErr
+	topo := &procPortTopo{
+		hosts:       make(map[int64]procPortHost),
+		hostModules: make(map[int64][]int64),
+		moduleNames: make(map[int64]string),
+		moduleProcs: make(map[string][]int64),
+		procs:       make(map[int64]metadata.ProcPortBinding),
+	}
+
+	moduleCond := new(metadata.QueryCondition)
+	moduleCond.Condition = mapstr.MapStr{common.BKAppIDField: appID}
+	moduleCond.Fields = []string{common.BKModuleIDField, common.BKModuleNameField}
+	moduleCond.Limit.Limit = common.BKNoLimit
+	moduleRet, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, common.BKInnerObjIDModule, moduleCond)
+	if nil != err {
+		blog.Errorf("getProcPortTopo get module http do error. appID:%d, err:%s, rid:%s", appID, err.Error(), lgc.rid)
+		return nil, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !moduleRet.Result {
+		blog.Errorf("getProcPortTopo get module http reply error. appID:%d, err code:%d, err msg:%s, rid:%s", appID, moduleRet.Code, moduleRet.ErrMsg, lgc.rid)
+		return nil, defErr.New(moduleRet.Code, moduleRet.ErrMsg)
+	}
+	for _, module := range moduleRet.Data.Info {
+		moduleID, err := module.Int64(common.BKModuleIDField)
+		if nil != err {
+			blog.Warnf("getProcPortTopo module id not integer, module:%+v, rid:%s", module, lgc.rid)
+			continue
+		}
+		topo.moduleNames[moduleID] = util.GetStrByInterface(module[common.BKModuleNameField])
+	}
+
+	p2mRet, err := lgc.CoreAPI.ProcController().GetProc2Module(ctx, lgc.header, mapstr.MapStr{common.BKAppIDField: appID})
+	if nil != err {
+		blog.Errorf("getProcPortTopo GetProc2Module http do error. appID:%d, err:%s, rid:%s", appID, err.Error(), lgc.rid)
+		return nil, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !p2mRet.Result {
+		blog.Errorf("getProcPortTopo GetProc2Module http reply error. appID:%d, err code:%d, err msg:%s, rid:%s", appID, p2mRet.Code, p2mRet.ErrMsg, lgc.rid)
+		return nil, defErr.New(p2mRet.Code, p2mRet.ErrMsg)
+	}
+	for _, item := range p2mRet.Data {
+		topo.moduleProcs[item.ModuleName] = append(topo.moduleProcs[item.ModuleName], item.ProcessID)
+	}
+
+	procCond := new(metadata.QueryCondition)
+	procCond.Condition = mapstr.MapStr{common.BKAppIDField: appID}
+	procCond.Fields = []string{common.BKProcessIDField, common.BKProcessNameField, common.BKBindIP, common.BKProtocol, common.BKPort}
+	procCond.Limit.Limit = common.BKNoLimit
+	procRet, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, common.BKInnerObjIDProc, procCond)
+	if nil != err {
+		blog.Errorf("getProcPortTopo get process http do error. appID:%d, err:%s, rid:%s", appID, err.Error(), lgc.rid)
+		return nil, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !procRet.Result {
+		blog.Errorf("getProcPortTopo get process http reply error. appID:%d, err code:%d, err msg:%s, rid:%s", appID, procRet.Code, procRet.ErrMsg, lgc.rid)
+		return nil, defErr.New(procRet.Code, procRet.ErrMsg)
+	}
+	for _, proc := range procRet.Data.Info {
+		procID, err := proc.Int64(common.BKProcessIDField)
+		if nil != err {
+			blog.Warnf("getProcPortTopo process id not integer, process:%+v, rid:%s", proc, lgc.rid)
+			continue
+		}
+		topo.procs[procID] = metadata.ProcPortBinding{
+			ProcID:   procID,
+			ProcName: util.GetStrByInterface(proc[common.BKProcessNameField]),
+			BindIP:   util.GetStrByInterface(proc[common.BKBindIP]),
+			Protocol: util.GetStrByInterface(proc[common.BKProtocol]),
+			Port:     util.GetStrByInterface(proc[common.BKPort]),
+		}
+	}
+
+	configRet, err := lgc.CoreAPI.HostController().Module().GetModulesHostConfig(ctx, lgc.header, map[string][]int64{common.BKAppIDField: []int64{appID}})
+	if nil != err {
+		blog.Errorf("getProcPortTopo GetModulesHostConfig http do error. appID:%d, err:%s, rid:%s", appID, err.Error(), lgc.rid)
+		return nil, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !configRet.Result {
+		blog.Errorf("getProcPortTopo GetModulesHostConfig http reply error. appID:%d, err code:%d, err msg:%s, rid:%s", appID, configRet.Code, configRet.ErrMsg, lgc.rid)
+		return nil, defErr.New(configRet.Code, configRet.ErrMsg)
+	}
+	hostIDs := make([]int64, 0)
+	for _, item := range configRet.Data {
+		topo.hostModules[item.HostID] = append(topo.hostModules[item.HostID], item.ModuleID)
+		if !util.ContainsInt64(hostIDs, item.HostID) {
+			hostIDs = append(hostIDs, item.HostID)
+		}
+	}
+	for _, hostID := range extraHostIDs {
+		if !util.ContainsInt64(hostIDs, hostID) {
+			hostIDs = append(hostIDs, hostID)
+		}
+	}
+	if 0 == len(hostIDs) {
+		return topo, nil
+	}
+
+	opt := new(metadata.QueryInput)
+	opt.Condition = mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}}
+	opt.Fields = fmt.Sprintf("%s,%s,%s", common.BKHostIDField, common.BKHostInnerIPField, common.BKHostOuterIPField)
+	opt.Limit = common.BKNoLimit
+	hostRet, err := lgc.CoreAPI.HostController().Host().GetHosts(ctx, lgc.header, opt)
+	if nil != err {
+		blog.Errorf("getProcPortTopo GetHosts http do error. appID:%d, err:%s, rid:%s", appID, err.Error(), lgc.rid)
+		return nil, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !hostRet.Result {
+		blog.Errorf("getProcPortTopo GetHosts http reply error. appID:%d, err code:%d, err msg:%s, rid:%s", appID, hostRet.Code, hostRet.ErrMsg, lgc.rid)
+		return nil, defErr.New(hostRet.Code, hostRet.ErrMsg)
+	}
+	for _, host := range hostRet.Data.Info {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if nil != err {
+			blog.Warnf("getProcPortTopo host id not integer, host:%+v, rid:%s", host, lgc.rid)
+			continue
+		}
+		topo.hosts[hostID] = procPortHost{
+			hostID:  hostID,
+			innerIP: util.GetStrByInterface(host[common.BKHostInnerIPField]),
+			outerIP: util.GetStrByInterface(host[common.BKHostOuterIPField]),
+		}
+	}
+
+	return topo, nil
+}
+
+// CheckProcUpdatePortConflict check the port conflicts caused by updating the bind_ip, protocol or port of the processes
+func (lgc *Logics) CheckProcUpdatePortConflict(ctx context.Context, appID int64, procData map[int64]mapstr.MapStr) ([]metadata.ProcPortConflict, error) {
+	procIDs := make([]int64, 0, len(procData))
+	for procID, data := range procData {
+		if port, ok := data[common.BKPort]; ok {
+			if err := ValidateProcPort(util.GetStrByInterface(port)); nil != err {
+				return nil, lgc.ccErr.Errorf(common.CCErrProcPortInvalid, err.Error())
+			}
+		}
+		procIDs = append(procIDs, procID)
+	}
+
+	topo, err := lgc.getProcPortTopo(ctx, appID, nil)
+	if nil != err {
+		return nil, err
+	}
+	after := topo.clone()
+	for procID, data := range procData {
+		binding, ok := after.procs[procID]
+		if !ok {
+			continue
+		}
+		if val, ok := data[common.BKBindIP]; ok {
+			binding.BindIP = util.GetStrByInterface(val)
+		}
+		if val, ok := data[common.BKProtocol]; ok {
+			binding.Protocol = util.GetStrByInterface(val)
+		}
+		if val, ok := data[common.BKPort]; ok {
+			binding.Port = util.GetStrByInterface(val)
+		}
+		if val, ok := data[common.BKProcessNameField]; ok {
+			binding.ProcName = util.GetStrByInterface(val)
+		}
+		after.procs[procID] = binding
+	}
+
+	hostIDs := topo.hostsWithProc(procIDs)
+	return newProcPortConflicts(topo.conflicts(hostIDs), after.conflicts(hostIDs)), nil
+}
+
+// CheckProcBindModulePortConflict check the port conflicts caused by binding the process to the module
+func (lgc *Logics) CheckProcBindModulePortConflict(ctx context.Context, appID, procID int64, moduleName string) ([]metadata.ProcPortConflict, error) {
+	topo, err := lgc.getProcPortTopo(ctx, appID, nil)
+	if nil != err {
+		return nil, err
+	}
+	after := topo.clone()
+	procIDs := after.moduleProcs[moduleName]
+	if util.ContainsInt64(procIDs, procID) {
+		return make([]metadata.ProcPortConflict, 0), nil
+	}
+	after.moduleProcs[moduleName] = append(append(make([]int64, 0, len(procIDs)+1), procIDs...), procID)
+
+	hostIDs := after.hostsWithProc([]int64{procID})
+	return newProcPortConflicts(topo.conflicts(hostIDs), after.conflicts(hostIDs)), nil
+}
+
+// CheckHostTransferPortConflict check the port conflicts caused by transferring the hosts to the modules of the business
+func (lgc *Logics) CheckHostTransferPortConflict(ctx context.Context, appID int64, param *metadata.ProcPortCheckParam) ([]metadata.ProcPortConflict, error) {
+	topo, err := lgc.getProcPortTopo(ctx, appID, param.HostID)
+	if nil != err {
+		return nil, err
+	}
+	after := topo.clone()
+	for _, hostID := range param.HostID {
+		moduleIDs := make([]int64, 0)
+		if param.IsIncrement {
+			moduleIDs = append(moduleIDs, topo.hostModules[hostID]...)
+		}
+		for _, moduleID := range param.ModuleID {
+			if !util.ContainsInt64(moduleIDs, moduleID) {
+				moduleIDs = append(moduleIDs, moduleID)
+			}
+		}
+		after.hostModules[hostID] = moduleIDs
+	}
+
+	return newProcPortConflicts(topo.conflicts(param.HostID), after.conflicts(param.HostID)), nil
+}
+
+// GetBizProcPortConflicts get all the port conflicts of the processes on the hosts of the business
+func (lgc *Logics) GetBizProcPortConflicts(ctx context.Context, appID int64) ([]metadata.ProcPortConflict, error) {
+	topo, err := lgc.getProcPortTopo(ctx, appID, nil)
+	if nil != err {
+		return nil, err
+	}
+	return topo.conflicts(topo.allHosts()), nil
+}
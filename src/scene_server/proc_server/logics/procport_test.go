@@ -0,0 +1,98 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestParseProcPortRanges(t *testing.T) {
+	ranges, err := parseProcPortRanges(" 8080, 8000-8010,8005-8020 ")
+	if err != nil {
+		t.Fatalf("parse port failed, err: %v", err)
+	}
+	if "8000-8020,8080" != formatProcPortRanges(ranges) {
+		t.Errorf("expect 8000-8020,8080, got %s", formatProcPortRanges(ranges))
+	}
+	if ranges, err := parseProcPortRanges(""); err != nil || 0 != len(ranges) {
+		t.Errorf("empty port should be valid, got %v, err: %v", ranges, err)
+	}
+	for _, port := range []string{"abc", "0", "65536", "8010-8000", "8000-", "80,,81"} {
+		if _, err := parseProcPortRanges(port); err == nil {
+			t.Errorf("port %s should be invalid", port)
+		}
+	}
+}
+
+func TestFindHostProcPortConflicts(t *testing.T) {
+	host := procPortHost{hostID: 1, innerIP: "10.0.0.1", outerIP: ""}
+	bindings := []metadata.ProcPortBinding{
+		{ProcID: 3, ProcName: "c", BindIP: metadata.ProcBindIPInnerIP, Port: "8005"},
+		{ProcID: 1, ProcName: "a", BindIP: metadata.ProcBindIPAll, Protocol: metadata.ProcProtocolTCP, Port: "8000-8010"},
+		{ProcID: 2, ProcName: "b", BindIP: metadata.ProcBindIPAll, Protocol: metadata.ProcProtocolUDP, Port: "8000"},
+		{ProcID: 4, ProcName: "d", BindIP: metadata.ProcBindIPLocalhost, Port: "9000"},
+		{ProcID: 5, ProcName: "e", BindIP: metadata.ProcBindIPInnerIP, Port: "9000"},
+		{ProcID: 6, ProcName: "f", BindIP: metadata.ProcBindIPOuterIP, Port: "9000"},
+		{ProcID: 7, ProcName: "g", BindIP: metadata.ProcBindIPOuterIP, Port: "9000"},
+	}
+	conflicts := findHostProcPortConflicts(host, bindings)
+	if len(conflicts) != 2 {
+		t.Fatalf("expect 2 conflicts, got %+v", conflicts)
+	}
+	first := conflicts[0]
+	if first.Processes[0].ProcID != 1 || first.Processes[1].ProcID != 3 || first.BindIP != "10.0.0.1" || first.Port != "8005" || first.Protocol != metadata.ProcProtocolTCP {
+		t.Errorf("unexpected conflict %+v", first)
+	}
+	second := conflicts[1]
+	if second.Processes[0].ProcID != 6 || second.Processes[1].ProcID != 7 || second.BindIP != metadata.ProcBindIPOuterIP {
+		t.Errorf("unexpected conflict %+v", second)
+	}
+}
+
+func TestProcPortTopoConflicts(t *testing.T) {
+	topo := &procPortTopo{
+		hosts:       map[int64]procPortHost{1: {hostID: 1, innerIP: "10.0.0.1"}, 2: {hostID: 2, innerIP: "10.0.0.2"}},
+		hostModules: map[int64][]int64{1: {11}, 2: {12}},
+		moduleNames: map[int64]string{11: "web", 12: "db", 13: "web"},
+		moduleProcs: map[string][]int64{"web": {1}, "db": {2}},
+		procs: map[int64]metadata.ProcPortBinding{
+			1: {ProcID: 1, ProcName: "nginx", Port: "80"},
+			2: {ProcID: 2, ProcName: "httpd", Port: "80"},
+		},
+	}
+	if conflicts := topo.conflicts(topo.allHosts()); len(conflicts) != 0 {
+		t.Fatalf("expect no conflict, got %+v", conflicts)
+	}
+
+	// move host 2 to the web module as well
+	after := topo.clone()
+	after.hostModules[2] = []int64{12, 13}
+	hostIDs := []int64{2}
+	conflicts := newProcPortConflicts(topo.conflicts(hostIDs), after.conflicts(hostIDs))
+	if len(conflicts) != 1 || conflicts[0].HostID != 2 || conflicts[0].Port != "80" {
+		t.Fatalf("expect a conflict on host 2, got %+v", conflicts)
+	}
+	if len(topo.hostModules[2]) != 1 {
+		t.Errorf("the origin topo should not be changed, got %+v", topo.hostModules)
+	}
+
+	// the conflict already exists is not reported again
+	if conflicts := newProcPortConflicts(after.conflicts(hostIDs), after.conflicts(hostIDs)); len(conflicts) != 0 {
+		t.Errorf("expect no new conflict, got %+v", conflicts)
+	}
+	if hostIDs := after.hostsWithProc([]int64{1}); len(hostIDs) != 2 {
+		t.Errorf("expect process 1 on 2 hosts, got %v", hostIDs)
+	}
+}
@@ -0,0 +1,276 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/thirdpartyclient/esbserver/gse"
+)
+
+// ReconcileProcInstanceState check the state of all the process instances registered to gse,
+// and remove the states of the instances which are not registered any more
+func (lgc *Logics) ReconcileProcInstanceState(ctx context.Context, gseCli gse.GseClientInterface) error {
+	now := time.Now().UTC()
+	round := now.Unix()
+	for start := 0; ; start += procStatePageSize {
+		dat := &metadata.QueryInput{
+			Condition: mapstr.MapStr{common.BKStatusField: metadata.ProcInstanceDetailStatusRegisterSucc},
+			Start:     start,
+			Limit:     procStatePageSize,
+		}
+		ret, err := lgc.CoreAPI.ProcController().GetProcInstanceDetail(ctx, lgc.header, dat)
+		if nil != err {
+			blog.Errorf("ReconcileProcInstanceState GetProcInstanceDetail http do error:%s,input:%+v,rid:%s", err.Error(), dat, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !ret.Result {
+			blog.Errorf("ReconcileProcInstanceState GetProcInstanceDetail http reply error:%s,input:%+v,rid:%s", ret.ErrMsg, dat, lgc.rid)
+			return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+		}
+
+		states := lgc.checkProcInstanceState(ctx, gseCli, ret.Data.Info, now, round)
+		if 0 < len(states) {
+			setRet, err := lgc.CoreAPI.ProcController().SetProcInstanceState(ctx, lgc.header, states)
+			if nil != err {
+				blog.Errorf("ReconcileProcInstanceState SetProcInstanceState http do error:%s,rid:%s", err.Error(), lgc.rid)
+				return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+			}
+			if !setRet.Result {
+				blog.Errorf("ReconcileProcInstanceState SetProcInstanceState http reply error:%s,rid:%s", setRet.ErrMsg, lgc.rid)
+				return lgc.ccErr.New(setRet.Code, setRet.ErrMsg)
+			}
+		}
+		if len(ret.Data.Info) < procStatePageSize {
+			break
+		}
+	}
+
+	cond := map[string]interface{}{
+		metadata.ProcInstanceStateFieldCheckRound: mapstr.MapStr{common.BKDBLT: round},
+	}
+	delRet, err := lgc.CoreAPI.ProcController().DeleteProcInstanceState(ctx, lgc.header, cond)
+	if nil != err {
+		blog.Errorf("ReconcileProcInstanceState DeleteProcInstanceState http do error:%s,rid:%s", err.Error(), lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !delRet.Result {
+		blog.Errorf("ReconcileProcInstanceState DeleteProcInstanceState http reply error:%s,rid:%s", delRet.ErrMsg, lgc.rid)
+		return lgc.ccErr.New(delRet.Code, delRet.ErrMsg)
+	}
+	return nil
+}
+
+// checkProcInstanceState query the status of the registered process instances from gse, the
+// instance is unknown if gse does not report it or the query is failed
+func (lgc *Logics) checkProcInstanceState(ctx context.Context, gseCli gse.GseClientInterface, details []metadata.ProcInstanceDetail, now time.Time, round int64) []metadata.ProcInstanceState {
+	groups := make(map[string][]metadata.ProcInstanceDetail)
+	keys := make([]string, 0)
+	for _, detail := range details {
+		key := fmt.Sprintf("%d-%d-%d", detail.AppID, detail.ModuleID, detail.ProcID)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], detail)
+	}
+
+	states := make([]metadata.ProcInstanceState, 0, len(details))
+	for _, key := range keys {
+		group := groups[key]
+		gseReq := &metadata.GseProcRequest{
+			AppID:    group[0].AppID,
+			ModuleID: group[0].ModuleID,
+			ProcID:   group[0].ProcID,
+			Meta:     metadata.GseProcMeta{Namespace: group[0].Meta.Namespace, Name: group[0].Meta.Name},
+		}
+		for _, detail := range group {
+			gseReq.Hosts = append(gseReq.Hosts, detail.Hosts...)
+		}
+
+		queryErr := ""
+		statusMap := make(map[string]metadata.GseProcStatus)
+		ret, err := gseCli.QueryProcStatus(ctx, lgc.header, gseReq)
+		if nil != err {
+			blog.Errorf("checkProcInstanceState query process %s status from gse error:%s,rid:%s", gseReq.Meta.Name, err.Error(), lgc.rid)
+			queryErr = err.Error()
+		} else if !ret.Result {
+			blog.Errorf("checkProcInstanceState query process %s status from gse failed, code:%d, message:%s,rid:%s", gseReq.Meta.Name, ret.Code, ret.Message, lgc.rid)
+			queryErr = ret.Message
+		} else {
+			for _, status := range ret.Data.ProcInfos {
+				statusMap[gseHostKey(status.Host.BkCloudId, status.Host.Ip)] = status
+			}
+		}
+
+		for _, detail := range group {
+			for _, host := range detail.Hosts {
+				state := metadata.ProcInstanceState{
+					OwnerID:    detail.OwnerID,
+					AppID:      detail.AppID,
+					ModuleID:   detail.ModuleID,
+					ProcID:     detail.ProcID,
+					HostID:     detail.HostID,
+					InnerIP:    host.Ip,
+					CloudID:    host.BkCloudId,
+					State:      metadata.ProcRunStateUnknown,
+					CheckTime:  now,
+					CheckRound: round,
+				}
+				status, ok := statusMap[gseHostKey(host.BkCloudId, host.Ip)]
+				switch {
+				case "" != queryErr:
+					state.Message = queryErr
+				case !ok:
+					state.Message = "not found in gse"
+				case metadata.GseProcStatusRunning == status.Status:
+					state.State = metadata.ProcRunStateRunning
+					state.Pid = status.Pid
+					state.LastSeen = &now
+				case metadata.GseProcStatusStopped == status.Status:
+					state.State = metadata.ProcRunStateStopped
+				default:
+					state.Message = fmt.Sprintf("gse status %d", status.Status)
+				}
+				states = append(states, state)
+			}
+		}
+	}
+	return states
+}
+
+// summarizeProcRunState count the expected and the actual running instances of every process in
+// every module, the instances on the host without any state are unknown
+func summarizeProcRunState(models []metadata.ProcInstanceModel, states []metadata.ProcInstanceState) []metadata.ProcModuleRunSummary {
+	stateMap := make(map[string]metadata.ProcRunState)
+	for _, state := range states {
+		stateMap[fmt.Sprintf("%d-%d-%d", state.ModuleID, state.ProcID, state.HostID)] = state.State
+	}
+
+	summaryMap := make(map[string]*metadata.ProcModuleRunSummary)
+	summaries := make([]*metadata.ProcModuleRunSummary, 0)
+	for _, model := range models {
+		key := getGseOpInstKey(model.ModuleID, model.ProcID)
+		summary, ok := summaryMap[key]
+		if !ok {
+			summary = &metadata.ProcModuleRunSummary{ModuleID: model.ModuleID, ProcID: model.ProcID}
+			summaryMap[key] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Expected++
+		switch stateMap[fmt.Sprintf("%d-%d-%d", model.ModuleID, model.ProcID, model.HostID)] {
+		case metadata.ProcRunStateRunning:
+			summary.Running++
+		case metadata.ProcRunStateStopped:
+			summary.Stopped++
+		default:
+			summary.Unknown++
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].ModuleID != summaries[j].ModuleID {
+			return summaries[i].ModuleID < summaries[j].ModuleID
+		}
+		return summaries[i].ProcID < summaries[j].ProcID
+	})
+	ret := make([]metadata.ProcModuleRunSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		ret = append(ret, *summary)
+	}
+	return ret
+}
+
+// GetProcRunSummary get the expected and the actual running instances of the processes in the business
+func (lgc *Logics) GetProcRunSummary(ctx context.Context, appID int64) ([]metadata.ProcModuleRunSummary, error) {
+	modelInput := &metadata.QueryInput{
+		Condition: mapstr.MapStr{common.BKAppIDField: appID},
+		Limit:     common.BKNoLimit,
+	}
+	modelRet, err := lgc.CoreAPI.ProcController().GetProcInstanceModel(ctx, lgc.header, modelInput)
+	if nil != err {
+		blog.Errorf("GetProcRunSummary GetProcInstanceModel http do error:%s,input:%+v,rid:%s", err.Error(), modelInput, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !modelRet.Result {
+		blog.Errorf("GetProcRunSummary GetProcInstanceModel http reply error:%s,input:%+v,rid:%s", modelRet.ErrMsg, modelInput, lgc.rid)
+		return nil, lgc.ccErr.New(modelRet.Code, modelRet.ErrMsg)
+	}
+
+	states, err := lgc.SearchProcInstanceState(ctx, appID, &metadata.QueryInput{Limit: common.BKNoLimit})
+	if nil != err {
+		return nil, err
+	}
+	return summarizeProcRunState(modelRet.Data.Info, states.Info), nil
+}
+
+// SearchProcInstanceState search the state of the process instances in the business
+func (lgc *Logics) SearchProcInstanceState(ctx context.Context, appID int64, input *metadata.QueryInput) (*metadata.ProcInstanceStateData, error) {
+	cond := mapstr.MapStr{}
+	if nil != input.Condition {
+		var err error
+		cond, err = mapstr.NewFromInterface(input.Condition)
+		if nil != err {
+			blog.Errorf("SearchProcInstanceState condition %+v is not a map,rid:%s", input.Condition, lgc.rid)
+			return nil, lgc.ccErr.Error(common.CCErrCommParamsInvalid)
+		}
+	}
+	cond[common.BKAppIDField] = appID
+	input.Condition = cond
+	ret, err := lgc.CoreAPI.ProcController().SearchProcInstanceState(ctx, lgc.header, input)
+	if nil != err {
+		blog.Errorf("SearchProcInstanceState http do error:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("SearchProcInstanceState http reply error:%s,input:%+v,rid:%s", ret.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return &ret.Data, nil
+}
+
+// timedTriggerReconcileProcState check the state of the process instances periodically, only one
+// proc server checks the state in an interval
+func (lgc *Logics) timedTriggerReconcileProcState(ctx context.Context) {
+	interval := procStateReconcileInterval
+	if nil != lgc.procHostInst && 0 < lgc.procHostInst.ProcStateInterval {
+		interval = lgc.procHostInst.ProcStateInterval
+	}
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		// the lock is not released, so that it's kept until the next round of the interval
+		locked, err := lgc.cache.SetNX(common.RedisProcSrvProcStateReconcileLockKey, "", interval*9/10).Result()
+		if nil != err {
+			blog.Warnf("timedTriggerReconcileProcState lock error:%s,rid:%s", err.Error(), lgc.rid)
+			continue
+		}
+		if !locked {
+			continue
+		}
+
+		header := make(http.Header, 0)
+		header.Set(common.BKHTTPOwnerID, common.BKSuperOwnerID)
+		header.Set(common.BKHTTPHeaderUser, common.BKProcInstanceOpUser)
+		newLgc := lgc.NewFromHeader(header)
+		if err := newLgc.ReconcileProcInstanceState(ctx, newLgc.esbServ.GseSrv()); nil != err {
+			blog.Warnf("timedTriggerReconcileProcState reconcile error:%s,rid:%s", err.Error(), newLgc.rid)
+		}
+	}
+}
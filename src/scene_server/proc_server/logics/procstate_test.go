@@ -0,0 +1,99 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/thirdpartyclient/esbserver/gse"
+)
+
+func newTestProcDetail(moduleID, hostID int64, ip string) metadata.ProcInstanceDetail {
+	detail := metadata.ProcInstanceDetail{HostID: hostID, OwnerID: "0"}
+	detail.AppID = 1
+	detail.ModuleID = moduleID
+	detail.ProcID = 10
+	detail.Meta = metadata.GseProcMeta{Namespace: getGseProcNameSpace(1, moduleID), Name: "nginx"}
+	detail.Hosts = []metadata.GseHost{{HostID: hostID, Ip: ip}}
+	return detail
+}
+
+func TestCheckProcInstanceState(t *testing.T) {
+	fake := gse.NewFakeGse()
+	details := []metadata.ProcInstanceDetail{
+		newTestProcDetail(2, 100, "127.0.0.1"),
+		newTestProcDetail(2, 101, "127.0.0.2"),
+		newTestProcDetail(2, 102, "127.0.0.3"),
+		newTestProcDetail(3, 100, "127.0.0.1"),
+	}
+	fake.SetProcStatus(metadata.GseProcStatus{Meta: details[0].Meta, Host: details[0].Hosts[0], Status: metadata.GseProcStatusRunning, Pid: 1234})
+	fake.SetProcStatus(metadata.GseProcStatus{Meta: details[1].Meta, Host: details[1].Hosts[0], Status: metadata.GseProcStatusStopped})
+
+	lgc := &Logics{}
+	now := time.Now().UTC()
+	states := lgc.checkProcInstanceState(context.Background(), fake, details, now, now.Unix())
+	if len(states) != len(details) {
+		t.Fatalf("expect %d states, got %d", len(details), len(states))
+	}
+	expect := []metadata.ProcRunState{metadata.ProcRunStateRunning, metadata.ProcRunStateStopped, metadata.ProcRunStateUnknown, metadata.ProcRunStateUnknown}
+	for idx, state := range states {
+		if state.State != expect[idx] || state.HostID != details[idx].HostID || state.ModuleID != details[idx].ModuleID {
+			t.Errorf("state %d expect %s of host %d, got %+v", idx, expect[idx], details[idx].HostID, state)
+		}
+		if state.CheckRound != now.Unix() || "0" != state.OwnerID {
+			t.Errorf("state %d is not filled, got %+v", idx, state)
+		}
+	}
+	if 1234 != states[0].Pid || nil == states[0].LastSeen || nil != states[1].LastSeen {
+		t.Errorf("the pid and the last seen time should be set only for the running process, got %+v, %+v", states[0], states[1])
+	}
+
+	fake.StatusErr = errors.New("gse is down")
+	states = lgc.checkProcInstanceState(context.Background(), fake, details[:1], now, now.Unix())
+	if 1 != len(states) || metadata.ProcRunStateUnknown != states[0].State || "gse is down" != states[0].Message {
+		t.Errorf("the state should be unknown if gse is failed, got %+v", states)
+	}
+}
+
+func TestSummarizeProcRunState(t *testing.T) {
+	models := []metadata.ProcInstanceModel{
+		{ModuleID: 3, ProcID: 10, HostID: 100, ProcInstanceID: 1},
+		{ModuleID: 2, ProcID: 10, HostID: 100, ProcInstanceID: 1},
+		{ModuleID: 2, ProcID: 10, HostID: 100, ProcInstanceID: 2},
+		{ModuleID: 2, ProcID: 10, HostID: 101, ProcInstanceID: 3},
+		{ModuleID: 2, ProcID: 10, HostID: 102, ProcInstanceID: 4},
+	}
+	states := []metadata.ProcInstanceState{
+		{ModuleID: 2, ProcID: 10, HostID: 100, State: metadata.ProcRunStateRunning},
+		{ModuleID: 2, ProcID: 10, HostID: 101, State: metadata.ProcRunStateStopped},
+		{ModuleID: 3, ProcID: 10, HostID: 100, State: metadata.ProcRunStateRunning},
+	}
+
+	summary := summarizeProcRunState(models, states)
+	expect := []metadata.ProcModuleRunSummary{
+		{ModuleID: 2, ProcID: 10, Expected: 4, Running: 2, Stopped: 1, Unknown: 1},
+		{ModuleID: 3, ProcID: 10, Expected: 1, Running: 1},
+	}
+	if len(summary) != len(expect) {
+		t.Fatalf("expect %d summaries, got %+v", len(expect), summary)
+	}
+	for idx := range expect {
+		if summary[idx] != expect[idx] {
+			t.Errorf("summary %d expect %+v, got %+v", idx, expect[idx], summary[idx])
+		}
+	}
+}
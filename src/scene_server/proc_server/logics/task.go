@@ -80,7 +80,7 @@ func (lgc *Logics) FilterGseTaskIDWaitResultByTaskID(ctx context.Context, taskID
 // handleGseOPProcResult  backgroud handle gse operate process result
 func (lgc *Logics) getGseOPProcTaskIDFromRedis(interval time.Duration) {
 	for {
-		val, err := lgc.cache.SPop(common.RedisProcSrvHostInstanceRefreshModuleKey).Result()
+		val, err := lgc.cache.SPop(common.RedisProcSrvQueryProcOPResultKey).Result()
 		if redis.Nil == err {
 			if 0 >= interval {
 				interval = GETTASKIDSPOPINTERVAL
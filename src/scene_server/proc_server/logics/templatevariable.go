@@ -0,0 +1,423 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+var procVariableNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,63}$`)
+
+// the variables set by the process instance, can not be defined by the user
+var procReservedVariables = []string{"proc_instance_id", "host_proc_id"}
+
+var procVariableTypes = map[metadata.ProcVariableType]bool{
+	metadata.ProcVariableTypeString: true,
+	metadata.ProcVariableTypeInt:    true,
+	metadata.ProcVariableTypeBool:   true,
+	metadata.ProcVariableTypeEnum:   true,
+	metadata.ProcVariableTypeIP:     true,
+	metadata.ProcVariableTypePort:   true,
+}
+
+// the levels of the mainline the variable can be overridden on, the latter is more specific
+var procVariableOverrideObjs = []string{common.BKInnerObjIDSet, common.BKInnerObjIDModule, common.BKInnerObjIDHost}
+
+func procVariableInt(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("%v is not an integer", val)
+		}
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	}
+	return util.GetInt64ByInterface(val)
+}
+
+// normalizeProcVariableValue check the value by the type of the variable, and convert it to the type
+func normalizeProcVariableValue(variable *metadata.ProcTemplateVariable, val interface{}) (interface{}, error) {
+	switch variable.Type {
+	case metadata.ProcVariableTypeString:
+		if s, ok := val.(string); ok {
+			return s, nil
+		}
+	case metadata.ProcVariableTypeInt:
+		if n, err := procVariableInt(val); nil == err {
+			return n, nil
+		}
+	case metadata.ProcVariableTypeBool:
+		switch b := val.(type) {
+		case bool:
+			return b, nil
+		case string:
+			if parsed, err := strconv.ParseBool(b); nil == err {
+				return parsed, nil
+			}
+		}
+	case metadata.ProcVariableTypeEnum:
+		if s, ok := val.(string); ok && util.InStrArr(variable.Options, s) {
+			return s, nil
+		}
+	case metadata.ProcVariableTypeIP:
+		if s, ok := val.(string); ok && nil != net.ParseIP(strings.TrimSpace(s)) {
+			return strings.TrimSpace(s), nil
+		}
+	case metadata.ProcVariableTypePort:
+		if n, err := procVariableInt(val); nil == err && n > 0 && n <= 65535 {
+			return n, nil
+		}
+	default:
+		return nil, fmt.Errorf("type %s of variable %s is not supported", variable.Type, variable.Name)
+	}
+	return nil, fmt.Errorf("value %v of variable %s is not a valid %s", val, variable.Name, variable.Type)
+}
+
+// checkProcTemplateVariable check the definition and the values of the variable, the default value and
+// the overridden values are converted to the type of the variable
+func checkProcTemplateVariable(variable *metadata.ProcTemplateVariable) error {
+	if !procVariableNameRegexp.MatchString(variable.Name) {
+		return fmt.Errorf("variable name %s should be letters, digits or underscores and not start with a digit", variable.Name)
+	}
+	if strings.HasPrefix(variable.Name, "bk_") || util.InStrArr(procReservedVariables, variable.Name) {
+		return fmt.Errorf("variable name %s is reserved", variable.Name)
+	}
+	if !procVariableTypes[variable.Type] {
+		return fmt.Errorf("type %s of variable %s is not supported", variable.Type, variable.Name)
+	}
+	if metadata.ProcVariableTypeEnum == variable.Type && 0 == len(variable.Options) {
+		return fmt.Errorf("the options of the enum variable %s is not set", variable.Name)
+	}
+
+	if nil != variable.Default {
+		val, err := normalizeProcVariableValue(variable, variable.Default)
+		if nil != err {
+			return err
+		}
+		variable.Default = val
+	}
+
+	exists := make(map[string]bool, len(variable.Overrides))
+	for idx := range variable.Overrides {
+		override := &variable.Overrides[idx]
+		if !util.InStrArr(procVariableOverrideObjs, override.ObjID) {
+			return fmt.Errorf("variable %s can not be overridden on %s", variable.Name, override.ObjID)
+		}
+		if override.InstID <= 0 {
+			return fmt.Errorf("the instance id of the %s to override variable %s is not set", override.ObjID, variable.Name)
+		}
+		key := fmt.Sprintf("%s.%d", override.ObjID, override.InstID)
+		if exists[key] {
+			return fmt.Errorf("variable %s is overridden on %s %d more than once", variable.Name, override.ObjID, override.InstID)
+		}
+		exists[key] = true
+		val, err := normalizeProcVariableValue(variable, override.Value)
+		if nil != err {
+			return err
+		}
+		override.Value = val
+	}
+	return nil
+}
+
+// effectiveProcVariable the value of the variable used by the process instance, the value overridden
+// on the host is used first, then the module, the set and the default value of the business at last
+func effectiveProcVariable(variable metadata.ProcTemplateVariable, inst metadata.ProcInstanceModel) metadata.ProcEffectiveVariable {
+	effective := metadata.ProcEffectiveVariable{
+		Name:         variable.Name,
+		Type:         variable.Type,
+		Value:        variable.Default,
+		Source:       common.BKInnerObjIDApp,
+		SourceInstID: inst.ApplicationID,
+	}
+	levels := map[string]int64{
+		common.BKInnerObjIDSet:    inst.SetID,
+		common.BKInnerObjIDModule: inst.ModuleID,
+		common.BKInnerObjIDHost:   inst.HostID,
+	}
+	level := -1
+	for _, override := range variable.Overrides {
+		if levels[override.ObjID] != override.InstID {
+			continue
+		}
+		for idx, objID := range procVariableOverrideObjs {
+			if objID == override.ObjID && idx > level {
+				level = idx
+				effective.Value = override.Value
+				effective.Source = override.ObjID
+				effective.SourceInstID = override.InstID
+			}
+		}
+	}
+	return effective
+}
+
+func effectiveProcVariables(variables []metadata.ProcTemplateVariable, inst metadata.ProcInstanceModel) []metadata.ProcEffectiveVariable {
+	effectives := make([]metadata.ProcEffectiveVariable, 0, len(variables))
+	for _, variable := range variables {
+		effectives = append(effectives, effectiveProcVariable(variable, inst))
+	}
+	return effectives
+}
+
+// SearchProcTemplateVariable search the template variables of the business
+func (lgc *Logics) SearchProcTemplateVariable(ctx context.Context, appID int64, input *metadata.QueryInput) (*metadata.ProcTemplateVariableData, error) {
+	cond, err := mapstr.NewFromInterface(input.Condition)
+	if err != nil {
+		blog.Errorf("SearchProcTemplateVariable condition is invalid,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "condition")
+	}
+	if nil == cond {
+		cond = mapstr.MapStr{}
+	}
+	cond[common.BKAppIDField] = appID
+	input.Condition = cond
+	ret, err := lgc.CoreAPI.ProcController().SearchTemplateVariable(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("SearchProcTemplateVariable http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("SearchProcTemplateVariable http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return &ret.Data, nil
+}
+
+func (lgc *Logics) getProcTemplateVariables(ctx context.Context, appID int64, cond mapstr.MapStr) ([]metadata.ProcTemplateVariable, error) {
+	input := &metadata.QueryInput{Condition: cond, Limit: common.BKNoLimit}
+	data, err := lgc.SearchProcTemplateVariable(ctx, appID, input)
+	if err != nil {
+		return nil, err
+	}
+	return data.Info, nil
+}
+
+func (lgc *Logics) getProcTemplateVariable(ctx context.Context, appID, id int64) (*metadata.ProcTemplateVariable, error) {
+	variables, err := lgc.getProcTemplateVariables(ctx, appID, mapstr.MapStr{metadata.ProcTemplateVariableFieldID: id})
+	if err != nil {
+		return nil, err
+	}
+	if 0 == len(variables) {
+		blog.Errorf("getProcTemplateVariable variable %d of business %d not found,rid:%s", id, appID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrProcTemplateVariableNotFound)
+	}
+	return &variables[0], nil
+}
+
+// checkProcVariableOverrideInst check the sets, modules and hosts the variable is overridden on belong to the business
+func (lgc *Logics) checkProcVariableOverrideInst(ctx context.Context, appID int64, overrides []metadata.ProcVariableOverride) error {
+	instIDs := make(map[string][]int64)
+	for _, override := range overrides {
+		instIDs[override.ObjID] = append(instIDs[override.ObjID], override.InstID)
+	}
+
+	for objID, ids := range instIDs {
+		ids = util.IntArrayUnique(ids)
+		found := make(map[int64]bool, len(ids))
+		if common.BKInnerObjIDHost == objID {
+			dat := map[string][]int64{common.BKAppIDField: []int64{appID}, common.BKHostIDField: ids}
+			ret, err := lgc.CoreAPI.HostController().Module().GetModulesHostConfig(ctx, lgc.header, dat)
+			if err != nil {
+				blog.Errorf("checkProcVariableOverrideInst GetModulesHostConfig http do error,err:%s,input:%+v,rid:%s", err.Error(), dat, lgc.rid)
+				return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+			}
+			if !ret.Result {
+				blog.Errorf("checkProcVariableOverrideInst GetModulesHostConfig http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, dat, lgc.rid)
+				return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+			}
+			for _, item := range ret.Data {
+				found[item.HostID] = true
+			}
+		} else {
+			insts, err := lgc.getInstByIDs(ctx, objID, ids)
+			if err != nil {
+				return err
+			}
+			for id, inst := range insts {
+				if bizID, err := inst.Int64(common.BKAppIDField); nil == err && bizID == appID {
+					found[id] = true
+				}
+			}
+		}
+		for _, id := range ids {
+			if !found[id] {
+				blog.Errorf("checkProcVariableOverrideInst %s %d not in business %d,rid:%s", objID, id, appID, lgc.rid)
+				return lgc.ccErr.Errorf(common.CCErrProcTemplateVariableInvalid, fmt.Sprintf("%s %d is not in the business", objID, id))
+			}
+		}
+	}
+	return nil
+}
+
+func (lgc *Logics) validateProcTemplateVariable(ctx context.Context, appID int64, variable *metadata.ProcTemplateVariable) error {
+	if err := checkProcTemplateVariable(variable); nil != err {
+		blog.Errorf("validateProcTemplateVariable variable %+v is invalid, err:%s,rid:%s", variable, err.Error(), lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrProcTemplateVariableInvalid, err.Error())
+	}
+	return lgc.checkProcVariableOverrideInst(ctx, appID, variable.Overrides)
+}
+
+func (lgc *Logics) updateProcTemplateVariable(ctx context.Context, variable *metadata.ProcTemplateVariable) error {
+	ret, err := lgc.CoreAPI.ProcController().UpdateTemplateVariable(ctx, lgc.header, variable)
+	if err != nil {
+		blog.Errorf("updateProcTemplateVariable http do error,err:%s,input:%+v,rid:%s", err.Error(), variable, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("updateProcTemplateVariable http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, variable, lgc.rid)
+		return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return nil
+}
+
+// CreateProcTemplateVariable define a variable of the business
+func (lgc *Logics) CreateProcTemplateVariable(ctx context.Context, appID int64, variable *metadata.ProcTemplateVariable) (*metadata.ProcTemplateVariable, error) {
+	variable.AppID = appID
+	if err := lgc.validateProcTemplateVariable(ctx, appID, variable); err != nil {
+		return nil, err
+	}
+	exists, err := lgc.getProcTemplateVariables(ctx, appID, mapstr.MapStr{metadata.ProcTemplateVariableFieldName: variable.Name})
+	if err != nil {
+		return nil, err
+	}
+	if 0 != len(exists) {
+		blog.Errorf("CreateProcTemplateVariable variable %s of business %d already exists,rid:%s", variable.Name, appID, lgc.rid)
+		return nil, lgc.ccErr.Errorf(common.CCErrProcTemplateVariableDuplicate, variable.Name)
+	}
+
+	ret, err := lgc.CoreAPI.ProcController().CreateTemplateVariable(ctx, lgc.header, variable)
+	if err != nil {
+		blog.Errorf("CreateProcTemplateVariable http do error,err:%s,input:%+v,rid:%s", err.Error(), variable, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("CreateProcTemplateVariable http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, variable, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return &ret.Data, nil
+}
+
+// UpdateProcTemplateVariable change the type, the options, the default value or the description of the
+// variable, the name can not be changed as it's referenced by the templates
+func (lgc *Logics) UpdateProcTemplateVariable(ctx context.Context, appID, id int64, data *metadata.ProcTemplateVariable) error {
+	variable, err := lgc.getProcTemplateVariable(ctx, appID, id)
+	if err != nil {
+		return err
+	}
+	if "" != data.Name && data.Name != variable.Name {
+		return lgc.ccErr.Errorf(common.CCErrProcTemplateVariableInvalid, "the name of the variable can not be changed")
+	}
+	if "" != data.Type {
+		variable.Type = data.Type
+	}
+	variable.Options = data.Options
+	variable.Default = data.Default
+	variable.Description = data.Description
+	if err := lgc.validateProcTemplateVariable(ctx, appID, variable); err != nil {
+		return err
+	}
+	return lgc.updateProcTemplateVariable(ctx, variable)
+}
+
+// DeleteProcTemplateVariable delete the variable and the values overridden
+func (lgc *Logics) DeleteProcTemplateVariable(ctx context.Context, appID, id int64) error {
+	if _, err := lgc.getProcTemplateVariable(ctx, appID, id); err != nil {
+		return err
+	}
+	cond := map[string]interface{}{common.BKAppIDField: appID, metadata.ProcTemplateVariableFieldID: id}
+	ret, err := lgc.CoreAPI.ProcController().DeleteTemplateVariable(ctx, lgc.header, cond)
+	if err != nil {
+		blog.Errorf("DeleteProcTemplateVariable http do error,err:%s,input:%+v,rid:%s", err.Error(), cond, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("DeleteProcTemplateVariable http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, cond, lgc.rid)
+		return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return nil
+}
+
+// SetProcTemplateVariableValue override the value of the variable on a set, module or host
+func (lgc *Logics) SetProcTemplateVariableValue(ctx context.Context, appID, id int64, override metadata.ProcVariableOverride) error {
+	variable, err := lgc.getProcTemplateVariable(ctx, appID, id)
+	if err != nil {
+		return err
+	}
+	overrides := make([]metadata.ProcVariableOverride, 0, len(variable.Overrides)+1)
+	for _, item := range variable.Overrides {
+		if item.ObjID != override.ObjID || item.InstID != override.InstID {
+			overrides = append(overrides, item)
+		}
+	}
+	variable.Overrides = append(overrides, override)
+	if err := lgc.validateProcTemplateVariable(ctx, appID, variable); err != nil {
+		return err
+	}
+	return lgc.updateProcTemplateVariable(ctx, variable)
+}
+
+// DeleteProcTemplateVariableValue remove the value of the variable overridden on a set, module or host
+func (lgc *Logics) DeleteProcTemplateVariableValue(ctx context.Context, appID, id int64, objID string, instID int64) error {
+	variable, err := lgc.getProcTemplateVariable(ctx, appID, id)
+	if err != nil {
+		return err
+	}
+	overrides := make([]metadata.ProcVariableOverride, 0, len(variable.Overrides))
+	for _, item := range variable.Overrides {
+		if item.ObjID != objID || item.InstID != instID {
+			overrides = append(overrides, item)
+		}
+	}
+	if len(overrides) == len(variable.Overrides) {
+		return nil
+	}
+	variable.Overrides = overrides
+	return lgc.updateProcTemplateVariable(ctx, variable)
+}
+
+// GetEffectiveProcVariables get the variables used to render the config templates for the process instance
+func (lgc *Logics) GetEffectiveProcVariables(ctx context.Context, appID int64, param *metadata.ProcEffectiveVariableParam) (*metadata.ProcEffectiveVariables, error) {
+	vars, err := lgc.newConfigFileVariables(ctx, appID, []int64{param.ProcID})
+	if err != nil {
+		return nil, err
+	}
+	for _, inst := range vars.insts {
+		if inst.ModuleID != param.ModuleID || inst.HostID != param.HostID {
+			continue
+		}
+		if 0 != param.ProcInstanceID && inst.ProcInstanceID != param.ProcInstanceID {
+			continue
+		}
+		return &metadata.ProcEffectiveVariables{
+			Instance:  inst,
+			Custom:    effectiveProcVariables(vars.custom, inst),
+			Variables: vars.context(inst),
+		}, nil
+	}
+	blog.Errorf("GetEffectiveProcVariables process instance %+v of business %d not found,rid:%s", param, appID, lgc.rid)
+	return nil, lgc.ccErr.Error(common.CCErrCommNotFound)
+}
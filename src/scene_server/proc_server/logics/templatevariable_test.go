@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestCheckProcTemplateVariable(t *testing.T) {
+	variable := &metadata.ProcTemplateVariable{
+		Name:    "listen_port",
+		Type:    metadata.ProcVariableTypePort,
+		Default: float64(8080),
+		Overrides: []metadata.ProcVariableOverride{
+			{ObjID: common.BKInnerObjIDModule, InstID: 2, Value: "9090"},
+		},
+	}
+	if err := checkProcTemplateVariable(variable); err != nil {
+		t.Fatalf("check variable failed, err: %v", err)
+	}
+	if variable.Default != int64(8080) || variable.Overrides[0].Value != int64(9090) {
+		t.Errorf("the values should be converted to int64, got %+v", variable)
+	}
+
+	invalids := []metadata.ProcTemplateVariable{
+		{Name: "1port", Type: metadata.ProcVariableTypeString},
+		{Name: "bk_biz_name", Type: metadata.ProcVariableTypeString},
+		{Name: "proc_instance_id", Type: metadata.ProcVariableTypeInt},
+		{Name: "port", Type: "float"},
+		{Name: "port", Type: metadata.ProcVariableTypePort, Default: float64(70000)},
+		{Name: "count", Type: metadata.ProcVariableTypeInt, Default: float64(1.5)},
+		{Name: "debug", Type: metadata.ProcVariableTypeBool, Default: "yes"},
+		{Name: "ip", Type: metadata.ProcVariableTypeIP, Default: "10.0.0"},
+		{Name: "env", Type: metadata.ProcVariableTypeEnum},
+		{Name: "env", Type: metadata.ProcVariableTypeEnum, Options: []string{"dev", "prod"}, Default: "test"},
+		{Name: "env", Type: metadata.ProcVariableTypeString, Overrides: []metadata.ProcVariableOverride{
+			{ObjID: common.BKInnerObjIDApp, InstID: 1, Value: "dev"},
+		}},
+		{Name: "env", Type: metadata.ProcVariableTypeString, Overrides: []metadata.ProcVariableOverride{
+			{ObjID: common.BKInnerObjIDHost, InstID: 1, Value: "dev"},
+			{ObjID: common.BKInnerObjIDHost, InstID: 1, Value: "prod"},
+		}},
+	}
+	for idx := range invalids {
+		if err := checkProcTemplateVariable(&invalids[idx]); err == nil {
+			t.Errorf("variable %+v should be invalid", invalids[idx])
+		}
+	}
+}
+
+func TestEffectiveProcVariable(t *testing.T) {
+	variable := metadata.ProcTemplateVariable{
+		Name:    "env",
+		Type:    metadata.ProcVariableTypeString,
+		Default: "dev",
+		Overrides: []metadata.ProcVariableOverride{
+			{ObjID: common.BKInnerObjIDHost, InstID: 5, Value: "host"},
+			{ObjID: common.BKInnerObjIDModule, InstID: 3, Value: "module"},
+			{ObjID: common.BKInnerObjIDSet, InstID: 2, Value: "set"},
+		},
+	}
+	cases := []struct {
+		inst   metadata.ProcInstanceModel
+		value  string
+		source string
+	}{
+		{metadata.ProcInstanceModel{ApplicationID: 1, SetID: 2, ModuleID: 3, HostID: 5}, "host", common.BKInnerObjIDHost},
+		{metadata.ProcInstanceModel{ApplicationID: 1, SetID: 2, ModuleID: 3, HostID: 6}, "module", common.BKInnerObjIDModule},
+		{metadata.ProcInstanceModel{ApplicationID: 1, SetID: 2, ModuleID: 4, HostID: 6}, "set", common.BKInnerObjIDSet},
+		{metadata.ProcInstanceModel{ApplicationID: 1, SetID: 7, ModuleID: 4, HostID: 6}, "dev", common.BKInnerObjIDApp},
+	}
+	for _, c := range cases {
+		effective := effectiveProcVariable(variable, c.inst)
+		if effective.Value != c.value || effective.Source != c.source {
+			t.Errorf("instance %+v expect %s from %s, got %+v", c.inst, c.value, c.source, effective)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// UpdateTemplateVersion change the content and the description of the version, only a draft can be
+// changed, and the status is changed by the review.
+func (lgc *Logics) UpdateTemplateVersion(ctx context.Context, appID, templateID, versionID int64, params *metadata.TemplateVersion) error {
+	input := &metadata.UpdateOption{
+		Condition: mapstr.MapStr{
+			common.BKOwnerIDField:   lgc.ownerID,
+			common.BKAppIDField:     appID,
+			common.BKTemlateIDField: templateID,
+			common.BKInstIDField:    versionID,
+			common.BKStatusField:    common.TemplateStatusDraft,
+		},
+		Data: mapstr.MapStr{
+			common.BKContentField:     params.Content,
+			common.BKDescriptionField: params.Description,
+		},
+	}
+	ret, err := lgc.CoreAPI.CoreService().Instance().UpdateInstance(ctx, lgc.header, common.BKInnerObjIDTempVersion, input)
+	if err != nil {
+		blog.Errorf("UpdateTemplateVersion UpdateInstance http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("UpdateTemplateVersion UpdateInstance http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+		return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	if ret.Data.Count == 0 {
+		blog.Errorf("UpdateTemplateVersion version %d of template %d is not a draft,rid:%s", versionID, templateID, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrProcTemplateVersionStatusWrong, "not draft", "update")
+	}
+	return nil
+}
+
+// SubmitTemplateVersion submit the draft version for reviewing
+func (lgc *Logics) SubmitTemplateVersion(ctx context.Context, appID, templateID, versionID int64) error {
+	version, err := lgc.GetTemplateVersion(ctx, appID, templateID, versionID)
+	if err != nil {
+		return err
+	}
+	data := mapstr.MapStr{
+		common.BKStatusField:            common.TemplateStatusReviewing,
+		common.BKTemplateSubmitterField: lgc.user,
+	}
+	if err := lgc.changeTemplateVersionStatus(ctx, version, common.TemplateStatusDraft, "submit", data); err != nil {
+		return err
+	}
+	return lgc.saveTemplateVersionAudit(ctx, appID, templateID, version, data, fmt.Sprintf("submit version %d of template %d", versionID, templateID))
+}
+
+// ApproveTemplateVersion approve the version in reviewing and release it, the version can not be
+// approved by the submitter.
+func (lgc *Logics) ApproveTemplateVersion(ctx context.Context, appID, templateID, versionID int64, comment string) error {
+	version, err := lgc.GetTemplateVersion(ctx, appID, templateID, versionID)
+	if err != nil {
+		return err
+	}
+	if submitter, _ := version.String(common.BKTemplateSubmitterField); submitter == lgc.user {
+		blog.Errorf("ApproveTemplateVersion version %d of template %d is approved by the submitter %s,rid:%s", versionID, templateID, submitter, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrProcTemplateVersionSelfReview)
+	}
+
+	data := mapstr.MapStr{
+		common.BKStatusField:              common.TemplateStatusReleased,
+		common.BKTemplateReviewerField:    lgc.user,
+		common.BKTemplateReleaseTimeField: time.Now().UTC(),
+	}
+	if err := lgc.releaseTemplateVersion(ctx, appID, templateID, version, common.TemplateStatusReviewing, "approve", data); err != nil {
+		return err
+	}
+	return lgc.saveTemplateVersionAudit(ctx, appID, templateID, version, data, fmt.Sprintf("approve version %d of template %d: %s", versionID, templateID, comment))
+}
+
+// RejectTemplateVersion reject the version in reviewing, it's changed back to draft
+func (lgc *Logics) RejectTemplateVersion(ctx context.Context, appID, templateID, versionID int64, comment string) error {
+	version, err := lgc.GetTemplateVersion(ctx, appID, templateID, versionID)
+	if err != nil {
+		return err
+	}
+	data := mapstr.MapStr{
+		common.BKStatusField:           common.TemplateStatusDraft,
+		common.BKTemplateReviewerField: lgc.user,
+	}
+	if err := lgc.changeTemplateVersionStatus(ctx, version, common.TemplateStatusReviewing, "reject", data); err != nil {
+		return err
+	}
+	return lgc.saveTemplateVersionAudit(ctx, appID, templateID, version, data, fmt.Sprintf("reject version %d of template %d: %s", versionID, templateID, comment))
+}
+
+// RollbackTemplateVersion release a deprecated version again, then render the config files of all the
+// process instances bound to the template with it and push them. the version released before the
+// current one is used if versionID is 0.
+func (lgc *Logics) RollbackTemplateVersion(ctx context.Context, distributor ConfigFileDistributor, appID, templateID, versionID int64) (*metadata.TemplateVersionRollbackResult, error) {
+	var version mapstr.MapStr
+	var err error
+	if versionID != 0 {
+		version, err = lgc.GetTemplateVersion(ctx, appID, templateID, versionID)
+	} else {
+		version, err = lgc.getPreviousReleasedVersion(ctx, appID, templateID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := version[common.BKTemplateReleaseTimeField]; !exists {
+		blog.Errorf("RollbackTemplateVersion version %+v has never been released,rid:%s", version, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrProcTemplateVersionNoRollback)
+	}
+	versionID, err = version.Int64(common.BKInstIDField)
+	if err != nil {
+		blog.Errorf("RollbackTemplateVersion version id of %+v is invalid, err:%v,rid:%s", version, err, lgc.rid)
+		return nil, lgc.ccErr.Errorf(common.CCErrCommInstFieldConvFail, common.BKInnerObjIDTempVersion, common.BKInstIDField, "int", err.Error())
+	}
+
+	data := mapstr.MapStr{
+		common.BKStatusField:              common.TemplateStatusReleased,
+		common.BKTemplateReleaseTimeField: time.Now().UTC(),
+	}
+	if err := lgc.releaseTemplateVersion(ctx, appID, templateID, version, common.TemplateStatusDeprecated, "roll back to", data); err != nil {
+		return nil, err
+	}
+	if err := lgc.saveTemplateVersionAudit(ctx, appID, templateID, version, data, fmt.Sprintf("roll back template %d to version %d", templateID, versionID)); err != nil {
+		return nil, err
+	}
+
+	if _, err := lgc.GenerateConfigFiles(ctx, appID, templateID, &metadata.CreateConfigFileParams{VersionID: versionID}); err != nil {
+		return nil, err
+	}
+	files, err := lgc.PushConfigFiles(ctx, distributor, appID, templateID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata.TemplateVersionRollbackResult{VersionID: versionID, Files: files}, nil
+}
+
+// getPreviousReleasedVersion get the deprecated version which is released most recently
+func (lgc *Logics) getPreviousReleasedVersion(ctx context.Context, appID, templateID int64) (mapstr.MapStr, error) {
+	input := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{
+			common.BKAppIDField:               appID,
+			common.BKTemlateIDField:           templateID,
+			common.BKStatusField:              common.TemplateStatusDeprecated,
+			common.BKTemplateReleaseTimeField: mapstr.MapStr{common.BKDBExists: true},
+		},
+		Limit:   metadata.SearchLimit{Limit: 1},
+		SortArr: []metadata.SearchSort{{Field: common.BKTemplateReleaseTimeField, IsDsc: true}},
+	}
+	ret, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, common.BKInnerObjIDTempVersion, input)
+	if err != nil {
+		blog.Errorf("getPreviousReleasedVersion ReadInstance http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("getPreviousReleasedVersion ReadInstance http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	if len(ret.Data.Info) == 0 {
+		blog.Errorf("getPreviousReleasedVersion template %d has no deprecated version,rid:%s", templateID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrProcTemplateVersionNoRollback)
+	}
+	return ret.Data.Info[0], nil
+}
+
+// releaseTemplateVersion release the version and deprecate the released one
+func (lgc *Logics) releaseTemplateVersion(ctx context.Context, appID, templateID int64, version mapstr.MapStr, from, op string, data mapstr.MapStr) error {
+	if err := lgc.changeTemplateVersionStatus(ctx, version, from, op, data); err != nil {
+		return err
+	}
+
+	versionID, _ := version.Int64(common.BKInstIDField)
+	input := &metadata.UpdateOption{
+		Condition: mapstr.MapStr{
+			common.BKAppIDField:     appID,
+			common.BKTemlateIDField: templateID,
+			common.BKInstIDField:    mapstr.MapStr{common.BKDBNE: versionID},
+			common.BKStatusField:    common.TemplateStatusReleased,
+		},
+		Data: mapstr.MapStr{common.BKStatusField: common.TemplateStatusDeprecated},
+	}
+	ret, err := lgc.CoreAPI.CoreService().Instance().UpdateInstance(ctx, lgc.header, common.BKInnerObjIDTempVersion, input)
+	if err != nil {
+		blog.Errorf("releaseTemplateVersion UpdateInstance http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("releaseTemplateVersion UpdateInstance http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+		return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return nil
+}
+
+// changeTemplateVersionStatus update the version if it's still in the status from, so that the
+// version is not changed by two operations at the same time.
+func (lgc *Logics) changeTemplateVersionStatus(ctx context.Context, version mapstr.MapStr, from, op string, data mapstr.MapStr) error {
+	status, _ := version.String(common.BKStatusField)
+	if status != from {
+		blog.Errorf("changeTemplateVersionStatus can not %s version %+v of status %s,rid:%s", op, version, status, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrProcTemplateVersionStatusWrong, status, op)
+	}
+
+	input := &metadata.UpdateOption{
+		Condition: mapstr.MapStr{
+			common.BKInstIDField: version[common.BKInstIDField],
+			common.BKStatusField: from,
+		},
+		Data: data,
+	}
+	ret, err := lgc.CoreAPI.CoreService().Instance().UpdateInstance(ctx, lgc.header, common.BKInnerObjIDTempVersion, input)
+	if err != nil {
+		blog.Errorf("changeTemplateVersionStatus UpdateInstance http do error,err:%s,input:%+v,rid:%s", err.Error(), input, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !ret.Result {
+		blog.Errorf("changeTemplateVersionStatus UpdateInstance http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, input, lgc.rid)
+		return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	if ret.Data.Count == 0 {
+		blog.Errorf("changeTemplateVersionStatus version %+v is changed by others,rid:%s", version, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrProcTemplateVersionStatusWrong, "changed", op)
+	}
+	return nil
+}
+
+// saveTemplateVersionAudit record the change of the version in the audit log of the template
+func (lgc *Logics) saveTemplateVersionAudit(ctx context.Context, appID, templateID int64, version, data mapstr.MapStr, desc string) error {
+	curData := version.Clone()
+	curData.Merge(data)
+	log := metadata.SaveAuditLogParams{
+		ID:    templateID,
+		Model: common.BKInnerObjIDProc,
+		Content: metadata.Content{
+			PreData: version,
+			CurData: curData,
+		},
+		OpDesc: desc,
+		OpType: auditoplog.AuditOpTypeModify,
+		BizID:  appID,
+	}
+	ret, err := lgc.CoreAPI.CoreService().Audit().SaveAuditLog(ctx, lgc.header, log)
+	if err != nil {
+		blog.Errorf("saveTemplateVersionAudit SaveAuditLog http do error,err:%s,input:%+v,rid:%s", err.Error(), log, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrAuditSaveLogFaile)
+	}
+	if !ret.Result {
+		blog.Errorf("saveTemplateVersionAudit SaveAuditLog http response error,err code:%d,err msg:%s,input:%+v,rid:%s", ret.Code, ret.ErrMsg, log, lgc.rid)
+		return lgc.ccErr.New(ret.Code, ret.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/apimachinery/coreservice/auditlog"
+	"configcenter/src/apimachinery/coreservice/instance"
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+type fakeClientSet struct {
+	apimachinery.ClientSetInterface
+	core *fakeCoreService
+}
+
+func (c *fakeClientSet) CoreService() coreservice.CoreServiceClientInterface {
+	return c.core
+}
+
+// fakeCoreService keeps the template versions in memory, beforeUpdate is called before a version
+// is updated, so that the version can be changed by "others" at the same time.
+type fakeCoreService struct {
+	coreservice.CoreServiceClientInterface
+	versions     []mapstr.MapStr
+	beforeUpdate func()
+	updates      int
+	audits       int
+}
+
+func (c *fakeCoreService) Instance() instance.InstanceClientInterface {
+	return &fakeInstanceClient{core: c}
+}
+
+func (c *fakeCoreService) Audit() auditlog.AuditClientInterface {
+	return &fakeAuditClient{core: c}
+}
+
+type fakeInstanceClient struct {
+	instance.InstanceClientInterface
+	core *fakeCoreService
+}
+
+func (c *fakeInstanceClient) ReadInstance(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (*metadata.QueryConditionResult, error) {
+	result := &metadata.QueryConditionResult{BaseResp: metadata.SuccessBaseResp}
+	for _, version := range c.core.versions {
+		if matchFakeCondition(version, input.Condition) {
+			result.Data.Info = append(result.Data.Info, version.Clone())
+		}
+	}
+	return result, nil
+}
+
+func (c *fakeInstanceClient) UpdateInstance(ctx context.Context, h http.Header, objID string, input *metadata.UpdateOption) (*metadata.UpdatedOptionResult, error) {
+	if c.core.beforeUpdate != nil {
+		c.core.beforeUpdate()
+		c.core.beforeUpdate = nil
+	}
+	c.core.updates++
+	result := &metadata.UpdatedOptionResult{BaseResp: metadata.SuccessBaseResp}
+	for _, version := range c.core.versions {
+		if matchFakeCondition(version, input.Condition) {
+			version.Merge(input.Data)
+			result.Data.Count++
+		}
+	}
+	return result, nil
+}
+
+type fakeAuditClient struct {
+	auditlog.AuditClientInterface
+	core *fakeCoreService
+}
+
+func (c *fakeAuditClient) SaveAuditLog(ctx context.Context, h http.Header, logs ...metadata.SaveAuditLogParams) (*metadata.Response, error) {
+	c.core.audits += len(logs)
+	return &metadata.Response{BaseResp: metadata.SuccessBaseResp}, nil
+}
+
+// matchFakeCondition match the equal and $ne conditions, the other operators are ignored
+func matchFakeCondition(version, cond mapstr.MapStr) bool {
+	for key, val := range cond {
+		if operators, ok := val.(mapstr.MapStr); ok {
+			if ne, exists := operators[common.BKDBNE]; exists && fmt.Sprint(version[key]) == fmt.Sprint(ne) {
+				return false
+			}
+			continue
+		}
+		if fmt.Sprint(version[key]) != fmt.Sprint(val) {
+			return false
+		}
+	}
+	return true
+}
+
+func newFakeTemplateVersion(id int64, status, submitter string) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.BKOwnerIDField:           common.BKDefaultOwnerID,
+		common.BKAppIDField:             int64(1),
+		common.BKTemlateIDField:         int64(2),
+		common.BKInstIDField:            id,
+		common.BKStatusField:            status,
+		common.BKTemplateSubmitterField: submitter,
+		common.BKContentField:           "port = 80",
+		common.BKDescriptionField:       "",
+	}
+}
+
+func newTemplateVersionLogics(core *fakeCoreService, user string) *Logics {
+	return &Logics{
+		Engine:  &backbone.Engine{CoreAPI: &fakeClientSet{core: core}},
+		header:  http.Header{},
+		user:    user,
+		ownerID: common.BKDefaultOwnerID,
+		ccErr:   errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+	}
+}
+
+func assertErrorCode(t *testing.T, err error, code int) {
+	if code == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	coder, ok := err.(errors.CCErrorCoder)
+	if !ok || coder.GetCode() != code {
+		t.Fatalf("expect error code %d, got %v", code, err)
+	}
+}
+
+func TestApproveTemplateVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		reviewer string
+		wantCode int
+	}{
+		{"approved by another user", "bob", 0},
+		{"approved by the submitter", "alice", common.CCErrProcTemplateVersionSelfReview},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &fakeCoreService{versions: []mapstr.MapStr{
+				newFakeTemplateVersion(10, common.TemplateStatusReleased, "carol"),
+				newFakeTemplateVersion(11, common.TemplateStatusReviewing, "alice"),
+			}}
+			lgc := newTemplateVersionLogics(core, tt.reviewer)
+
+			err := lgc.ApproveTemplateVersion(context.Background(), 1, 2, 11, "lgtm")
+			assertErrorCode(t, err, tt.wantCode)
+			if tt.wantCode != 0 {
+				if core.updates != 0 || core.audits != 0 {
+					t.Errorf("the version should not be changed, updates: %d, audits: %d", core.updates, core.audits)
+				}
+				return
+			}
+			if status := core.versions[1][common.BKStatusField]; status != common.TemplateStatusReleased {
+				t.Errorf("the approved version should be released, got %v", status)
+			}
+			if reviewer := core.versions[1][common.BKTemplateReviewerField]; reviewer != tt.reviewer {
+				t.Errorf("the reviewer should be %s, got %v", tt.reviewer, reviewer)
+			}
+			if status := core.versions[0][common.BKStatusField]; status != common.TemplateStatusDeprecated {
+				t.Errorf("the version released before should be deprecated, got %v", status)
+			}
+			if core.audits != 1 {
+				t.Errorf("the approval should be audited once, got %d", core.audits)
+			}
+		})
+	}
+}
+
+func TestChangeTemplateVersionStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		changedTo   string
+		wantStatus  string
+		wantCode    int
+		wantUpdates int
+	}{
+		{"submit a draft", common.TemplateStatusDraft, "", common.TemplateStatusReviewing, 0, 1},
+		{"submit a version in reviewing", common.TemplateStatusReviewing, "", common.TemplateStatusReviewing, common.CCErrProcTemplateVersionStatusWrong, 0},
+		{"submit a released version", common.TemplateStatusReleased, "", common.TemplateStatusReleased, common.CCErrProcTemplateVersionStatusWrong, 0},
+		// the version is submitted by another request after it's read, so the update matches nothing
+		{"submitted by others at the same time", common.TemplateStatusDraft, common.TemplateStatusReviewing, common.TemplateStatusReviewing, common.CCErrProcTemplateVersionStatusWrong, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &fakeCoreService{versions: []mapstr.MapStr{newFakeTemplateVersion(11, tt.status, "")}}
+			if tt.changedTo != "" {
+				core.beforeUpdate = func() {
+					core.versions[0][common.BKStatusField] = tt.changedTo
+					core.versions[0][common.BKTemplateSubmitterField] = "bob"
+				}
+			}
+			lgc := newTemplateVersionLogics(core, "alice")
+
+			err := lgc.SubmitTemplateVersion(context.Background(), 1, 2, 11)
+			assertErrorCode(t, err, tt.wantCode)
+			if status := core.versions[0][common.BKStatusField]; status != tt.wantStatus {
+				t.Errorf("the status should be %s, got %v", tt.wantStatus, status)
+			}
+			if core.updates != tt.wantUpdates {
+				t.Errorf("expect %d updates, got %d", tt.wantUpdates, core.updates)
+			}
+			if tt.changedTo != "" && core.versions[0][common.BKTemplateSubmitterField] != "bob" {
+				t.Errorf("the version changed by others should not be overwritten, got %v", core.versions[0])
+			}
+		})
+	}
+}
+
+func TestUpdateTemplateVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		wantCode int
+	}{
+		{"update a draft", common.TemplateStatusDraft, 0},
+		{"update a version in reviewing", common.TemplateStatusReviewing, common.CCErrProcTemplateVersionStatusWrong},
+		{"update a released version", common.TemplateStatusReleased, common.CCErrProcTemplateVersionStatusWrong},
+		{"update a deprecated version", common.TemplateStatusDeprecated, common.CCErrProcTemplateVersionStatusWrong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &fakeCoreService{versions: []mapstr.MapStr{newFakeTemplateVersion(11, tt.status, "")}}
+			lgc := newTemplateVersionLogics(core, "alice")
+
+			params := &metadata.TemplateVersion{Content: "port = 8080", Description: "new port", Status: common.TemplateStatusReleased}
+			err := lgc.UpdateTemplateVersion(context.Background(), 1, 2, 11, params)
+			assertErrorCode(t, err, tt.wantCode)
+
+			wantContent := "port = 80"
+			if tt.wantCode == 0 {
+				wantContent = params.Content
+			}
+			if content := core.versions[0][common.BKContentField]; content != wantContent {
+				t.Errorf("the content should be %q, got %v", wantContent, content)
+			}
+			if status := core.versions[0][common.BKStatusField]; status != tt.status {
+				t.Errorf("the status should not be changed by the update, got %v", status)
+			}
+		})
+	}
+}
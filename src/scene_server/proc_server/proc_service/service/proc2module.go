@@ -51,6 +51,16 @@ func (ps *ProcServer) BindModuleProcess(req *restful.Request, resp *restful.Resp
 	//     return
 	// }
 
+	conflicts, err := srvData.lgc.CheckProcBindModulePortConflict(srvData.ctx, int64(appID), int64(procID), moduleName)
+	if nil != err {
+		blog.Errorf("BindModuleProcess check port conflict error. err:%s, input:%+v,rid:%s", err.Error(), params, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	if writeProcPortConflict(srvData, resp, conflicts) {
+		return
+	}
+
 	ret, err := ps.CoreAPI.ProcController().CreateProc2Module(srvData.ctx, srvData.header, params)
 	if nil != err {
 		blog.Errorf("BindModuleProcess CreateProc2Module http do  error.  err:%s, input:%+v,rid:%s", err.Error(), params, srvData.rid)
@@ -26,6 +26,7 @@ import (
 	meta "configcenter/src/common/metadata"
 	params "configcenter/src/common/paraparse"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/proc_server/logics"
 
 	"github.com/emicklei/go-restful"
 	"github.com/gin-gonic/gin/json"
@@ -57,6 +58,14 @@ func (ps *ProcServer) CreateProcess(req *restful.Request, resp *restful.Response
 		return
 	}
 
+	if input.Exists(common.BKPort) {
+		if err := logics.ValidateProcPort(util.GetStrByInterface(input[common.BKPort])); err != nil {
+			blog.Errorf("create process failed! port is invalid, err: %v,input:%#v,rid:%s", err, input, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrProcPortInvalid, err.Error())})
+			return
+		}
+	}
+
 	input[common.BKAppIDField] = appID
 	input[common.BKOwnerIDField] = ownerID
 	ret, err := ps.CoreAPI.CoreService().Instance().CreateInstance(srvData.ctx, srvData.header, common.BKInnerObjIDProc, &meta.CreateModelInstance{Data: input})
@@ -143,6 +152,18 @@ func (ps *ProcServer) UpdateProcess(req *restful.Request, resp *restful.Response
 		}
 	}
 
+	if isProcPortChanged(procData) {
+		conflicts, err := srvData.lgc.CheckProcUpdatePortConflict(srvData.ctx, int64(appID), map[int64]mapstr.MapStr{int64(procID): procData})
+		if err != nil {
+			blog.Errorf("update process failed! check port conflict err: %v,input:%#v,rid:%s", err, procData, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+			return
+		}
+		if writeProcPortConflict(srvData, resp, conflicts) {
+			return
+		}
+	}
+
 	input := new(meta.UpdateOption)
 	condition := make(map[string]interface{})
 	condition[common.BKOwnerIDField] = ownerID
@@ -235,6 +256,27 @@ func (ps *ProcServer) BatchUpdateProcess(req *restful.Request, resp *restful.Res
 		}
 	}
 
+	if isProcPortChanged(procData) {
+		portData := make(map[int64]mapstr.MapStr, len(procIDArr))
+		for _, procIDStr := range procIDArr {
+			procID, err := strconv.ParseInt(procIDStr, 10, 64)
+			if err != nil {
+				resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+				return
+			}
+			portData[procID] = procData
+		}
+		conflicts, err := srvData.lgc.CheckProcUpdatePortConflict(srvData.ctx, int64(appID), portData)
+		if err != nil {
+			blog.Errorf("batch update process failed! check port conflict err: %v,input:%#v,rid:%s", err, procData, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+			return
+		}
+		if writeProcPortConflict(srvData, resp, conflicts) {
+			return
+		}
+	}
+
 	updatedProcesses := make([]extensions.ProcessSimplify, 0)
 	for index, procIDStr := range procIDArr {
 		procID, err := strconv.Atoi(procIDStr)
@@ -347,6 +389,11 @@ func (ps *ProcServer) BatchUpdateProcess(req *restful.Request, resp *restful.Res
 	resp.WriteEntity(meta.NewSuccessResp(nil))
 }
 
+// isProcPortChanged whether the address the process listens on is changed
+func isProcPortChanged(procData mapstr.MapStr) bool {
+	return procData.Exists(common.BKBindIP) || procData.Exists(common.BKProtocol) || procData.Exists(common.BKPort)
+}
+
 func (ps *ProcServer) DeleteProcess(req *restful.Request, resp *restful.Response) {
 	srvData := ps.newSrvComm(req.Request.Header)
 	defErr := srvData.ccErr
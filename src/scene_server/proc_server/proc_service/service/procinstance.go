@@ -13,6 +13,7 @@ package service
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/emicklei/go-restful"
 	"github.com/gin-gonic/gin/json"
@@ -46,7 +47,12 @@ func (ps *ProcServer) OperateProcessInstance(req *restful.Request, resp *restful
 		return
 	}
 
-	result, err := srvData.lgc.OperateProcInstanceByGse(srvData.ctx, procOpParam, procInstModel)
+	var result string
+	if nil != procOpParam.Batch {
+		result, err = srvData.lgc.CreateProcOpBatchTask(srvData.ctx, procOpParam, procInstModel)
+	} else {
+		result, err = srvData.lgc.OperateProcInstanceByGse(srvData.ctx, procOpParam, procInstModel)
+	}
 	if err != nil {
 		blog.Errorf("operate process failed. err: %v,input:%+v,rid:%s", err, procOpParam, srvData.rid)
 		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrProcOperateFaile)})
@@ -80,6 +86,66 @@ func (ps *ProcServer) QueryProcessOperateResult(req *restful.Request, resp *rest
 	resp.WriteEntity(meta.NewSuccessResp(succ))
 }
 
+func (ps *ProcServer) GetProcOpBatchTask(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	bizID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("get rolling process operate task, but got invalid business id %s,rid:%s", req.PathParameter(common.BKAppIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, common.BKAppIDField)})
+		return
+	}
+	taskID := req.PathParameter("taskID")
+	task, err := srvData.lgc.GetProcOpBatchTask(srvData.ctx, bizID, taskID)
+	if nil != err {
+		blog.Errorf("get rolling process operate task %s failed, err: %v,rid:%s", taskID, err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+	task.HTTPHeader = nil
+	resp.WriteEntity(meta.NewSuccessResp(task))
+}
+
+func (ps *ProcServer) PauseProcOpBatchTask(req *restful.Request, resp *restful.Response) {
+	ps.changeProcOpBatchTask(req, resp, "pause")
+}
+
+func (ps *ProcServer) ResumeProcOpBatchTask(req *restful.Request, resp *restful.Response) {
+	ps.changeProcOpBatchTask(req, resp, "resume")
+}
+
+func (ps *ProcServer) AbortProcOpBatchTask(req *restful.Request, resp *restful.Response) {
+	ps.changeProcOpBatchTask(req, resp, "abort")
+}
+
+func (ps *ProcServer) changeProcOpBatchTask(req *restful.Request, resp *restful.Response, action string) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	bizID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("%s rolling process operate task, but got invalid business id %s,rid:%s", action, req.PathParameter(common.BKAppIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, common.BKAppIDField)})
+		return
+	}
+	taskID := req.PathParameter("taskID")
+	switch action {
+	case "pause":
+		err = srvData.lgc.PauseProcOpBatchTask(srvData.ctx, bizID, taskID)
+	case "resume":
+		err = srvData.lgc.ResumeProcOpBatchTask(srvData.ctx, bizID, taskID)
+	default:
+		err = srvData.lgc.AbortProcOpBatchTask(srvData.ctx, bizID, taskID)
+	}
+	if nil != err {
+		blog.Errorf("%s rolling process operate task %s failed, err: %v,rid:%s", action, taskID, err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
 func (ps *ProcServer) RefreshProcHostInstByEvent(req *restful.Request, resp *restful.Response) {
 	srvData := ps.newSrvComm(req.Request.Header)
 	defErr := srvData.ccErr
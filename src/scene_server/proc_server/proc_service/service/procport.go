@@ -0,0 +1,86 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+)
+
+// writeProcPortConflict reject the request with the port conflicts, return false when no conflict found
+func writeProcPortConflict(srvData *srvComm, resp *restful.Response, conflicts []meta.ProcPortConflict) bool {
+	if 0 == len(conflicts) {
+		return false
+	}
+	blog.Errorf("process port conflict: %+v,rid:%s", conflicts, srvData.rid)
+	resp.WriteError(http.StatusBadRequest, &meta.RespError{
+		Msg:  srvData.ccErr.Errorf(common.CCErrProcPortConflict, conflicts[0].Desc()),
+		Data: conflicts,
+	})
+	return true
+}
+
+// CheckHostPortConflict check whether transferring hosts to the modules cause process port conflicts
+func (ps *ProcServer) CheckHostPortConflict(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("check host port conflict, but got invalid business id %s,rid:%s", req.PathParameter(common.BKAppIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+	input := new(meta.ProcPortCheckParam)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("check host port conflict, decode request body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	conflicts, err := srvData.lgc.CheckHostTransferPortConflict(srvData.ctx, appID, input)
+	if err != nil {
+		blog.Errorf("check host port conflict of business %d failed, input:%+v, err: %v,rid:%s", appID, input, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(conflicts))
+}
+
+// GetBizPortConflicts report the process port conflicts on the hosts of a business
+func (ps *ProcServer) GetBizPortConflicts(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("get port conflicts, but got invalid business id %s,rid:%s", req.PathParameter(common.BKAppIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+
+	conflicts, err := srvData.lgc.GetBizProcPortConflicts(srvData.ctx, appID)
+	if err != nil {
+		blog.Errorf("get port conflicts of business %d failed, err: %v,rid:%s", appID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(conflicts))
+}
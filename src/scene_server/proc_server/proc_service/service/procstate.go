@@ -0,0 +1,75 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+)
+
+// GetProcRunSummary get the expected and the actually running instances of the processes per module
+func (ps *ProcServer) GetProcRunSummary(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("get process run summary, but got invalid business id %s,rid:%s", req.PathParameter(common.BKAppIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+
+	summary, err := srvData.lgc.GetProcRunSummary(srvData.ctx, appID)
+	if err != nil {
+		blog.Errorf("get process run summary of business %d failed, err: %v,rid:%s", appID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(summary))
+}
+
+// SearchProcInstanceState search the running state of the process instances in a business
+func (ps *ProcServer) SearchProcInstanceState(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("search process instance state, but got invalid business id %s,rid:%s", req.PathParameter(common.BKAppIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+	input := new(meta.QueryInput)
+	if err := decodeOptionalBody(req, input); err != nil {
+		blog.Errorf("search process instance state, decode request body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 == input.Limit {
+		input.Limit = common.BKNoLimit
+	}
+
+	states, err := srvData.lgc.SearchProcInstanceState(srvData.ctx, appID, input)
+	if err != nil {
+		blog.Errorf("search process instance state of business %d failed, err: %v,rid:%s", appID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(states))
+}
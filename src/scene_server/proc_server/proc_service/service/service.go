@@ -107,30 +107,50 @@ func (ps *ProcServer) WebService() *restful.Container {
 
 	api.Route(api.GET("/{" + common.BKOwnerIDField + "}/{" + common.BKAppIDField + "}/{" + common.BKProcessIDField + "}").To(ps.GetProcessDetailByID))
 
-	api.Route(api.POST("/operate/process").To(ps.OperateProcessInstance))
+	api.Route(api.POST("/operate/process").To(ps.OperateProcessInstance).Reads(metadata.ProcessOperate{}))
 	api.Route(api.GET("/operate/process/taskresult/{taskID}").To(ps.QueryProcessOperateResult))
+	api.Route(api.GET("/operate/process/batch/{bk_supplier_account}/{bk_biz_id}/{taskID}").To(ps.GetProcOpBatchTask))
+	api.Route(api.POST("/operate/process/batch/pause/{bk_supplier_account}/{bk_biz_id}/{taskID}").To(ps.PauseProcOpBatchTask))
+	api.Route(api.POST("/operate/process/batch/resume/{bk_supplier_account}/{bk_biz_id}/{taskID}").To(ps.ResumeProcOpBatchTask))
+	api.Route(api.POST("/operate/process/batch/abort/{bk_supplier_account}/{bk_biz_id}/{taskID}").To(ps.AbortProcOpBatchTask))
+	api.Route(api.GET("/state/summary/{bk_supplier_account}/{bk_biz_id}").To(ps.GetProcRunSummary).Writes(metadata.ProcModuleRunSummaryResult{}))
+	api.Route(api.POST("/state/search/{bk_supplier_account}/{bk_biz_id}").To(ps.SearchProcInstanceState).Reads(metadata.QueryInput{}).Writes(metadata.ProcInstanceStateResult{}))
+	api.Route(api.POST("/port/conflict/check/{bk_supplier_account}/{bk_biz_id}").To(ps.CheckHostPortConflict).Reads(metadata.ProcPortCheckParam{}).Writes(metadata.ProcPortConflictResult{}))
+	api.Route(api.GET("/port/conflict/{bk_supplier_account}/{bk_biz_id}").To(ps.GetBizPortConflicts).Writes(metadata.ProcPortConflictResult{}))
 
 	api.Route(api.POST("/template/{bk_supplier_account}/{bk_biz_id}").To(ps.CreateTemplate))
 	api.Route(api.PUT("/template/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.UpdateTemplate))
 	api.Route(api.DELETE("/template/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.DeleteTemplate))
 	api.Route(api.POST("/template/search/{bk_supplier_account}/{bk_biz_id}").To(ps.SearchTemplate))
 	api.Route(api.POST("/template/version/search/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.SearchTemplateVersion))
-	api.Route(api.POST("/template/version/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.CreateTemplateVersion))
-	api.Route(api.PUT("/template/vesrion/{bk_supplier_account}/{bk_biz_id}/{template_id}/{version_id}").To(ps.UpdateTemplateVersion))
+	api.Route(api.POST("/template/version/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.CreateTemplateVersion).Reads(metadata.TemplateVersion{}))
+	api.Route(api.PUT("/template/vesrion/{bk_supplier_account}/{bk_biz_id}/{template_id}/{version_id}").To(ps.UpdateTemplateVersion).Reads(metadata.TemplateVersion{}))
+	api.Route(api.POST("/template/version/submit/{bk_supplier_account}/{bk_biz_id}/{template_id}/{version_id}").To(ps.SubmitTemplateVersion))
+	api.Route(api.POST("/template/version/approve/{bk_supplier_account}/{bk_biz_id}/{template_id}/{version_id}").To(ps.ApproveTemplateVersion))
+	api.Route(api.POST("/template/version/reject/{bk_supplier_account}/{bk_biz_id}/{template_id}/{version_id}").To(ps.RejectTemplateVersion))
+	api.Route(api.POST("/template/version/rollback/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.RollbackTemplateVersion).Reads(metadata.TemplateVersionRollback{}))
 	api.Route(api.GET("/template/proc/{bk_supplier_account}/{bk_biz_id}/{bk_process_id}").To(ps.GetProcBindTemplate))
 	api.Route(api.PUT("/template/proc/{bk_supplier_account}/{bk_biz_id}/{bk_process_id}/{template_id}").To(ps.BindProc2Template))
 	api.Route(api.DELETE("/template/proc/{bk_supplier_account}/{bk_biz_id}/{bk_process_id}/{template_id}").To(ps.DeleteProc2Template))
-	api.Route(api.POST("/template/preview/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.PreviewCfg))
-	api.Route(api.POST("/template/create/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.CreateCfg))
+	api.Route(api.POST("/template/preview/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.PreviewCfg).Reads(metadata.FilePriviewMap{}))
+	api.Route(api.POST("/template/create/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.CreateCfg).Reads(metadata.CreateConfigFileParams{}))
 	api.Route(api.POST("/template/push/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.PushCfg))
 	api.Route(api.POST("/template/getremote/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.GetRemoteCfg))
+	api.Route(api.POST("/template/diff/{bk_supplier_account}/{bk_biz_id}/{template_id}").To(ps.DiffCfg))
 	api.Route(api.GET("/template/group/{bk_supplier_account}/{bk_biz_id}").To(ps.GetTemplateGroup))
+	api.Route(api.POST("/template/variable/{bk_supplier_account}/{bk_biz_id}").To(ps.CreateTemplateVariable).Reads(metadata.ProcTemplateVariable{}).Writes(metadata.ProcTemplateVariableCreateResult{}))
+	api.Route(api.PUT("/template/variable/{bk_supplier_account}/{bk_biz_id}/{variable_id}").To(ps.UpdateTemplateVariable).Reads(metadata.ProcTemplateVariable{}))
+	api.Route(api.DELETE("/template/variable/{bk_supplier_account}/{bk_biz_id}/{variable_id}").To(ps.DeleteTemplateVariable))
+	api.Route(api.POST("/template/variable/search/{bk_supplier_account}/{bk_biz_id}").To(ps.SearchTemplateVariable).Reads(metadata.QueryInput{}).Writes(metadata.ProcTemplateVariableResult{}))
+	api.Route(api.PUT("/template/variable/value/{bk_supplier_account}/{bk_biz_id}/{variable_id}").To(ps.SetTemplateVariableValue).Reads(metadata.ProcVariableOverride{}))
+	api.Route(api.DELETE("/template/variable/value/{bk_supplier_account}/{bk_biz_id}/{variable_id}/{bk_obj_id}/{bk_inst_id}").To(ps.DeleteTemplateVariableValue))
+	api.Route(api.POST("/template/variable/effective/{bk_supplier_account}/{bk_biz_id}").To(ps.GetEffectiveTemplateVariables).Reads(metadata.ProcEffectiveVariableParam{}).Writes(metadata.ProcEffectiveVariablesResult{}))
 
 	//v2
 	api.Route(api.POST("/openapi/GetProcessPortByApplicationID/{" + common.BKAppIDField + "}").To(ps.GetProcessPortByApplicationID))
 	api.Route(api.POST("/openapi/GetProcessPortByIP").To(ps.GetProcessPortByIP))
 
-	api.Route(api.POST("/process/refresh/hostinstnum").To(ps.RefreshProcHostInstByEvent))
+	api.Route(api.POST("/process/refresh/hostinstnum").To(ps.RefreshProcHostInstByEvent).Reads(metadata.EventInst{}))
 
 	container.Add(api)
 
@@ -245,5 +265,11 @@ func (ps *ProcServer) OnProcessConfigUpdate(previous, current cfnc.ProcessConfig
 			procHostInstConfig.GetModuleIDInterval = time.Duration(get_mid_interval) * time.Second
 		}
 	}
+	if val, ok := current.ConfigMap["procstate.interval"]; ok {
+		interval, err := util.GetIntByInterface(val)
+		if nil == err {
+			procHostInstConfig.ProcStateInterval = time.Duration(interval) * time.Second
+		}
+	}
 	ps.ConfigMap = current.ConfigMap
 }
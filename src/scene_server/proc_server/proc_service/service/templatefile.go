@@ -13,6 +13,8 @@
 package service
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -21,6 +23,7 @@ import (
 	"configcenter/src/common/blog"
 	types "configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
+	"configcenter/src/scene_server/proc_server/logics"
 
 	"github.com/emicklei/go-restful"
 	"github.com/flosch/pongo2"
@@ -47,7 +50,7 @@ func (ps *ProcServer) PreviewCfg(req *restful.Request, resp *restful.Response) {
 	}
 
 	instArr := strings.Split(params.Inst, ".")
-	if 0 != len(instArr) {
+	if 4 != len(instArr) {
 		blog.Errorf("inst params error: %v,input:%+v,rid:%s", err, params, srvData.rid)
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
 		return
@@ -59,20 +62,25 @@ func (ps *ProcServer) PreviewCfg(req *restful.Request, resp *restful.Response) {
 	instIDStr := instArr[3]
 
 	funcID, err := strconv.ParseInt(funIDStr, 10, 64)
-	if 0 != len(instArr) {
+	if nil != err {
 		blog.Errorf("funcID params error: %v,input:%+v,rid:%s", err, params, srvData.rid)
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
 		return
 	}
 	instID, err := strconv.ParseInt(instIDStr, 10, 64)
-	if 0 != len(instArr) {
+	if nil != err {
 		blog.Errorf("inst params error: %v,input:%+v,rid:%s", err, params, srvData.rid)
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
 		return
 	}
 
 	variables := srvData.lgc.NewVariables(srvData.ctx, appID)
-	vars := variables.GetStandVariables(srvData.ctx, setName, moduleName, funcID, instID)
+	vars, err := variables.GetStandVariables(srvData.ctx, setName, moduleName, funcID, instID)
+	if err != nil {
+		blog.Errorf("get variables of the process instance failed, err: %v,input:%+v,rid:%s", err, params, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
 	tpl, err := pongo2.FromString(params.Content)
 	if err != nil {
 		blog.Errorf("content params error: %v,input:%+v,rid:%s", err, params, srvData.rid)
@@ -91,19 +99,103 @@ func (ps *ProcServer) PreviewCfg(req *restful.Request, resp *restful.Response) {
 	resp.WriteEntity(meta.NewSuccessResp(result))
 }
 
+// CreateCfg render the config files of the processes bound to the template
 func (ps *ProcServer) CreateCfg(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
 
+	appID, templateID, err := parseTemplatePathParams(req)
+	if nil != err {
+		blog.Errorf("create config file failed! err: %v,input:%+v,rid:%s", err, req.PathParameters(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+
+	params := new(meta.CreateConfigFileParams)
+	if err := decodeOptionalBody(req, params); err != nil {
+		blog.Errorf("create config file failed! decode request body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	files, err := srvData.lgc.GenerateConfigFiles(srvData.ctx, appID, templateID, params)
+	if err != nil {
+		blog.Errorf("create config file of template %d failed, err: %v,input:%+v,rid:%s", templateID, err, params, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(files))
 }
 
+// PushCfg push the generated config files of the template to the hosts
 func (ps *ProcServer) PushCfg(req *restful.Request, resp *restful.Response) {
-
+	ps.handleConfigFiles(req, resp, "push", (*logics.Logics).PushConfigFiles)
 }
 
+// GetRemoteCfg get the content of the config files of the template on the hosts
 func (ps *ProcServer) GetRemoteCfg(req *restful.Request, resp *restful.Response) {
-
+	ps.handleConfigFiles(req, resp, "get remote", (*logics.Logics).FetchRemoteConfigFiles)
 }
 
-// If it is necessary
+// DiffCfg compare the config files on the hosts with the generated config files
 func (ps *ProcServer) DiffCfg(req *restful.Request, resp *restful.Response) {
+	ps.handleConfigFiles(req, resp, "diff", (*logics.Logics).DiffConfigFiles)
+}
+
+type configFileHandler func(lgc *logics.Logics, ctx context.Context, distributor logics.ConfigFileDistributor, appID, templateID int64, hostIDs []int64) ([]meta.ConfigFileResult, error)
+
+func (ps *ProcServer) handleConfigFiles(req *restful.Request, resp *restful.Response, op string, handler configFileHandler) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
 
+	appID, templateID, err := parseTemplatePathParams(req)
+	if nil != err {
+		blog.Errorf("%s config file failed! err: %v,input:%+v,rid:%s", op, err, req.PathParameters(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+
+	params := new(meta.ConfigFileHostParams)
+	if err := decodeOptionalBody(req, params); err != nil {
+		blog.Errorf("%s config file failed! decode request body err: %v,rid:%s", op, err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	distributor, err := logics.NewConfigFileDistributor(ps.ConfigMap, ps.EsbServ)
+	if err != nil {
+		blog.Errorf("%s config file failed! create the distributor err: %v,rid:%s", op, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrProcDistributeConfigFileFail)})
+		return
+	}
+
+	results, err := handler(srvData.lgc, srvData.ctx, distributor, appID, templateID, params.HostIDs)
+	if err != nil {
+		blog.Errorf("%s config file of template %d failed, err: %v,input:%+v,rid:%s", op, templateID, err, params, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(results))
+}
+
+func parseTemplatePathParams(req *restful.Request) (appID, templateID int64, err error) {
+	appID, err = strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	templateID, err = strconv.ParseInt(req.PathParameter(common.BKTemlateIDField), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return appID, templateID, nil
+}
+
+// decodeOptionalBody decode the request body, the body can be empty
+func decodeOptionalBody(req *restful.Request, v interface{}) error {
+	if err := json.NewDecoder(req.Request.Body).Decode(v); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
 }
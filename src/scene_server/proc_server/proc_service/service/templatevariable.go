@@ -0,0 +1,229 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+)
+
+func parseTemplateVariablePathParams(req *restful.Request) (appID, variableID int64, err error) {
+	appID, err = strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	variableID, err = strconv.ParseInt(req.PathParameter("variable_id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return appID, variableID, nil
+}
+
+// CreateTemplateVariable define a variable used by the config templates of the business
+func (ps *ProcServer) CreateTemplateVariable(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("create template variable, but got invalid business id %s,rid:%s", req.PathParameter(common.BKAppIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+	input := new(meta.ProcTemplateVariable)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("create template variable, decode request body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	input.OwnerID = srvData.ownerID
+	input.Creator = srvData.user
+	input.Modifier = srvData.user
+
+	variable, err := srvData.lgc.CreateProcTemplateVariable(srvData.ctx, appID, input)
+	if err != nil {
+		blog.Errorf("create template variable of business %d failed, input:%+v, err: %v,rid:%s", appID, input, err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(variable))
+}
+
+// UpdateTemplateVariable change the definition of the variable
+func (ps *ProcServer) UpdateTemplateVariable(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, variableID, err := parseTemplateVariablePathParams(req)
+	if err != nil {
+		blog.Errorf("update template variable failed! err: %v,input:%+v,rid:%s", err, req.PathParameters(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+	input := new(meta.ProcTemplateVariable)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("update template variable, decode request body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	input.Modifier = srvData.user
+
+	if err := srvData.lgc.UpdateProcTemplateVariable(srvData.ctx, appID, variableID, input); err != nil {
+		blog.Errorf("update template variable %d of business %d failed, input:%+v, err: %v,rid:%s", variableID, appID, input, err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// DeleteTemplateVariable delete the variable with the values overridden on the sets, modules and hosts
+func (ps *ProcServer) DeleteTemplateVariable(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, variableID, err := parseTemplateVariablePathParams(req)
+	if err != nil {
+		blog.Errorf("delete template variable failed! err: %v,input:%+v,rid:%s", err, req.PathParameters(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+
+	if err := srvData.lgc.DeleteProcTemplateVariable(srvData.ctx, appID, variableID); err != nil {
+		blog.Errorf("delete template variable %d of business %d failed, err: %v,rid:%s", variableID, appID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchTemplateVariable search the variables of the business
+func (ps *ProcServer) SearchTemplateVariable(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("search template variable, but got invalid business id %s,rid:%s", req.PathParameter(common.BKAppIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+	input := new(meta.QueryInput)
+	if err := decodeOptionalBody(req, input); err != nil {
+		blog.Errorf("search template variable, decode request body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 == input.Limit {
+		input.Limit = common.BKNoLimit
+	}
+
+	data, err := srvData.lgc.SearchProcTemplateVariable(srvData.ctx, appID, input)
+	if err != nil {
+		blog.Errorf("search template variable of business %d failed, input:%+v, err: %v,rid:%s", appID, input, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(data))
+}
+
+// SetTemplateVariableValue override the value of the variable on a set, module or host
+func (ps *ProcServer) SetTemplateVariableValue(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, variableID, err := parseTemplateVariablePathParams(req)
+	if err != nil {
+		blog.Errorf("set template variable value failed! err: %v,input:%+v,rid:%s", err, req.PathParameters(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+	input := meta.ProcVariableOverride{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("set template variable value, decode request body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := srvData.lgc.SetProcTemplateVariableValue(srvData.ctx, appID, variableID, input); err != nil {
+		blog.Errorf("set value of template variable %d of business %d failed, input:%+v, err: %v,rid:%s", variableID, appID, input, err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// DeleteTemplateVariableValue remove the value of the variable overridden on a set, module or host
+func (ps *ProcServer) DeleteTemplateVariableValue(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, variableID, err := parseTemplateVariablePathParams(req)
+	if err != nil {
+		blog.Errorf("delete template variable value failed! err: %v,input:%+v,rid:%s", err, req.PathParameters(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+	objID := req.PathParameter(common.BKObjIDField)
+	instID, err := strconv.ParseInt(req.PathParameter(common.BKInstIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("delete template variable value, but got invalid instance id %s,rid:%s", req.PathParameter(common.BKInstIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+
+	if err := srvData.lgc.DeleteProcTemplateVariableValue(srvData.ctx, appID, variableID, objID, instID); err != nil {
+		blog.Errorf("delete value of template variable %d of business %d on %s %d failed, err: %v,rid:%s", variableID, appID, objID, instID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// GetEffectiveTemplateVariables get the variables used to render the config templates for a process instance
+func (ps *ProcServer) GetEffectiveTemplateVariables(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("get effective template variables, but got invalid business id %s,rid:%s", req.PathParameter(common.BKAppIDField), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+	input := new(meta.ProcEffectiveVariableParam)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("get effective template variables, decode request body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 == input.ProcID || 0 == input.ModuleID || 0 == input.HostID {
+		blog.Errorf("get effective template variables, but the process instance is not set, input:%+v,rid:%s", input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+
+	variables, err := srvData.lgc.GetEffectiveProcVariables(srvData.ctx, appID, input)
+	if err != nil {
+		blog.Errorf("get effective template variables of business %d failed, input:%+v, err: %v,rid:%s", appID, input, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(variables))
+}
@@ -13,6 +13,7 @@
 package service
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"time"
@@ -21,6 +22,7 @@ import (
 	"configcenter/src/common/blog"
 	types "configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
+	"configcenter/src/scene_server/proc_server/logics"
 	"configcenter/src/scene_server/validator"
 
 	"github.com/emicklei/go-restful"
@@ -79,6 +81,8 @@ func (ps *ProcServer) SearchTemplateVersion(req *restful.Request, resp *restful.
 	resp.WriteEntity(meta.NewSuccessResp(ret.Data.Info))
 }
 
+// CreateTemplateVersion create a version of the template, the version is always created as a draft,
+// and the status in the request is ignored, a version is released by submitting and approving it.
 func (ps *ProcServer) CreateTemplateVersion(req *restful.Request, resp *restful.Response) {
 	srvData := ps.newSrvComm(req.Request.Header)
 	defErr := srvData.ccErr
@@ -113,7 +117,7 @@ func (ps *ProcServer) CreateTemplateVersion(req *restful.Request, resp *restful.
 		common.BKOperatorField:    user,
 		common.BKTemlateIDField:   templateID,
 		common.BKContentField:     params.Content,
-		common.BKStatusField:      params.Status,
+		common.BKStatusField:      common.TemplateStatusDraft,
 		common.BKDescriptionField: params.Description}
 	valid := validator.NewValidMap(ownerID, common.BKInnerObjIDTempVersion, srvData.header, ps.Engine)
 	if err := valid.ValidMap(input, common.ValidCreate, 0); err != nil {
@@ -128,6 +132,7 @@ func (ps *ProcServer) CreateTemplateVersion(req *restful.Request, resp *restful.
 	if err != nil {
 		blog.Errorf("CreateTemplateVersion CreateObject http do error,err:%s,input:%+v,query:%+v,rid:%s", err.Error(), params, input, srvData.rid)
 		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
 	}
 	if !ret.Result {
 		blog.Errorf("CreateTemplateVersion  CreateObject http response error,err code:%d,err msg:%s,input:%+v,query:%+v,rid:%s", ret.Code, ret.ErrMsg, params, input, srvData.rid)
@@ -135,28 +140,6 @@ func (ps *ProcServer) CreateTemplateVersion(req *restful.Request, resp *restful.
 		return
 	}
 
-	versionID := ret.Data.Created.ID
-
-	//only one online status
-	if params.Status == common.TemplateStatusOnline {
-		data := types.MapStr{common.BKStatusField: common.TemplateStatusHistory}
-		condition := types.MapStr{
-			common.BKTemlateIDField: types.MapStr{common.BKDBEQ: versionID},
-			common.BKOwnerIDField:   ownerID,
-			common.BKStatusField:    common.TemplateStatusOnline}
-		input := &meta.UpdateOption{Condition: condition, Data: data}
-		ret, err := ps.CoreAPI.CoreService().Instance().UpdateInstance(srvData.ctx, srvData.header, common.BKInnerObjIDTempVersion, input)
-		if err != nil {
-			blog.Errorf("CreateTemplateVersion UpdateObject http do error,err:%s,input:%+v,query:%+v,rid:%s", err.Error(), params, input, srvData.rid)
-			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
-		}
-		if !ret.Result {
-			blog.Errorf("CreateTemplateVersion  UpdateObject http response error,err code:%d,err msg:%s,input:%+v,query:%+v,rid:%s", ret.Code, ret.ErrMsg, params, input, srvData.rid)
-			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.New(ret.Code, ret.ErrMsg)})
-			return
-		}
-	}
-
 	resp.WriteEntity(meta.NewSuccessResp(nil))
 }
 
@@ -164,7 +147,6 @@ func (ps *ProcServer) UpdateTemplateVersion(req *restful.Request, resp *restful.
 	srvData := ps.newSrvComm(req.Request.Header)
 
 	defErr := srvData.ccErr
-	ownerID := srvData.ownerID
 
 	appIDStr := req.PathParameter(common.BKAppIDField)
 	appID, err := strconv.ParseInt(appIDStr, 10, 64)
@@ -196,46 +178,100 @@ func (ps *ProcServer) UpdateTemplateVersion(req *restful.Request, resp *restful.
 		return
 	}
 
-	condition := types.MapStr{
-		common.BKAppIDField:     appID,
-		common.BKTemlateIDField: templateID,
-		common.BKVersionIDField: versionID}
-	data := types.NewFromStruct(params, "field")
+	if err := srvData.lgc.UpdateTemplateVersion(srvData.ctx, appID, templateID, versionID, &params); err != nil {
+		blog.Errorf("UpdateTemplateVersion failed, err: %v,input:%+v,rid:%s", err, params, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
 
-	input := &meta.UpdateOption{Condition: condition, Data: data}
+// SubmitTemplateVersion submit the draft version for reviewing
+func (ps *ProcServer) SubmitTemplateVersion(req *restful.Request, resp *restful.Response) {
+	ps.reviewTemplateVersion(req, resp, "submit", func(lgc *logics.Logics, ctx context.Context, appID, templateID, versionID int64, comment string) error {
+		return lgc.SubmitTemplateVersion(ctx, appID, templateID, versionID)
+	})
+}
 
-	ret, err := ps.CoreAPI.CoreService().Instance().UpdateInstance(srvData.ctx, srvData.header, common.BKInnerObjIDTempVersion, input)
-	if err != nil {
-		blog.Errorf("UpdateTemplateVersion UpdateObject http do error,err:%s,input:%+v,query:%+v,rid:%s", err.Error(), params, input, srvData.rid)
-		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+// ApproveTemplateVersion approve the version in reviewing and release it
+func (ps *ProcServer) ApproveTemplateVersion(req *restful.Request, resp *restful.Response) {
+	ps.reviewTemplateVersion(req, resp, "approve", (*logics.Logics).ApproveTemplateVersion)
+}
+
+// RejectTemplateVersion reject the version in reviewing
+func (ps *ProcServer) RejectTemplateVersion(req *restful.Request, resp *restful.Response) {
+	ps.reviewTemplateVersion(req, resp, "reject", (*logics.Logics).RejectTemplateVersion)
+}
+
+type templateVersionReviewer func(lgc *logics.Logics, ctx context.Context, appID, templateID, versionID int64, comment string) error
+
+func (ps *ProcServer) reviewTemplateVersion(req *restful.Request, resp *restful.Response, op string, reviewer templateVersionReviewer) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, templateID, err := parseTemplatePathParams(req)
+	if nil != err {
+		blog.Errorf("%s template version failed! err: %v,input:%+v,rid:%s", op, err, req.PathParameters(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
 	}
-	if !ret.Result {
-		blog.Errorf("UpdateTemplateVersion  UpdateObject http response error,err code:%d,err msg:%s,input:%+v,query:%+v,rid:%s", ret.Code, ret.ErrMsg, params, input, srvData.rid)
-		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.New(ret.Code, ret.ErrMsg)})
+	versionID, err := strconv.ParseInt(req.PathParameter(common.BKVersionIDField), 10, 64)
+	if nil != err {
+		blog.Errorf("%s template version failed! err: %v,input:%+v,rid:%s", op, err, req.PathParameters(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
 		return
 	}
 
-	//only one online status
-	if params.Status == common.TemplateStatusOnline {
-		data := types.MapStr{common.BKStatusField: common.TemplateStatusHistory}
-		condition := types.MapStr{
-			common.BKTemlateIDField: templateID,
-			common.BKVersionIDField: types.MapStr{common.BKDBNE: versionID},
-			common.BKOwnerIDField:   ownerID,
-			common.BKStatusField:    common.TemplateStatusOnline}
+	params := new(meta.TemplateVersionReview)
+	if err := decodeOptionalBody(req, params); err != nil {
+		blog.Errorf("%s template version failed! decode request body err: %v,rid:%s", op, err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
 
-		input := &meta.UpdateOption{Condition: condition, Data: data}
-		ret, err := ps.CoreAPI.CoreService().Instance().UpdateInstance(srvData.ctx, srvData.header, common.BKInnerObjIDTempVersion, input)
-		if err != nil {
-			blog.Errorf("UpdateTemplateVersion UpdateObject http do error,err:%s,input:%+v,query:%+v,rid:%s", err.Error(), params, input, srvData.rid)
-			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
-		}
-		if !ret.Result {
-			blog.Errorf("UpdateTemplateVersion  UpdateObject http response error,err code:%d,err msg:%s,input:%+v,query:%+v,rid:%s", ret.Code, ret.ErrMsg, params, input, srvData.rid)
-			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.New(ret.Code, ret.ErrMsg)})
-			return
-		}
+	if err := reviewer(srvData.lgc, srvData.ctx, appID, templateID, versionID, params.Comment); err != nil {
+		blog.Errorf("%s version %d of template %d failed, err: %v,rid:%s", op, versionID, templateID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
 	}
 
 	resp.WriteEntity(meta.NewSuccessResp(nil))
 }
+
+// RollbackTemplateVersion release a deprecated version of the template again, and push the config
+// files rendered from it to all the bound process instances
+func (ps *ProcServer) RollbackTemplateVersion(req *restful.Request, resp *restful.Response) {
+	srvData := ps.newSrvComm(req.Request.Header)
+	defErr := srvData.ccErr
+
+	appID, templateID, err := parseTemplatePathParams(req)
+	if nil != err {
+		blog.Errorf("rollback template version failed! err: %v,input:%+v,rid:%s", err, req.PathParameters(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPInputInvalid)})
+		return
+	}
+
+	params := new(meta.TemplateVersionRollback)
+	if err := decodeOptionalBody(req, params); err != nil {
+		blog.Errorf("rollback template version failed! decode request body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	distributor, err := logics.NewConfigFileDistributor(ps.ConfigMap, ps.EsbServ)
+	if err != nil {
+		blog.Errorf("rollback template version failed! create the distributor err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrProcDistributeConfigFileFail)})
+		return
+	}
+
+	result, err := srvData.lgc.RollbackTemplateVersion(srvData.ctx, distributor, appID, templateID, params.VersionID)
+	if err != nil {
+		blog.Errorf("rollback template %d to version %d failed, err: %v,rid:%s", templateID, params.VersionID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(result))
+}
@@ -38,6 +38,7 @@ type Core interface {
 	AuditOperation() operation.AuditOperationInterface
 	HealthOperation() operation.HealthOperationInterface
 	UniqueOperation() operation.UniqueOperationInterface
+	HistoryOperation() operation.HistoryOperationInterface
 }
 
 type core struct {
@@ -57,6 +58,7 @@ type core struct {
 	identifier     operation.IdentifierOperationInterface
 	health         operation.HealthOperationInterface
 	unique         operation.UniqueOperationInterface
+	history        operation.HistoryOperationInterface
 }
 
 // New create a core manager
@@ -81,6 +83,7 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 	identifier := operation.NewIdentifier(client)
 	audit := operation.NewAuditOperation(client)
 	unique := operation.NewUniqueOperation(client, authManager)
+	history := operation.NewHistoryOperation(client)
 
 	targetModel := model.New(client)
 	targetInst := inst.New(client)
@@ -98,6 +101,7 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 	businessOperation.SetProxy(setOperation, moduleOperation, instOperation, objectOperation)
 
 	graphics.SetProxy(objectOperation, associationOperation)
+	history.SetProxy(objectOperation, instOperation)
 
 	return &core{
 		set:            setOperation,
@@ -116,6 +120,7 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 		identifier:     identifier,
 		health:         healthOpeartion,
 		unique:         unique,
+		history:        history,
 	}
 }
 
@@ -170,3 +175,6 @@ func (c *core) HealthOperation() operation.HealthOperationInterface {
 func (c *core) UniqueOperation() operation.UniqueOperationInterface {
 	return c.unique
 }
+func (c *core) HistoryOperation() operation.HistoryOperationInterface {
+	return c.history
+}
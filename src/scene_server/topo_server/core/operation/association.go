@@ -83,6 +83,8 @@ type AssociationOperationInterface interface {
 	CheckBeAssociation(params types.ContextParams, obj model.Object, cond condition.Condition) error
 	CreateCommonInstAssociation(params types.ContextParams, data *metadata.InstAsst) error
 	DeleteInstAssociation(params types.ContextParams, cond condition.Condition) error
+	PlanInstAssociationDelete(params types.ContextParams, obj model.Object, instIDs []int64) (*metadata.InstAsstDeletePlan, error)
+	FindAssociationViolations(params types.ContextParams, request *metadata.FindAssociationViolationsRequest) ([]metadata.AssociationViolation, error)
 
 	// 关联关系改造后的接口
 	SearchObjectAssoWithAssoKindList(params types.ContextParams, asstKindIDs []string) (resp *metadata.AssociationList, err error)
@@ -289,6 +291,24 @@ func (a *association) DeleteInstAssociation(params types.ContextParams, cond con
 	return nil
 }
 
+// PlanInstAssociationDelete work out the instances and associations affected by deleting the instances,
+// according to the on delete action of the object associations.
+func (a *association) PlanInstAssociationDelete(params types.ContextParams, obj model.Object, instIDs []int64) (*metadata.InstAsstDeletePlan, error) {
+	input := &metadata.InstAsstDeletePlanRequest{ObjectID: obj.GetObjectID(), InstIDs: instIDs}
+	rsp, err := a.clientSet.CoreService().Association().PlanInstAssociationDelete(context.Background(), params.Header, input)
+	if nil != err {
+		blog.Errorf("[operation-asst] failed to request core service, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-asst] failed to plan the inst association delete of %s %v, err: %s, rid: %s", obj.GetObjectID(), instIDs, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return &rsp.Data, nil
+}
+
 func (a *association) CreateCommonInstAssociation(params types.ContextParams, data *metadata.InstAsst) error {
 	// create a new
 	rspAsst, err := a.clientSet.CoreService().Association().CreateInstAssociation(context.Background(), params.Header, &metadata.CreateOneInstanceAssociation{Data: *data})
@@ -525,6 +545,22 @@ func (a *association) CheckBeAssociation(params types.ContextParams, obj model.O
 	return nil
 }
 
+// FindAssociationViolations find the instances which violate the mapping and max count constraints of the object associations
+func (a *association) FindAssociationViolations(params types.ContextParams, request *metadata.FindAssociationViolationsRequest) ([]metadata.AssociationViolation, error) {
+	rsp, err := a.clientSet.CoreService().Association().FindInstAssociationViolations(context.Background(), params.Header, request)
+	if nil != err {
+		blog.Errorf("[operation-asst] failed to request core service, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-asst] failed to find the association violations of %v, err: %s, rid: %s", request.ObjectAsstIDs, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return rsp.Data, nil
+}
+
 // 关联关系改造后的接口
 func (a *association) SearchObjectAssoWithAssoKindList(params types.ContextParams, asstKindIDs []string) (resp *metadata.AssociationList, err error) {
 	if len(asstKindIDs) == 0 {
@@ -776,65 +812,7 @@ func (a *association) CreateInst(params types.ContextParams, request *metadata.C
 	objID := objectAsst.ObjectID
 	asstObjID := objectAsst.AsstObjID
 
-	switch result.Data[0].Mapping {
-	case metadata.OneToOneMapping:
-		// search instances belongs to this association.
-		cond := condition.CreateCondition()
-		cond.Field(common.AssociationObjAsstIDField).Eq(request.ObjectAsstID)
-		cond.Field(common.BKInstIDField).Eq(request.InstID)
-		inst, err := a.SearchInst(params, &metadata.SearchAssociationInstRequest{Condition: cond.ToMapStr()})
-		if err != nil {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %v, rid: %s", cond, err, params.ReqID)
-			return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
-		}
-
-		if !inst.Result {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %s, rid: %s", cond, resp.ErrMsg, params.ReqID)
-			return nil, params.Err.New(resp.Code, resp.ErrMsg)
-		}
-		if len(inst.Data) >= 1 {
-			return nil, params.Err.Error(common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation)
-		}
-
-		cond = condition.CreateCondition()
-		cond.Field(common.AssociationObjAsstIDField).Eq(request.ObjectAsstID)
-		cond.Field(common.BKAsstInstIDField).Eq(request.AsstInstID)
-
-		inst, err = a.SearchInst(params, &metadata.SearchAssociationInstRequest{Condition: cond.ToMapStr()})
-		if err != nil {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %v, rid: %s", cond, err, params.ReqID)
-			return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
-		}
-
-		if !inst.Result {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %s, rid: %s", cond, resp.ErrMsg, params.ReqID)
-			return nil, params.Err.New(resp.Code, resp.ErrMsg)
-		}
-		if len(inst.Data) >= 1 {
-			return nil, params.Err.Error(common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation)
-		}
-	case metadata.OneToManyMapping:
-		cond = condition.CreateCondition()
-		cond.Field(common.AssociationObjAsstIDField).Eq(request.ObjectAsstID)
-		cond.Field(common.BKAsstInstIDField).Eq(request.AsstInstID)
-
-		inst, err := a.SearchInst(params, &metadata.SearchAssociationInstRequest{Condition: cond.ToMapStr()})
-		if err != nil {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %v", cond, err)
-			return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
-		}
-
-		if !inst.Result {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %s", cond, resp.ErrMsg)
-			return nil, params.Err.New(resp.Code, resp.ErrMsg)
-		}
-		if len(inst.Data) >= 1 {
-			return nil, params.Err.Error(common.CCErrorTopoCreateMultipleInstancesForOneToManyAssociation)
-		}
-
-	default:
-		// after all the check, new association instance can be created.
-	}
+	// the mapping and the max count constraints are checked by core service when the association is created.
 
 	input := metadata.CreateOneInstanceAssociation{
 		Data: metadata.InstAsst{
@@ -851,6 +829,10 @@ func (a *association) CreateInst(params types.ContextParams, request *metadata.C
 		blog.Errorf("create instance association failed, do coreservice create failed, err: %+v, rid: %s", err, params.ReqID)
 		return nil, err
 	}
+	if !createResult.Result {
+		blog.Errorf("create instance association failed, do coreservice create failed, err: %s, rid: %s", createResult.ErrMsg, params.ReqID)
+		return nil, params.Err.New(createResult.Code, createResult.ErrMsg)
+	}
 
 	resp = &metadata.CreateAssociationInstResult{BaseResp: createResult.BaseResp}
 	instanceAssociationID := int64(createResult.Data.Created.ID)
@@ -0,0 +1,438 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/model"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// HistoryOperationInterface reconstruct the instances and topology as it was at a given time.
+//
+// the data at time T is replayed from the audit logs: the earliest log of an instance which happens
+// after T decides its state. if it is a create log, the instance does not exist at T, if it is an
+// update or delete log, the pre data of the log is the state at T. an instance without any log
+// after T is not changed since then, so its current data is used.
+type HistoryOperationInterface interface {
+	FindInstAsOf(params types.ContextParams, obj model.Object, instID int64, asOf time.Time) (*metadata.InstanceAsOf, error)
+	FindHostModulesAsOf(params types.ContextParams, hostID int64, asOf time.Time) (*metadata.HostModulesAsOf, error)
+	SearchBusinessTopoAsOf(params types.ContextParams, bizObj model.Object, bizID int64, asOf time.Time) ([]*metadata.TopoInstRst, error)
+
+	SetProxy(obj ObjectOperationInterface, inst InstOperationInterface)
+}
+
+const (
+	// historyLogPageSize the page size when replay the audit logs
+	historyLogPageSize = 500
+	// historyLogMaxCount the max count of the audit logs replayed in one query, a time too early
+	// which needs more logs than this is refused, so that the logs never run the server out of memory.
+	historyLogMaxCount = 100000
+	// historyLogFieldInstID the instance id field of audit log
+	historyLogFieldInstID = "inst_id"
+)
+
+// NewHistoryOperation create a new history operation instance
+func NewHistoryOperation(client apimachinery.ClientSetInterface) HistoryOperationInterface {
+	return &history{
+		clientSet: client,
+	}
+}
+
+type history struct {
+	clientSet apimachinery.ClientSetInterface
+	obj       ObjectOperationInterface
+	inst      InstOperationInterface
+}
+
+func (h *history) SetProxy(obj ObjectOperationInterface, inst InstOperationInterface) {
+	h.obj = obj
+	h.inst = inst
+}
+
+// scanLogsAfter scan the audit logs matching the condition which happens after the given time page by page in
+// time order, the handle returns false to stop scanning when the rest logs are not needed any more.
+func (h *history) scanLogsAfter(params types.ContextParams, cond mapstr.MapStr, asOf time.Time, handle func(logs []metadata.OperationLog) bool) error {
+	cond.Set(common.BKOwnerIDField, params.SupplierAccount)
+	// the time is passed with the sub-second part, so that the logs inside the same second before it are excluded.
+	cond.Set(common.BKOpTimeField, mapstr.MapStr{
+		common.BKDBGT:              asOf.UTC().Format(time.RFC3339Nano),
+		common.BKTimeTypeParseFlag: "1",
+	})
+
+	for start := 0; ; start += historyLogPageSize {
+		if start >= historyLogMaxCount {
+			blog.Errorf("[operation-history] too many audit logs after %s, condition: %#v, rid: %s", asOf, cond, params.ReqID)
+			return params.Err.Errorf(common.CCErrCommXXExceedLimit, "audit logs", historyLogMaxCount)
+		}
+		query := metadata.QueryInput{
+			Condition: cond,
+			Start:     start,
+			Limit:     historyLogPageSize,
+			Sort:      common.BKOpTimeField,
+		}
+		rsp, err := h.clientSet.CoreService().Audit().SearchAuditLog(params.Context, params.Header, query)
+		if nil != err {
+			blog.Errorf("[operation-history] failed to search audit logs, condition: %#v, err: %s, rid: %s", cond, err.Error(), params.ReqID)
+			return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !rsp.Result {
+			blog.Errorf("[operation-history] failed to search audit logs, condition: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, params.ReqID)
+			return params.Err.New(rsp.Code, rsp.ErrMsg)
+		}
+
+		if !handle(logsAfter(rsp.Data.Info, asOf)) || len(rsp.Data.Info) < historyLogPageSize {
+			return nil
+		}
+	}
+}
+
+// logsAfter filter the logs which happens after the given time again, so that the logs at or before the time
+// are never replayed even if the time in the condition is compared in a coarser precision.
+func logsAfter(logs []metadata.OperationLog, asOf time.Time) []metadata.OperationLog {
+	after := make([]metadata.OperationLog, 0, len(logs))
+	for _, log := range logs {
+		if log.CreateTime.After(asOf) {
+			after = append(after, log)
+		}
+	}
+	return after
+}
+
+// logPreData get the data before the operation which is recorded by the audit log
+func logPreData(log metadata.OperationLog) mapstr.MapStr {
+	content, err := mapstr.NewFromInterface(log.Content)
+	if nil != err {
+		return nil
+	}
+	preData, err := content.MapStr("pre_data")
+	if nil != err {
+		return nil
+	}
+	return preData
+}
+
+// replayInstLogs decide the state of the instances by the logs which are in time order, the data of the instances
+// existing at that time is filled into results. the logs can be replayed page by page with the same decided
+// instances, the instances not decided by all the logs are not changed since then.
+func replayInstLogs(logs []metadata.OperationLog, results map[int64]mapstr.MapStr, decided map[int64]bool) {
+	for _, log := range logs {
+		if decided[log.InstID] {
+			continue
+		}
+		switch auditoplog.AuditOpType(log.OpType) {
+		case auditoplog.AuditOpTypeAdd:
+			decided[log.InstID] = true
+		case auditoplog.AuditOpTypeModify, auditoplog.AuditOpTypeDel:
+			// some logs do not record the previous data, the next log of this instance decides its state.
+			preData := logPreData(log)
+			if len(preData) == 0 {
+				continue
+			}
+			results[log.InstID] = preData
+			decided[log.InstID] = true
+		}
+	}
+}
+
+// findInstsAsOf get the data of the instances at the given time, the instances which not exist at that time is not returned.
+func (h *history) findInstsAsOf(params types.ContextParams, obj model.Object, instIDs []int64, asOf time.Time) (map[int64]mapstr.MapStr, error) {
+	results := make(map[int64]mapstr.MapStr)
+	if len(instIDs) == 0 {
+		return results, nil
+	}
+
+	cond := mapstr.MapStr{
+		common.BKOpTargetField: obj.GetObjectID(),
+		historyLogFieldInstID:  mapstr.MapStr{common.BKDBIN: instIDs},
+		common.BKOpTypeField: mapstr.MapStr{common.BKDBIN: []auditoplog.AuditOpType{
+			auditoplog.AuditOpTypeAdd,
+			auditoplog.AuditOpTypeModify,
+			auditoplog.AuditOpTypeDel,
+		}},
+	}
+	decided := make(map[int64]bool)
+	err := h.scanLogsAfter(params, cond, asOf, func(logs []metadata.OperationLog) bool {
+		replayInstLogs(logs, results, decided)
+		return len(decided) < len(instIDs)
+	})
+	if nil != err {
+		return nil, err
+	}
+
+	unchanged := make([]int64, 0)
+	for _, instID := range instIDs {
+		if !decided[instID] {
+			unchanged = append(unchanged, instID)
+		}
+	}
+	if len(unchanged) == 0 {
+		return results, nil
+	}
+
+	query := mapstr.MapStr{obj.GetInstIDFieldName(): mapstr.MapStr{common.BKDBIN: unchanged}}
+	if obj.IsCommon() {
+		query.Set(common.BKObjIDField, obj.GetObjectID())
+	}
+	current, err := h.inst.FindOriginInst(params, obj, &metadata.QueryInput{Condition: query, Limit: common.BKNoLimit})
+	if nil != err {
+		blog.Errorf("[operation-history] failed to find the current %s instances %v, err: %s, rid: %s", obj.GetObjectID(), unchanged, err.Error(), params.ReqID)
+		return nil, err
+	}
+	for _, data := range current.Info {
+		instID, err := data.Int64(obj.GetInstIDFieldName())
+		if nil != err {
+			blog.Errorf("[operation-history] failed to get the inst id from %#v, err: %s, rid: %s", data, err.Error(), params.ReqID)
+			return nil, params.Err.Errorf(common.CCErrCommInstFieldConvFail, obj.GetObjectID(), obj.GetInstIDFieldName(), "int", err.Error())
+		}
+		results[instID] = data
+	}
+
+	return results, nil
+}
+
+func (h *history) FindInstAsOf(params types.ContextParams, obj model.Object, instID int64, asOf time.Time) (*metadata.InstanceAsOf, error) {
+	insts, err := h.findInstsAsOf(params, obj, []int64{instID}, asOf)
+	if nil != err {
+		return nil, err
+	}
+
+	data, exists := insts[instID]
+	return &metadata.InstanceAsOf{
+		ObjectID: obj.GetObjectID(),
+		InstID:   instID,
+		AsOf:     asOf,
+		Exists:   exists,
+		Data:     data,
+	}, nil
+}
+
+func (h *history) FindHostModulesAsOf(params types.ContextParams, hostID int64, asOf time.Time) (*metadata.HostModulesAsOf, error) {
+	result := &metadata.HostModulesAsOf{
+		HostID:  hostID,
+		AsOf:    asOf,
+		Modules: make([]metadata.Ref, 0),
+	}
+
+	cond := mapstr.MapStr{
+		common.BKOpTargetField: common.BKInnerObjIDHost,
+		historyLogFieldInstID:  hostID,
+		common.BKOpTypeField: mapstr.MapStr{common.BKDBIN: []auditoplog.AuditOpType{
+			auditoplog.AuditOpTypeAdd,
+			auditoplog.AuditOpTypeHostModule,
+		}},
+	}
+	// only the earliest log is needed.
+	var earliest *metadata.OperationLog
+	err := h.scanLogsAfter(params, cond, asOf, func(logs []metadata.OperationLog) bool {
+		if len(logs) != 0 {
+			earliest = &logs[0]
+		}
+		return false
+	})
+	if nil != err {
+		return nil, err
+	}
+
+	if nil != earliest {
+		log := *earliest
+		if auditoplog.AuditOpType(log.OpType) == auditoplog.AuditOpTypeAdd {
+			// the host is not created yet.
+			return result, nil
+		}
+
+		preData := logPreData(log)
+		if nil != preData {
+			result.BizID, _ = preData.Int64(common.BKAppIDField)
+			modules, _ := preData.MapStrArray("module")
+			for _, module := range modules {
+				ref := metadata.Ref{}
+				ref.RefID, _ = module.Int64("ref_id")
+				ref.RefName, _ = module.String("ref_name")
+				result.Modules = append(result.Modules, ref)
+			}
+		}
+		return result, nil
+	}
+
+	// the host module is not changed since then.
+	relation, err := h.clientSet.CoreService().Host().GetHostModuleRelation(params.Context, params.Header, &metadata.HostModuleRelationRequest{HostIDArr: []int64{hostID}})
+	if nil != err {
+		blog.Errorf("[operation-history] failed to get the host %d module relation, err: %s, rid: %s", hostID, err.Error(), params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !relation.Result {
+		blog.Errorf("[operation-history] failed to get the host %d module relation, err: %s, rid: %s", hostID, relation.ErrMsg, params.ReqID)
+		return nil, params.Err.New(relation.Code, relation.ErrMsg)
+	}
+	if len(relation.Data) == 0 {
+		return result, nil
+	}
+
+	moduleIDs := make([]int64, 0)
+	for _, item := range relation.Data {
+		result.BizID = item.AppID
+		moduleIDs = append(moduleIDs, item.ModuleID)
+	}
+
+	moduleObj, err := h.obj.FindSingleObject(params, common.BKInnerObjIDModule)
+	if nil != err {
+		return nil, err
+	}
+	query := mapstr.MapStr{common.BKModuleIDField: mapstr.MapStr{common.BKDBIN: moduleIDs}}
+	modules, err := h.inst.FindOriginInst(params, moduleObj, &metadata.QueryInput{Condition: query, Limit: common.BKNoLimit})
+	if nil != err {
+		blog.Errorf("[operation-history] failed to find the modules %v, err: %s, rid: %s", moduleIDs, err.Error(), params.ReqID)
+		return nil, err
+	}
+	for _, module := range modules.Info {
+		ref := metadata.Ref{}
+		ref.RefID, _ = module.Int64(common.BKModuleIDField)
+		ref.RefName, _ = module.String(common.BKModuleNameField)
+		result.Modules = append(result.Modules, ref)
+	}
+
+	return result, nil
+}
+
+func (h *history) SearchBusinessTopoAsOf(params types.ContextParams, bizObj model.Object, bizID int64, asOf time.Time) ([]*metadata.TopoInstRst, error) {
+	results := make([]*metadata.TopoInstRst, 0)
+
+	bizs, err := h.findInstsAsOf(params, bizObj, []int64{bizID}, asOf)
+	if nil != err {
+		return nil, err
+	}
+	biz, exists := bizs[bizID]
+	if !exists {
+		return results, nil
+	}
+
+	results = append(results, newTopoInstAsOf(bizObj, bizID, biz))
+	if err := h.fillMainlineChildInstAsOf(params, bizObj, bizID, results, asOf); nil != err {
+		blog.Errorf("[operation-history] failed to fill the mainline child instances of business %d, err: %s, rid: %s", bizID, err.Error(), params.ReqID)
+		return nil, err
+	}
+	return results, nil
+}
+
+func newTopoInstAsOf(obj model.Object, instID int64, data mapstr.MapStr) *metadata.TopoInstRst {
+	tmp := &metadata.TopoInstRst{Child: []*metadata.TopoInstRst{}}
+	tmp.InstID = instID
+	tmp.InstName, _ = data.String(obj.GetInstNameFieldName())
+	tmp.ObjID = obj.Object().ObjectID
+	tmp.ObjName = obj.Object().ObjectName
+	return tmp
+}
+
+// fillMainlineChildInstAsOf works like fillMainlineChildInst, the candidates of the children are the instances
+// under the parents now, and the instances of this business which are changed after the given time.
+func (h *history) fillMainlineChildInstAsOf(params types.ContextParams, object model.Object, bizID int64, parentInsts []*metadata.TopoInstRst, asOf time.Time) error {
+	childObj, err := object.GetMainlineChildObject()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		blog.Errorf("[operation-history] GetMainlineChildObject for %+v failed: %v, rid: %s", object, err, params.ReqID)
+		return err
+	}
+
+	parentMap := make(map[int64]*metadata.TopoInstRst)
+	parentIDs := make([]int64, 0)
+	for _, parent := range parentInsts {
+		parentMap[parent.InstID] = parent
+		parentIDs = append(parentIDs, parent.InstID)
+	}
+
+	candidates := make(map[int64]bool)
+	query := mapstr.MapStr{common.BKInstParentStr: mapstr.MapStr{common.BKDBIN: parentIDs}}
+	if childObj.IsCommon() {
+		query.Set(common.BKObjIDField, childObj.GetObjectID())
+	}
+	current, err := h.inst.FindOriginInst(params, childObj, &metadata.QueryInput{Condition: query, Limit: common.BKNoLimit})
+	if nil != err {
+		blog.Errorf("[operation-history] failed to find the current child instances for %#v, err: %s, rid: %s", query, err.Error(), params.ReqID)
+		return err
+	}
+	for _, data := range current.Info {
+		instID, err := data.Int64(childObj.GetInstIDFieldName())
+		if nil != err {
+			return params.Err.Errorf(common.CCErrCommInstFieldConvFail, childObj.GetObjectID(), childObj.GetInstIDFieldName(), "int", err.Error())
+		}
+		candidates[instID] = true
+	}
+
+	logCond := mapstr.MapStr{
+		common.BKOpTargetField: childObj.GetObjectID(),
+		common.BKAppIDField:    bizID,
+	}
+	err = h.scanLogsAfter(params, logCond, asOf, func(logs []metadata.OperationLog) bool {
+		for _, log := range logs {
+			candidates[log.InstID] = true
+		}
+		return true
+	})
+	if nil != err {
+		return err
+	}
+
+	candidateIDs := make([]int64, 0)
+	for instID := range candidates {
+		candidateIDs = append(candidateIDs, instID)
+	}
+	sort.Slice(candidateIDs, func(i, j int) bool { return candidateIDs[i] < candidateIDs[j] })
+
+	insts, err := h.findInstsAsOf(params, childObj, candidateIDs, asOf)
+	if nil != err {
+		return err
+	}
+
+	childTopoInsts := make([]*metadata.TopoInstRst, 0)
+	for _, instID := range candidateIDs {
+		data, exists := insts[instID]
+		if !exists {
+			continue
+		}
+		parentID, err := data.Int64(common.BKInstParentStr)
+		if nil != err {
+			blog.Warnf("[operation-history] the %s instance %d has no valid parent, skip it, data: %#v, rid: %s", childObj.GetObjectID(), instID, data, params.ReqID)
+			continue
+		}
+		parent, ok := parentMap[parentID]
+		if !ok {
+			continue
+		}
+		if childObj.GetObjectID() == common.BKInnerObjIDSet {
+			if flag, err := data.Int64(common.BKDefaultField); nil == err && int(flag) == common.DefaultResSetFlag {
+				continue
+			}
+		}
+
+		tmp := newTopoInstAsOf(childObj, instID, data)
+		parent.Child = append(parent.Child, tmp)
+		childTopoInsts = append(childTopoInsts, tmp)
+	}
+
+	if len(childTopoInsts) == 0 {
+		return nil
+	}
+	return h.fillMainlineChildInstAsOf(params, childObj, bizID, childTopoInsts, asOf)
+}
@@ -0,0 +1,165 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func historyLog(instID int64, opType auditoplog.AuditOpType, preData map[string]interface{}) metadata.OperationLog {
+	log := metadata.OperationLog{InstID: instID, OpType: int(opType)}
+	if preData != nil {
+		log.Content = map[string]interface{}{"pre_data": preData}
+	}
+	return log
+}
+
+func TestReplayInstLogs(t *testing.T) {
+	tests := []struct {
+		name        string
+		logs        []metadata.OperationLog
+		wantResults map[int64]mapstr.MapStr
+		wantDecided map[int64]bool
+	}{
+		{
+			name:        "no logs after the time",
+			logs:        []metadata.OperationLog{},
+			wantResults: map[int64]mapstr.MapStr{},
+			wantDecided: map[int64]bool{},
+		},
+		{
+			name: "created after the time",
+			logs: []metadata.OperationLog{
+				historyLog(1, auditoplog.AuditOpTypeAdd, nil),
+				historyLog(1, auditoplog.AuditOpTypeModify, map[string]interface{}{"bk_inst_name": "a"}),
+			},
+			wantResults: map[int64]mapstr.MapStr{},
+			wantDecided: map[int64]bool{1: true},
+		},
+		{
+			name: "the earliest update decides the state",
+			logs: []metadata.OperationLog{
+				historyLog(1, auditoplog.AuditOpTypeModify, map[string]interface{}{"bk_inst_name": "a"}),
+				historyLog(1, auditoplog.AuditOpTypeModify, map[string]interface{}{"bk_inst_name": "b"}),
+				historyLog(1, auditoplog.AuditOpTypeDel, map[string]interface{}{"bk_inst_name": "c"}),
+			},
+			wantResults: map[int64]mapstr.MapStr{1: {"bk_inst_name": "a"}},
+			wantDecided: map[int64]bool{1: true},
+		},
+		{
+			name: "deleted after the time",
+			logs: []metadata.OperationLog{
+				historyLog(2, auditoplog.AuditOpTypeDel, map[string]interface{}{"bk_inst_name": "d"}),
+			},
+			wantResults: map[int64]mapstr.MapStr{2: {"bk_inst_name": "d"}},
+			wantDecided: map[int64]bool{2: true},
+		},
+		{
+			name: "log without pre data is skipped",
+			logs: []metadata.OperationLog{
+				historyLog(1, auditoplog.AuditOpTypeModify, nil),
+				historyLog(1, auditoplog.AuditOpTypeModify, map[string]interface{}{"bk_inst_name": "b"}),
+				historyLog(2, auditoplog.AuditOpTypeModify, nil),
+			},
+			wantResults: map[int64]mapstr.MapStr{1: {"bk_inst_name": "b"}},
+			wantDecided: map[int64]bool{1: true},
+		},
+		{
+			name: "multiple instances",
+			logs: []metadata.OperationLog{
+				historyLog(1, auditoplog.AuditOpTypeModify, map[string]interface{}{"bk_inst_name": "a"}),
+				historyLog(2, auditoplog.AuditOpTypeAdd, nil),
+				historyLog(3, auditoplog.AuditOpTypeDel, map[string]interface{}{"bk_inst_name": "c"}),
+				historyLog(2, auditoplog.AuditOpTypeDel, map[string]interface{}{"bk_inst_name": "b"}),
+			},
+			wantResults: map[int64]mapstr.MapStr{1: {"bk_inst_name": "a"}, 3: {"bk_inst_name": "c"}},
+			wantDecided: map[int64]bool{1: true, 2: true, 3: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(map[int64]mapstr.MapStr)
+			decided := make(map[int64]bool)
+			replayInstLogs(tt.logs, results, decided)
+			if !reflect.DeepEqual(results, tt.wantResults) {
+				t.Errorf("replayInstLogs() results = %v, want %v", results, tt.wantResults)
+			}
+			if !reflect.DeepEqual(decided, tt.wantDecided) {
+				t.Errorf("replayInstLogs() decided = %v, want %v", decided, tt.wantDecided)
+			}
+		})
+	}
+}
+
+func TestReplayInstLogsByPage(t *testing.T) {
+	pages := [][]metadata.OperationLog{
+		{
+			historyLog(1, auditoplog.AuditOpTypeModify, map[string]interface{}{"bk_inst_name": "a"}),
+			historyLog(2, auditoplog.AuditOpTypeModify, nil),
+		},
+		{
+			historyLog(1, auditoplog.AuditOpTypeDel, map[string]interface{}{"bk_inst_name": "b"}),
+			historyLog(2, auditoplog.AuditOpTypeDel, map[string]interface{}{"bk_inst_name": "c"}),
+		},
+	}
+
+	results := make(map[int64]mapstr.MapStr)
+	decided := make(map[int64]bool)
+	for _, page := range pages {
+		replayInstLogs(page, results, decided)
+	}
+
+	wantResults := map[int64]mapstr.MapStr{1: {"bk_inst_name": "a"}, 2: {"bk_inst_name": "c"}}
+	if !reflect.DeepEqual(results, wantResults) {
+		t.Errorf("replayInstLogs() results = %v, want %v", results, wantResults)
+	}
+	if !reflect.DeepEqual(decided, map[int64]bool{1: true, 2: true}) {
+		t.Errorf("replayInstLogs() decided = %v", decided)
+	}
+}
+
+func TestReplayInstLogsAsOf(t *testing.T) {
+	asOf := time.Date(2019, 5, 1, 12, 0, 0, 600*int(time.Millisecond), time.UTC)
+	logAt := func(at time.Time, instID int64, opType auditoplog.AuditOpType, preData map[string]interface{}) metadata.OperationLog {
+		log := historyLog(instID, opType, preData)
+		log.CreateTime = at
+		return log
+	}
+
+	logs := []metadata.OperationLog{
+		// inside the second of the time but before it, the state at the time is the one after this log.
+		logAt(asOf.Add(-300*time.Millisecond), 1, auditoplog.AuditOpTypeModify, map[string]interface{}{"bk_inst_name": "a"}),
+		logAt(asOf, 2, auditoplog.AuditOpTypeAdd, nil),
+		logAt(asOf.Add(200*time.Millisecond), 1, auditoplog.AuditOpTypeModify, map[string]interface{}{"bk_inst_name": "b"}),
+		logAt(asOf.Add(300*time.Millisecond), 2, auditoplog.AuditOpTypeModify, map[string]interface{}{"bk_inst_name": "c"}),
+	}
+
+	results := make(map[int64]mapstr.MapStr)
+	decided := make(map[int64]bool)
+	replayInstLogs(logsAfter(logs, asOf), results, decided)
+
+	wantResults := map[int64]mapstr.MapStr{1: {"bk_inst_name": "b"}, 2: {"bk_inst_name": "c"}}
+	if !reflect.DeepEqual(results, wantResults) {
+		t.Errorf("replayInstLogs() results = %v, want %v", results, wantResults)
+	}
+	if !reflect.DeepEqual(decided, map[int64]bool{1: true, 2: true}) {
+		t.Errorf("replayInstLogs() decided = %v", decided)
+	}
+}
@@ -378,70 +378,222 @@ func (c *commonInst) DeleteInstByInstID(params types.ContextParams, obj model.Ob
 	}
 
 	for _, delInst := range deleteIDS {
-		preAudit := NewSupplementary().Audit(params, c.clientSet, delInst.obj, c).CreateSnapshot(delInst.instID, condition.CreateCondition().ToMapStr())
-		// if this instance has been bind to a instance by the association, then this instance should not be deleted.
-		innerCond := condition.CreateCondition()
-		innerCond.Field(common.BKAsstObjIDField).Eq(delInst.obj.GetObjectID())
-		innerCond.Field(common.BKAsstInstIDField).Eq(delInst.instID)
-		err := c.asst.CheckBeAssociation(params, delInst.obj, innerCond)
+		targets, unlinked, err := c.planInstDelete(params, delInst)
 		if nil != err {
 			return err
 		}
 
-		// this instance has not be bind to another instance, we can delete all the associations it created
-		// by the association with other instances.
-		innerCond = condition.CreateCondition()
-		innerCond.Field(common.BKObjIDField).Eq(delInst.obj.GetObjectID())
-		innerCond.Field(common.BKInstIDField).Eq(delInst.instID)
-		if err := c.asst.DeleteInstAssociation(params, innerCond); nil != err {
-			blog.Errorf("[operation-inst] failed to delete the inst asst, err: %s", err.Error())
-			return err
+		// take a copy of these instances and their associations, which is kept in recycle bin after
+		// the instances is deleted, so that they can be restored.
+		snapshots := make([][]metadata.RecycleRecord, len(targets))
+		for idx, target := range targets {
+			if snapshots[idx], err = c.snapshotForRecycleBin(params, target.obj, target.instID); nil != err {
+				return err
+			}
 		}
 
-		// delete this instance now.
-		delCond := condition.CreateCondition()
-		delCond.Field(delInst.obj.GetInstIDFieldName()).In(delInst.instID)
-		if delInst.obj.IsCommon() {
-			delCond.Field(common.BKObjIDField).Eq(delInst.obj.GetObjectID())
+		// remove all the associations of these instances, including the ones of the instances on the other side.
+		if len(unlinked) > 0 {
+			innerCond := condition.CreateCondition()
+			innerCond.Field(common.BKFieldID).In(unlinked)
+			if err := c.asst.DeleteInstAssociation(params, innerCond); nil != err {
+				blog.Errorf("[operation-inst] failed to delete the inst asst, err: %s", err.Error())
+				return err
+			}
 		}
-		// clear association
-		rsp, err := c.clientSet.CoreService().Instance().DeleteInstance(context.Background(), params.Header, delInst.obj.GetObjectID(), &metadata.DeleteOption{Condition: delCond.ToMapStr()})
-		if nil != err {
-			blog.Errorf("[operation-inst] failed to request object controller, err: %s", err.Error())
-			return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+
+		for idx, target := range targets {
+			recordIDs, err := c.moveToRecycleBin(params, target.obj, snapshots[idx])
+			if nil != err {
+				return err
+			}
+			if err := c.deleteInstWithAudit(params, target); nil != err {
+				c.rollbackRecycleBin(params, recordIDs)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// planInstDelete work out the instances deleted together with the instance by the cascade association,
+// and the id of the associations to be unlinked, it returns an error if the instance is restricted
+// from being deleted by an association.
+func (c *commonInst) planInstDelete(params types.ContextParams, delInst deletedInst) ([]deletedInst, []int64, error) {
+	plan, err := c.asst.PlanInstAssociationDelete(params, delInst.obj, []int64{delInst.instID})
+	if nil != err {
+		return nil, nil, err
+	}
+
+	// if this instance has been bind to a instance by the association, then this instance should not be deleted.
+	if len(plan.Restricted) > 0 {
+		beAsstObject := []string{}
+		for _, asst := range plan.Restricted {
+			beAsstObject = append(beAsstObject, asst.ObjectID)
 		}
+		blog.Errorf("[operation-inst] the inst %s %d is restricted from being deleted by associations %#v, rid: %s", delInst.obj.GetObjectID(), delInst.instID, plan.Restricted, params.ReqID)
+		return nil, nil, params.Err.Errorf(common.CCErrTopoInstHasBeenAssociation, beAsstObject)
+	}
 
-		if !rsp.Result {
-			blog.Errorf("[operation-inst] failed to delete the object(%s) inst by the condition(%#v), err: %s", delInst.obj.GetObjectID(), delCond.ToMapStr(), rsp.ErrMsg)
-			return params.Err.New(rsp.Code, rsp.ErrMsg)
+	targets := []deletedInst{delInst}
+	objects := map[string]model.Object{delInst.obj.GetObjectID(): delInst.obj}
+	for _, item := range plan.Instances {
+		// the instances deleted by cascade have the object association which leads to them.
+		if 0 == len(item.ObjectAsstID) {
+			continue
+		}
+		obj, exists := objects[item.ObjectID]
+		if !exists {
+			obj, err = c.obj.FindSingleObject(params, item.ObjectID)
+			if nil != err {
+				return nil, nil, err
+			}
+			// the mainline instances have their own delete flow with the topology, they are never deleted by cascade.
+			isMainline, err := obj.IsMainlineObject()
+			if nil != err {
+				return nil, nil, err
+			}
+			if isMainline {
+				blog.Errorf("[operation-inst] the inst %s %d can not delete the mainline inst %s %d by cascade, rid: %s", delInst.obj.GetObjectID(), delInst.instID, item.ObjectID, item.InstID, params.ReqID)
+				return nil, nil, params.Err.Errorf(common.CCErrTopoInstHasBeenAssociation, []string{item.ObjectID})
+			}
+			objects[item.ObjectID] = obj
 		}
+		targets = append(targets, deletedInst{instID: item.InstID, obj: obj})
+	}
+
+	unlinked := make([]int64, 0)
+	for _, asst := range plan.Unlinked {
+		unlinked = append(unlinked, asst.ID)
+	}
+	return targets, unlinked, nil
+}
+
+func (c *commonInst) deleteInstWithAudit(params types.ContextParams, delInst deletedInst) error {
+	preAudit := NewSupplementary().Audit(params, c.clientSet, delInst.obj, c).CreateSnapshot(delInst.instID, condition.CreateCondition().ToMapStr())
+
+	delCond := condition.CreateCondition()
+	delCond.Field(delInst.obj.GetInstIDFieldName()).In(delInst.instID)
+	if delInst.obj.IsCommon() {
+		delCond.Field(common.BKObjIDField).Eq(delInst.obj.GetObjectID())
+	}
+	rsp, err := c.clientSet.CoreService().Instance().DeleteInstance(context.Background(), params.Header, delInst.obj.GetObjectID(), &metadata.DeleteOption{Condition: delCond.ToMapStr()})
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request object controller, err: %s", err.Error())
+		return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
 
-		NewSupplementary().Audit(params, c.clientSet, delInst.obj, c).CommitDeleteLog(preAudit, nil, nil)
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to delete the object(%s) inst by the condition(%#v), err: %s", delInst.obj.GetObjectID(), delCond.ToMapStr(), rsp.ErrMsg)
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
 	}
+
+	NewSupplementary().Audit(params, c.clientSet, delInst.obj, c).CommitDeleteLog(preAudit, nil, nil)
 	return nil
 }
 
+func (c *commonInst) snapshotForRecycleBin(params types.ContextParams, obj model.Object, instID int64) ([]metadata.RecycleRecord, error) {
+	input := &metadata.CreateRecycleRecords{InstIDs: []int64{instID}}
+	rsp, err := c.clientSet.CoreService().RecycleBin().SnapshotRecycleRecords(context.Background(), params.Header, obj.GetObjectID(), input)
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request core service, err: %s", err.Error())
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to snapshot the object(%s) inst(%d) for recycle bin, err: %s", obj.GetObjectID(), instID, rsp.ErrMsg)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return rsp.Data.Info, nil
+}
+
+// moveToRecycleBin save the snapshots of the instance into recycle bin before it's deleted, so that the
+// instance is never deleted without its snapshots, the records are rolled back if the deletion fails.
+func (c *commonInst) moveToRecycleBin(params types.ContextParams, obj model.Object, records []metadata.RecycleRecord) ([]int64, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	input := &metadata.SaveRecycleRecords{Records: records}
+	rsp, err := c.clientSet.CoreService().RecycleBin().CreateRecycleRecords(context.Background(), params.Header, obj.GetObjectID(), input)
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request core service, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to move the object(%s) inst into recycle bin, err: %s, rid: %s", obj.GetObjectID(), rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	recordIDs := make([]int64, 0)
+	for _, created := range rsp.Data.Created {
+		recordIDs = append(recordIDs, int64(created.ID))
+	}
+	if len(rsp.Data.Exceptions) > 0 {
+		blog.Errorf("[operation-inst] failed to move the object(%s) inst into recycle bin, err: %#v, rid: %s", obj.GetObjectID(), rsp.Data.Exceptions, params.ReqID)
+		c.rollbackRecycleBin(params, recordIDs)
+		return nil, params.Err.New(int(rsp.Data.Exceptions[0].Code), rsp.Data.Exceptions[0].Message)
+	}
+	return recordIDs, nil
+}
+
+// rollbackRecycleBin purge the recycle records saved for the instance which failed to be deleted.
+func (c *commonInst) rollbackRecycleBin(params types.ContextParams, recordIDs []int64) {
+	if len(recordIDs) == 0 {
+		return
+	}
+
+	input := &metadata.PurgeRecycleRecords{IDs: recordIDs}
+	rsp, err := c.clientSet.CoreService().RecycleBin().PurgeRecycleRecords(context.Background(), params.Header, input)
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request core service, err: %s, rid: %s", err.Error(), params.ReqID)
+		return
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to rollback the recycle records %v, err: %s, rid: %s", recordIDs, rsp.ErrMsg, params.ReqID)
+	}
+}
+
 func (c *commonInst) DeleteMainlineInstWithID(params types.ContextParams, obj model.Object, instID int64) error {
 	object := obj.Object()
 	preAudit := NewSupplementary().Audit(params, c.clientSet, obj, c).CreateSnapshot(instID, condition.CreateCondition().ToMapStr())
-	// if this instance has been bind to a instance by the association, then this instance should not be deleted.
-	innerCond := condition.CreateCondition()
-	innerCond.Field(common.BKAsstObjIDField).Eq(object.ObjectID)
-	innerCond.Field(common.BKOwnerIDField).Eq(params.SupplierAccount)
-	innerCond.Field(common.BKAsstInstIDField).Eq(instID)
-	err := c.asst.CheckBeAssociation(params, obj, innerCond)
+	targets, unlinked, err := c.planInstDelete(params, deletedInst{instID: instID, obj: obj})
 	if nil != err {
 		return err
 	}
+	// the first target is the mainline instance itself, the others are deleted by cascade.
+	snapshots := make([][]metadata.RecycleRecord, len(targets))
+	for idx, target := range targets {
+		if snapshots[idx], err = c.snapshotForRecycleBin(params, target.obj, target.instID); nil != err {
+			return err
+		}
+	}
 
-	// this instance has not be bind to another instance, we can delete all the associations it created
-	// by the association with other instances.
-	innerCond = condition.CreateCondition()
-	innerCond.Field(common.BKObjIDField).Eq(object.ObjectID)
-	innerCond.Field(common.BKOwnerIDField).Eq(params.SupplierAccount)
-	innerCond.Field(common.BKInstIDField).Eq(instID)
-	if err = c.asst.DeleteInstAssociation(params, innerCond); nil != err {
-		blog.Errorf("[operation-inst] failed to delete the inst asst, err: %s", err.Error())
+	if len(unlinked) > 0 {
+		innerCond := condition.CreateCondition()
+		innerCond.Field(common.BKFieldID).In(unlinked)
+		if err = c.asst.DeleteInstAssociation(params, innerCond); nil != err {
+			blog.Errorf("[operation-inst] failed to delete the inst asst, err: %s", err.Error())
+			return err
+		}
+	}
+
+	for idx, target := range targets[1:] {
+		recordIDs, err := c.moveToRecycleBin(params, target.obj, snapshots[idx+1])
+		if nil != err {
+			return err
+		}
+		if err := c.deleteInstWithAudit(params, target); nil != err {
+			c.rollbackRecycleBin(params, recordIDs)
+			return err
+		}
+	}
+
+	recordIDs, err := c.moveToRecycleBin(params, obj, snapshots[0])
+	if nil != err {
 		return err
 	}
 
@@ -457,11 +609,13 @@ func (c *commonInst) DeleteMainlineInstWithID(params types.ContextParams, obj mo
 
 	if nil != err {
 		blog.Errorf("[operation-inst] failed to request object controller, err: %s", err.Error())
+		c.rollbackRecycleBin(params, recordIDs)
 		return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
 	}
 
 	if common.CCSuccess != rsp.Code {
 		blog.Errorf("[operation-inst] failed to delete the object(%s) inst by the condition(%#v), err: %s", object.ObjectID, delCond.ToMapStr(), rsp.ErrMsg)
+		c.rollbackRecycleBin(params, recordIDs)
 		return params.Err.Error(rsp.Code)
 	}
 
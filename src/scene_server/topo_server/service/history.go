@@ -0,0 +1,139 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"configcenter/src/auth"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// parseAsOf parse the point-in-time of the history query
+func parseAsOf(params types.ContextParams, data mapstr.MapStr) (time.Time, error) {
+	option := metadata.HistoryQueryOption{}
+	if err := data.MarshalJSONInto(&option); nil != err {
+		blog.Errorf("[api-history] failed to parse the input (%#v), err: %s, rid: %s", data, err.Error(), params.ReqID)
+		return time.Time{}, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+	asOf, err := option.Time()
+	if nil != err {
+		blog.Errorf("[api-history] invalid as_of %v, err: %s, rid: %s", option.AsOf, err.Error(), params.ReqID)
+		return time.Time{}, params.Err.Errorf(common.CCErrCommParamsIsInvalid, "as_of")
+	}
+	return asOf, nil
+}
+
+// authorizeHistoryRead the history is replayed from audit logs, so it needs the authority to read the audit logs.
+func (s *Service) authorizeHistoryRead(params types.ContextParams, bizID int64) (interface{}, error) {
+	if err := s.AuthManager.AuthorizeAuditRead(params.Context, params.Header, bizID); err != nil {
+		blog.Errorf("[api-history] authorize failed, AuthorizeAuditRead failed, err: %+v, rid: %s", err, params.ReqID)
+		resp, err := s.AuthManager.GenAuthorizeAuditReadNoPermissionsResponse(params.Context, params.Header, bizID)
+		if err != nil {
+			return nil, fmt.Errorf("try authorize failed, err: %v", err)
+		}
+		return resp, auth.NoAuthorizeError
+	}
+	return nil, nil
+}
+
+// FindInstAsOf find the instance data as it was at the given time
+func (s *Service) FindInstAsOf(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams("bk_obj_id")
+	instID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if nil != err {
+		return nil, params.Err.Errorf(common.CCErrCommParamsIsInvalid, "inst_id")
+	}
+	asOf, err := parseAsOf(params, data)
+	if nil != err {
+		return nil, err
+	}
+
+	obj, err := s.Core.ObjectOperation().FindSingleObject(params, objID)
+	if nil != err {
+		blog.Errorf("[api-history] failed to find the object %s, err: %s, rid: %s", objID, err.Error(), params.ReqID)
+		return nil, err
+	}
+
+	result, err := s.Core.HistoryOperation().FindInstAsOf(params, obj, instID, asOf)
+	if nil != err {
+		return nil, err
+	}
+
+	var bizID int64
+	if result.Exists && result.Data.Exists(common.BKAppIDField) {
+		bizID, _ = result.Data.Int64(common.BKAppIDField)
+	}
+	if resp, err := s.authorizeHistoryRead(params, bizID); err != nil {
+		return resp, err
+	}
+
+	if result.Exists {
+		if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(params.Context, params.Header, objID, result.Data); err != nil {
+			blog.Errorf("redact instance attribute failed, object: %s, err: %+v, rid: %s", objID, err, params.ReqID)
+			return nil, params.Err.Error(common.CCErrCommAuthorizeFailed)
+		}
+	}
+	return result, nil
+}
+
+// FindHostModulesAsOf find the modules which the host belongs to at the given time
+func (s *Service) FindHostModulesAsOf(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	hostID, err := strconv.ParseInt(pathParams("bk_host_id"), 10, 64)
+	if nil != err {
+		return nil, params.Err.Errorf(common.CCErrCommParamsIsInvalid, common.BKHostIDField)
+	}
+	asOf, err := parseAsOf(params, data)
+	if nil != err {
+		return nil, err
+	}
+
+	result, err := s.Core.HistoryOperation().FindHostModulesAsOf(params, hostID, asOf)
+	if nil != err {
+		return nil, err
+	}
+
+	if resp, err := s.authorizeHistoryRead(params, result.BizID); err != nil {
+		return resp, err
+	}
+	return result, nil
+}
+
+// SearchBusinessTopoAsOf search the business topo as it was at the given time
+func (s *Service) SearchBusinessTopoAsOf(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	bizID, err := strconv.ParseInt(pathParams("bk_biz_id"), 10, 64)
+	if nil != err {
+		return nil, params.Err.Errorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField)
+	}
+	asOf, err := parseAsOf(params, data)
+	if nil != err {
+		return nil, err
+	}
+
+	if resp, err := s.authorizeHistoryRead(params, bizID); err != nil {
+		return resp, err
+	}
+
+	bizObj, err := s.Core.ObjectOperation().FindSingleObject(params, common.BKInnerObjIDApp)
+	if nil != err {
+		return nil, err
+	}
+
+	return s.Core.HistoryOperation().SearchBusinessTopoAsOf(params, bizObj, bizID, asOf)
+}
@@ -16,12 +16,16 @@ import (
 	"strconv"
 	"strings"
 
+	"configcenter/src/auth"
+	"configcenter/src/auth/meta"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	paraparse "configcenter/src/common/paraparse"
+	"configcenter/src/scene_server/topo_server/core/inst"
+	"configcenter/src/scene_server/topo_server/core/model"
 	"configcenter/src/scene_server/topo_server/core/operation"
 	"configcenter/src/scene_server/topo_server/core/types"
 )
@@ -57,6 +61,17 @@ func (s *Service) CreateInst(params types.ContextParams, pathParams, queryParams
 			return nil, params.Err.Error(common.CCErrCommParamsIsInvalid)
 		}
 
+		// auth: check authorization on protected attributes
+		if batchInfo.BatchInfo != nil {
+			datas := make([]mapstr.MapStr, 0)
+			for _, item := range *batchInfo.BatchInfo {
+				datas = append(datas, item)
+			}
+			if resp, err := s.authorizeInstanceAttributeUpdate(params, objID, datas...); err != nil {
+				return resp, err
+			}
+		}
+
 		setInst, err := s.Core.InstOperation().CreateInstBatch(params, obj, batchInfo)
 		if nil != err {
 			blog.Errorf("failed to create new object %s, %s", objID, err.Error())
@@ -82,6 +97,11 @@ func (s *Service) CreateInst(params types.ContextParams, pathParams, queryParams
 		return setInst, nil
 	}
 
+	// auth: check authorization on protected attributes
+	if resp, err := s.authorizeInstanceAttributeUpdate(params, objID, data); err != nil {
+		return resp, err
+	}
+
 	setInst, err := s.Core.InstOperation().CreateInst(params, obj, data)
 	if nil != err {
 		blog.Errorf("failed to create a new %s, %s", objID, err.Error())
@@ -115,6 +135,15 @@ func (s *Service) DeleteInsts(params types.ContextParams, pathParams, queryParam
 		return nil, err
 	}
 
+	// dry run: list the instances and associations affected by the deletion without deleting them.
+	if "true" == queryParams("dry_run") {
+		return s.Core.AssociationOperation().PlanInstAssociationDelete(params, obj, deleteCondition.Delete.InstID)
+	}
+
+	if err := s.deregisterCascadeInstances(params, obj, deleteCondition.Delete.InstID); err != nil {
+		return nil, err
+	}
+
 	// auth: deregister resources
 	if err := s.AuthManager.DeregisterInstanceByRawID(params.Context, params.Header, obj.GetObjectID(), deleteCondition.Delete.InstID...); err != nil {
 		blog.Errorf("batch delete instance failed, deregister instance failed, instID: %d, err: %s", deleteCondition.Delete.InstID, err)
@@ -143,6 +172,15 @@ func (s *Service) DeleteInst(params types.ContextParams, pathParams, queryParams
 		return nil, err
 	}
 
+	// dry run: list the instances and associations affected by the deletion without deleting them.
+	if "true" == queryParams("dry_run") {
+		return s.Core.AssociationOperation().PlanInstAssociationDelete(params, obj, []int64{instID})
+	}
+
+	if err := s.deregisterCascadeInstances(params, obj, []int64{instID}); err != nil {
+		return nil, err
+	}
+
 	// auth: deregister resources
 	if err := s.AuthManager.DeregisterInstanceByRawID(params.Context, params.Header, obj.GetObjectID(), instID); err != nil {
 		blog.Errorf("delete instance failed, deregister instance failed, instID: %d, err: %s", instID, err)
@@ -153,6 +191,38 @@ func (s *Service) DeleteInst(params types.ContextParams, pathParams, queryParams
 	return nil, err
 }
 
+// deregisterCascadeInstances authorize and deregister the instances which are deleted together with the
+// instances by the cascade association, the instances themselves are authorized by the caller.
+func (s *Service) deregisterCascadeInstances(params types.ContextParams, obj model.Object, instIDs []int64) error {
+	plan, err := s.Core.AssociationOperation().PlanInstAssociationDelete(params, obj, instIDs)
+	if nil != err {
+		return err
+	}
+
+	cascades := make(map[string][]int64)
+	for _, item := range plan.Instances {
+		if 0 == len(item.ObjectAsstID) {
+			continue
+		}
+		cascades[item.ObjectID] = append(cascades[item.ObjectID], item.InstID)
+	}
+
+	for objID, ids := range cascades {
+		if err := s.AuthManager.AuthorizeByInstanceID(params.Context, params.Header, meta.Delete, objID, ids...); err != nil {
+			blog.Errorf("delete instance failed, authorize on the cascade instances %s %v failed, err: %+v, rid: %s", objID, ids, err, params.ReqID)
+			return params.Err.Error(common.CCErrCommAuthNotHavePermission)
+		}
+	}
+
+	for objID, ids := range cascades {
+		if err := s.AuthManager.DeregisterInstanceByRawID(params.Context, params.Header, objID, ids...); err != nil {
+			blog.Errorf("delete instance failed, deregister the cascade instances %s %v failed, err: %+v, rid: %s", objID, ids, err, params.ReqID)
+			return params.Err.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+		}
+	}
+	return nil
+}
+
 func (s *Service) UpdateInsts(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 	objID := pathParams("bk_obj_id")
 
@@ -182,6 +252,15 @@ func (s *Service) UpdateInsts(params types.ContextParams, pathParams, queryParam
 		return nil, err
 	}
 
+	// auth: check authorization on protected attributes
+	datas := make([]mapstr.MapStr, 0)
+	for _, item := range updateCondition.Update {
+		datas = append(datas, item.InstInfo)
+	}
+	if resp, err := s.authorizeInstanceAttributeUpdate(params, objID, datas...); err != nil {
+		return resp, err
+	}
+
 	instanceIDs := make([]int64, 0)
 	for _, item := range updateCondition.Update {
 		instanceIDs = append(instanceIDs, item.InstID)
@@ -235,6 +314,11 @@ func (s *Service) UpdateInst(params types.ContextParams, pathParams, queryParams
 		data.Remove("metadata")
 	}
 
+	// auth: check authorization on protected attributes
+	if resp, err := s.authorizeInstanceAttributeUpdate(params, objID, data); err != nil {
+		return resp, err
+	}
+
 	cond := condition.CreateCondition()
 	cond.Field(obj.GetInstIDFieldName()).Eq(instID)
 	err = s.Core.InstOperation().UpdateInst(params, data, obj, cond, instID)
@@ -287,6 +371,12 @@ func (s *Service) SearchInsts(params types.ContextParams, pathParams, queryParam
 		return nil, err
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactInstanceAttributes(params, obj.GetObjectID(), instItems); err != nil {
+		blog.Errorf("[api-inst] failed to redact the protected attributes of the objects(%s), error info is %s", obj.GetObjectID(), err.Error())
+		return nil, err
+	}
+
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
 	result.Set("info", instItems)
@@ -324,6 +414,12 @@ func (s *Service) SearchInstAndAssociationDetail(params types.ContextParams, pat
 		return nil, err
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactInstanceAttributes(params, obj.GetObjectID(), instItems); err != nil {
+		blog.Errorf("[api-inst] failed to redact the protected attributes of the objects(%s), error info is %s", obj.GetObjectID(), err.Error())
+		return nil, err
+	}
+
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
 	result.Set("info", instItems)
@@ -360,6 +456,12 @@ func (s *Service) SearchInstByObject(params types.ContextParams, pathParams, que
 		return nil, err
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactInstanceAttributes(params, obj.GetObjectID(), instItems); err != nil {
+		blog.Errorf("[api-inst] failed to redact the protected attributes of the objects(%s), error info is %s", obj.GetObjectID(), err.Error())
+		return nil, err
+	}
+
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
 	result.Set("info", instItems)
@@ -383,6 +485,12 @@ func (s *Service) SearchInstByAssociation(params types.ContextParams, pathParams
 		return nil, err
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactInstanceAttributes(params, obj.GetObjectID(), instItems); err != nil {
+		blog.Errorf("[api-inst] failed to redact the protected attributes of the objects(%s), error info is %s", obj.GetObjectID(), err.Error())
+		return nil, err
+	}
+
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
 	result.Set("info", instItems)
@@ -415,6 +523,12 @@ func (s *Service) SearchInstByInstID(params types.ContextParams, pathParams, que
 		return nil, err
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactInstanceAttributes(params, obj.GetObjectID(), instItems); err != nil {
+		blog.Errorf("[api-inst] failed to redact the protected attributes of the objects(%s), error info is %s", obj.GetObjectID(), err.Error())
+		return nil, err
+	}
+
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
 	result.Set("info", instItems)
@@ -476,3 +590,36 @@ func (s *Service) SearchInstTopo(params types.ContextParams, pathParams, queryPa
 
 	return instItems, err
 }
+
+// authorizeInstanceAttributeUpdate check the authority of the auth protected attributes in the datas,
+// it returns the no permission response with auth.NoAuthorizeError when it's not authorized.
+func (s *Service) authorizeInstanceAttributeUpdate(params types.ContextParams, objID string, datas ...mapstr.MapStr) (interface{}, error) {
+	err := s.AuthManager.AuthorizeInstanceAttributeUpdate(params.Context, params.Header, objID, datas...)
+	if err == nil {
+		return nil, nil
+	}
+	if err != auth.NoAuthorizeError {
+		blog.Errorf("authorize instance attribute update failed, object: %s, err: %+v, rid: %s", objID, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommAuthorizeFailed)
+	}
+
+	resp, err := s.AuthManager.GenInstanceAttributeNoPermissionResp(params.Context, params.Header, objID, datas...)
+	if err != nil {
+		blog.Errorf("generate instance attribute no permission response failed, object: %s, err: %+v, rid: %s", objID, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommAuthorizeFailed)
+	}
+	return resp, auth.NoAuthorizeError
+}
+
+// redactInstanceAttributes remove the auth protected attributes which the user has no authority to read.
+func (s *Service) redactInstanceAttributes(params types.ContextParams, objID string, insts []inst.Inst) error {
+	datas := make([]mapstr.MapStr, 0)
+	for _, item := range insts {
+		datas = append(datas, item.ToMapStr())
+	}
+	if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(params.Context, params.Header, objID, datas...); err != nil {
+		blog.Errorf("redact instance attribute failed, object: %s, err: %+v, rid: %s", objID, err, params.ReqID)
+		return params.Err.Error(common.CCErrCommAuthorizeFailed)
+	}
+	return nil
+}
@@ -37,6 +37,11 @@ func (s *Service) CreateBusiness(params types.ContextParams, pathParams, queryPa
 		return nil, err
 	}
 
+	// auth: check authorization on protected attributes
+	if resp, err := s.authorizeInstanceAttributeUpdate(params, common.BKInnerObjIDApp, data); err != nil {
+		return resp, err
+	}
+
 	data.Set(common.BKDefaultField, 0)
 	business, err := s.Core.BusinessOperation().CreateBusiness(params, obj, data)
 	if err != nil {
@@ -98,6 +103,11 @@ func (s *Service) UpdateBusiness(params types.ContextParams, pathParams, queryPa
 		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "business id")
 	}
 
+	// auth: check authorization on protected attributes
+	if resp, err := s.authorizeInstanceAttributeUpdate(params, common.BKInnerObjIDApp, data); err != nil {
+		return resp, err
+	}
+
 	err = s.Core.BusinessOperation().UpdateBusiness(params, data, obj, bizID)
 	if err != nil {
 		return nil, err
@@ -357,6 +367,11 @@ func (s *Service) SearchBusiness(params types.ContextParams, pathParams, queryPa
 		return nil, err
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactInstanceAttributes(params, obj.GetObjectID(), instItems); err != nil {
+		return nil, err
+	}
+
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
 	result.Set("info", instItems)
@@ -45,6 +45,11 @@ func (s *Service) CreateModule(params types.ContextParams, pathParams, queryPara
 		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "set id")
 	}
 
+	// auth: check authorization on protected attributes
+	if resp, err := s.authorizeInstanceAttributeUpdate(params, common.BKInnerObjIDModule, data); err != nil {
+		return resp, err
+	}
+
 	module, err := s.Core.ModuleOperation().CreateModule(params, obj, bizID, setID, data)
 	if err != nil {
 		blog.Errorf("[api-module] create module failed, error info is %s", err.Error())
@@ -135,6 +140,11 @@ func (s *Service) UpdateModule(params types.ContextParams, pathParams, queryPara
 		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "module id")
 	}
 
+	// auth: check authorization on protected attributes
+	if resp, err := s.authorizeInstanceAttributeUpdate(params, common.BKInnerObjIDModule, data); err != nil {
+		return resp, err
+	}
+
 	err = s.Core.ModuleOperation().UpdateModule(params, data, obj, bizID, setID, moduleID)
 	if err != nil {
 		blog.Errorf("update module failed, err: %+v", err)
@@ -195,6 +205,11 @@ func (s *Service) SearchModule(params types.ContextParams, pathParams, queryPara
 		return nil, err
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactInstanceAttributes(params, obj.GetObjectID(), instItems); err != nil {
+		return nil, err
+	}
+
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
 	result.Set("info", instItems)
@@ -40,6 +40,11 @@ func (s *Service) CreateSet(params types.ContextParams, pathParams, queryParams
 		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "business id")
 	}
 
+	// auth: check authorization on protected attributes
+	if resp, err := s.authorizeInstanceAttributeUpdate(params, common.BKInnerObjIDSet, data); err != nil {
+		return resp, err
+	}
+
 	set, err := s.Core.SetOperation().CreateSet(params, obj, bizID, data)
 	if err != nil {
 		return nil, err
@@ -152,6 +157,11 @@ func (s *Service) UpdateSet(params types.ContextParams, pathParams, queryParams
 		return nil, err
 	}
 
+	// auth: check authorization on protected attributes
+	if resp, err := s.authorizeInstanceAttributeUpdate(params, common.BKInnerObjIDSet, data); err != nil {
+		return resp, err
+	}
+
 	err = s.Core.SetOperation().UpdateSet(params, data, obj, bizID, setID)
 	if err != nil {
 		blog.Errorf("update set failed, err: %+v", err)
@@ -205,6 +215,11 @@ func (s *Service) SearchSet(params types.ContextParams, pathParams, queryParams
 		return nil, err
 	}
 
+	// auth: remove the protected attributes which the user has no authority to read
+	if err := s.redactInstanceAttributes(params, obj.GetObjectID(), instItems); err != nil {
+		return nil, err
+	}
+
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
 	result.Set("info", instItems)
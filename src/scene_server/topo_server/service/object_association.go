@@ -124,6 +124,18 @@ func (s *Service) UpdateObjectAssociation(params types.ContextParams, pathParams
 
 }
 
+// FindObjectAssociationViolations find the instances which are associated with more instances than
+// the mapping and the max count constraints of the object associations allow.
+func (s *Service) FindObjectAssociationViolations(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	request := &metadata.FindAssociationViolationsRequest{}
+	if err := data.MarshalJSONInto(request); err != nil {
+		blog.Errorf("find object association violations failed, parse the input (%#v) failed, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+
+	return s.Core.AssociationOperation().FindAssociationViolations(params, request)
+}
+
 // ImportInstanceAssociation import instance  association
 func (s *Service) ImportInstanceAssociation(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 	objID := pathParams("bk_obj_id")
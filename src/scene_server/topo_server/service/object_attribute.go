@@ -35,6 +35,10 @@ func (s *Service) CreateObjectAttribute(params types.ContextParams, pathParams,
 		blog.Errorf("create object attribute success, but register model attribute to auth failed, err: %+v", err)
 		return nil, params.Err.Error(common.CCErrCommRegistResourceToIAMFailed)
 	}
+	if err := s.AuthManager.RegisterInstanceAttributes(params.Context, params.Header, *attribute); err != nil {
+		blog.Errorf("create object attribute success, but register instance attribute to auth failed, err: %+v", err)
+		return nil, params.Err.Error(common.CCErrCommRegistResourceToIAMFailed)
+	}
 
 	return attr.ToMapStr()
 }
@@ -65,6 +69,15 @@ func (s *Service) UpdateObjectAttribute(params types.ContextParams, pathParams,
 
 	data.Remove(metadata.BKMetadata)
 
+	// auth: the instance attribute resource should be deregistered when the protection is turned off,
+	// it will be registered again below if the update failed.
+	if protected, err := data.Bool(metadata.AttributeFieldIsAuthProtected); err == nil && protected == false {
+		if err := s.AuthManager.DeregisterInstanceAttributesByID(params.Context, params.Header, id); err != nil {
+			blog.Errorf("update object attribute failed, deregister instance attribute to auth failed, err: %+v", err)
+			return nil, params.Err.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+		}
+	}
+
 	err = s.Core.AttributeOperation().UpdateObjectAttribute(params, data, id)
 
 	// auth: update registered resource
@@ -72,6 +85,10 @@ func (s *Service) UpdateObjectAttribute(params types.ContextParams, pathParams,
 		blog.Errorf("update object attribute success , but update registered model attribute to auth failed, err: %+v", err)
 		return nil, params.Err.Error(common.CCErrCommRegistResourceToIAMFailed)
 	}
+	if err := s.AuthManager.RegisterInstanceAttributesByID(params.Context, params.Header, id); err != nil {
+		blog.Errorf("update object attribute success , but register instance attribute to auth failed, err: %+v", err)
+		return nil, params.Err.Error(common.CCErrCommRegistResourceToIAMFailed)
+	}
 
 	return nil, err
 }
@@ -98,6 +115,10 @@ func (s *Service) DeleteObjectAttribute(params types.ContextParams, pathParams,
 		blog.Errorf("delete object attribute failed, deregistered model attribute to auth failed, err: %+v", err)
 		return nil, params.Err.Error(common.CCErrCommUnRegistResourceToIAMFailed)
 	}
+	if err := s.AuthManager.DeregisterInstanceAttributesByID(params.Context, params.Header, id); err != nil {
+		blog.Errorf("delete object attribute failed, deregistered instance attribute to auth failed, err: %+v", err)
+		return nil, params.Err.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+	}
 
 	err = s.Core.AttributeOperation().DeleteObjectAttribute(params, cond)
 
@@ -0,0 +1,216 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/auth"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// SearchRecycleRecords search the deleted instances in recycle bin
+func (s *Service) SearchRecycleRecords(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	query := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&query); nil != err {
+		blog.Errorf("[recyclebin] failed to parse the input (%#v), error info is %s", data, err.Error())
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+	if nil == query.Condition {
+		query.Condition = mapstr.New()
+	}
+	if 0 == query.Limit.Limit {
+		query.Limit.Limit = common.BKDefaultLimit
+	}
+
+	// the recycle bin keeps the whole data of the deleted instances, which is as sensitive as audit log.
+	var businessID int64
+	if query.Condition.Exists(common.BKAppIDField) {
+		id, err := query.Condition.Int64(common.BKAppIDField)
+		if err != nil {
+			blog.Errorf("%s field in query condition but parse int failed, err: %+v", common.BKAppIDField, err)
+			return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, common.BKAppIDField)
+		}
+		businessID = id
+	}
+	if err := s.AuthManager.AuthorizeAuditRead(params.Context, params.Header, businessID); err != nil {
+		blog.Errorf("SearchRecycleRecords failed, authorize failed, AuthorizeAuditRead failed, err: %+v", err)
+		resp, err := s.AuthManager.GenAuthorizeAuditReadNoPermissionsResponse(params.Context, params.Header, businessID)
+		if err != nil {
+			return nil, fmt.Errorf("try authorize failed, err: %v", err)
+		}
+		return resp, auth.NoAuthorizeError
+	}
+
+	rsp, err := s.Engine.CoreAPI.CoreService().RecycleBin().SearchRecycleRecords(params.Context, params.Header, &query)
+	if nil != err {
+		blog.Errorf("[recyclebin] failed to request core service, err: %s", err.Error())
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[recyclebin] failed to search recycle records, condition: %#v, err: %s", query, rsp.ErrMsg)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	// auth: remove the protected attributes which the user has no authority to read
+	objDatas := make(map[string][]mapstr.MapStr)
+	for _, record := range rsp.Data.Info {
+		if record.Data == nil {
+			continue
+		}
+		objDatas[record.ObjectID] = append(objDatas[record.ObjectID], record.Data)
+	}
+	for objID, datas := range objDatas {
+		if err := s.AuthManager.RedactUnauthorizedInstanceAttributes(params.Context, params.Header, objID, datas...); err != nil {
+			blog.Errorf("[recyclebin] redact instance attribute failed, object: %s, err: %+v, rid: %s", objID, err, params.ReqID)
+			return nil, params.Err.Error(common.CCErrCommAuthorizeFailed)
+		}
+	}
+	return rsp.Data, nil
+}
+
+// RestoreRecycleRecords restore the deleted instances in recycle bin
+func (s *Service) RestoreRecycleRecords(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.RestoreRecycleRecords{}
+	if err := data.MarshalJSONInto(&input); nil != err {
+		blog.Errorf("[recyclebin] failed to parse the input (%#v), error info is %s", data, err.Error())
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+	if len(input.IDs) == 0 {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	cond := condition.CreateCondition()
+	cond.Field(common.BKFieldID).In(input.IDs)
+	if err := s.authorizeRecycleRecords(params, cond, meta.Create); err != nil {
+		return nil, err
+	}
+
+	rsp, err := s.Engine.CoreAPI.CoreService().RecycleBin().RestoreRecycleRecords(params.Context, params.Header, &input)
+	if nil != err {
+		blog.Errorf("[recyclebin] failed to request core service, err: %s", err.Error())
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[recyclebin] failed to restore recycle records %v, err: %s", input.IDs, rsp.ErrMsg)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	// the instances is deregistered from auth center when they are deleted.
+	restoredIDs := make(map[string][]int64)
+	for _, restored := range rsp.Data.Restored {
+		restoredIDs[restored.ObjectID] = append(restoredIDs[restored.ObjectID], restored.InstID)
+	}
+	for objID, instIDs := range restoredIDs {
+		var err error
+		switch objID {
+		case common.BKInnerObjIDHost:
+			err = s.AuthManager.RegisterHostsByID(params.Context, params.Header, instIDs...)
+		case common.BKInnerObjIDSet:
+			err = s.AuthManager.RegisterSetByID(params.Context, params.Header, instIDs...)
+		case common.BKInnerObjIDModule:
+			err = s.AuthManager.RegisterModuleByID(params.Context, params.Header, instIDs...)
+		default:
+			err = s.AuthManager.RegisterInstancesByID(params.Context, params.Header, objID, instIDs...)
+		}
+		if err != nil {
+			blog.Errorf("[recyclebin] restore recycle records success, but register %s instances %v to iam failed, err: %+v", objID, instIDs, err)
+			return nil, params.Err.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+	}
+
+	return rsp.Data, nil
+}
+
+// PurgeRecycleRecords delete the instances in recycle bin permanently
+func (s *Service) PurgeRecycleRecords(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.PurgeRecycleRecords{}
+	if err := data.MarshalJSONInto(&input); nil != err {
+		blog.Errorf("[recyclebin] failed to parse the input (%#v), error info is %s", data, err.Error())
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+	// all the expired records are purged when no ids is specified
+	if len(input.IDs) == 0 && !input.Expired {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	cond := condition.CreateCondition()
+	if len(input.IDs) > 0 {
+		cond.Field(common.BKFieldID).In(input.IDs)
+	}
+	if input.Expired {
+		cond.Field(metadata.RecycleRecordFieldExpireTime).Lte(time.Now())
+	}
+	if err := s.authorizeRecycleRecords(params, cond, meta.Delete); err != nil {
+		return nil, err
+	}
+
+	rsp, err := s.Engine.CoreAPI.CoreService().RecycleBin().PurgeRecycleRecords(params.Context, params.Header, &input)
+	if nil != err {
+		blog.Errorf("[recyclebin] failed to request core service, err: %s", err.Error())
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[recyclebin] failed to purge recycle records %v, err: %s", input.IDs, rsp.ErrMsg)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return rsp.Data, nil
+}
+
+// authorizeRecycleRecords check if the user has the authority to operate the instances of the records
+// matched by the condition, restoring requires create authority and purging requires delete authority.
+func (s *Service) authorizeRecycleRecords(params types.ContextParams, cond condition.Condition, action meta.Action) error {
+	query := &metadata.QueryCondition{
+		Condition: cond.ToMapStr(),
+		Fields:    []string{common.BKFieldID, common.BKObjIDField, common.BKAppIDField},
+		Limit:     metadata.SearchLimit{Limit: common.BKNoLimit},
+	}
+	rsp, err := s.Engine.CoreAPI.CoreService().RecycleBin().SearchRecycleRecords(params.Context, params.Header, query)
+	if nil != err {
+		blog.Errorf("[recyclebin] failed to request core service, err: %s", err.Error())
+		return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[recyclebin] failed to search recycle records by %+v, err: %s", query.Condition, rsp.ErrMsg)
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	authorized := make(map[string]bool)
+	for _, record := range rsp.Data.Info {
+		resourceType := meta.ModelInstance
+		if record.ObjectID == common.BKInnerObjIDHost {
+			resourceType = meta.HostInstance
+		}
+		key := fmt.Sprintf("%s:%d", resourceType, record.BizID)
+		if authorized[key] {
+			continue
+		}
+		authorize := s.AuthManager.AuthorizeResourceCreate
+		if action == meta.Delete {
+			authorize = s.AuthManager.AuthorizeResourceDelete
+		}
+		if err := authorize(params.Context, params.Header, record.BizID, resourceType); err != nil {
+			blog.Errorf("[recyclebin] authorize %s %s of business %d failed, err: %+v", action, resourceType, record.BizID, err)
+			return params.Err.Error(common.CCErrCommAuthNotHavePermission)
+		}
+		authorized[key] = true
+	}
+	return nil
+}
@@ -73,18 +73,28 @@ func (s *Service) WebService() *restful.Container {
 		if actionItem.Path == "/healthz" {
 			action = healthz
 		}
+		var route *restful.RouteBuilder
 		switch actionItem.Verb {
 		case http.MethodPost:
-			action.Route(action.POST(actionItem.Path).To(actionItem.Handler))
+			route = action.POST(actionItem.Path)
 		case http.MethodDelete:
-			action.Route(action.DELETE(actionItem.Path).To(actionItem.Handler))
+			route = action.DELETE(actionItem.Path)
 		case http.MethodPut:
-			action.Route(action.PUT(actionItem.Path).To(actionItem.Handler))
+			route = action.PUT(actionItem.Path)
 		case http.MethodGet:
-			action.Route(action.GET(actionItem.Path).To(actionItem.Handler))
+			route = action.GET(actionItem.Path)
 		default:
 			blog.Errorf(" the url (%s), the http method (%s) is not supported", actionItem.Path, actionItem.Verb)
+			continue
 		}
+		route.To(actionItem.Handler)
+		if actionItem.ReadSample != nil {
+			route.Reads(actionItem.ReadSample)
+		}
+		if actionItem.WriteSample != nil {
+			route.Writes(actionItem.WriteSample)
+		}
+		action.Route(route)
 	}
 	container := restful.NewContainer().Add(api)
 	container.Add(healthz)
@@ -188,6 +198,14 @@ func (s *Service) addActionEx(method string, path string, handlerFunc LogicFunc,
 	s.actions = append(s.actions, actionObject)
 }
 
+// addActionWithModels add an action with the samples of its request and response bodies, which
+// describe the action in the openapi document.
+func (s *Service) addActionWithModels(method string, path string, handlerFunc LogicFunc, readSample, writeSample interface{}) {
+	s.addActionEx(method, path, handlerFunc, nil, false)
+	s.actions[len(s.actions)-1].ReadSample = readSample
+	s.actions[len(s.actions)-1].WriteSample = writeSample
+}
+
 // Actions return the all actions
 func (s *Service) Actions() []*httpserver.Action {
 
@@ -284,7 +302,7 @@ func (s *Service) Actions() []*httpserver.Action {
 					s.sendCompleteResponse(resp, common.CCSystemBusy, dataErr.Error(), data)
 				}
 				return
-			}})
+			}, ReadSample: act.ReadSample, WriteSample: act.WriteSample})
 		}(a)
 
 	}
@@ -16,6 +16,7 @@ import (
 	"net/http"
 
 	"configcenter/src/common"
+	"configcenter/src/common/metadata"
 )
 
 func (s *Service) initHealth() {
@@ -29,26 +30,27 @@ func (s *Service) initAssociation() {
 	s.addAction(http.MethodDelete, "/topo/model/mainline/owners/{owner_id}/objectids/{bk_obj_id}", s.DeleteMainLineObject, nil)
 	s.addAction(http.MethodGet, "/topo/model/{owner_id}", s.SearchMainLineObjectTopo, nil)
 	s.addAction(http.MethodGet, "/topo/model/{owner_id}/{cls_id}/{bk_obj_id}", s.SearchObjectByClassificationID, nil)
-	s.addAction(http.MethodGet, "/topo/inst/{owner_id}/{bk_biz_id}", s.SearchBusinessTopo, nil)
+	s.addActionWithModels(http.MethodGet, "/topo/inst/{owner_id}/{bk_biz_id}", s.SearchBusinessTopo, nil, metadata.SearchTopoInstResult{})
 	// TODO: delete this api, it's not used by front.
 	s.addAction(http.MethodGet, "/topo/inst/child/{owner_id}/{obj_id}/{app_id}/{inst_id}", s.SearchMainLineChildInstTopo, nil)
 
 	// association type methods
 	s.addAction(http.MethodPost, "/topo/association/type/action/search/batch", s.SearchObjectAssoWithAssoKindList, nil)
-	s.addAction(http.MethodPost, "/topo/association/type/action/search", s.SearchAssociationType, nil)
-	s.addAction(http.MethodPost, "/topo/association/type/action/create", s.CreateAssociationType, nil)
-	s.addAction(http.MethodPut, "/topo/association/type/{id}/action/update", s.UpdateAssociationType, nil)
+	s.addActionWithModels(http.MethodPost, "/topo/association/type/action/search", s.SearchAssociationType, metadata.SearchAssociationTypeRequest{}, metadata.SearchAssociationTypeResult{})
+	s.addActionWithModels(http.MethodPost, "/topo/association/type/action/create", s.CreateAssociationType, metadata.AssociationKind{}, metadata.CreateAssociationTypeResult{})
+	s.addActionWithModels(http.MethodPut, "/topo/association/type/{id}/action/update", s.UpdateAssociationType, metadata.UpdateAssociationTypeRequest{}, metadata.UpdateAssociationTypeResult{})
 	s.addAction(http.MethodDelete, "/topo/association/type/{id}/action/delete", s.DeleteAssociationType, nil)
 
 	// object association methods
-	s.addAction(http.MethodPost, "/object/association/action/search", s.SearchObjectAssociation, nil)
-	s.addAction(http.MethodPost, "/object/association/action/create", s.CreateObjectAssociation, nil)
+	s.addActionWithModels(http.MethodPost, "/object/association/action/search", s.SearchObjectAssociation, metadata.SearchAssociationObjectRequest{}, metadata.SearchAssociationObjectResult{})
+	s.addActionWithModels(http.MethodPost, "/object/association/action/create", s.CreateObjectAssociation, metadata.Association{}, nil)
 	s.addAction(http.MethodPut, "/object/association/{id}/action/update", s.UpdateObjectAssociation, nil)
 	s.addAction(http.MethodDelete, "/object/association/{id}/action/delete", s.DeleteObjectAssociation, nil)
+	s.addActionWithModels(http.MethodPost, "/object/association/action/violations", s.FindObjectAssociationViolations, metadata.FindAssociationViolationsRequest{}, metadata.AssociationViolationsResult{})
 
 	// inst association methods
-	s.addAction(http.MethodPost, "/inst/association/action/search", s.SearchAssociationInst, nil)
-	s.addAction(http.MethodPost, "/inst/association/action/create", s.CreateAssociationInst, nil)
+	s.addActionWithModels(http.MethodPost, "/inst/association/action/search", s.SearchAssociationInst, metadata.SearchAssociationInstRequest{}, metadata.SearchAssociationInstResult{})
+	s.addActionWithModels(http.MethodPost, "/inst/association/action/create", s.CreateAssociationInst, metadata.CreateAssociationInstRequest{}, metadata.CreateAssociationInstResult{})
 	s.addAction(http.MethodDelete, "/inst/association/{association_id}/action/delete", s.DeleteAssociationInst, nil)
 
 	// topo search methods
@@ -63,10 +65,22 @@ func (s *Service) initAssociation() {
 
 func (s *Service) initAuditLog() {
 
-	s.addAction(http.MethodPost, "/audit/search", s.AuditQuery, nil)
+	s.addActionWithModels(http.MethodPost, "/audit/search", s.AuditQuery, metadata.QueryInput{}, metadata.AuditQueryResult{})
 	s.addAction(http.MethodPost, "/object/{bk_obj_id}/audit/search", s.InstanceAuditQuery, nil)
 }
 
+func (s *Service) initRecycleBin() {
+	s.addActionWithModels(http.MethodPost, "/recyclebin/search", s.SearchRecycleRecords, metadata.QueryCondition{}, metadata.SearchRecycleRecordsResult{})
+	s.addActionWithModels(http.MethodPost, "/recyclebin/restore", s.RestoreRecycleRecords, metadata.RestoreRecycleRecords{}, metadata.RestoreRecycleRecordsResp{})
+	s.addActionWithModels(http.MethodDelete, "/recyclebin/purge", s.PurgeRecycleRecords, metadata.PurgeRecycleRecords{}, nil)
+}
+
+func (s *Service) initHistory() {
+	s.addActionWithModels(http.MethodPost, "/inst/asof/{bk_obj_id}/{inst_id}", s.FindInstAsOf, metadata.HistoryQueryOption{}, metadata.InstanceAsOfResult{})
+	s.addActionWithModels(http.MethodPost, "/topo/inst/asof/{bk_biz_id}", s.SearchBusinessTopoAsOf, metadata.HistoryQueryOption{}, metadata.SearchTopoInstResult{})
+	s.addActionWithModels(http.MethodPost, "/topo/hostmodule/asof/{bk_host_id}", s.FindHostModulesAsOf, metadata.HistoryQueryOption{}, metadata.HostModulesAsOfResult{})
+}
+
 func (s *Service) initCompatiblev2() {
 	s.addAction(http.MethodPost, "/app/searchAll", s.SearchAllApp, nil)
 
@@ -197,6 +211,8 @@ func (s *Service) initService() {
 	s.initHealth()
 	s.initAssociation()
 	s.initAuditLog()
+	s.initRecycleBin()
+	s.initHistory()
 	s.initCompatiblev2()
 	s.initBusiness()
 	s.initInst()
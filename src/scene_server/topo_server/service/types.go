@@ -36,6 +36,9 @@ type action struct {
 	HandlerFunc                LogicFunc
 	HandlerParseOriginDataFunc ParseOriginDataFunc
 	PublicOnly bool
+	// ReadSample and WriteSample the samples of the request and the response bodies
+	ReadSample  interface{}
+	WriteSample interface{}
 }
 
 // API the API interface
@@ -27,8 +27,15 @@ type ServerOption struct {
 
 // Config export
 type Config struct {
-	Mongo mongo.Config
-	Redis redis.Config
+	Mongo      mongo.Config
+	Redis      redis.Config
+	RecycleBin RecycleBinConfig
+}
+
+// RecycleBinConfig the recycle bin config
+type RecycleBinConfig struct {
+	// RetentionDays the days that a deleted instance is kept in recycle bin
+	RetentionDays int
 }
 
 //NewServerOption create a ServerOption object
@@ -43,6 +50,6 @@ func NewServerOption() *ServerOption {
 //AddFlags add flags
 func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.AddrPort, "addrport", "127.0.0.1:60001", "The ip address and port for the serve on")
-	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181")
+	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181, etcd://127.0.0.1:2379 or file:///data/cmdb/regdiscv")
 	fs.StringVar(&s.ServConf.ExConfig, "config", "", "The config path. e.g conf/api.conf")
 }
@@ -16,6 +16,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"configcenter/src/common"
@@ -42,6 +43,7 @@ func (t *CoreServer) onCoreServiceConfigUpdate(previous, current cc.ProcessConfi
 
 	t.Config.Mongo = mongo.ParseConfigFromKV("mongodb", current.ConfigMap)
 	t.Config.Redis = redis.ParseConfigFromKV("redis", current.ConfigMap)
+	t.Config.RecycleBin.RetentionDays, _ = strconv.Atoi(current.ConfigMap["recyclebin.retention_days"])
 
 	blog.V(3).Infof("the new cfg:%#v the origin cfg:%#v", t.Config, current.ConfigMap)
 
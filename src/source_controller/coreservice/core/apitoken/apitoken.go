@@ -0,0 +1,227 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	// tokenBytes the random bytes of a plain token
+	tokenBytes = 20
+	// tokenPrefixLength the length of the token prefix kept for display
+	tokenPrefixLength = len(metadata.APITokenPrefix) + 6
+	// lastUsedPrecision the last used time is only updated when it is older than this,
+	// so that a busy token does not write the database on every request.
+	lastUsedPrecision = time.Minute
+)
+
+var _ core.APITokenOperation = (*apiTokenManager)(nil)
+
+type apiTokenManager struct {
+	dbProxy dal.RDB
+}
+
+// New create a new api token manager instance
+func New(dbProxy dal.RDB) core.APITokenOperation {
+	return &apiTokenManager{
+		dbProxy: dbProxy,
+	}
+}
+
+// CreateAPIToken create an api token for current user, the plain token is only returned here.
+func (m *apiTokenManager) CreateAPIToken(ctx core.ContextParams, inputParam metadata.CreateAPITokenRequest) (*metadata.CreatedAPIToken, error) {
+	if inputParam.Name == "" {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "name")
+	}
+	switch inputParam.Type {
+	case metadata.APITokenTypePersonal:
+		inputParam.User = ctx.User
+	case metadata.APITokenTypeService:
+		if inputParam.User == "" {
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "bk_user")
+		}
+	default:
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "type")
+	}
+	if len(inputParam.Scopes) == 0 {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "scopes")
+	}
+	for _, scope := range inputParam.Scopes {
+		if err := metadata.ValidateAPITokenScope(scope); err != nil {
+			blog.Errorf("CreateAPIToken failed, err: %v, rid: %s", err, ctx.ReqID)
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "scopes")
+		}
+	}
+	now := time.Now()
+	if inputParam.ExpireTime != nil && !inputParam.ExpireTime.After(now) {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.APITokenFieldExpireTime)
+	}
+
+	plain, err := generateToken()
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, generate token failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Errorf(common.CCErrCommInternalServerError, err.Error())
+	}
+	id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameAPIToken)
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, generate token id failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	token := metadata.APIToken{
+		ID:          int64(id),
+		OwnerID:     ctx.SupplierAccount,
+		Name:        inputParam.Name,
+		Type:        inputParam.Type,
+		User:        inputParam.User,
+		Creator:     ctx.User,
+		TokenHash:   hashToken(plain),
+		TokenPrefix: plain[:tokenPrefixLength],
+		Scopes:      inputParam.Scopes,
+		ExpireTime:  inputParam.ExpireTime,
+		CreateTime:  now,
+	}
+	if err := m.dbProxy.Table(common.BKTableNameAPIToken).Insert(ctx, token); err != nil {
+		blog.Errorf("CreateAPIToken failed, insert token failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	return &metadata.CreatedAPIToken{APIToken: token, Token: plain}, nil
+}
+
+// SearchAPITokens search the api tokens of current supplier account
+func (m *apiTokenManager) SearchAPITokens(ctx core.ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryAPITokensResult, error) {
+	cond := util.SetQueryOwner(inputParam.Condition, ctx.SupplierAccount)
+
+	query := m.dbProxy.Table(common.BKTableNameAPIToken).Find(cond)
+	if len(inputParam.SortArr) == 0 {
+		query = query.Sort(common.BKFieldID)
+	}
+	for _, sort := range inputParam.SortArr {
+		field := sort.Field
+		if sort.IsDsc {
+			field = "-" + field
+		}
+		query = query.Sort(field)
+	}
+
+	tokens := make([]metadata.APIToken, 0)
+	err := query.Start(uint64(inputParam.Limit.Offset)).Limit(uint64(inputParam.Limit.Limit)).All(ctx, &tokens)
+	if err != nil {
+		blog.Errorf("SearchAPITokens failed, search tokens failed, err: %+v, cond: %+v, rid: %s", err, cond, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	count, err := m.dbProxy.Table(common.BKTableNameAPIToken).Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("SearchAPITokens failed, count tokens failed, err: %+v, cond: %+v, rid: %s", err, cond, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.QueryAPITokensResult{Count: count, Info: tokens}, nil
+}
+
+// RevokeAPITokens revoke the api tokens created by or acting as current user, the revoked
+// tokens are kept for audit.
+func (m *apiTokenManager) RevokeAPITokens(ctx core.ContextParams, inputParam metadata.RevokeAPITokenRequest) (*metadata.UpdatedCount, error) {
+	if len(inputParam.IDs) == 0 {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	cond.Element(&mongo.In{Key: common.BKFieldID, Val: inputParam.IDs})
+	cond.Element(&mongo.Eq{Key: metadata.APITokenFieldRevoked, Val: false})
+	cond.Or(&mongo.Eq{Key: metadata.APITokenFieldCreator, Val: ctx.User})
+	cond.Or(&mongo.Eq{Key: metadata.APITokenFieldUser, Val: ctx.User})
+
+	count, err := m.dbProxy.Table(common.BKTableNameAPIToken).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		blog.Errorf("RevokeAPITokens failed, count tokens failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	doc := mapstr.MapStr{
+		metadata.APITokenFieldRevoked:    true,
+		metadata.APITokenFieldRevokeTime: time.Now(),
+	}
+	if err := m.dbProxy.Table(common.BKTableNameAPIToken).Update(ctx, cond.ToMapStr(), doc); err != nil {
+		blog.Errorf("RevokeAPITokens failed, update tokens failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return &metadata.UpdatedCount{Count: count}, nil
+}
+
+// ValidateAPIToken find the api token by the plain token, and record the last used time.
+// the token of any supplier account can be validated, as the supplier account is decided by the token.
+func (m *apiTokenManager) ValidateAPIToken(ctx core.ContextParams, inputParam metadata.ValidateAPITokenRequest) (*metadata.APIToken, error) {
+	if !strings.HasPrefix(inputParam.Token, metadata.APITokenPrefix) {
+		return nil, ctx.Error.Error(common.CCErrAPITokenInvalid)
+	}
+
+	tokenHash := hashToken(inputParam.Token)
+	cond := mapstr.MapStr{metadata.APITokenFieldTokenHash: tokenHash}
+	tokens := make([]metadata.APIToken, 0)
+	if err := m.dbProxy.Table(common.BKTableNameAPIToken).Find(cond).All(ctx, &tokens); err != nil {
+		blog.Errorf("ValidateAPIToken failed, search token failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	now := time.Now()
+	if len(tokens) != 1 || tokens[0].Revoked || tokens[0].Expired(now) {
+		blog.V(4).Infof("ValidateAPIToken, the token is not found, revoked or expired, rid: %s", ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrAPITokenInvalid)
+	}
+	token := tokens[0]
+
+	if token.LastUsedTime == nil || now.Sub(*token.LastUsedTime) > lastUsedPrecision {
+		updateCond := mapstr.MapStr{
+			metadata.APITokenFieldTokenHash: tokenHash,
+			common.BKDBOR: []mapstr.MapStr{
+				{metadata.APITokenFieldLastUsedTime: nil},
+				{metadata.APITokenFieldLastUsedTime: mapstr.MapStr{common.BKDBLT: now.Add(-lastUsedPrecision)}},
+			},
+		}
+		doc := mapstr.MapStr{metadata.APITokenFieldLastUsedTime: now}
+		if err := m.dbProxy.Table(common.BKTableNameAPIToken).Update(ctx, updateCond, doc); err != nil {
+			// the token is still valid even if the last used time is not recorded.
+			blog.Warnf("ValidateAPIToken, update last used time of token %d failed, err: %+v, rid: %s", token.ID, err, ctx.ReqID)
+		} else {
+			token.LastUsedTime = &now
+		}
+	}
+	return &token, nil
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return metadata.APITokenPrefix + hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,214 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apitoken
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+// fakeDB keeps the tokens in memory and records the writes, the filters are not evaluated,
+// every find returns all the tokens.
+type fakeDB struct {
+	dal.RDB
+	tokens  []metadata.APIToken
+	inserts []interface{}
+	updates []mapstr.MapStr
+}
+
+func (db *fakeDB) Table(collection string) dal.Table {
+	return &fakeTable{db: db}
+}
+
+func (db *fakeDB) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	return uint64(len(db.inserts) + 1), nil
+}
+
+type fakeTable struct {
+	dal.Table
+	db *fakeDB
+}
+
+func (t *fakeTable) Find(filter dal.Filter) dal.Find {
+	return &fakeFind{db: t.db}
+}
+
+func (t *fakeTable) Insert(ctx context.Context, docs interface{}) error {
+	t.db.inserts = append(t.db.inserts, docs)
+	return nil
+}
+
+func (t *fakeTable) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	t.db.updates = append(t.db.updates, doc.(mapstr.MapStr))
+	return nil
+}
+
+type fakeFind struct {
+	dal.Find
+	db *fakeDB
+}
+
+func (f *fakeFind) All(ctx context.Context, result interface{}) error {
+	*result.(*[]metadata.APIToken) = append([]metadata.APIToken(nil), f.db.tokens...)
+	return nil
+}
+
+func (f *fakeFind) Count(ctx context.Context) (uint64, error) {
+	return uint64(len(f.db.tokens)), nil
+}
+
+func newTestContext() core.ContextParams {
+	return core.ContextParams{
+		Context:         context.Background(),
+		ReqID:           "test_req_id",
+		SupplierAccount: "0",
+		User:            "admin",
+		Error:           errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+	}
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name     string
+		input    metadata.CreateAPITokenRequest
+		wantErr  bool
+		wantUser string
+	}{
+		{"personal token acts as current user", metadata.CreateAPITokenRequest{Name: "ci", Type: metadata.APITokenTypePersonal, User: "other", Scopes: []string{"host:read"}}, false, "admin"},
+		{"service token acts as the service account", metadata.CreateAPITokenRequest{Name: "ci", Type: metadata.APITokenTypeService, User: "robot", Scopes: []string{"*:*"}}, false, "robot"},
+		{"service token needs the service account", metadata.CreateAPITokenRequest{Name: "ci", Type: metadata.APITokenTypeService, Scopes: []string{"*:*"}}, true, ""},
+		{"name is required", metadata.CreateAPITokenRequest{Type: metadata.APITokenTypePersonal, Scopes: []string{"host:read"}}, true, ""},
+		{"unknown type", metadata.CreateAPITokenRequest{Name: "ci", Type: "robot", Scopes: []string{"host:read"}}, true, ""},
+		{"scopes are required", metadata.CreateAPITokenRequest{Name: "ci", Type: metadata.APITokenTypePersonal}, true, ""},
+		{"invalid scope", metadata.CreateAPITokenRequest{Name: "ci", Type: metadata.APITokenTypePersonal, Scopes: []string{"admin:read"}}, true, ""},
+		{"expired on creation", metadata.CreateAPITokenRequest{Name: "ci", Type: metadata.APITokenTypePersonal, Scopes: []string{"host:read"}, ExpireTime: &past}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{}
+			created, err := New(db).CreateAPIToken(newTestContext(), tt.input)
+			if tt.wantErr {
+				if err == nil || len(db.inserts) != 0 {
+					t.Errorf("CreateAPIToken() want error without insert, got err: %v, inserts: %d", err, len(db.inserts))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateAPIToken() unexpected error: %v", err)
+			}
+			if created.User != tt.wantUser || created.Creator != "admin" || created.OwnerID != "0" {
+				t.Errorf("CreateAPIToken() user: %s, creator: %s, owner: %s", created.User, created.Creator, created.OwnerID)
+			}
+			if !strings.HasPrefix(created.Token, metadata.APITokenPrefix) || !strings.HasPrefix(created.Token, created.TokenPrefix) {
+				t.Errorf("CreateAPIToken() token %s does not start with prefix %s", created.Token, created.TokenPrefix)
+			}
+			if len(db.inserts) != 1 {
+				t.Fatalf("CreateAPIToken() want 1 insert, got %d", len(db.inserts))
+			}
+			stored := db.inserts[0].(metadata.APIToken)
+			if stored.TokenHash != hashToken(created.Token) || strings.Contains(stored.TokenHash, created.Token) {
+				t.Errorf("CreateAPIToken() stored hash %s does not match the plain token", stored.TokenHash)
+			}
+		})
+	}
+}
+
+func TestRevokeAPITokens(t *testing.T) {
+	db := &fakeDB{tokens: []metadata.APIToken{{ID: 1}}}
+	manager := New(db)
+
+	if _, err := manager.RevokeAPITokens(newTestContext(), metadata.RevokeAPITokenRequest{}); err == nil {
+		t.Errorf("RevokeAPITokens() without ids want error")
+	}
+	if len(db.updates) != 0 {
+		t.Fatalf("RevokeAPITokens() without ids should not update, got %d updates", len(db.updates))
+	}
+
+	result, err := manager.RevokeAPITokens(newTestContext(), metadata.RevokeAPITokenRequest{IDs: []int64{1}})
+	if err != nil {
+		t.Fatalf("RevokeAPITokens() unexpected error: %v", err)
+	}
+	if result.Count != 1 || len(db.updates) != 1 {
+		t.Fatalf("RevokeAPITokens() count: %d, updates: %d", result.Count, len(db.updates))
+	}
+	if revoked, _ := db.updates[0][metadata.APITokenFieldRevoked].(bool); !revoked {
+		t.Errorf("RevokeAPITokens() should set %s, got %v", metadata.APITokenFieldRevoked, db.updates[0])
+	}
+	if _, ok := db.updates[0][metadata.APITokenFieldRevokeTime]; !ok {
+		t.Errorf("RevokeAPITokens() should record %s, got %v", metadata.APITokenFieldRevokeTime, db.updates[0])
+	}
+}
+
+func TestValidateAPIToken(t *testing.T) {
+	plain := metadata.APITokenPrefix + "0123456789abcdef"
+	now := time.Now()
+	recent := now.Add(-lastUsedPrecision / 2)
+	stale := now.Add(-2 * lastUsedPrecision)
+	expired := now.Add(-time.Second)
+
+	tests := []struct {
+		name        string
+		plain       string
+		token       metadata.APIToken
+		wantErr     bool
+		wantUpdated bool
+	}{
+		{"never used token records last used time", plain, metadata.APIToken{ID: 1}, false, true},
+		{"stale last used time is updated", plain, metadata.APIToken{ID: 1, LastUsedTime: &stale}, false, true},
+		{"recent last used time is not written again", plain, metadata.APIToken{ID: 1, LastUsedTime: &recent}, false, false},
+		{"revoked token", plain, metadata.APIToken{ID: 1, Revoked: true}, true, false},
+		{"expired token", plain, metadata.APIToken{ID: 1, ExpireTime: &expired}, true, false},
+		{"token without prefix", "0123456789abcdef", metadata.APIToken{ID: 1}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.token.TokenHash = hashToken(tt.plain)
+			db := &fakeDB{tokens: []metadata.APIToken{tt.token}}
+			token, err := New(db).ValidateAPIToken(newTestContext(), metadata.ValidateAPITokenRequest{Token: tt.plain})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAPIToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if updated := len(db.updates) != 0; updated != tt.wantUpdated {
+				t.Errorf("ValidateAPIToken() updated last used time = %v, want %v", updated, tt.wantUpdated)
+			}
+			if tt.wantUpdated {
+				if token.LastUsedTime == nil || token.LastUsedTime.Before(now) {
+					t.Errorf("ValidateAPIToken() last used time %v is not refreshed", token.LastUsedTime)
+				}
+				if _, ok := db.updates[0][metadata.APITokenFieldLastUsedTime]; !ok {
+					t.Errorf("ValidateAPIToken() should update %s, got %v", metadata.APITokenFieldLastUsedTime, db.updates[0])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAPITokenNotFound(t *testing.T) {
+	_, err := New(&fakeDB{}).ValidateAPIToken(newTestContext(), metadata.ValidateAPITokenRequest{Token: metadata.APITokenPrefix + "00"})
+	coder, ok := err.(errors.CCErrorCoder)
+	if !ok || coder.GetCode() != common.CCErrAPITokenInvalid {
+		t.Errorf("ValidateAPIToken() of unknown token want error %d, got %v", common.CCErrAPITokenInvalid, err)
+	}
+}
@@ -15,6 +15,8 @@ package association
 import (
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/dal"
+
+	redis "gopkg.in/redis.v5"
 )
 
 var _ core.AssociationOperation = (*associationManager)(nil)
@@ -27,7 +29,7 @@ type associationManager struct {
 }
 
 // New create a new association manager instance
-func New(dbProxy dal.RDB, dependent OperationDependences) core.AssociationOperation {
+func New(dbProxy dal.RDB, dependent OperationDependences, cache *redis.Client) core.AssociationOperation {
 	asstModel := &associationModel{dbProxy: dbProxy}
 	asstKind := &associationKind{
 		dbProxy:          dbProxy,
@@ -41,6 +43,7 @@ func New(dbProxy dal.RDB, dependent OperationDependences) core.AssociationOperat
 			associationKind:  asstKind,
 			associationModel: asstModel,
 			dependent:        dependent,
+			cache:            cache,
 		},
 		associationModel: &associationModel{
 			dbProxy: dbProxy,
@@ -21,6 +21,8 @@ import (
 	"configcenter/src/common/universalsql/mongo"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/dal"
+
+	redis "gopkg.in/redis.v5"
 )
 
 type associationInstance struct {
@@ -28,6 +30,7 @@ type associationInstance struct {
 	*associationKind
 	*associationModel
 	dependent OperationDependences
+	cache     *redis.Client
 }
 
 func (m *associationInstance) isExists(ctx core.ContextParams, instID, asstInstID int64, objAsstID string, meta metadata.Metadata) (origin *metadata.InstAsst, exists bool, err error) {
@@ -104,7 +107,7 @@ func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParam
 	//check association kind
 	cond := mongo.NewCondition()
 	cond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: inputParam.Data.ObjectAsstID})
-	_, exists, err = m.associationModel.isExists(ctx, cond)
+	objAsst, exists, err := m.associationModel.isExists(ctx, cond)
 	if nil != err {
 		blog.Errorf("check asst kind(%#v)is not exist", inputParam.Data.ObjectAsstID)
 		return nil, err
@@ -133,7 +136,8 @@ func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParam
 		blog.Errorf("asst inst is not exist objid(%#v), instid(%#v)", inputParam.Data.ObjectID, inputParam.Data.InstID)
 		return nil, ctx.Error.Error(common.CCErrorInstToAsstIsNotExist)
 	}
-	id, err := m.save(ctx, inputParam.Data)
+	//check the mapping and max count constraints and save
+	id, err := m.saveWithinLimits(ctx, objAsst, inputParam.Data)
 	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
 }
 
@@ -219,8 +223,17 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 			})
 			continue
 		}
-		//save asst inst
-		id, err := m.save(ctx, item)
+		//check the mapping and max count constraints and save asst inst
+		objAsstCond := mongo.NewCondition()
+		objAsstCond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: item.ObjectAsstID})
+		objAsst, exists, err := m.associationModel.isExists(ctx, objAsstCond)
+		if nil == err && !exists {
+			err = ctx.Error.Error(common.CCErrorTopoAsstKindIsNotExist)
+		}
+		var id uint64
+		if nil == err {
+			id, err = m.saveWithinLimits(ctx, objAsst, item)
+		}
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
 				Message:     err.Error(),
@@ -0,0 +1,271 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+
+	redis "gopkg.in/redis.v5"
+)
+
+const (
+	instanceLimitLockExpire = 30 * time.Second
+	instanceLimitLockRetry  = 50
+)
+
+// unlockInstanceLimitScript delete the lock only if it's still held by the value, so that
+// a lock expired and taken by others is never released by the previous holder
+var unlockInstanceLimitScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// saveWithinLimits save the instance association with the instances on the limited sides locked, so that
+// the concurrent creations never pass the limit check together.
+func (m *associationInstance) saveWithinLimits(ctx core.ContextParams, asst *metadata.Association, item metadata.InstAsst) (uint64, error) {
+	unlock, err := m.lockInstanceLimits(ctx, asst, item)
+	if nil != err {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := m.checkInstanceLimits(ctx, asst, item); nil != err {
+		return 0, err
+	}
+	return m.save(ctx, item)
+}
+
+// instanceLimitLockKeys return the lock keys of the instances on the sides of the object association
+// which have a limit, the sides without limit are never counted and need no lock.
+func instanceLimitLockKeys(asst *metadata.Association, item metadata.InstAsst) []string {
+	maxDestPerSrc, maxSrcPerDest := asst.InstanceLimits()
+	keys := make([]string, 0)
+	if maxDestPerSrc > 0 {
+		keys = append(keys, fmt.Sprintf("%s%s:%s:%d", common.RedisCoreSrvAsstInstLimitLockKeyPrefix, item.ObjectAsstID, metadata.AssociationSideSource, item.InstID))
+	}
+	if maxSrcPerDest > 0 {
+		keys = append(keys, fmt.Sprintf("%s%s:%s:%d", common.RedisCoreSrvAsstInstLimitLockKeyPrefix, item.ObjectAsstID, metadata.AssociationSideDestination, item.AsstInstID))
+	}
+	return keys
+}
+
+// lockInstanceLimits lock the instances on the limited sides of the object association, the returned
+// function release the locks taken.
+func (m *associationInstance) lockInstanceLimits(ctx core.ContextParams, asst *metadata.Association, item metadata.InstAsst) (func(), error) {
+	keys := instanceLimitLockKeys(asst, item)
+	if nil == m.cache || 0 == len(keys) {
+		return func() {}, nil
+	}
+
+	value := ctx.ReqID
+	if value == "" {
+		value = util.GenerateRID()
+	}
+	locked := make([]string, 0)
+	unlock := func() {
+		for _, key := range locked {
+			if err := unlockInstanceLimitScript.Run(m.cache, []string{key}, value).Err(); nil != err {
+				blog.Warnf("request(%s): unlock the instance association limit %s failed, err: %s", ctx.ReqID, key, err.Error())
+			}
+		}
+	}
+
+	for _, key := range keys {
+		ok := false
+		for retry := 0; retry < instanceLimitLockRetry && !ok; retry++ {
+			if 0 < retry {
+				time.Sleep(time.Millisecond * 100)
+			}
+			var err error
+			ok, err = m.cache.SetNX(key, value, instanceLimitLockExpire).Result()
+			if nil != err {
+				blog.Errorf("request(%s): lock the instance association limit %s failed, err: %s", ctx.ReqID, key, err.Error())
+				unlock()
+				return nil, ctx.Error.Errorf(common.CCErrCommUtilHandleFail, "redis setnx", err.Error())
+			}
+		}
+		if !ok {
+			blog.Errorf("request(%s): the instance association limit %s is locked by others", ctx.ReqID, key)
+			unlock()
+			return nil, ctx.Error.Errorf(common.CCErrCommUtilHandleFail, "lock instance association", key)
+		}
+		locked = append(locked, key)
+	}
+	return unlock, nil
+}
+
+// checkInstanceLimits check whether the instances on both side of the new instance association can be
+// associated with one more instance, according to the mapping and the max count constraints.
+func (m *associationInstance) checkInstanceLimits(ctx core.ContextParams, asst *metadata.Association, item metadata.InstAsst) error {
+	maxDestPerSrc, maxSrcPerDest := asst.InstanceLimits()
+
+	if maxDestPerSrc > 0 {
+		cond := mapstr.MapStr{
+			common.AssociationObjAsstIDField: item.ObjectAsstID,
+			common.BKInstIDField:             item.InstID,
+			common.BKOwnerIDField:            ctx.SupplierAccount,
+		}
+		cnt, err := m.instCount(ctx, cond)
+		if nil != err {
+			blog.Errorf("request(%s): count the instance associations by the condition (%#v) failed, err: %s", ctx.ReqID, cond, err.Error())
+			return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+		}
+		if int64(cnt) >= maxDestPerSrc {
+			blog.Errorf("request(%s): the source instance %d has been associated with %d instances by %s, limit %d", ctx.ReqID, item.InstID, cnt, item.ObjectAsstID, maxDestPerSrc)
+			return m.limitError(ctx, asst, maxDestPerSrc)
+		}
+	}
+
+	if maxSrcPerDest > 0 {
+		cond := mapstr.MapStr{
+			common.AssociationObjAsstIDField: item.ObjectAsstID,
+			common.BKAsstInstIDField:         item.AsstInstID,
+			common.BKOwnerIDField:            ctx.SupplierAccount,
+		}
+		cnt, err := m.instCount(ctx, cond)
+		if nil != err {
+			blog.Errorf("request(%s): count the instance associations by the condition (%#v) failed, err: %s", ctx.ReqID, cond, err.Error())
+			return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+		}
+		if int64(cnt) >= maxSrcPerDest {
+			blog.Errorf("request(%s): the destination instance %d has been associated by %d instances by %s, limit %d", ctx.ReqID, item.AsstInstID, cnt, item.ObjectAsstID, maxSrcPerDest)
+			return m.limitError(ctx, asst, maxSrcPerDest)
+		}
+	}
+
+	return nil
+}
+
+// limitError keep the error codes of the mapping the same as the ones returned by the topo server before.
+func (m *associationInstance) limitError(ctx core.ContextParams, asst *metadata.Association, limit int64) error {
+	if asst.MaxDestCount == 0 && asst.MaxSrcCount == 0 {
+		switch asst.Mapping {
+		case metadata.OneToOneMapping:
+			return ctx.Error.Error(common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation)
+		case metadata.OneToManyMapping:
+			return ctx.Error.Error(common.CCErrorTopoCreateMultipleInstancesForOneToManyAssociation)
+		}
+	}
+	return ctx.Error.Errorf(common.CCErrorTopoAssociationInstanceCountExceedLimit, asst.AssociationName, limit)
+}
+
+// FindInstanceAssociationViolations find all the instances which are associated with more instances than the
+// object association allows, they may be created before the constraints are enforced or changed.
+func (m *associationInstance) FindInstanceAssociationViolations(ctx core.ContextParams, inputParam metadata.FindAssociationViolationsRequest) ([]metadata.AssociationViolation, error) {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.In{Key: common.BKOwnerIDField, Val: []string{ctx.SupplierAccount, common.BKDefaultOwnerID}})
+	if len(inputParam.ObjectAsstIDs) > 0 {
+		cond.Element(&mongo.In{Key: metadata.AssociationFieldAsstID, Val: inputParam.ObjectAsstIDs})
+	}
+	assts, err := m.associationModel.search(ctx, cond)
+	if nil != err {
+		blog.Errorf("request(%s): search the object associations by the condition (%#v) failed, err: %s", ctx.ReqID, cond.ToMapStr(), err.Error())
+		return nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	violations := make([]metadata.AssociationViolation, 0)
+	for idx := range assts {
+		asst := &assts[idx]
+		maxDestPerSrc, maxSrcPerDest := asst.InstanceLimits()
+
+		if maxDestPerSrc > 0 {
+			items, err := m.findExceededInstances(ctx, asst.AssociationName, common.BKInstIDField, maxDestPerSrc)
+			if nil != err {
+				return nil, err
+			}
+			violations = append(violations, newAssociationViolations(asst, metadata.AssociationSideSource, items)...)
+		}
+
+		if maxSrcPerDest > 0 {
+			items, err := m.findExceededInstances(ctx, asst.AssociationName, common.BKAsstInstIDField, maxSrcPerDest)
+			if nil != err {
+				return nil, err
+			}
+			violations = append(violations, newAssociationViolations(asst, metadata.AssociationSideDestination, items)...)
+		}
+	}
+
+	return violations, nil
+}
+
+// newAssociationViolations convert the instances exceeding the limit on the side of the object association
+// into violations, the items which are within the limit are ignored.
+func newAssociationViolations(asst *metadata.Association, side string, items []exceededInstance) []metadata.AssociationViolation {
+	maxDestPerSrc, maxSrcPerDest := asst.InstanceLimits()
+	objID, limit := asst.ObjectID, maxDestPerSrc
+	if side == metadata.AssociationSideDestination {
+		objID, limit = asst.AsstObjID, maxSrcPerDest
+	}
+
+	violations := make([]metadata.AssociationViolation, 0)
+	if limit <= 0 {
+		return violations
+	}
+	for _, item := range items {
+		if item.Total <= limit {
+			continue
+		}
+		violations = append(violations, metadata.AssociationViolation{
+			ObjectAsstID: asst.AssociationName,
+			Mapping:      asst.Mapping,
+			Side:         side,
+			ObjectID:     objID,
+			InstID:       item.InstID,
+			Count:        item.Total,
+			Limit:        limit,
+		})
+	}
+	return violations
+}
+
+type exceededInstance struct {
+	InstID int64 `bson:"_id"`
+	Total  int64 `bson:"total"`
+}
+
+// findExceededInstances group the instance associations by the instance id field, and find the
+// instances which are associated with more instances than the limit.
+func (m *associationInstance) findExceededInstances(ctx core.ContextParams, objAsstID, instIDField string, limit int64) ([]exceededInstance, error) {
+	pipeline := []mapstr.MapStr{
+		{common.BKDBMatch: mapstr.MapStr{
+			common.AssociationObjAsstIDField: objAsstID,
+			common.BKOwnerIDField:            ctx.SupplierAccount,
+		}},
+		{common.BKDBGroup: mapstr.MapStr{
+			"_id":   "$" + instIDField,
+			"total": mapstr.MapStr{common.BKDBSum: 1},
+		}},
+		{common.BKDBMatch: mapstr.MapStr{
+			"total": mapstr.MapStr{common.BKDBGT: limit},
+		}},
+		{"$sort": mapstr.MapStr{"_id": 1}},
+	}
+
+	results := make([]exceededInstance, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).AggregateAll(ctx, pipeline, &results); nil != err {
+		blog.ErrorJSON("request(%s): aggregate the instance associations failed, err: %s, pipeline: %s", ctx.ReqID, err, pipeline)
+		return nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	return results, nil
+}
@@ -0,0 +1,147 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestInstanceLimits(t *testing.T) {
+	tests := []struct {
+		name              string
+		asst              metadata.Association
+		wantMaxDestPerSrc int64
+		wantMaxSrcPerDest int64
+	}{
+		{"one to one", metadata.Association{Mapping: metadata.OneToOneMapping}, 1, 1},
+		{"one to one ignores max count", metadata.Association{Mapping: metadata.OneToOneMapping, MaxDestCount: 5, MaxSrcCount: 5}, 1, 1},
+		{"one to many", metadata.Association{Mapping: metadata.OneToManyMapping}, 0, 1},
+		{"one to many with max destination count", metadata.Association{Mapping: metadata.OneToManyMapping, MaxDestCount: 3}, 3, 1},
+		{"many to many", metadata.Association{Mapping: metadata.ManyToManyMapping}, 0, 0},
+		{"many to many with max count", metadata.Association{Mapping: metadata.ManyToManyMapping, MaxDestCount: 3, MaxSrcCount: 2}, 3, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxDestPerSrc, maxSrcPerDest := tt.asst.InstanceLimits()
+			if maxDestPerSrc != tt.wantMaxDestPerSrc || maxSrcPerDest != tt.wantMaxSrcPerDest {
+				t.Errorf("InstanceLimits() = (%d, %d), want (%d, %d)", maxDestPerSrc, maxSrcPerDest, tt.wantMaxDestPerSrc, tt.wantMaxSrcPerDest)
+			}
+		})
+	}
+}
+
+func TestNewAssociationViolations(t *testing.T) {
+	oneToOne := &metadata.Association{
+		AssociationName: "switch_connect_host",
+		ObjectID:        "switch",
+		AsstObjID:       "host",
+		Mapping:         metadata.OneToOneMapping,
+	}
+	oneToMany := &metadata.Association{
+		AssociationName: "switch_connect_router",
+		ObjectID:        "switch",
+		AsstObjID:       "router",
+		Mapping:         metadata.OneToManyMapping,
+		MaxDestCount:    3,
+	}
+	manyToMany := &metadata.Association{
+		AssociationName: "router_connect_router",
+		ObjectID:        "router",
+		AsstObjID:       "router",
+		Mapping:         metadata.ManyToManyMapping,
+	}
+
+	tests := []struct {
+		name  string
+		asst  *metadata.Association
+		side  string
+		items []exceededInstance
+		want  []metadata.AssociationViolation
+	}{
+		{
+			name:  "one to one source exceeded",
+			asst:  oneToOne,
+			side:  metadata.AssociationSideSource,
+			items: []exceededInstance{{InstID: 1, Total: 2}},
+			want: []metadata.AssociationViolation{
+				{ObjectAsstID: "switch_connect_host", Mapping: metadata.OneToOneMapping, Side: "src", ObjectID: "switch", InstID: 1, Count: 2, Limit: 1},
+			},
+		},
+		{
+			name:  "one to one destination exceeded",
+			asst:  oneToOne,
+			side:  metadata.AssociationSideDestination,
+			items: []exceededInstance{{InstID: 7, Total: 3}},
+			want: []metadata.AssociationViolation{
+				{ObjectAsstID: "switch_connect_host", Mapping: metadata.OneToOneMapping, Side: "dest", ObjectID: "host", InstID: 7, Count: 3, Limit: 1},
+			},
+		},
+		{
+			name:  "within the max destination count",
+			asst:  oneToMany,
+			side:  metadata.AssociationSideSource,
+			items: []exceededInstance{{InstID: 1, Total: 3}, {InstID: 2, Total: 4}},
+			want: []metadata.AssociationViolation{
+				{ObjectAsstID: "switch_connect_router", Mapping: metadata.OneToManyMapping, Side: "src", ObjectID: "switch", InstID: 2, Count: 4, Limit: 3},
+			},
+		},
+		{
+			name:  "no limit never violated",
+			asst:  manyToMany,
+			side:  metadata.AssociationSideSource,
+			items: []exceededInstance{{InstID: 1, Total: 100}},
+			want:  []metadata.AssociationViolation{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newAssociationViolations(tt.asst, tt.side, tt.items)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newAssociationViolations() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstanceLimitLockKeys(t *testing.T) {
+	item := metadata.InstAsst{ObjectAsstID: "switch_connect_host", InstID: 1, AsstInstID: 2}
+
+	tests := []struct {
+		name string
+		asst metadata.Association
+		want []string
+	}{
+		{"one to one locks both sides", metadata.Association{Mapping: metadata.OneToOneMapping}, []string{
+			"cc:v3:lock:asstinstlimit:switch_connect_host:src:1",
+			"cc:v3:lock:asstinstlimit:switch_connect_host:dest:2",
+		}},
+		{"one to many locks the destination", metadata.Association{Mapping: metadata.OneToManyMapping}, []string{
+			"cc:v3:lock:asstinstlimit:switch_connect_host:dest:2",
+		}},
+		{"many to many without limit locks nothing", metadata.Association{Mapping: metadata.ManyToManyMapping}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asst := tt.asst
+			if got := instanceLimitLockKeys(&asst, item); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("instanceLimitLockKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// maxCascadeDeleteInstances the max number of instances can be deleted together by cascade,
+// in case of deleting a large amount of instances by a mistake.
+const maxCascadeDeleteInstances = 1000
+
+type instRef struct {
+	objID  string
+	instID int64
+}
+
+// restriction an association which may prevent the instance from being deleted,
+// unless the instance on the other side is deleted together.
+type restriction struct {
+	asst  metadata.InstAsst
+	other instRef
+}
+
+// PlanInstanceAssociationDelete work out the instances and the associations affected by deleting the instances,
+// it only reads the data, the caller is responsible to delete them.
+//
+// for each instance association of the instances to be deleted:
+//   - the instance is the source, the destination instance is deleted with it if the on delete action is
+//     delete_dest, otherwise the association is unlinked.
+//   - the instance is the destination, the source instance is deleted with it if the on delete action is
+//     delete_src, the association is unlinked if the action is unlink, otherwise the association restricts
+//     the instance from being deleted.
+//
+// inner model instances such as hosts and modules have their own delete flow, so they are never deleted by
+// cascade, an association which would cascade delete them restricts the deletion instead.
+func (m *associationInstance) PlanInstanceAssociationDelete(ctx core.ContextParams, inputParam metadata.InstAsstDeletePlanRequest) (*metadata.InstAsstDeletePlan, error) {
+	if 0 == len(inputParam.ObjectID) {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)
+	}
+
+	plan := &metadata.InstAsstDeletePlan{
+		Instances:  make([]metadata.InstAsstDeleteRef, 0),
+		Restricted: make([]metadata.InstAsst, 0),
+		Unlinked:   make([]metadata.InstAsst, 0),
+	}
+
+	deleted := make(map[instRef]bool)
+	queue := make([]metadata.InstAsstDeleteRef, 0)
+	for _, instID := range inputParam.InstIDs {
+		ref := instRef{objID: inputParam.ObjectID, instID: instID}
+		if deleted[ref] {
+			continue
+		}
+		deleted[ref] = true
+		item := metadata.InstAsstDeleteRef{ObjectID: inputParam.ObjectID, InstID: instID}
+		plan.Instances = append(plan.Instances, item)
+		queue = append(queue, item)
+	}
+
+	onDeleteActions := make(map[string]metadata.AssociationOnDeleteAction)
+	unlinked := make(map[int64]metadata.InstAsst)
+	restrictions := make([]restriction, 0)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		assts, err := m.searchInstAssociationsOf(ctx, current.ObjectID, current.InstID)
+		if nil != err {
+			return nil, err
+		}
+
+		for _, asst := range assts {
+			if _, exists := unlinked[asst.ID]; exists {
+				continue
+			}
+			unlinked[asst.ID] = asst
+
+			action, err := m.getOnDeleteAction(ctx, onDeleteActions, asst.ObjectAsstID)
+			if nil != err {
+				return nil, err
+			}
+
+			other, cascade, restrict := decideOnDelete(instRef{objID: current.ObjectID, instID: current.InstID}, asst, action)
+			if restrict {
+				restrictions = append(restrictions, restriction{asst: asst, other: other})
+			}
+
+			if !cascade || deleted[other] {
+				continue
+			}
+			deleted[other] = true
+			item := metadata.InstAsstDeleteRef{ObjectID: other.objID, InstID: other.instID, ObjectAsstID: asst.ObjectAsstID}
+			plan.Instances = append(plan.Instances, item)
+			queue = append(queue, item)
+			if len(plan.Instances) > maxCascadeDeleteInstances {
+				blog.Errorf("request(%s): plan instance association delete failed, too many instances are deleted by cascade, input: %#v", ctx.ReqID, inputParam)
+				return nil, ctx.Error.Errorf(common.CCErrCommXXExceedLimit, "cascade delete instances", maxCascadeDeleteInstances)
+			}
+		}
+	}
+
+	// the restriction is lifted if the instance on the other side is deleted together,
+	// or it does not exist any more, which is a dangling association and is just unlinked.
+	for _, item := range restrictions {
+		if deleted[item.other] {
+			continue
+		}
+		exists, err := m.dependent.IsInstanceExist(ctx, item.other.objID, uint64(item.other.instID))
+		if nil != err {
+			blog.Errorf("request(%s): check instance %s %d exists failed, err: %s", ctx.ReqID, item.other.objID, item.other.instID, err.Error())
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		plan.Restricted = append(plan.Restricted, item.asst)
+	}
+
+	for _, asst := range unlinked {
+		plan.Unlinked = append(plan.Unlinked, asst)
+	}
+	sort.Slice(plan.Unlinked, func(i, j int) bool { return plan.Unlinked[i].ID < plan.Unlinked[j].ID })
+
+	return plan, nil
+}
+
+// decideOnDelete decide how the association is handled when the current instance is deleted, it returns the
+// instance on the other side, whether that instance is deleted by cascade, and whether the association restricts
+// the current instance from being deleted. the association is unlinked if neither.
+func decideOnDelete(current instRef, asst metadata.InstAsst, action metadata.AssociationOnDeleteAction) (other instRef, cascade, restrict bool) {
+	if asst.ObjectID == current.objID && asst.InstID == current.instID {
+		other = instRef{objID: asst.AsstObjectID, instID: asst.AsstInstID}
+		cascade = action == metadata.DeleteDestinatioin
+	} else {
+		other = instRef{objID: asst.ObjectID, instID: asst.InstID}
+		cascade = action == metadata.DeleteSource
+		restrict = action == metadata.NoAction || len(action) == 0
+	}
+
+	if cascade && common.IsInnerModel(other.objID) {
+		cascade, restrict = false, true
+	}
+	return other, cascade, restrict
+}
+
+// searchInstAssociationsOf search all the instance associations of which the instance is the source or the destination.
+func (m *associationInstance) searchInstAssociationsOf(ctx core.ContextParams, objID string, instID int64) ([]metadata.InstAsst, error) {
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKObjIDField: objID, common.BKInstIDField: instID},
+			{common.BKAsstObjIDField: objID, common.BKAsstInstIDField: instID},
+		},
+	}
+
+	results := make([]metadata.InstAsst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(cond).All(ctx, &results); nil != err {
+		blog.Errorf("request(%s): search the instance associations of %s %d failed, err: %s", ctx.ReqID, objID, instID, err.Error())
+		return nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	return results, nil
+}
+
+// getOnDeleteAction get the on delete action of the object association, the result is cached in the actions.
+func (m *associationInstance) getOnDeleteAction(ctx core.ContextParams, actions map[string]metadata.AssociationOnDeleteAction, objAsstID string) (metadata.AssociationOnDeleteAction, error) {
+	if action, exists := actions[objAsstID]; exists {
+		return action, nil
+	}
+
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: metadata.AssociationFieldAsstID, Val: objAsstID})
+	cond.Element(&mongo.In{Key: common.BKOwnerIDField, Val: []string{ctx.SupplierAccount, common.BKDefaultOwnerID}})
+	assts, err := m.associationModel.search(ctx, cond)
+	if nil != err {
+		return "", ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	// the object association may be deleted already, then its instance associations are just unlinked.
+	action := metadata.Unlink
+	if len(assts) > 0 {
+		action = assts[0].OnDelete
+	}
+	actions[objAsstID] = action
+	return action, nil
+}
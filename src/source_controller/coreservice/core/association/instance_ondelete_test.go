@@ -0,0 +1,64 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestDecideOnDelete(t *testing.T) {
+	current := instRef{objID: "switch", instID: 1}
+	asSource := metadata.InstAsst{ObjectID: "switch", InstID: 1, AsstObjectID: "router", AsstInstID: 2}
+	asDestination := metadata.InstAsst{ObjectID: "router", InstID: 2, AsstObjectID: "switch", AsstInstID: 1}
+	toHost := metadata.InstAsst{ObjectID: "switch", InstID: 1, AsstObjectID: common.BKInnerObjIDHost, AsstInstID: 3}
+	fromHost := metadata.InstAsst{ObjectID: common.BKInnerObjIDHost, InstID: 3, AsstObjectID: "switch", AsstInstID: 1}
+
+	tests := []struct {
+		name         string
+		asst         metadata.InstAsst
+		action       metadata.AssociationOnDeleteAction
+		wantOther    instRef
+		wantCascade  bool
+		wantRestrict bool
+	}{
+		{"source with no action", asSource, metadata.NoAction, instRef{"router", 2}, false, false},
+		{"source with delete destination", asSource, metadata.DeleteDestinatioin, instRef{"router", 2}, true, false},
+		{"source with delete source", asSource, metadata.DeleteSource, instRef{"router", 2}, false, false},
+		{"source with unlink", asSource, metadata.Unlink, instRef{"router", 2}, false, false},
+		{"destination with no action", asDestination, metadata.NoAction, instRef{"router", 2}, false, true},
+		{"destination with empty action", asDestination, "", instRef{"router", 2}, false, true},
+		{"destination with delete source", asDestination, metadata.DeleteSource, instRef{"router", 2}, true, false},
+		{"destination with delete destination", asDestination, metadata.DeleteDestinatioin, instRef{"router", 2}, false, false},
+		{"destination with unlink", asDestination, metadata.Unlink, instRef{"router", 2}, false, false},
+		{"inner model is never deleted by cascade", toHost, metadata.DeleteDestinatioin, instRef{common.BKInnerObjIDHost, 3}, false, true},
+		{"inner model source is never deleted by cascade", fromHost, metadata.DeleteSource, instRef{common.BKInnerObjIDHost, 3}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other, cascade, restrict := decideOnDelete(current, tt.asst, tt.action)
+			if other != tt.wantOther {
+				t.Errorf("decideOnDelete() other = %v, want %v", other, tt.wantOther)
+			}
+			if cascade != tt.wantCascade {
+				t.Errorf("decideOnDelete() cascade = %v, want %v", cascade, tt.wantCascade)
+			}
+			if restrict != tt.wantRestrict {
+				t.Errorf("decideOnDelete() restrict = %v, want %v", restrict, tt.wantRestrict)
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ package association_test
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -24,10 +25,7 @@ import (
 	"configcenter/src/source_controller/coreservice/core/association"
 	"configcenter/src/source_controller/coreservice/core/instances"
 	"configcenter/src/source_controller/coreservice/core/model"
-	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
-
-	"github.com/stretchr/testify/require"
 )
 
 type instDependences struct {
@@ -44,7 +42,7 @@ func (s *instDependences) DeleteInstAsst(ctx core.ContextParams, objID string, i
 }
 
 // SelectObjectAttWithParams select object att with params
-func (s *instDependences) SelectObjectAttWithParams(ctx core.ContextParams, objID string) (attribute []metadata.Attribute, err error) {
+func (s *instDependences) SelectObjectAttWithParams(ctx core.ContextParams, objID string, bizID int64) (attribute []metadata.Attribute, err error) {
 	return nil, nil
 }
 
@@ -79,29 +77,37 @@ func (m *mockDependences) IsInstanceExist(ctx core.ContextParams, objID string,
 	return false, nil
 }
 
-func newModel(t *testing.T) core.ModelOperation {
+var (
+	testDBOnce sync.Once
+	testDB     *local.Mongo
+	testDBErr  error
+)
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
-	return model.New(db, &mockDependences{})
+// newTestDB dials the test mongodb once, and skips the test when it can not be reached
+func newTestDB(t *testing.T) *local.Mongo {
+	testDBOnce.Do(func() {
+		testDB, testDBErr = local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
+	})
+	if testDBErr != nil {
+		t.Skipf("mongodb is not available, %v", testDBErr)
+	}
+	return testDB
 }
 
-func newAssociation(t *testing.T) core.AssociationOperation {
+func newModel(t *testing.T) core.ModelOperation {
+	return model.New(newTestDB(t), &mockDependences{})
+}
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
-	return association.New(db, &mockDependences{})
+func newAssociation(t *testing.T) core.AssociationOperation {
+	return association.New(newTestDB(t), &mockDependences{}, nil)
 }
 
 func newInstances(t *testing.T) core.InstanceOperation {
-
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
-	return instances.New(db, &instDependences{})
+	return instances.New(newTestDB(t), &instDependences{}, nil)
 }
 
 var defaultCtx = func() core.ContextParams {
-	err, _ := errors.New("../../../../../resources/errors/")
+	err, _ := errors.NewFactory("../../../../../resources/errors/")
 	lan, _ := language.New("../../../../../resources/language/")
 	return core.ContextParams{
 		Context:         context.Background(),
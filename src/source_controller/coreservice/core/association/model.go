@@ -85,6 +85,10 @@ func (m *associationModel) createModelAssociation(ctx core.ContextParams, inputP
 		}
 	}
 
+	if len(inputParam.Spec.OnDelete) == 0 {
+		inputParam.Spec.OnDelete = metadata.NoAction
+	}
+
 	id, err := m.save(ctx, &inputParam.Spec)
 	if nil != err {
 		blog.Errorf("request(%s): it is failed to create a new association (%s=>%s), error info is %s", ctx.ReqID, inputParam.Spec.ObjectID, inputParam.Spec.AsstObjID, err.Error())
@@ -116,7 +120,8 @@ func (m *associationModel) UpdateModelAssociation(ctx core.ContextParams, inputP
 
 	// only field in white list could be update
 	// bk_asst_obj_id is allowed for add business model level
-	validFields := []string{"bk_obj_asst_name", "bk_asst_obj_id"}
+	validFields := []string{"bk_obj_asst_name", "bk_asst_obj_id", metadata.AssociationFieldOnDelete,
+		metadata.AssociationFieldMaxDestCount, metadata.AssociationFieldMaxSrcCount}
 	validData := map[string]interface{}{}
 	filterOutFields := []string{}
 	for key, val := range inputParam.Data {
@@ -131,6 +136,29 @@ func (m *associationModel) UpdateModelAssociation(ctx core.ContextParams, inputP
 		blog.Warnf("update object association got invalid fields: %v", filterOutFields)
 	}
 
+	if onDelete, exists := validData[metadata.AssociationFieldOnDelete]; exists {
+		action, ok := onDelete.(string)
+		if !ok || !metadata.AssociationOnDeleteAction(action).IsValid() {
+			blog.Errorf("request(%s): update object association failed, the on delete action (%v) is invalid", ctx.ReqID, onDelete)
+			return &metadata.UpdatedCount{}, ctx.Error.Errorf(common.CCErrorTopoAssociationOnDeleteInvalid, onDelete)
+		}
+	}
+
+	// the existing instance associations which exceed the new max count are not removed,
+	// they can be found by the association violations api.
+	for _, field := range []string{metadata.AssociationFieldMaxDestCount, metadata.AssociationFieldMaxSrcCount} {
+		val, exists := validData[field]
+		if !exists {
+			continue
+		}
+		cnt, err := util.GetInt64ByInterface(val)
+		if nil != err || cnt < 0 {
+			blog.Errorf("request(%s): update object association failed, the %s (%v) is invalid", ctx.ReqID, field, val)
+			return &metadata.UpdatedCount{}, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, field)
+		}
+		validData[field] = cnt
+	}
+
 	cnt, err := m.update(ctx, validData, updateCond)
 	if nil != err {
 		blog.Errorf("request(%s): it is to update the association by the condition (%#v), error info is %s", ctx.ReqID, updateCond.ToMapStr(), err.Error())
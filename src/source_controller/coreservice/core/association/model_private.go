@@ -42,6 +42,21 @@ func (m *associationModel) isValid(ctx core.ContextParams, inputParam metadata.C
 		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, metadata.AssociationFieldAssociationObjectID)
 	}
 
+	if !inputParam.Spec.OnDelete.IsValid() {
+		blog.Errorf("request(%s): it is failed to create a new model association, because of the on delete action (%s) is invalid", ctx.ReqID, inputParam.Spec.OnDelete)
+		return ctx.Error.Errorf(common.CCErrorTopoAssociationOnDeleteInvalid, inputParam.Spec.OnDelete)
+	}
+
+	if inputParam.Spec.MaxDestCount < 0 {
+		blog.Errorf("request(%s): it is failed to create a new model association, because of the %s (%d) is negative", ctx.ReqID, metadata.AssociationFieldMaxDestCount, inputParam.Spec.MaxDestCount)
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AssociationFieldMaxDestCount)
+	}
+
+	if inputParam.Spec.MaxSrcCount < 0 {
+		blog.Errorf("request(%s): it is failed to create a new model association, because of the %s (%d) is negative", ctx.ReqID, metadata.AssociationFieldMaxSrcCount, inputParam.Spec.MaxSrcCount)
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AssociationFieldMaxSrcCount)
+	}
+
 	return nil
 }
 
@@ -115,6 +115,8 @@ type InstanceAssociation interface {
 	CreateManyInstanceAssociation(ctx ContextParams, inputParam metadata.CreateManyInstanceAssociation) (*metadata.CreateManyDataResult, error)
 	SearchInstanceAssociation(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
 	DeleteInstanceAssociation(ctx ContextParams, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	PlanInstanceAssociationDelete(ctx ContextParams, inputParam metadata.InstAsstDeletePlanRequest) (*metadata.InstAsstDeletePlan, error)
+	FindInstanceAssociationViolations(ctx ContextParams, inputParam metadata.FindAssociationViolationsRequest) ([]metadata.AssociationViolation, error)
 }
 
 // DataSynchronize manager data synchronize interface
@@ -153,6 +155,35 @@ type AuditOperation interface {
 	SearchAuditLog(ctx ContextParams, param metadata.QueryInput) ([]metadata.OperationLog, uint64, error)
 }
 
+// RecycleBinOperation recycle bin methods
+type RecycleBinOperation interface {
+	SnapshotRecycleRecords(ctx ContextParams, objID string, inputParam metadata.CreateRecycleRecords) (*metadata.SnapshotRecycleRecordsResult, error)
+	CreateRecycleRecords(ctx ContextParams, objID string, inputParam metadata.SaveRecycleRecords) (*metadata.CreateManyDataResult, error)
+	SearchRecycleRecords(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryRecycleRecordsResult, error)
+	RestoreRecycleRecords(ctx ContextParams, inputParam metadata.RestoreRecycleRecords) (*metadata.RestoreRecycleRecordsResult, error)
+	PurgeRecycleRecords(ctx ContextParams, inputParam metadata.PurgeRecycleRecords) (*metadata.DeletedCount, error)
+}
+
+// APITokenOperation api token methods
+type APITokenOperation interface {
+	CreateAPIToken(ctx ContextParams, inputParam metadata.CreateAPITokenRequest) (*metadata.CreatedAPIToken, error)
+	SearchAPITokens(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryAPITokensResult, error)
+	RevokeAPITokens(ctx ContextParams, inputParam metadata.RevokeAPITokenRequest) (*metadata.UpdatedCount, error)
+	ValidateAPIToken(ctx ContextParams, inputParam metadata.ValidateAPITokenRequest) (*metadata.APIToken, error)
+}
+
+// LocalAuthOperation the roles and role bindings of the local authorization
+type LocalAuthOperation interface {
+	CreateAuthRole(ctx ContextParams, inputParam metadata.AuthRole) (*metadata.CreateOneDataResult, error)
+	UpdateAuthRole(ctx ContextParams, id int64, inputParam metadata.AuthRole) (*metadata.UpdatedCount, error)
+	DeleteAuthRole(ctx ContextParams, id int64) (*metadata.DeletedCount, error)
+	SearchAuthRoles(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryAuthRolesResult, error)
+	CreateAuthRoleBinding(ctx ContextParams, inputParam metadata.AuthRoleBinding) (*metadata.CreateOneDataResult, error)
+	DeleteAuthRoleBinding(ctx ContextParams, id int64) (*metadata.DeletedCount, error)
+	SearchAuthRoleBindings(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryAuthRoleBindingsResult, error)
+	GetUserAuthPermissions(ctx ContextParams, user string) ([]metadata.AuthPermission, error)
+}
+
 // Core core itnerfaces methods
 type Core interface {
 	ModelOperation() ModelOperation
@@ -162,6 +193,9 @@ type Core interface {
 	DataSynchronizeOperation() DataSynchronizeOperation
 	HostOperation() HostOperation
 	AuditOperation() AuditOperation
+	RecycleBinOperation() RecycleBinOperation
+	APITokenOperation() APITokenOperation
+	LocalAuthOperation() LocalAuthOperation
 }
 
 type core struct {
@@ -172,10 +206,13 @@ type core struct {
 	topo            TopoOperation
 	host            HostOperation
 	audit           AuditOperation
+	recycleBin      RecycleBinOperation
+	apiToken        APITokenOperation
+	localAuth       LocalAuthOperation
 }
 
 // New create core
-func New(model ModelOperation, instance InstanceOperation, association AssociationOperation, dataSynchronize DataSynchronizeOperation, topo TopoOperation, host HostOperation, audit AuditOperation) Core {
+func New(model ModelOperation, instance InstanceOperation, association AssociationOperation, dataSynchronize DataSynchronizeOperation, topo TopoOperation, host HostOperation, audit AuditOperation, recycleBin RecycleBinOperation, apiToken APITokenOperation, localAuth LocalAuthOperation) Core {
 	return &core{
 		model:           model,
 		instance:        instance,
@@ -184,6 +221,9 @@ func New(model ModelOperation, instance InstanceOperation, association Associati
 		topo:            topo,
 		host:            host,
 		audit:           audit,
+		recycleBin:      recycleBin,
+		apiToken:        apiToken,
+		localAuth:       localAuth,
 	}
 }
 
@@ -214,3 +254,15 @@ func (m *core) HostOperation() HostOperation {
 func (m *core) AuditOperation() AuditOperation {
 	return m.audit
 }
+
+func (m *core) RecycleBinOperation() RecycleBinOperation {
+	return m.recycleBin
+}
+
+func (m *core) APITokenOperation() APITokenOperation {
+	return m.apiToken
+}
+
+func (m *core) LocalAuthOperation() LocalAuthOperation {
+	return m.localAuth
+}
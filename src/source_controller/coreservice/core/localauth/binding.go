@@ -0,0 +1,152 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package localauth
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// CreateAuthRoleBinding bind a role to the users
+func (m *localAuthManager) CreateAuthRoleBinding(ctx core.ContextParams, inputParam metadata.AuthRoleBinding) (*metadata.CreateOneDataResult, error) {
+	if len(inputParam.Users) == 0 {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, metadata.AuthRoleBindingFieldUsers)
+	}
+	if inputParam.BizID < 0 {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField)
+	}
+
+	roleCond := mongo.NewCondition()
+	roleCond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	roleCond.Element(&mongo.Eq{Key: common.BKFieldID, Val: inputParam.RoleID})
+	count, err := m.dbProxy.Table(common.BKTableNameAuthRole).Find(roleCond.ToMapStr()).Count(ctx)
+	if err != nil {
+		blog.Errorf("CreateAuthRoleBinding failed, count role failed, err: %+v, cond: %+v, rid: %s", err, roleCond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AuthRoleBindingFieldRoleID)
+	}
+
+	id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameAuthRoleBinding)
+	if err != nil {
+		blog.Errorf("CreateAuthRoleBinding failed, generate role binding id failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	binding := metadata.AuthRoleBinding{
+		ID:         int64(id),
+		OwnerID:    ctx.SupplierAccount,
+		RoleID:     inputParam.RoleID,
+		Users:      inputParam.Users,
+		BizID:      inputParam.BizID,
+		InstIDs:    inputParam.InstIDs,
+		Creator:    ctx.User,
+		CreateTime: time.Now(),
+	}
+	if binding.InstIDs == nil {
+		binding.InstIDs = make([]int64, 0)
+	}
+	if err := m.dbProxy.Table(common.BKTableNameAuthRoleBinding).Insert(ctx, binding); err != nil {
+		blog.Errorf("CreateAuthRoleBinding failed, insert role binding failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, nil
+}
+
+// DeleteAuthRoleBinding delete a role binding
+func (m *localAuthManager) DeleteAuthRoleBinding(ctx core.ContextParams, id int64) (*metadata.DeletedCount, error) {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	cond.Element(&mongo.Eq{Key: common.BKFieldID, Val: id})
+	count, err := m.dbProxy.Table(common.BKTableNameAuthRoleBinding).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		blog.Errorf("DeleteAuthRoleBinding failed, count role binding failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if err := m.dbProxy.Table(common.BKTableNameAuthRoleBinding).Delete(ctx, cond.ToMapStr()); err != nil {
+		blog.Errorf("DeleteAuthRoleBinding failed, delete role binding failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return &metadata.DeletedCount{Count: count}, nil
+}
+
+// SearchAuthRoleBindings search the role bindings of current supplier account
+func (m *localAuthManager) SearchAuthRoleBindings(ctx core.ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryAuthRoleBindingsResult, error) {
+	cond := util.SetQueryOwner(inputParam.Condition, ctx.SupplierAccount)
+	bindings := make([]metadata.AuthRoleBinding, 0)
+	query := m.dbProxy.Table(common.BKTableNameAuthRoleBinding).Find(cond).Sort(common.BKFieldID)
+	err := query.Start(uint64(inputParam.Limit.Offset)).Limit(uint64(inputParam.Limit.Limit)).Fields(inputParam.Fields...).All(ctx, &bindings)
+	if err != nil {
+		blog.Errorf("SearchAuthRoleBindings failed, search role bindings failed, err: %+v, cond: %+v, rid: %s", err, cond, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	count, err := m.dbProxy.Table(common.BKTableNameAuthRoleBinding).Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("SearchAuthRoleBindings failed, count role bindings failed, err: %+v, cond: %+v, rid: %s", err, cond, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return &metadata.QueryAuthRoleBindingsResult{Count: count, Info: bindings}, nil
+}
+
+// GetUserAuthPermissions get the rules of all the roles bound to the user, with the scope of the bindings.
+func (m *localAuthManager) GetUserAuthPermissions(ctx core.ContextParams, user string) ([]metadata.AuthPermission, error) {
+	permissions := make([]metadata.AuthPermission, 0)
+	if user == "" {
+		return permissions, nil
+	}
+
+	bindingCond := mongo.NewCondition()
+	bindingCond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	bindingCond.Element(&mongo.Eq{Key: metadata.AuthRoleBindingFieldUsers, Val: user})
+	bindings := make([]metadata.AuthRoleBinding, 0)
+	if err := m.dbProxy.Table(common.BKTableNameAuthRoleBinding).Find(bindingCond.ToMapStr()).All(ctx, &bindings); err != nil {
+		blog.Errorf("GetUserAuthPermissions failed, search role bindings failed, err: %+v, cond: %+v, rid: %s", err, bindingCond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(bindings) == 0 {
+		return permissions, nil
+	}
+
+	roleIDs := make([]int64, 0)
+	for _, binding := range bindings {
+		roleIDs = append(roleIDs, binding.RoleID)
+	}
+	roleCond := mongo.NewCondition()
+	roleCond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	roleCond.Element(&mongo.In{Key: common.BKFieldID, Val: roleIDs})
+	roles := make([]metadata.AuthRole, 0)
+	if err := m.dbProxy.Table(common.BKTableNameAuthRole).Find(roleCond.ToMapStr()).All(ctx, &roles); err != nil {
+		blog.Errorf("GetUserAuthPermissions failed, search roles failed, err: %+v, cond: %+v, rid: %s", err, roleCond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	roleMap := make(map[int64]metadata.AuthRole)
+	for _, role := range roles {
+		roleMap[role.ID] = role
+	}
+
+	for _, binding := range bindings {
+		role, ok := roleMap[binding.RoleID]
+		if !ok {
+			blog.Warnf("GetUserAuthPermissions, the role %d of binding %d does not exist, rid: %s", binding.RoleID, binding.ID, ctx.ReqID)
+			continue
+		}
+		permissions = append(permissions, metadata.AuthPermission{Binding: binding, Rules: role.Rules})
+	}
+	return permissions, nil
+}
@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package localauth
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+var _ core.LocalAuthOperation = (*localAuthManager)(nil)
+
+type localAuthManager struct {
+	dbProxy dal.RDB
+}
+
+// New create a new local authorization manager instance
+func New(dbProxy dal.RDB) core.LocalAuthOperation {
+	return &localAuthManager{
+		dbProxy: dbProxy,
+	}
+}
+
+// CreateAuthRole create a role, the role name is unique in a supplier account.
+func (m *localAuthManager) CreateAuthRole(ctx core.ContextParams, inputParam metadata.AuthRole) (*metadata.CreateOneDataResult, error) {
+	if err := m.validateRole(ctx, 0, inputParam); err != nil {
+		return nil, err
+	}
+
+	id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameAuthRole)
+	if err != nil {
+		blog.Errorf("CreateAuthRole failed, generate role id failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	now := time.Now()
+	role := metadata.AuthRole{
+		ID:          int64(id),
+		OwnerID:     ctx.SupplierAccount,
+		Name:        inputParam.Name,
+		Description: inputParam.Description,
+		Rules:       inputParam.Rules,
+		Creator:     ctx.User,
+		CreateTime:  now,
+		LastTime:    now,
+	}
+	if err := m.dbProxy.Table(common.BKTableNameAuthRole).Insert(ctx, role); err != nil {
+		blog.Errorf("CreateAuthRole failed, insert role failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, nil
+}
+
+// UpdateAuthRole update the name, description and rules of a role
+func (m *localAuthManager) UpdateAuthRole(ctx core.ContextParams, id int64, inputParam metadata.AuthRole) (*metadata.UpdatedCount, error) {
+	if err := m.validateRole(ctx, id, inputParam); err != nil {
+		return nil, err
+	}
+
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	cond.Element(&mongo.Eq{Key: common.BKFieldID, Val: id})
+	count, err := m.dbProxy.Table(common.BKTableNameAuthRole).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		blog.Errorf("UpdateAuthRole failed, count role failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		return nil, ctx.Error.Error(common.CCErrCommNotFound)
+	}
+
+	doc := mapstr.MapStr{
+		metadata.AuthRoleFieldName:        inputParam.Name,
+		metadata.AuthRoleFieldDescription: inputParam.Description,
+		metadata.AuthRoleFieldRules:       inputParam.Rules,
+		common.LastTimeField:              time.Now(),
+	}
+	if err := m.dbProxy.Table(common.BKTableNameAuthRole).Update(ctx, cond.ToMapStr(), doc); err != nil {
+		blog.Errorf("UpdateAuthRole failed, update role failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return &metadata.UpdatedCount{Count: count}, nil
+}
+
+// DeleteAuthRole delete a role with all its bindings
+func (m *localAuthManager) DeleteAuthRole(ctx core.ContextParams, id int64) (*metadata.DeletedCount, error) {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	cond.Element(&mongo.Eq{Key: common.BKFieldID, Val: id})
+	count, err := m.dbProxy.Table(common.BKTableNameAuthRole).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		blog.Errorf("DeleteAuthRole failed, count role failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		return &metadata.DeletedCount{Count: 0}, nil
+	}
+
+	bindingCond := mongo.NewCondition()
+	bindingCond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	bindingCond.Element(&mongo.Eq{Key: metadata.AuthRoleBindingFieldRoleID, Val: id})
+	if err := m.dbProxy.Table(common.BKTableNameAuthRoleBinding).Delete(ctx, bindingCond.ToMapStr()); err != nil {
+		blog.Errorf("DeleteAuthRole failed, delete role bindings failed, err: %+v, cond: %+v, rid: %s", err, bindingCond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	if err := m.dbProxy.Table(common.BKTableNameAuthRole).Delete(ctx, cond.ToMapStr()); err != nil {
+		blog.Errorf("DeleteAuthRole failed, delete role failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return &metadata.DeletedCount{Count: count}, nil
+}
+
+// SearchAuthRoles search the roles of current supplier account
+func (m *localAuthManager) SearchAuthRoles(ctx core.ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryAuthRolesResult, error) {
+	cond := util.SetQueryOwner(inputParam.Condition, ctx.SupplierAccount)
+	roles := make([]metadata.AuthRole, 0)
+	query := m.dbProxy.Table(common.BKTableNameAuthRole).Find(cond).Sort(common.BKFieldID)
+	err := query.Start(uint64(inputParam.Limit.Offset)).Limit(uint64(inputParam.Limit.Limit)).Fields(inputParam.Fields...).All(ctx, &roles)
+	if err != nil {
+		blog.Errorf("SearchAuthRoles failed, search roles failed, err: %+v, cond: %+v, rid: %s", err, cond, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	count, err := m.dbProxy.Table(common.BKTableNameAuthRole).Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("SearchAuthRoles failed, count roles failed, err: %+v, cond: %+v, rid: %s", err, cond, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return &metadata.QueryAuthRolesResult{Count: count, Info: roles}, nil
+}
+
+// validateRole validate the role, id is the role to be updated, which is 0 for a new role.
+func (m *localAuthManager) validateRole(ctx core.ContextParams, id int64, role metadata.AuthRole) error {
+	if role.Name == "" {
+		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, metadata.AuthRoleFieldName)
+	}
+	if len(role.Rules) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, metadata.AuthRoleFieldRules)
+	}
+	for _, rule := range role.Rules {
+		if len(rule.ResourceTypes) == 0 || len(rule.Actions) == 0 {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AuthRoleFieldRules)
+		}
+	}
+
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	cond.Element(&mongo.Eq{Key: metadata.AuthRoleFieldName, Val: role.Name})
+	cond.Element(&mongo.Neq{Key: common.BKFieldID, Val: id})
+	count, err := m.dbProxy.Table(common.BKTableNameAuthRole).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		blog.Errorf("validate role failed, count role by name failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if count > 0 {
+		return ctx.Error.Errorf(common.CCErrCommDuplicateItem, metadata.AuthRoleFieldName)
+	}
+	return nil
+}
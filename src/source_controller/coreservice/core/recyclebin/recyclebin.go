@@ -0,0 +1,337 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recyclebin
+
+import (
+	"context"
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/instances"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	// purgeInterval the interval of purging the expired recycle records
+	purgeInterval = time.Hour
+	// restoreLockExpire the expiration of the lock on the instance being restored
+	restoreLockExpire = 30 * time.Second
+)
+
+// unlockRestoreScript delete the lock only if it's still held by the value, so that
+// a lock expired and taken by others is never released by the previous holder
+var unlockRestoreScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+var _ core.RecycleBinOperation = (*recycleBinManager)(nil)
+
+type recycleBinManager struct {
+	dbProxy   dal.RDB
+	dependent OperationDependences
+	cache     *redis.Client
+	retention time.Duration
+	EventC    eventclient.Client
+}
+
+// New create a new recycle bin manager instance, the expired records will be purged in background.
+func New(dbProxy dal.RDB, dependent OperationDependences, cache *redis.Client, retentionDays int) core.RecycleBinOperation {
+	if retentionDays <= 0 {
+		retentionDays = metadata.RecycleBinDefaultRetentionDays
+	}
+	m := &recycleBinManager{
+		dbProxy:   dbProxy,
+		dependent: dependent,
+		cache:     cache,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		EventC:    eventclient.NewClientViaRedis(cache, dbProxy),
+	}
+	go m.loopPurgeExpired()
+	return m
+}
+
+// SnapshotRecycleRecords take a copy of the instances, with their associations and host module relations,
+// which is saved into recycle bin by CreateRecycleRecords before the instances is deleted.
+// it should be called before the instances is deleted.
+func (m *recycleBinManager) SnapshotRecycleRecords(ctx core.ContextParams, objID string, inputParam metadata.CreateRecycleRecords) (*metadata.SnapshotRecycleRecordsResult, error) {
+	dataResult := &metadata.SnapshotRecycleRecordsResult{
+		Info:       make([]metadata.RecycleRecord, 0),
+		Exceptions: make([]metadata.ExceptionResult, 0),
+	}
+	if len(inputParam.InstIDs) == 0 {
+		return dataResult, nil
+	}
+
+	tableName := common.GetInstTableName(objID)
+	instIDField := common.GetInstIDField(objID)
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.In{Key: instIDField, Val: inputParam.InstIDs})
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	if tableName == common.BKTableNameBaseInst {
+		cond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: objID})
+	}
+	insts := make([]mapstr.MapStr, 0)
+	if err := m.dbProxy.Table(tableName).Find(cond.ToMapStr()).All(ctx, &insts); err != nil {
+		blog.Errorf("SnapshotRecycleRecords failed, search %s instances failed, err: %+v, cond: %+v, rid: %s", objID, err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	instMap := make(map[int64]mapstr.MapStr)
+	for _, inst := range insts {
+		instID, err := util.GetInt64ByInterface(inst[instIDField])
+		if err != nil {
+			blog.ErrorJSON("SnapshotRecycleRecords failed, parse %s field of %s instance failed, err: %s, inst: %s, rid: %s", instIDField, objID, err, inst, ctx.ReqID)
+			return nil, ctx.Error.Errorf(common.CCErrCommInstFieldConvFail, objID, instIDField, "integer", err.Error())
+		}
+		inst.Remove("_id")
+		instMap[instID] = inst
+	}
+
+	for idx, instID := range inputParam.InstIDs {
+		inst, ok := instMap[instID]
+		if !ok {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     ctx.Error.Error(common.CCErrCommNotFound).Error(),
+				Code:        common.CCErrCommNotFound,
+				Data:        instID,
+				OriginIndex: int64(idx),
+			})
+			continue
+		}
+
+		record := metadata.RecycleRecord{
+			OwnerID:  ctx.SupplierAccount,
+			ObjectID: objID,
+			InstID:   instID,
+			Data:     inst,
+		}
+		bizID, err := instances.FetchBizIDFromInstance(objID, inst)
+		if err != nil {
+			blog.Warnf("SnapshotRecycleRecords, get business id of %s instance %d failed, err: %+v, rid: %s", objID, instID, err, ctx.ReqID)
+		}
+		record.BizID = bizID
+
+		record.Associations, err = m.searchInstAssociations(ctx, objID, instID)
+		if err != nil {
+			return nil, err
+		}
+		if objID == common.BKInnerObjIDHost {
+			record.ModuleHosts, err = m.searchModuleHosts(ctx, instID)
+			if err != nil {
+				return nil, err
+			}
+			if len(record.ModuleHosts) > 0 {
+				record.BizID, _ = util.GetInt64ByInterface(record.ModuleHosts[0][common.BKAppIDField])
+			}
+		}
+		dataResult.Info = append(dataResult.Info, record)
+	}
+	return dataResult, nil
+}
+
+// CreateRecycleRecords save the snapshots of the deleted instances into recycle bin,
+// it should be called before the instances are deleted, and the records are purged if the deletion fails.
+func (m *recycleBinManager) CreateRecycleRecords(ctx core.ContextParams, objID string, inputParam metadata.SaveRecycleRecords) (*metadata.CreateManyDataResult, error) {
+	dataResult := &metadata.CreateManyDataResult{}
+	if len(inputParam.Records) == 0 {
+		return dataResult, nil
+	}
+
+	now := time.Now()
+	records := make([]interface{}, 0)
+	for idx, record := range inputParam.Records {
+		if record.ObjectID != objID || record.InstID <= 0 || len(record.Data) == 0 {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "records").Error(),
+				Code:        common.CCErrCommParamsIsInvalid,
+				Data:        record.InstID,
+				OriginIndex: int64(idx),
+			})
+			continue
+		}
+
+		id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameRecycleBin)
+		if err != nil {
+			blog.Errorf("CreateRecycleRecords failed, generate record id failed, err: %+v, rid: %s", err, ctx.ReqID)
+			return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+		}
+		record.ID = int64(id)
+		record.OwnerID = ctx.SupplierAccount
+		record.Operator = ctx.User
+		record.DeleteTime = now
+		record.ExpireTime = now.Add(m.retention)
+		records = append(records, record)
+		dataResult.Created = append(dataResult.Created, metadata.CreatedDataResult{OriginIndex: int64(idx), ID: id})
+	}
+
+	if len(records) == 0 {
+		return dataResult, nil
+	}
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Insert(ctx, records); err != nil {
+		blog.Errorf("CreateRecycleRecords failed, insert records failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	return dataResult, nil
+}
+
+// SearchRecycleRecords search the recycle records of current supplier account
+func (m *recycleBinManager) SearchRecycleRecords(ctx core.ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryRecycleRecordsResult, error) {
+	cond := util.SetQueryOwner(inputParam.Condition, ctx.SupplierAccount)
+	for _, field := range []string{metadata.RecycleRecordFieldDeleteTime, metadata.RecycleRecordFieldExpireTime} {
+		if val, exists := cond[field]; exists {
+			cond[field] = parseTimeCondition(val)
+		}
+	}
+	query := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(cond)
+	if len(inputParam.SortArr) == 0 {
+		query = query.Sort("-" + metadata.RecycleRecordFieldDeleteTime)
+	}
+	for _, sort := range inputParam.SortArr {
+		field := sort.Field
+		if sort.IsDsc {
+			field = "-" + field
+		}
+		query = query.Sort(field)
+	}
+
+	records := make([]metadata.RecycleRecord, 0)
+	err := query.Start(uint64(inputParam.Limit.Offset)).Limit(uint64(inputParam.Limit.Limit)).Fields(inputParam.Fields...).All(ctx, &records)
+	if err != nil {
+		blog.Errorf("SearchRecycleRecords failed, search records failed, err: %+v, cond: %+v, rid: %s", err, cond, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	count, err := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("SearchRecycleRecords failed, count records failed, err: %+v, cond: %+v, rid: %s", err, cond, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.QueryRecycleRecordsResult{Count: count, Info: records}, nil
+}
+
+// parseTimeCondition parse the time values of a condition, which are passed as string through http.
+func parseTimeCondition(val interface{}) interface{} {
+	switch t := val.(type) {
+	case string:
+		if ts, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			return ts
+		}
+	case map[string]interface{}:
+		for op, item := range t {
+			t[op] = parseTimeCondition(item)
+		}
+	case mapstr.MapStr:
+		for op, item := range t {
+			t[op] = parseTimeCondition(item)
+		}
+	}
+	return val
+}
+
+// PurgeRecycleRecords delete the recycle records permanently
+func (m *recycleBinManager) PurgeRecycleRecords(ctx core.ContextParams, inputParam metadata.PurgeRecycleRecords) (*metadata.DeletedCount, error) {
+	if len(inputParam.IDs) == 0 && !inputParam.Expired {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	if len(inputParam.IDs) > 0 {
+		cond.Element(&mongo.In{Key: common.BKFieldID, Val: inputParam.IDs})
+	}
+	if inputParam.Expired {
+		cond.Element(&mongo.Lte{Key: metadata.RecycleRecordFieldExpireTime, Val: time.Now()})
+	}
+
+	count, err := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		blog.Errorf("PurgeRecycleRecords failed, count records failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Delete(ctx, cond.ToMapStr()); err != nil {
+		blog.Errorf("PurgeRecycleRecords failed, delete records failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return &metadata.DeletedCount{Count: count}, nil
+}
+
+// loopPurgeExpired purge the expired records of all the supplier accounts periodically, only one
+// core service purges them in an interval
+func (m *recycleBinManager) loopPurgeExpired() {
+	for {
+		m.purgeExpired()
+		time.Sleep(purgeInterval)
+	}
+}
+
+func (m *recycleBinManager) purgeExpired() {
+	// the lock is not released, so that it's kept until the next round of the interval
+	locked, err := m.cache.SetNX(common.RedisCoreSrvRecycleBinPurgeLockKey, "", purgeInterval*9/10).Result()
+	if err != nil {
+		blog.Warnf("purge expired recycle records, lock failed, err: %+v", err)
+		return
+	}
+	if !locked {
+		return
+	}
+
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Lte{Key: metadata.RecycleRecordFieldExpireTime, Val: time.Now()})
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Delete(context.Background(), cond.ToMapStr()); err != nil {
+		blog.Errorf("purge expired recycle records failed, err: %+v", err)
+	}
+}
+
+func (m *recycleBinManager) searchInstAssociations(ctx core.ContextParams, objID string, instID int64) ([]mapstr.MapStr, error) {
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKObjIDField: objID, common.BKInstIDField: instID},
+			{common.BKAsstObjIDField: objID, common.BKAsstInstIDField: instID},
+		},
+	}
+	assts := make([]mapstr.MapStr, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(cond).All(ctx, &assts); err != nil {
+		blog.Errorf("search instance associations of %s instance %d failed, err: %+v, rid: %s", objID, instID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	for _, asst := range assts {
+		asst.Remove("_id")
+	}
+	return assts, nil
+}
+
+func (m *recycleBinManager) searchModuleHosts(ctx core.ContextParams, hostID int64) ([]mapstr.MapStr, error) {
+	cond := mapstr.MapStr{common.BKHostIDField: hostID}
+	relations := make([]mapstr.MapStr, 0)
+	if err := m.dbProxy.Table(common.BKTableNameModuleHostConfig).Find(cond).All(ctx, &relations); err != nil {
+		blog.Errorf("search module host relations of host %d failed, err: %+v, rid: %s", hostID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	for _, relation := range relations {
+		relation.Remove("_id")
+	}
+	return relations, nil
+}
@@ -0,0 +1,472 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recyclebin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+// fakeDB keeps the documents of each table in memory as json decoded maps, the equal, $eq, $ne,
+// $in, $lte and $or filters are evaluated, which are all the ones used by the recycle bin.
+type fakeDB struct {
+	dal.RDB
+	tables   map[string][]mapstr.MapStr
+	sequence uint64
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{tables: make(map[string][]mapstr.MapStr)}
+}
+
+func (db *fakeDB) Table(collection string) dal.Table {
+	return &fakeTable{db: db, name: collection}
+}
+
+func (db *fakeDB) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	db.sequence++
+	return db.sequence, nil
+}
+
+func (db *fakeDB) insert(table string, docs ...interface{}) {
+	for _, doc := range docs {
+		js, _ := json.Marshal(doc)
+		item := mapstr.MapStr{}
+		_ = json.Unmarshal(js, &item)
+		db.tables[table] = append(db.tables[table], item)
+	}
+}
+
+func (db *fakeDB) find(table string, filter dal.Filter) []mapstr.MapStr {
+	found := make([]mapstr.MapStr, 0)
+	for _, doc := range db.tables[table] {
+		if matchFilter(doc, toMapStr(filter)) {
+			found = append(found, doc)
+		}
+	}
+	return found
+}
+
+func toMapStr(val interface{}) mapstr.MapStr {
+	switch t := val.(type) {
+	case mapstr.MapStr:
+		return t
+	case map[string]interface{}:
+		return mapstr.MapStr(t)
+	}
+	return nil
+}
+
+func matchFilter(doc mapstr.MapStr, filter mapstr.MapStr) bool {
+	for key, val := range filter {
+		if key == common.BKDBOR {
+			matched := false
+			for _, sub := range val.([]mapstr.MapStr) {
+				matched = matched || matchFilter(doc, sub)
+			}
+			if !matched {
+				return false
+			}
+			continue
+		}
+		ops := toMapStr(val)
+		if ops == nil {
+			ops = mapstr.MapStr{common.BKDBEQ: val}
+		}
+		for op, opVal := range ops {
+			if !matchOperator(doc[key], op, opVal) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchOperator(docVal interface{}, op string, val interface{}) bool {
+	switch op {
+	case common.BKDBEQ:
+		return sameValue(docVal, val)
+	case common.BKDBNE:
+		return !sameValue(docVal, val)
+	case common.BKDBIN:
+		items := reflect.ValueOf(val)
+		for idx := 0; idx < items.Len(); idx++ {
+			if sameValue(docVal, items.Index(idx).Interface()) {
+				return true
+			}
+		}
+		return false
+	case common.BKDBLTE:
+		docTime, err := time.Parse(time.RFC3339Nano, fmt.Sprint(docVal))
+		return err == nil && !docTime.After(val.(time.Time))
+	}
+	panic("unsupported operator " + op)
+}
+
+func sameValue(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+type fakeTable struct {
+	dal.Table
+	db   *fakeDB
+	name string
+}
+
+func (t *fakeTable) Find(filter dal.Filter) dal.Find {
+	return &fakeFind{table: t, filter: filter}
+}
+
+func (t *fakeTable) Insert(ctx context.Context, docs interface{}) error {
+	items := reflect.ValueOf(docs)
+	if items.Kind() != reflect.Slice {
+		t.db.insert(t.name, docs)
+		return nil
+	}
+	for idx := 0; idx < items.Len(); idx++ {
+		t.db.insert(t.name, items.Index(idx).Interface())
+	}
+	return nil
+}
+
+func (t *fakeTable) Delete(ctx context.Context, filter dal.Filter) error {
+	kept := make([]mapstr.MapStr, 0)
+	for _, doc := range t.db.tables[t.name] {
+		if !matchFilter(doc, toMapStr(filter)) {
+			kept = append(kept, doc)
+		}
+	}
+	t.db.tables[t.name] = kept
+	return nil
+}
+
+type fakeFind struct {
+	dal.Find
+	table  *fakeTable
+	filter dal.Filter
+}
+
+func (f *fakeFind) Sort(sort string) dal.Find        { return f }
+func (f *fakeFind) Start(start uint64) dal.Find      { return f }
+func (f *fakeFind) Limit(limit uint64) dal.Find      { return f }
+func (f *fakeFind) Fields(fields ...string) dal.Find { return f }
+
+func (f *fakeFind) All(ctx context.Context, result interface{}) error {
+	js, _ := json.Marshal(f.table.db.find(f.table.name, f.filter))
+	return json.Unmarshal(js, result)
+}
+
+func (f *fakeFind) Count(ctx context.Context) (uint64, error) {
+	return uint64(len(f.table.db.find(f.table.name, f.filter))), nil
+}
+
+// fakeDependent creates the instance associations into the fake db, the ones whose object
+// association id is in conflicts are refused like a violation of the mapping constraint.
+type fakeDependent struct {
+	db        *fakeDB
+	conflicts map[string]bool
+	err       error
+}
+
+func (d *fakeDependent) SelectObjectAttWithParams(ctx core.ContextParams, objID string, bizID int64) ([]metadata.Attribute, error) {
+	return nil, nil
+}
+
+func (d *fakeDependent) SearchUnique(ctx core.ContextParams, objID string) ([]metadata.ObjectUnique, error) {
+	return nil, nil
+}
+
+func (d *fakeDependent) CreateManyInstAsst(ctx core.ContextParams, assts []metadata.InstAsst) (*metadata.CreateManyDataResult, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	result := &metadata.CreateManyDataResult{}
+	for idx, asst := range assts {
+		if d.conflicts[asst.ObjectAsstID] {
+			result.Exceptions = append(result.Exceptions, metadata.ExceptionResult{
+				Message:     "exceed the limit",
+				Code:        common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation,
+				OriginIndex: int64(idx),
+			})
+			continue
+		}
+		id, _ := d.db.NextSequence(ctx, common.BKTableNameInstAsst)
+		asst.ID = int64(id)
+		d.db.insert(common.BKTableNameInstAsst, asst)
+		result.Created = append(result.Created, metadata.CreatedDataResult{ID: id})
+	}
+	return result, nil
+}
+
+type fakeEventClient struct {
+	events []*metadata.EventInst
+}
+
+func (c *fakeEventClient) Push(ctx context.Context, events ...*metadata.EventInst) error {
+	c.events = append(c.events, events...)
+	return nil
+}
+
+func newTestManager(db *fakeDB, conflicts ...string) (*recycleBinManager, *fakeEventClient) {
+	dependent := &fakeDependent{db: db, conflicts: make(map[string]bool)}
+	for _, objAsstID := range conflicts {
+		dependent.conflicts[objAsstID] = true
+	}
+	events := &fakeEventClient{}
+	return &recycleBinManager{
+		dbProxy:   db,
+		dependent: dependent,
+		retention: 24 * time.Hour,
+		EventC:    events,
+	}, events
+}
+
+func newTestContext() core.ContextParams {
+	return core.ContextParams{
+		Context:         context.Background(),
+		ReqID:           "test_req_id",
+		SupplierAccount: "0",
+		User:            "admin",
+		Error:           errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+	}
+}
+
+func newSwitch(instID int64) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.BKObjIDField:   "switch",
+		common.BKInstIDField:  instID,
+		common.BKOwnerIDField: "0",
+	}
+}
+
+func newSwitchAsst(id int64, objAsstID string, instID, asstInstID int64) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.BKFieldID:                 id,
+		common.AssociationObjAsstIDField: objAsstID,
+		common.BKObjIDField:              "switch",
+		common.BKInstIDField:             instID,
+		common.BKAsstObjIDField:          "switch",
+		common.BKAsstInstIDField:         asstInstID,
+		common.BKOwnerIDField:            "0",
+	}
+}
+
+func newObjAsst(objAsstID, ownerID string) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.AssociationObjAsstIDField: objAsstID,
+		common.BKOwnerIDField:            ownerID,
+	}
+}
+
+func TestRecycleInstance(t *testing.T) {
+	db := newFakeDB()
+	db.insert(common.BKTableNameBaseInst, newSwitch(1), newSwitch(2))
+	db.insert(common.BKTableNameInstAsst, newSwitchAsst(10, "switch_connect_switch", 1, 2))
+	manager, _ := newTestManager(db)
+	ctx := newTestContext()
+
+	snapshots, err := manager.SnapshotRecycleRecords(ctx, "switch", metadata.CreateRecycleRecords{InstIDs: []int64{1, 3}})
+	if err != nil {
+		t.Fatalf("SnapshotRecycleRecords() unexpected error: %v", err)
+	}
+	if len(snapshots.Info) != 1 || len(snapshots.Exceptions) != 1 || snapshots.Exceptions[0].OriginIndex != 1 {
+		t.Fatalf("SnapshotRecycleRecords() want the instance 1 and an exception of 3, got %+v", snapshots)
+	}
+	if len(snapshots.Info[0].Associations) != 1 {
+		t.Errorf("SnapshotRecycleRecords() want 1 association, got %v", snapshots.Info[0].Associations)
+	}
+
+	// the snapshot is saved before the instance is deleted, records of other models are refused.
+	records := append(snapshots.Info, metadata.RecycleRecord{ObjectID: "router", InstID: 1, Data: newSwitch(1)})
+	created, err := manager.CreateRecycleRecords(ctx, "switch", metadata.SaveRecycleRecords{Records: records})
+	if err != nil {
+		t.Fatalf("CreateRecycleRecords() unexpected error: %v", err)
+	}
+	if len(created.Created) != 1 || len(created.Exceptions) != 1 || created.Exceptions[0].OriginIndex != 1 {
+		t.Fatalf("CreateRecycleRecords() want 1 record and an exception of the router, got %+v", created)
+	}
+
+	saved := make([]metadata.RecycleRecord, 0)
+	if err := db.Table(common.BKTableNameRecycleBin).Find(mapstr.MapStr{}).All(ctx, &saved); err != nil {
+		t.Fatalf("find recycle records failed: %v", err)
+	}
+	if len(saved) != 1 || saved[0].Operator != "admin" || saved[0].OwnerID != "0" {
+		t.Fatalf("CreateRecycleRecords() saved %+v", saved)
+	}
+	if retention := saved[0].ExpireTime.Sub(saved[0].DeleteTime); retention != 24*time.Hour {
+		t.Errorf("CreateRecycleRecords() want the record expires after 24h, got %v", retention)
+	}
+}
+
+func TestRestoreRecycleRecords(t *testing.T) {
+	record := metadata.RecycleRecord{
+		ID:       100,
+		OwnerID:  "0",
+		ObjectID: "switch",
+		InstID:   1,
+		Data:     newSwitch(1),
+		Associations: []mapstr.MapStr{
+			newSwitchAsst(10, "switch_connect_switch", 1, 2),
+			newSwitchAsst(11, "switch_uplink_switch", 1, 3),
+			newSwitchAsst(12, "switch_removed_switch", 1, 2),
+		},
+	}
+
+	tests := []struct {
+		name          string
+		exists        bool
+		conflicts     []string
+		wantErr       bool
+		wantAssts     int
+		wantDropped   int
+		wantRemaining int
+	}{
+		{"restore the instance and the associations", false, nil, false, 2, 1, 0},
+		{"drop the association conflicting with the constraints", false, []string{"switch_uplink_switch"}, false, 1, 2, 0},
+		{"instance with the same id exists", true, nil, true, 0, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB()
+			db.insert(common.BKTableNameBaseInst, newSwitch(2), newSwitch(3))
+			if tt.exists {
+				db.insert(common.BKTableNameBaseInst, newSwitch(1))
+			}
+			// the model association of other supplier account is never used.
+			db.insert(common.BKTableNameObjAsst,
+				newObjAsst("switch_connect_switch", "0"),
+				newObjAsst("switch_uplink_switch", "0"),
+				newObjAsst("switch_removed_switch", "1"))
+			db.insert(common.BKTableNameRecycleBin, record)
+			manager, events := newTestManager(db, tt.conflicts...)
+
+			result, err := manager.RestoreRecycleRecords(newTestContext(), metadata.RestoreRecycleRecords{IDs: []int64{100}})
+			if err != nil {
+				t.Fatalf("RestoreRecycleRecords() unexpected error: %v", err)
+			}
+			if tt.wantErr {
+				if len(result.Exceptions) != 1 || len(result.Restored) != 0 || len(events.events) != 0 {
+					t.Fatalf("RestoreRecycleRecords() want an exception, got %+v", result)
+				}
+			} else {
+				if len(result.Restored) != 1 || len(events.events) != 1 {
+					t.Fatalf("RestoreRecycleRecords() want the record restored, got %+v", result)
+				}
+				if dropped := len(result.Restored[0].DroppedAssociations); dropped != tt.wantDropped {
+					t.Errorf("RestoreRecycleRecords() want %d dropped associations, got %d", tt.wantDropped, dropped)
+				}
+				if cnt := len(db.find(common.BKTableNameBaseInst, newSwitch(1))); cnt != 1 {
+					t.Errorf("RestoreRecycleRecords() want the instance restored once, got %d", cnt)
+				}
+			}
+			if cnt := len(db.tables[common.BKTableNameInstAsst]); cnt != tt.wantAssts {
+				t.Errorf("RestoreRecycleRecords() want %d associations, got %d", tt.wantAssts, cnt)
+			}
+			if cnt := len(db.tables[common.BKTableNameRecycleBin]); cnt != tt.wantRemaining {
+				t.Errorf("RestoreRecycleRecords() want %d records remaining, got %d", tt.wantRemaining, cnt)
+			}
+		})
+	}
+}
+
+func TestRestoreRollback(t *testing.T) {
+	db := newFakeDB()
+	db.insert(common.BKTableNameBaseInst, newSwitch(2))
+	db.insert(common.BKTableNameObjAsst, newObjAsst("switch_connect_switch", "0"))
+	db.insert(common.BKTableNameRecycleBin, metadata.RecycleRecord{
+		ID:           100,
+		OwnerID:      "0",
+		ObjectID:     "switch",
+		InstID:       1,
+		Data:         newSwitch(1),
+		Associations: []mapstr.MapStr{newSwitchAsst(10, "switch_connect_switch", 1, 2)},
+	})
+	manager, events := newTestManager(db)
+	manager.dependent.(*fakeDependent).err = fmt.Errorf("association is unavailable")
+
+	result, err := manager.RestoreRecycleRecords(newTestContext(), metadata.RestoreRecycleRecords{IDs: []int64{100}})
+	if err != nil {
+		t.Fatalf("RestoreRecycleRecords() unexpected error: %v", err)
+	}
+	if len(result.Exceptions) != 1 || len(events.events) != 0 {
+		t.Fatalf("RestoreRecycleRecords() want an exception, got %+v", result)
+	}
+	if cnt := len(db.find(common.BKTableNameBaseInst, newSwitch(1))); cnt != 0 {
+		t.Errorf("RestoreRecycleRecords() want the restored instance removed, got %d", cnt)
+	}
+	if cnt := len(db.tables[common.BKTableNameRecycleBin]); cnt != 1 {
+		t.Errorf("RestoreRecycleRecords() want the record kept, got %d", cnt)
+	}
+}
+
+func TestPurgeRecycleRecords(t *testing.T) {
+	now := time.Now()
+	records := []metadata.RecycleRecord{
+		{ID: 1, OwnerID: "0", ObjectID: "switch", InstID: 1, ExpireTime: now.Add(-time.Hour)},
+		{ID: 2, OwnerID: "0", ObjectID: "switch", InstID: 2, ExpireTime: now.Add(time.Hour)},
+		{ID: 3, OwnerID: "1", ObjectID: "switch", InstID: 3, ExpireTime: now.Add(-time.Hour)},
+	}
+
+	tests := []struct {
+		name      string
+		input     metadata.PurgeRecycleRecords
+		wantErr   bool
+		wantCount uint64
+		wantKept  []int64
+	}{
+		{"ids or expired is required", metadata.PurgeRecycleRecords{}, true, 0, []int64{1, 2, 3}},
+		{"purge by ids", metadata.PurgeRecycleRecords{IDs: []int64{2}}, false, 1, []int64{1, 3}},
+		{"purge the expired of the supplier account", metadata.PurgeRecycleRecords{Expired: true}, false, 1, []int64{2, 3}},
+		{"ids of other supplier account are ignored", metadata.PurgeRecycleRecords{IDs: []int64{3}}, false, 0, []int64{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB()
+			for _, record := range records {
+				db.insert(common.BKTableNameRecycleBin, record)
+			}
+			manager, _ := newTestManager(db)
+
+			deleted, err := manager.PurgeRecycleRecords(newTestContext(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PurgeRecycleRecords() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && deleted.Count != tt.wantCount {
+				t.Errorf("PurgeRecycleRecords() deleted %d, want %d", deleted.Count, tt.wantCount)
+			}
+			kept := make([]int64, 0)
+			for _, doc := range db.tables[common.BKTableNameRecycleBin] {
+				id, _ := doc.Int64(common.BKFieldID)
+				kept = append(kept, id)
+			}
+			if !reflect.DeepEqual(kept, tt.wantKept) {
+				t.Errorf("PurgeRecycleRecords() kept %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
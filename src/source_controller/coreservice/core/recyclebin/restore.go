@@ -0,0 +1,397 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recyclebin
+
+import (
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// RestoreRecycleRecords restore the instances in recycle bin, the unique constraints of the model
+// and the associations are validated again, since they may have been changed after the deletion.
+func (m *recycleBinManager) RestoreRecycleRecords(ctx core.ContextParams, inputParam metadata.RestoreRecycleRecords) (*metadata.RestoreRecycleRecordsResult, error) {
+	if len(inputParam.IDs) == 0 {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.In{Key: common.BKFieldID, Val: inputParam.IDs})
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	records := make([]metadata.RecycleRecord, 0)
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(cond.ToMapStr()).All(ctx, &records); err != nil {
+		blog.Errorf("RestoreRecycleRecords failed, search records failed, err: %+v, cond: %+v, rid: %s", err, cond.ToMapStr(), ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	recordMap := make(map[int64]metadata.RecycleRecord)
+	for _, record := range records {
+		recordMap[record.ID] = record
+	}
+
+	result := &metadata.RestoreRecycleRecordsResult{
+		Restored:   make([]metadata.RestoredRecycleRecord, 0),
+		Exceptions: make([]metadata.ExceptionResult, 0),
+	}
+	for idx, id := range inputParam.IDs {
+		record, ok := recordMap[id]
+		if !ok {
+			result.Exceptions = append(result.Exceptions, newException(ctx.Error.Error(common.CCErrCommNotFound), id, idx))
+			continue
+		}
+
+		restored, err := m.restore(ctx, record)
+		if err != nil {
+			blog.Errorf("RestoreRecycleRecords, restore record %d failed, err: %+v, rid: %s", id, err, ctx.ReqID)
+			result.Exceptions = append(result.Exceptions, newException(err, id, idx))
+			continue
+		}
+		result.Restored = append(result.Restored, *restored)
+	}
+	return result, nil
+}
+
+func newException(err error, id int64, idx int) metadata.ExceptionResult {
+	exception := metadata.ExceptionResult{
+		Message:     err.Error(),
+		Code:        common.CCErrCommDBInsertFailed,
+		Data:        id,
+		OriginIndex: int64(idx),
+	}
+	if ccErr, ok := err.(errors.CCErrorCoder); ok {
+		exception.Code = int64(ccErr.GetCode())
+	}
+	return exception
+}
+
+func (m *recycleBinManager) restore(ctx core.ContextParams, record metadata.RecycleRecord) (*metadata.RestoredRecycleRecord, error) {
+	// the instance is locked, so that the records of it can not be restored concurrently.
+	unlock, err := m.lockRestore(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// the instance id is kept when restoring, so that the references to it is still valid.
+	exists, err := m.instExists(ctx, record.ObjectID, record.InstID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ctx.Error.Errorf(common.CCErrCommDuplicateItem, common.GetInstIDField(record.ObjectID))
+	}
+
+	if err := m.validRestoreUnique(ctx, record); err != nil {
+		return nil, err
+	}
+
+	// a host can not be restored without the module it belongs to.
+	for _, relation := range record.ModuleHosts {
+		moduleID, err := util.GetInt64ByInterface(relation[common.BKModuleIDField])
+		if err != nil {
+			return nil, ctx.Error.Errorf(common.CCErrCommInstFieldConvFail, common.BKInnerObjIDModule, common.BKModuleIDField, "integer", err.Error())
+		}
+		exists, err := m.instExists(ctx, common.BKInnerObjIDModule, moduleID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			blog.Errorf("restore host %d failed, module %d not exist, rid: %s", record.InstID, moduleID, ctx.ReqID)
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKModuleIDField)
+		}
+	}
+
+	restoredAssts, droppedAssts, err := m.validRestoreAssociations(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	// the collections can not be written in one transaction, so the documents inserted are
+	// removed again when any of the following steps failed.
+	rollback := make([]restoredDocs, 0)
+	tableName := common.GetInstTableName(record.ObjectID)
+	if err := m.dbProxy.Table(tableName).Insert(ctx, record.Data); err != nil {
+		blog.Errorf("restore %s instance %d failed, insert instance failed, err: %+v, rid: %s", record.ObjectID, record.InstID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	instCond := mapstr.MapStr{common.GetInstIDField(record.ObjectID): record.InstID}
+	if tableName == common.BKTableNameBaseInst {
+		instCond[common.BKObjIDField] = record.ObjectID
+	}
+	rollback = append(rollback, restoredDocs{table: tableName, cond: instCond})
+
+	if len(record.ModuleHosts) > 0 {
+		if err := m.dbProxy.Table(common.BKTableNameModuleHostConfig).Insert(ctx, record.ModuleHosts); err != nil {
+			blog.Errorf("restore host %d failed, insert module host relations failed, err: %+v, rid: %s", record.InstID, err, ctx.ReqID)
+			m.rollbackRestore(ctx, record, rollback)
+			return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+		}
+		rollback = append(rollback, restoredDocs{
+			table: common.BKTableNameModuleHostConfig,
+			cond:  mapstr.MapStr{common.BKHostIDField: record.InstID},
+		})
+	}
+	if len(restoredAssts) > 0 {
+		asstIDs, conflicted, err := m.restoreAssociations(ctx, record, restoredAssts)
+		if err != nil {
+			m.rollbackRestore(ctx, record, rollback)
+			return nil, err
+		}
+		droppedAssts = append(droppedAssts, conflicted...)
+		if len(asstIDs) > 0 {
+			rollback = append(rollback, restoredDocs{
+				table: common.BKTableNameInstAsst,
+				cond:  mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: asstIDs}},
+			})
+		}
+	}
+
+	delCond := mapstr.MapStr{common.BKFieldID: record.ID}
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Delete(ctx, delCond); err != nil {
+		blog.Errorf("restore %s instance %d failed, delete record %d failed, err: %+v, rid: %s", record.ObjectID, record.InstID, record.ID, err, ctx.ReqID)
+		m.rollbackRestore(ctx, record, rollback)
+		return nil, ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+
+	m.pushRestoreEvent(ctx, record)
+
+	return &metadata.RestoredRecycleRecord{
+		ID:                  record.ID,
+		ObjectID:            record.ObjectID,
+		InstID:              record.InstID,
+		DroppedAssociations: droppedAssts,
+	}, nil
+}
+
+// restoredDocs the documents inserted when restoring a record
+type restoredDocs struct {
+	table string
+	cond  mapstr.MapStr
+}
+
+// rollbackRestore remove the restored documents in reverse order, the failure is only logged
+// because the restoring has failed already.
+// lockRestore lock the instance of the record being restored, the returned function release the lock.
+func (m *recycleBinManager) lockRestore(ctx core.ContextParams, record metadata.RecycleRecord) (func(), error) {
+	if m.cache == nil {
+		return func() {}, nil
+	}
+
+	key := common.RedisCoreSrvRecycleBinRestoreLockKeyPrefix + record.ObjectID + ":" + strconv.FormatInt(record.InstID, 10)
+	value := ctx.ReqID
+	if value == "" {
+		value = util.GenerateRID()
+	}
+	locked, err := m.cache.SetNX(key, value, restoreLockExpire).Result()
+	if err != nil {
+		blog.Errorf("restore record %d failed, lock %s failed, err: %+v, rid: %s", record.ID, key, err, ctx.ReqID)
+		return nil, ctx.Error.Errorf(common.CCErrCommUtilHandleFail, "redis setnx", err.Error())
+	}
+	if !locked {
+		blog.Errorf("restore record %d failed, the instance is being restored by others, rid: %s", record.ID, ctx.ReqID)
+		return nil, ctx.Error.Errorf(common.CCErrCommUtilHandleFail, "lock recycle record", key)
+	}
+
+	return func() {
+		if err := unlockRestoreScript.Run(m.cache, []string{key}, value).Err(); err != nil {
+			blog.Warnf("restore record %d, unlock %s failed, err: %+v, rid: %s", record.ID, key, err, ctx.ReqID)
+		}
+	}, nil
+}
+
+func (m *recycleBinManager) rollbackRestore(ctx core.ContextParams, record metadata.RecycleRecord, docs []restoredDocs) {
+	for idx := len(docs) - 1; idx >= 0; idx-- {
+		if err := m.dbProxy.Table(docs[idx].table).Delete(ctx, docs[idx].cond); err != nil {
+			blog.Errorf("rollback restoring %s instance %d failed, delete %s by %+v failed, err: %+v, rid: %s",
+				record.ObjectID, record.InstID, docs[idx].table, docs[idx].cond, err, ctx.ReqID)
+		}
+	}
+}
+
+func (m *recycleBinManager) instExists(ctx core.ContextParams, objID string, instID int64) (bool, error) {
+	tableName := common.GetInstTableName(objID)
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.GetInstIDField(objID), Val: instID})
+	if tableName == common.BKTableNameBaseInst {
+		cond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: objID})
+	}
+	cnt, err := m.dbProxy.Table(tableName).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		blog.Errorf("check %s instance %d exists failed, err: %+v, rid: %s", objID, instID, err, ctx.ReqID)
+		return false, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return cnt > 0, nil
+}
+
+// validRestoreUnique check if the instance to be restored conflicts with the existing instances
+func (m *recycleBinManager) validRestoreUnique(ctx core.ContextParams, record metadata.RecycleRecord) error {
+	uniques, err := m.dependent.SearchUnique(ctx, record.ObjectID)
+	if err != nil {
+		blog.Errorf("[validRestoreUnique] search [%s] unique error %v, rid: %s", record.ObjectID, err, ctx.ReqID)
+		return err
+	}
+	if len(uniques) == 0 {
+		return nil
+	}
+
+	attributes, err := m.dependent.SelectObjectAttWithParams(ctx, record.ObjectID, record.BizID)
+	if err != nil {
+		blog.Errorf("[validRestoreUnique] search [%s] attributes error %v, rid: %s", record.ObjectID, err, ctx.ReqID)
+		return err
+	}
+	idToProperty := make(map[uint64]string)
+	for _, attribute := range attributes {
+		idToProperty[uint64(attribute.ID)] = attribute.PropertyID
+	}
+
+	tableName := common.GetInstTableName(record.ObjectID)
+	for _, unique := range uniques {
+		cond := mongo.NewCondition()
+		propertyIDs := make([]string, 0)
+		anyEmpty := false
+		for _, key := range unique.Keys {
+			if key.Kind != metadata.UniqueKeyKindProperty {
+				blog.Errorf("[validRestoreUnique] find [%s] property [%d] unique kind invalid [%s], rid: %s", record.ObjectID, key.ID, key.Kind, ctx.ReqID)
+				return ctx.Error.Errorf(common.CCErrTopoObjectUniqueKeyKindInvalid, key.Kind)
+			}
+			propertyID, ok := idToProperty[key.ID]
+			if !ok {
+				blog.Errorf("[validRestoreUnique] find [%s] property [%d] failed, rid: %s", record.ObjectID, key.ID, ctx.ReqID)
+				return ctx.Error.Errorf(common.CCErrTopoObjectPropertyNotFound, key.ID)
+			}
+			val, ok := record.Data[propertyID]
+			if !ok || val == nil || val == "" {
+				anyEmpty = true
+			}
+			cond.Element(&mongo.Eq{Key: propertyID, Val: val})
+			propertyIDs = append(propertyIDs, propertyID)
+		}
+
+		if anyEmpty && !unique.MustCheck {
+			continue
+		}
+
+		// only search data not in diable status
+		cond.Element(&mongo.Neq{Key: common.BKDataStatusField, Val: common.DataStatusDisabled})
+		cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: record.OwnerID})
+		if tableName == common.BKTableNameBaseInst {
+			cond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: record.ObjectID})
+		}
+		if record.Data.Exists(metadata.BKMetadata) && record.BizID > 0 {
+			_, metaCond := cond.Embed(metadata.BKMetadata)
+			_, labelCond := metaCond.Embed(metadata.BKLabel)
+			labelCond.Element(&mongo.Eq{Key: common.BKAppIDField, Val: strconv.FormatInt(record.BizID, 10)})
+		}
+
+		cnt, err := m.dbProxy.Table(tableName).Find(cond.ToMapStr()).Count(ctx)
+		if err != nil {
+			blog.Errorf("[validRestoreUnique] search [%s] inst error %v, rid: %s", record.ObjectID, err, ctx.ReqID)
+			return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+		}
+		if cnt > 0 {
+			blog.Errorf("[validRestoreUnique] duplicate data condition: %#v, objID %s, rid: %s", cond.ToMapStr(), record.ObjectID, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommDuplicateItem, strings.Join(propertyIDs, ","))
+		}
+	}
+	return nil
+}
+
+// validRestoreAssociations split the associations of the record into the ones which can be restored,
+// and the ones whose model association or associated instance does not exist any more. the mapping and
+// max count constraints of the ones to be restored are checked when they are created.
+func (m *recycleBinManager) validRestoreAssociations(ctx core.ContextParams, record metadata.RecycleRecord) (restored []mapstr.MapStr, dropped []mapstr.MapStr, err error) {
+	restored = make([]mapstr.MapStr, 0)
+	dropped = make([]mapstr.MapStr, 0)
+	for _, asst := range record.Associations {
+		objAsstID, _ := asst.String(common.AssociationObjAsstIDField)
+		asstCond := mongo.NewCondition()
+		asstCond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: objAsstID})
+		asstCond.Element(&mongo.In{Key: common.BKOwnerIDField, Val: []string{ctx.SupplierAccount, common.BKDefaultOwnerID}})
+		cnt, err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(asstCond.ToMapStr()).Count(ctx)
+		if err != nil {
+			blog.Errorf("search model association %s failed, err: %+v, rid: %s", objAsstID, err, ctx.ReqID)
+			return nil, nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+		}
+		if cnt == 0 {
+			dropped = append(dropped, asst)
+			continue
+		}
+
+		// find the instance on the other side of the association
+		objID, _ := asst.String(common.BKObjIDField)
+		instID, _ := asst.Int64(common.BKInstIDField)
+		if objID == record.ObjectID && instID == record.InstID {
+			objID, _ = asst.String(common.BKAsstObjIDField)
+			instID, _ = asst.Int64(common.BKAsstInstIDField)
+		}
+		exists, err := m.instExists(ctx, objID, instID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			dropped = append(dropped, asst)
+			continue
+		}
+		restored = append(restored, asst)
+	}
+	return restored, dropped, nil
+}
+
+// restoreAssociations create the associations of the restored instance with the mapping and max count constraints
+// checked again, the ones conflicting with the associations created after the deletion are dropped.
+func (m *recycleBinManager) restoreAssociations(ctx core.ContextParams, record metadata.RecycleRecord, assts []mapstr.MapStr) (created []int64, dropped []mapstr.MapStr, err error) {
+	items := make([]metadata.InstAsst, 0)
+	for _, asst := range assts {
+		item := metadata.InstAsst{}
+		if err := asst.MarshalJSONInto(&item); err != nil {
+			blog.ErrorJSON("restore %s instance %d failed, parse association %s failed, err: %s, rid: %s", record.ObjectID, record.InstID, asst, err, ctx.ReqID)
+			return nil, nil, ctx.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+		}
+		items = append(items, item)
+	}
+
+	result, err := m.dependent.CreateManyInstAsst(ctx, items)
+	if err != nil {
+		blog.Errorf("restore %s instance %d failed, create associations failed, err: %+v, rid: %s", record.ObjectID, record.InstID, err, ctx.ReqID)
+		return nil, nil, err
+	}
+	created = make([]int64, 0)
+	for _, item := range result.Created {
+		created = append(created, int64(item.ID))
+	}
+	dropped = make([]mapstr.MapStr, 0)
+	for _, exception := range result.Exceptions {
+		blog.Warnf("restore %s instance %d, drop association %+v, err: %s, rid: %s", record.ObjectID, record.InstID, assts[exception.OriginIndex], exception.Message, ctx.ReqID)
+		dropped = append(dropped, assts[exception.OriginIndex])
+	}
+	return created, dropped, nil
+}
+
+func (m *recycleBinManager) pushRestoreEvent(ctx core.ContextParams, record metadata.RecycleRecord) {
+	event := eventclient.NewEventWithHeader(ctx.Header)
+	event.EventType = metadata.EventTypeInstData
+	event.ObjType = record.ObjectID
+	event.Action = metadata.EventActionCreate
+	event.Data = []metadata.EventData{{CurData: record.Data}}
+	if err := m.EventC.Push(ctx, event); err != nil {
+		blog.ErrorJSON("push restore event of %s instance %s failed, err: %s, rid: %s", record.ObjectID, record.InstID, err, ctx.ReqID)
+	}
+}
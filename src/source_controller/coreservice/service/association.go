@@ -188,3 +188,21 @@ func (s *coreService) DeleteInstanceAssociation(params core.ContextParams, pathP
 	}
 	return s.core.AssociationOperation().DeleteInstanceAssociation(params, inputData)
 }
+
+func (s *coreService) PlanInstanceAssociationDelete(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	inputData := metadata.InstAsstDeletePlanRequest{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.AssociationOperation().PlanInstanceAssociationDelete(params, inputData)
+}
+
+func (s *coreService) FindInstanceAssociationViolations(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	inputData := metadata.FindAssociationViolationsRequest{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.AssociationOperation().FindInstanceAssociationViolations(params, inputData)
+}
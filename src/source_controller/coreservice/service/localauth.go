@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) CreateAuthRole(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.AuthRole{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.LocalAuthOperation().CreateAuthRole(params, inputData)
+}
+
+func (s *coreService) UpdateAuthRole(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.AuthRole{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		return nil, params.Error.Errorf(common.CCErrCommParamsNeedInt, "id")
+	}
+	return s.core.LocalAuthOperation().UpdateAuthRole(params, id, inputData)
+}
+
+func (s *coreService) DeleteAuthRole(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		return nil, params.Error.Errorf(common.CCErrCommParamsNeedInt, "id")
+	}
+	return s.core.LocalAuthOperation().DeleteAuthRole(params, id)
+}
+
+func (s *coreService) SearchAuthRoles(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.LocalAuthOperation().SearchAuthRoles(params, inputData)
+}
+
+func (s *coreService) CreateAuthRoleBinding(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.AuthRoleBinding{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.LocalAuthOperation().CreateAuthRoleBinding(params, inputData)
+}
+
+func (s *coreService) DeleteAuthRoleBinding(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		return nil, params.Error.Errorf(common.CCErrCommParamsNeedInt, "id")
+	}
+	return s.core.LocalAuthOperation().DeleteAuthRoleBinding(params, id)
+}
+
+func (s *coreService) SearchAuthRoleBindings(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.LocalAuthOperation().SearchAuthRoleBindings(params, inputData)
+}
+
+func (s *coreService) GetUserAuthPermissions(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.GetUserAuthPermissionsRequest{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.LocalAuthOperation().GetUserAuthPermissions(params, inputData.User)
+}
@@ -36,13 +36,16 @@ import (
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/app/options"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/apitoken"
 	"configcenter/src/source_controller/coreservice/core/association"
 	"configcenter/src/source_controller/coreservice/core/auditlog"
 	"configcenter/src/source_controller/coreservice/core/datasynchronize"
 	"configcenter/src/source_controller/coreservice/core/host"
 	"configcenter/src/source_controller/coreservice/core/instances"
+	"configcenter/src/source_controller/coreservice/core/localauth"
 	"configcenter/src/source_controller/coreservice/core/mainline"
 	"configcenter/src/source_controller/coreservice/core/model"
+	"configcenter/src/source_controller/coreservice/core/recyclebin"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/mongo/remote"
@@ -114,11 +117,14 @@ func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err
 	s.core = core.New(
 		model.New(db, s),
 		instances.New(db, s, cache),
-		association.New(db, s),
+		association.New(db, s, cache),
 		datasynchronize.New(db, s),
 		mainline.New(db),
 		host.New(db, cache),
 		auditlog.New(db),
+		recyclebin.New(db, s, cache, cfg.RecycleBin.RetentionDays),
+		apitoken.New(db),
+		localauth.New(db),
 	)
 	return nil
 }
@@ -102,6 +102,8 @@ func (s *coreService) initInstanceAssociation() {
 	s.addAction(http.MethodPost, "/createmany/instanceassociation", s.CreateManyInstanceAssociation, nil)
 	s.addAction(http.MethodPost, "/read/instanceassociation", s.SearchInstanceAssociation, nil)
 	s.addAction(http.MethodDelete, "/delete/instanceassociation", s.DeleteInstanceAssociation, nil)
+	s.addAction(http.MethodPost, "/read/instanceassociation/deleteplan", s.PlanInstanceAssociationDelete, nil)
+	s.addAction(http.MethodPost, "/read/instanceassociation/violations", s.FindInstanceAssociationViolations, nil)
 }
 
 func (s *coreService) initMainline() {
@@ -124,6 +126,32 @@ func (s *coreService) audit() {
 	s.addAction(http.MethodPost, "/read/auditlog", s.SearchAuditLog, nil)
 }
 
+func (s *coreService) recycleBin() {
+	s.addAction(http.MethodPost, "/read/recyclebin/snapshot/{bk_obj_id}", s.SnapshotRecycleRecords, nil)
+	s.addAction(http.MethodPost, "/create/recyclebin/{bk_obj_id}", s.CreateRecycleRecords, nil)
+	s.addAction(http.MethodPost, "/read/recyclebin", s.SearchRecycleRecords, nil)
+	s.addAction(http.MethodPost, "/update/recyclebin/restore", s.RestoreRecycleRecords, nil)
+	s.addAction(http.MethodDelete, "/delete/recyclebin", s.PurgeRecycleRecords, nil)
+}
+
+func (s *coreService) apiToken() {
+	s.addAction(http.MethodPost, "/create/apitoken", s.CreateAPIToken, nil)
+	s.addAction(http.MethodPost, "/read/apitoken", s.SearchAPITokens, nil)
+	s.addAction(http.MethodPost, "/update/apitoken/revoke", s.RevokeAPITokens, nil)
+	s.addAction(http.MethodPost, "/read/apitoken/validate", s.ValidateAPIToken, nil)
+}
+
+func (s *coreService) localAuth() {
+	s.addAction(http.MethodPost, "/create/localauth/role", s.CreateAuthRole, nil)
+	s.addAction(http.MethodPut, "/update/localauth/role/{id}", s.UpdateAuthRole, nil)
+	s.addAction(http.MethodDelete, "/delete/localauth/role/{id}", s.DeleteAuthRole, nil)
+	s.addAction(http.MethodPost, "/read/localauth/role", s.SearchAuthRoles, nil)
+	s.addAction(http.MethodPost, "/create/localauth/rolebinding", s.CreateAuthRoleBinding, nil)
+	s.addAction(http.MethodDelete, "/delete/localauth/rolebinding/{id}", s.DeleteAuthRoleBinding, nil)
+	s.addAction(http.MethodPost, "/read/localauth/rolebinding", s.SearchAuthRoleBindings, nil)
+	s.addAction(http.MethodPost, "/read/localauth/permission", s.GetUserAuthPermissions, nil)
+}
+
 func (s *coreService) initService() {
 	s.initModelClassification()
 	s.initModel()
@@ -136,4 +164,7 @@ func (s *coreService) initService() {
 	s.initMainline()
 	s.host()
 	s.audit()
+	s.recycleBin()
+	s.apiToken()
+	s.localAuth()
 }
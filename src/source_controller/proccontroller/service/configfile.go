@@ -0,0 +1,155 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+	"github.com/gin-gonic/gin/json"
+)
+
+func (ps *ProctrlServer) CreateConfigFile(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := make([]meta.ProcConfigFile, 0)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("create process config file failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if 0 == len(input) {
+		resp.WriteEntity(meta.NewSuccessResp(nil))
+		return
+	}
+	files := make([]interface{}, 0)
+	ts := time.Now().UTC()
+	for _, item := range input {
+		item.OwnerID = util.GetOwnerID(req.Request.Header)
+		item.CreateTime = ts
+		files = append(files, item)
+	}
+	err := ps.Instance.Table(common.BKTableNameProcConfigFile).Insert(ctx, files)
+	if nil != err {
+		blog.Errorf("create process config file to db failed, error:%s", err.Error())
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+func (ps *ProctrlServer) UpdateConfigFile(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.UpdateParams)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("update process config file failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	input.Condition = util.SetModOwner(input.Condition, util.GetOwnerID(req.Request.Header))
+	if 0 == len(input.Data) {
+		resp.WriteEntity(meta.NewSuccessResp(nil))
+		return
+	}
+	// the push time is a string after json decoding, store it as time
+	if pushTime, ok := input.Data[meta.ProcConfigFileFieldPushTime].(string); ok {
+		ts, err := time.Parse(time.RFC3339Nano, pushTime)
+		if err != nil {
+			blog.Errorf("update process config file failed, parse push time %s err: %v", pushTime, err)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, meta.ProcConfigFileFieldPushTime)})
+			return
+		}
+		input.Data[meta.ProcConfigFileFieldPushTime] = ts
+	}
+
+	err := ps.Instance.Table(common.BKTableNameProcConfigFile).Update(ctx, input.Condition, input.Data)
+	if nil != err {
+		blog.Errorf("update process config file to db failed, error:%s", err.Error())
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+func (ps *ProctrlServer) SearchConfigFile(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("search process config file failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	input.Condition = util.SetModOwner(input.Condition, util.GetOwnerID(req.Request.Header))
+	cnt, err := ps.Instance.Table(common.BKTableNameProcConfigFile).Find(input.Condition).Count(ctx)
+	if err != nil {
+		blog.Errorf("search process config file failed. err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	data := make([]meta.ProcConfigFile, 0)
+	err = ps.Instance.Table(common.BKTableNameProcConfigFile).Find(input.Condition).Fields(strings.Split(input.Fields, ",")...).
+		Sort(input.Sort).Start(uint64(input.Start)).Limit(uint64(input.Limit)).All(ctx, &data)
+	if err != nil {
+		blog.Errorf("search process config file failed. err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	ret := meta.ProcConfigFileResult{
+		BaseResp: meta.SuccessBaseResp,
+	}
+	ret.Data.Info = data
+	ret.Data.Count = int(cnt)
+	resp.WriteEntity(ret)
+}
+
+func (ps *ProctrlServer) DeleteConfigFile(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := make(map[string]interface{}, 0)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("delete process config file failed, decode request body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	input = util.SetModOwner(input, util.GetOwnerID(req.Request.Header))
+	err := ps.Instance.Table(common.BKTableNameProcConfigFile).Delete(ctx, input)
+	if nil != err {
+		blog.Errorf("delete process config file error: %s, input:%v", err.Error(), input)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
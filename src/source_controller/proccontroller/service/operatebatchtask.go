@@ -0,0 +1,122 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+	"github.com/gin-gonic/gin/json"
+)
+
+func (ps *ProctrlServer) AddOperateBatchTask(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.ProcOpBatchTask)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("add operate process batch task failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	ts := time.Now().UTC()
+	input.OwnerID = util.GetOwnerID(req.Request.Header)
+	input.User = util.GetUser(req.Request.Header)
+	input.CreateTime = ts
+	input.LastTime = ts
+	err := ps.Instance.Table(common.BKTableNameProcOpBatchTask).Insert(ctx, input)
+	if nil != err {
+		blog.Errorf("add operate process batch task %s to db failed, error:%s", input.TaskID, err.Error())
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// UpdateOperateBatchTask replace the rolling task with the same task id by the request body
+func (ps *ProctrlServer) UpdateOperateBatchTask(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.ProcOpBatchTask)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("update operate process batch task failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if "" == input.TaskID {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, common.BKTaskIDField)})
+		return
+	}
+
+	cond := mapstr.MapStr{common.BKTaskIDField: input.TaskID}
+	cond = util.SetModOwner(cond, util.GetOwnerID(req.Request.Header))
+	input.OwnerID = util.GetOwnerID(req.Request.Header)
+	input.LastTime = time.Now().UTC()
+	err := ps.Instance.Table(common.BKTableNameProcOpBatchTask).Update(ctx, cond, input)
+	if nil != err {
+		blog.Errorf("update operate process batch task %s to db failed, error:%s", input.TaskID, err.Error())
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+func (ps *ProctrlServer) SearchOperateBatchTask(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("search operate process batch task failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	input.Condition = util.SetModOwner(input.Condition, util.GetOwnerID(req.Request.Header))
+	cnt, err := ps.Instance.Table(common.BKTableNameProcOpBatchTask).Find(input.Condition).Count(ctx)
+	if err != nil {
+		blog.Errorf("search operate process batch task failed. err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	data := make([]meta.ProcOpBatchTask, 0)
+	err = ps.Instance.Table(common.BKTableNameProcOpBatchTask).Find(input.Condition).Fields(strings.Split(input.Fields, ",")...).
+		Sort(input.Sort).Start(uint64(input.Start)).Limit(uint64(input.Limit)).All(ctx, &data)
+	if err != nil {
+		blog.Errorf("search operate process batch task failed. err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	ret := meta.ProcOpBatchTaskResult{
+		BaseResp: meta.SuccessBaseResp,
+	}
+	ret.Data.Info = data
+	ret.Data.Count = int(cnt)
+	resp.WriteEntity(ret)
+}
@@ -0,0 +1,167 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/eventclient"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+	"github.com/gin-gonic/gin/json"
+)
+
+// SetProcInstanceState save the checked state of the process instances, an event is pushed
+// for every instance whose state is changed
+func (ps *ProctrlServer) SetProcInstanceState(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+	ownerID := util.GetOwnerID(req.Request.Header)
+
+	input := make([]meta.ProcInstanceState, 0)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("set process instance state failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	for _, state := range input {
+		// the states of all the owners are checked together by the super owner
+		if common.BKSuperOwnerID != ownerID || "" == state.OwnerID {
+			state.OwnerID = ownerID
+		}
+		cond := map[string]interface{}{
+			common.BKAppIDField:     state.AppID,
+			common.BKModuleIDField:  state.ModuleID,
+			common.BKProcessIDField: state.ProcID,
+			common.BKHostIDField:    state.HostID,
+		}
+		cond = util.SetModOwner(cond, ownerID)
+		existing := make([]meta.ProcInstanceState, 0)
+		if err := ps.Instance.Table(common.BKTableNameProcInstanceState).Find(cond).All(ctx, &existing); err != nil {
+			blog.Errorf("set process instance state, get state %+v failed, err: %v", cond, err)
+			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+			return
+		}
+
+		var preData interface{}
+		action := meta.EventActionCreate
+		state.ChangeTime = state.CheckTime
+		if 0 < len(existing) {
+			pre := existing[0]
+			preData = pre
+			action = meta.EventActionUpdate
+			if pre.State == state.State {
+				state.ChangeTime = pre.ChangeTime
+			}
+			if nil == state.LastSeen {
+				state.LastSeen = pre.LastSeen
+			}
+		}
+
+		var err error
+		if 0 == len(existing) {
+			err = ps.Instance.Table(common.BKTableNameProcInstanceState).Insert(ctx, state)
+		} else {
+			err = ps.Instance.Table(common.BKTableNameProcInstanceState).Update(ctx, cond, state)
+		}
+		if nil != err {
+			blog.Errorf("set process instance state %+v failed, err: %v", cond, err)
+			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+			return
+		}
+
+		if 0 < len(existing) && existing[0].State == state.State {
+			continue
+		}
+		srcevent := eventclient.NewEventWithHeader(req.Request.Header)
+		srcevent.OwnerID = state.OwnerID
+		srcevent.EventType = meta.EventTypeRelation
+		srcevent.ObjType = meta.EventObjTypeProcState
+		srcevent.Action = action
+		srcevent.Data = []meta.EventData{
+			{
+				CurData: state,
+				PreData: preData,
+			},
+		}
+		if err := ps.EventC.Push(ctx, srcevent); err != nil {
+			blog.Errorf("set process instance state %+v, push event failed, err: %v", cond, err)
+		}
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+func (ps *ProctrlServer) SearchProcInstanceState(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("search process instance state failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	input.Condition = util.SetModOwner(input.Condition, util.GetOwnerID(req.Request.Header))
+	cnt, err := ps.Instance.Table(common.BKTableNameProcInstanceState).Find(input.Condition).Count(ctx)
+	if err != nil {
+		blog.Errorf("search process instance state failed. err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	data := make([]meta.ProcInstanceState, 0)
+	err = ps.Instance.Table(common.BKTableNameProcInstanceState).Find(input.Condition).Fields(strings.Split(input.Fields, ",")...).
+		Sort(input.Sort).Start(uint64(input.Start)).Limit(uint64(input.Limit)).All(ctx, &data)
+	if err != nil {
+		blog.Errorf("search process instance state failed. err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	ret := meta.ProcInstanceStateResult{
+		BaseResp: meta.SuccessBaseResp,
+	}
+	ret.Data.Info = data
+	ret.Data.Count = int(cnt)
+	resp.WriteEntity(ret)
+}
+
+func (ps *ProctrlServer) DeleteProcInstanceState(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := make(map[string]interface{}, 0)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("delete process instance state failed, decode request body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	input = util.SetModOwner(input, util.GetOwnerID(req.Request.Header))
+	if err := ps.Instance.Table(common.BKTableNameProcInstanceState).Delete(ctx, input); nil != err {
+		blog.Errorf("delete process instance state error: %s, input:%v", err.Error(), input)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
@@ -65,10 +65,26 @@ func (ps *ProctrlServer) WebService() *restful.Container {
 	api.Route(api.PUT("/instance/register/detail").To(ps.ModifyRegisterProcInstanceDetail))
 	api.Route(api.POST("/instance/register/detail/search").To(ps.GetProcInstanceDetail))
 	api.Route(api.DELETE("/instance/register/detail").To(ps.DeleteRegisterProcInstanceDetail))
+	api.Route(api.POST("/instance/state").To(ps.SetProcInstanceState))
+	api.Route(api.POST("/instance/state/search").To(ps.SearchProcInstanceState))
+	api.Route(api.DELETE("/instance/state").To(ps.DeleteProcInstanceState))
 
 	api.Route(api.POST("/operate/task").To(ps.AddOperateTaskInfo))
 	api.Route(api.PUT("/operate/task").To(ps.UpdateOperateTaskInfo))
 	api.Route(api.POST("/operate/task/search").To(ps.SearchOperateTaskInfo))
+	api.Route(api.POST("/operate/batch/task").To(ps.AddOperateBatchTask))
+	api.Route(api.PUT("/operate/batch/task").To(ps.UpdateOperateBatchTask))
+	api.Route(api.POST("/operate/batch/task/search").To(ps.SearchOperateBatchTask))
+
+	api.Route(api.POST("/config/file").To(ps.CreateConfigFile))
+	api.Route(api.PUT("/config/file").To(ps.UpdateConfigFile))
+	api.Route(api.POST("/config/file/search").To(ps.SearchConfigFile))
+	api.Route(api.DELETE("/config/file").To(ps.DeleteConfigFile))
+
+	api.Route(api.POST("/template/variable").To(ps.CreateTemplateVariable))
+	api.Route(api.PUT("/template/variable").To(ps.UpdateTemplateVariable))
+	api.Route(api.POST("/template/variable/search").To(ps.SearchTemplateVariable))
+	api.Route(api.DELETE("/template/variable").To(ps.DeleteTemplateVariable))
 
 	container.Add(api)
 
@@ -0,0 +1,158 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+	"github.com/gin-gonic/gin/json"
+)
+
+func (ps *ProctrlServer) CreateTemplateVariable(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.ProcTemplateVariable)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("create template variable failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	id, err := ps.Instance.NextSequence(ctx, common.BKTableNameProcTemplateVariable)
+	if nil != err {
+		blog.Errorf("create template variable %s failed, get id error:%s", input.Name, err.Error())
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+	ts := time.Now().UTC()
+	input.ID = int64(id)
+	input.OwnerID = util.GetOwnerID(req.Request.Header)
+	input.Creator = util.GetUser(req.Request.Header)
+	input.Modifier = input.Creator
+	input.CreateTime = ts
+	input.LastTime = ts
+	if nil == input.Overrides {
+		input.Overrides = make([]meta.ProcVariableOverride, 0)
+	}
+	err = ps.Instance.Table(common.BKTableNameProcTemplateVariable).Insert(ctx, input)
+	if nil != err {
+		blog.Errorf("create template variable %s to db failed, error:%s", input.Name, err.Error())
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(input))
+}
+
+// UpdateTemplateVariable replace the template variable with the same id by the request body
+func (ps *ProctrlServer) UpdateTemplateVariable(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.ProcTemplateVariable)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("update template variable failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 == input.ID {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, meta.ProcTemplateVariableFieldID)})
+		return
+	}
+
+	cond := mapstr.MapStr{meta.ProcTemplateVariableFieldID: input.ID}
+	cond = util.SetModOwner(cond, util.GetOwnerID(req.Request.Header))
+	input.OwnerID = util.GetOwnerID(req.Request.Header)
+	input.Modifier = util.GetUser(req.Request.Header)
+	input.LastTime = time.Now().UTC()
+	if nil == input.Overrides {
+		input.Overrides = make([]meta.ProcVariableOverride, 0)
+	}
+	err := ps.Instance.Table(common.BKTableNameProcTemplateVariable).Update(ctx, cond, input)
+	if nil != err {
+		blog.Errorf("update template variable %d to db failed, error:%s", input.ID, err.Error())
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+func (ps *ProctrlServer) SearchTemplateVariable(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("search template variable failed! decode request body err: %s", err.Error())
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	input.Condition = util.SetModOwner(input.Condition, util.GetOwnerID(req.Request.Header))
+	cnt, err := ps.Instance.Table(common.BKTableNameProcTemplateVariable).Find(input.Condition).Count(ctx)
+	if err != nil {
+		blog.Errorf("search template variable failed. err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	data := make([]meta.ProcTemplateVariable, 0)
+	err = ps.Instance.Table(common.BKTableNameProcTemplateVariable).Find(input.Condition).Fields(strings.Split(input.Fields, ",")...).
+		Sort(input.Sort).Start(uint64(input.Start)).Limit(uint64(input.Limit)).All(ctx, &data)
+	if err != nil {
+		blog.Errorf("search template variable failed. err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	ret := meta.ProcTemplateVariableResult{
+		BaseResp: meta.SuccessBaseResp,
+	}
+	ret.Data.Info = data
+	ret.Data.Count = int(cnt)
+	resp.WriteEntity(ret)
+}
+
+func (ps *ProctrlServer) DeleteTemplateVariable(req *restful.Request, resp *restful.Response) {
+	language := util.GetLanguage(req.Request.Header)
+	defErr := ps.Core.CCErr.CreateDefaultCCErrorIf(language)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := make(map[string]interface{}, 0)
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("delete template variable failed, decode request body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	input = util.SetModOwner(input, util.GetOwnerID(req.Request.Header))
+	err := ps.Instance.Table(common.BKTableNameProcTemplateVariable).Delete(ctx, input)
+	if nil != err {
+		blog.Errorf("delete template variable error: %s, input:%v", err.Error(), input)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
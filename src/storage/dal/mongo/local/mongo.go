@@ -154,7 +154,9 @@ func (f *Find) Limit(limit uint64) dal.Find {
 }
 
 // All 查询多个
-func (f *Find) All(ctx context.Context, result interface{}) error {
+func (f *Find) All(ctx context.Context, result interface{}) (err error) {
+	span := dal.StartSpan(ctx, "find", f.collName)
+	defer func() { dal.FinishSpan(span, err) }()
 	f.dbc.Refresh()
 	query := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter)
 	query = query.Select(f.projection)
@@ -165,10 +167,12 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 }
 
 // One 查询一个
-func (f *Find) One(ctx context.Context, result interface{}) error {
+func (f *Find) One(ctx context.Context, result interface{}) (err error) {
+	span := dal.StartSpan(ctx, "findOne", f.collName)
+	defer func() { dal.FinishSpan(span, err) }()
 	f.dbc.Refresh()
 
-	err := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter).One(result)
+	err = f.dbc.DB(f.dbname).C(f.collName).Find(f.filter).One(result)
 	if err == mgo.ErrNotFound {
 		err = dal.ErrDocumentNotFound
 	}
@@ -176,34 +180,44 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 }
 
 // Count 统计数量(非事务)
-func (f *Find) Count(ctx context.Context) (uint64, error) {
-	count, err := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter).Count()
-	return uint64(count), err
+func (f *Find) Count(ctx context.Context) (count uint64, err error) {
+	span := dal.StartSpan(ctx, "count", f.collName)
+	defer func() { dal.FinishSpan(span, err) }()
+	total, err := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter).Count()
+	return uint64(total), err
 }
 
 // Insert 插入数据, docs 可以为 单个数据 或者 多个数据
-func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
+func (c *Collection) Insert(ctx context.Context, docs interface{}) (err error) {
+	span := dal.StartSpan(ctx, "insert", c.collName)
+	defer func() { dal.FinishSpan(span, err) }()
 	c.dbc.Refresh()
 	return c.dbc.DB(c.dbname).C(c.collName).Insert(util.ConverToInterfaceSlice(docs)...)
 }
 
 // Update 更新数据
-func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interface{}) (err error) {
+	span := dal.StartSpan(ctx, "update", c.collName)
+	defer func() { dal.FinishSpan(span, err) }()
 	c.dbc.Refresh()
 	data := bson.M{"$set": doc}
-	_, err := c.dbc.DB(c.dbname).C(c.collName).UpdateAll(filter, data)
+	_, err = c.dbc.DB(c.dbname).C(c.collName).UpdateAll(filter, data)
 	return err
 }
 
 // Delete 删除数据
-func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
+func (c *Collection) Delete(ctx context.Context, filter dal.Filter) (err error) {
+	span := dal.StartSpan(ctx, "delete", c.collName)
+	defer func() { dal.FinishSpan(span, err) }()
 	c.dbc.Refresh()
-	_, err := c.dbc.DB(c.dbname).C(c.collName).RemoveAll(filter)
+	_, err = c.dbc.DB(c.dbname).C(c.collName).RemoveAll(filter)
 	return err
 }
 
 // NextSequence 获取新序列号(非事务)
-func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (sequence uint64, err error) {
+	span := dal.StartSpan(ctx, "nextSequence", "cc_idgenerator")
+	defer func() { dal.FinishSpan(span, err) }()
 	c.dbc.Refresh()
 	coll := c.dbc.DB(c.dbname).C("cc_idgenerator")
 	change := mgo.Change{
@@ -217,7 +231,7 @@ func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (uint64,
 	}
 	doc := Idgen{}
 
-	_, err := coll.Find(bson.M{"_id": sequenceName}).Apply(change, &doc)
+	_, err = coll.Find(bson.M{"_id": sequenceName}).Apply(change, &doc)
 	if err != nil {
 		return 0, err
 	}
@@ -357,11 +371,15 @@ func (c *Collection) DropColumn(ctx context.Context, field string) error {
 }
 
 // AggregateAll aggregate all operation
-func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) (err error) {
+	span := dal.StartSpan(ctx, "aggregate", c.collName)
+	defer func() { dal.FinishSpan(span, err) }()
 	return c.dbc.DB(c.dbname).C(c.collName).Pipe(pipeline).All(result)
 }
 
 // AggregateOne aggregate one operation
-func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) (err error) {
+	span := dal.StartSpan(ctx, "aggregateOne", c.collName)
+	defer func() { dal.FinishSpan(span, err) }()
 	return c.dbc.DB(c.dbname).C(c.collName).Pipe(pipeline).One(result)
 }
@@ -47,7 +47,9 @@ func (c *Collection) Find(filter dal.Filter) dal.Find {
 }
 
 // Update 更新数据
-func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interface{}) (err error) {
+	span := dal.StartSpan(ctx, "update", c.collection)
+	defer func() { dal.FinishSpan(span, err) }()
 	// build msg
 	msg := types.OPUpdateOperation{}
 	msg.OPCode = types.OPUpdateCode
@@ -71,7 +73,7 @@ func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interfac
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err = c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -82,7 +84,9 @@ func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interfac
 }
 
 // Delete 删除数据
-func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
+func (c *Collection) Delete(ctx context.Context, filter dal.Filter) (err error) {
+	span := dal.StartSpan(ctx, "delete", c.collection)
+	defer func() { dal.FinishSpan(span, err) }()
 	// build msg
 	msg := types.OPDeleteOperation{}
 	msg.OPCode = types.OPDeleteCode
@@ -103,7 +107,7 @@ func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err = c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -114,7 +118,9 @@ func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
 }
 
 // Insert 插入数据, docs 可以为 单个数据 或者 多个数据
-func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
+func (c *Collection) Insert(ctx context.Context, docs interface{}) (err error) {
+	span := dal.StartSpan(ctx, "insert", c.collection)
+	defer func() { dal.FinishSpan(span, err) }()
 
 	// build msg
 	msg := types.OPInsertOperation{}
@@ -137,7 +143,7 @@ func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err = c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -178,7 +184,9 @@ func (c *Collection) DropColumn(ctx context.Context, field string) error {
 }
 
 // AggregateOne 聚合查询
-func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) (err error) {
+	span := dal.StartSpan(ctx, "aggregateOne", c.collection)
+	defer func() { dal.FinishSpan(span, err) }()
 	// build msg
 	msg := types.OPAggregateOperation{}
 	msg.OPCode = types.OPAggregateCode
@@ -200,7 +208,7 @@ func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, res
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, msg, &reply)
+	err = c.rpc.Call(types.CommandRDBOperation, msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -215,6 +223,8 @@ func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, res
 }
 
 // AggregateAll 聚合查询
-func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) (err error) {
+	span := dal.StartSpan(ctx, "aggregate", c.collection)
+	defer func() { dal.FinishSpan(span, err) }()
 	return dal.ErrNotImplemented
 }
@@ -56,7 +56,9 @@ func (f *Find) Limit(limit uint64) dal.Find {
 }
 
 // All 查询多个
-func (f *Find) All(ctx context.Context, result interface{}) error {
+func (f *Find) All(ctx context.Context, result interface{}) (err error) {
+	span := dal.StartSpan(ctx, "find", f.collection)
+	defer func() { dal.FinishSpan(span, err) }()
 	// set txn
 	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
 	if ok {
@@ -69,7 +71,7 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 
 	// call
 	reply := types.OPReply{}
-	err := f.rpc.Call(types.CommandRDBOperation, f.msg, &reply)
+	err = f.rpc.Call(types.CommandRDBOperation, f.msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -80,7 +82,9 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 }
 
 // One 查询一个
-func (f *Find) One(ctx context.Context, result interface{}) error {
+func (f *Find) One(ctx context.Context, result interface{}) (err error) {
+	span := dal.StartSpan(ctx, "findOne", f.collection)
+	defer func() { dal.FinishSpan(span, err) }()
 	// set txn
 	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
 	if ok {
@@ -93,7 +97,7 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 
 	// call
 	reply := types.OPReply{}
-	err := f.rpc.Call(types.CommandRDBOperation, f.msg, &reply)
+	err = f.rpc.Call(types.CommandRDBOperation, f.msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -108,7 +112,9 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 }
 
 // Count 统计数量(非事务)
-func (f *Find) Count(ctx context.Context) (uint64, error) {
+func (f *Find) Count(ctx context.Context) (count uint64, err error) {
+	span := dal.StartSpan(ctx, "count", f.collection)
+	defer func() { dal.FinishSpan(span, err) }()
 	// build msg
 	f.msg.OPCode = types.OPCountCode
 
@@ -124,7 +130,7 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 
 	// call
 	reply := types.OPReply{}
-	err := f.rpc.Call(types.CommandRDBOperation, f.msg, &reply)
+	err = f.rpc.Call(types.CommandRDBOperation, f.msg, &reply)
 	if err != nil {
 		return 0, err
 	}
@@ -140,7 +140,9 @@ func (c *Mongo) Table(collection string) dal.Table {
 }
 
 // NextSequence 获取新序列号(非事务)
-func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (sequence uint64, err error) {
+	span := dal.StartSpan(ctx, "nextSequence", "cc_idgenerator")
+	defer func() { dal.FinishSpan(span, err) }()
 	// build msg
 	msg := types.OPFindAndModifyOperation{}
 	msg.OPCode = types.OPFindAndModifyCode
@@ -169,7 +171,7 @@ func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (uint64,
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err = c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return 0, err
 	}
@@ -13,9 +13,12 @@
 package redis
 
 import (
+	"context"
 	"strconv"
 	"strings"
 
+	"configcenter/src/common/trace"
+
 	redis "gopkg.in/redis.v5"
 )
 
@@ -83,3 +86,35 @@ func NewFromConfig(cfg Config) (*redis.Client, error) {
 func IsNilErr(err error) bool {
 	return redis.Nil == err
 }
+
+// Trace returns a copy of the client whose commands are traced as the children of the span in the ctx,
+// the client is returned as it is if the spans are not exported or there is no span in the ctx, so that
+// the background jobs do not start a trace for each command.
+func Trace(ctx context.Context, client *redis.Client) *redis.Client {
+	if client == nil || !trace.Enabled() || !trace.SpanContextFromContext(ctx).IsValid() {
+		return client
+	}
+	traced := client.WithContext(ctx)
+	traced.WrapProcess(func(process func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			span := trace.StartDBSpan(ctx, "redis", commandName(cmd), "")
+			err := process(cmd)
+			if IsNilErr(err) {
+				span.Finish(nil)
+			} else {
+				span.Finish(err)
+			}
+			return err
+		}
+	})
+	return traced
+}
+
+// commandName get the name of the command, the arguments are not recorded as they may be sensitive.
+func commandName(cmd redis.Cmder) string {
+	name := cmd.String()
+	if idx := strings.IndexAny(name, " :"); idx > 0 {
+		name = name[:idx]
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,33 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal
+
+import (
+	"context"
+
+	"configcenter/src/common/trace"
+)
+
+// StartSpan start the span of a mongodb operation on the collection, the parent is the span in the ctx,
+// which is set by util.GetDBContext from the request header.
+func StartSpan(ctx context.Context, operation, collection string) *trace.Span {
+	return trace.StartDBSpan(ctx, "mongodb", operation, collection)
+}
+
+// FinishSpan end the span of a mongodb operation, the document not found error is not a failure.
+func FinishSpan(span *trace.Span, err error) {
+	if err == ErrDocumentNotFound {
+		err = nil
+	}
+	span.Finish(err)
+}
@@ -65,8 +65,8 @@ func (p *gse) QueryProcOperateResult(ctx context.Context, h http.Header, taskID
 	return
 }
 
-func (p *gse) QueryProcStatus(ctx context.Context, h http.Header, data *metadata.GseProcRequest) (resp *metadata.EsbResponse, err error) {
-	resp = new(metadata.EsbResponse)
+func (p *gse) QueryProcStatus(ctx context.Context, h http.Header, data *metadata.GseProcRequest) (resp *metadata.GseProcStatusResult, err error) {
+	resp = new(metadata.GseProcStatusResult)
 	subPath := "/v2/gse/get_proc_status/"
 	type esbParams struct {
 		*esbutil.EsbCommParams
@@ -132,3 +132,51 @@ func (p *gse) UnRegisterProcInfo(ctx context.Context, h http.Header, data *metad
 
 	return
 }
+
+// PushConfigFile push the config files to the hosts, the result of each host is in the data
+func (p *gse) PushConfigFile(ctx context.Context, h http.Header, data *metadata.GsePushConfigFileRequest) (resp *metadata.GseConfigFileResult, err error) {
+	resp = new(metadata.GseConfigFileResult)
+	subPath := "/v2/gse/push_config_file/"
+	type esbParams struct {
+		*esbutil.EsbCommParams
+		*metadata.GsePushConfigFileRequest `json:",inline"`
+	}
+	params := &esbParams{
+		EsbCommParams:            esbutil.GetEsbRequestParams(p.config.GetConfig(), h),
+		GsePushConfigFileRequest: data,
+	}
+
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(params).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
+
+// GetConfigFileContent get the content of the file on the hosts
+func (p *gse) GetConfigFileContent(ctx context.Context, h http.Header, data *metadata.GseGetConfigFileRequest) (resp *metadata.GseConfigFileResult, err error) {
+	resp = new(metadata.GseConfigFileResult)
+	subPath := "/v2/gse/get_config_file_content/"
+	type esbParams struct {
+		*esbutil.EsbCommParams
+		*metadata.GseGetConfigFileRequest `json:",inline"`
+	}
+	params := &esbParams{
+		EsbCommParams:           esbutil.GetEsbRequestParams(p.config.GetConfig(), h),
+		GseGetConfigFileRequest: data,
+	}
+
+	err = p.client.Post().
+		WithContext(ctx).
+		Body(params).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	return
+}
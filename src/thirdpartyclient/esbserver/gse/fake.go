@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"configcenter/src/common/metadata"
+)
+
+// FakeGse is a GseClientInterface which keeps the process status in memory, it's used in tests
+// instead of the gse behind esb. all the other operations succeed without doing anything.
+type FakeGse struct {
+	lock   sync.RWMutex
+	status map[string]metadata.GseProcStatus
+	// StatusErr is returned by QueryProcStatus if it's not nil
+	StatusErr error
+}
+
+var _ GseClientInterface = (*FakeGse)(nil)
+
+// NewFakeGse create a fake gse client without any process
+func NewFakeGse() *FakeGse {
+	return &FakeGse{status: make(map[string]metadata.GseProcStatus)}
+}
+
+func fakeGseProcKey(meta metadata.GseProcMeta, host metadata.GseHost) string {
+	return fmt.Sprintf("%s:%s:%d:%s", meta.Namespace, meta.Name, host.BkCloudId, host.Ip)
+}
+
+// SetProcStatus set the status of a process on a host
+func (f *FakeGse) SetProcStatus(status metadata.GseProcStatus) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.status[fakeGseProcKey(status.Meta, status.Host)] = status
+}
+
+// RemoveProc remove the process on a host, gse knows nothing about it after that
+func (f *FakeGse) RemoveProc(meta metadata.GseProcMeta, host metadata.GseHost) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.status, fakeGseProcKey(meta, host))
+}
+
+func (f *FakeGse) OperateProcess(ctx context.Context, h http.Header, data *metadata.GseProcRequest) (*metadata.EsbResponse, error) {
+	return &metadata.EsbResponse{EsbBaseResponse: metadata.EsbBaseResponse{Result: true}}, nil
+}
+
+func (f *FakeGse) QueryProcOperateResult(ctx context.Context, h http.Header, taskid string) (*metadata.GseProcessOperateTaskResult, error) {
+	return &metadata.GseProcessOperateTaskResult{EsbBaseResponse: metadata.EsbBaseResponse{Result: true}}, nil
+}
+
+func (f *FakeGse) QueryProcStatus(ctx context.Context, h http.Header, data *metadata.GseProcRequest) (*metadata.GseProcStatusResult, error) {
+	if f.StatusErr != nil {
+		return nil, f.StatusErr
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	resp := &metadata.GseProcStatusResult{EsbBaseResponse: metadata.EsbBaseResponse{Result: true}}
+	resp.Data.ProcInfos = make([]metadata.GseProcStatus, 0)
+	for _, host := range data.Hosts {
+		if status, ok := f.status[fakeGseProcKey(data.Meta, host)]; ok {
+			resp.Data.ProcInfos = append(resp.Data.ProcInfos, status)
+		}
+	}
+	return resp, nil
+}
+
+func (f *FakeGse) RegisterProcInfo(ctx context.Context, h http.Header, data *metadata.GseProcRequest) (*metadata.EsbResponse, error) {
+	return &metadata.EsbResponse{EsbBaseResponse: metadata.EsbBaseResponse{Result: true}}, nil
+}
+
+func (f *FakeGse) UnRegisterProcInfo(ctx context.Context, h http.Header, data *metadata.GseProcRequest) (*metadata.EsbResponse, error) {
+	return &metadata.EsbResponse{EsbBaseResponse: metadata.EsbBaseResponse{Result: true}}, nil
+}
+
+func (f *FakeGse) PushConfigFile(ctx context.Context, h http.Header, data *metadata.GsePushConfigFileRequest) (*metadata.GseConfigFileResult, error) {
+	return &metadata.GseConfigFileResult{EsbBaseResponse: metadata.EsbBaseResponse{Result: true}}, nil
+}
+
+func (f *FakeGse) GetConfigFileContent(ctx context.Context, h http.Header, data *metadata.GseGetConfigFileRequest) (*metadata.GseConfigFileResult, error) {
+	return &metadata.GseConfigFileResult{EsbBaseResponse: metadata.EsbBaseResponse{Result: true}}, nil
+}
@@ -17,3 +17,6 @@ var ResourcePath string = "/tmp"
 const API_VERSION = "v3"
 
 const IsSkipLogin = "skiplogin"
+
+// LoginRedirectURL the url which the user is redirected to after logged in by the login page
+const LoginRedirectURL = "login_c_url"
@@ -45,7 +45,7 @@ func ValidLogin(config options.Config, disc discovery.DiscoveryInterface) gin.Ha
 		path1 := pathArr[1]
 
 		switch path1 {
-		case "healthz", "metrics":
+		case "healthz", "metrics", "login":
 			c.Next()
 			return
 		}
@@ -13,7 +13,14 @@
 package manager
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"configcenter/src/common"
 	"configcenter/src/common/metadata"
+
+	"github.com/gin-gonic/gin"
 )
 
 var (
@@ -23,3 +30,34 @@ var (
 func RegisterPlugin(params *metadata.LoginPluginInfo) {
 	LoginPluginInfo = append(LoginPluginInfo, params)
 }
+
+// NewLoginToken generate a random token, which is used as the bk_token of the user
+// logged in by a plugin without the blueking login system.
+// the cookie is only sent over https, unless session.cookie_secure is false for the plain http deployments.
+func NewLoginToken(c *gin.Context, config map[string]string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	secure := "false" != strings.ToLower(strings.TrimSpace(config["session.cookie_secure"]))
+	c.SetCookie(common.HTTPCookieBKToken, token, 0, "/", "", secure, true)
+	return token, nil
+}
+
+// MapRole map the groups of a user to the cmdb role by the role mapping config,
+// such as "cmdb-admin:1,cmdb-dev:2", the first matched one in the mapping wins.
+func MapRole(mapping string, groups []string, defaultRole string) string {
+	for _, item := range strings.Split(mapping, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		for _, group := range groups {
+			if strings.EqualFold(strings.TrimSpace(kv[0]), group) {
+				return strings.TrimSpace(kv[1])
+			}
+		}
+	}
+	return defaultRole
+}
@@ -41,3 +41,14 @@ func CurrentPlugin(c *gin.Context, version string) metadata.LoginUserPluginInerf
 
 	return nil
 }
+
+// IsBuiltinPlugin check whether the login plugin of the version is built in,
+// otherwise the plugin should be loaded from login.so
+func IsBuiltinPlugin(version string) bool {
+	for _, plugin := range manager.LoginPluginInfo {
+		if plugin.Version == version {
+			return true
+		}
+	}
+	return false
+}
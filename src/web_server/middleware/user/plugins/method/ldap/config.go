@@ -0,0 +1,144 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// ldapConfig the config of the ldap login, which is read from the [ldap] section of the config file.
+type ldapConfig struct {
+	// URL the address of the ldap server, such as ldap://127.0.0.1:389 or ldaps://127.0.0.1:636
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+
+	// BindDN and BindPassword are used to search the user, anonymous bind is used if they are empty.
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter the filter to search the user by the user name, %s is replaced by the escaped user name.
+	UserFilter string
+	// UserListFilter the filter to search the user list.
+	UserListFilter string
+
+	UsernameAttribute string
+	ChNameAttribute   string
+	EmailAttribute    string
+	PhoneAttribute    string
+	GroupAttribute    string
+
+	// RoleMapping map the groups of the user to the cmdb role, such as "cn=cmdb-admin,ou=groups,dc=example,dc=com:1".
+	RoleMapping string
+	DefaultRole string
+	Language    string
+	SiteURL     string
+}
+
+func parseConfig(config map[string]string) (*ldapConfig, error) {
+	cfg := &ldapConfig{
+		URL:                config["ldap.url"],
+		StartTLS:           "true" == config["ldap.start_tls"],
+		InsecureSkipVerify: "true" == config["ldap.insecure_skip_verify"],
+		BindDN:             config["ldap.bind_dn"],
+		BindPassword:       config["ldap.bind_password"],
+		BaseDN:             config["ldap.base_dn"],
+		UserFilter:         "(uid=%s)",
+		UserListFilter:     "(objectClass=person)",
+		UsernameAttribute:  "uid",
+		ChNameAttribute:    "cn",
+		EmailAttribute:     "mail",
+		PhoneAttribute:     "telephoneNumber",
+		GroupAttribute:     "memberOf",
+		RoleMapping:        config["ldap.role_mapping"],
+		DefaultRole:        config["ldap.default_role"],
+		Language:           config["session.defaultlanguage"],
+		SiteURL:            strings.TrimSuffix(config["site.domain_url"], "/"),
+	}
+
+	if "" == cfg.URL {
+		return nil, errors.New("config ldap.url not found")
+	}
+	if "" == cfg.BaseDN {
+		return nil, errors.New("config ldap.base_dn not found")
+	}
+	if filter := config["ldap.user_filter"]; "" != filter {
+		if !strings.Contains(filter, "%s") {
+			return nil, fmt.Errorf("config ldap.user_filter %s has no %%s for the user name", filter)
+		}
+		cfg.UserFilter = filter
+	}
+	if filter := config["ldap.user_list_filter"]; "" != filter {
+		cfg.UserListFilter = filter
+	}
+	if attr := config["ldap.username_attribute"]; "" != attr {
+		cfg.UsernameAttribute = attr
+	}
+	if attr := config["ldap.chname_attribute"]; "" != attr {
+		cfg.ChNameAttribute = attr
+	}
+	if attr := config["ldap.email_attribute"]; "" != attr {
+		cfg.EmailAttribute = attr
+	}
+	if attr := config["ldap.phone_attribute"]; "" != attr {
+		cfg.PhoneAttribute = attr
+	}
+	if attr := config["ldap.group_attribute"]; "" != attr {
+		cfg.GroupAttribute = attr
+	}
+	if "" == cfg.Language {
+		cfg.Language = "zh-cn"
+	}
+	return cfg, nil
+}
+
+func (cfg *ldapConfig) attributes() []string {
+	return []string{cfg.UsernameAttribute, cfg.ChNameAttribute, cfg.EmailAttribute, cfg.PhoneAttribute, cfg.GroupAttribute}
+}
+
+// dial connect to the ldap server, and bind with the service account.
+func (cfg *ldapConfig) dial() (*goldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	conn, err := goldap.DialURL(cfg.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}),
+		goldap.DialWithTLSConfig(tlsConfig))
+	if nil != err {
+		return nil, fmt.Errorf("connect to ldap server %s failed, err: %v", cfg.URL, err)
+	}
+	conn.SetTimeout(30 * time.Second)
+
+	if cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); nil != err {
+			conn.Close()
+			return nil, fmt.Errorf("start tls with ldap server %s failed, err: %v", cfg.URL, err)
+		}
+	}
+
+	if "" == cfg.BindDN {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(cfg.BindDN, cfg.BindPassword)
+	}
+	if nil != err {
+		conn.Close()
+		return nil, fmt.Errorf("bind to ldap server %s with %s failed, err: %v", cfg.URL, cfg.BindDN, err)
+	}
+	return conn, nil
+}
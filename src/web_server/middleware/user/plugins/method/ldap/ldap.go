@@ -0,0 +1,188 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"fmt"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	webCommon "configcenter/src/web_server/common"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+
+	"github.com/gin-gonic/gin"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/holmeswang/contrib/sessions"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "ldap login",
+		Version:    common.BKLDAPLoginUserPluginVersion,
+		HandleFunc: &user{},
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+const (
+	// the path of the login page which post the user name and password.
+	loginPath = "/login"
+
+	// the max number of users returned by the user list.
+	maxUserListSize = 1000
+)
+
+type user struct {
+}
+
+// LoginUser authenticate the user name and password posted by the login page with ldap bind.
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (*metadata.LoginUserInfo, bool) {
+	if c.Request.Method != http.MethodPost || c.Request.URL.Path != loginPath {
+		return nil, false
+	}
+	userName := c.PostForm("username")
+	password := c.PostForm("password")
+	// an empty password means an unauthenticated bind, which always succeeds.
+	if "" == userName || "" == password {
+		return nil, false
+	}
+
+	cfg, err := parseConfig(config)
+	if nil != err {
+		blog.Errorf("ldap login failed, err: %v", err)
+		return nil, false
+	}
+
+	conn, err := cfg.dial()
+	if nil != err {
+		blog.Errorf("ldap login failed, err: %v", err)
+		return nil, false
+	}
+	defer conn.Close()
+
+	filter := fmt.Sprintf(cfg.UserFilter, goldap.EscapeFilter(userName))
+	request := goldap.NewSearchRequest(cfg.BaseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 2, 0, false,
+		filter, cfg.attributes(), nil)
+	result, err := conn.Search(request)
+	if nil != err {
+		blog.Errorf("ldap login failed, search user by filter %s failed, err: %v", filter, err)
+		return nil, false
+	}
+	if len(result.Entries) != 1 {
+		blog.Errorf("ldap login failed, search user by filter %s got %d entries", filter, len(result.Entries))
+		return nil, false
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); nil != err {
+		blog.Errorf("ldap login failed, bind with user %s failed, err: %v", entry.DN, err)
+		return nil, false
+	}
+
+	userInfo := &metadata.LoginUserInfo{
+		UserName: entry.GetAttributeValue(cfg.UsernameAttribute),
+		ChName:   entry.GetAttributeValue(cfg.ChNameAttribute),
+		Email:    entry.GetAttributeValue(cfg.EmailAttribute),
+		Phone:    entry.GetAttributeValue(cfg.PhoneAttribute),
+		Role:     manager.MapRole(cfg.RoleMapping, groupNames(entry.GetAttributeValues(cfg.GroupAttribute)), cfg.DefaultRole),
+		OnwerUin: common.BKDefaultOwnerID,
+		Language: cfg.Language,
+	}
+	if "" == userInfo.UserName {
+		userInfo.UserName = userName
+	}
+	if "" == userInfo.ChName {
+		userInfo.ChName = userInfo.UserName
+	}
+
+	userInfo.BkToken, err = manager.NewLoginToken(c, config)
+	if nil != err {
+		blog.Errorf("ldap login failed, generate login token failed, err: %v", err)
+		return nil, false
+	}
+	return userInfo, true
+}
+
+// GetUserList search the users with the service account.
+func (m *user) GetUserList(c *gin.Context, config map[string]string) ([]*metadata.LoginSystemUserInfo, error) {
+	cfg, err := parseConfig(config)
+	if nil != err {
+		return nil, err
+	}
+
+	conn, err := cfg.dial()
+	if nil != err {
+		blog.Errorf("get ldap user list failed, err: %v", err)
+		return nil, err
+	}
+	defer conn.Close()
+
+	request := goldap.NewSearchRequest(cfg.BaseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, maxUserListSize, 0, false,
+		cfg.UserListFilter, []string{cfg.UsernameAttribute, cfg.ChNameAttribute}, nil)
+	result, err := conn.Search(request)
+	// the partial result is still returned when the size limit is exceeded.
+	if nil != err && !goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+		blog.Errorf("get ldap user list failed, search users by filter %s failed, err: %v", cfg.UserListFilter, err)
+		return nil, fmt.Errorf("search ldap users failed, err: %v", err)
+	}
+
+	userList := make([]*metadata.LoginSystemUserInfo, 0)
+	if nil == result {
+		return userList, nil
+	}
+	for _, entry := range result.Entries {
+		userName := entry.GetAttributeValue(cfg.UsernameAttribute)
+		if "" == userName {
+			continue
+		}
+		chName := entry.GetAttributeValue(cfg.ChNameAttribute)
+		if "" == chName {
+			chName = userName
+		}
+		userList = append(userList, &metadata.LoginSystemUserInfo{CnName: chName, EnName: userName})
+	}
+	return userList, nil
+}
+
+// GetLoginUrl return the url of the login page, the current url is kept in session to
+// redirect the user back after logged in.
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	siteURL := ""
+	if cfg, err := parseConfig(config); nil == err {
+		siteURL = cfg.SiteURL
+	}
+
+	if c.Request.URL.Path != loginPath {
+		session := sessions.Default(c)
+		session.Set(webCommon.LoginRedirectURL, c.Request.URL.String())
+		session.Save()
+	}
+	return siteURL + loginPath
+}
+
+// groupNames return both the dn and the first rdn value of the groups, so that the role
+// mapping can use either the dn or the cn of the groups.
+func groupNames(groups []string) []string {
+	names := make([]string, 0)
+	for _, group := range groups {
+		names = append(names, group)
+		dn, err := goldap.ParseDN(group)
+		if nil != err || 0 == len(dn.RDNs) || 0 == len(dn.RDNs[0].Attributes) {
+			continue
+		}
+		names = append(names, dn.RDNs[0].Attributes[0].Value)
+	}
+	return names
+}
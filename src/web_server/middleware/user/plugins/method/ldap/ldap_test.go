@@ -0,0 +1,200 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	testBindDN       = "cn=admin,dc=example,dc=com"
+	testBindPassword = "admin-secret"
+	testUserDN       = "uid=alice,ou=people,dc=example,dc=com"
+	testUserPassword = "alice-secret"
+)
+
+// fakeServer answers the bind and search requests of one user, which is just enough for the login.
+type fakeServer struct {
+	listener net.Listener
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	s := &fakeServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *fakeServer) Close() {
+	s.listener.Close()
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case goldap.ApplicationBindRequest:
+			dn := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := goldap.LDAPResultInvalidCredentials
+			if (dn == testBindDN && password == testBindPassword) || (dn == testUserDN && password == testUserPassword) {
+				code = goldap.LDAPResultSuccess
+			}
+			s.write(conn, messageID, newResult(goldap.ApplicationBindResponse, code))
+		case goldap.ApplicationSearchRequest:
+			// the filter is (uid=%s), the user is found by the value of the equality match.
+			filter := op.Children[6]
+			if len(filter.Children) == 2 && filter.Children[1].Value == "alice" {
+				s.write(conn, messageID, newUserEntry())
+			}
+			s.write(conn, messageID, newResult(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess))
+		case goldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func (s *fakeServer) write(conn net.Conn, messageID int64, op *ber.Packet) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "Message ID"))
+	packet.AppendChild(op)
+	conn.Write(packet.Bytes())
+}
+
+func newResult(tag ber.Tag, code int) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return op
+}
+
+func newUserEntry() *ber.Packet {
+	attrs := map[string]string{
+		"uid":      "alice",
+		"cn":       "Alice",
+		"mail":     "alice@example.com",
+		"memberOf": "cn=cmdb-admin,ou=groups,dc=example,dc=com",
+	}
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, testUserDN, "DN"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, value := range attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		attr.AppendChild(values)
+		attributes.AppendChild(attr)
+	}
+	op.AppendChild(attributes)
+	return op
+}
+
+func newLoginContext(method, path, userName, password string) (*gin.Context, *httptest.ResponseRecorder) {
+	form := url.Values{"username": {userName}, "password": {password}}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c, w
+}
+
+func TestLoginUser(t *testing.T) {
+	server := newFakeServer(t)
+	defer server.Close()
+
+	config := map[string]string{
+		"ldap.url":           server.URL(),
+		"ldap.bind_dn":       testBindDN,
+		"ldap.bind_password": testBindPassword,
+		"ldap.base_dn":       "dc=example,dc=com",
+		"ldap.role_mapping":  "cmdb-admin:1",
+		"ldap.default_role":  "2",
+	}
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		userName string
+		password string
+		config   map[string]string
+		ok       bool
+	}{
+		{"bind succeeds with the password", http.MethodPost, loginPath, "alice", testUserPassword, config, true},
+		{"bind fails with a wrong password", http.MethodPost, loginPath, "alice", "wrong", config, false},
+		{"user not found", http.MethodPost, loginPath, "bob", testUserPassword, config, false},
+		{"empty password is never an unauthenticated bind", http.MethodPost, loginPath, "alice", "", config, false},
+		{"only the posted login page logs in", http.MethodGet, loginPath, "alice", testUserPassword, config, false},
+		{"other pages never log in", http.MethodPost, "/host", "alice", testUserPassword, config, false},
+		{"service account bind fails", http.MethodPost, loginPath, "alice", testUserPassword, map[string]string{
+			"ldap.url":           server.URL(),
+			"ldap.bind_dn":       testBindDN,
+			"ldap.bind_password": "wrong",
+			"ldap.base_dn":       "dc=example,dc=com",
+		}, false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newLoginContext(tt.method, tt.path, tt.userName, tt.password)
+			userInfo, ok := (&user{}).LoginUser(c, tt.config, false)
+			if ok != tt.ok {
+				t.Fatalf("LoginUser() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if userInfo.UserName != "alice" || userInfo.ChName != "Alice" || userInfo.Email != "alice@example.com" {
+				t.Errorf("LoginUser() user info = %+v", userInfo)
+			}
+			if userInfo.Role != "1" {
+				t.Errorf("LoginUser() role = %s, want the role mapped from the group", userInfo.Role)
+			}
+			if userInfo.BkToken == "" || !strings.Contains(w.Header().Get("Set-Cookie"), userInfo.BkToken) {
+				t.Errorf("LoginUser() token %s is not set into the cookie %s", userInfo.BkToken, w.Header().Get("Set-Cookie"))
+			}
+		})
+	}
+}
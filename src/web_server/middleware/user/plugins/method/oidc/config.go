@@ -0,0 +1,153 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+)
+
+// oidcConfig the config of the openid connect login, which is read from the [oidc] section of the config file.
+type oidcConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL the callback url registered in the identity provider,
+	// it's the site domain url with the path /login/callback by default.
+	RedirectURL  string
+	SiteURL      string
+	HTTPSSiteURL string
+	Scopes       []string
+
+	// the claims which the user info is mapped from.
+	UsernameClaim string
+	ChNameClaim   string
+	EmailClaim    string
+	PhoneClaim    string
+	RoleClaim     string
+
+	// RoleMapping map the values of the role claim to the cmdb role, such as "cmdb-admin:1,cmdb-dev:2".
+	RoleMapping string
+	DefaultRole string
+	Language    string
+}
+
+func parseConfig(config map[string]string) (*oidcConfig, error) {
+	cfg := &oidcConfig{
+		Issuer:        strings.TrimSuffix(config["oidc.issuer"], "/"),
+		ClientID:      config["oidc.client_id"],
+		ClientSecret:  config["oidc.client_secret"],
+		RedirectURL:   config["oidc.redirect_url"],
+		SiteURL:       strings.TrimSuffix(config["site.domain_url"], "/"),
+		HTTPSSiteURL:  strings.TrimSuffix(config["site.https_domain_url"], "/"),
+		Scopes:        []string{"openid", "profile", "email"},
+		UsernameClaim: "preferred_username",
+		ChNameClaim:   "name",
+		EmailClaim:    "email",
+		PhoneClaim:    "phone_number",
+		RoleClaim:     config["oidc.role_claim"],
+		RoleMapping:   config["oidc.role_mapping"],
+		DefaultRole:   config["oidc.default_role"],
+		Language:      config["session.defaultlanguage"],
+	}
+
+	if "" == cfg.Issuer {
+		return nil, errors.New("config oidc.issuer not found")
+	}
+	if "" == cfg.ClientID {
+		return nil, errors.New("config oidc.client_id not found")
+	}
+	if scopes := strings.Fields(strings.Replace(config["oidc.scopes"], ",", " ", -1)); len(scopes) > 0 {
+		cfg.Scopes = scopes
+	}
+	if claim := config["oidc.username_claim"]; "" != claim {
+		cfg.UsernameClaim = claim
+	}
+	if claim := config["oidc.chname_claim"]; "" != claim {
+		cfg.ChNameClaim = claim
+	}
+	if claim := config["oidc.email_claim"]; "" != claim {
+		cfg.EmailClaim = claim
+	}
+	if claim := config["oidc.phone_claim"]; "" != claim {
+		cfg.PhoneClaim = claim
+	}
+	if "" == cfg.Language {
+		cfg.Language = "zh-cn"
+	}
+	return cfg, nil
+}
+
+func (cfg *oidcConfig) redirectURL(input *metadata.LogoutRequestParams) string {
+	if "" != cfg.RedirectURL {
+		return cfg.RedirectURL
+	}
+	if nil != input && common.LogoutHTTPSchemeHTTPS == input.HTTPScheme && "" != cfg.HTTPSSiteURL {
+		return cfg.HTTPSSiteURL + callbackPath
+	}
+	return cfg.SiteURL + callbackPath
+}
+
+// mapClaims map the claims of the id token and the user info endpoint to the user info
+func (cfg *oidcConfig) mapClaims(claims map[string]interface{}) *metadata.LoginUserInfo {
+	userInfo := &metadata.LoginUserInfo{
+		UserName: claimString(claims, cfg.UsernameClaim),
+		ChName:   claimString(claims, cfg.ChNameClaim),
+		Email:    claimString(claims, cfg.EmailClaim),
+		Phone:    claimString(claims, cfg.PhoneClaim),
+		Role:     cfg.DefaultRole,
+		OnwerUin: common.BKDefaultOwnerID,
+		Language: cfg.Language,
+	}
+	if "" == userInfo.ChName {
+		userInfo.ChName = userInfo.UserName
+	}
+	if "" != cfg.RoleClaim {
+		userInfo.Role = manager.MapRole(cfg.RoleMapping, claimStrings(claims, cfg.RoleClaim), cfg.DefaultRole)
+	}
+	return userInfo
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	val, ok := claims[name]
+	if !ok || nil == val {
+		return ""
+	}
+	if str, ok := val.(string); ok {
+		return str
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// claimStrings get the values of a claim which can be a string or a string array, such as groups.
+func claimStrings(claims map[string]interface{}, name string) []string {
+	switch val := claims[name].(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		result := make([]string, 0)
+		for _, item := range val {
+			if str, ok := item.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,216 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// jwksRefreshInterval the min interval of fetching the key set again when the key of an id token is not found,
+// the provider may have rotated its keys.
+const jwksRefreshInterval = 5 * time.Minute
+
+// jsonWebKey a public key of the provider, see https://tools.ietf.org/html/rfc7517
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if nil != err {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if nil != err {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if nil != err {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if nil != err {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func decodeBigInt(val string) (*big.Int, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(val, "="))
+	if nil != err {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
+
+// getKey get the signing key of the provider by the key id, the key set is fetched again
+// if the key is not found, but not more often than jwksRefreshInterval.
+func (p *providerConfig) getKey(kid string) (crypto.PublicKey, error) {
+	p.keysLock.Lock()
+	defer p.keysLock.Unlock()
+
+	if key := lookupKey(p.keys, kid); nil != key {
+		return key, nil
+	}
+	if nil != p.keys && time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("the signing key %s is not found", kid)
+	}
+
+	set := new(jsonWebKeySet)
+	if err := getJSON(p.JWKSURI, "", set); nil != err {
+		return nil, fmt.Errorf("get the key set failed, err: %v", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for idx := range set.Keys {
+		if "" != set.Keys[idx].Use && "sig" != set.Keys[idx].Use {
+			continue
+		}
+		key, err := set.Keys[idx].publicKey()
+		if nil != err {
+			continue
+		}
+		keys[set.Keys[idx].Kid] = key
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key := lookupKey(p.keys, kid); nil != key {
+		return key, nil
+	}
+	return nil, fmt.Errorf("the signing key %s is not found", kid)
+}
+
+// lookupKey find the key by id, the id token may have no key id when the key set has only one key.
+func lookupKey(keys map[string]crypto.PublicKey, kid string) crypto.PublicKey {
+	if key, exists := keys[kid]; exists {
+		return key
+	}
+	if "" == kid && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// verifyIDTokenSignature verify the signature of the id token with the key of the provider,
+// only the asymmetric algorithms are accepted.
+func verifyIDTokenSignature(idToken string, getKey func(kid string) (crypto.PublicKey, error)) error {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return errors.New("the id token is not a valid jwt")
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[0], "="))
+	if nil != err {
+		return fmt.Errorf("decode the id token header failed, err: %v", err)
+	}
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := json.Unmarshal(rawHeader, &header); nil != err {
+		return fmt.Errorf("unmarshal the id token header failed, err: %v", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if nil != err {
+		return fmt.Errorf("decode the id token signature failed, err: %v", err)
+	}
+
+	var hash crypto.Hash
+	switch header.Alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signing algorithm %s", header.Alg)
+	}
+
+	key, err := getKey(header.Kid)
+	if nil != err {
+		return err
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	digest := hasher.Sum(nil)
+
+	switch header.Alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("the key %s is not a rsa key", header.Kid)
+		}
+		if "RS" == header.Alg[:2] {
+			return rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature)
+		}
+		return rsa.VerifyPSS(rsaKey, hash, digest, signature, nil)
+	default:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("the key %s is not an ecdsa key", header.Kid)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("the id token signature has invalid length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("the id token signature is invalid")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	webCommon "configcenter/src/web_server/common"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+
+	"github.com/gin-gonic/gin"
+	"github.com/holmeswang/contrib/sessions"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "openid connect login",
+		Version:    common.BKOIDCLoginUserPluginVersion,
+		HandleFunc: &user{providers: make(map[string]*providerConfig)},
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+const (
+	sessionStateKey = "oidc_state"
+	sessionNonceKey = "oidc_nonce"
+	// the redirect uri used in the authorization request, it must be the same in the token request.
+	sessionRedirectURIKey = "oidc_redirect_uri"
+
+	// the path which the identity provider redirect the user back to with the authorization code.
+	callbackPath = "/login/callback"
+)
+
+// LoginUser exchange the authorization code for the id token when the user is redirected back
+// from the identity provider, and map the claims to the user info.
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (*metadata.LoginUserInfo, bool) {
+	code := c.Query("code")
+	state := c.Query("state")
+	if "" == code || "" == state {
+		return nil, false
+	}
+
+	session := sessions.Default(c)
+	expectState, _ := session.Get(sessionStateKey).(string)
+	nonce, _ := session.Get(sessionNonceKey).(string)
+	redirectURI, _ := session.Get(sessionRedirectURIKey).(string)
+	if "" == expectState || state != expectState {
+		blog.Errorf("oidc login failed, the state %s does not match the one in session", state)
+		return nil, false
+	}
+	session.Delete(sessionStateKey)
+	session.Delete(sessionNonceKey)
+	session.Delete(sessionRedirectURIKey)
+
+	cfg, err := parseConfig(config)
+	if nil != err {
+		blog.Errorf("oidc login failed, err: %v", err)
+		return nil, false
+	}
+
+	provider, err := m.getProvider(cfg.Issuer)
+	if nil != err {
+		blog.Errorf("oidc login failed, discover the provider %s failed, err: %v", cfg.Issuer, err)
+		return nil, false
+	}
+
+	claims, err := m.exchange(cfg, provider, code, redirectURI, nonce)
+	if nil != err {
+		blog.Errorf("oidc login failed, err: %v", err)
+		return nil, false
+	}
+
+	userInfo := cfg.mapClaims(claims)
+	if "" == userInfo.UserName {
+		blog.Errorf("oidc login failed, the claim %s of the user name is empty, claims: %v", cfg.UsernameClaim, claims)
+		return nil, false
+	}
+
+	userInfo.BkToken, err = manager.NewLoginToken(c, config)
+	if nil != err {
+		blog.Errorf("oidc login failed, generate login token failed, err: %v", err)
+		return nil, false
+	}
+	return userInfo, true
+}
+
+// GetUserList the identity provider has no user list api, so only the current user is returned.
+func (m *user) GetUserList(c *gin.Context, config map[string]string) ([]*metadata.LoginSystemUserInfo, error) {
+	session := sessions.Default(c)
+	userName, _ := session.Get(common.WEBSessionUinKey).(string)
+	chName, _ := session.Get(common.WEBSessionChineseNameKey).(string)
+	if "" == userName {
+		return make([]*metadata.LoginSystemUserInfo, 0), nil
+	}
+	if "" == chName {
+		chName = userName
+	}
+	return []*metadata.LoginSystemUserInfo{{CnName: chName, EnName: userName}}, nil
+}
+
+// GetLoginUrl return the authorization url of the identity provider, the state and nonce
+// are kept in session to be checked when the user is redirected back.
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	cfg, err := parseConfig(config)
+	if nil != err {
+		blog.Errorf("get oidc login url failed, err: %v", err)
+		return ""
+	}
+
+	provider, err := m.getProvider(cfg.Issuer)
+	if nil != err {
+		blog.Errorf("get oidc login url failed, discover the provider %s failed, err: %v", cfg.Issuer, err)
+		return ""
+	}
+
+	state, err := randomString()
+	if nil != err {
+		blog.Errorf("get oidc login url failed, generate state failed, err: %v", err)
+		return ""
+	}
+	nonce, err := randomString()
+	if nil != err {
+		blog.Errorf("get oidc login url failed, generate nonce failed, err: %v", err)
+		return ""
+	}
+
+	redirectURI := cfg.redirectURL(input)
+	session := sessions.Default(c)
+	session.Set(sessionStateKey, state)
+	session.Set(sessionNonceKey, nonce)
+	session.Set(sessionRedirectURIKey, redirectURI)
+	if c.Request.URL.Path != callbackPath {
+		session.Set(webCommon.LoginRedirectURL, c.Request.URL.String())
+	}
+	session.Save()
+
+	values := url.Values{}
+	values.Set("response_type", "code")
+	values.Set("client_id", cfg.ClientID)
+	values.Set("redirect_uri", redirectURI)
+	values.Set("scope", strings.Join(cfg.Scopes, " "))
+	values.Set("state", state)
+	values.Set("nonce", nonce)
+
+	sep := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return provider.AuthorizationEndpoint + sep + values.Encode()
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("read random failed, err: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
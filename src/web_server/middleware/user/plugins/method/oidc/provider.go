@@ -0,0 +1,230 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// providerConfig the metadata of the identity provider, see
+// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type providerConfig struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	// the signing keys fetched from the jwks uri by key id.
+	keysLock      sync.Mutex
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type user struct {
+	lock      sync.RWMutex
+	providers map[string]*providerConfig
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// getProvider discover the provider metadata of the issuer, the result is cached.
+func (m *user) getProvider(issuer string) (*providerConfig, error) {
+	m.lock.RLock()
+	provider, exists := m.providers[issuer]
+	m.lock.RUnlock()
+	if exists {
+		return provider, nil
+	}
+
+	provider = new(providerConfig)
+	if err := getJSON(issuer+"/.well-known/openid-configuration", "", provider); nil != err {
+		return nil, err
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("the issuer %s in provider metadata does not match the config %s", provider.Issuer, issuer)
+	}
+	if "" == provider.AuthorizationEndpoint || "" == provider.TokenEndpoint {
+		return nil, errors.New("the provider metadata has no authorization or token endpoint")
+	}
+
+	m.lock.Lock()
+	m.providers[issuer] = provider
+	m.lock.Unlock()
+	return provider, nil
+}
+
+// exchange the authorization code for the tokens, and return the claims of the id token merged with the
+// claims from the user info endpoint.
+// the signature of the id token is verified by the keys of the provider when it exposes a jwks uri, otherwise
+// the id token is trusted as it's received directly from the token endpoint over tls. the issuer, audience,
+// expiry and nonce are always validated, see https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+func (m *user) exchange(cfg *oidcConfig, provider *providerConfig, code, redirectURI, nonce string) (map[string]interface{}, error) {
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("code", code)
+	values.Set("redirect_uri", redirectURI)
+
+	req, err := http.NewRequest(http.MethodPost, provider.TokenEndpoint, strings.NewReader(values.Encode()))
+	if nil != err {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+
+	token := new(tokenResponse)
+	if err := doJSON(req, token); nil != err {
+		return nil, fmt.Errorf("exchange the authorization code failed, err: %v", err)
+	}
+	if "" != token.Error {
+		return nil, fmt.Errorf("exchange the authorization code failed, err: %s, %s", token.Error, token.ErrorDescription)
+	}
+	if "" == token.IDToken {
+		return nil, errors.New("exchange the authorization code failed, no id token is returned")
+	}
+
+	if "" != provider.JWKSURI {
+		if err := verifyIDTokenSignature(token.IDToken, provider.getKey); nil != err {
+			return nil, fmt.Errorf("verify the id token signature failed, err: %v", err)
+		}
+	}
+	claims, err := parseIDToken(token.IDToken)
+	if nil != err {
+		return nil, err
+	}
+	if err := validateClaims(claims, cfg.Issuer, cfg.ClientID, nonce); nil != err {
+		return nil, err
+	}
+
+	if "" != provider.UserInfoEndpoint && "" != token.AccessToken {
+		userInfo := make(map[string]interface{})
+		if err := getJSON(provider.UserInfoEndpoint, token.AccessToken, &userInfo); nil != err {
+			return nil, fmt.Errorf("get user info failed, err: %v", err)
+		}
+		if userInfo["sub"] != claims["sub"] {
+			return nil, errors.New("the subject of the user info does not match the id token")
+		}
+		for key, val := range userInfo {
+			if _, exists := claims[key]; !exists {
+				claims[key] = val
+			}
+		}
+	}
+
+	return claims, nil
+}
+
+func parseIDToken(idToken string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("the id token is not a valid jwt")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if nil != err {
+		return nil, fmt.Errorf("decode the id token payload failed, err: %v", err)
+	}
+
+	claims := make(map[string]interface{})
+	decoder := json.NewDecoder(strings.NewReader(string(payload)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); nil != err {
+		return nil, fmt.Errorf("unmarshal the id token claims failed, err: %v", err)
+	}
+	return claims, nil
+}
+
+func validateClaims(claims map[string]interface{}, issuer, clientID, nonce string) error {
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != issuer {
+		return fmt.Errorf("the id token issuer %v does not match %s", claims["iss"], issuer)
+	}
+
+	audMatched := false
+	switch aud := claims["aud"].(type) {
+	case string:
+		audMatched = aud == clientID
+	case []interface{}:
+		for _, item := range aud {
+			if item == clientID {
+				audMatched = true
+			}
+		}
+	}
+	if !audMatched {
+		return fmt.Errorf("the id token audience %v does not contain the client %s", claims["aud"], clientID)
+	}
+
+	exp, ok := claims["exp"].(json.Number)
+	if !ok {
+		return errors.New("the id token has no expiry")
+	}
+	expAt, err := exp.Int64()
+	if nil != err || time.Now().Unix() > expAt {
+		return errors.New("the id token is expired")
+	}
+
+	if claims["nonce"] != nonce {
+		return errors.New("the id token nonce does not match the one in session")
+	}
+	return nil
+}
+
+func getJSON(rawURL, accessToken string, result interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if nil != err {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if "" != accessToken {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doJSON(req, result)
+}
+
+func doJSON(req *http.Request, result interface{}) error {
+	resp, err := httpClient.Do(req)
+	if nil != err {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return err
+	}
+	// the token endpoint returns the error in the json body with status 400.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("request %s got status %d, body: %s", req.URL.String(), resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, result); nil != err {
+		return fmt.Errorf("unmarshal the response of %s failed, err: %v, body: %s", req.URL.String(), err, string(body))
+	}
+	return nil
+}
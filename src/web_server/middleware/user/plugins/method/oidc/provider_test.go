@@ -0,0 +1,164 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func makeIDToken(payload string) string {
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func TestValidateIDToken(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	cases := []struct {
+		payload string
+		valid   bool
+	}{
+		{fmt.Sprintf(`{"iss":"https://sso.example.com","aud":"cmdb","exp":%d,"nonce":"n1"}`, exp), true},
+		{fmt.Sprintf(`{"iss":"https://sso.example.com/","aud":["other","cmdb"],"exp":%d,"nonce":"n1"}`, exp), true},
+		{fmt.Sprintf(`{"iss":"https://evil.example.com","aud":"cmdb","exp":%d,"nonce":"n1"}`, exp), false},
+		{fmt.Sprintf(`{"iss":"https://sso.example.com","aud":"other","exp":%d,"nonce":"n1"}`, exp), false},
+		{fmt.Sprintf(`{"iss":"https://sso.example.com","aud":"cmdb","exp":%d,"nonce":"n2"}`, exp), false},
+		{`{"iss":"https://sso.example.com","aud":"cmdb","exp":1,"nonce":"n1"}`, false},
+	}
+
+	for idx, c := range cases {
+		claims, err := parseIDToken(makeIDToken(c.payload))
+		if err != nil {
+			t.Fatalf("case %d: parse id token failed, err: %v", idx, err)
+		}
+		err = validateClaims(claims, "https://sso.example.com", "cmdb", "n1")
+		if c.valid != (err == nil) {
+			t.Errorf("case %d: expect valid %v, got err: %v", idx, c.valid, err)
+		}
+	}
+}
+
+func TestMapClaims(t *testing.T) {
+	cfg, err := parseConfig(map[string]string{
+		"oidc.issuer":       "https://sso.example.com",
+		"oidc.client_id":    "cmdb",
+		"oidc.role_claim":   "groups",
+		"oidc.role_mapping": "cmdb-admin:1, cmdb-dev:2",
+		"oidc.default_role": "0",
+	})
+	if err != nil {
+		t.Fatalf("parse config failed, err: %v", err)
+	}
+
+	userInfo := cfg.mapClaims(map[string]interface{}{
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"groups":             []interface{}{"users", "cmdb-dev"},
+	})
+	if userInfo.UserName != "alice" || userInfo.ChName != "alice" || userInfo.Email != "alice@example.com" {
+		t.Errorf("unexpected user info: %+v", userInfo)
+	}
+	if userInfo.Role != "2" {
+		t.Errorf("expect role 2, got %s", userInfo.Role)
+	}
+
+	userInfo = cfg.mapClaims(map[string]interface{}{"preferred_username": "bob", "groups": "users"})
+	if userInfo.Role != "0" {
+		t.Errorf("expect default role 0, got %s", userInfo.Role)
+	}
+}
+
+func signIDToken(t *testing.T, alg, kid string, key crypto.Signer, payload string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"alg":"%s","kid":"%s"}`, alg, kid)))
+	signed := header + "." + base64.RawURLEncoding.EncodeToString([]byte(payload))
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("sign id token failed, err: %v", err)
+		}
+		signature = sig
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("sign id token failed, err: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyIDTokenSignature(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key failed, err: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ecdsa key failed, err: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key failed, err: %v", err)
+	}
+
+	encode := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	set := jsonWebKeySet{Keys: []jsonWebKey{
+		{Kty: "RSA", Kid: "rsa1", Use: "sig", N: encode(rsaKey.N), E: encode(big.NewInt(int64(rsaKey.E)))},
+		{Kty: "EC", Kid: "ec1", Crv: "P-256", X: encode(ecKey.X), Y: encode(ecKey.Y)},
+	}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer server.Close()
+	provider := &providerConfig{JWKSURI: server.URL}
+
+	payload := `{"iss":"https://sso.example.com","aud":"cmdb"}`
+	valid := signIDToken(t, "RS256", "rsa1", rsaKey, payload)
+	tampered := valid[:len(valid)-4] + "AAAA"
+	cases := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"rsa signed", valid, true},
+		{"ecdsa signed", signIDToken(t, "ES256", "ec1", ecKey, payload), true},
+		{"tampered signature", tampered, false},
+		{"signed by other key", signIDToken(t, "RS256", "rsa1", otherKey, payload), false},
+		{"unknown key id", signIDToken(t, "RS256", "rsa2", rsaKey, payload), false},
+		{"key type mismatch", signIDToken(t, "RS256", "ec1", rsaKey, payload), false},
+		{"forged without key id", makeIDToken(payload), false},
+		{"none algorithm", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".", false},
+	}
+
+	for _, c := range cases {
+		err := verifyIDTokenSignature(c.token, provider.getKey)
+		if c.valid != (err == nil) {
+			t.Errorf("%s: expect valid %v, got err: %v", c.name, c.valid, err)
+		}
+	}
+}
@@ -1,31 +1,17 @@
 /*
  * Tencent is pleased to support the open source community by making 蓝鲸 available.
  * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
- * Licensed under the MIT License (the "License"); you may not use this file except 
+ * Licensed under the MIT License (the "License"); you may not use this file except
  * in compliance with the License. You may obtain a copy of the License at
  * http://opensource.org/licenses/MIT
  * Unless required by applicable law or agreed to in writing, software distributed under
  * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
- * either express or implied. See the License for the specific language governing permissions and 
+ * either express or implied. See the License for the specific language governing permissions and
  * limitations under the License.
  */
- 
-package middleware
+
+package manager
 
 import (
-	"github.com/emicklei/go-restful"
+	_ "configcenter/src/web_server/middleware/user/plugins/method/ldap"
 )
-
-func BasicAuthenticate(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
-	u, p, ok := req.Request.BasicAuth()
-	if !ok || u != "admin" || p != "admin" {
-		resp.AddHeader("WWW-Authenticate", "Basic realm=Protected Area")
-		resp.WriteErrorString(401, "401: Not Authorized")
-		chain.ProcessFilter(req, resp)
-	}
-	chain.ProcessFilter(req, resp)
-}
-
-func ValidAuth() {
-
-}
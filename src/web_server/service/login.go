@@ -13,7 +13,14 @@
 package service
 
 import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
+	webCommon "configcenter/src/web_server/common"
 	"configcenter/src/web_server/middleware/user"
 
 	"github.com/gin-gonic/gin"
@@ -33,3 +40,65 @@ func (s *Service) LogOutUser(c *gin.Context) {
 	c.JSON(200, ret)
 	return
 }
+
+// loginPage the login page of the login plugins which authenticate the user name and password, such as ldap.
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>CMDB</title></head>
+<body>
+<form method="post" action="/login">
+	{{if .}}<p style="color:red">{{.}}</p>{{end}}
+	<p><input type="text" name="username" placeholder="username" autofocus></p>
+	<p><input type="password" name="password" placeholder="password"></p>
+	<p><input type="submit" value="login"></p>
+</form>
+</body>
+</html>`))
+
+func renderLoginPage(c *gin.Context, status int, message string) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := loginPage.Execute(c.Writer, message); nil != err {
+		blog.Errorf("render login page failed, err: %v", err)
+	}
+}
+
+// LoginPage show the login page
+func (s *Service) LoginPage(c *gin.Context) {
+	renderLoginPage(c, http.StatusOK, "")
+}
+
+// Login log in the user with the user name and password posted by the login page, or with the
+// authorization code when the user is redirected back from the identity provider, then redirect
+// the user back to the page before logged in.
+func (s *Service) Login(c *gin.Context) {
+	userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.VersionPlg)
+	if !userManger.LoginUser(c) {
+		if http.MethodPost == c.Request.Method {
+			renderLoginPage(c, http.StatusUnauthorized, "invalid username or password")
+			return
+		}
+		c.String(http.StatusUnauthorized, "login failed")
+		return
+	}
+
+	session := sessions.Default(c)
+	redirectURL, _ := session.Get(webCommon.LoginRedirectURL).(string)
+	session.Delete(webCommon.LoginRedirectURL)
+	session.Save()
+
+	c.Redirect(http.StatusFound, safeRedirectURL(redirectURL))
+}
+
+// safeRedirectURL only allow redirecting to the path of this site, the url with a scheme or host, or with a
+// backslash which the browsers treat as a slash, is replaced by the root path.
+func safeRedirectURL(redirectURL string) string {
+	if strings.Contains(redirectURL, "\\") || !strings.HasPrefix(redirectURL, "/") || strings.HasPrefix(redirectURL, "//") {
+		return "/"
+	}
+	u, err := url.Parse(redirectURL)
+	if nil != err || "" != u.Scheme || "" != u.Host {
+		return "/"
+	}
+	return redirectURL
+}
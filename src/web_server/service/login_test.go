@@ -0,0 +1,45 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+)
+
+func TestSafeRedirectURL(t *testing.T) {
+	cases := []struct {
+		redirectURL string
+		want        string
+	}{
+		{"", "/"},
+		{"/", "/"},
+		{"/#/business/2", "/#/business/2"},
+		{"/host?bk_biz_id=2", "/host?bk_biz_id=2"},
+		{"//evil.com", "/"},
+		{"///evil.com", "/"},
+		{"/\\evil.com", "/"},
+		{"/\\/evil.com", "/"},
+		{"\\\\evil.com", "/"},
+		{"http://evil.com/", "/"},
+		{"https:evil.com", "/"},
+		{"evil.com", "/"},
+		{"javascript:alert(1)", "/"},
+		{"/\t/evil.com", "/"},
+	}
+
+	for _, c := range cases {
+		if got := safeRedirectURL(c.redirectURL); got != c.want {
+			t.Errorf("safeRedirectURL(%q) = %q, want %q", c.redirectURL, got, c.want)
+		}
+	}
+}